@@ -0,0 +1,190 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/monitor"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	alertRulesOutDir string
+	extraAlertsDir   string
+)
+
+// lookPromtool returns the path of promtool which checks alerting rules.
+var lookPromtool = func() (string, error) {
+	return exec.LookPath("promtool")
+}
+
+var clusterAlertRulesCmd = &cli.Command{
+	Name:   "alert-rules",
+	Usage:  "Render Prometheus alerting rules of a 3fs cluster into a directory for an external Prometheus",
+	Action: renderAlertRules,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.StringFlag{
+			Name:        "out",
+			Usage:       "Path to the output directory",
+			Destination: &alertRulesOutDir,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "extra-alerts-dir",
+			Usage:       "Directory of extra rule files which are checked and written with the bundled rules",
+			Destination: &extraAlertsDir,
+		},
+	},
+}
+
+// alertRuleFile is the part of a Prometheus rule file checked without promtool.
+type alertRuleFile struct {
+	Groups []struct {
+		Name  string `yaml:"name"`
+		Rules []any  `yaml:"rules"`
+	} `yaml:"groups"`
+}
+
+func renderAlertRules(ctx *cli.Context) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+	data, err := monitor.RenderAlertRules(runner.Runtime)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	files := []*task.RenderedFile{{Path: monitor.AlertRulesFileName(cfg.Name), Data: data}}
+	if extraAlertsDir != "" {
+		extraFiles, err := readExtraAlertRules(extraAlertsDir, files[0].Path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		files = append(files, extraFiles...)
+	}
+	if err = checkAlertRules(ctx.Context, runner.Runtime, files); err != nil {
+		return errors.Trace(err)
+	}
+	for _, file := range files {
+		if err = writeRenderedFile(alertRulesOutDir, file); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	logrus.Infof("Rendered %d alerting rule files into %s, add them to rule_files of Prometheus and reload it",
+		len(files), alertRulesOutDir)
+
+	return nil
+}
+
+// readExtraAlertRules reads rule files in the dir, they mustn't replace the bundled file.
+func readExtraAlertRules(dir, bundled string) ([]*task.RenderedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read extra alerts dir %s", dir)
+	}
+	var files []*task.RenderedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if ext := filepath.Ext(name); ext != ".yml" && ext != ".yaml" {
+			logrus.Warnf("Skipped %s in %s, rule files must be *.yml or *.yaml", name, dir)
+			continue
+		}
+		if name == bundled {
+			return nil, errors.Errorf("%s in %s conflicts with the bundled rule file", name, dir)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		files = append(files, &task.RenderedFile{Path: name, Data: data})
+	}
+	return files, nil
+}
+
+// checkAlertRules checks rule files by promtool, or only checks groups of them if
+// promtool isn't found on the deploy host.
+func checkAlertRules(ctx context.Context, r *task.Runtime, files []*task.RenderedFile) error {
+	for _, file := range files {
+		var rules alertRuleFile
+		if err := yaml.Unmarshal(file.Data, &rules); err != nil {
+			return errors.Annotatef(err, "parse rule file %s", file.Path)
+		}
+		if len(rules.Groups) == 0 {
+			return errors.Errorf("rule file %s has no groups", file.Path)
+		}
+		for _, group := range rules.Groups {
+			if group.Name == "" {
+				return errors.Errorf("rule file %s has a group without name", file.Path)
+			}
+		}
+	}
+
+	promtool, err := lookPromtool()
+	if err != nil {
+		logrus.Warn("promtool isn't found in PATH, only groups of rule files are checked")
+		return nil
+	}
+	tmpDir, err := os.MkdirTemp(r.LocalTempDir(), "m3fs-alerts")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			logrus.Warnf("Failed to remove %s: %v", tmpDir, err)
+		}
+	}()
+	args := []string{"check", "rules"}
+	for _, file := range files {
+		if err = writeRenderedFile(tmpDir, file); err != nil {
+			return errors.Trace(err)
+		}
+		args = append(args, filepath.Join(tmpDir, file.Path))
+	}
+	if _, err = r.LocalEm.Runner.NonSudoExec(ctx, promtool, args...); err != nil {
+		return errors.Annotate(err, "promtool check rules")
+	}
+	return nil
+}
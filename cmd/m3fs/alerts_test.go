@@ -0,0 +1,146 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+func TestAlertRulesSuite(t *testing.T) {
+	suiteRun(t, &alertRulesSuite{})
+}
+
+type alertRulesSuite struct {
+	Suite
+
+	lookPromtool func() (string, error)
+}
+
+func (s *alertRulesSuite) SetupTest() {
+	s.Suite.SetupTest()
+	dir := s.T().TempDir()
+	configFilePath = filepath.Join(dir, "cluster.yml")
+	workDir = filepath.Join(dir, "work")
+	alertRulesOutDir = filepath.Join(dir, "rules")
+	s.NoError(os.WriteFile(configFilePath, []byte(`
+name: "open3fs"
+networkType: "RXE"
+nodes:
+  - name: node1
+    host: "192.168.1.1"
+    username: root
+services:
+  fdb:
+    nodes: [node1]
+  clickhouse:
+    nodes: [node1]
+  monitor:
+    nodes: [node1]
+    alerts:
+      unreachableNodes: 2
+  mgmtd:
+    nodes: [node1]
+  meta:
+    nodes: [node1]
+  storage:
+    nodes: [node1]
+  client:
+    nodes: [node1]
+`), 0644))
+	s.lookPromtool = lookPromtool
+	lookPromtool = func() (string, error) {
+		return "", errors.New("not found")
+	}
+}
+
+func (s *alertRulesSuite) TearDownTest() {
+	configFilePath = ""
+	workDir = ""
+	alertRulesOutDir = ""
+	extraAlertsDir = ""
+	lookPromtool = s.lookPromtool
+}
+
+func (s *alertRulesSuite) render() error {
+	ctx := cli.NewContext(cli.NewApp(), flag.NewFlagSet("test", flag.ContinueOnError), nil)
+	return renderAlertRules(ctx)
+}
+
+func (s *alertRulesSuite) writeExtra(name, data string) {
+	if extraAlertsDir == "" {
+		extraAlertsDir = s.T().TempDir()
+	}
+	s.NoError(os.WriteFile(filepath.Join(extraAlertsDir, name), []byte(data), 0644))
+}
+
+func (s *alertRulesSuite) TestRender() {
+	s.NoError(s.render())
+
+	data, err := os.ReadFile(filepath.Join(alertRulesOutDir, "m3fs-open3fs.rules.yml"))
+	s.NoError(err)
+	s.Contains(string(data), `expr: m3fs_drift_unreachable_nodes{cluster="open3fs"} >= 2`)
+}
+
+func (s *alertRulesSuite) TestExtraAlertsDir() {
+	s.writeExtra("capacity.yml", "groups:\n  - name: capacity\n    rules: []\n")
+	s.writeExtra("README.md", "rules of the site")
+
+	s.NoError(s.render())
+
+	entries, err := os.ReadDir(alertRulesOutDir)
+	s.NoError(err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	s.Equal([]string{"capacity.yml", "m3fs-open3fs.rules.yml"}, names)
+}
+
+func (s *alertRulesSuite) TestExtraAlertsConflict() {
+	s.writeExtra("m3fs-open3fs.rules.yml", "groups:\n  - name: m3fs\n    rules: []\n")
+
+	s.ErrorContains(s.render(), "m3fs-open3fs.rules.yml in "+extraAlertsDir+" conflicts with the bundled rule file")
+	s.NoDirExists(alertRulesOutDir)
+}
+
+func (s *alertRulesSuite) TestInvalidExtraAlerts() {
+	s.writeExtra("capacity.yaml", "rules: []\n")
+
+	s.ErrorContains(s.render(), "rule file capacity.yaml has no groups")
+	s.NoDirExists(alertRulesOutDir)
+}
+
+func (s *alertRulesSuite) TestPromtool() {
+	promtool := filepath.Join(s.T().TempDir(), "promtool")
+	lookPromtool = func() (string, error) {
+		return promtool, nil
+	}
+	s.NoError(os.WriteFile(promtool, []byte("#!/bin/sh\n[ \"$1 $2\" = \"check rules\" ] && [ -f \"$3\" ]\n"), 0755))
+	s.NoError(s.render())
+
+	s.NoError(os.WriteFile(promtool, []byte("#!/bin/sh\necho 'bad expr' >&2\nexit 1\n"), 0755))
+	s.NoError(os.RemoveAll(alertRulesOutDir))
+	err := s.render()
+	s.ErrorContains(err, "promtool check rules")
+	s.ErrorContains(err, "bad expr")
+	s.NoDirExists(alertRulesOutDir)
+}
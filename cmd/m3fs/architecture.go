@@ -126,6 +126,9 @@ func (g *ArchDiagram) buildServiceMap() map[config.ServiceType][]string {
 	serviceMap := make(map[config.ServiceType][]string)
 
 	for _, serviceType := range config.AllServiceTypes {
+		if !g.cfg.Services.Enabled(serviceType) {
+			continue
+		}
 		nodes, nodeGroups := g.getServiceNodeConfig(serviceType)
 		serviceMap[serviceType] = nodes
 		for _, nodeGroup := range nodeGroups {
@@ -260,7 +263,7 @@ func (g *ArchDiagram) Render() string {
 		networkType := string(g.cfg.NetworkType)
 		localRunnerCfg := &external.LocalRunnerCfg{
 			Logger:         log.Logger,
-			MaxExitTimeout: g.cfg.CmdMaxExitTimeout,
+			MaxExitTimeout: g.cfg.CmdMaxExitTimeout.DurationPtr(),
 		}
 		localRunner := external.NewLocalRunner(localRunnerCfg)
 		speed := network.GetSpeed(context.TODO(), localRunner, g.cfg.NetworkType)
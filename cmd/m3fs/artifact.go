@@ -15,15 +15,25 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 
 	"github.com/open3fs/m3fs/pkg/artifact"
+	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/task"
 )
 
+var (
+	inspectOutput string
+	restartExport bool
+)
+
 var artifactCmd = &cli.Command{
 	Name:    "artifact",
 	Aliases: []string{"a"},
@@ -43,9 +53,10 @@ var artifactCmd = &cli.Command{
 					Required:    true,
 				},
 				&cli.StringFlag{
-					Name:        "tmp-dir",
-					Aliases:     []string{"t"},
-					Usage:       "Temporary dir used to save downloaded packages (default: \"/tmp/3fs\")",
+					Name:    "tmp-dir",
+					Aliases: []string{"t"},
+					Usage: "Temporary dir used to save downloaded packages " +
+						"(default: \"3fs\" of the staging dir, or \"/tmp/3fs\" without the staging dir)",
 					Destination: &tmpDir,
 					Required:    false,
 				},
@@ -63,6 +74,21 @@ var artifactCmd = &cli.Command{
 					Destination: &outputPath,
 					Required:    true,
 				},
+				&cli.BoolFlag{
+					Name: "restart-export",
+					Usage: "Ignore the checkpoint of an interrupted export in the tmp dir, images are " +
+						"verified again instead of being resumed",
+					Destination: &restartExport,
+				},
+			},
+		},
+		{
+			Name:      "inspect",
+			Usage:     "Print images, architectures and checksums of a 3fs offline artifact",
+			ArgsUsage: "<artifact>",
+			Action:    inspectArtifact,
+			Flags: []cli.Flag{
+				newOutputFlag(&inspectOutput),
 			},
 		},
 	},
@@ -73,7 +99,9 @@ func exportArtifact(ctx *cli.Context) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	if tmpDir == "" {
+	if tmpDir == "" && cfg.StagingDir != "" {
+		tmpDir = filepath.Join(cfg.StagingDir, "3fs")
+	} else if tmpDir == "" {
 		tmpDir = "/tmp/3fs"
 	}
 
@@ -87,6 +115,10 @@ func exportArtifact(ctx *cli.Context) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	runner.SetQuiet(quiet)
+	runner.SetTimingsOut(timingsOut)
+	runner.SetLogCommandStats(commandStats)
+	runner.SetEventsOut(eventsOut)
 	runner.Init()
 	if err = runner.Store(task.RuntimeArtifactTmpDirKey, tmpDir); err != nil {
 		return errors.Trace(err)
@@ -97,9 +129,77 @@ func exportArtifact(ctx *cli.Context) error {
 	if err = runner.Store(task.RuntimeArtifactGzipKey, artifactGzip); err != nil {
 		return errors.Trace(err)
 	}
+	if err = runner.Store(task.RuntimeArtifactRestartExportKey, restartExport); err != nil {
+		return errors.Trace(err)
+	}
 	if err = runner.Run(ctx.Context); err != nil {
 		return errors.Annotate(err, "import artifact")
 	}
 
 	return nil
 }
+
+func inspectArtifact(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("artifact path is required")
+	}
+	format, err := checkOutputFormat(inspectOutput)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	inspection, err := artifact.Inspect(ctx.Args().First())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = printInspection(os.Stdout, inspection, format); err != nil {
+		return errors.Trace(err)
+	}
+	if failed := inspection.Failed(); len(failed) > 0 {
+		return errors.Errorf("%d of %d images of the artifact are missing or corrupted",
+			len(failed), len(inspection.Images))
+	}
+	return nil
+}
+
+func printInspection(w io.Writer, inspection *artifact.Inspection, format string) error {
+	return printOutput(w, format, inspection, func(w io.Writer) error {
+		return printInspectionTable(w, inspection)
+	})
+}
+
+func printInspectionTable(w io.Writer, inspection *artifact.Inspection) error {
+	valueOrUnknown := func(value string) string {
+		if value == "" {
+			return "unknown"
+		}
+		return value
+	}
+	createdAt := ""
+	if inspection.CreatedAt != nil {
+		createdAt = inspection.CreatedAt.Format("2006-01-02 15:04:05 MST")
+	}
+	tw := newTable(w)
+	fmt.Fprintf(tw, "Artifact:\t%s\n", inspection.Path)
+	fmt.Fprintf(tw, "Size:\t%s (%d bytes)\n", common.FormatBytes(inspection.Size), inspection.Size)
+	fmt.Fprintf(tw, "SHA256:\t%s\n", inspection.Sha256sum)
+	fmt.Fprintf(tw, "Compression:\t%s\n", inspection.Compression)
+	fmt.Fprintf(tw, "Architectures:\t%s\n", valueOrUnknown(strings.Join(inspection.Architectures, ",")))
+	if inspection.HasManifest {
+		fmt.Fprintf(tw, "Exported by:\tm3fs %s\n", valueOrUnknown(inspection.M3fsVersion))
+		fmt.Fprintf(tw, "Created at:\t%s\n", valueOrUnknown(createdAt))
+	} else {
+		fmt.Fprintln(tw, "Manifest:\tnot found, images are found by contents of files")
+	}
+	if err := tw.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+
+	fmt.Fprintln(w)
+	tw = newTable(w, "IMAGE", "TAGS", "ARCH", "SIZE", "DIGEST", "STATUS")
+	for _, image := range inspection.Images {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", valueOrUnknown(image.Image),
+			valueOrUnknown(strings.Join(image.Tags, ",")), valueOrUnknown(image.Architecture),
+			common.FormatBytes(image.Size), valueOrUnknown(image.ID), image.Status)
+	}
+	return errors.Trace(tw.Flush())
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/artifact"
+)
+
+func TestArtifactSuite(t *testing.T) {
+	suiteRun(t, &artifactSuite{})
+}
+
+type artifactSuite struct {
+	Suite
+}
+
+func (s *artifactSuite) TestPrintInspection() {
+	inspection := &artifact.Inspection{
+		Path:          "3fs.tar.gz",
+		Size:          2048,
+		Sha256sum:     "abc",
+		Compression:   artifact.CompressionGzip,
+		Architectures: []string{"amd64"},
+		Images: []artifact.InspectedImage{
+			{
+				ManifestImage: artifact.ManifestImage{
+					Image:        "open3fs/3fs:20250410",
+					ID:           "sha256:def",
+					Size:         1024,
+					Tags:         []string{"open3fs/3fs:20250410"},
+					Architecture: "amd64",
+				},
+				Status: artifact.ImageStatusOK,
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	s.NoError(printInspection(buf, inspection, "text"))
+	s.Contains(buf.String(), "Size:           2.0 KiB (2048 bytes)\n")
+	s.Contains(buf.String(), "Manifest:       not found")
+	s.Contains(buf.String(), "IMAGE                 TAGS                  ARCH   SIZE     DIGEST      STATUS\n")
+	s.Contains(buf.String(), "open3fs/3fs:20250410  open3fs/3fs:20250410  amd64  1.0 KiB  sha256:def  ok\n")
+
+	buf.Reset()
+	s.NoError(printInspection(buf, inspection, "json"))
+	decoded := new(artifact.Inspection)
+	s.NoError(json.Unmarshal(buf.Bytes(), decoded))
+	s.Equal(inspection, decoded)
+}
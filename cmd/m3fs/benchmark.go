@@ -0,0 +1,180 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/benchmark"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	benchmarkSize         string
+	benchmarkBlockSize    string
+	benchmarkDuration     time.Duration
+	benchmarkParallel     int
+	benchmarkMinIOPS      float64
+	benchmarkMinBandwidth float64
+	benchmarkJSON         bool
+)
+
+var clusterBenchmarkCmd = &cli.Command{
+	Name:   "benchmark",
+	Usage:  "Benchmark data disks of storage nodes of a 3fs cluster by a bounded fio test",
+	Action: runBenchmark,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "size",
+			Usage:       "Size of the test file on each disk",
+			Value:       "256M",
+			Destination: &benchmarkSize,
+		},
+		&cli.StringFlag{
+			Name:        "block-size",
+			Usage:       "Block size of IOs",
+			Value:       "4k",
+			Destination: &benchmarkBlockSize,
+		},
+		&cli.DurationFlag{
+			Name:        "duration",
+			Usage:       "Duration of the test on each disk",
+			Value:       10 * time.Second,
+			Destination: &benchmarkDuration,
+		},
+		&cli.IntFlag{
+			Name:        "parallel",
+			Aliases:     []string{"p"},
+			Usage:       "Number of nodes benchmarked at the same time",
+			Value:       10,
+			Destination: &benchmarkParallel,
+		},
+		&cli.Float64Flag{
+			Name:        "min-iops",
+			Usage:       "Baseline of read and write IOPS of a disk, 0 means not checked",
+			Destination: &benchmarkMinIOPS,
+		},
+		&cli.Float64Flag{
+			Name:        "min-bandwidth",
+			Usage:       "Baseline of read and write bandwidth of a disk in MiB/s, 0 means not checked",
+			Destination: &benchmarkMinBandwidth,
+		},
+		&cli.BoolFlag{
+			Name:        "json",
+			Usage:       "Print results in JSON, alias of --output json",
+			Destination: &benchmarkJSON,
+		},
+		newOutputFlag(&outputFormat),
+	},
+}
+
+func runBenchmark(ctx *cli.Context) error {
+	if benchmarkParallel <= 0 {
+		return errors.New("--parallel must be positive")
+	}
+	if benchmarkDuration < time.Second {
+		return errors.New("--duration must be at least 1s")
+	}
+	if benchmarkJSON {
+		outputFormat = outputFormatJSON
+	}
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+	runner.Runtime.RequireOptionalTools = requireOptionalTools
+
+	b := benchmark.NewBenchmark(runner.Runtime)
+	b.Size = benchmarkSize
+	b.BlockSize = benchmarkBlockSize
+	b.Duration = benchmarkDuration
+	b.Parallel = benchmarkParallel
+	b.Baseline = benchmark.Baseline{MinIOPS: benchmarkMinIOPS, MinBandwidth: benchmarkMinBandwidth}
+	results, err := b.Run(ctx.Context)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if err = printBenchmarkResults(os.Stdout, results, format); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(checkBenchmarkResults(results))
+}
+
+// checkBenchmarkResults returns an error if any disk failed or is below the baseline.
+func checkBenchmarkResults(results []*benchmark.Result) error {
+	failed, below := 0, 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		} else if result.BelowBaseline {
+			below++
+		}
+	}
+	if failed > 0 || below > 0 {
+		return errors.Errorf("%d of %d disks failed and %d disks are below the baseline",
+			failed, len(results), below)
+	}
+	return nil
+}
+
+func printBenchmarkResults(out io.Writer, results []*benchmark.Result, format string) error {
+	return printOutput(out, format, results, func(out io.Writer) error {
+		return printBenchmarkResultsTable(out, results)
+	})
+}
+
+func printBenchmarkResultsTable(out io.Writer, results []*benchmark.Result) error {
+	w := newTable(out, "NODE", "DISK", "READ IOPS", "WRITE IOPS", "READ MiB/s", "WRITE MiB/s",
+		"READ LAT(us)", "WRITE LAT(us)", "STATUS")
+	for _, result := range results {
+		status := "OK"
+		switch {
+		case result.Error != "":
+			status = "FAILED: " + result.Error
+		case result.Skipped != "":
+			status = "SKIPPED: " + result.Skipped
+		case result.BelowBaseline:
+			status = "BELOW BASELINE"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.0f\t%.0f\t%.1f\t%.1f\t%.1f\t%.1f\t%s\n", result.Node, result.Disk,
+			result.ReadIOPS, result.WriteIOPS, result.ReadBandwidth, result.WriteBandwidth,
+			result.ReadLatency, result.WriteLatency, status)
+	}
+	return errors.Trace(w.Flush())
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var clusterCleanCmd = &cli.Command{
+	Name:   "clean",
+	Usage:  "Remove temp dirs left by interrupted or failed runs of a 3fs cluster",
+	Action: cleanCluster,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+	},
+}
+
+func cleanCluster(ctx *cli.Context) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// runs of the cluster can't be interrupted by the clean
+	lock, err := lockCluster(cfg, "cluster clean")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer unlockCluster(lock)
+
+	runIDs, err := task.RunsWithTempDirs(cfg.WorkDir, cfg.Name, "")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(runIDs) == 0 {
+		fmt.Println("No temp dir is left by runs")
+		return nil
+	}
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.SetFreezeOverride(freezeOverride)
+	runner.Init()
+
+	removed, failedRuns := 0, 0
+	for _, id := range runIDs {
+		dirs, err := runner.Runtime.CleanRunTempDirs(ctx.Context, task.RunDir(cfg.WorkDir, cfg.Name, id))
+		removed += len(dirs)
+		for _, dir := range dirs {
+			node := dir.Node
+			if node == "" {
+				node = "localhost"
+			}
+			fmt.Printf("Removed %s of %s left by run %s\n", dir.Path, node, id)
+		}
+		if err != nil {
+			logrus.Warnf("Failed to clean run %s: %v", id, err)
+			failedRuns++
+		}
+	}
+	if failedRuns > 0 {
+		return errors.Errorf("failed to clean temp dirs of %d of %d runs", failedRuns, len(runIDs))
+	}
+	fmt.Printf("Removed %d temp dirs left by %d runs\n", removed, len(runIDs))
+	return nil
+}
@@ -16,12 +16,15 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
-	"gopkg.in/yaml.v3"
 
 	fsclient "github.com/open3fs/m3fs/pkg/3fs_client"
 	"github.com/open3fs/m3fs/pkg/artifact"
@@ -34,6 +37,10 @@ import (
 	"github.com/open3fs/m3fs/pkg/mgmtd"
 	"github.com/open3fs/m3fs/pkg/monitor"
 	"github.com/open3fs/m3fs/pkg/network"
+	"github.com/open3fs/m3fs/pkg/plugin"
+	"github.com/open3fs/m3fs/pkg/preflight"
+	imgregistry "github.com/open3fs/m3fs/pkg/registry"
+	"github.com/open3fs/m3fs/pkg/smoketest"
 	"github.com/open3fs/m3fs/pkg/storage"
 	"github.com/open3fs/m3fs/pkg/task"
 )
@@ -67,6 +74,38 @@ var clusterCmd = &cli.Command{
 					Usage:       "Image registry (default is empty)",
 					Destination: &registry,
 				},
+				&cli.BoolFlag{
+					Name:        "skip-preflight",
+					Usage:       "Skip preflight checks of nodes, at the operator's own risk",
+					Destination: &skipPreflight,
+				},
+				&cli.BoolFlag{
+					Name:        "only-preflight",
+					Usage:       "Only run preflight checks of nodes, exit with non-zero code if any check fails",
+					Destination: &onlyPreflight,
+				},
+				&cli.BoolFlag{
+					Name: "gate",
+					Usage: "Pause for approval between phases of tasks, the approval file in the run dir is " +
+						"awaited if stdin isn't a terminal (default is phaseGates of the cluster config)",
+					Destination: &phaseGate,
+				},
+				&cli.StringFlag{
+					Name:        "until",
+					Usage:       "Stop cleanly after tasks of the phase: prepare, deploy or verify",
+					Destination: &untilPhase,
+				},
+				&cli.BoolFlag{
+					Name:        "explain",
+					Usage:       "Print whether each task will run and why without running anything",
+					Destination: &explain,
+				},
+				&cli.StringFlag{
+					Name: "runbook",
+					Usage: "Write a Markdown runbook of tasks, steps, nodes and config files into the file " +
+						"without running anything",
+					Destination: &runbookPath,
+				},
 			},
 		},
 		{
@@ -115,8 +154,87 @@ var clusterCmd = &cli.Command{
 					Destination: &artifactPath,
 					Required:    false,
 				},
+				&cli.BoolFlag{
+					Name:        "probe-bandwidth",
+					Usage:       "Probe throughput of nodes to copy the artifact to the slowest nodes first",
+					Destination: &probeBandwidth,
+				},
+				&cli.BoolFlag{
+					Name: "parallel-download",
+					Usage: "Copy the artifact to the cache node of artifactCache only, other nodes download it " +
+						"from the cache server on the node in parallel",
+					Destination: &parallelDownload,
+				},
+			},
+		},
+		{
+			Name:   "runs",
+			Usage:  "List past runs of a 3fs cluster",
+			Action: listClusterRuns,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "config",
+					Aliases:     []string{"c"},
+					Usage:       "Path to the cluster configuration file",
+					Destination: &configFilePath,
+					Required:    true,
+				},
+				&cli.StringFlag{
+					Name:        "workdir",
+					Aliases:     []string{"w"},
+					Usage:       "Path to the working directory (default is current directory)",
+					Destination: &workDir,
+				},
+				newOutputFlag(&outputFormat),
+			},
+		},
+		{
+			Name:   "history",
+			Usage:  "List runs in the run history of a 3fs cluster",
+			Action: listClusterHistory,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "config",
+					Aliases:     []string{"c"},
+					Usage:       "Path to the cluster configuration file",
+					Destination: &configFilePath,
+					Required:    true,
+				},
+				&cli.StringFlag{
+					Name:        "workdir",
+					Aliases:     []string{"w"},
+					Usage:       "Path to the working directory (default is current directory)",
+					Destination: &workDir,
+				},
+				newOutputFlag(&outputFormat),
 			},
 		},
+		clusterCordonCmd,
+		clusterUncordonCmd,
+		clusterPauseCmd,
+		clusterResumeCmd,
+		clusterStatusCmd,
+		clusterDoctorCmd,
+		clusterExecCmd,
+		clusterPingCmd,
+		clusterVerifyConfigCmd,
+		clusterMonitorDriftCmd,
+		clusterAlertRulesCmd,
+		clusterFactsCmd,
+		clusterImportCmd,
+		clusterStateCmd,
+		clusterJournalCmd,
+		clusterReplayCmd,
+		clusterCollectLogsCmd,
+		clusterLogsCmd,
+		clusterBenchmarkCmd,
+		clusterSmokeTestCmd,
+		clusterUpgradeCmd,
+		clusterRollbackCmd,
+		clusterSyncCmd,
+		clusterCleanCmd,
+		clusterTasksCmd,
+		clusterGraphCmd,
 		{
 			Name:    "architecture",
 			Aliases: []string{"arch"},
@@ -141,77 +259,340 @@ var clusterCmd = &cli.Command{
 }
 
 func loadClusterConfig() (*config.Config, error) {
-	cfg := config.NewConfigWithDefaults()
-	file, err := os.Open(configFilePath)
+	cfg, err := readClusterConfig(configFilePath, "")
 	if err != nil {
-		return nil, errors.Annotate(err, "open config file")
+		return nil, errors.Trace(err)
 	}
-	if err = yaml.NewDecoder(file).Decode(cfg); err != nil {
-		return nil, errors.Annotate(err, "load cluster config")
+	return setupClusterConfig(cfg)
+}
+
+// setupClusterConfig applies global flags, the inventory and secrets to the config read
+// from the config file, and validates it.
+func setupClusterConfig(cfg *config.Config) (*config.Config, error) {
+	applyTLSFlags(cfg)
+	if inventoryURL != "" {
+		if err := mergeInventory(cfg); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if err := cfg.ResolveSecrets(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, resolved := range cfg.ResolvedSecretRefs() {
+		logrus.Infof("Resolved %s from %s", resolved.Field, resolved.Ref)
+	}
+	if localMode {
+		cfg.Local = true
 	}
-	if err = cfg.SetValidate(workDir, registry); err != nil {
+	if stagingDir != "" {
+		cfg.StagingDir = stagingDir
+	}
+	if err := cfg.SetValidate(workDirOverride(), registry); err != nil {
 		return nil, errors.Annotate(err, "validate cluster config")
 	}
+	if cfg.Local {
+		for _, warning := range cfg.Warnings() {
+			logrus.Warn(warning)
+		}
+	}
+	stateKey, err := cfg.StateEncryption.DecodeKey()
+	if err != nil {
+		return nil, errors.Annotate(err, "decode stateEncryption.key")
+	}
+	if err = task.SetStateKey(stateKey); err != nil {
+		return nil, errors.Trace(err)
+	}
 	logrus.Debugf("Cluster config: %+v", cfg)
 
 	return cfg, nil
 }
 
+// applyTLSFlags overrides the tls config by global flags.
+func applyTLSFlags(cfg *config.Config) {
+	if caFile != "" {
+		cfg.TLS.CAFile = caFile
+	}
+	if certFile != "" {
+		cfg.TLS.CertFile = certFile
+	}
+	if keyFile != "" {
+		cfg.TLS.KeyFile = keyFile
+	}
+	if insecureSkipVerify {
+		cfg.TLS.InsecureSkipVerify = true
+	}
+}
+
+// lockCluster acquires the lock of the cluster, which prevents concurrent mutating commands.
+// Commands are refused in deployment freezes of the config.
+func lockCluster(cfg *config.Config, command string) (*task.Lock, error) {
+	if err := checkDeploymentWindows(cfg, command, time.Now()); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return lockClusterState(cfg, command)
+}
+
+// lockClusterState is lockCluster for commands only changing local state of the cluster,
+// e.g. cordons, which aren't refused in deployment freezes.
+func lockClusterState(cfg *config.Config, command string) (*task.Lock, error) {
+	lock, err := task.AcquireLock(cfg.WorkDir, cfg.Name, command, forceUnlock)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return lock, nil
+}
+
+// checkClusterState warns if the config diverges from the recorded state of the
+// deployed cluster. Nodes aren't probed, `cluster doctor` compares running containers
+// to the recorded state.
+func checkClusterState(cfg *config.Config) error {
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if state == nil {
+		return nil
+	}
+	diffs, err := state.Diverge(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, diff := range diffs {
+		logrus.Warnf("Config diverges from the recorded state of the cluster: %s", diff)
+	}
+	return nil
+}
+
+func unlockCluster(lock *task.Lock) {
+	if err := lock.Release(); err != nil {
+		logrus.Warnf("Failed to release cluster lock: %v", err)
+	}
+}
+
+// newClusterRunner creates and initializes a runner which records the run under the work dir.
+func newClusterRunner(cfg *config.Config, command string, tasks ...task.Interface) (*task.Runner, error) {
+	return newClusterRunnerWithID(cfg, command, runID, tasks...)
+}
+
+// newClusterRunnerWithID is newClusterRunner recording the run with the run id, it's
+// used by commands running several runs.
+func newClusterRunnerWithID(
+	cfg *config.Config, command, id string, tasks ...task.Interface) (*task.Runner, error) {
+
+	runner, err := task.NewRunner(cfg, tasks...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = runner.SetRun(command, id); err != nil {
+		return nil, errors.Trace(err)
+	}
+	runner.SetQuiet(quiet)
+	runner.SetKeepTemp(keepTemp)
+	runner.SetPerNodeLogs(perNodeLogs)
+	runner.SetTimingsOut(timingsOut)
+	runner.SetLogCommandStats(commandStats)
+	runner.SetEventsOut(eventsOut)
+	runner.SetFreezeOverride(freezeOverride)
+	runner.Init()
+	runner.Runtime.RefreshFacts = refreshFacts
+	runner.Runtime.RequireOptionalTools = requireOptionalTools
+	watchPauseSignals(cfg)
+	if showDashboard {
+		attachDashboard(runner.Runtime)
+	}
+	return runner, nil
+}
+
+// newTask returns a function creating a task of type T.
+func newTask[T any, PT interface {
+	*T
+	task.Interface
+}]() func() task.Interface {
+	return func() task.Interface { return PT(new(T)) }
+}
+
+// createClusterFactories are factories generating tasks of creating the cluster in order.
+var createClusterFactories = []task.Factory{
+	task.When(func(*config.Config) bool { return !skipPreflight }, task.Single(newTask[preflight.PreflightTask]())),
+	task.IfEnabled(config.ServiceFdb, task.Single(newTask[fdb.CreateFdbClusterTask]())),
+	task.IfEnabled(config.ServiceClickhouse, task.Single(newTask[clickhouse.CreateClickhouseClusterTask]())),
+	task.IfEnabled(config.ServiceMonitor, task.Single(newTask[monitor.CreateMonitorTask]())),
+	task.IfEnabled(config.ServiceMgmtd, task.Single(newTask[mgmtd.CreateMgmtdServiceTask]())),
+	task.IfEnabled(config.ServiceMeta, task.Single(newTask[meta.CreateMetaServiceTask]())),
+	task.IfEnabled(config.ServiceStorage, storage.NewPrepareStorageDisksTasks),
+	task.IfEnabled(config.ServiceStorage, task.Single(newTask[storage.CreateStorageServiceTask]())),
+	task.IfEnabled(config.ServiceStorage, task.Single(newTask[mgmtd.InitUserAndChainTask]())),
+	task.IfEnabled(config.ServiceClient, task.Single(newTask[fsclient.Create3FSClientServiceTask]())),
+	plugin.NewRunPluginTasks,
+	task.IfEnabled(config.ServiceClient, task.Single(newTask[smoketest.SmokeTestTask]())),
+}
+
+// deleteClusterFactories are factories generating tasks of deleting the cluster in order.
+var deleteClusterFactories = []task.Factory{
+	plugin.NewDeletePluginTasks,
+	task.IfEnabled(config.ServiceClient, task.Single(newTask[fsclient.Delete3FSClientServiceTask]())),
+	task.IfEnabled(config.ServiceStorage, task.Single(newTask[storage.DeleteStorageServiceTask]())),
+	task.IfEnabled(config.ServiceMeta, task.Single(newTask[meta.DeleteMetaServiceTask]())),
+	task.IfEnabled(config.ServiceMgmtd, task.Single(newTask[mgmtd.DeleteMgmtdServiceTask]())),
+	task.IfEnabled(config.ServiceMonitor, task.Single(newTask[monitor.DeleteMonitorTask]())),
+	task.IfEnabled(config.ServiceClickhouse, task.Single(newTask[clickhouse.DeleteClickhouseClusterTask]())),
+	task.IfEnabled(config.ServiceFdb, task.Single(newTask[fdb.DeleteFdbClusterTask]())),
+	task.When(func(*config.Config) bool { return clusterDeleteAll },
+		task.Single(newTask[network.PrepareNetworkTask]())),
+	task.When(func(cfg *config.Config) bool { return clusterDeleteAll && cfg.ManageHosts },
+		task.Single(newTask[network.RemoveHostsTask]())),
+	task.When(func(cfg *config.Config) bool { return clusterDeleteAll && cfg.ManageFirewall },
+		task.Single(newTask[network.RemoveFirewallTask]())),
+	task.When(func(cfg *config.Config) bool { return clusterDeleteAll && !cfg.DNS.IsEmpty() },
+		task.Single(newTask[network.RemoveDNSTask]())),
+	task.When(func(cfg *config.Config) bool { return clusterDeleteAll && len(cfg.TunedNodes()) > 0 },
+		task.Single(newTask[network.RemoveTuningTask]())),
+}
+
+// prepareClusterFactories are factories generating tasks of preparing to deploy the cluster in order.
+var prepareClusterFactories = []task.Factory{
+	task.When(func(*config.Config) bool { return artifactPath != "" },
+		task.Single(newTask[artifact.ImportArtifactTask]())),
+	task.When(imgregistry.NeedConfigTLS, task.Single(newTask[imgregistry.ConfigRegistryTLSTask]())),
+	task.When(func(cfg *config.Config) bool { return cfg.ManageHosts }, task.Single(newTask[network.ManageHostsTask]())),
+	task.When(func(cfg *config.Config) bool { return !cfg.DNS.IsEmpty() }, task.Single(newTask[network.ManageDNSTask]())),
+	task.When(func(cfg *config.Config) bool { return cfg.ManageFirewall },
+		task.Single(newTask[network.ManageFirewallTask]())),
+	task.When(func(cfg *config.Config) bool { return len(cfg.TunedNodes()) > 0 },
+		task.Single(newTask[network.TuneKernelTask]())),
+	task.Single(newTask[network.PrepareNetworkTask]()),
+}
+
+// createClusterTasks returns tasks of creating the cluster generated from the config in order.
+func createClusterTasks(cfg *config.Config) []task.Interface {
+	return task.GenerateTasks(cfg, createClusterFactories...)
+}
+
+// deleteClusterTasks returns tasks of deleting the cluster generated from the config in order.
+func deleteClusterTasks(cfg *config.Config) []task.Interface {
+	return task.GenerateTasks(cfg, deleteClusterFactories...)
+}
+
+// prepareClusterTasks returns tasks of preparing to deploy the cluster generated from the config in order.
+func prepareClusterTasks(cfg *config.Config) []task.Interface {
+	return task.GenerateTasks(cfg, prepareClusterFactories...)
+}
+
 func createCluster(ctx *cli.Context) error {
+	if skipPreflight && onlyPreflight {
+		return errors.New("--skip-preflight and --only-preflight can't be used together")
+	}
 	cfg, err := loadClusterConfig()
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if explain && runbookPath != "" {
+		return errors.New("--explain and --runbook can't be used together")
+	}
+	if explain {
+		return errors.Trace(explainCreateCluster(cfg))
+	}
+	if runbookPath != "" {
+		return errors.Trace(writeCreateRunbook(cfg, runbookPath))
+	}
+	if onlyPreflight {
+		return errors.Trace(runPreflight(ctx, cfg))
+	}
+
+	lock, err := lockCluster(cfg, "cluster create")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer unlockCluster(lock)
 
-	runner, err := task.NewRunner(cfg,
-		new(fdb.CreateFdbClusterTask),
-		new(clickhouse.CreateClickhouseClusterTask),
-		new(monitor.CreateMonitorTask),
-		new(mgmtd.CreateMgmtdServiceTask),
-		new(meta.CreateMetaServiceTask),
-		new(storage.CreateStorageServiceTask),
-		new(mgmtd.InitUserAndChainTask),
-		new(fsclient.Create3FSClientServiceTask),
-	)
+	if skipPreflight {
+		logrus.Warn("Preflight checks are skipped")
+	}
+	if err = checkClusterState(cfg); err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := newClusterRunner(cfg, "cluster create", createClusterTasks(cfg)...)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	runner.Init()
+	cordons, err := applyCordons(runner)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = setupPhases(runner, phaseGate || cfg.PhaseGates); err != nil {
+		return errors.Trace(err)
+	}
 	if err = runner.Run(ctx.Context); err != nil {
 		return errors.Annotate(err, "create cluster")
 	}
+	if runner.Stopped() {
+		// the cluster isn't fully created, so it isn't recorded as deployed
+		log.Logger.Infof("Cluster creation stopped after phase %s, rerun without --until to complete it", untilPhase)
+		return nil
+	}
+	state, err := task.NewClusterState(runner.Runtime)
+	if err != nil {
+		return errors.Annotate(err, "generate cluster state")
+	}
+	state.Cordons = cordons
+	if err = task.SaveClusterState(cfg.WorkDir, state); err != nil {
+		return errors.Trace(err)
+	}
 	log.Logger.Infof("3FS is mounted at %s on node %s",
 		cfg.Services.Client.HostMountpoint, strings.Join(cfg.Services.Client.Nodes, ","))
 
 	return nil
 }
 
+// runPreflight runs only preflight checks, it doesn't modify the cluster so the lock isn't required.
+func runPreflight(ctx *cli.Context, cfg *config.Config) error {
+	runner, err := newClusterRunner(cfg, "cluster create --only-preflight", new(preflight.PreflightTask))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = applyCordons(runner); err != nil {
+		return errors.Trace(err)
+	}
+	if err = runner.Run(ctx.Context); err != nil {
+		return errors.Annotate(err, "preflight")
+	}
+	log.Logger.Infof("Preflight checks passed")
+	return nil
+}
+
 func deleteCluster(ctx *cli.Context) error {
 	cfg, err := loadClusterConfig()
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	runnerTasks := []task.Interface{
-		new(fsclient.Delete3FSClientServiceTask),
-		new(storage.DeleteStorageServiceTask),
-		new(meta.DeleteMetaServiceTask),
-		new(mgmtd.DeleteMgmtdServiceTask),
-		new(monitor.DeleteMonitorTask),
-		new(clickhouse.DeleteClickhouseClusterTask),
-		new(fdb.DeleteFdbClusterTask),
+	lock, err := lockCluster(cfg, "cluster delete")
+	if err != nil {
+		return errors.Trace(err)
 	}
-	if clusterDeleteAll {
-		runnerTasks = append(runnerTasks, new(network.PrepareNetworkTask))
+	defer unlockCluster(lock)
+
+	if err = checkClusterState(cfg); err != nil {
+		return errors.Trace(err)
 	}
-	runner, err := task.NewRunner(cfg, runnerTasks...)
+	runner, err := newClusterRunner(cfg, "cluster delete", deleteClusterTasks(cfg)...)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	runner.Init()
+	prompt := fmt.Sprintf("Delete services and data of cluster %s on %d node(s)?", cfg.Name, len(cfg.Nodes))
+	if clusterDeleteAll {
+		prompt = fmt.Sprintf("Delete services, data, images and scripts of cluster %s on %d node(s)?",
+			cfg.Name, len(cfg.Nodes))
+	}
+	if err = confirmRun(runner, "cluster delete", confirmation{prompt: prompt, typedName: cfg.Name}); err != nil {
+		return errors.Trace(err)
+	}
 	if err = runner.Run(ctx.Context); err != nil {
 		return errors.Annotate(err, "delete cluster")
 	}
+	if err = task.RemoveClusterState(cfg.WorkDir, cfg.Name); err != nil {
+		return errors.Trace(err)
+	}
 
 	return nil
 }
@@ -221,21 +602,32 @@ func prepareCluster(ctx *cli.Context) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	runnerTasks := []task.Interface{}
-	if artifactPath != "" {
-		runnerTasks = append(runnerTasks, new(artifact.ImportArtifactTask))
+	if parallelDownload {
+		if artifactPath == "" {
+			return errors.New("--parallel-download requires --artifact")
+		}
+		cfg.ArtifactCache.Enabled = true
 	}
-	runnerTasks = append(runnerTasks, new(network.PrepareNetworkTask))
+	lock, err := lockCluster(cfg, "cluster prepare")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer unlockCluster(lock)
 
-	runner, err := task.NewRunner(cfg, runnerTasks...)
+	runner, err := newClusterRunner(cfg, "cluster prepare", prepareClusterTasks(cfg)...)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	runner.Init()
+	if _, err = applyCordons(runner); err != nil {
+		return errors.Trace(err)
+	}
 	if artifactPath != "" {
 		if err = runner.Store(task.RuntimeArtifactPathKey, artifactPath); err != nil {
 			return errors.Trace(err)
 		}
+		if err = runner.Store(task.RuntimeArtifactProbeBandwidthKey, probeBandwidth); err != nil {
+			return errors.Trace(err)
+		}
 	}
 	if err = runner.Run(ctx.Context); err != nil {
 		return errors.Annotate(err, "prepare cluster")
@@ -244,6 +636,89 @@ func prepareCluster(ctx *cli.Context) error {
 	return nil
 }
 
+func listClusterRuns(ctx *cli.Context) error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	records, err := task.LoadRunRecords(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(printRunRecords(os.Stdout, records, format))
+}
+
+func printRunRecords(out io.Writer, records []*task.RunRecord, format string) error {
+	return printOutput(out, format, records, func(out io.Writer) error {
+		w := newTable(out, "RUN ID", "COMMAND", "STATUS", "START TIME", "DURATION")
+		for _, record := range records {
+			duration := "-"
+			if record.EndTime != nil {
+				duration = record.Duration().Round(time.Second).String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", record.ID, record.Command, record.Status,
+				record.StartTime.Format(time.DateTime), duration)
+		}
+		return errors.Trace(w.Flush())
+	})
+}
+
+func listClusterHistory(ctx *cli.Context) error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	entries, err := task.LoadRunHistory(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(entries) == 0 && cfg.RunHistory == 0 && format == outputFormatTable {
+		fmt.Println("Run history is disabled, enable it by runHistory of the cluster config")
+		return nil
+	}
+	return errors.Trace(printRunHistory(os.Stdout, entries, format))
+}
+
+func printRunHistory(out io.Writer, entries []*task.RunHistoryEntry, format string) error {
+	return printOutput(out, format, entries, func(out io.Writer) error {
+		return printRunHistoryTable(out, entries)
+	})
+}
+
+func printRunHistoryTable(out io.Writer, entries []*task.RunHistoryEntry) error {
+	w := newTable(out, "RUN ID", "COMMAND", "STATUS", "START TIME", "DURATION", "TASKS", "LAST PHASE", "FAILED NODES")
+	for _, entry := range entries {
+		failed := make([]string, 0, len(entry.FailedNodes))
+		for _, name := range slices.Sorted(maps.Keys(entry.FailedNodes)) {
+			failed = append(failed, fmt.Sprintf("%s(%s)", name, strings.Join(entry.FailedNodes[name], ",")))
+		}
+		failedNodes := "-"
+		if len(failed) > 0 {
+			failedNodes = strings.Join(failed, " ")
+		}
+		lastPhase := string(entry.LastPhase)
+		if lastPhase == "" {
+			lastPhase = "-"
+		}
+		command := entry.Command
+		if entry.FreezeOverride != "" {
+			command += fmt.Sprintf(" (freeze overridden: %s)", entry.FreezeOverride)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", entry.ID, command, entry.Status,
+			entry.StartTime.Format(time.DateTime), entry.Duration().Round(time.Second), entry.Tasks,
+			lastPhase, failedNodes)
+	}
+	return errors.Trace(w.Flush())
+}
+
 func drawClusterArchitecture(ctx *cli.Context) error {
 	cfg, err := loadClusterConfig()
 	if err != nil {
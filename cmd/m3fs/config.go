@@ -15,17 +15,40 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
 
+	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
 )
 
 var (
 	clusterName      string
 	sampleConfigPath string
+	convertFrom      string
+	convertTo        string
+	convertOutput    string
+	diffOutput       string
+	dumpFormat       string
+	maxErrors        int
+)
+
+// config file formats, toml is only encoded, because m3fs has no toml decoder of
+// cluster configs.
+const (
+	configFormatYAML = "yaml"
+	configFormatJSON = "json"
+	configFormatTOML = "toml"
 )
 
 var configCmd = &cli.Command{
@@ -60,6 +83,86 @@ var configCmd = &cli.Command{
 				},
 			},
 		},
+		{
+			Name:   "convert",
+			Usage:  "Convert a 3fs config between yaml and json formats, or into toml",
+			Action: convertConfig,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "config",
+					Aliases:     []string{"c"},
+					Usage:       "Path to the cluster configuration file",
+					Destination: &configFilePath,
+					Required:    true,
+				},
+				&cli.StringFlag{
+					Name:        "from",
+					Usage:       "Format of the input file: yaml or json (default is detected from the file extension)",
+					Destination: &convertFrom,
+				},
+				&cli.StringFlag{
+					Name:        "to",
+					Usage:       "Format of the output: yaml, json or toml",
+					Destination: &convertTo,
+					Required:    true,
+				},
+				&cli.StringFlag{
+					Name:        "out",
+					Usage:       "Output path (default is stdout)",
+					Destination: &convertOutput,
+				},
+			},
+		},
+		{
+			Name:   "validate",
+			Usage:  "Validate a 3fs config, print all errors grouped by category and warnings of a valid config",
+			Action: validateConfig,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "config",
+					Aliases:     []string{"c"},
+					Usage:       "Path to the cluster configuration file",
+					Destination: &configFilePath,
+					Required:    true,
+				},
+				&cli.IntFlag{
+					Name:        "max-errors",
+					Usage:       "Maximum number of errors to print, all errors are printed if it's 0",
+					Destination: &maxErrors,
+				},
+			},
+		},
+		{
+			Name: "diff",
+			Usage: "Print semantic differences between two 3fs configs, exit with code 1 if there are " +
+				"differences",
+			ArgsUsage: "<old config> <new config>",
+			Action:    diffConfig,
+			Flags: []cli.Flag{
+				newOutputFlag(&diffOutput),
+			},
+		},
+		{
+			Name:   "dump",
+			Usage:  "Print the effective 3fs config with the profile given by --profile or selected by the config applied",
+			Action: dumpConfig,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "config",
+					Aliases:     []string{"c"},
+					Usage:       "Path to the cluster configuration file",
+					Destination: &configFilePath,
+					Required:    true,
+				},
+				&cli.StringFlag{
+					Name:        "format",
+					Aliases:     []string{"f"},
+					Usage:       "Format of the output: yaml, json or toml",
+					Value:       configFormatYAML,
+					Destination: &dumpFormat,
+				},
+			},
+		},
 	},
 }
 
@@ -71,11 +174,174 @@ workDir: "/opt/3fs"
 # - ERDMA: use aliyun ERDMA as RDMA network protocol
 # -   RXE: use Linux rxe kernel module to mock RDMA network protocol
 networkType: "RDMA"
+# containerRuntime configure the container runtime of nodes, it's detected on each node if not set,
+# can be one of the following: docker, containerd (operated by nerdctl), podman
+# containerRuntime: "docker"
+# hostKeyPolicy configure verifying SSH host keys of nodes, keys are pinned in .m3fs/<name>/known_hosts
+# of the work dir, can be one of the following:
+# -      accept-new: pin keys of nodes on first contact and enforce them thereafter (default)
+# -          strict: require keys of nodes in the known_hosts file
+# - insecure-ignore: don't verify keys of nodes, it's insecure
+# hostKeyPolicy: "accept-new"
+# durations of the config are written like 30s, 5m or 1h30m, plain numbers are seconds,
+# and they must not exceed 24h.
+# connectTimeout limits connecting to a node by SSH, so that an unreachable node fails fast,
+# preflight checks use at most 10s.
+# connectTimeout: 30s
+# commandTimeout limits a command run on a node which isn't limited by its task, not limited if not set.
+# commandTimeout: 30m
+# watchdogInterval warns of a task as stuck if no step or command of it starts or ends for the interval,
+# commands in flight are logged with the warning. The task isn't stopped, 0 disables the watchdog.
+# watchdogInterval: 10m
+# watchdogDump dumps goroutines of m3fs into the run dir with the warning for debugging.
+# watchdogDump: true
+# deploymentWindows freezes commands changing the cluster, they're refused in denied windows and outside
+# allowed windows if there are any. Bounds are written as "15:04" for daily windows, "Fri 15:04" for weekly
+# windows or "2006-01-02 15:04" for one-off windows in the timezone, which is the local timezone if not set.
+# Pass --override-freeze with a reason to run a command anyway, the reason is recorded in the run history.
+# deploymentWindows:
+#   timezone: "Asia/Shanghai"
+#   denied:
+#     - name: weekend
+#       start: "Fri 17:00"
+#       end: "Mon 06:00"
+# stateEncryption encrypts the cluster state and records of runs in the work dir at rest by AES-256-GCM. The key
+# is base64 encoded 32 bytes, e.g. generated by "openssl rand -base64 32", and it's a reference of a secret like
+# passwords. Plain files stay readable, run "m3fs cluster state encrypt" to encrypt them at once.
+# stateEncryption:
+#   key: "env://M3FS_STATE_KEY"
+# local deploys a single node cluster on the local host without SSH, e.g. for smoke tests and demos, it's
+# the same as the global --local flag. All services are collapsed onto the first node addressed by 127.0.0.1,
+# the replication factor of storage is reduced to 1 with warnings.
+# local: true
+# stagingDir is the dir in which artifacts are staged on the deploy host and nodes, default is the temp dir on
+# the deploy host and the work dir on nodes. Free space of it is checked before staging.
+# stagingDir: /data/m3fs-staging
+# artifactCache configures the node caching the artifact for "m3fs cluster prepare --parallel-download",
+# the artifact is copied to the node once, other nodes download it from the cache server on the port
+# by curl. The node is the first node if not set.
+# artifactCache:
+#   node: node1
+#   port: 18080
+# artifactChecksumRetries is the number of times an image whose checksum mismatches after it's transferred to a
+# node is transferred again, before failing. The artifact isn't transferred again if it's corrupted itself.
+# artifactChecksumRetries: 2
+# quarantine keeps a run going when nodes become unreachable, e.g. by a partial network partition.
+# Unreachable nodes of a step are quarantined while other nodes continue, then retried every retryInterval
+# until they recover. The run fails if a node is still unreachable after timeout, 0 disables quarantine.
+# Quarantined nodes are reported at the end of the run.
+# quarantine:
+#   timeout: 10m
+#   retryInterval: 10s
+# manageHosts makes cluster prepare write entries of all nodes into a m3fs managed block of /etc/hosts
+# of nodes, so that nodes can address each other by names without DNS. Hosts of nodes must be IP addresses.
+# manageHosts: true
+# manageFirewall makes cluster prepare open ports of services on nodes to all nodes of the cluster in the
+# detected firewall of nodes, firewalld, ufw, nftables or iptables, and cluster delete --all removes the rules.
+# manageFirewall: true
+# dns makes cluster prepare manage nameservers and search domains of the resolver of nodes, in a drop-in of
+# systemd-resolved if it's active on a node, or in a m3fs managed block of /etc/resolv.conf otherwise. The preflight
+# of cluster create checks names of all nodes resolve, and cluster delete --all removes the config.
+# dns:
+#   nameservers:
+#     - 10.0.0.53
+#   searchDomains:
+#     - cluster.example.com
+# phaseGates makes cluster create pause for approval between the prepare, deploy and verify phases
+# phaseGates: true
+# deployment configures how cluster create and cluster upgrade deploy mgmtd, meta, storage and client on
+# their nodes. By default services are created on all nodes at once and upgraded node by node.
+# -        all-at-once: deploy all nodes of a service in parallel
+# -            rolling: deploy batchSize nodes at a time, each batch must be ready before the next one
+# - canary-then-rolling: deploy a single node first, then roll out like rolling
+# Rolling strategies require readinessFailureMode of these services to be fatal.
+# deployment:
+#   strategy: rolling
+#   batchSize: 1
+# retryPolicy of deployment is the budget of retries of failed steps. At most budget of runs of steps on nodes
+# in the window are retries, besides minRetries, failed steps aren't retried once it's exhausted. 0 disables it.
+#   retryPolicy:
+#     budget: 0.1
+#     window: 10m
+#     minRetries: 10
+# maxParallel of deployment limits the number of nodes running a step in parallel, and maxParallel of phases
+# (prepare, deploy and verify) overrides it for tasks of the phase. Nodes aren't limited if it's not set.
+# Preparing nodes is mostly waiting for them, so prepare can run on all nodes at once, while deploy pulls
+# images and starts containers, limit it by the bandwidth of the registry in a large cluster.
+#   maxParallel: 50
+#   phases:
+#     deploy:
+#       maxParallel: 20
+# autoParallel derives maxParallel of phases without it from the deploy host, 4 nodes per idle CPU by the load
+# average within min and max, it conflicts with maxParallel of deployment. backoff halves the number of nodes of
+# running steps while the load average per CPU of the deploy host is above backoffLoad.
+#   autoParallel:
+#     enabled: true
+#     min: 1
+#     max: 64
+#     backoff: true
+#     backoffLoad: 1.5
+# runHistory is the number of latest runs kept in .m3fs/<name>/history.jsonl of the work dir,
+# which are listed by cluster history. Runs aren't kept in the history if it's not set.
+# runHistory: 50
+# profiles are overrides of the config for environments like dev, staging and prod, keyed by names of profiles.
+# The profile given by the global --profile flag, or else selected by profile, is applied on top of the config:
+# mappings are merged, other values including lists are replaced. "m3fs config dump" prints the effective config.
+# profile: prod
+# profiles:
+#   dev:
+#     services:
+#       storage:
+#         replicationFactor: 1
+#   prod:
+#     phaseGates: true
+#     services:
+#       storage:
+#         resources:
+#           memory: 64g
+# plugins are site-specific tasks of cluster create, e.g. registering nodes into a CMDB. The command runs
+# with sudo on nodes selected by nodes and services, or once on the local host if no node is selected.
+# A plugin runs right after tasks in after listed by cluster tasks, or at the end of its phase, default
+# is deploy. deleteCommand undoes the command, it's run by cluster delete.
+# plugins:
+#   - name: register-cmdb
+#     command: "/opt/site/cmdb register"
+#     deleteCommand: "/opt/site/cmdb unregister"
+#     services: [storage]
+#     after: [CreateStorageServiceTask]
+#     retries: 2
+# tuning configure sysctls and kernel modules of nodes of services, they're applied and persisted in
+# /etc/sysctl.d and /etc/modules-load.d of nodes by cluster prepare.
+# tuning:
+#   storage:
+#     sysctls:
+#       vm.max_map_count: "262144"
+#     modules:
+#       - rdma_ucm
+# env configure the environment of commands run on all nodes, e.g. proxy settings,
+# env of a node takes precedence over it.
+# env:
+#   HTTP_PROXY: "http://proxy.example.com:3128"
+#   NO_PROXY: "192.168.1.0/24"
+# Passwords of nodes, node groups and clickhouse and values of env can reference secrets instead of embedding
+# them, references are resolved when the config is loaded:
+# - env://<NAME>: the environment variable
+# - file:///<path>: the file on the local node
+# - secret://vault/<path>#<key>: the key of the secret of HashiCorp Vault at VAULT_ADDR with VAULT_TOKEN,
+#   e.g. secret://vault/secret/data/m3fs#password
 nodes:
   - name: node1
     host: "192.168.1.1"
     username: "root"
     password: "password"
+    # env:
+    #   LANG: "en_US.UTF-8"
+    # zone is the availability zone of the node, replicas of storage can be spread across zones by
+    # services.storage.placement.
+    # zone: "az1"
+    # capacity is the capacity of data disks of the storage node, data is placed in proportion to
+    # capacities of storage nodes if they're set for all storage nodes.
+    # capacity: "4t"
   - name: node2
     host: "192.168.1.2"
     username: "root"
@@ -85,6 +351,14 @@ services:
     nodes: 
       - node1
     hostMountpoint: /mnt/3fs
+    # mountOptions are options of mounting the filesystem: ro, rw, allow_other and noallow_other,
+    # default is rw and allow_other.
+    # mountOptions:
+    #   - ro
+    # mgmtdEndpoints are host:port of mgmtd the client connects to, default is mgmtd services
+    # of the cluster.
+    # mgmtdEndpoints:
+    #   - 192.168.1.1:8000
   storage:
     nodes: 
       - node1
@@ -93,6 +367,62 @@ services:
     # - nvme: NVMe SSD
     # - dir: use a directory on the filesystem
     diskType: "nvme"
+    # placement constrains placing replicas across zones of storage nodes, zones of all storage nodes are
+    # required if it's set. spreadZones places replicas of every chain in distinct zones, minZones is the
+    # minimum number of zones storage nodes span.
+    # placement:
+    #   spreadZones: true
+    #   minZones: 2
+    # readinessTimeout configure how long to wait for the service to be ready after started,
+    # every service has its own readinessTimeout and readinessInterval.
+    # readinessTimeout: 10m
+    # readinessInterval: 5s
+    # readinessFailureMode configure what to do if the service isn't ready after readinessTimeout,
+    # can be one of the following:
+    # -       fatal: abort the deployment (default)
+    # -        warn: log a warning and continue, e.g. for the monitor service
+    # - retry-later: continue and re-check the service after later tasks, fail if it's still not ready
+    # readinessFailureMode: fatal
+    # shutdownTimeout configure how long to wait for the service to exit after shutdownSignal before it's
+    # killed by SIGKILL, when it's stopped by cluster upgrade, rollback or delete. Every service has its own
+    # shutdownTimeout and shutdownSignal, storage waits for 5m by default to flush data.
+    # shutdownTimeout: 5m
+    # shutdownSignal: SIGTERM
+    # resources limit the container of the service, every service has its own resources.
+    # The preflight of 'cluster create' checks that nodes can accommodate them.
+    # resources:
+    #   memory: 64g
+    #   cpus: 16
+    #   nofile: 1048576
+    #   nproc: 65535
+    # mounts and devices pass paths and devices of nodes into the container of the service, e.g. data disks
+    # and RDMA devices of storage. Every service has its own mounts, devices and securityOpts, the preflight
+    # of 'cluster create' checks they exist on nodes. selinuxLabel relabels the source, z to share it among
+    # containers or Z to make it private, system paths like /usr mustn't be relabeled.
+    # mounts:
+    #   - source: /mnt/nvme0
+    #     target: /mnt/3fsdata/data0
+    #     readOnly: false
+    #     selinuxLabel: z
+    # devices:
+    #   - host: /dev/infiniband/uverbs0
+    #     container: /dev/infiniband/uverbs0
+    #     permissions: rwm
+    # securityOpts are security options of the container, e.g. label=disable or apparmor=unconfined.
+    # securityOpts:
+    #   - apparmor=unconfined
+    # commandWrapper prefixes the command launching the service in its container for debugging, e.g.
+    # strace or numactl. The executable must be an absolute path of a static binary on the node, only the
+    # executable is mounted into the container.
+    # Every service except fdb and clickhouse has its own commandWrapper, clear it to remove the wrapper.
+    # commandWrapper: /usr/bin/numactl --interleave=all
+    # extraConfig sets native 3fs options in the main config file of the service, every 3fs service
+    # has its own extraConfig. Keys rendered from m3fs settings are kept unless override is set.
+    # extraConfig:
+    #   server.service.check_update_interval: 10s
+    #   server.mgmtd:
+    #     value: ["RDMA://192.168.1.1:8000"]
+    #     override: true
   mgmtd:
     nodes: 
       - node1
@@ -100,8 +430,18 @@ services:
     nodes: 
       - node1
   monitor:
+    # enabled is whether the service is deployed, default is true. Services depending on a
+    # disabled service must be disabled too, e.g. monitor depends on clickhouse.
+    # enabled: false
     nodes:
       - node1
+    # alerts are thresholds of alerting rules rendered by m3fs cluster alert-rules.
+    # alerts:
+    #   for: 5m
+    #   unreachableNodes: 1
+    #   driftFindings: 1
+    #   staleCheck: 30m
+    #   slowTask: 0s
   fdb:
     nodes: 
       - node1
@@ -116,6 +456,12 @@ services:
     password: "password"
     # TCP port for Clickhouse
     tcpPort: 8999
+    # shards and replicas of metrics tables, replicas of shards take clickhouse nodes in order.
+    # Replicated tables are coordinated by the embedded clickhouse keeper, default is a single node.
+    # shards: 1
+    # replicas: 1
+    # retentionDays is the TTL of metrics, default is a month
+    # retentionDays: 30
 images:
   registry: "{{ .registry }}"
   3fs:
@@ -128,6 +474,12 @@ images:
   clickhouse:
     repo: "open3fs/clickhouse"
     tag: "25.1-jammy"
+# tls configure verifying certificates of the registry and HTTP downloads, e.g. for the internal PKI.
+# Certificates are installed for the container runtime of nodes by 'cluster prepare'.
+# tls:
+#   caFile: "/etc/m3fs/ca.crt"
+#   certFile: "/etc/m3fs/client.crt"
+#   keyFile: "/etc/m3fs/client.key"
 `
 
 func createSampleConfig(ctx *cli.Context) error {
@@ -156,3 +508,274 @@ func createSampleConfig(ctx *cli.Context) error {
 
 	return nil
 }
+
+// configFormatOf returns format of the config file by its extension, yaml is
+// assumed for unknown extensions.
+func configFormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return configFormatJSON
+	case ".toml":
+		return configFormatTOML
+	default:
+		return configFormatYAML
+	}
+}
+
+func checkConfigFormat(format string) error {
+	switch format {
+	case configFormatYAML, configFormatJSON, configFormatTOML:
+		return nil
+	default:
+		return errors.Errorf("invalid config format: %s", format)
+	}
+}
+
+// decodeClusterConfig decodes config data on top of the default config, the profile given
+// by --profile or selected by the config is applied.
+func decodeClusterConfig(data []byte, format string) (*config.Config, error) {
+	if err := checkConfigFormat(format); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if format == configFormatTOML {
+		return nil, errors.New("toml configs can't be read, convert them to yaml or json")
+	}
+	// json is a subset of yaml, so both of them are decoded by the yaml decoder to
+	// share field names.
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, errors.Annotatef(err, "decode %s config", format)
+	}
+	if err := config.ApplyProfile(&doc, configProfile); err != nil {
+		return nil, errors.Trace(err)
+	}
+	cfg := config.NewConfigWithDefaults()
+	if err := doc.Decode(cfg); err != nil {
+		return nil, errors.Annotatef(err, "decode %s config", format)
+	}
+	return cfg, nil
+}
+
+// encodeClusterConfig encodes the config in the format.
+func encodeClusterConfig(cfg *config.Config, format string) ([]byte, error) {
+	if err := checkConfigFormat(format); err != nil {
+		return nil, errors.Trace(err)
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, errors.Annotate(err, "encode yaml config")
+	}
+	if format == configFormatYAML {
+		return data, nil
+	}
+	if format == configFormatTOML {
+		encoded, err := task.EncodeTOML(cfg)
+		if err != nil {
+			return nil, errors.Annotate(err, "encode toml config")
+		}
+		return []byte(encoded + "\n"), nil
+	}
+
+	var value any
+	if err = yaml.Unmarshal(data, &value); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if data, err = json.MarshalIndent(value, "", "  "); err != nil {
+		return nil, errors.Annotate(err, "encode json config")
+	}
+	return append(data, '\n'), nil
+}
+
+// readClusterConfig reads the config file, the format is detected from the
+// file extension if it's empty.
+func readClusterConfig(path, format string) (*config.Config, error) {
+	if format == "" {
+		format = configFormatOf(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "read config file")
+	}
+	cfg, err := decodeClusterConfig(data, format)
+	if err != nil {
+		return nil, errors.Annotate(err, "load cluster config")
+	}
+	return cfg, nil
+}
+
+func convertConfig(ctx *cli.Context) error {
+	from := strings.ToLower(convertFrom)
+	to := strings.ToLower(convertTo)
+	if err := checkConfigFormat(to); err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := readClusterConfig(configFilePath, from)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Validate a separate copy, because validation expands node groups and fills
+	// derived fields which should not be written back into the config file.
+	validateCfg, err := readClusterConfig(configFilePath, from)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = validateCfg.SetValidate(workDirOverride(), registry); err != nil {
+		return errors.Annotate(err, "validate cluster config")
+	}
+
+	data, err := encodeClusterConfig(cfg, to)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if convertOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return errors.Trace(err)
+	}
+	if err = os.WriteFile(convertOutput, data, 0644); err != nil {
+		return errors.Annotatef(err, "write %s", convertOutput)
+	}
+	return nil
+}
+
+func dumpConfig(ctx *cli.Context) error {
+	format := strings.ToLower(dumpFormat)
+	if err := checkConfigFormat(format); err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := readClusterConfig(configFilePath, "")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Validate a separate copy like convert, derived fields aren't part of the config.
+	validateCfg, err := readClusterConfig(configFilePath, "")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = validateCfg.SetValidate(workDirOverride(), registry); err != nil {
+		return errors.Annotate(err, "validate cluster config")
+	}
+	cfg.Services.SetEnabledExplicitly()
+	data, err := encodeClusterConfig(cfg, format)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = os.Stdout.Write(data)
+	return errors.Trace(err)
+}
+
+func validateConfig(ctx *cli.Context) error {
+	if maxErrors < 0 {
+		return errors.New("--max-errors must not be negative")
+	}
+	cfg, err := readClusterConfig(configFilePath, "")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if localMode {
+		cfg.Local = true
+	}
+	if stagingDir != "" {
+		cfg.StagingDir = stagingDir
+	}
+	err = cfg.SetValidate(workDirOverride(), registry)
+	if err == nil {
+		fmt.Printf("Config %s is valid\n", configFilePath)
+		for _, warning := range cfg.Warnings() {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+		return nil
+	}
+	validationErrs, ok := errors.Cause(err).(config.ValidationErrors)
+	if !ok {
+		return errors.Annotate(err, "validate cluster config")
+	}
+	if err = printValidationErrors(os.Stdout, validationErrs, maxErrors); err != nil {
+		return errors.Trace(err)
+	}
+	return cli.Exit(fmt.Sprintf("config %s has %d errors", configFilePath, len(validationErrs)), 1)
+}
+
+// printValidationErrors prints errors grouped by category with keys of them, at most
+// maxErrors errors are printed if it's positive.
+func printValidationErrors(w io.Writer, errs config.ValidationErrors, maxErrors int) error {
+	groups := errs.Group()
+	printed := 0
+	for _, category := range config.ValidationCategories {
+		group := groups[category]
+		if len(group) == 0 || (maxErrors > 0 && printed >= maxErrors) {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s:\n", category); err != nil {
+			return errors.Trace(err)
+		}
+		for _, validationErr := range group {
+			if maxErrors > 0 && printed >= maxErrors {
+				break
+			}
+			if _, err := fmt.Fprintf(w, "  %s: %s\n", validationErr.Key, validationErr.Message); err != nil {
+				return errors.Trace(err)
+			}
+			printed++
+		}
+	}
+	if printed < len(errs) {
+		if _, err := fmt.Fprintf(w, "... and %d more errors\n", len(errs)-printed); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// loadDiffConfig reads and validates the config, so that configs are normalized the
+// same way before diffing.
+func loadDiffConfig(path string) (*config.Config, error) {
+	cfg, err := readClusterConfig(path, "")
+	if err != nil {
+		return nil, errors.Annotatef(err, "read %s", path)
+	}
+	if err = cfg.SetValidate("", ""); err != nil {
+		return nil, errors.Annotatef(err, "validate %s", path)
+	}
+	return cfg, nil
+}
+
+func diffConfig(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("old and new config files are required")
+	}
+	format, err := checkOutputFormat(diffOutput)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	oldCfg, err := loadDiffConfig(ctx.Args().Get(0))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	newCfg, err := loadDiffConfig(ctx.Args().Get(1))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	changes, err := config.Diff(oldCfg, newCfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if err = printConfigChanges(os.Stdout, changes, format); err != nil {
+		return errors.Trace(err)
+	}
+	if len(changes) > 0 {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+func printConfigChanges(w io.Writer, changes []*config.Change, format string) error {
+	return printOutput(w, format, changes, func(w io.Writer) error {
+		for _, change := range changes {
+			if _, err := fmt.Fprintln(w, change); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+func TestConfigConvertSuite(t *testing.T) {
+	suiteRun(t, &configConvertSuite{})
+}
+
+type configConvertSuite struct {
+	Suite
+}
+
+func (s *configConvertSuite) TestConfigFormatOf() {
+	s.Equal(configFormatYAML, configFormatOf("cluster.yml"))
+	s.Equal(configFormatYAML, configFormatOf("cluster"))
+	s.Equal(configFormatJSON, configFormatOf("cluster.JSON"))
+	s.Equal(configFormatTOML, configFormatOf("cluster.toml"))
+}
+
+func (s *configConvertSuite) TestRoundTrip() {
+	yamlCfg, err := decodeClusterConfig([]byte(`
+name: "open3fs"
+networkType: "RXE"
+nodes:
+  - name: node1
+    host: "192.168.1.1"
+services:
+  mgmtd:
+    nodes:
+      - node1
+`), configFormatYAML)
+	s.NoError(err)
+	s.Equal("open3fs", yamlCfg.Name)
+	s.Equal([]string{"node1"}, yamlCfg.Services.Mgmtd.Nodes)
+
+	data, err := encodeClusterConfig(yamlCfg, configFormatJSON)
+	s.NoError(err)
+	s.Contains(string(data), `"networkType": "RXE"`)
+
+	jsonCfg, err := decodeClusterConfig(data, configFormatJSON)
+	s.NoError(err)
+	yamlData, err := encodeClusterConfig(yamlCfg, configFormatYAML)
+	s.NoError(err)
+	jsonYAMLData, err := encodeClusterConfig(jsonCfg, configFormatYAML)
+	s.NoError(err)
+	s.Equal(string(yamlData), string(jsonYAMLData))
+}
+
+func (s *configConvertSuite) TestDecodeWithProfile() {
+	data := []byte(`
+name: "open3fs"
+networkType: "RXE"
+profiles:
+  prod:
+    networkType: "RDMA"
+`)
+	cfg, err := decodeClusterConfig(data, configFormatYAML)
+	s.NoError(err)
+	s.Equal(config.NetworkTypeRXE, cfg.NetworkType)
+	s.Contains(cfg.Profiles, "prod")
+
+	configProfile = "prod"
+	defer func() { configProfile = "" }()
+	cfg, err = decodeClusterConfig(data, configFormatYAML)
+	s.NoError(err)
+	s.Equal(config.NetworkTypeRDMA, cfg.NetworkType)
+	dumped, err := encodeClusterConfig(cfg, configFormatYAML)
+	s.NoError(err)
+	s.Contains(string(dumped), "profile: prod\n")
+	s.NotContains(string(dumped), "profiles:")
+
+	configProfile = "dev"
+	_, err = decodeClusterConfig(data, configFormatYAML)
+	s.Error(err, "profile dev not exists in profiles, available profiles: [prod]")
+}
+
+func (s *configConvertSuite) TestInvalidFormat() {
+	_, err := decodeClusterConfig([]byte("name: open3fs"), "ini")
+	s.Error(err)
+	s.Contains(err.Error(), "invalid config format: ini")
+
+	_, err = decodeClusterConfig([]byte("name = 'open3fs'"), configFormatTOML)
+	s.Error(err)
+	s.Contains(err.Error(), "toml configs can't be read")
+}
+
+func (s *configConvertSuite) TestEncodeTOML() {
+	cfg, err := decodeClusterConfig([]byte(`
+name: "open3fs"
+networkType: "RXE"
+nodes:
+  - name: node1
+    host: "192.168.1.1"
+services:
+  mgmtd:
+    nodes:
+      - node1
+`), configFormatYAML)
+	s.NoError(err)
+
+	data, err := encodeClusterConfig(cfg, configFormatTOML)
+	s.NoError(err)
+	s.Contains(string(data), "name = 'open3fs'\n")
+	s.Contains(string(data), "networkType = 'RXE'\n")
+	s.Contains(string(data), "[[nodes]]\nhost = '192.168.1.1'\n")
+	s.Contains(string(data), "[services.mgmtd]\n")
+}
+
+func TestConfigDiffSuite(t *testing.T) {
+	suiteRun(t, &configDiffSuite{})
+}
+
+type configDiffSuite struct {
+	Suite
+}
+
+func (s *configDiffSuite) TestPrintChanges() {
+	changes := []*config.Change{
+		{Kind: config.ChangeChanged, Path: "services.storage.diskNumPerNode", Old: 1, New: 8},
+		{Kind: config.ChangeRemoved, Path: "services.client.nodes", Old: "node1"},
+	}
+
+	buf := new(bytes.Buffer)
+	s.NoError(printConfigChanges(buf, changes, "text"))
+	s.Equal("~ services.storage.diskNumPerNode: 1 -> 8\n- services.client.nodes: node1\n", buf.String())
+
+	buf.Reset()
+	s.NoError(printConfigChanges(buf, changes, "json"))
+	s.Contains(buf.String(), `"kind": "changed"`)
+	s.Contains(buf.String(), `"path": "services.client.nodes"`)
+
+	buf.Reset()
+	s.NoError(printConfigChanges(buf, nil, "json"))
+	s.Equal("[]\n", buf.String())
+}
+
+func TestConfigValidateSuite(t *testing.T) {
+	suiteRun(t, &configValidateSuite{})
+}
+
+type configValidateSuite struct {
+	Suite
+}
+
+func (s *configValidateSuite) TestPrintValidationErrors() {
+	errs := config.ValidationErrors{
+		{Category: config.ValidationCategoryServices, Key: "services.fdb.nodes", Message: "fdb error"},
+		{Category: config.ValidationCategoryNodes, Key: "nodes[0].host", Message: "host error"},
+		{Category: config.ValidationCategoryNodes, Key: "nodes[1].host", Message: "host error 2"},
+	}
+
+	buf := new(bytes.Buffer)
+	s.NoError(printValidationErrors(buf, errs, 0))
+	s.Equal("nodes:\n  nodes[0].host: host error\n  nodes[1].host: host error 2\n"+
+		"services:\n  services.fdb.nodes: fdb error\n", buf.String())
+
+	buf.Reset()
+	s.NoError(printValidationErrors(buf, errs, 1))
+	s.Equal("nodes:\n  nodes[0].host: host error\n... and 2 more errors\n", buf.String())
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// assumeYes answers yes to all confirmations, it's set by the global --yes, and --yes of
+// cluster upgrade, rollback and sync. It doesn't approve phase gates, which are enabled
+// explicitly to pause runs.
+var assumeYes bool
+
+// stdinReader reads answers of confirmations.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// stdinIsTerminal returns whether stdin is a terminal, the operator is asked for
+// confirmations and approvals of gates only if it's a terminal.
+var stdinIsTerminal = func() bool {
+	return isTerminal(os.Stdin)
+}
+
+// confirmation is a question asked to the operator before running a mutating action.
+type confirmation struct {
+	// summary describes what the action changes, it's printed before the prompt.
+	summary string
+	prompt  string
+	// typedName makes the operator type the name instead of y to confirm, it's required
+	// by the most dangerous actions, e.g. deleting the cluster.
+	typedName string
+}
+
+// ask asks the operator to confirm, it returns true without asking if --yes is set. It
+// fails if stdin isn't a terminal, so that actions aren't confirmed by accident in scripts.
+func (c confirmation) ask() (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if !stdinIsTerminal() {
+		return false, errors.Errorf("%q must be confirmed but stdin isn't a terminal, pass --yes to confirm it",
+			c.prompt)
+	}
+	return c.read()
+}
+
+// read prints the question and reads the answer of the operator from stdin.
+func (c confirmation) read() (bool, error) {
+	if c.summary != "" {
+		fmt.Print(strings.TrimSuffix(c.summary, "\n") + "\n")
+	}
+	if c.typedName != "" {
+		fmt.Printf("%s\nType %s to confirm: ", c.prompt, c.typedName)
+	} else {
+		fmt.Printf("%s [y/N]: ", c.prompt)
+	}
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, errors.Annotate(err, "read answer")
+	}
+	answer = strings.TrimSpace(answer)
+	if c.typedName != "" {
+		return answer == c.typedName, nil
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// require is ask which fails unless the operator confirms.
+func (c confirmation) require(action string) error {
+	ok, err := c.ask()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !ok {
+		return errors.Errorf("%s isn't confirmed", action)
+	}
+	return nil
+}
+
+// confirm asks the operator a yes or no question, see confirmation.ask.
+func confirm(prompt string) (bool, error) {
+	ok, err := confirmation{prompt: prompt}.ask()
+	return ok, errors.Trace(err)
+}
+
+// confirmRun makes the operator confirm the run of the command before it runs. The banner
+// of the run is printed as the summary, it isn't printed again when the run starts.
+func confirmRun(runner *task.Runner, command string, c confirmation) error {
+	if assumeYes {
+		return nil
+	}
+	if !quiet {
+		c.summary = runner.Banner()
+	}
+	if err := c.require(command); err != nil {
+		return errors.Trace(err)
+	}
+	runner.SetQuiet(true)
+	return nil
+}
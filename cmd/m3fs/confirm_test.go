@@ -0,0 +1,96 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestConfirmSuite(t *testing.T) {
+	suiteRun(t, &confirmSuite{})
+}
+
+type confirmSuite struct {
+	Suite
+	isTerminal func() bool
+}
+
+func (s *confirmSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.isTerminal = stdinIsTerminal
+	stdinIsTerminal = func() bool { return true }
+}
+
+func (s *confirmSuite) TearDownTest() {
+	stdinIsTerminal = s.isTerminal
+	stdinReader = bufio.NewReader(os.Stdin)
+	assumeYes = false
+	quiet = false
+}
+
+func (s *confirmSuite) TestConfirm() {
+	stdinReader = bufio.NewReader(strings.NewReader("y\nno\n"))
+
+	ok, err := confirm("continue?")
+	s.NoError(err)
+	s.True(ok)
+	ok, err = confirm("continue?")
+	s.NoError(err)
+	s.False(ok)
+	ok, err = confirm("continue?")
+	s.NoError(err)
+	s.False(ok)
+
+	assumeYes = true
+	ok, err = confirm("continue?")
+	s.NoError(err)
+	s.True(ok)
+}
+
+func (s *confirmSuite) TestNotTerminal() {
+	stdinIsTerminal = func() bool { return false }
+
+	_, err := confirm("continue?")
+	s.ErrorContains(err, `"continue?" must be confirmed but stdin isn't a terminal, pass --yes`)
+
+	assumeYes = true
+	ok, err := confirm("continue?")
+	s.NoError(err)
+	s.True(ok)
+}
+
+func (s *confirmSuite) TestTypedName() {
+	stdinReader = bufio.NewReader(strings.NewReader("y\ntest-cluster\n"))
+	c := confirmation{prompt: "Delete cluster test-cluster?", typedName: "test-cluster"}
+
+	s.ErrorContains(c.require("cluster delete"), "cluster delete isn't confirmed")
+	s.NoError(c.require("cluster delete"))
+}
+
+func (s *confirmSuite) TestConfirmRun() {
+	stdinReader = bufio.NewReader(strings.NewReader("yes\n"))
+	cfg := config.NewConfigWithDefaults()
+	cfg.Name = "test-cluster"
+	runner, err := task.NewRunner(cfg)
+	s.NoError(err)
+
+	s.NoError(confirmRun(runner, "cluster delete", confirmation{prompt: "Delete?"}))
+}
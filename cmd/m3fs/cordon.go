@@ -0,0 +1,187 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var includeCordoned bool
+
+var clusterCordonCmd = &cli.Command{
+	Name: "cordon",
+	Usage: "Cordon a node to exclude it from create, prepare and upgrade of a 3fs cluster, " +
+		"cordoned nodes are listed if no node is given",
+	ArgsUsage: "[node]",
+	Action:    cordonNode,
+	Flags:     cordonFlags(newOutputFlag(&outputFormat)),
+}
+
+var clusterUncordonCmd = &cli.Command{
+	Name:      "uncordon",
+	Usage:     "Uncordon a node to include it in runs of a 3fs cluster again",
+	ArgsUsage: "<node>",
+	Action:    uncordonNode,
+	Flags:     cordonFlags(),
+}
+
+func cordonFlags(flags ...cli.Flag) []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+	}, flags...)
+}
+
+func cordonNode(ctx *cli.Context) error {
+	if ctx.Args().Len() == 0 {
+		return errors.Trace(listCordonedNodes())
+	}
+	return errors.Trace(updateCordon(ctx, "cluster cordon", func(state *task.ClusterState, node string) {
+		if !state.CordonNode(node) {
+			fmt.Printf("Node %s is already cordoned\n", node)
+			return
+		}
+		fmt.Printf("Node %s is cordoned, it's skipped by runs until it's uncordoned\n", node)
+	}))
+}
+
+func uncordonNode(ctx *cli.Context) error {
+	return errors.Trace(updateCordon(ctx, "cluster uncordon", func(state *task.ClusterState, node string) {
+		if !state.UncordonNode(node) {
+			fmt.Printf("Node %s isn't cordoned\n", node)
+			return
+		}
+		fmt.Printf("Node %s is uncordoned\n", node)
+	}))
+}
+
+// updateCordon updates the cordon of the node given by the argument in the recorded
+// state of the cluster.
+func updateCordon(ctx *cli.Context, command string, update func(*task.ClusterState, string)) error {
+	if ctx.Args().Len() != 1 {
+		return errors.Errorf("%s requires exactly one node", command)
+	}
+	node := ctx.Args().First()
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !slices.ContainsFunc(cfg.Nodes, func(n config.Node) bool { return n.Name == node }) {
+		return errors.Errorf("node %s not exists in node list", node)
+	}
+	lock, err := lockClusterState(cfg, command)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer unlockCluster(lock)
+
+	state, err := loadCordonState(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	update(state, node)
+	return errors.Trace(task.SaveClusterState(cfg.WorkDir, state))
+}
+
+// loadCordonState loads the recorded state of the cluster which cordons are kept in.
+func loadCordonState(cfg *config.Config) (*task.ClusterState, error) {
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if state == nil {
+		return nil, errors.Errorf("no recorded state of cluster %s, only clusters created by m3fs have cordons",
+			cfg.Name)
+	}
+	return state, nil
+}
+
+func listCordonedNodes() error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	state, err := loadCordonState(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(printCordons(os.Stdout, state.Cordons, format))
+}
+
+func printCordons(out io.Writer, cordons []*task.Cordon, format string) error {
+	return printOutput(out, format, cordons, func(out io.Writer) error {
+		w := newTable(out, "NODE", "CORDONED AT")
+		for _, cordon := range cordons {
+			fmt.Fprintf(w, "%s\t%s\n", cordon.Node, cordon.Time.Format(time.DateTime))
+		}
+		return errors.Trace(w.Flush())
+	})
+}
+
+// applyCordons makes the runner skip nodes cordoned in the recorded state of the cluster,
+// unless --include-cordoned is set. It returns the recorded cordons, which are kept by
+// the state saved after the run.
+func applyCordons(runner *task.Runner) ([]*task.Cordon, error) {
+	cfg := runner.Runtime.Cfg
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if state == nil {
+		return nil, nil
+	}
+	runner.Runtime.CordonedNodes = skippedCordonedNodes(state)
+	return state.Cordons, nil
+}
+
+// skippedCordonedNodes returns cordoned nodes of the state which runs skip.
+func skippedCordonedNodes(state *task.ClusterState) []string {
+	nodes := state.CordonedNodes()
+	if len(nodes) == 0 {
+		return nil
+	}
+	if includeCordoned {
+		logrus.Warnf("Cordoned nodes %v are included by --include-cordoned", nodes)
+		return nil
+	}
+	logrus.Infof("Cordoned nodes %v are skipped", nodes)
+	return nodes
+}
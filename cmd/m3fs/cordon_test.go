@@ -0,0 +1,59 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestCordonSuite(t *testing.T) {
+	suiteRun(t, &cordonSuite{})
+}
+
+type cordonSuite struct {
+	Suite
+}
+
+func (s *cordonSuite) TearDownTest() {
+	includeCordoned = false
+}
+
+func (s *cordonSuite) TestPrintCordons() {
+	cordons := []*task.Cordon{
+		{Node: "node2", Time: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	buf := new(bytes.Buffer)
+	s.NoError(printCordons(buf, cordons, outputFormatTable))
+	s.Equal("NODE   CORDONED AT\nnode2  2025-03-01 10:00:00\n", buf.String())
+
+	buf.Reset()
+	s.NoError(printCordons(buf, nil, outputFormatJSON))
+	s.Equal("[]\n", buf.String())
+}
+
+func (s *cordonSuite) TestSkippedCordonedNodes() {
+	state := new(task.ClusterState)
+	s.Nil(skippedCordonedNodes(state))
+
+	state.CordonNode("node2")
+	s.Equal([]string{"node2"}, skippedCordonedNodes(state))
+
+	includeCordoned = true
+	s.Nil(skippedCordonedNodes(state))
+}
@@ -0,0 +1,317 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	mlog "github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var showDashboard bool
+
+// isTerminal returns whether the file is a terminal. Character devices which aren't
+// terminals, e.g. /dev/null, have no window size.
+func isTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	return err == nil
+}
+
+// terminalSize returns the width and height of the terminal of the file, the width is
+// COLUMNS and the height is LINES if they can't be detected, 80x24 by default.
+func terminalSize(f *os.File) (int, int) {
+	if ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ); err == nil && ws.Col > 0 && ws.Row > 0 {
+		return int(ws.Col), int(ws.Row)
+	}
+	size := func(env string, def int) int {
+		if n, err := strconv.Atoi(os.Getenv(env)); err == nil && n > 0 {
+			return n
+		}
+		return def
+	}
+	return size("COLUMNS", 80), size("LINES", 24)
+}
+
+// attachDashboard reports progress of tasks of the runtime to the dashboard rendered in
+// stderr, logs are written above the dashboard if stderr is a terminal.
+func attachDashboard(runtime *task.Runtime) {
+	d := newDashboard(os.Stderr)
+	runtime.Progress = d
+	if d.tty {
+		mlog.SetOutput(d)
+	}
+}
+
+// dashboardStatuses are marks and colors of statuses of nodes in cells of the dashboard.
+var dashboardStatuses = map[string]struct {
+	mark  string
+	color color.Attribute
+}{
+	task.NodeStatusPending: {".", color.FgHiBlack},
+	task.NodeStatusRunning: {"*", color.FgHiYellow},
+	task.NodeStatusOK:      {"+", color.FgHiGreen},
+	task.NodeStatusFailed:  {"x", color.FgHiRed},
+}
+
+// dashboard renders statuses of nodes of the running task in place in the terminal, as a
+// grid of cells colored by status with the overall progress at the bottom. Logs written
+// to it are written above the grid. If the output isn't a terminal, it logs a line as
+// each node ends instead.
+type dashboard struct {
+	mu       sync.Mutex
+	out      io.Writer
+	tty      bool
+	colored  bool
+	size     func() (int, int)
+	now      func() time.Time
+	interval time.Duration
+
+	index    int
+	total    int
+	task     string
+	start    time.Time
+	nodes    []string
+	statuses map[string]string
+	// lines is the number of lines rendered in the terminal.
+	lines    int
+	rendered time.Time
+	timer    *time.Timer
+}
+
+var _ task.ProgressReporter = new(dashboard)
+
+func newDashboard(out *os.File) *dashboard {
+	tty := isTerminal(out)
+	return &dashboard{
+		out:      out,
+		tty:      tty,
+		colored:  tty && !color.NoColor,
+		size:     func() (int, int) { return terminalSize(out) },
+		now:      time.Now,
+		interval: 100 * time.Millisecond,
+	}
+}
+
+// TaskStarted renders the task without nodes.
+func (d *dashboard) TaskStarted(index, total int, name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.index, d.total, d.task = index, total, name
+	d.start = d.now()
+	d.nodes = nil
+	d.statuses = make(map[string]string)
+	d.render(true)
+}
+
+// NodeChanged updates the cell of the node.
+func (d *dashboard) NodeChanged(name, node, status string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if name != d.task {
+		return
+	}
+	if _, ok := d.statuses[node]; !ok {
+		d.nodes = append(d.nodes, node)
+	}
+	d.statuses[node] = status
+	if d.tty {
+		d.render(false)
+		return
+	}
+	if status == task.NodeStatusOK || status == task.NodeStatusFailed {
+		logrus.Infof("Node %s %s in task %s, %s", node, status, name, d.counts())
+	}
+}
+
+// TaskEnded replaces the grid with the summary of the task.
+func (d *dashboard) TaskEnded(name string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if name != d.task {
+		return
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.tty {
+		d.clear()
+		status := task.NodeStatusOK
+		if err != nil {
+			status = task.NodeStatusFailed
+		}
+		summary := fmt.Sprintf("%s task %d/%d %s", d.mark(status), d.index, d.total, name)
+		if len(d.nodes) > 0 {
+			summary += ": " + d.counts()
+		}
+		fmt.Fprintf(d.out, "%s in %s\n", summary, d.now().Sub(d.start).Round(time.Second))
+	}
+	d.task = ""
+	d.nodes = nil
+}
+
+// Write writes logs above the grid.
+func (d *dashboard) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.lines == 0 {
+		return d.out.Write(p)
+	}
+	d.clear()
+	n, err := d.out.Write(p)
+	d.render(true)
+	return n, err
+}
+
+// counts returns counts of nodes by status, e.g. "48/200 node(s) done, 47 ok, 1 failed,
+// 30 running, 122 pending".
+func (d *dashboard) counts() string {
+	counts := make(map[string]int)
+	for _, status := range d.statuses {
+		counts[status]++
+	}
+	done := counts[task.NodeStatusOK] + counts[task.NodeStatusFailed]
+	return fmt.Sprintf("%d/%d node(s) done, %d ok, %d failed, %d running, %d pending", done, len(d.nodes),
+		counts[task.NodeStatusOK], counts[task.NodeStatusFailed],
+		counts[task.NodeStatusRunning], counts[task.NodeStatusPending])
+}
+
+func (d *dashboard) mark(status string) string {
+	s := dashboardStatuses[status]
+	if !d.colored {
+		return s.mark
+	}
+	c := color.New(s.color)
+	c.EnableColor()
+	return c.Sprint(s.mark)
+}
+
+// clear erases lines rendered in the terminal.
+func (d *dashboard) clear() {
+	if d.lines > 0 {
+		fmt.Fprintf(d.out, "\x1b[%dA\x1b[J", d.lines)
+		d.lines = 0
+	}
+}
+
+// render renders the grid, it's rendered at most once per interval unless forced, a
+// skipped change is rendered when the interval elapses.
+func (d *dashboard) render(force bool) {
+	if !d.tty || d.task == "" {
+		return
+	}
+	if elapsed := d.now().Sub(d.rendered); !force && elapsed < d.interval {
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.interval-elapsed, func() {
+				d.mu.Lock()
+				defer d.mu.Unlock()
+				d.timer = nil
+				d.render(true)
+			})
+		}
+		return
+	}
+	d.clear()
+	lines := d.grid()
+	for _, line := range lines {
+		fmt.Fprintln(d.out, line)
+	}
+	d.lines = len(lines)
+	d.rendered = d.now()
+}
+
+// grid returns lines of cells of nodes and the progress line. If cells of all nodes
+// don't fit in the terminal, ok nodes are hidden, then other nodes which still don't fit.
+func (d *dashboard) grid() []string {
+	width, height := d.size()
+	width = max(width-1, 1)
+	progress := fmt.Sprintf("task %d/%d %s", d.index, d.total, d.task)
+	if len(d.nodes) > 0 {
+		progress = fmt.Sprintf("%s %s: %s", progressBar(d.statuses, 20), progress, d.counts())
+	}
+	progress += fmt.Sprintf(", %s elapsed", d.now().Sub(d.start).Round(time.Second))
+
+	cellWidth := 0
+	for _, node := range d.nodes {
+		cellWidth = max(cellWidth, len(node)+4)
+	}
+	cellWidth = min(cellWidth, width)
+	perLine := max(width/max(cellWidth, 1), 1)
+	nodes := d.nodes
+	var hidden string
+	if len(nodes) > max(height-2, 1)*perLine {
+		// a line tells hidden nodes
+		maxCells := max(height-3, 1) * perLine
+		nodes = slices.DeleteFunc(slices.Clone(nodes), func(node string) bool {
+			return d.statuses[node] == task.NodeStatusOK
+		})
+		hidden = fmt.Sprintf("%d ok node(s)", len(d.nodes)-len(nodes))
+		if len(nodes) > maxCells {
+			hidden += fmt.Sprintf(" and %d other node(s)", len(nodes)-maxCells)
+			nodes = nodes[:maxCells]
+		}
+		hidden += " are hidden"
+	}
+
+	var lines []string
+	var line strings.Builder
+	for i, node := range nodes {
+		name := truncate(node, cellWidth-4)
+		fmt.Fprintf(&line, "%s %-*s  ", d.mark(d.statuses[node]), cellWidth-4, name)
+		if (i+1)%perLine == 0 || i == len(nodes)-1 {
+			lines = append(lines, strings.TrimRight(line.String(), " "))
+			line.Reset()
+		}
+	}
+	if hidden != "" {
+		lines = append(lines, truncate(hidden, width))
+	}
+	return append(lines, truncate(progress, width))
+}
+
+// truncate returns the text truncated to the width.
+func truncate(text string, width int) string {
+	if len(text) > width {
+		return text[:max(width, 0)]
+	}
+	return text
+}
+
+// progressBar returns the bar of nodes which are done, e.g. "[#####---------------]".
+func progressBar(statuses map[string]string, width int) string {
+	done := 0
+	for _, status := range statuses {
+		if status == task.NodeStatusOK || status == task.NodeStatusFailed {
+			done++
+		}
+	}
+	filled := 0
+	if len(statuses) > 0 {
+		filled = done * width / len(statuses)
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
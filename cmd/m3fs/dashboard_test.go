@@ -0,0 +1,143 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestDashboardSuite(t *testing.T) {
+	suiteRun(t, &dashboardSuite{})
+}
+
+type dashboardSuite struct {
+	Suite
+	out       *bytes.Buffer
+	now       time.Time
+	width     int
+	height    int
+	dashboard *dashboard
+}
+
+func (s *dashboardSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.out = new(bytes.Buffer)
+	s.now = time.Date(2025, 4, 19, 12, 0, 0, 0, time.UTC)
+	s.width, s.height = 46, 24
+	s.dashboard = &dashboard{
+		out:  s.out,
+		tty:  true,
+		size: func() (int, int) { return s.width, s.height },
+		now:  func() time.Time { return s.now },
+	}
+}
+
+// screen returns the output since the last clear of the dashboard.
+func (s *dashboardSuite) screen() string {
+	output := s.out.String()
+	if i := strings.LastIndex(output, "\x1b[J"); i >= 0 {
+		return output[i+len("\x1b[J"):]
+	}
+	return output
+}
+
+func (s *dashboardSuite) TestRender() {
+	d := s.dashboard
+	d.TaskStarted(3, 12, "DeployStorage")
+	s.Equal("task 3/12 DeployStorage, 0s elapsed\n", s.screen())
+
+	for _, node := range []string{"node1", "node2", "node3", "node4", "node5"} {
+		d.NodeChanged("DeployStorage", node, task.NodeStatusPending)
+	}
+	d.NodeChanged("DeployStorage", "node1", task.NodeStatusOK)
+	d.NodeChanged("DeployStorage", "node2", task.NodeStatusFailed)
+	d.NodeChanged("DeployStorage", "node3", task.NodeStatusRunning)
+	d.NodeChanged("OtherTask", "node4", task.NodeStatusOK)
+	s.now = s.now.Add(5 * time.Second)
+	d.NodeChanged("DeployStorage", "node4", task.NodeStatusRunning)
+	s.Equal("+ node1  x node2  * node3  * node4  . node5\n"+
+		"[########------------] task 3/12 DeployStorag\n", s.screen())
+	s.Equal(2, d.lines)
+
+	s.width = 20
+	d.NodeChanged("DeployStorage", "node5", task.NodeStatusRunning)
+	s.Equal("+ node1  x node2\n* node3  * node4\n* node5\n[########----------\n", s.screen())
+
+	_, err := d.Write([]byte("level=info msg=hello\n"))
+	s.NoError(err)
+	s.Contains(s.out.String(), "\x1b[4A\x1b[Jlevel=info msg=hello\n+ node1  x node2\n")
+
+	s.now = s.now.Add(time.Minute)
+	d.TaskEnded("DeployStorage", errors.New("node2 failed"))
+	s.Equal("x task 3/12 DeployStorage: 2/5 node(s) done, 1 ok, 1 failed, 3 running, 0 pending in 1m5s\n",
+		s.screen())
+	s.Zero(d.lines)
+
+	_, err = d.Write([]byte("level=info msg=bye\n"))
+	s.NoError(err)
+	s.True(strings.HasSuffix(s.out.String(), "pending in 1m5s\nlevel=info msg=bye\n"))
+}
+
+func (s *dashboardSuite) TestHideNodes() {
+	s.width, s.height = 30, 5
+	d := s.dashboard
+	d.TaskStarted(1, 1, "Task")
+	for _, node := range []string{"node1", "node2", "node3", "node4", "node5", "node6", "node7"} {
+		d.NodeChanged("Task", node, task.NodeStatusOK)
+	}
+	s.Equal("+ node1  + node2  + node3\n+ node4  + node5  + node6\n+ node7\n[####################] task 1\n",
+		s.screen())
+
+	s.height = 4
+	d.NodeChanged("Task", "node2", task.NodeStatusFailed)
+	s.Equal("x node2\n6 ok node(s) are hidden\n[####################] task 1\n", s.screen())
+
+	for _, node := range []string{"node3", "node4", "node5", "node6"} {
+		d.NodeChanged("Task", node, task.NodeStatusRunning)
+	}
+	s.Equal("x node2  * node3  * node4\n2 ok node(s) and 2 other node\n[########------------] task 1\n",
+		s.screen())
+}
+
+func (s *dashboardSuite) TestThrottle() {
+	d := s.dashboard
+	d.interval = 50 * time.Millisecond
+	d.now = time.Now
+	d.TaskStarted(1, 1, "Task")
+	d.NodeChanged("Task", "node1", task.NodeStatusRunning)
+	s.NotContains(s.out.String(), "node1")
+	s.Eventually(func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return strings.Contains(s.out.String(), "* node1")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *dashboardSuite) TestNotTerminal() {
+	d := s.dashboard
+	d.tty = false
+	d.TaskStarted(1, 1, "Task")
+	d.NodeChanged("Task", "node1", task.NodeStatusRunning)
+	d.NodeChanged("Task", "node1", task.NodeStatusOK)
+	d.TaskEnded("Task", nil)
+
+	s.Empty(s.out.String())
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/doctor"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	doctorFix          bool
+	doctorParallel     int
+	doctorMaxClockSkew time.Duration
+)
+
+var clusterDoctorCmd = &cli.Command{
+	Name:   "doctor",
+	Usage:  "Diagnose a 3fs cluster and print problems with suggested fixes",
+	Action: runDoctor,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.BoolFlag{
+			Name:        "fix",
+			Usage:       "Fix problems which are safe to fix automatically, e.g. start stopped service containers",
+			Destination: &doctorFix,
+		},
+		&cli.IntFlag{
+			Name:        "parallel",
+			Aliases:     []string{"p"},
+			Usage:       "Number of nodes checked at the same time",
+			Value:       10,
+			Destination: &doctorParallel,
+		},
+		&cli.DurationFlag{
+			Name:        "max-clock-skew",
+			Usage:       "Max allowed clock difference between nodes and the local node",
+			Value:       time.Second,
+			Destination: &doctorMaxClockSkew,
+		},
+		newOutputFlag(&outputFormat),
+	},
+}
+
+// defines exit codes of cluster doctor by the verdict.
+const (
+	doctorExitWarn = 1
+	doctorExitFail = 2
+)
+
+func runDoctor(ctx *cli.Context) error {
+	if doctorParallel <= 0 {
+		return errors.New("--parallel must be positive")
+	}
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if doctorFix {
+		lock, err := lockCluster(cfg, "cluster doctor --fix")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer unlockCluster(lock)
+	}
+
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+
+	d := doctor.NewDoctor(runner.Runtime)
+	d.Fix = doctorFix
+	d.Parallel = doctorParallel
+	d.MaxClockSkew = doctorMaxClockSkew
+	report, err := d.Run(ctx.Context)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = printDoctorReport(os.Stdout, report, format); err != nil {
+		return errors.Trace(err)
+	}
+
+	switch report.Verdict() {
+	case doctor.StatusFail:
+		return cli.Exit("cluster doctor found failed checks", doctorExitFail)
+	case doctor.StatusWarn:
+		return cli.Exit("cluster doctor found warnings", doctorExitWarn)
+	}
+	return nil
+}
+
+// doctorOutput is the structured output of cluster doctor.
+type doctorOutput struct {
+	Verdict doctor.Status   `json:"verdict"`
+	Results []doctor.Result `json:"results"`
+}
+
+func printDoctorReport(out io.Writer, report *doctor.Report, format string) error {
+	results := report.Results()
+	output := &doctorOutput{Verdict: report.Verdict(), Results: results}
+	return printOutput(out, format, output, func(out io.Writer) error {
+		return printDoctorReportTable(out, report, results)
+	})
+}
+
+func printDoctorReportTable(out io.Writer, report *doctor.Report, results []doctor.Result) error {
+	w := newTable(out, "STATUS", "CHECK", "NODE", "MESSAGE")
+	for _, result := range results {
+		node := result.Node
+		if node == "" {
+			node = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Status, result.Check, node, result.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+
+	fixes := 0
+	for _, result := range results {
+		if result.Fix == "" || result.Status == doctor.StatusPass {
+			continue
+		}
+		if fixes == 0 {
+			fmt.Fprintln(out, "\nSuggested fixes:")
+		}
+		fixes++
+		target := result.Check
+		if result.Node != "" {
+			target = fmt.Sprintf("%s on %s", result.Check, result.Node)
+		}
+		fmt.Fprintf(out, "%d. [%s] %s\n", fixes, target, result.Fix)
+	}
+
+	fmt.Fprintf(out, "\nVerdict: %s (%d failed, %d warnings, %d passed)\n", report.Verdict(),
+		report.Count(doctor.StatusFail), report.Count(doctor.StatusWarn), report.Count(doctor.StatusPass))
+	return nil
+}
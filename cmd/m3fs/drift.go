@@ -0,0 +1,437 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/doctor"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	driftInterval    time.Duration
+	driftWebhooks    cli.StringSlice
+	driftPushgateway string
+)
+
+// driftHTTPTimeout limits posting results to a webhook or the pushgateway, so that an
+// unresponsive endpoint doesn't stall checks.
+const driftHTTPTimeout = 30 * time.Second
+
+// driftPushgatewayJob is the job of metrics pushed to the pushgateway.
+const driftPushgatewayJob = "m3fs_drift"
+
+// defines checks of drift findings
+const (
+	// driftCheckConfigFile is a config file on a node which differs from the rendered one.
+	driftCheckConfigFile = "config-file"
+	// driftCheckVersionSkew is a service container running an image other than the config's.
+	driftCheckVersionSkew = doctor.CheckVersionSkew
+	// driftCheckConfigDrift is the config diverging from the recorded state of the cluster.
+	driftCheckConfigDrift = doctor.CheckConfigDrift
+	// driftCheckStateDrift is a service container running an image other than the recorded one.
+	driftCheckStateDrift = doctor.CheckStateDrift
+)
+
+var driftChecks = []string{driftCheckConfigFile, driftCheckVersionSkew, driftCheckConfigDrift, driftCheckStateDrift}
+
+var clusterMonitorDriftCmd = &cli.Command{
+	Name: "monitor-drift",
+	Usage: "Check config files and versions of a 3fs cluster periodically until interrupted, " +
+		"drift is reported to webhooks and the pushgateway when it appears or is resolved",
+	Action: monitorDrift,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.DurationFlag{
+			Name:        "interval",
+			Usage:       "Interval between checks",
+			Value:       10 * time.Minute,
+			Destination: &driftInterval,
+		},
+		&cli.StringSliceFlag{
+			Name:        "webhook",
+			Usage:       "URL which changes of drift are posted to as JSON, can be repeated",
+			Destination: &driftWebhooks,
+		},
+		&cli.StringFlag{
+			Name:        "pushgateway",
+			Usage:       "URL of the Prometheus pushgateway which metrics of drift are pushed to after each check",
+			Destination: &driftPushgateway,
+		},
+	},
+}
+
+// driftFinding is a drift found by a check.
+type driftFinding struct {
+	Check string `json:"check"`
+	// Node is empty for drift of the whole cluster.
+	Node    string `json:"node,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f driftFinding) String() string {
+	if f.Node == "" {
+		return fmt.Sprintf("%s: %s", f.Check, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Check, f.Node, f.Message)
+}
+
+// driftRound is the result of a round of checks.
+type driftRound struct {
+	Time     time.Time
+	Findings []driftFinding
+	// Unreachable are nodes which failed to be checked, their findings are unknown.
+	Unreachable []string
+}
+
+// driftChange is the change of drift after a round, which is posted to webhooks.
+type driftChange struct {
+	Cluster  string         `json:"cluster"`
+	Time     time.Time      `json:"time"`
+	Text     string         `json:"text"`
+	Appeared []driftFinding `json:"appeared,omitempty"`
+	Resolved []driftFinding `json:"resolved,omitempty"`
+	// Findings are all drift known after the round.
+	Findings    []driftFinding `json:"findings"`
+	Unreachable []string       `json:"unreachableNodes,omitempty"`
+}
+
+// driftTracker tracks drift across rounds. Findings of unreachable nodes are kept until
+// the nodes are checked again, so that a transient failure doesn't resolve them.
+type driftTracker struct {
+	cluster     string
+	findings    []driftFinding
+	unreachable []string
+}
+
+// update updates findings by the round, it returns the change, or nil if nothing changed.
+func (t *driftTracker) update(round *driftRound) *driftChange {
+	change := &driftChange{Cluster: t.cluster, Time: round.Time, Unreachable: round.Unreachable}
+	var findings []driftFinding
+	for _, finding := range t.findings {
+		switch {
+		case slices.Contains(round.Findings, finding):
+		case finding.Node != "" && slices.Contains(round.Unreachable, finding.Node):
+			findings = append(findings, finding)
+		default:
+			change.Resolved = append(change.Resolved, finding)
+		}
+	}
+	for _, finding := range round.Findings {
+		if !slices.Contains(t.findings, finding) {
+			change.Appeared = append(change.Appeared, finding)
+		}
+		findings = append(findings, finding)
+	}
+	unreachableChanged := !slices.Equal(t.unreachable, round.Unreachable)
+	t.findings, t.unreachable = findings, round.Unreachable
+	if len(change.Appeared) == 0 && len(change.Resolved) == 0 && !unreachableChanged {
+		return nil
+	}
+	change.Findings = slices.Clone(findings)
+	change.Text = change.text()
+	return change
+}
+
+func (c *driftChange) text() string {
+	var parts []string
+	if len(c.Appeared) > 0 {
+		parts = append(parts, fmt.Sprintf("%d drift appeared", len(c.Appeared)))
+	}
+	if len(c.Resolved) > 0 {
+		parts = append(parts, fmt.Sprintf("%d drift resolved", len(c.Resolved)))
+	}
+	if len(c.Unreachable) > 0 {
+		parts = append(parts, fmt.Sprintf("nodes %s are unreachable", strings.Join(c.Unreachable, ",")))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "all nodes are reachable again")
+	}
+	return fmt.Sprintf("m3fs cluster %s: %s, %d drift in total", c.Cluster, strings.Join(parts, ", "),
+		len(c.Findings))
+}
+
+// roundManagers creates a manager of each node once in a round, so that checks of the
+// round share the connection to a node, connections are closed after the round.
+type roundManagers struct {
+	runtime *task.Runtime
+
+	mu       sync.Mutex
+	managers map[string]*external.Manager
+}
+
+func (m *roundManagers) get(node config.Node, logger log.Interface) (*external.Manager, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if em, ok := m.managers[node.Name]; ok {
+		return em, nil
+	}
+	em, err := m.runtime.NodeManager(node, logger)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	m.managers[node.Name] = em
+	return em, nil
+}
+
+func (m *roundManagers) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, em := range m.managers {
+		em.Close()
+	}
+	clear(m.managers)
+}
+
+func monitorDrift(ctx *cli.Context) error {
+	if driftInterval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if state == nil {
+		return errors.Errorf("no recorded state of cluster %s, only clusters created by m3fs can be monitored",
+			cfg.Name)
+	}
+	files, err := renderConfigFiles(cfg, "", true)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	httpClient, err := external.NewHTTPClient(&cfg.TLS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	httpClient.Timeout = driftHTTPTimeout
+
+	// checks are read-only, so the cluster isn't locked
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+	direct, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	direct.Init()
+	managers := &roundManagers{runtime: direct.Runtime, managers: make(map[string]*external.Manager)}
+	runner.Runtime.NewNodeManager = managers.get
+
+	c, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	tracker := &driftTracker{cluster: cfg.Name}
+	logrus.Infof("Monitoring drift of cluster %s every %s, interrupt to stop", cfg.Name, driftInterval)
+	for {
+		round, err := checkDrift(c, runner.Runtime, files)
+		managers.close()
+		if c.Err() != nil {
+			logrus.Infof("Stopped monitoring drift of cluster %s", cfg.Name)
+			return nil
+		}
+		if err != nil {
+			logrus.Warnf("Failed to check drift of cluster %s: %v", cfg.Name, err)
+		} else {
+			reportDriftRound(c, httpClient, tracker, round)
+		}
+		select {
+		case <-c.Done():
+			logrus.Infof("Stopped monitoring drift of cluster %s", cfg.Name)
+			return nil
+		case <-time.After(driftInterval):
+		}
+	}
+}
+
+// checkDrift verifies config files and runs the doctor to find drift of the cluster.
+func checkDrift(ctx context.Context, r *task.Runtime, files []*nodeRenderedFile) (*driftRound, error) {
+	round := &driftRound{Time: time.Now()}
+	unreachable := make(map[string]bool)
+	for _, check := range verifyConfigFiles(ctx, r, r.Cfg.Nodes, files, false) {
+		switch check.status {
+		case configFileDrifted, configFileMissing:
+			round.Findings = append(round.Findings, driftFinding{
+				Check:   driftCheckConfigFile,
+				Node:    check.Node,
+				Message: fmt.Sprintf("%s is %s", check.Path, check.status),
+			})
+		case configFileFailed:
+			unreachable[check.Node] = true
+		}
+	}
+
+	d := doctor.NewDoctor(r)
+	report, err := d.Run(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, result := range report.Results() {
+		switch {
+		case result.Check == doctor.CheckConnectivity && result.Status != doctor.StatusPass:
+			unreachable[result.Node] = true
+		case slices.Contains(driftChecks, result.Check) && result.Status != doctor.StatusPass:
+			round.Findings = append(round.Findings, driftFinding{
+				Check:   result.Check,
+				Node:    result.Node,
+				Message: result.Message,
+			})
+		}
+	}
+	for _, node := range r.Cfg.Nodes {
+		if unreachable[node.Name] {
+			round.Unreachable = append(round.Unreachable, node.Name)
+		}
+	}
+	return round, nil
+}
+
+// reportDriftRound logs the round, posts the change of drift to webhooks and pushes
+// metrics of the round to the pushgateway.
+func reportDriftRound(ctx context.Context, client *http.Client, tracker *driftTracker, round *driftRound) {
+	if len(round.Unreachable) > 0 {
+		logrus.Warnf("Nodes %s are unreachable, their drift is checked again in the next round",
+			strings.Join(round.Unreachable, ","))
+	}
+	change := tracker.update(round)
+	if change == nil {
+		logrus.Infof("No change of drift, %d drift in total", len(tracker.findings))
+	} else {
+		for _, finding := range change.Appeared {
+			logrus.Warnf("Drift appeared: %s", finding)
+		}
+		for _, finding := range change.Resolved {
+			logrus.Infof("Drift resolved: %s", finding)
+		}
+		for _, webhook := range driftWebhooks.Value() {
+			if err := postDriftChange(ctx, client, webhook, change); err != nil {
+				logrus.Warnf("Failed to post drift to webhook %s: %v", webhookHost(webhook), err)
+			}
+		}
+	}
+	if driftPushgateway != "" {
+		if err := pushDriftMetrics(ctx, client, driftPushgateway, tracker, round.Time); err != nil {
+			logrus.Warnf("Failed to push metrics of drift to %s: %v", driftPushgateway, err)
+		}
+	}
+}
+
+// webhookHost returns the scheme and host of the webhook for logs, paths of webhooks
+// often carry tokens.
+func webhookHost(webhook string) string {
+	u, err := url.Parse(webhook)
+	if err != nil || u.Host == "" {
+		return "(invalid URL)"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func postDriftChange(ctx context.Context, client *http.Client, webhook string, change *driftChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(sendDriftRequest(ctx, client, http.MethodPost, webhook, "application/json", data))
+}
+
+// driftMetrics renders metrics of drift tracked by the tracker in the Prometheus text format.
+func driftMetrics(tracker *driftTracker, checkTime time.Time) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString("# TYPE m3fs_drift_findings gauge\n")
+	for _, check := range driftChecks {
+		count := 0
+		for _, finding := range tracker.findings {
+			if finding.Check == check {
+				count++
+			}
+		}
+		fmt.Fprintf(buf, "m3fs_drift_findings{check=%q} %d\n", check, count)
+	}
+	buf.WriteString("# TYPE m3fs_drift_unreachable_nodes gauge\n")
+	fmt.Fprintf(buf, "m3fs_drift_unreachable_nodes %d\n", len(tracker.unreachable))
+	buf.WriteString("# TYPE m3fs_drift_last_check_timestamp_seconds gauge\n")
+	fmt.Fprintf(buf, "m3fs_drift_last_check_timestamp_seconds %d\n", checkTime.Unix())
+	return buf.Bytes()
+}
+
+// pushDriftMetrics replaces metrics of the cluster in the pushgateway.
+func pushDriftMetrics(ctx context.Context, client *http.Client, pushgateway string, tracker *driftTracker,
+	checkTime time.Time) error {
+
+	target := fmt.Sprintf("%s/metrics/job/%s/cluster/%s", strings.TrimRight(pushgateway, "/"),
+		driftPushgatewayJob, url.PathEscape(tracker.cluster))
+	return errors.Trace(sendDriftRequest(ctx, client, http.MethodPut, target,
+		"text/plain; version=0.0.4", driftMetrics(tracker, checkTime)))
+}
+
+func sendDriftRequest(ctx context.Context, client *http.Client, method, target, contentType string,
+	data []byte) error {
+
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := client.Do(req)
+	if urlErr, ok := err.(*url.Error); ok {
+		// the error mustn't reveal the URL
+		return errors.Trace(urlErr.Err)
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
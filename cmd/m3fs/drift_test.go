@@ -0,0 +1,123 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDriftSuite(t *testing.T) {
+	suiteRun(t, &driftSuite{})
+}
+
+type driftSuite struct {
+	Suite
+}
+
+func (s *driftSuite) TestTracker() {
+	tracker := &driftTracker{cluster: "open3fs"}
+	file := driftFinding{Check: driftCheckConfigFile, Node: "node1", Message: "/root/3fs/meta.toml is drifted"}
+	skew := driftFinding{Check: driftCheckVersionSkew, Node: "node2", Message: "meta runs open3fs/3fs:20250101"}
+	state := driftFinding{Check: driftCheckConfigDrift, Message: "3fs version is 20250410 in the config"}
+
+	s.Nil(tracker.update(&driftRound{}))
+
+	change := tracker.update(&driftRound{Findings: []driftFinding{file, skew}})
+	s.Equal([]driftFinding{file, skew}, change.Appeared)
+	s.Empty(change.Resolved)
+	s.Equal("m3fs cluster open3fs: 2 drift appeared, 2 drift in total", change.Text)
+	s.Nil(tracker.update(&driftRound{Findings: []driftFinding{file, skew}}))
+
+	// findings of the unreachable node are kept
+	change = tracker.update(&driftRound{Findings: []driftFinding{state}, Unreachable: []string{"node2"}})
+	s.Equal([]driftFinding{state}, change.Appeared)
+	s.Equal([]driftFinding{file}, change.Resolved)
+	s.Equal([]driftFinding{skew, state}, change.Findings)
+	s.Equal("m3fs cluster open3fs: 1 drift appeared, 1 drift resolved, nodes node2 are unreachable, "+
+		"2 drift in total", change.Text)
+
+	change = tracker.update(&driftRound{Findings: []driftFinding{skew, state}})
+	s.Empty(change.Appeared)
+	s.Empty(change.Resolved)
+	s.Equal("m3fs cluster open3fs: all nodes are reachable again, 2 drift in total", change.Text)
+
+	change = tracker.update(&driftRound{})
+	s.Equal([]driftFinding{skew, state}, change.Resolved)
+	s.Empty(change.Findings)
+}
+
+func (s *driftSuite) TestMetrics() {
+	tracker := &driftTracker{cluster: "open3fs", unreachable: []string{"node3"}, findings: []driftFinding{
+		{Check: driftCheckConfigFile, Node: "node1"},
+		{Check: driftCheckConfigFile, Node: "node2"},
+		{Check: driftCheckVersionSkew, Node: "node2"},
+	}}
+
+	s.Equal(`# TYPE m3fs_drift_findings gauge
+m3fs_drift_findings{check="config-file"} 2
+m3fs_drift_findings{check="version-skew"} 1
+m3fs_drift_findings{check="config-drift"} 0
+m3fs_drift_findings{check="state-drift"} 0
+# TYPE m3fs_drift_unreachable_nodes gauge
+m3fs_drift_unreachable_nodes 1
+# TYPE m3fs_drift_last_check_timestamp_seconds gauge
+m3fs_drift_last_check_timestamp_seconds 1700000000
+`, string(driftMetrics(tracker, time.Unix(1700000000, 0))))
+}
+
+func (s *driftSuite) TestSend() {
+	var (
+		method, path string
+		body         []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		body, _ = io.ReadAll(r.Body)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	tracker := &driftTracker{cluster: "open3fs"}
+
+	s.NoError(pushDriftMetrics(s.Ctx(), server.Client(), server.URL+"/", tracker, time.Unix(0, 0)))
+	s.Equal(http.MethodPut, method)
+	s.Equal("/metrics/job/m3fs_drift/cluster/open3fs", path)
+	s.Contains(string(body), "m3fs_drift_unreachable_nodes 0\n")
+
+	change := tracker.update(&driftRound{Findings: []driftFinding{{Check: driftCheckConfigDrift, Message: "x"}}})
+	s.NoError(postDriftChange(s.Ctx(), server.Client(), server.URL+"/hook", change))
+	s.Equal(http.MethodPost, method)
+	var posted driftChange
+	s.NoError(json.Unmarshal(body, &posted))
+	s.Equal(change.Appeared, posted.Appeared)
+	s.Equal("open3fs", posted.Cluster)
+
+	s.ErrorContains(postDriftChange(s.Ctx(), server.Client(), server.URL+"/fail", change),
+		"unexpected status 500 Internal Server Error")
+	err := postDriftChange(s.Ctx(), server.Client(), "http://127.0.0.1:1/token", change)
+	s.Error(err)
+	s.NotContains(err.Error(), "token")
+}
+
+func (s *driftSuite) TestWebhookHost() {
+	s.Equal("https://hooks.example.com", webhookHost("https://hooks.example.com/services/T000/B000/secret"))
+	s.Equal("(invalid URL)", webhookHost("hooks"))
+}
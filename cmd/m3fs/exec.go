@@ -0,0 +1,223 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+	"github.com/open3fs/m3fs/pkg/utils"
+)
+
+var (
+	execNodes    string
+	execParallel int
+	execSudo     bool
+)
+
+var clusterExecCmd = &cli.Command{
+	Name:      "exec",
+	Usage:     "Run an ad-hoc command on nodes of a 3fs cluster",
+	ArgsUsage: "-- <shell command>",
+	Action:    execOnCluster,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:    "nodes",
+			Aliases: []string{"n"},
+			Usage: "Comma separated node names, hosts, glob patterns of them or service names " +
+				"to select nodes (default is all nodes)",
+			Destination: &execNodes,
+		},
+		&cli.IntFlag{
+			Name:        "parallel",
+			Aliases:     []string{"p"},
+			Usage:       "Number of nodes running the command at the same time",
+			Value:       10,
+			Destination: &execParallel,
+		},
+		&cli.BoolFlag{
+			Name:        "sudo",
+			Usage:       "Run the command with sudo",
+			Destination: &execSudo,
+		},
+	},
+}
+
+// execResult is the result of running the command on a node.
+type execResult struct {
+	node     config.Node
+	output   string
+	exitCode int
+	err      error
+}
+
+// selectNodes returns nodes matching the selector, which is comma separated
+// node names, hosts, glob patterns of them or service names.
+func selectNodes(cfg *config.Config, selector string) ([]config.Node, error) {
+	if selector == "" {
+		return cfg.Nodes, nil
+	}
+
+	selected := utils.NewSet[string]()
+	for _, item := range strings.Split(selector, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		matched := false
+		serviceNodes := utils.NewSet(cfg.Services.ServiceNodes(config.ServiceType(item))...)
+		for _, node := range cfg.Nodes {
+			if !serviceNodes.Contains(node.Name) {
+				nameMatched, err := path.Match(item, node.Name)
+				if err != nil {
+					return nil, errors.Annotatef(err, "invalid node selector %s", item)
+				}
+				hostMatched, _ := path.Match(item, node.Host)
+				if !nameMatched && !hostMatched {
+					continue
+				}
+			}
+			matched = true
+			selected.Add(node.Name)
+		}
+		if !matched {
+			return nil, errors.Errorf("no node matches %s", item)
+		}
+	}
+
+	nodes := make([]config.Node, 0, selected.Len())
+	for _, node := range cfg.Nodes {
+		if selected.Contains(node.Name) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func execOnCluster(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errors.New("command is required")
+	}
+	if execParallel <= 0 {
+		return errors.New("--parallel must be positive")
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	nodes, err := selectNodes(cfg, execNodes)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// the run is recorded only for the command journal
+	if err = runner.SetRun("cluster exec", runID); err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+
+	cmdline := strings.Join(ctx.Args().Slice(), " ")
+	var mu sync.Mutex
+	results := make(map[string]*execResult, len(nodes))
+	execOnNode := func(c context.Context, node config.Node) error {
+		result := &execResult{node: node}
+		logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+		em, err := runner.Runtime.NodeManager(node, logger)
+		if err == nil {
+			result.output, err = runShellCommand(c, em, cmdline)
+		}
+		result.err = err
+		result.exitCode = external.ExitCode(err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		results[node.Name] = result
+		printExecResult(result)
+		return nil
+	}
+
+	size := min(execParallel, len(nodes))
+	workerPool := common.NewWorkerPool(execOnNode, size)
+	workerPool.Start(ctx.Context)
+	for _, node := range nodes {
+		workerPool.Add(node)
+	}
+	workerPool.Join()
+
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tHOST\tEXIT CODE")
+	for _, node := range nodes {
+		result := results[node.Name]
+		if result.err != nil {
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", node.Name, node.Host, result.exitCode)
+	}
+	if err = w.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	if failed > 0 {
+		return errors.Errorf("command failed on %d of %d nodes", failed, len(nodes))
+	}
+	return nil
+}
+
+// runShellCommand runs the command line by shell on the node of the manager.
+func runShellCommand(ctx context.Context, em *external.Manager, cmdline string) (string, error) {
+	if execSudo {
+		// both runners run the command line by shell with sudo
+		return em.Runner.Exec(ctx, cmdline)
+	}
+	if _, ok := em.Runner.(*external.LocalRunner); ok {
+		// the local runner runs the command without shell
+		return em.Runner.NonSudoExec(ctx, "/bin/bash", "-c", cmdline)
+	}
+	return em.Runner.NonSudoExec(ctx, cmdline)
+}
+
+func printExecResult(result *execResult) {
+	fmt.Printf("==> %s (%s) exit code %d\n", result.node.Name, result.node.Host, result.exitCode)
+	if result.output != "" {
+		fmt.Print(strings.TrimRight(result.output, "\n") + "\n")
+	}
+	if result.err != nil && result.exitCode < 0 {
+		fmt.Printf("error: %v\n", result.err)
+	}
+}
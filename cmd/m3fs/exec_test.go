@@ -0,0 +1,78 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+func TestSelectNodesSuite(t *testing.T) {
+	suiteRun(t, &selectNodesSuite{})
+}
+
+type selectNodesSuite struct {
+	Suite
+	cfg *config.Config
+}
+
+func (s *selectNodesSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.cfg = &config.Config{
+		Nodes: []config.Node{
+			{Name: "node1", Host: "192.168.1.1"},
+			{Name: "node2", Host: "192.168.1.2"},
+			{Name: "storage1", Host: "192.168.2.1"},
+		},
+		Services: config.Services{
+			Storage: config.Storage{Nodes: []string{"node2", "storage1"}},
+		},
+	}
+}
+
+func (s *selectNodesSuite) nodeNames(nodes []config.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+func (s *selectNodesSuite) TestAll() {
+	nodes, err := selectNodes(s.cfg, "")
+	s.NoError(err)
+	s.Equal([]string{"node1", "node2", "storage1"}, s.nodeNames(nodes))
+}
+
+func (s *selectNodesSuite) TestSelect() {
+	nodes, err := selectNodes(s.cfg, "storage1, node*")
+	s.NoError(err)
+	s.Equal([]string{"node1", "node2", "storage1"}, s.nodeNames(nodes))
+
+	nodes, err = selectNodes(s.cfg, "192.168.1.*")
+	s.NoError(err)
+	s.Equal([]string{"node1", "node2"}, s.nodeNames(nodes))
+
+	nodes, err = selectNodes(s.cfg, "storage")
+	s.NoError(err)
+	s.Equal([]string{"node2", "storage1"}, s.nodeNames(nodes))
+}
+
+func (s *selectNodesSuite) TestNoMatch() {
+	_, err := selectNodes(s.cfg, "node1,node3")
+	s.Error(err)
+	s.Contains(err.Error(), "no node matches node3")
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// refreshFacts gathers facts of nodes again instead of using the cached ones.
+var refreshFacts bool
+
+var factsNodes string
+
+// factsParallel is the number of nodes whose facts are gathered at the same time.
+const factsParallel = 10
+
+var clusterFactsCmd = &cli.Command{
+	Name:   "facts",
+	Usage:  "Print facts of nodes of a 3fs cluster, which are gathered once and cached in the work dir",
+	Action: printClusterFacts,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.StringFlag{
+			Name:    "nodes",
+			Aliases: []string{"n"},
+			Usage: "Comma separated node names, hosts, glob patterns of them or service names " +
+				"to select nodes (default is all nodes)",
+			Destination: &factsNodes,
+		},
+		func() cli.Flag {
+			flag := newOutputFlag(&outputFormat)
+			flag.Value = outputFormatJSON
+			return flag
+		}(),
+	},
+}
+
+func printClusterFacts(ctx *cli.Context) error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	nodes, err := selectNodes(cfg, factsNodes)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+	runner.Runtime.RefreshFacts = refreshFacts
+
+	facts, errs := gatherFacts(ctx.Context, runner.Runtime, nodes)
+	if err = printFacts(os.Stdout, facts, format); err != nil {
+		return errors.Trace(err)
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("failed to gather facts of %d node(s):\n%s", len(errs), strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// gatherFacts returns facts of the nodes in the order of them, and errors of nodes whose
+// facts fail to be gathered.
+func gatherFacts(ctx context.Context, r *task.Runtime, nodes []config.Node) ([]*task.NodeFacts, []string) {
+	var (
+		mu       sync.Mutex
+		gathered = make(map[string]*task.NodeFacts, len(nodes))
+		failed   = make(map[string]error)
+	)
+	gatherNode := func(ctx context.Context, node config.Node) error {
+		logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+		em, err := r.NodeManager(node, logger)
+		var facts *task.NodeFacts
+		if err == nil {
+			facts, err = r.NodeFacts(ctx, em, node)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed[node.Name] = err
+		} else {
+			gathered[node.Name] = facts
+		}
+		return nil
+	}
+	workerPool := common.NewWorkerPool(gatherNode, max(min(factsParallel, len(nodes)), 1))
+	workerPool.Start(ctx)
+	for _, node := range nodes {
+		workerPool.Add(node)
+	}
+	workerPool.Join()
+
+	var (
+		facts []*task.NodeFacts
+		errs  []string
+	)
+	for _, node := range nodes {
+		if f, ok := gathered[node.Name]; ok {
+			facts = append(facts, f)
+		} else if err, ok := failed[node.Name]; ok {
+			errs = append(errs, fmt.Sprintf("%s: %v", node.Name, err))
+		}
+	}
+	return facts, errs
+}
+
+func printFacts(out io.Writer, facts []*task.NodeFacts, format string) error {
+	return printOutput(out, format, facts, func(out io.Writer) error {
+		w := newTable(out, "NODE", "HOST", "OS", "KERNEL", "ARCH", "CPUS", "MEMORY", "DISKS", "RUNTIME", "TOOLS")
+		for _, f := range facts {
+			var disks []string
+			for _, disk := range f.Disks {
+				disks = append(disks, fmt.Sprintf("%s(%s)", disk.Name, common.FormatBytes(int64(disk.Size))))
+			}
+			tools := strings.Join(f.Tools, ",")
+			if tools == "" {
+				tools = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", f.Node, f.Host, f.OS, f.Kernel, f.Arch,
+				f.CPUs, common.FormatBytes(int64(f.MemoryBytes)), strings.Join(disks, ","), f.ContainerRuntime, tools)
+		}
+		return errors.Trace(w.Flush())
+	})
+}
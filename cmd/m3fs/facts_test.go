@@ -0,0 +1,100 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestFactsSuite(t *testing.T) {
+	suiteRun(t, &factsSuite{})
+}
+
+type factsSuite struct {
+	Suite
+
+	script  *externaltest.Script
+	runtime *task.Runtime
+	nodes   []config.Node
+}
+
+func (s *factsSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.script = externaltest.NewScript()
+	s.nodes = []config.Node{{Name: "node1", Host: "192.168.1.1"}, {Name: "node2", Host: "192.168.1.2"}}
+	cfg := &config.Config{Name: "open3fs", ContainerRuntime: config.ContainerRuntimeDocker}
+	s.runtime = &task.Runtime{
+		Cfg:            cfg,
+		Services:       &cfg.Services,
+		WorkDir:        s.T().TempDir(),
+		LocalEm:        s.script.Manager("local", log.Logger),
+		NewNodeManager: s.script.NodeManager,
+	}
+	s.script.On(`^cat /etc/os-release$`, externaltest.Response{Output: "PRETTY_NAME=\"Ubuntu 22.04.3 LTS\"\n"})
+	s.script.On(`^nproc$`, externaltest.Response{Output: "16\n"})
+	s.script.On(`^cat /proc/meminfo$`, externaltest.Response{Output: "MemTotal:       65536000 kB\n"})
+	s.script.On(`^cat /proc/sys`, externaltest.Response{Output: "1048576\n"})
+	s.script.On(`^lsblk`, externaltest.Response{Output: "nvme0n1 disk 549755813888\n"})
+}
+
+func (s *factsSuite) TestGatherFacts() {
+	s.script.OnNode("node2", `^uname`, externaltest.Response{Err: errors.New("connection reset")})
+	s.script.On(`^uname`, externaltest.Response{Output: "5.15.0-91-generic x86_64\n"})
+
+	facts, errs := gatherFacts(s.Ctx(), s.runtime, s.nodes)
+	s.Len(facts, 1)
+	s.Equal("node1", facts[0].Node)
+	s.Len(errs, 1)
+	s.Contains(errs[0], "node2: ")
+	s.Contains(errs[0], "connection reset")
+
+	buf := new(bytes.Buffer)
+	s.NoError(printFacts(buf, facts, outputFormatTable))
+	s.Regexp(`node1\s+192.168.1.1\s+Ubuntu 22.04.3 LTS\s+5.15.0-91-generic\s+x86_64\s+16\s+62.5 GiB\s+`+
+		`nvme0n1\(512.0 GiB\)\s+docker\s+systemctl`, buf.String())
+}
+
+func (s *factsSuite) newRuntime(refresh bool) *task.Runtime {
+	return &task.Runtime{
+		Cfg:            s.runtime.Cfg,
+		Services:       s.runtime.Services,
+		WorkDir:        s.runtime.WorkDir,
+		NewNodeManager: s.script.NodeManager,
+		RefreshFacts:   refresh,
+	}
+}
+
+func (s *factsSuite) TestGatherCachedFacts() {
+	s.script.On(`^uname`, externaltest.Response{Output: "5.15.0-91-generic x86_64\n"})
+	_, errs := gatherFacts(s.Ctx(), s.runtime, s.nodes)
+	s.Empty(errs)
+	s.Equal(2, s.script.Count("", `^uname`))
+
+	facts, errs := gatherFacts(s.Ctx(), s.newRuntime(false), s.nodes)
+	s.Empty(errs)
+	s.Len(facts, 2)
+	s.Equal(2, s.script.Count("", `^uname`))
+
+	_, errs = gatherFacts(s.Ctx(), s.newRuntime(true), s.nodes)
+	s.Empty(errs)
+	s.Equal(4, s.script.Count("", `^uname`))
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+var (
+	// overrideFreezeReason is the reason given by --override-freeze.
+	overrideFreezeReason string
+	// freezeOverride is the reason of the overridden freeze of this command, it's recorded
+	// in records of runs.
+	freezeOverride string
+)
+
+// checkDeploymentWindows refuses the command if the time is in a deployment freeze of the
+// config, unless the freeze is overridden with a reason and confirmed.
+func checkDeploymentWindows(cfg *config.Config, command string, now time.Time) error {
+	freezeErr := cfg.DeploymentWindows.Check(now)
+	if freezeErr == nil {
+		return nil
+	}
+	reason := strings.TrimSpace(overrideFreezeReason)
+	if reason == "" {
+		return errors.Errorf("%s is refused: %v, pass --override-freeze with a reason to run it anyway",
+			command, freezeErr)
+	}
+	logrus.Warnf("%s overrides the freeze: %v, reason: %s", command, freezeErr, reason)
+	c := confirmation{prompt: fmt.Sprintf("Run %s in the deployment freeze?", command)}
+	if err := c.require("override of the freeze"); err != nil {
+		return errors.Trace(err)
+	}
+	freezeOverride = reason
+	return nil
+}
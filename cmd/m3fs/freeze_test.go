@@ -0,0 +1,126 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestFreezeSuite(t *testing.T) {
+	suiteRun(t, &freezeSuite{})
+}
+
+type freezeSuite struct {
+	Suite
+	cfg *config.Config
+	now time.Time
+}
+
+func (s *freezeSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.cfg = config.NewConfigWithDefaults()
+	s.cfg.DeploymentWindows = config.DeploymentWindows{
+		Timezone: "UTC",
+		Denied:   []config.TimeWindow{{Name: "weekend", Start: "Fri 17:00", End: "Mon 06:00"}},
+	}
+	// 2025-04-19 is a Saturday.
+	s.now = time.Date(2025, 4, 19, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *freezeSuite) TearDownTest() {
+	overrideFreezeReason = ""
+	freezeOverride = ""
+	assumeYes = false
+}
+
+func (s *freezeSuite) TestAllowed() {
+	s.NoError(checkDeploymentWindows(s.cfg, "cluster create", s.now.Add(-24*time.Hour)))
+	s.Empty(freezeOverride)
+}
+
+func (s *freezeSuite) TestRefused() {
+	err := checkDeploymentWindows(s.cfg, "cluster create", s.now)
+	s.ErrorContains(err, "cluster create is refused: deployment is frozen by denied window weekend")
+	s.ErrorContains(err, "pass --override-freeze with a reason")
+	s.Empty(freezeOverride)
+}
+
+func (s *freezeSuite) TestOverridden() {
+	overrideFreezeReason = " hotfix of INC-42 "
+	assumeYes = true
+
+	s.NoError(checkDeploymentWindows(s.cfg, "cluster upgrade", s.now))
+	s.Equal("hotfix of INC-42", freezeOverride)
+}
+
+func (s *freezeSuite) TestOverrideNotConfirmed() {
+	overrideFreezeReason = "hotfix"
+	isTerminal := stdinIsTerminal
+	defer func() { stdinIsTerminal = isTerminal }()
+	stdinIsTerminal = func() bool { return false }
+
+	err := checkDeploymentWindows(s.cfg, "cluster upgrade", s.now)
+	s.ErrorContains(err, "pass --yes to confirm it")
+	s.Empty(freezeOverride)
+}
+
+func (s *freezeSuite) TestCleanRefused() {
+	dir := s.T().TempDir()
+	path := filepath.Join(dir, "cluster.yml")
+	s.NoError(os.WriteFile(path, []byte(`
+name: "open3fs"
+networkType: "RDMA"
+nodes:
+  - name: node1
+    host: "192.168.1.1"
+    username: root
+services:
+  fdb:
+    nodes: [node1]
+  clickhouse:
+    nodes: [node1]
+  monitor:
+    nodes: [node1]
+  mgmtd:
+    nodes: [node1]
+  meta:
+    nodes: [node1]
+  storage:
+    nodes: [node1]
+  client:
+    nodes: [node1]
+    hostMountpoint: /mnt/3fs
+deploymentWindows:
+  denied:
+    - name: migration
+      start: "2000-01-01 00:00"
+      end: "2999-01-01 00:00"
+`), 0644))
+	defer func(path, dir string) { configFilePath, workDir = path, dir }(configFilePath, workDir)
+	configFilePath, workDir = path, dir
+	ctx := cli.NewContext(cli.NewApp(), flag.NewFlagSet("test", flag.ContinueOnError), nil)
+
+	s.ErrorContains(cleanCluster(ctx), "cluster clean is refused")
+	s.NoFileExists(task.LockFilePath(dir, "open3fs"))
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	phaseGate  bool
+	untilPhase string
+)
+
+// gateApprovalInterval is the interval of checking the approval file of a gate.
+var gateApprovalInterval = 2 * time.Second
+
+// gateApprovalFilePath returns the path of the file approving the phase of the run.
+func gateApprovalFilePath(runDir string, phase task.Phase) string {
+	return filepath.Join(runDir, fmt.Sprintf("approve-%s", phase))
+}
+
+// newPhaseGate returns the gate which asks for confirmation before the next phase. In
+// non-interactive mode it waits until the approval file of the phase is created in the
+// run dir, e.g. by a CI job after an approval. --yes doesn't approve phases.
+func newPhaseGate(runDir string) task.PhaseGate {
+	return func(ctx context.Context, completed, next task.Phase) error {
+		if stdinIsTerminal() {
+			prompt := fmt.Sprintf("Phase %s completed, continue with phase %s?", completed, next)
+			ok, err := confirmation{prompt: prompt}.read()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if !ok {
+				return errors.Errorf("phase %s isn't approved", next)
+			}
+			return nil
+		}
+
+		approvalFile := gateApprovalFilePath(runDir, next)
+		logrus.Infof("Phase %s completed, create %s to continue with phase %s", completed, approvalFile, next)
+		ticker := time.NewTicker(gateApprovalInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := os.Stat(approvalFile); err == nil {
+				logrus.Infof("Phase %s is approved", next)
+				return nil
+			} else if !os.IsNotExist(err) {
+				return errors.Annotatef(err, "check approval file %s", approvalFile)
+			}
+			select {
+			case <-ctx.Done():
+				return errors.Annotatef(ctx.Err(), "wait for approval of phase %s", next)
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// setupPhases sets the gate and the phase to stop after of the runner by flags.
+func setupPhases(runner *task.Runner, gate bool) error {
+	if gate {
+		runner.SetPhaseGate(newPhaseGate(runner.Runtime.RunDir))
+	}
+	if untilPhase != "" {
+		phase, err := task.ParsePhase(untilPhase)
+		if err != nil {
+			return errors.Annotate(err, "--until")
+		}
+		runner.SetUntil(phase)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestPhaseGateSuite(t *testing.T) {
+	suiteRun(t, &phaseGateSuite{})
+}
+
+type phaseGateSuite struct {
+	Suite
+	runDir         string
+	isTerminal     func() bool
+	approvalPeriod time.Duration
+}
+
+func (s *phaseGateSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.runDir = s.T().TempDir()
+	s.isTerminal = stdinIsTerminal
+	s.approvalPeriod = gateApprovalInterval
+	gateApprovalInterval = 10 * time.Millisecond
+}
+
+func (s *phaseGateSuite) TearDownTest() {
+	stdinIsTerminal = s.isTerminal
+	gateApprovalInterval = s.approvalPeriod
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+func (s *phaseGateSuite) TestConfirm() {
+	stdinIsTerminal = func() bool { return true }
+	stdinReader = bufio.NewReader(strings.NewReader("y\nn\n"))
+	gate := newPhaseGate(s.runDir)
+
+	s.NoError(gate(s.Ctx(), task.PhasePrepare, task.PhaseDeploy))
+	err := gate(s.Ctx(), task.PhaseDeploy, task.PhaseVerify)
+	s.Error(err)
+	s.Contains(err.Error(), "phase verify isn't approved")
+}
+
+func (s *phaseGateSuite) TestConfirmIgnoresYes() {
+	assumeYes = true
+	defer func() { assumeYes = false }()
+	stdinIsTerminal = func() bool { return true }
+	stdinReader = bufio.NewReader(strings.NewReader("n\n"))
+
+	err := newPhaseGate(s.runDir)(s.Ctx(), task.PhasePrepare, task.PhaseDeploy)
+	s.Error(err)
+	s.Contains(err.Error(), "phase deploy isn't approved")
+}
+
+func (s *phaseGateSuite) TestApprovalFile() {
+	stdinIsTerminal = func() bool { return false }
+	gate := newPhaseGate(s.runDir)
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = os.WriteFile(gateApprovalFilePath(s.runDir, task.PhaseDeploy), nil, 0644)
+	}()
+
+	s.NoError(gate(s.Ctx(), task.PhasePrepare, task.PhaseDeploy))
+}
+
+func (s *phaseGateSuite) TestApprovalFileCanceled() {
+	stdinIsTerminal = func() bool { return false }
+	ctx, cancel := context.WithTimeout(s.Ctx(), 30*time.Millisecond)
+	defer cancel()
+
+	err := newPhaseGate(s.runDir)(ctx, task.PhasePrepare, task.PhaseDeploy)
+	s.Error(err)
+	s.Contains(err.Error(), "wait for approval of phase deploy")
+}
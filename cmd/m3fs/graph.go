@@ -0,0 +1,69 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var graphFormat string
+
+var clusterGraphCmd = &cli.Command{
+	Name:   "graph",
+	Usage:  "Print the dependency graph of tasks of a cluster command as Graphviz dot or Mermaid",
+	Action: printClusterGraph,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "command",
+			Usage:       "Cluster command whose tasks are drawn: create, delete or prepare",
+			Value:       "create",
+			Destination: &tasksCommand,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Format of the graph: dot or mermaid",
+			Value:       string(task.GraphFormatDot),
+			Destination: &graphFormat,
+		},
+	},
+}
+
+func printClusterGraph(ctx *cli.Context) error {
+	format, err := task.ParseGraphFormat(graphFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := newCommandTasksRunner(cfg, tasksCommand)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(task.WriteGraph(os.Stdout, runner.Explain(), format))
+}
@@ -0,0 +1,234 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/discovery"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	importOutput string
+	importForce  bool
+)
+
+var clusterImportCmd = &cli.Command{
+	Name: "import",
+	Usage: "Import a 3fs cluster which isn't deployed by m3fs, services are discovered on nodes of the config " +
+		"to generate a complete config and record the cluster state",
+	Action: importCluster,
+	Flags: cordonFlags(
+		&cli.StringFlag{
+			Name:        "out",
+			Usage:       "Path of the generated config file",
+			Destination: &importOutput,
+			Required:    true,
+		},
+		&cli.BoolFlag{
+			Name:        "force",
+			Usage:       "Overwrite the output file and the recorded state of a cluster managed by m3fs",
+			Destination: &importForce,
+		},
+	),
+}
+
+func importCluster(ctx *cli.Context) error {
+	if _, err := os.Stat(importOutput); err == nil && !importForce {
+		return errors.Errorf("output path %s already exists", importOutput)
+	}
+	partial, err := readClusterConfig(configFilePath, "")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if dir := workDirOverride(); dir != "" {
+		partial.WorkDir = dir
+	}
+	probeCfg, err := readClusterConfig(configFilePath, "")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	probeAllNodes(probeCfg)
+	if probeCfg, err = setupClusterConfig(probeCfg); err != nil {
+		return errors.Trace(err)
+	}
+
+	lock, err := lockCluster(probeCfg, "cluster import")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer unlockCluster(lock)
+	state, err := task.LoadClusterState(probeCfg.WorkDir, probeCfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if state != nil && !importForce {
+		return errors.Errorf("cluster %s is already managed by m3fs, its state is recorded in %s", probeCfg.Name,
+			task.ClusterStateFilePath(probeCfg.WorkDir, probeCfg.Name))
+	}
+
+	runner, err := task.NewRunner(probeCfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.SetFreezeOverride(freezeOverride)
+	runner.Init()
+	runner.Runtime.RefreshFacts = refreshFacts
+	nodes, err := discovery.NewDiscoverer(runner.Runtime).Discover(ctx.Context)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var errs []string
+	for _, node := range nodes {
+		if node.Err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", node.Node, node.Err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("failed to discover %d node(s):\n%s", len(errs), strings.Join(errs, "\n"))
+	}
+	if err = printDiscoveredNodes(os.Stdout, nodes); err != nil {
+		return errors.Trace(err)
+	}
+
+	result := discovery.CompleteConfig(partial, probeCfg, nodes)
+	format := configFormatOf(importOutput)
+	data, err := encodeClusterConfig(partial, format)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// validate a copy, because validation expands node groups and fills derived fields
+	cfg, err := decodeClusterConfig(data, format)
+	if err == nil {
+		cfg, err = setupClusterConfig(cfg)
+	}
+	if validationErrs, ok := errors.Cause(err).(config.ValidationErrors); ok {
+		for _, validationErr := range validationErrs {
+			result.Discrepancies = append(result.Discrepancies,
+				&discovery.Discrepancy{Key: validationErr.Key, Message: validationErr.Message})
+		}
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	if format == configFormatYAML {
+		data = append(importedConfigHeader(result.Discrepancies), data...)
+	}
+	if err = os.WriteFile(importOutput, data, 0644); err != nil {
+		return errors.Annotatef(err, "write config file %s", importOutput)
+	}
+
+	if len(result.Discrepancies) > 0 {
+		fmt.Printf("\nDiscrepancies which need manual input:\n")
+		for _, d := range result.Discrepancies {
+			fmt.Printf("  %s\n", d)
+		}
+		return errors.Errorf("config is written to %s, but the cluster state isn't recorded because of "+
+			"%d discrepancies, resolve them in the config or on nodes and import it again by -c %s",
+			importOutput, len(result.Discrepancies), importOutput)
+	}
+	if runner, err = task.NewRunner(cfg); err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+	if state, err = discovery.NewClusterState(runner.Runtime, result, nodes); err != nil {
+		return errors.Annotate(err, "generate cluster state")
+	}
+	if err = task.SaveClusterState(cfg.WorkDir, state); err != nil {
+		return errors.Trace(err)
+	}
+	fmt.Printf("\nCluster %s is imported, config is written to %s and the cluster state to %s.\n"+
+		"Review the config, then manage the cluster with it, e.g. by cluster status and cluster upgrade.\n",
+		cfg.Name, importOutput, task.ClusterStateFilePath(cfg.WorkDir, cfg.Name))
+	return nil
+}
+
+// probeAllNodes makes services without nodes in the config run on all nodes, so that all
+// nodes are probed for them.
+func probeAllNodes(cfg *config.Config) {
+	groups := make([]string, len(cfg.NodeGroups))
+	for i, nodeGroup := range cfg.NodeGroups {
+		groups[i] = nodeGroup.Name
+	}
+	for _, service := range config.AllServiceTypes {
+		if !cfg.Services.Enabled(service) || len(cfg.Services.ServiceNodes(service)) > 0 ||
+			len(cfg.Services.ServiceNodeGroups(service)) > 0 {
+			continue
+		}
+		for _, node := range cfg.Nodes {
+			_ = cfg.Services.AddServiceNode(service, node.Name)
+		}
+		setServiceNodeGroups(&cfg.Services, service, groups)
+	}
+}
+
+func setServiceNodeGroups(services *config.Services, service config.ServiceType, groups []string) {
+	switch service {
+	case config.ServiceFdb:
+		services.Fdb.NodeGroups = groups
+	case config.ServiceClickhouse:
+		services.Clickhouse.NodeGroups = groups
+	case config.ServiceMonitor:
+		services.Monitor.NodeGroups = groups
+	case config.ServiceMgmtd:
+		services.Mgmtd.NodeGroups = groups
+	case config.ServiceMeta:
+		services.Meta.NodeGroups = groups
+	case config.ServiceStorage:
+		services.Storage.NodeGroups = groups
+	case config.ServiceClient:
+		services.Client.NodeGroups = groups
+	}
+}
+
+// importedConfigHeader returns comments of the generated config, which list discrepancies
+// needing manual input.
+func importedConfigHeader(discrepancies []*discovery.Discrepancy) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString("# Generated by m3fs cluster import from services discovered on nodes.\n")
+	if len(discrepancies) > 0 {
+		buf.WriteString("# Discrepancies which need manual input:\n")
+		for _, d := range discrepancies {
+			fmt.Fprintf(buf, "#   %s\n", d)
+		}
+	}
+	return buf.Bytes()
+}
+
+func printDiscoveredNodes(out io.Writer, nodes []*discovery.Node) error {
+	w := newTable(out, "NODE", "HOST", "SERVICE", "CONTAINER", "STATUS", "IMAGE", "PORTS")
+	for _, node := range nodes {
+		if len(node.Services) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t-\n", node.Node, node.Host)
+		}
+		for _, s := range node.Services {
+			ports := make([]string, len(s.Ports))
+			for i, port := range s.Ports {
+				ports[i] = fmt.Sprint(port)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", node.Node, node.Host, s.Service, s.Container, s.Status,
+				s.Image, strings.Join(ports, ","))
+		}
+	}
+	return errors.Trace(w.Flush())
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/discovery"
+)
+
+func TestImportSuite(t *testing.T) {
+	suiteRun(t, &importSuite{})
+}
+
+type importSuite struct {
+	Suite
+}
+
+func (s *importSuite) TestProbeAllNodes() {
+	cfg := config.NewConfigWithDefaults()
+	cfg.Nodes = []config.Node{{Name: "node1"}, {Name: "node2"}}
+	cfg.NodeGroups = []config.NodeGroup{{Name: "group1"}}
+	cfg.Services.Mgmtd.Nodes = []string{"node1"}
+	cfg.Services.Meta.NodeGroups = []string{"group1"}
+	cfg.Services.SetEnabled(config.ServiceMonitor, false)
+
+	probeAllNodes(cfg)
+
+	s.Equal([]string{"node1", "node2"}, cfg.Services.Storage.Nodes)
+	s.Equal([]string{"group1"}, cfg.Services.Storage.NodeGroups)
+	s.Equal([]string{"node1"}, cfg.Services.Mgmtd.Nodes)
+	s.Empty(cfg.Services.Mgmtd.NodeGroups)
+	s.Empty(cfg.Services.Meta.Nodes)
+	s.Empty(cfg.Services.Monitor.Nodes)
+}
+
+func (s *importSuite) TestImportedConfigHeader() {
+	s.Equal("# Generated by m3fs cluster import from services discovered on nodes.\n",
+		string(importedConfigHeader(nil)))
+	s.Equal("# Generated by m3fs cluster import from services discovered on nodes.\n"+
+		"# Discrepancies which need manual input:\n"+
+		"#   workDir: work dir is /root but services are placed in /opt/3fs on node1\n",
+		string(importedConfigHeader([]*discovery.Discrepancy{
+			{Key: "workDir", Message: "work dir is /root but services are placed in /opt/3fs on node1"},
+		})))
+}
+
+func (s *importSuite) TestPrintDiscoveredNodes() {
+	buf := new(bytes.Buffer)
+	s.NoError(printDiscoveredNodes(buf, []*discovery.Node{
+		{Node: "node1", Host: "10.0.0.1", Services: []*discovery.Service{{
+			Service: config.ServiceMgmtd, Container: "3fs-mgmtd", Status: "running",
+			Image: "open3fs/3fs:20250410", Ports: []int{8000, 9000},
+		}}},
+		{Node: "node2", Host: "10.0.0.2"},
+	}))
+	s.Contains(buf.String(), "mgmtd")
+	s.Contains(buf.String(), "8000,9000")
+	s.Contains(buf.String(), "node2")
+}
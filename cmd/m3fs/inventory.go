@@ -0,0 +1,63 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/inventory"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+const (
+	inventoryCacheFileName = "inventory.json"
+	inventoryFetchTimeout  = 30 * time.Second
+)
+
+// mergeInventory merges nodes fetched from the inventory into the config.
+func mergeInventory(cfg *config.Config) error {
+	if cfg.Name == "" {
+		return errors.New("name is required")
+	}
+	dir := workDirOverride()
+	if dir == "" {
+		dir = cfg.WorkDir
+	}
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	client, err := external.NewHTTPClient(&cfg.TLS)
+	if err != nil {
+		return errors.Annotate(err, "create http client")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), inventoryFetchTimeout)
+	defer cancel()
+	provider := &inventory.HTTPProvider{URL: inventoryURL, Client: client}
+	cacheFile := filepath.Join(task.ClusterStateDir(dir, cfg.Name), inventoryCacheFileName)
+	nodes, err := inventory.Load(ctx, provider, cacheFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotate(inventory.Merge(cfg, nodes), "merge inventory")
+}
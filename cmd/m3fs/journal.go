@@ -0,0 +1,205 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	journalRunID  string
+	journalNode   string
+	journalTask   string
+	journalFailed bool
+	journalJSON   bool
+)
+
+var clusterJournalCmd = &cli.Command{
+	Name:   "journal",
+	Usage:  "Show commands run on nodes of a 3fs cluster by a run",
+	Action: showClusterJournal,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.StringFlag{
+			Name:        "run",
+			Usage:       "ID of the run (default is the latest run)",
+			Destination: &journalRunID,
+		},
+		&cli.StringFlag{
+			Name:        "node",
+			Usage:       "Only show commands run on the node",
+			Destination: &journalNode,
+		},
+		&cli.StringFlag{
+			Name:        "task",
+			Usage:       "Only show commands run by the task",
+			Destination: &journalTask,
+		},
+		&cli.BoolFlag{
+			Name:        "failed",
+			Usage:       "Only show failed commands",
+			Destination: &journalFailed,
+		},
+		&cli.BoolFlag{
+			Name:        "json",
+			Usage:       "Print entries as JSON lines",
+			Destination: &journalJSON,
+		},
+		newOutputFlag(&outputFormat),
+	},
+}
+
+// latestJournalRun returns id of the latest run which has a command journal.
+func latestJournalRun(workDir, clusterName string) (string, error) {
+	runsDir := task.RunsDir(workDir, clusterName)
+	entries, err := os.ReadDir(runsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.Annotatef(err, "read runs directory %s", runsDir)
+	}
+	var (
+		latestID   string
+		latestTime time.Time
+	)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := os.Stat(task.JournalFilePath(filepath.Join(runsDir, entry.Name())))
+		if err != nil {
+			continue
+		}
+		if latestID == "" || info.ModTime().After(latestTime) {
+			latestID = entry.Name()
+			latestTime = info.ModTime()
+		}
+	}
+	if latestID == "" {
+		return "", errors.Errorf("no run of cluster %s has a command journal", clusterName)
+	}
+	return latestID, nil
+}
+
+// filterJournal returns entries run on the node by the task, all nodes or tasks
+// match if they're empty.
+func filterJournal(entries []*external.JournalEntry, node, taskName string, failedOnly bool,
+) []*external.JournalEntry {
+
+	filtered := make([]*external.JournalEntry, 0, len(entries))
+	for _, entry := range entries {
+		if node != "" && entry.Node != node {
+			continue
+		}
+		if taskName != "" && entry.Task != taskName {
+			continue
+		}
+		if failedOnly && entry.ExitCode == 0 {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+func showClusterJournal(ctx *cli.Context) error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	id := journalRunID
+	if id == "" {
+		if id, err = latestJournalRun(cfg.WorkDir, cfg.Name); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	entries, err := external.ReadJournal(task.JournalFilePath(task.RunDir(cfg.WorkDir, cfg.Name, id)))
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return errors.Errorf("run %s has no command journal", id)
+		}
+		return errors.Trace(err)
+	}
+	entries = filterJournal(entries, journalNode, journalTask, journalFailed)
+
+	// secrets of the current config are redacted as well, in case they're changed
+	// after the run
+	external.RegisterSecrets(cfg.Secrets()...)
+	for _, entry := range entries {
+		entry.Command = external.Redact(entry.Command)
+	}
+	if journalJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err = encoder.Encode(entry); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	}
+	return errors.Trace(printJournal(os.Stdout, entries, format))
+}
+
+func printJournal(out io.Writer, entries []*external.JournalEntry, format string) error {
+	return printOutput(out, format, entries, func(out io.Writer) error {
+		return printJournalTable(out, entries)
+	})
+}
+
+func printJournalTable(out io.Writer, entries []*external.JournalEntry) error {
+	w := newTable(out, "TIME", "NODE", "TASK", "EXIT CODE", "DURATION", "COMMAND")
+	for _, entry := range entries {
+		taskName := entry.Task
+		if taskName == "" {
+			taskName = "-"
+		}
+		command := entry.Command
+		if entry.Sudo {
+			command = "sudo " + command
+		}
+		if entry.FreezeOverride != "" {
+			command = "(override deployment freeze: " + entry.FreezeOverride + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", entry.Time.Local().Format(time.DateTime),
+			entry.Node, taskName, entry.ExitCode,
+			(time.Duration(entry.DurationMs) * time.Millisecond).String(), command)
+	}
+	return errors.Trace(w.Flush())
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+func TestFilterJournalSuite(t *testing.T) {
+	suiteRun(t, &filterJournalSuite{})
+}
+
+type filterJournalSuite struct {
+	Suite
+	entries []*external.JournalEntry
+}
+
+func (s *filterJournalSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.entries = []*external.JournalEntry{
+		{Node: "node1", Task: "task1", Command: "ls"},
+		{Node: "node2", Task: "task1", Command: "false", ExitCode: 1},
+		{Node: "node1", Task: "task2", Command: "exit 2", ExitCode: 2},
+	}
+}
+
+func (s *filterJournalSuite) commands(entries []*external.JournalEntry) []string {
+	commands := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		commands = append(commands, entry.Command)
+	}
+	return commands
+}
+
+func (s *filterJournalSuite) TestNoFilter() {
+	s.Equal([]string{"ls", "false", "exit 2"}, s.commands(filterJournal(s.entries, "", "", false)))
+}
+
+func (s *filterJournalSuite) TestFilter() {
+	s.Equal([]string{"ls", "exit 2"}, s.commands(filterJournal(s.entries, "node1", "", false)))
+	s.Equal([]string{"ls", "false"}, s.commands(filterJournal(s.entries, "", "task1", false)))
+	s.Equal([]string{"false", "exit 2"}, s.commands(filterJournal(s.entries, "", "", true)))
+	s.Equal([]string{"exit 2"}, s.commands(filterJournal(s.entries, "node1", "task2", true)))
+}
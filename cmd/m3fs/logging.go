@@ -0,0 +1,72 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	mlog "github.com/open3fs/m3fs/pkg/log"
+)
+
+// defines formats of logs.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// defines timezones of timestamps of logs.
+const (
+	logTimezoneLocal = "local"
+	logTimezoneUTC   = "utc"
+)
+
+// newLogOptions returns options of the logger by global flags.
+func newLogOptions() (mlog.Options, error) {
+	opts := mlog.Options{
+		Level:      logrus.InfoLevel,
+		Timestamps: logTimestamps,
+		Caller:     logCaller,
+	}
+	if debug {
+		opts.Level = logrus.DebugLevel
+	}
+	switch strings.ToLower(logFormat) {
+	case logFormatText, "":
+	case logFormatJSON:
+		opts.JSON = true
+	default:
+		return opts, errors.Errorf("invalid log format %s, must be text or json", logFormat)
+	}
+	switch strings.ToLower(logTimezone) {
+	case logTimezoneLocal, "":
+	case logTimezoneUTC:
+		opts.UTC = true
+	default:
+		return opts, errors.Errorf("invalid log timezone %s, must be local or utc", logTimezone)
+	}
+	if logFile != "" {
+		// the file is kept open until m3fs exits
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return opts, errors.Annotate(err, "open log file")
+		}
+		opts.File = f
+	}
+	return opts, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	mlog "github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestLoggingSuite(t *testing.T) {
+	suiteRun(t, &loggingSuite{})
+}
+
+type loggingSuite struct {
+	Suite
+}
+
+func (s *loggingSuite) TearDownTest() {
+	debug, logFormat, logTimestamps, logCaller, logTimezone = false, logFormatText, false, false, logTimezoneLocal
+	logFile = ""
+}
+
+func (s *loggingSuite) TestNewLogOptions() {
+	opts, err := newLogOptions()
+	s.NoError(err)
+	s.Equal(mlog.Options{Level: logrus.InfoLevel}, opts)
+
+	debug, logFormat, logTimestamps, logCaller, logTimezone = true, "JSON", true, true, "UTC"
+	opts, err = newLogOptions()
+	s.NoError(err)
+	s.Equal(mlog.Options{Level: logrus.DebugLevel, JSON: true, Timestamps: true, Caller: true, UTC: true}, opts)
+
+	logFormat = "xml"
+	_, err = newLogOptions()
+	s.Error(err, "invalid log format xml, must be text or json")
+
+	logFormat, logTimezone = logFormatText, "Asia/Shanghai"
+	_, err = newLogOptions()
+	s.Error(err, "invalid log timezone Asia/Shanghai, must be local or utc")
+}
+
+func (s *loggingSuite) TestLogFile() {
+	logFile = filepath.Join(s.T().TempDir(), "m3fs.log")
+	opts, err := newLogOptions()
+	s.NoError(err)
+	f, ok := opts.File.(*os.File)
+	s.True(ok)
+	defer f.Close()
+	s.Equal(logFile, f.Name())
+
+	logFile = filepath.Join(s.T().TempDir(), "missing", "m3fs.log")
+	_, err = newLogOptions()
+	s.ErrorContains(err, "open log file")
+}
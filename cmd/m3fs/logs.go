@@ -0,0 +1,430 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/collect"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	collectLogsNodes       string
+	collectLogsSince       string
+	collectLogsIncremental bool
+	collectLogsParallel    int
+	collectLogsMaxSize     int
+
+	logsService string
+	logsNodes   string
+	logsFollow  bool
+	logsTail    int
+	logsSource  string
+)
+
+// defines sources of logs of services.
+const (
+	logsSourceContainer = "container"
+	logsSourceFile      = "file"
+)
+
+var clusterCollectLogsCmd = &cli.Command{
+	Name:   "collect-logs",
+	Usage:  "Collect logs of services from nodes of a 3fs cluster into a gzipped tarball",
+	Action: collectClusterLogs,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:    "nodes",
+			Aliases: []string{"n"},
+			Usage: "Comma separated node names, hosts, glob patterns of them or service names " +
+				"to select nodes (default is all nodes)",
+			Destination: &collectLogsNodes,
+		},
+		&cli.StringFlag{
+			Name:        "since",
+			Usage:       "Only collect logs since the duration before now like 2h, or the timestamp",
+			Destination: &collectLogsSince,
+		},
+		&cli.BoolFlag{
+			Name:        "incremental",
+			Aliases:     []string{"i"},
+			Usage:       "Only collect logs after the last collection of each node",
+			Destination: &collectLogsIncremental,
+		},
+		&cli.IntFlag{
+			Name:        "parallel",
+			Aliases:     []string{"p"},
+			Usage:       "Number of nodes collected at the same time",
+			Value:       10,
+			Destination: &collectLogsParallel,
+		},
+		&cli.IntFlag{
+			Name:        "max-log-size",
+			Usage:       "Max MiB collected of each log, only the last bytes of a larger log are collected",
+			Value:       collect.DefaultMaxLogBytes >> 20,
+			Destination: &collectLogsMaxSize,
+		},
+		&cli.StringFlag{
+			Name:        "out",
+			Usage:       "Output path (default is m3fs-logs-<cluster>-<time>.tar.gz)",
+			Destination: &outputPath,
+		},
+	},
+}
+
+func collectClusterLogs(ctx *cli.Context) error {
+	if collectLogsParallel <= 0 {
+		return errors.New("--parallel must be positive")
+	}
+	if collectLogsMaxSize <= 0 {
+		return errors.New("--max-log-size must be positive")
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	now := time.Now()
+	var since time.Time
+	if collectLogsSince != "" {
+		if since, err = collect.ParseSince(collectLogsSince, now); err != nil {
+			return errors.Annotate(err, "--since")
+		}
+	}
+	nodes, err := selectNodes(cfg, collectLogsNodes)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	output := outputPath
+	if output == "" {
+		output = fmt.Sprintf("m3fs-logs-%s-%s.tar.gz", cfg.Name, now.Format("20060102-150405"))
+	}
+	if _, err = os.Stat(output); err == nil {
+		return errors.Errorf("output path %s already exists", output)
+	} else if !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+
+	f, err := os.Create(output)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	collector := collect.NewCollector(runner.Runtime)
+	collector.Nodes = nodes
+	collector.Since = since
+	collector.Incremental = collectLogsIncremental
+	collector.Parallel = collectLogsParallel
+	collector.MaxLogBytes = int64(collectLogsMaxSize) << 20
+	results, err := collector.Collect(ctx.Context, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(output)
+		return errors.Trace(err)
+	}
+
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tFILES\tBYTES\tERROR")
+	for _, result := range results {
+		errMsg := "-"
+		if result.Err != nil {
+			failed++
+			errMsg = result.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", result.Node, result.Files, result.Bytes, errMsg)
+	}
+	if err = w.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	fmt.Printf("Logs are collected into %s\n", output)
+	if failed > 0 {
+		return errors.Errorf("failed to collect logs of %d of %d nodes", failed, len(results))
+	}
+	return nil
+}
+
+var clusterLogsCmd = &cli.Command{
+	Name:   "logs",
+	Usage:  "Print or follow logs of a service on nodes of a 3fs cluster",
+	Action: showClusterLogs,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "service",
+			Aliases:     []string{"s"},
+			Usage:       "Service whose logs are printed",
+			Destination: &logsService,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:    "nodes",
+			Aliases: []string{"node", "n"},
+			Usage: "Comma separated node names, hosts or glob patterns of them to select nodes " +
+				"(default is all nodes of the service)",
+			Destination: &logsNodes,
+		},
+		&cli.BoolFlag{
+			Name:        "follow",
+			Aliases:     []string{"f"},
+			Usage:       "Follow new lines of logs until interrupted by Ctrl-C",
+			Destination: &logsFollow,
+		},
+		&cli.IntFlag{
+			Name:        "tail",
+			Usage:       "Number of last lines of logs printed of each node, all lines are printed if it's negative",
+			Value:       100,
+			Destination: &logsTail,
+		},
+		&cli.StringFlag{
+			Name: "source",
+			Usage: "Source of logs: container for stdout and stderr of the container, " +
+				"or file for log files in the log dir of the service",
+			Value:       logsSourceContainer,
+			Destination: &logsSource,
+		},
+		&cli.BoolFlag{
+			Name:        "no-color",
+			Usage:       "Disable colored node prefixes of lines",
+			Destination: &noColorOutput,
+		},
+	},
+}
+
+// nodePrefixColors are colors of node prefixes of lines, nodes take them in turn.
+var nodePrefixColors = []color.Attribute{
+	color.FgHiCyan, color.FgHiGreen, color.FgHiYellow, color.FgHiBlue, color.FgHiMagenta, color.FgHiRed,
+}
+
+// nodePrefixes returns prefixes of lines of the nodes, which are aligned node names
+// colored in turn if colored is set.
+func nodePrefixes(nodes []config.Node, colored bool) map[string]string {
+	width := 0
+	for _, node := range nodes {
+		width = max(width, len(node.Name))
+	}
+	prefixes := make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		prefix := fmt.Sprintf("%-*s |", width, node.Name)
+		if colored {
+			c := color.New(nodePrefixColors[i%len(nodePrefixColors)])
+			c.EnableColor()
+			prefix = c.Sprint(prefix)
+		}
+		prefixes[node.Name] = prefix + " "
+	}
+	return prefixes
+}
+
+// linePrefixWriter writes lines prefixed into the output shared by writers of nodes,
+// a partial line is buffered until it completes, so lines of nodes never interleave.
+type linePrefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, errors.Trace(err)
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes the buffered partial line.
+func (w *linePrefixWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+	return errors.Trace(err)
+}
+
+func (w *linePrefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return errors.Trace(err)
+}
+
+// serviceLogNodes returns selected nodes running the service.
+func serviceLogNodes(cfg *config.Config, service config.ServiceType, selector string) ([]config.Node, error) {
+	if !slices.Contains(config.AllServiceTypes, service) {
+		return nil, errors.Errorf("invalid service %s, must be one of %v", service, config.AllServiceTypes)
+	}
+	serviceNodes := cfg.Services.ServiceNodes(service)
+	if len(serviceNodes) == 0 {
+		return nil, errors.Errorf("no node runs service %s", service)
+	}
+	selected, err := selectNodes(cfg, selector)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	nodes := slices.DeleteFunc(selected, func(node config.Node) bool {
+		return !slices.Contains(serviceNodes, node.Name)
+	})
+	if len(nodes) == 0 {
+		return nil, errors.Errorf("no selected node runs service %s", service)
+	}
+	return nodes, nil
+}
+
+func showClusterLogs(ctx *cli.Context) error {
+	if logsSource != logsSourceContainer && logsSource != logsSourceFile {
+		return errors.Errorf("invalid source of logs: %s", logsSource)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	nodes, err := serviceLogNodes(cfg, config.ServiceType(logsService), logsNodes)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+
+	// Ctrl-C stops following logs and closes commands on nodes
+	c, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	failed := streamServiceLogs(c, runner.Runtime, config.ServiceType(logsService), nodes, os.Stdout)
+	if failed > 0 {
+		return errors.Errorf("failed to print logs of %d of %d nodes", failed, len(nodes))
+	}
+	return nil
+}
+
+// streamServiceLogs writes logs of the service on the nodes into out with node
+// prefixes, it returns the number of nodes failed.
+func streamServiceLogs(ctx context.Context, r *task.Runtime, service config.ServiceType,
+	nodes []config.Node, out io.Writer) int {
+
+	prefixes := nodePrefixes(nodes, !noColorOutput && !color.NoColor)
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed int
+	)
+	for _, node := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := &linePrefixWriter{mu: &mu, out: out, prefix: prefixes[node.Name]}
+			err := streamNodeLogs(ctx, r, service, node, w)
+			if flushErr := w.Flush(); err == nil {
+				err = flushErr
+			}
+			if err != nil {
+				logrus.Errorf("Failed to print logs of %s on node %s: %v", service, node.Name, err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return failed
+}
+
+func streamNodeLogs(ctx context.Context, r *task.Runtime, service config.ServiceType,
+	node config.Node, w io.Writer) error {
+
+	logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+	em, err := r.NodeManager(node, logger)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if logsSource == logsSourceContainer {
+		runtime, err := r.ContainerRuntime(ctx, em, node)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = em.UseContainerRuntime(runtime); err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(em.Docker.StreamLogs(ctx, w, r.Services.ContainerName(service), logsTail, logsFollow))
+	}
+
+	streamer, ok := em.Runner.(external.StreamRunner)
+	if !ok {
+		return errors.New("the runner doesn't support streaming outputs of commands")
+	}
+	args := []string{"-n", "+1"}
+	if logsTail >= 0 {
+		args[1] = strconv.Itoa(logsTail)
+	}
+	if logsFollow {
+		args = append(args, "-F")
+	}
+	args = append(args, path.Join(collect.LogDir(r.WorkDir, service), "*.log"), "2>&1")
+	err = streamer.Stream(ctx, w, "tail", args...)
+	if ctx.Err() != nil {
+		// following logs stops when the context is done
+		return nil
+	}
+	return errors.Trace(err)
+}
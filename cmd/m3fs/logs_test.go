@@ -0,0 +1,114 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestClusterLogsSuite(t *testing.T) {
+	suiteRun(t, &clusterLogsSuite{})
+}
+
+type clusterLogsSuite struct {
+	Suite
+	cfg     *config.Config
+	script  *externaltest.Script
+	runtime *task.Runtime
+}
+
+func (s *clusterLogsSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.cfg = config.NewConfigWithDefaults()
+	s.cfg.WorkDir = "/opt/3fs"
+	s.cfg.ContainerRuntime = config.ContainerRuntimeDocker
+	s.cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "192.168.1.1"},
+		{Name: "storage1", Host: "192.168.1.2"},
+	}
+	s.cfg.Services.Storage.Nodes = []string{"node1", "storage1"}
+	s.cfg.Services.Meta.Nodes = []string{"node1"}
+	s.script = externaltest.NewScript()
+	s.runtime = &task.Runtime{Cfg: s.cfg, Services: &s.cfg.Services, WorkDir: s.cfg.WorkDir}
+	s.runtime.NewNodeManager = s.script.NodeManager
+
+	logsFollow = false
+	logsTail = 100
+	logsSource = logsSourceContainer
+	noColorOutput = true
+}
+
+func (s *clusterLogsSuite) TestServiceLogNodes() {
+	nodes, err := serviceLogNodes(s.cfg, config.ServiceStorage, "")
+	s.NoError(err)
+	s.Len(nodes, 2)
+
+	nodes, err = serviceLogNodes(s.cfg, config.ServiceStorage, "storage*")
+	s.NoError(err)
+	s.Len(nodes, 1)
+	s.Equal("storage1", nodes[0].Name)
+
+	_, err = serviceLogNodes(s.cfg, config.ServiceMeta, "storage1")
+	s.Error(err, "no selected node runs service meta")
+	_, err = serviceLogNodes(s.cfg, config.ServiceClient, "")
+	s.Error(err, "no node runs service client")
+	_, err = serviceLogNodes(s.cfg, "unknown", "")
+	s.Error(err)
+}
+
+func (s *clusterLogsSuite) TestContainerLogs() {
+	s.script.OnNode("node1", `^docker logs --tail 100 3fs-storage`, externaltest.Response{Output: "line1\nline2\n"})
+	s.script.OnNode("storage1", `^docker logs`, externaltest.Response{Output: "partial"})
+
+	out := new(bytes.Buffer)
+	s.Equal(0, streamServiceLogs(context.Background(), s.runtime, config.ServiceStorage, s.cfg.Nodes, out))
+	s.Contains(out.String(), "node1    | line1\nnode1    | line2\n")
+	s.Contains(out.String(), "storage1 | partial\n")
+	s.Equal(1, s.script.Count("storage1", `^docker logs --tail 100 3fs-storage 2>&1$`))
+}
+
+func (s *clusterLogsSuite) TestFollowLogFiles() {
+	logsFollow = true
+	logsTail = -1
+	logsSource = logsSourceFile
+	s.script.OnNode("storage1", `^tail`, externaltest.Response{ExitCode: 1})
+
+	out := new(bytes.Buffer)
+	s.Equal(1, streamServiceLogs(context.Background(), s.runtime, config.ServiceStorage, s.cfg.Nodes, out))
+	s.Equal(1, s.script.Count("node1", `^tail -n \+1 -F /opt/3fs/storage/log/\*\.log 2>&1$`))
+}
+
+func (s *clusterLogsSuite) TestLinePrefixWriter() {
+	prefixes := nodePrefixes(s.cfg.Nodes, true)
+	s.Contains(prefixes["node1"], "node1    |")
+	s.NotEqual("node1    | ", prefixes["node1"])
+
+	out := new(bytes.Buffer)
+	w := &linePrefixWriter{mu: new(sync.Mutex), out: out, prefix: "n1 | "}
+	_, err := w.Write([]byte("a\nb"))
+	s.NoError(err)
+	s.Equal("n1 | a\n", out.String())
+	_, err = w.Write([]byte("c\n"))
+	s.NoError(err)
+	s.NoError(w.Flush())
+	s.Equal("n1 | a\nn1 | bc\n", out.String())
+}
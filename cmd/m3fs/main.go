@@ -15,17 +15,16 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"os"
-	"runtime"
+	"path/filepath"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
-	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/errors"
 	mlog "github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
 )
 
 var (
@@ -36,21 +35,70 @@ var (
 	outputPath       string
 	tmpDir           string
 	workDir          string
+	globalWorkDir    string
+	runID            string
+	forceUnlock      bool
+	probeBandwidth   bool
+	parallelDownload bool
+	skipPreflight    bool
+	onlyPreflight    bool
 	registry         string
 	clusterDeleteAll bool
 	noColorOutput    bool
+	quiet            bool
+	timingsOut       string
+	commandStats     bool
+	eventsOut        string
+	keepTemp         bool
+	perNodeLogs      bool
+	localMode        bool
+	logFormat        string
+	logTimestamps    bool
+	logCaller        bool
+	logTimezone      string
+	logFile          string
+	stagingDir       string
+	configProfile    string
+
+	requireOptionalTools bool
+
+	caFile             string
+	certFile           string
+	keyFile            string
+	insecureSkipVerify bool
+	inventoryURL       string
+	templatesDir       string
 )
 
+// workDirOverride returns the work dir overriding workDir of the cluster config. The
+// --workdir flag of a command takes precedence over the global --work-dir flag, they're
+// bound to different variables since a command resets its flags to their defaults.
+func workDirOverride() string {
+	if workDir != "" {
+		return workDir
+	}
+	return globalWorkDir
+}
+
 func main() {
 	app := &cli.App{
 		Name:  "m3fs",
 		Usage: "3FS Deploy Tool",
 		Before: func(ctx *cli.Context) error {
-			level := logrus.InfoLevel
-			if debug {
-				level = logrus.DebugLevel
+			logOptions, err := newLogOptions()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			mlog.InitLoggerWithOptions(logOptions)
+			if templatesDir != "" {
+				names, err := task.OverrideTemplates(templatesDir)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				for _, name := range names {
+					logrus.Infof("Template %s is overridden by %s", name, filepath.Join(templatesDir, name))
+				}
 			}
-			mlog.InitLogger(level)
 			return nil
 		},
 		Commands: []*cli.Command{
@@ -58,7 +106,9 @@ func main() {
 			clusterCmd,
 			configCmd,
 			osCmd,
+			supportBundleCmd,
 			tmplCmd,
+			versionCmd,
 		},
 		Action: func(ctx *cli.Context) error {
 			return cli.ShowAppHelp(ctx)
@@ -75,18 +125,159 @@ func main() {
 				Usage:       "Enable debug mode",
 				Destination: &debug,
 			},
+			&cli.StringFlag{
+				Name:        "log-format",
+				Usage:       "Format of logs: text or json",
+				Value:       logFormatText,
+				Destination: &logFormat,
+			},
+			&cli.BoolFlag{
+				Name:        "log-timestamps",
+				Usage:       "Log full timestamps in RFC3339Nano format",
+				Destination: &logTimestamps,
+			},
+			&cli.BoolFlag{
+				Name:        "log-caller",
+				Usage:       "Log the file:line of the caller of each log",
+				Destination: &logCaller,
+			},
+			&cli.StringFlag{
+				Name:        "log-timezone",
+				Usage:       "Timezone of timestamps of logs: local or utc",
+				Value:       logTimezoneLocal,
+				Destination: &logTimezone,
+			},
+			&cli.StringFlag{
+				Name:        "log-file",
+				Usage:       "Path of the file logs are also appended to, it's included by support-bundle",
+				Destination: &logFile,
+			},
+			&cli.StringFlag{
+				Name:        "work-dir",
+				Usage:       "Path to the working directory, overrides workDir of the cluster config",
+				Destination: &globalWorkDir,
+			},
+			&cli.StringFlag{
+				Name:        "run-id",
+				Usage:       "Label of the run of letters, digits, '.', '_' and '-', used to namespace run state (default is the start time)",
+				Destination: &runID,
+			},
+			&cli.BoolFlag{
+				Name:        "force-unlock",
+				Usage:       "Break the stale cluster lock left by a gone process, or the lock held on another host",
+				Destination: &forceUnlock,
+			},
+			&cli.StringFlag{
+				Name:        "override-freeze",
+				Usage:       "Run a command in a deployment freeze of the config, the value is the reason recorded of the run",
+				Destination: &overrideFreezeReason,
+			},
+			&cli.BoolFlag{
+				Name:        "include-cordoned",
+				Usage:       "Include cordoned nodes in the run, which are skipped by default",
+				Destination: &includeCordoned,
+			},
+			&cli.BoolFlag{
+				Name:        "refresh-facts",
+				Usage:       "Gather facts of nodes again instead of using the ones cached in the work dir",
+				Destination: &refreshFacts,
+			},
+			&cli.BoolFlag{
+				Name:        "require-optional-tools",
+				Usage:       "Fail instead of skipping features whose optional tools are missing, e.g. fio of benchmarks",
+				Destination: &requireOptionalTools,
+			},
+			&cli.BoolFlag{
+				Name:        "dashboard",
+				Usage:       "Render statuses of nodes of the running task as a grid, or log them if it's not a terminal",
+				Destination: &showDashboard,
+			},
+			&cli.BoolFlag{
+				Name:        "local",
+				Usage:       "Deploy a single node cluster on the local host without SSH, e.g. for smoke tests and demos",
+				Destination: &localMode,
+			},
+			&cli.StringFlag{
+				Name:        "staging-dir",
+				Usage:       "Dir in which artifacts are staged on the deploy host and nodes, overrides stagingDir of the cluster config",
+				Destination: &stagingDir,
+			},
+			&cli.StringFlag{
+				Name:        "profile",
+				Usage:       "Profile of the cluster config to apply, overrides profile of the cluster config",
+				Destination: &configProfile,
+			},
+			&cli.BoolFlag{
+				Name:        "quiet",
+				Aliases:     []string{"q"},
+				Usage:       "Don't print the cluster summary banner before running tasks",
+				Destination: &quiet,
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage: "Don't ask for confirmation before mutating commands, required if stdin isn't a terminal. " +
+					"Phase gates still wait for approval",
+				Destination: &assumeYes,
+			},
+			&cli.BoolFlag{
+				Name:        "keep-temp",
+				Usage:       "Keep temp dirs of a failed run for debugging, remove them by `m3fs cluster clean` later",
+				Destination: &keepTemp,
+			},
+			&cli.BoolFlag{
+				Name: "per-node-logs",
+				Usage: "Log info messages of every node running steps in parallel, " +
+					"instead of identical messages once with the count of nodes",
+				Destination: &perNodeLogs,
+			},
+			&cli.StringFlag{
+				Name:        "timings-out",
+				Usage:       "Write timings of tasks into the file, in prometheus textfile format if it ends with .prom, otherwise in CSV format",
+				Destination: &timingsOut,
+			},
+			&cli.BoolFlag{
+				Name:        "command-stats",
+				Usage:       "Log the count and latencies of commands run on every node at the end of the run, slowest first",
+				Destination: &commandStats,
+			},
+			&cli.StringFlag{
+				Name:        "record-events",
+				Usage:       "Record progress and commands of the run into the file as JSON lines, see `m3fs cluster replay`",
+				Destination: &eventsOut,
+			},
+			&cli.StringFlag{
+				Name:        "ca-file",
+				Usage:       "Path to the CA bundle trusted by the registry and HTTP downloads, overrides tls.caFile of the cluster config",
+				Destination: &caFile,
+			},
+			&cli.StringFlag{
+				Name:        "cert-file",
+				Usage:       "Path to the client certificate for mutual TLS, overrides tls.certFile of the cluster config",
+				Destination: &certFile,
+			},
+			&cli.StringFlag{
+				Name:        "key-file",
+				Usage:       "Path to the client key for mutual TLS, overrides tls.keyFile of the cluster config",
+				Destination: &keyFile,
+			},
+			&cli.BoolFlag{
+				Name:        "insecure-skip-verify",
+				Usage:       "Skip verifying TLS certificates of HTTP downloads, it's insecure and discouraged",
+				Destination: &insecureSkipVerify,
+			},
+			&cli.StringFlag{
+				Name:        "inventory",
+				Usage:       "URL of the inventory returning nodes of the cluster in JSON, they're merged into nodes of the cluster config",
+				Destination: &inventoryURL,
+			},
+			&cli.StringFlag{
+				Name:        "templates-dir",
+				Usage:       "Directory of templates overriding bundled templates of the same names, see `m3fs template list`",
+				Destination: &templatesDir,
+			},
 		},
-		Version: fmt.Sprintf(`%s
-Git SHA: %s
-Build At: %s
-Go Version: %s
-Go OS/Arch: %s/%s`,
-			common.Version,
-			common.GitSha[:7],
-			common.BuildTime,
-			runtime.Version(),
-			runtime.GOOS,
-			runtime.GOARCH),
+		Version: versionText(),
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -0,0 +1,132 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// defines output formats of read commands
+const (
+	outputFormatTable = "table"
+	outputFormatJSON  = "json"
+	outputFormatYAML  = "yaml"
+	// outputFormatText is the former name of the table format, it's kept for compatibility.
+	outputFormatText = "text"
+)
+
+// outputFormat is the output format of read commands sharing it.
+var outputFormat string
+
+// newOutputFlag returns the --output flag of a read command, which is the format of
+// printing the result of the command.
+func newOutputFlag(destination *string) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:        "output",
+		Aliases:     []string{"o"},
+		Usage:       "Output format: table, json or yaml",
+		Value:       outputFormatTable,
+		Destination: destination,
+	}
+}
+
+// checkOutputFormat validates the output format and returns it, text is taken as table.
+func checkOutputFormat(format string) (string, error) {
+	switch format {
+	case outputFormatTable, outputFormatJSON, outputFormatYAML:
+		return format, nil
+	case outputFormatText:
+		return outputFormatTable, nil
+	default:
+		return "", errors.Errorf("invalid output format: %s", format)
+	}
+}
+
+// newTable returns a writer aligning tab separated columns of a table, the header is
+// written as the first row. The writer must be flushed after all rows are written.
+func newTable(w io.Writer, header ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(header) > 0 {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	return tw
+}
+
+// printOutput prints the result of a read command in the format. The result is marshaled
+// into JSON or YAML with keys of its JSON encoding, so that both formats carry the same
+// data, or rendered by printTable for the table format. A nil slice is printed as empty.
+func printOutput(w io.Writer, format string, result any, printTable func(io.Writer) error) error {
+	if v := reflect.ValueOf(result); v.Kind() == reflect.Slice && v.IsNil() {
+		result = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	switch format {
+	case outputFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return errors.Trace(encoder.Encode(result))
+	case outputFormatYAML:
+		data, err := json.Marshal(result)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if data, err = jsonToYAML(data); err != nil {
+			return errors.Trace(err)
+		}
+		_, err = w.Write(data)
+		return errors.Trace(err)
+	default:
+		return errors.Trace(printTable(w))
+	}
+}
+
+// jsonToYAML converts the JSON document into YAML in block style, order of keys is kept.
+func jsonToYAML(data []byte) ([]byte, error) {
+	// JSON is a subset of YAML
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, errors.Annotate(err, "parse JSON")
+	}
+	resetYAMLStyle(&node)
+	buf := new(bytes.Buffer)
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// resetYAMLStyle clears the flow and quoted styles of nodes parsed from JSON, scalars
+// are still quoted if they'd be taken as another type.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/doctor"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestOutputSuite(t *testing.T) {
+	suiteRun(t, &outputSuite{})
+}
+
+type outputSuite struct {
+	Suite
+}
+
+func (s *outputSuite) TestCheckOutputFormat() {
+	for format, expected := range map[string]string{
+		"table": outputFormatTable,
+		"text":  outputFormatTable,
+		"json":  outputFormatJSON,
+		"yaml":  outputFormatYAML,
+	} {
+		actual, err := checkOutputFormat(format)
+		s.NoError(err)
+		s.Equal(expected, actual)
+	}
+
+	_, err := checkOutputFormat("xml")
+	s.Error(err, "invalid output format: xml")
+}
+
+// TestOutputFlagIsFormat checks -o is the output format of every command, paths of
+// files written by commands take --out. artifact export keeps -o as its output path
+// for compatibility.
+func (s *outputSuite) TestOutputFlagIsFormat() {
+	var walk func(prefix string, cmds []*cli.Command)
+	walk = func(prefix string, cmds []*cli.Command) {
+		for _, cmd := range cmds {
+			name := prefix + " " + cmd.Name
+			for _, flag := range cmd.Flags {
+				if name == "m3fs artifact export" ||
+					(!slices.Contains(flag.Names(), "output") && !slices.Contains(flag.Names(), "o")) {
+					continue
+				}
+				usage := flag.(cli.DocGenerationFlag).GetUsage()
+				s.True(strings.HasPrefix(usage, "Output format"), "%s: %s", name, usage)
+			}
+			walk(name, cmd.Subcommands)
+		}
+	}
+	walk("m3fs", []*cli.Command{
+		artifactCmd, clusterCmd, configCmd, osCmd, supportBundleCmd, tmplCmd, versionCmd,
+	})
+}
+
+// print prints the result in the format and returns the output.
+func (s *outputSuite) print(format string, result any) string {
+	buf := new(bytes.Buffer)
+	s.NoError(printOutput(buf, format, result, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, "table")
+		return err
+	}))
+	return buf.String()
+}
+
+// normalize returns the value decoded from JSON of the value, so that values decoded
+// from JSON and YAML are comparable.
+func (s *outputSuite) normalize(value any) any {
+	data, err := json.Marshal(value)
+	s.NoError(err)
+	var normalized any
+	s.NoError(json.Unmarshal(data, &normalized))
+	return normalized
+}
+
+func (s *outputSuite) assertSameData(result any) {
+	expected := s.normalize(result)
+
+	var fromJSON any
+	s.NoError(json.Unmarshal([]byte(s.print(outputFormatJSON, result)), &fromJSON))
+	s.Equal(expected, fromJSON)
+
+	var fromYAML any
+	s.NoError(yaml.Unmarshal([]byte(s.print(outputFormatYAML, result)), &fromYAML))
+	s.Equal(expected, s.normalize(fromYAML))
+}
+
+func (s *outputSuite) TestFormatsCarrySameData() {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.assertSameData([]*task.RunHistoryEntry{
+		{
+			ID:          "run1",
+			Command:     "cluster create",
+			Status:      task.RunStatusFailed,
+			StartTime:   start,
+			EndTime:     start.Add(time.Minute),
+			Error:       "true",
+			Tasks:       3,
+			LastPhase:   task.PhaseDeploy,
+			FailedNodes: map[string][]string{"RunMetaTask": {"node1", "node2"}},
+		},
+	})
+	s.assertSameData(&doctorOutput{
+		Verdict: doctor.StatusWarn,
+		Results: []doctor.Result{
+			{Check: doctor.CheckSudo, Node: "node1", Status: doctor.StatusWarn, Message: "123", Fix: "fix: it"},
+			{Check: doctor.CheckFdbQuorum, Status: doctor.StatusPass, Message: "null"},
+		},
+	})
+}
+
+func (s *outputSuite) TestPrintTable() {
+	s.Equal("table\n", s.print(outputFormatTable, []string{"a"}))
+}
+
+func (s *outputSuite) TestPrintNilSlice() {
+	var entries []*task.RunHistoryEntry
+	s.Equal("[]\n", s.print(outputFormatJSON, entries))
+	s.Equal("[]\n", s.print(outputFormatYAML, entries))
+}
+
+func (s *outputSuite) TestPrintYAML() {
+	result := map[string]any{
+		"name":    "true",
+		"count":   2,
+		"enabled": false,
+		"nodes":   []string{"node1", "node2"},
+	}
+	s.Equal(`count: 2
+enabled: false
+name: "true"
+nodes:
+  - node1
+  - node2
+`, s.print(outputFormatYAML, result))
+}
@@ -0,0 +1,279 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var clusterPauseCmd = &cli.Command{
+	Name: "pause",
+	Usage: "Pause runs of a 3fs cluster, a run finishes its current task and waits before the next one " +
+		"until the cluster is resumed",
+	Action: pauseCluster,
+	Flags:  cordonFlags(),
+}
+
+var clusterResumeCmd = &cli.Command{
+	Name:   "resume",
+	Usage:  "Resume paused runs of a 3fs cluster",
+	Action: resumeCluster,
+	Flags:  cordonFlags(),
+}
+
+var clusterStatusCmd = &cli.Command{
+	Name:   "status",
+	Usage:  "Show whether a 3fs cluster is paused or being changed by a run, and its latest run",
+	Action: showClusterStatus,
+	Flags:  cordonFlags(newOutputFlag(&outputFormat)),
+}
+
+// pauseSignalsOnce makes the handler of pause signals installed once, as commands may
+// create several runners.
+var pauseSignalsOnce sync.Once
+
+func pauseCluster(ctx *cli.Context) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	paused, err := task.PauseCluster(cfg.WorkDir, cfg.Name, "cluster pause")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !paused {
+		fmt.Printf("Cluster %s is already paused\n", cfg.Name)
+		return nil
+	}
+	fmt.Printf("Cluster %s is paused, runs wait before their next task until `m3fs cluster resume`\n", cfg.Name)
+	return nil
+}
+
+func resumeCluster(ctx *cli.Context) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	resumed, err := task.ResumeCluster(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !resumed {
+		fmt.Printf("Cluster %s isn't paused\n", cfg.Name)
+		return nil
+	}
+	fmt.Printf("Cluster %s is resumed\n", cfg.Name)
+	return nil
+}
+
+// watchPauseSignals pauses runs of the cluster on SIGUSR1 and resumes them on SIGUSR2.
+func watchPauseSignals(cfg *config.Config) {
+	pauseSignalsOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+		go func() {
+			for sig := range signals {
+				handlePauseSignal(cfg, sig)
+			}
+		}()
+	})
+}
+
+func handlePauseSignal(cfg *config.Config, sig os.Signal) {
+	var err error
+	if sig == syscall.SIGUSR1 {
+		_, err = task.PauseCluster(cfg.WorkDir, cfg.Name, "SIGUSR1")
+		logrus.Infof("Pausing cluster %s by %s, the run pauses after its current task", cfg.Name, sig)
+	} else {
+		_, err = task.ResumeCluster(cfg.WorkDir, cfg.Name)
+		logrus.Infof("Resuming cluster %s by %s", cfg.Name, sig)
+	}
+	if err != nil {
+		logrus.Warnf("Failed to handle %s: %v", sig, err)
+	}
+}
+
+// clusterStatus is the status of a cluster shown by `cluster status`.
+type clusterStatus struct {
+	Cluster string          `json:"cluster"`
+	Paused  *task.PauseInfo `json:"paused,omitempty"`
+	// Lock is the holder of the lock of the cluster, i.e. the running command.
+	Lock    *task.LockInfo `json:"lock,omitempty"`
+	LastRun *runStatus     `json:"lastRun,omitempty"`
+	// Disabled are services disabled in the config, they aren't deployed.
+	Disabled []config.ServiceType `json:"disabled,omitempty"`
+	// Passthrough are paths and devices of nodes passed into containers of services.
+	Passthrough []*servicePassthrough `json:"passthrough,omitempty"`
+}
+
+// servicePassthrough is paths and devices of nodes passed into containers of a service.
+type servicePassthrough struct {
+	Service      config.ServiceType `json:"service"`
+	Mounts       []string           `json:"mounts,omitempty"`
+	Devices      []string           `json:"devices,omitempty"`
+	SecurityOpts []string           `json:"securityOpts,omitempty"`
+}
+
+// passthroughStatus returns paths and devices passed into containers of enabled services,
+// including executables of command wrappers.
+func passthroughStatus(cfg *config.Config) []*servicePassthrough {
+	var passthroughs []*servicePassthrough
+	for _, service := range config.AllServiceTypes {
+		p := cfg.Services.Passthrough(service)
+		wrapper := cfg.Services.CommandWrapper(service)
+		if !cfg.Services.Enabled(service) || (p.IsEmpty() && len(wrapper) == 0) {
+			continue
+		}
+		status := &servicePassthrough{Service: service, SecurityOpts: p.SecurityOpts}
+		for _, m := range p.Mounts {
+			status.Mounts = append(status.Mounts, m.String())
+		}
+		if len(wrapper) > 0 {
+			status.Mounts = append(status.Mounts, config.Mount{Source: wrapper[0], ReadOnly: true}.String())
+		}
+		for _, d := range p.Devices {
+			status.Devices = append(status.Devices, d.String())
+		}
+		passthroughs = append(passthroughs, status)
+	}
+	return passthroughs
+}
+
+// runStatus is the status of a run.
+type runStatus struct {
+	ID        string         `json:"id"`
+	Command   string         `json:"command"`
+	Status    task.RunStatus `json:"status"`
+	StartTime time.Time      `json:"startTime"`
+	NextTask  string         `json:"nextTask,omitempty"`
+	// Interrupted is whether the run ended without recording its result, e.g. it crashed.
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+func showClusterStatus(ctx *cli.Context) error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	status, err := loadClusterStatus(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(printClusterStatus(os.Stdout, status, format))
+}
+
+func loadClusterStatus(cfg *config.Config) (*clusterStatus, error) {
+	status := &clusterStatus{
+		Cluster:     cfg.Name,
+		Disabled:    cfg.Services.DisabledServices(),
+		Passthrough: passthroughStatus(cfg),
+	}
+	var err error
+	if status.Paused, err = task.ReadPauseInfo(cfg.WorkDir, cfg.Name); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if status.Lock, err = task.ReadLockInfo(task.LockFilePath(cfg.WorkDir, cfg.Name)); err != nil {
+		return nil, errors.Trace(err)
+	}
+	records, err := task.LoadRunRecords(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(records) > 0 {
+		record := records[0]
+		status.LastRun = &runStatus{
+			ID:        record.ID,
+			Command:   record.Command,
+			Status:    record.Status,
+			StartTime: record.StartTime,
+			NextTask:  record.NextTask,
+			Interrupted: (record.Status == task.RunStatusRunning || record.Status == task.RunStatusPaused) &&
+				status.Lock == nil,
+		}
+	}
+	return status, nil
+}
+
+func printClusterStatus(out io.Writer, status *clusterStatus, format string) error {
+	return printOutput(out, format, status, func(out io.Writer) error {
+		w := newTable(out)
+		fmt.Fprintf(w, "Cluster:\t%s\n", status.Cluster)
+		paused := "no"
+		if status.Paused != nil {
+			paused = "yes"
+			if !status.Paused.Time.IsZero() {
+				paused += fmt.Sprintf(", since %s", status.Paused.Time.Format(time.DateTime))
+			}
+			if status.Paused.By != "" {
+				paused += fmt.Sprintf(" by %s", status.Paused.By)
+			}
+		}
+		fmt.Fprintf(w, "Paused:\t%s\n", paused)
+		running := "no"
+		if status.Lock != nil {
+			running = fmt.Sprintf("%s (pid %d on %s) since %s", status.Lock.Command, status.Lock.PID,
+				status.Lock.Host, status.Lock.StartTime.Format(time.DateTime))
+		}
+		fmt.Fprintf(w, "Running:\t%s\n", running)
+		if len(status.Disabled) > 0 {
+			disabled := make([]string, len(status.Disabled))
+			for i, service := range status.Disabled {
+				disabled[i] = string(service)
+			}
+			fmt.Fprintf(w, "Disabled:\t%s\n", strings.Join(disabled, ", "))
+		}
+		for _, p := range status.Passthrough {
+			if len(p.Mounts) > 0 {
+				fmt.Fprintf(w, "Mounts of %s:\t%s\n", p.Service, strings.Join(p.Mounts, ", "))
+			}
+			if len(p.Devices) > 0 {
+				fmt.Fprintf(w, "Devices of %s:\t%s\n", p.Service, strings.Join(p.Devices, ", "))
+			}
+			if len(p.SecurityOpts) > 0 {
+				fmt.Fprintf(w, "Security opts of %s:\t%s\n", p.Service, strings.Join(p.SecurityOpts, ", "))
+			}
+		}
+		if run := status.LastRun; run != nil {
+			state := string(run.Status)
+			if run.Interrupted {
+				state += ", interrupted"
+			}
+			fmt.Fprintf(w, "Last run:\t%s %s (%s) started at %s\n", run.ID, run.Command, state,
+				run.StartTime.Format(time.DateTime))
+			if run.NextTask != "" {
+				fmt.Fprintf(w, "Next task:\t%s\n", run.NextTask)
+			}
+		}
+		return errors.Trace(w.Flush())
+	})
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestPauseSuite(t *testing.T) {
+	suiteRun(t, &pauseSuite{})
+}
+
+type pauseSuite struct {
+	Suite
+	cfg *config.Config
+}
+
+func (s *pauseSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir()}
+}
+
+func (s *pauseSuite) TestHandlePauseSignal() {
+	handlePauseSignal(s.cfg, syscall.SIGUSR1)
+	info, err := task.ReadPauseInfo(s.cfg.WorkDir, "test")
+	s.NoError(err)
+	s.Equal("SIGUSR1", info.By)
+
+	handlePauseSignal(s.cfg, syscall.SIGUSR2)
+	info, err = task.ReadPauseInfo(s.cfg.WorkDir, "test")
+	s.NoError(err)
+	s.Nil(info)
+}
+
+func (s *pauseSuite) TestLoadClusterStatus() {
+	status, err := loadClusterStatus(s.cfg)
+	s.NoError(err)
+	s.Equal(&clusterStatus{Cluster: "test"}, status)
+
+	_, err = task.PauseCluster(s.cfg.WorkDir, "test", "cluster pause")
+	s.NoError(err)
+	record := &task.RunRecord{
+		ID:        "run1",
+		Command:   "cluster create",
+		Status:    task.RunStatusPaused,
+		StartTime: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		NextTask:  "CreateMetaServiceTask",
+	}
+	s.NoError(task.SaveRunRecord(task.RunDir(s.cfg.WorkDir, "test", "run1"), record))
+
+	status, err = loadClusterStatus(s.cfg)
+	s.NoError(err)
+	s.Equal("cluster pause", status.Paused.By)
+	s.Nil(status.Lock)
+	s.Equal("CreateMetaServiceTask", status.LastRun.NextTask)
+	s.True(status.LastRun.Interrupted)
+
+	lock, err := task.AcquireLock(s.cfg.WorkDir, "test", "cluster create", false)
+	s.NoError(err)
+	defer unlockCluster(lock)
+	status, err = loadClusterStatus(s.cfg)
+	s.NoError(err)
+	s.Equal("cluster create", status.Lock.Command)
+	s.False(status.LastRun.Interrupted)
+
+	disabled := false
+	s.cfg.Services.Client.Enabled = &disabled
+	status, err = loadClusterStatus(s.cfg)
+	s.NoError(err)
+	s.Equal([]config.ServiceType{config.ServiceClient}, status.Disabled)
+}
+
+func (s *pauseSuite) TestPrintClusterStatus() {
+	status := &clusterStatus{
+		Cluster:  "test",
+		Paused:   &task.PauseInfo{Time: time.Date(2025, 3, 1, 10, 5, 0, 0, time.UTC), By: "SIGUSR1"},
+		Disabled: []config.ServiceType{config.ServiceClickhouse, config.ServiceMonitor},
+		LastRun: &runStatus{
+			ID:          "run1",
+			Command:     "cluster create",
+			Status:      task.RunStatusPaused,
+			StartTime:   time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+			NextTask:    "CreateMetaServiceTask",
+			Interrupted: true,
+		},
+	}
+	buf := new(bytes.Buffer)
+	s.NoError(printClusterStatus(buf, status, outputFormatTable))
+	s.Equal("Cluster:    test\n"+
+		"Paused:     yes, since 2025-03-01 10:05:00 by SIGUSR1\n"+
+		"Running:    no\n"+
+		"Disabled:   clickhouse, monitor\n"+
+		"Last run:   run1 cluster create (paused, interrupted) started at 2025-03-01 10:00:00\n"+
+		"Next task:  CreateMetaServiceTask\n", buf.String())
+
+	buf.Reset()
+	s.NoError(printClusterStatus(buf, &clusterStatus{Cluster: "test", Passthrough: []*servicePassthrough{
+		{
+			Service: config.ServiceStorage,
+			Mounts:  []string{"/data1:/mnt/data1 (ro)"},
+			Devices: []string{"/dev/nvme0n1:/dev/nvme0n1"},
+		},
+		{Service: config.ServiceMeta, SecurityOpts: []string{"label=disable"}},
+	}}, outputFormatTable))
+	s.Equal("Cluster:                test\n"+
+		"Paused:                 no\n"+
+		"Running:                no\n"+
+		"Mounts of storage:      /data1:/mnt/data1 (ro)\n"+
+		"Devices of storage:     /dev/nvme0n1:/dev/nvme0n1\n"+
+		"Security opts of meta:  label=disable\n", buf.String())
+
+	buf.Reset()
+	s.NoError(printClusterStatus(buf, &clusterStatus{Cluster: "test"}, outputFormatJSON))
+	s.Equal("{\n  \"cluster\": \"test\"\n}\n", buf.String())
+}
+
+func (s *pauseSuite) TestPassthroughStatus() {
+	s.cfg.Services.Storage.Mounts = []config.Mount{{Source: "/data1", Target: "/mnt/data1", ReadOnly: true}}
+	s.cfg.Services.Storage.Devices = []config.Device{{Host: "/dev/infiniband/uverbs0", Permissions: "rw"}}
+	s.cfg.Services.Meta.CommandWrapper = "/usr/bin/numactl --interleave=all"
+	s.cfg.Services.Client.SecurityOpts = []string{"apparmor=unconfined"}
+	s.cfg.Services.SetEnabled(config.ServiceClient, false)
+
+	s.Equal([]*servicePassthrough{
+		{
+			Service: config.ServiceStorage,
+			Mounts:  []string{"/data1:/mnt/data1 (ro)"},
+			Devices: []string{"/dev/infiniband/uverbs0:/dev/infiniband/uverbs0 (rw)"},
+		},
+		{Service: config.ServiceMeta, Mounts: []string{"/usr/bin/numactl:/usr/bin/numactl (ro)"}},
+	}, passthroughStatus(s.cfg))
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	pingNodes    string
+	pingParallel int
+	pingTimeout  time.Duration
+)
+
+var clusterPingCmd = &cli.Command{
+	Name:   "ping",
+	Usage:  "Check nodes of a 3fs cluster are reachable and accept the SSH account, exit with non-zero code if any isn't",
+	Action: pingCluster,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.StringFlag{
+			Name:    "nodes",
+			Aliases: []string{"n"},
+			Usage: "Comma separated node names, hosts, glob patterns of them or service names " +
+				"to select nodes (default is all nodes)",
+			Destination: &pingNodes,
+		},
+		&cli.IntFlag{
+			Name:        "parallel",
+			Aliases:     []string{"p"},
+			Usage:       "Number of nodes checked at the same time",
+			Value:       50,
+			Destination: &pingParallel,
+		},
+		&cli.DurationFlag{
+			Name:        "timeout",
+			Usage:       "Timeout of connecting to a node and running a command on it",
+			Value:       5 * time.Second,
+			Destination: &pingTimeout,
+		},
+		newOutputFlag(&outputFormat),
+	},
+}
+
+// pingResult is the result of checking a node.
+type pingResult struct {
+	Node      string                  `json:"node" yaml:"node"`
+	Host      string                  `json:"host" yaml:"host"`
+	Reachable bool                    `json:"reachable" yaml:"reachable"`
+	AuthOK    bool                    `json:"authOK" yaml:"authOK"`
+	Latency   string                  `json:"latency" yaml:"latency"`
+	Reason    external.ConnectFailure `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Error     string                  `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// OK returns whether the node is reachable and accepts the SSH account.
+func (r *pingResult) OK() bool {
+	return r.Reachable && r.AuthOK
+}
+
+func pingCluster(ctx *cli.Context) error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if pingParallel <= 0 {
+		return errors.New("--parallel must be positive")
+	}
+	if pingTimeout <= 0 {
+		return errors.New("--timeout must be positive")
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	nodes, err := selectNodes(cfg, pingNodes)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// an unreachable node must fail fast instead of waiting for the timeouts of the config
+	if cfg.ConnectTimeout <= 0 || cfg.ConnectTimeout.Duration() > pingTimeout {
+		cfg.ConnectTimeout = config.Duration(pingTimeout)
+	}
+	cfg.CommandTimeout = config.Duration(pingTimeout)
+
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+
+	results := pingNodesOf(ctx.Context, runner.Runtime, nodes, pingParallel)
+	if err = printPingResults(os.Stdout, format, results); err != nil {
+		return errors.Trace(err)
+	}
+	failed := 0
+	for _, result := range results {
+		if !result.OK() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d nodes failed", failed, len(nodes))
+	}
+	return nil
+}
+
+// pingNodesOf connects to the nodes and runs `true` on them, and returns results in
+// the order of the nodes.
+func pingNodesOf(ctx context.Context, r *task.Runtime, nodes []config.Node, parallel int) []*pingResult {
+	var mu sync.Mutex
+	results := make(map[string]*pingResult, len(nodes))
+	pingNode := func(ctx context.Context, node config.Node) error {
+		result := &pingResult{Node: node.Name, Host: node.Host}
+		logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+		start := time.Now()
+		em, err := r.NodeManager(node, logger)
+		if err == nil {
+			_, err = em.Runner.NonSudoExec(ctx, "true")
+		}
+		result.Latency = time.Since(start).Round(time.Millisecond).String()
+		if err != nil {
+			result.Reason = external.ClassifyConnectError(err)
+			result.Reachable = result.Reason.Reachable()
+			result.Error = err.Error()
+		} else {
+			result.Reachable = true
+			result.AuthOK = true
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		results[node.Name] = result
+		return nil
+	}
+	workerPool := common.NewWorkerPool(pingNode, max(min(parallel, len(nodes)), 1))
+	workerPool.Start(ctx)
+	for _, node := range nodes {
+		workerPool.Add(node)
+	}
+	workerPool.Join()
+
+	ordered := make([]*pingResult, 0, len(nodes))
+	for _, node := range nodes {
+		ordered = append(ordered, results[node.Name])
+	}
+	return ordered
+}
+
+func printPingResults(out io.Writer, format string, results []*pingResult) error {
+	return printOutput(out, format, results, func(out io.Writer) error {
+		w := newTable(out, "NODE", "HOST", "REACHABLE", "AUTH", "LATENCY", "REASON")
+		for _, result := range results {
+			reachable := "no"
+			if result.Reachable {
+				reachable = "yes"
+			}
+			auth := "ok"
+			if result.Reason == external.ConnectFailureAuth {
+				auth = "failed"
+			} else if !result.AuthOK {
+				auth = "-"
+			}
+			reason := "-"
+			if result.Reason != external.ConnectFailureNone {
+				reason = string(result.Reason)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Node, result.Host, reachable, auth,
+				result.Latency, reason)
+		}
+		if err := w.Flush(); err != nil {
+			return errors.Trace(err)
+		}
+		for _, result := range results {
+			if result.Error != "" {
+				fmt.Fprintf(out, "%s: %s\n", result.Node, result.Error)
+			}
+		}
+		return nil
+	})
+}
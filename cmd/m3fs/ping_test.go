@@ -0,0 +1,95 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestPingSuite(t *testing.T) {
+	suiteRun(t, &pingSuite{})
+}
+
+type pingSuite struct {
+	Suite
+
+	script  *externaltest.Script
+	runtime *task.Runtime
+	nodes   []config.Node
+}
+
+func (s *pingSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.script = externaltest.NewScript()
+	s.nodes = []config.Node{
+		{Name: "node1", Host: "192.168.1.1"},
+		{Name: "node2", Host: "192.168.1.2"},
+		{Name: "node3", Host: "192.168.1.3"},
+	}
+	cfg := &config.Config{Name: "open3fs"}
+	s.runtime = &task.Runtime{
+		Cfg:      cfg,
+		Services: &cfg.Services,
+		WorkDir:  s.T().TempDir(),
+		LocalEm:  s.script.Manager("local", log.Logger),
+		NewNodeManager: func(node config.Node, logger log.Interface) (*external.Manager, error) {
+			if node.Name == "node3" {
+				return nil, errors.New("establish connection to 192.168.1.3:22: dial tcp 192.168.1.3:22: " +
+					"connect: connection refused")
+			}
+			return s.script.NodeManager(node, logger)
+		},
+	}
+}
+
+func (s *pingSuite) TestPingNodes() {
+	s.script.OnNode("node2", `^true$`, externaltest.Response{
+		Err: errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none password]"),
+	})
+	s.script.On(`^true$`, externaltest.Response{})
+
+	results := pingNodesOf(s.Ctx(), s.runtime, s.nodes, 2)
+	s.Len(results, 3)
+	s.True(results[0].OK())
+	s.True(results[1].Reachable)
+	s.False(results[1].AuthOK)
+	s.Equal(external.ConnectFailureAuth, results[1].Reason)
+	s.False(results[2].Reachable)
+	s.Equal(external.ConnectFailureRefused, results[2].Reason)
+
+	buf := new(bytes.Buffer)
+	s.NoError(printPingResults(buf, outputFormatTable, results))
+	s.Regexp(`node1\s+192.168.1.1\s+yes\s+ok\s+\S+\s+-\n`, buf.String())
+	s.Regexp(`node2\s+192.168.1.2\s+yes\s+failed\s+\S+\s+auth failed\n`, buf.String())
+	s.Regexp(`node3\s+192.168.1.3\s+no\s+-\s+\S+\s+connection refused\n`, buf.String())
+	s.Contains(buf.String(), "node3: establish connection to 192.168.1.3:22")
+
+	buf.Reset()
+	s.NoError(printPingResults(buf, outputFormatJSON, results))
+	var decoded []map[string]any
+	s.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	s.Len(decoded, 3)
+	s.Equal("auth failed", decoded[1]["reason"])
+	s.Equal(false, decoded[1]["authOK"])
+}
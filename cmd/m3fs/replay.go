@@ -0,0 +1,95 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	mlog "github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	replayFile     string
+	replaySpeed    float64
+	replayCommands bool
+)
+
+var clusterReplayCmd = &cli.Command{
+	Name:   "replay",
+	Usage:  "Replay progress of a run recorded by --record-events in the dashboard",
+	Action: replayClusterEvents,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "file",
+			Aliases:     []string{"f"},
+			Usage:       "Path to the file of recorded events",
+			Destination: &replayFile,
+			Required:    true,
+		},
+		&cli.Float64Flag{
+			Name:        "speed",
+			Usage:       "Speed relative to the recorded run, 0 replays events without delays",
+			Value:       1,
+			Destination: &replaySpeed,
+		},
+		&cli.BoolFlag{
+			Name:        "commands",
+			Usage:       "Log recorded commands run on nodes",
+			Destination: &replayCommands,
+		},
+	},
+}
+
+func replayClusterEvents(ctx *cli.Context) error {
+	if replaySpeed < 0 {
+		return errors.Errorf("invalid speed %v", replaySpeed)
+	}
+	events, err := task.ReadEventsFile(replayFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	replayer := task.NewEventReplayer(events)
+	replayer.SetSpeed(replaySpeed)
+	d := newDashboard(os.Stderr)
+	d.now = replayer.Now
+	if d.tty {
+		mlog.SetOutput(d)
+	}
+	return errors.Trace(replayer.Replay(ctx.Context, d, logReplayedEvent))
+}
+
+// logReplayedEvent logs replayed events other than progress of tasks.
+func logReplayedEvent(event *task.Event) {
+	switch event.Type {
+	case task.EventRunStarted:
+		logrus.Infof("Run of %s on cluster %s started at %s", event.Command, event.Cluster, event.Time)
+	case task.EventRunEnded:
+		if event.Error != "" {
+			logrus.Errorf("Run failed: %s", event.Error)
+		} else {
+			logrus.Info("Run succeeded")
+		}
+	case task.EventCommand:
+		if replayCommands {
+			logrus.Infof("Ran %q on %s in %dms, exit code %d", event.Command, event.Node, event.DurationMs,
+				event.ExitCode)
+		}
+	}
+}
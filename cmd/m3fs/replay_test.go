@@ -0,0 +1,92 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestReplaySuite(t *testing.T) {
+	suiteRun(t, &replaySuite{})
+}
+
+type replaySuite struct {
+	Suite
+}
+
+// testRecordedEvents are events recorded from a run whose second task failed on node2.
+const testRecordedEvents = `{"type":"header","time":"2025-04-10T08:00:00Z","version":1}
+{"type":"run_started","time":"2025-04-10T08:00:00Z","cluster":"test","command":"cluster create"}
+{"type":"task_started","time":"2025-04-10T08:00:00Z","index":1,"total":2,"task":"CreateMgmtd"}
+{"type":"node_changed","time":"2025-04-10T08:00:00Z","task":"CreateMgmtd","node":"node1","status":"running"}
+{"type":"command","time":"2025-04-10T08:00:01Z","task":"CreateMgmtd","node":"node1","command":"true","durationMs":3}
+{"type":"node_changed","time":"2025-04-10T08:00:04Z","task":"CreateMgmtd","node":"node1","status":"ok"}
+{"type":"task_ended","time":"2025-04-10T08:00:04Z","task":"CreateMgmtd"}
+{"type":"task_started","time":"2025-04-10T08:00:04Z","index":2,"total":2,"task":"CreateMeta"}
+{"type":"node_changed","time":"2025-04-10T08:00:04Z","task":"CreateMeta","node":"node1","status":"pending"}
+{"type":"node_changed","time":"2025-04-10T08:00:04Z","task":"CreateMeta","node":"node2","status":"pending"}
+{"type":"node_changed","time":"2025-04-10T08:00:05Z","task":"CreateMeta","node":"node1","status":"running"}
+{"type":"node_changed","time":"2025-04-10T08:00:05Z","task":"CreateMeta","node":"node2","status":"running"}
+{"type":"node_changed","time":"2025-04-10T08:00:09Z","task":"CreateMeta","node":"node1","status":"ok"}
+{"type":"node_changed","time":"2025-04-10T08:00:12Z","task":"CreateMeta","node":"node2","status":"failed"}
+{"type":"task_ended","time":"2025-04-10T08:00:12Z","task":"CreateMeta","error":"node2 failed"}
+{"type":"run_ended","time":"2025-04-10T08:00:12Z","error":"node2 failed"}
+`
+
+func (s *replaySuite) TestDashboard() {
+	events, err := task.ReadEvents(strings.NewReader(testRecordedEvents))
+	s.NoError(err)
+	replayer := task.NewEventReplayer(events)
+	out := new(bytes.Buffer)
+	d := &dashboard{
+		out:  out,
+		tty:  true,
+		size: func() (int, int) { return 120, 24 },
+		now:  replayer.Now,
+	}
+	var others []string
+	s.NoError(replayer.Replay(s.Ctx(), d, func(event *task.Event) { others = append(others, event.Type) }))
+
+	counts := func(done, total, ok, failed, running, pending int) string {
+		return fmt.Sprintf("%d/%d node(s) done, %d ok, %d failed, %d running, %d pending",
+			done, total, ok, failed, running, pending)
+	}
+	s.Equal("task 1/2 CreateMgmtd, 0s elapsed\n"+
+		"\x1b[1A\x1b[J* node1\n[--------------------] task 1/2 CreateMgmtd: "+counts(0, 1, 0, 0, 1, 0)+
+		", 0s elapsed\n"+
+		"\x1b[2A\x1b[J+ node1\n[####################] task 1/2 CreateMgmtd: "+counts(1, 1, 1, 0, 0, 0)+
+		", 4s elapsed\n"+
+		"\x1b[2A\x1b[J+ task 1/2 CreateMgmtd: "+counts(1, 1, 1, 0, 0, 0)+" in 4s\n"+
+		"task 2/2 CreateMeta, 0s elapsed\n"+
+		"\x1b[1A\x1b[J. node1\n[--------------------] task 2/2 CreateMeta: "+counts(0, 1, 0, 0, 0, 1)+
+		", 0s elapsed\n"+
+		"\x1b[2A\x1b[J. node1  . node2\n[--------------------] task 2/2 CreateMeta: "+counts(0, 2, 0, 0, 0, 2)+
+		", 0s elapsed\n"+
+		"\x1b[2A\x1b[J* node1  . node2\n[--------------------] task 2/2 CreateMeta: "+counts(0, 2, 0, 0, 1, 1)+
+		", 1s elapsed\n"+
+		"\x1b[2A\x1b[J* node1  * node2\n[--------------------] task 2/2 CreateMeta: "+counts(0, 2, 0, 0, 2, 0)+
+		", 1s elapsed\n"+
+		"\x1b[2A\x1b[J+ node1  * node2\n[##########----------] task 2/2 CreateMeta: "+counts(1, 2, 1, 0, 1, 0)+
+		", 5s elapsed\n"+
+		"\x1b[2A\x1b[J+ node1  x node2\n[####################] task 2/2 CreateMeta: "+counts(2, 2, 1, 1, 0, 0)+
+		", 8s elapsed\n"+
+		"\x1b[2A\x1b[Jx task 2/2 CreateMeta: "+counts(2, 2, 1, 1, 0, 0)+" in 8s\n", out.String())
+	s.Equal([]string{task.EventHeader, task.EventRunStarted, task.EventCommand, task.EventRunEnded}, others)
+}
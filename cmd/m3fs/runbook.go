@@ -0,0 +1,167 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var runbookPath string
+
+// writeCreateRunbook writes the runbook of cluster create into the file, nothing is run.
+func writeCreateRunbook(cfg *config.Config, filePath string) error {
+	tasks, plans, err := planCreateCluster(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	files, err := renderConfigFiles(cfg, "", false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	buf := new(bytes.Buffer)
+	if err = writeRunbook(buf, cfg, tasks, plans, files); err != nil {
+		return errors.Trace(err)
+	}
+	// secrets of the cluster config are registered when tasks are initialized
+	if err = os.WriteFile(filePath, []byte(external.Redact(buf.String())), 0644); err != nil {
+		return errors.Annotatef(err, "write runbook %s", filePath)
+	}
+	fmt.Printf("Runbook of %d tasks is written to %s\n", len(plans), filePath)
+	return nil
+}
+
+// writeRunbook writes the Markdown runbook of the tasks. The runbook is deterministic,
+// it doesn't contain anything varying between runs like time.
+func writeRunbook(w io.Writer, cfg *config.Config, tasks []task.Interface,
+	plans []task.TaskPlan, files []*nodeRenderedFile) error {
+
+	fmt.Fprintf(w, "# Runbook of creating 3fs cluster %s\n\n", cfg.Name)
+	generator := "m3fs"
+	if common.Version != "" {
+		generator += " " + common.Version
+	}
+	fmt.Fprintf(w, "Generated by %s without running anything. Secrets are redacted, "+
+		"values generated during deployment are placeholders.\n\n", generator)
+
+	fmt.Fprintln(w, "## Nodes")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Node | Host | Services |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, node := range cfg.Nodes {
+		var services []string
+		for _, service := range config.AllServiceTypes {
+			if slices.Contains(cfg.Services.ServiceNodes(service), node.Name) {
+				services = append(services, string(service))
+			}
+		}
+		fmt.Fprintf(w, "| %s | %s | %s |\n", node.Name, node.Host, dashIfEmpty(strings.Join(services, ", ")))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "## Tasks")
+	// config files of a service are listed under the first task of the service
+	listed := make(map[config.ServiceType]bool)
+	for i, plan := range plans {
+		fmt.Fprintf(w, "\n### %d. %s\n\n", i+1, plan.Name)
+		fmt.Fprintf(w, "- Phase: %s\n", plan.Phase)
+		fmt.Fprintf(w, "- Service: %s\n", dashIfEmpty(string(plan.Service)))
+		fmt.Fprintf(w, "- Plan: %s\n", plan.Reason)
+		if len(plan.Deps) > 0 {
+			fmt.Fprintf(w, "- Depends on: %s\n", strings.Join(plan.Deps, ", "))
+		}
+		if !plan.Run {
+			continue
+		}
+
+		if steps := task.StepPlansOf(tasks[i]); len(steps) > 0 {
+			fmt.Fprintln(w, "\nSteps:")
+			fmt.Fprintln(w)
+			for j, step := range steps {
+				nodes := "no node"
+				if len(step.Nodes) > 0 {
+					nodes = strings.Join(step.Nodes, ", ")
+				}
+				if len(step.Batches) > 1 {
+					sizes := make([]string, len(step.Batches))
+					for k, size := range step.Batches {
+						sizes[k] = strconv.Itoa(size)
+					}
+					nodes += fmt.Sprintf(" in batches of %s node(s)", strings.Join(sizes, ", "))
+				} else if step.Parallel {
+					nodes += " in parallel"
+				}
+				fmt.Fprintf(w, "%d. %s on %s\n", j+1, step.Name, nodes)
+			}
+		}
+
+		if plan.Service == "" || listed[plan.Service] {
+			continue
+		}
+		listed[plan.Service] = true
+		var serviceFiles []*nodeRenderedFile
+		for _, file := range files {
+			if file.Service == plan.Service {
+				serviceFiles = append(serviceFiles, file)
+			}
+		}
+		if len(serviceFiles) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, "\nConfig files:")
+		for _, file := range serviceFiles {
+			fmt.Fprintf(w, "\n#### %s:%s\n\n", file.Node, file.Path)
+			fmt.Fprintf(w, "```%s\n%s", codeLanguage(file.Path), file.Data)
+			if !bytes.HasSuffix(file.Data, []byte("\n")) {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, "```")
+		}
+	}
+	return nil
+}
+
+// codeLanguage returns the language of code blocks of the file by its extension.
+func codeLanguage(filePath string) string {
+	switch path.Ext(filePath) {
+	case ".toml":
+		return "toml"
+	case ".yml", ".yaml":
+		return "yaml"
+	case ".xml":
+		return "xml"
+	default:
+		return ""
+	}
+}
+
+func dashIfEmpty(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
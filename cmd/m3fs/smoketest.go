@@ -0,0 +1,96 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/smoketest"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var clusterSmokeTestCmd = &cli.Command{
+	Name:   "smoke-test",
+	Usage:  "Check a 3fs cluster is functional by writing a file through the client and reading it back",
+	Action: runSmokeTest,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		newOutputFlag(&outputFormat),
+	},
+}
+
+func runSmokeTest(ctx *cli.Context) error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+
+	results, err := smoketest.NewSmokeTest(runner.Runtime).Run(ctx.Context)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = printSmokeTestResults(os.Stdout, results, format); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(smoketest.CheckResults(results))
+}
+
+func printSmokeTestResults(out io.Writer, results []*smoketest.StepResult, format string) error {
+	return printOutput(out, format, results, func(out io.Writer) error {
+		return printSmokeTestResultsTable(out, results)
+	})
+}
+
+func printSmokeTestResultsTable(out io.Writer, results []*smoketest.StepResult) error {
+	w := newTable(out, "STEP", "NODE", "DURATION", "STATUS")
+	for _, result := range results {
+		duration, status := result.Duration.Round(time.Millisecond).String(), "PASSED"
+		switch {
+		case result.Skipped:
+			duration, status = "-", "SKIPPED"
+		case result.Failed():
+			status = "FAILED: " + result.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Step, result.Node, duration, status)
+	}
+	return errors.Trace(w.Flush())
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/smoketest"
+)
+
+func TestSmokeTestSuite(t *testing.T) {
+	suiteRun(t, &smokeTestSuite{})
+}
+
+type smokeTestSuite struct {
+	Suite
+}
+
+func (s *smokeTestSuite) TestPrintResults() {
+	results := []*smoketest.StepResult{
+		{Step: smoketest.StepCheckMount, Node: "node1", Duration: 12 * time.Millisecond},
+		{Step: smoketest.StepWrite, Node: "node1", Duration: time.Second, Error: "no space"},
+		{Step: smoketest.StepRead, Node: "node1", Skipped: true},
+	}
+	var buf bytes.Buffer
+	s.NoError(printSmokeTestResults(&buf, results, outputFormatTable))
+	s.Equal(`STEP         NODE   DURATION  STATUS
+check mount  node1  12ms      PASSED
+write file   node1  1s        FAILED: no space
+read file    node1  -         SKIPPED
+`, buf.String())
+}
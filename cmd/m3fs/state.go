@@ -0,0 +1,129 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	stateRunID   string
+	stateInPlace bool
+)
+
+var clusterStateCmd = &cli.Command{
+	Name:  "state",
+	Usage: "Manage state files of a 3fs cluster, which are the cluster state and records of runs",
+	Subcommands: []*cli.Command{
+		{
+			Name: "decrypt",
+			Usage: "Print the decrypted cluster state or record of a run by stateEncryption.key of the config, " +
+				"or rewrite all state files in plain with --in-place before the key is removed from the config",
+			Action: decryptState,
+			Flags: cordonFlags(
+				&cli.StringFlag{
+					Name:        "run",
+					Usage:       "Print the record of the run instead of the cluster state",
+					Destination: &stateRunID,
+				},
+				&cli.BoolFlag{
+					Name:        "in-place",
+					Usage:       "Rewrite encrypted state files in plain instead of printing",
+					Destination: &stateInPlace,
+				},
+			),
+		},
+		{
+			Name: "encrypt",
+			Usage: "Encrypt plain state files by stateEncryption.key of the config, " +
+				"otherwise they're encrypted as they're saved again",
+			Action: encryptState,
+			Flags:  cordonFlags(),
+		},
+	},
+}
+
+func decryptState(ctx *cli.Context) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if stateInPlace {
+		return errors.Trace(rewriteState(cfg, "cluster state decrypt", false))
+	}
+	path := task.ClusterStateFilePath(cfg.WorkDir, cfg.Name)
+	if stateRunID != "" {
+		path = task.RunRecordFilePath(task.RunDir(cfg.WorkDir, cfg.Name, stateRunID))
+	}
+	return errors.Trace(printStateFile(os.Stdout, path))
+}
+
+func encryptState(ctx *cli.Context) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(rewriteState(cfg, "cluster state encrypt", true))
+}
+
+// printStateFile prints the decrypted content of the state file.
+func printStateFile(out io.Writer, path string) error {
+	data, err := task.ReadStateFile(path)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return errors.Errorf("state file %s not exists", path)
+		}
+		return errors.Trace(err)
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	_, err = out.Write(data)
+	return errors.Trace(err)
+}
+
+// rewriteState rewrites state files of the cluster encrypted or in plain under the lock
+// of the cluster state.
+func rewriteState(cfg *config.Config, command string, encrypt bool) error {
+	lock, err := lockClusterState(cfg, command)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer unlockCluster(lock)
+
+	paths, err := task.RewriteStateFiles(cfg.WorkDir, cfg.Name, encrypt)
+	action := "Decrypted"
+	if encrypt {
+		action = "Encrypted"
+	}
+	for _, path := range paths {
+		fmt.Printf("%s %s\n", action, path)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(paths) == 0 {
+		fmt.Println("No state file needs to be rewritten")
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/support"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// supportBundleRuns is the number of latest runs whose records and journals are bundled.
+var supportBundleRuns int
+
+var supportBundleCmd = &cli.Command{
+	Name: "support-bundle",
+	Usage: "Collect the redacted config, state, command journals, version, log file and node facts " +
+		"of a 3fs cluster into a gzipped tarball for bug reports",
+	Action: createSupportBundle,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.IntFlag{
+			Name:        "runs",
+			Usage:       "Number of latest runs whose records and command journals are collected",
+			Value:       5,
+			Destination: &supportBundleRuns,
+		},
+		&cli.StringFlag{
+			Name:        "out",
+			Usage:       "Output path (default is m3fs-support-<cluster>-<time>.tar.gz)",
+			Destination: &outputPath,
+		},
+	},
+}
+
+func createSupportBundle(ctx *cli.Context) error {
+	if supportBundleRuns < 0 {
+		return errors.New("--runs must not be negative")
+	}
+	cfg, loadErr := loadClusterConfig()
+	if loadErr != nil {
+		// the config of a failed deployment may be invalid, gather whatever exists by it
+		var err error
+		if cfg, err = loadSupportConfig(); err != nil {
+			return errors.Annotate(loadErr, "load cluster config")
+		}
+	}
+	now := time.Now()
+	name := fmt.Sprintf("m3fs-support-%s-%s", cfg.Name, now.Format("20060102-150405"))
+	output := outputPath
+	if output == "" {
+		output = name + ".tar.gz"
+	}
+	if _, err := os.Stat(output); err == nil {
+		return errors.Errorf("output path %s already exists", output)
+	} else if !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+
+	bundle := gatherSupportBundle(cfg, name, now, loadErr)
+	f, err := os.Create(output)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = bundle.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(output)
+		return errors.Trace(err)
+	}
+	fmt.Printf("Support bundle of %d file(s) is written to %s\n", len(bundle.Names()), output)
+	if notes := bundle.Notes(); len(notes) > 0 {
+		fmt.Printf("Some information isn't collected, see %s in the bundle:\n  %s\n",
+			support.NotesFileName, strings.Join(notes, "\n  "))
+	}
+	return nil
+}
+
+// loadSupportConfig loads the config as is without validation, secrets aren't resolved.
+func loadSupportConfig() (*config.Config, error) {
+	cfg, err := readClusterConfig(configFilePath, "")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if cfg.Name == "" {
+		return nil, errors.New("name of the cluster is required")
+	}
+	if dir := workDirOverride(); dir != "" {
+		cfg.WorkDir = dir
+	} else if cfg.WorkDir == "" {
+		if cfg.WorkDir, err = os.Getwd(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return cfg, nil
+}
+
+// gatherSupportBundle gathers the support bundle of the cluster, failures of gathering
+// are noted in the bundle.
+func gatherSupportBundle(cfg *config.Config, name string, now time.Time, loadErr error) *support.Bundle {
+	bundle := support.NewBundle(name, cfg.Secrets(), now)
+	if loadErr != nil {
+		bundle.Notef("load cluster config: %v", loadErr)
+	}
+	bundle.AddJSON("version.json", getVersionInfo())
+
+	if redacted, err := task.RedactConfig(cfg); err != nil {
+		bundle.Notef("redact config: %v", err)
+	} else if data, err := yaml.Marshal(redacted); err != nil {
+		bundle.Notef("encode config: %v", err)
+	} else {
+		bundle.AddRedacted("config.yaml", data)
+	}
+
+	if status, err := loadClusterStatus(cfg); err != nil {
+		bundle.Notef("load cluster status: %v", err)
+	} else {
+		bundle.AddJSON("status.json", status)
+	}
+
+	runs, err := latestRuns(cfg, supportBundleRuns)
+	if err != nil {
+		bundle.Notef("list runs: %v", err)
+	}
+	bundle.AddDir("state", task.ClusterStateDir(cfg.WorkDir, cfg.Name), task.ReadStateFile, func(rel string) bool {
+		if rel == "known_hosts" {
+			return true
+		}
+		parts := strings.Split(rel, "/")
+		return len(parts) >= 2 && parts[0] == "runs" && !slices.Contains(runs, parts[1])
+	})
+
+	var facts []*task.NodeFacts
+	var uncached []string
+	for _, node := range cfg.Nodes {
+		nodeFacts, err := task.LoadNodeFacts(cfg.WorkDir, cfg.Name, node.Name)
+		if err != nil {
+			bundle.Notef("load facts of node %s: %v", node.Name, err)
+		} else if nodeFacts == nil {
+			uncached = append(uncached, node.Name)
+		} else {
+			facts = append(facts, nodeFacts)
+		}
+	}
+	if len(uncached) > 0 {
+		bundle.Notef("facts of nodes %s aren't cached, run `m3fs cluster facts` to gather them",
+			strings.Join(uncached, ", "))
+	}
+	if len(facts) > 0 {
+		buf := new(bytes.Buffer)
+		if err = printFacts(buf, facts, outputFormatTable); err != nil {
+			bundle.Notef("summarize facts: %v", err)
+		} else {
+			bundle.Add("facts.txt", buf.Bytes())
+		}
+	}
+
+	if logFile != "" {
+		bundle.AddFile("m3fs.log", logFile, nil)
+	} else {
+		bundle.Notef("no log file, run m3fs with --log-file to keep logs for the bundle")
+	}
+	return bundle
+}
+
+// latestRuns returns ids of the latest runs of the cluster, ids of runs are ordered by time.
+func latestRuns(cfg *config.Config, n int) ([]string, error) {
+	entries, err := os.ReadDir(task.RunsDir(cfg.WorkDir, cfg.Name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+	slices.Sort(ids)
+	slices.Reverse(ids)
+	return ids[:min(n, len(ids))], nil
+}
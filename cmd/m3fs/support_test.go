@@ -0,0 +1,120 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestSupportSuite(t *testing.T) {
+	suiteRun(t, &supportSuite{})
+}
+
+type supportSuite struct {
+	Suite
+	cfg *config.Config
+}
+
+func (s *supportSuite) SetupTest() {
+	s.Suite.SetupTest()
+	password := "node-password"
+	s.cfg = &config.Config{
+		Name:    "test",
+		WorkDir: s.T().TempDir(),
+		Nodes: []config.Node{
+			{Name: "node1", Host: "10.0.0.1", Password: &password},
+			{Name: "node2", Host: "10.0.0.2"},
+		},
+	}
+	s.cfg.Services.Clickhouse.Password = "ck-password"
+	supportBundleRuns = 1
+}
+
+func (s *supportSuite) TearDownTest() {
+	supportBundleRuns, logFile = 5, ""
+	s.Suite.TearDownTest()
+}
+
+func (s *supportSuite) TestGatherSupportBundle() {
+	for _, id := range []string{"run1", "run2"} {
+		runDir := task.RunDir(s.cfg.WorkDir, "test", id)
+		s.NoError(task.SaveRunRecord(runDir, &task.RunRecord{ID: id, Status: task.RunStatusFailed}))
+		s.NoError(os.WriteFile(task.JournalFilePath(runDir),
+			[]byte(`{"command":"clickhouse-client --password ck-password"}`+"\n"), 0644))
+	}
+	s.NoError(os.WriteFile(task.KnownHostsFilePath(s.cfg.WorkDir, "test"), []byte("keys"), 0644))
+	s.NoError(task.SaveNodeFacts(s.cfg.WorkDir, "test", &task.NodeFacts{Node: "node1", Host: "10.0.0.1"}))
+	logFile = filepath.Join(s.T().TempDir(), "m3fs.log")
+	s.NoError(os.WriteFile(logFile, []byte("level=info msg=hello\n"), 0644))
+
+	bundle := gatherSupportBundle(s.cfg, "bundle", time.Now(), errors.New("invalid config"))
+	s.Equal([]string{
+		"version.json",
+		"config.yaml",
+		"status.json",
+		"state/facts/node1.json",
+		"state/runs/run2/journal.jsonl",
+		"state/runs/run2/run.json",
+		"facts.txt",
+		"m3fs.log",
+	}, bundle.Names())
+	s.Equal([]string{
+		"load cluster config: invalid config",
+		"facts of nodes node2 aren't cached, run `m3fs cluster facts` to gather them",
+	}, bundle.Notes())
+}
+
+func (s *supportSuite) TestScrubSecrets() {
+	runDir := task.RunDir(s.cfg.WorkDir, "test", "run1")
+	s.NoError(os.MkdirAll(runDir, 0755))
+	s.NoError(os.WriteFile(task.JournalFilePath(runDir),
+		[]byte(`{"command":"clickhouse-client --password ck-password"}`+"\n"), 0644))
+	bundle := gatherSupportBundle(s.cfg, "bundle", time.Now(), nil)
+	s.Contains(bundle.Notes(), "no log file, run m3fs with --log-file to keep logs for the bundle")
+
+	out := new(bytes.Buffer)
+	s.NoError(bundle.Write(out))
+	gr, err := gzip.NewReader(out)
+	s.NoError(err)
+	tr := tar.NewReader(gr)
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		s.NoError(err)
+		content, err := io.ReadAll(tr)
+		s.NoError(err)
+		files[header.Name] = string(content)
+	}
+	s.Equal(`{"command":"clickhouse-client --password xxxxx"}`+"\n", files["bundle/state/runs/run1/journal.jsonl"])
+	s.Contains(files["bundle/config.yaml"], "password: <secret:config:nodes[node1].password>")
+	for name, content := range files {
+		s.NotContains(content, "node-password", name)
+		s.NotContains(content, "ck-password", name)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var syncDryRun bool
+
+var clusterSyncCmd = &cli.Command{
+	Name: "sync",
+	Usage: "Converge 3fs services of a cluster to images of the config, only services whose " +
+		"deployed images differ are replaced",
+	Action: syncCluster,
+	Flags: append(upgradeFlags(), &cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "Print services to be synced without changing the cluster",
+		Destination: &syncDryRun,
+	}),
+}
+
+// convergedState returns a copy of the state in which services of the phases run
+// the images they are upgraded to.
+func convergedState(state *task.ClusterState, phases []*upgradePhase) *task.ClusterState {
+	converged := *state
+	converged.Nodes = make([]*task.NodeState, 0, len(state.Nodes))
+	for _, node := range state.Nodes {
+		n := *node
+		n.Services = make([]*task.ServiceState, 0, len(node.Services))
+		for _, service := range node.Services {
+			svc := *service
+			n.Services = append(n.Services, &svc)
+		}
+		converged.Nodes = append(converged.Nodes, &n)
+	}
+	for _, phase := range phases {
+		for _, node := range phase.nodes {
+			converged.SetNodeImage(node, phase.service, phase.to)
+		}
+	}
+	return &converged
+}
+
+// checkSyncable checks the config differs from the deployed cluster only in images of
+// services running the 3fs image, other differences can't be converged by replacing
+// containers of the services.
+func checkSyncable(state *task.ClusterState, cfg *config.Config, phases []*upgradePhase) error {
+	diffs, err := convergedState(state, phases).Diverge(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(diffs) > 0 {
+		return errors.Errorf("config diverges from the deployed cluster, which can't be changed by sync:\n%s",
+			strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+// syncPreviousVersion returns the previous version to record after the cluster is synced
+// to the version.
+func syncPreviousVersion(state *task.ClusterState, version string) string {
+	if state.Version == "" || state.Version == version {
+		return state.PreviousVersion
+	}
+	return state.Version
+}
+
+func syncCluster(ctx *cli.Context) error {
+	return errors.Trace(runSync(ctx.Context, "cluster sync"))
+}
+
+// runSync converges services running the 3fs image to the image of the config. It's
+// idempotent: only services on nodes whose recorded images differ from the config are
+// replaced, by the same tasks as upgrade, which wait for each of them to be ready before
+// moving on. Other nodes of the services are untouched, and each replaced node is
+// recorded at once so that an interrupted sync resumes where it stopped.
+func runSync(ctx context.Context, command string) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	lock, err := lockCluster(cfg, command)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer unlockCluster(lock)
+
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if state == nil {
+		return errors.Errorf("no recorded state of cluster %s, only clusters created by m3fs can be synced",
+			cfg.Name)
+	}
+	phases, err := planUpgrade(state, cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkSyncable(state, cfg, phases); err != nil {
+		return errors.Trace(err)
+	}
+	cordoned := skippedCordonedNodes(state)
+	phases = excludeUpgradeNodes(phases, cordoned)
+	if len(phases) == 0 {
+		logrus.Infof("Cluster %s is in sync with the config", cfg.Name)
+		return nil
+	}
+	fmt.Printf("Sync cluster %s to the config:\n", cfg.Name)
+	if err = printUpgradePlan(os.Stdout, phases); err != nil {
+		return errors.Trace(err)
+	}
+	if syncDryRun {
+		return nil
+	}
+
+	tasks, phaseOfTask := newUpgradeTasks(phases, "")
+	runner, err := newClusterRunner(cfg, command, tasks...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Runtime.CordonedNodes = cordoned
+	if artifactPath != "" {
+		if err = runner.Store(task.RuntimeArtifactPathKey, artifactPath); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	version := cfg.Images.FFFS.Tag
+	previousVersion := syncPreviousVersion(state, version)
+	recordUpgrades(runner, state, phaseOfTask)
+	runner.SetBeforeTask(func(_ context.Context, t task.Interface) error {
+		phase, ok := phaseOfTask[t]
+		if !ok {
+			return nil
+		}
+		ok, err := confirm(fmt.Sprintf("Sync %s on %d node(s) to %s?", phase.service, len(phase.nodes), phase.to))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !ok {
+			return errors.Errorf("sync of %s is aborted", phase.service)
+		}
+		if state.PreviousVersion == previousVersion {
+			return nil
+		}
+		state.PreviousVersion = previousVersion
+		return errors.Annotate(task.SaveClusterState(cfg.WorkDir, state), "record previous version")
+	})
+	if err = runner.Run(ctx); err != nil {
+		return errors.Annotate(err, "sync cluster")
+	}
+
+	if err = saveUpgradedState(runner.Runtime, state, phases); err != nil {
+		return errors.Trace(err)
+	}
+	logrus.Infof("Cluster %s is synced to %s", cfg.Name, version)
+
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/preflight"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	tasksCommand string
+	tasksOutput  string
+	explain      bool
+)
+
+var clusterTasksCmd = &cli.Command{
+	Name:   "tasks",
+	Usage:  "Print tasks of a cluster command in order without running them",
+	Action: listClusterTasks,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "command",
+			Usage:       "Cluster command whose tasks are printed: create, delete or prepare",
+			Value:       "create",
+			Destination: &tasksCommand,
+		},
+		newOutputFlag(&tasksOutput),
+	},
+}
+
+func listClusterTasks(ctx *cli.Context) error {
+	format, err := checkOutputFormat(tasksOutput)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := newCommandTasksRunner(cfg, tasksCommand)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tasks := runner.Tasks()
+	metadata := make([]task.Metadata, len(tasks))
+	for i, t := range tasks {
+		metadata[i] = task.MetadataOf(t)
+	}
+	return errors.Trace(printTasksMetadata(os.Stdout, metadata, format))
+}
+
+// newCommandTasksRunner returns a runner of tasks of the cluster command. Tasks are
+// initialized to resolve their names and steps, nothing is run.
+func newCommandTasksRunner(cfg *config.Config, command string) (*task.Runner, error) {
+	var tasks []task.Interface
+	switch command {
+	case "create":
+		tasks = createClusterTasks(cfg)
+	case "delete":
+		tasks = deleteClusterTasks(cfg)
+	case "prepare":
+		tasks = prepareClusterTasks(cfg)
+	default:
+		return nil, errors.Errorf("invalid command: %s", command)
+	}
+	runner, err := task.NewRunner(cfg, tasks...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	runner.Init()
+	return runner, nil
+}
+
+func printTasksMetadata(w io.Writer, metadata []task.Metadata, format string) error {
+	return printOutput(w, format, metadata, func(w io.Writer) error {
+		tw := newTable(w, "#", "TASK", "PHASE", "SERVICE", "SCOPE", "STEPS", "DEPENDS ON")
+		for i, m := range metadata {
+			service, deps := string(m.Service), strings.Join(m.Deps, ",")
+			if service == "" {
+				service = "-"
+			}
+			if deps == "" {
+				deps = "-"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\t%s\n", i+1, m.Name, m.Phase, service, m.Scope, m.Steps, deps)
+		}
+		return errors.Trace(tw.Flush())
+	})
+}
+
+// explainCreateCluster prints whether each task of cluster create will run and why,
+// nothing is run.
+func explainCreateCluster(cfg *config.Config) error {
+	_, plans, err := planCreateCluster(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(printTaskPlans(os.Stdout, plans))
+}
+
+// planCreateCluster returns initialized tasks of cluster create and their plans,
+// nothing is run.
+func planCreateCluster(cfg *config.Config) ([]task.Interface, []task.TaskPlan, error) {
+	var tasks []task.Interface
+	switch {
+	case onlyPreflight:
+		tasks = []task.Interface{new(preflight.PreflightTask)}
+	case skipPreflight:
+		// the preflight is explained as skipped
+		tasks = append([]task.Interface{new(preflight.PreflightTask)}, createClusterTasks(cfg)...)
+	default:
+		tasks = createClusterTasks(cfg)
+	}
+	runner, err := task.NewRunner(cfg, tasks...)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	runner.Init()
+	if !onlyPreflight {
+		if err = setupPhases(runner, phaseGate || cfg.PhaseGates); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	tasks = runner.Tasks()
+	plans := runner.Explain()
+	if skipPreflight {
+		plans[0].Run = false
+		plans[0].Reason = "skipped: excluded by --skip-preflight"
+	}
+	return tasks, plans, nil
+}
+
+func printTaskPlans(w io.Writer, plans []task.TaskPlan) error {
+	tw := newTable(w, "#", "TASK", "PHASE", "SERVICE", "PLAN")
+	running := 0
+	for i, plan := range plans {
+		service := string(plan.Service)
+		if service == "" {
+			service = "-"
+		}
+		if plan.Run {
+			running++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, plan.Name, plan.Phase, service, plan.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	_, err := fmt.Fprintf(w, "%d of %d tasks will run\n", running, len(plans))
+	return errors.Trace(err)
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/smoketest"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestClusterTasksSuite(t *testing.T) {
+	suiteRun(t, &clusterTasksSuite{})
+}
+
+type clusterTasksSuite struct {
+	Suite
+}
+
+func (s *clusterTasksSuite) TestCreateClusterTasks() {
+	cfg, err := decodeClusterConfig([]byte(`
+name: "open3fs"
+networkType: "RXE"
+nodes:
+  - name: node1
+    host: "192.168.1.1"
+    username: root
+  - name: node2
+    host: "192.168.1.2"
+    username: root
+services:
+  fdb:
+    nodes: [node1]
+  clickhouse:
+    nodes: [node1]
+  monitor:
+    nodes: [node1]
+  mgmtd:
+    nodes: [node1]
+  meta:
+    nodes: [node1]
+  storage:
+    nodes: [node1, node2]
+  client:
+    nodes: [node2]
+`), configFormatYAML)
+	s.NoError(err)
+	s.NoError(cfg.SetValidate(s.T().TempDir(), ""))
+	tasks := createClusterTasks(cfg)
+	runner, err := task.NewRunner(cfg, tasks...)
+	s.NoError(err)
+	runner.Init()
+
+	metadata := make([]task.Metadata, len(tasks))
+	for i, t := range tasks {
+		metadata[i] = task.MetadataOf(t)
+	}
+	s.Equal("PreflightTask", metadata[0].Name)
+	s.Equal(task.ScopeNode, metadata[0].Scope)
+	s.Equal(task.PhasePrepare, metadata[0].Phase)
+	s.Equal("PrepareStorageDisksTask[node1]", metadata[6].Name)
+	s.Equal(task.ScopeCluster, metadata[6].Scope)
+	s.Equal("PrepareStorageDisksTask[node2]", metadata[7].Name)
+	s.Equal("CreateStorageServiceTask", metadata[8].Name)
+	s.Equal(config.ServiceStorage, metadata[8].Service)
+	s.Equal([]string{"CreateMgmtdServiceTask", "PrepareStorageDisksTask[node1]", "PrepareStorageDisksTask[node2]"},
+		metadata[8].Deps)
+
+	buf := new(bytes.Buffer)
+	s.NoError(printTasksMetadata(buf, metadata[6:7], "text"))
+	s.Contains(buf.String(), "#  TASK                            PHASE   SERVICE  SCOPE    STEPS  DEPENDS ON\n")
+	s.Contains(buf.String(), "1  PrepareStorageDisksTask[node1]  deploy  storage  cluster  1      -\n")
+
+	// tasks are generated from the config deterministically
+	names := func(tasks []task.Interface) []string {
+		runner, err := task.NewRunner(cfg, tasks...)
+		s.NoError(err)
+		runner.Init()
+		var names []string
+		for _, t := range runner.Tasks() {
+			names = append(names, t.Name())
+		}
+		return names
+	}
+	s.Equal(names(createClusterTasks(cfg)), names(createClusterTasks(cfg)))
+	cfg.Services.Storage.Nodes = []string{"node2"}
+	s.Equal([]string{"PrepareStorageDisksTask[node2]", "CreateStorageServiceTask"},
+		names(createClusterTasks(cfg))[6:8])
+
+	// plugin tasks float into builtin tasks
+	cfg.Plugins = []config.PluginTask{
+		{Name: "cmdb", Command: "register", DeleteCommand: "unregister", After: []string{"CreateStorageServiceTask"}},
+		{Name: "precheck", Command: "check", Phase: "prepare"},
+	}
+	s.Equal([]string{"PreflightTask", "PluginTask[precheck]", "CreateFdbClusterTask"}, names(createClusterTasks(cfg))[:3])
+	s.Equal([]string{"CreateStorageServiceTask", "PluginTask[cmdb]", "InitUserAndChainTask"},
+		names(createClusterTasks(cfg))[8:11])
+	s.Equal("DeletePluginTask[cmdb]", names(deleteClusterTasks(cfg))[0])
+
+	// the cluster is verified after services and plugins are deployed
+	created := names(createClusterTasks(cfg))
+	s.Equal([]string{"Create3FSClientServiceTask", smoketest.TaskName}, created[len(created)-2:])
+	runner, err = task.NewRunner(cfg, createClusterTasks(cfg)...)
+	s.NoError(err)
+	runner.Init()
+	ordered := runner.Tasks()
+	s.Equal(task.PhaseVerify, task.MetadataOf(ordered[len(ordered)-1]).Phase)
+}
+
+// deployTask is a task of the deploy phase without steps.
+type deployTask struct {
+	task.BaseTask
+}
+
+func (t *deployTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("deployTask")
+	t.BaseTask.SetPhase(task.PhaseDeploy)
+	t.BaseTask.Init(r, logger)
+}
+
+func (s *clusterTasksSuite) TestUntilDeploySkipsVerify() {
+	cfg := config.NewConfigWithDefaults()
+	cfg.Name = "test"
+	cfg.WorkDir = s.T().TempDir()
+	// the smoke test fails without client nodes if it runs
+	runner, err := task.NewRunner(cfg, new(deployTask), new(smoketest.SmokeTestTask))
+	s.NoError(err)
+	runner.Init()
+	defer func(phase string) { untilPhase = phase }(untilPhase)
+	untilPhase = string(task.PhaseDeploy)
+	s.NoError(setupPhases(runner, false))
+
+	s.NoError(runner.Run(s.Ctx()))
+	s.True(runner.Stopped())
+
+	untilPhase = ""
+	runner, err = task.NewRunner(cfg, new(deployTask), new(smoketest.SmokeTestTask))
+	s.NoError(err)
+	runner.Init()
+	s.NoError(setupPhases(runner, false))
+	s.ErrorContains(runner.Run(s.Ctx()), "no client node")
+}
+
+func (s *clusterTasksSuite) TestInvalidCommand() {
+	_, err := newCommandTasksRunner(config.NewConfigWithDefaults(), "upgrade")
+	s.ErrorContains(err, "invalid command: upgrade")
+}
+
+func (s *clusterTasksSuite) TestPrintTaskPlans() {
+	plans := []task.TaskPlan{
+		{
+			Metadata: task.Metadata{Name: "PreflightTask", Phase: task.PhasePrepare},
+			Reason:   "skipped: excluded by --skip-preflight",
+		},
+		{
+			Metadata: task.Metadata{Name: "CreateMetaServiceTask", Phase: task.PhaseDeploy, Service: config.ServiceMeta},
+			Run:      true,
+			Reason:   "will run",
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	s.NoError(printTaskPlans(buf, plans))
+	s.Equal("#  TASK                   PHASE    SERVICE  PLAN\n"+
+		"1  PreflightTask          prepare  -        skipped: excluded by --skip-preflight\n"+
+		"2  CreateMetaServiceTask  deploy   meta     will run\n"+
+		"1 of 2 tasks will run\n", buf.String())
+}
+
+func (s *clusterTasksSuite) TestWriteRunbook() {
+	cfg, err := decodeClusterConfig([]byte(`
+name: "open3fs"
+networkType: "RXE"
+nodes:
+  - name: node1
+    host: "192.168.1.1"
+    username: root
+  - name: node2
+    host: "192.168.1.2"
+    username: root
+services:
+  fdb:
+    nodes: [node1]
+  clickhouse:
+    nodes: [node1]
+    password: "ch-secret"
+  monitor:
+    nodes: [node1]
+  mgmtd:
+    nodes: [node1]
+  meta:
+    nodes: [node1]
+  storage:
+    nodes: [node1, node2]
+  client:
+    nodes: [node2]
+`), configFormatYAML)
+	s.NoError(err)
+	s.NoError(cfg.SetValidate(s.T().TempDir(), ""))
+	runbook := filepath.Join(s.T().TempDir(), "runbook.md")
+
+	s.NoError(writeCreateRunbook(cfg, runbook))
+
+	data, err := os.ReadFile(runbook)
+	s.NoError(err)
+	content := string(data)
+	s.Contains(content, "# Runbook of creating 3fs cluster open3fs\n")
+	s.Contains(content, "| node2 | 192.168.1.2 | storage, client |\n")
+	s.Contains(content, "### 9. CreateStorageServiceTask\n\n- Phase: deploy\n- Service: storage\n")
+	s.Contains(content, "run 3FS container on node1, node2 in parallel\n")
+	s.Regexp("#### node2:/.*/storage/config.d/storage_main.toml\n\n```toml\n", content)
+	s.NotContains(content, "ch-secret")
+
+	// the runbook is deterministic
+	s.NoError(writeCreateRunbook(cfg, runbook))
+	again, err := os.ReadFile(runbook)
+	s.NoError(err)
+	s.Equal(content, string(again))
+}
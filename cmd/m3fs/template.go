@@ -14,7 +14,52 @@
 
 package main
 
-import "github.com/urfave/cli/v2"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	fsclient "github.com/open3fs/m3fs/pkg/3fs_client"
+	"github.com/open3fs/m3fs/pkg/clickhouse"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/fdb"
+	"github.com/open3fs/m3fs/pkg/meta"
+	"github.com/open3fs/m3fs/pkg/mgmtd"
+	"github.com/open3fs/m3fs/pkg/monitor"
+	"github.com/open3fs/m3fs/pkg/storage"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	renderOutDir         string
+	renderNodeName       string
+	renderIncludeSecrets bool
+)
+
+const (
+	clickhousePasswordPlaceholder = "<CLICKHOUSE_PASSWORD>"
+	// the user token is generated by mgmtd during deployment, so it's always a placeholder.
+	userTokenPlaceholder = "<USER_TOKEN>"
+	// the fdb cluster id is random for a new cluster, it's a placeholder unless the cluster has been created.
+	fdbClusterIDPlaceholder = "<FDB_DESCRIPTION>:<FDB_ID>"
+)
+
+// configRenderers are renderers of all services in the order of deployment.
+var configRenderers = []*task.ConfigRenderer{
+	fdb.ConfigRenderer,
+	clickhouse.ConfigRenderer,
+	monitor.ConfigRenderer,
+	mgmtd.ConfigRenderer,
+	meta.ConfigRenderer,
+	storage.ConfigRenderer,
+	fsclient.ConfigRenderer,
+}
 
 var tmplCmd = &cli.Command{
 	Name:    "template",
@@ -32,5 +77,159 @@ var tmplCmd = &cli.Command{
 				},
 			},
 		},
+		{
+			Name:   "list",
+			Usage:  "List names of bundled templates, which can be overridden by files in --templates-dir",
+			Action: listTemplates,
+		},
+		{
+			Name:   "render",
+			Usage:  "Render config files of all services into a directory without touching any node",
+			Action: renderTemplates,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "config",
+					Aliases:     []string{"c"},
+					Usage:       "Path to the cluster configuration file",
+					Destination: &configFilePath,
+					Required:    true,
+				},
+				&cli.StringFlag{
+					Name:        "workdir",
+					Aliases:     []string{"w"},
+					Usage:       "Path to the working directory (default is current directory)",
+					Destination: &workDir,
+				},
+				&cli.StringFlag{
+					Name:        "out",
+					Usage:       "Path to the output directory",
+					Destination: &renderOutDir,
+					Required:    true,
+				},
+				&cli.StringFlag{
+					Name:        "node",
+					Aliases:     []string{"n"},
+					Usage:       "Only render config files of the node (default is all nodes)",
+					Destination: &renderNodeName,
+				},
+				&cli.BoolFlag{
+					Name:        "include-secrets",
+					Usage:       "Render secrets instead of placeholders",
+					Destination: &renderIncludeSecrets,
+				},
+			},
+		},
 	},
 }
+
+func listTemplates(ctx *cli.Context) error {
+	for _, name := range task.TemplateNames() {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func renderTemplates(ctx *cli.Context) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if renderNodeName != "" {
+		if !slices.ContainsFunc(cfg.Nodes, func(n config.Node) bool { return n.Name == renderNodeName }) {
+			return errors.Errorf("node %s not found", renderNodeName)
+		}
+	}
+	files, err := renderConfigFiles(cfg, renderNodeName, renderIncludeSecrets)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, file := range files {
+		if err = writeRenderedFile(filepath.Join(renderOutDir, file.Node), file.RenderedFile); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	logrus.Infof("Rendered %d config files into %s", len(files), renderOutDir)
+
+	return nil
+}
+
+// nodeRenderedFile is a config file of the service rendered for the node.
+type nodeRenderedFile struct {
+	*task.RenderedFile
+	Service config.ServiceType
+	Node    string
+}
+
+// renderConfigFiles renders config files of all services in the order of deployment
+// without touching any node, only files of the node are rendered if it's not empty.
+// Secrets are rendered as placeholders unless includeSecrets is true.
+func renderConfigFiles(cfg *config.Config, nodeName string, includeSecrets bool) ([]*nodeRenderedFile, error) {
+	if !includeSecrets {
+		cfg.Services.Clickhouse.Password = clickhousePasswordPlaceholder
+	}
+
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	runner.Init()
+	r := runner.Runtime
+	r.Store(task.RuntimeUserTokenKey, userTokenPlaceholder)
+	fdbClusterID, err := recordedFdbClusterID(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r.Store(task.RuntimeFdbClusterIDKey, fdbClusterID)
+
+	for _, renderer := range configRenderers {
+		if renderer.Prepare == nil {
+			continue
+		}
+		if err = renderer.Prepare(r); err != nil {
+			return nil, errors.Annotatef(err, "prepare %s configs", renderer.Service)
+		}
+	}
+	var renderedFiles []*nodeRenderedFile
+	for _, renderer := range configRenderers {
+		for _, name := range r.ServiceNodes(renderer.Service) {
+			if nodeName != "" && name != nodeName {
+				continue
+			}
+			files, err := renderer.Render(r, r.Nodes[name])
+			if err != nil {
+				return nil, errors.Annotatef(err, "render %s configs of node %s", renderer.Service, name)
+			}
+			for _, file := range files {
+				renderedFiles = append(renderedFiles,
+					&nodeRenderedFile{RenderedFile: file, Service: renderer.Service, Node: name})
+			}
+		}
+	}
+	return renderedFiles, nil
+}
+
+// recordedFdbClusterID returns the description:ID part of the fdb cluster file recorded when
+// the cluster is created, so that rendered config files are the same as deployed ones.
+func recordedFdbClusterID(cfg *config.Config) (string, error) {
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if state == nil || state.FdbClusterFile == "" {
+		return fdbClusterIDPlaceholder, nil
+	}
+	clusterID, _, _ := strings.Cut(state.FdbClusterFile, "@")
+	return clusterID, nil
+}
+
+// writeRenderedFile writes the file into the directory mirroring its destination path on the node.
+func writeRenderedFile(dir string, file *task.RenderedFile) error {
+	filePath := filepath.Join(dir, strings.TrimPrefix(file.Path, "/"))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return errors.Annotatef(err, "create directory of %s", filePath)
+	}
+	if err := os.WriteFile(filePath, file.Data, 0644); err != nil {
+		return errors.Annotatef(err, "write %s", filePath)
+	}
+	return nil
+}
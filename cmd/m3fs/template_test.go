@@ -0,0 +1,105 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplateRenderSuite(t *testing.T) {
+	suiteRun(t, &templateRenderSuite{})
+}
+
+type templateRenderSuite struct {
+	Suite
+}
+
+func (s *templateRenderSuite) SetupTest() {
+	s.Suite.SetupTest()
+	dir := s.T().TempDir()
+	configFilePath = filepath.Join(dir, "cluster.yml")
+	workDir = filepath.Join(dir, "work")
+	s.NoError(os.WriteFile(configFilePath, []byte(`
+name: "open3fs"
+networkType: "RXE"
+nodeGroups:
+  - name: meta
+    username: root
+    ipBegin: "192.168.1.1"
+    ipEnd: "192.168.1.3"
+  - name: storage
+    username: root
+    ipBegin: "192.168.1.11"
+    ipEnd: "192.168.1.14"
+services:
+  fdb:
+    nodeGroups: [meta]
+  clickhouse:
+    nodeGroups: [meta]
+  monitor:
+    nodeGroups: [meta]
+  mgmtd:
+    nodeGroups: [meta]
+  meta:
+    nodeGroups: [meta]
+  storage:
+    nodeGroups: [storage]
+  client:
+    nodeGroups: [storage]
+`), 0644))
+}
+
+func (s *templateRenderSuite) TearDownTest() {
+	configFilePath = ""
+	workDir = ""
+	renderOutDir = ""
+}
+
+func (s *templateRenderSuite) render() map[string]string {
+	renderOutDir = s.T().TempDir()
+	s.NoError(renderTemplates(nil))
+
+	files := make(map[string]string)
+	s.NoError(filepath.WalkDir(renderOutDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(renderOutDir, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = string(data)
+		return nil
+	}))
+	return files
+}
+
+func (s *templateRenderSuite) TestRenderDeterministic() {
+	first := s.render()
+	s.NotEmpty(first)
+	for i := 0; i < 3; i++ {
+		s.Equal(first, s.render())
+	}
+
+	fdbClusterFile := first[filepath.Join("storage-node(192.168.1.11)", workDir, "storage/config.d/fdb.cluster")]
+	s.Contains(fdbClusterFile, fdbClusterIDPlaceholder+"@")
+}
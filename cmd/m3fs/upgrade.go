@@ -0,0 +1,618 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	fsclient "github.com/open3fs/m3fs/pkg/3fs_client"
+	"github.com/open3fs/m3fs/pkg/artifact"
+	"github.com/open3fs/m3fs/pkg/benchmark"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/meta"
+	"github.com/open3fs/m3fs/pkg/mgmtd"
+	"github.com/open3fs/m3fs/pkg/preflight"
+	"github.com/open3fs/m3fs/pkg/storage"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	upgradeTo              string
+	upgradeCanary          string
+	upgradeCanaryBenchmark bool
+)
+
+func upgradeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.StringFlag{
+			Name:        "artifact",
+			Aliases:     []string{"a"},
+			Usage:       "Path to the 3fs artifact containing images of the version, images are pulled if not set",
+			Destination: &artifactPath,
+		},
+		&cli.BoolFlag{
+			Name:        "skip-preflight",
+			Usage:       "Skip preflight checks of nodes, at the operator's own risk",
+			Destination: &skipPreflight,
+		},
+		&cli.BoolFlag{
+			Name:        "yes",
+			Aliases:     []string{"y"},
+			Usage:       "Don't ask for confirmation before upgrading each service, same as the global --yes",
+			Destination: &assumeYes,
+		},
+	}
+}
+
+func canaryFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name: "canary",
+			Usage: "Node to upgrade first, the rest of the cluster is upgraded only after all services " +
+				"on it are ready",
+			Destination: &upgradeCanary,
+		},
+		&cli.BoolFlag{
+			Name:        "canary-benchmark",
+			Usage:       "Benchmark data disks of the canary node before upgrading the rest, it must be a storage node",
+			Destination: &upgradeCanaryBenchmark,
+		},
+	}
+}
+
+var clusterUpgradeCmd = &cli.Command{
+	Name:   "upgrade",
+	Usage:  "Upgrade 3fs services of a cluster to a version, node by node",
+	Action: upgradeCluster,
+	Flags: append(append(upgradeFlags(), canaryFlags()...), &cli.StringFlag{
+		Name:        "to",
+		Usage:       "Version to upgrade to, which is the tag of the 3fs image",
+		Destination: &upgradeTo,
+		Required:    true,
+	}),
+}
+
+var clusterRollbackCmd = &cli.Command{
+	Name:   "rollback",
+	Usage:  "Roll 3fs services of a cluster back to the version before the last upgrade",
+	Action: rollbackCluster,
+	Flags:  append(upgradeFlags(), canaryFlags()...),
+}
+
+// upgradeAction words prompts and messages of an upgrade or a rollback, which share
+// runUpgrade.
+type upgradeAction struct {
+	// verb is "upgrade" or "roll back", prompts start with it capitalized.
+	verb string
+	past string
+	noun string
+	// typedName makes the operator type the name of the cluster to confirm the run.
+	typedName bool
+}
+
+var (
+	actionUpgrade  = upgradeAction{verb: "upgrade", past: "upgraded", noun: "upgrade"}
+	actionRollback = upgradeAction{verb: "roll back", past: "rolled back", noun: "rollback", typedName: true}
+)
+
+// title returns the verb capitalized to start a sentence.
+func (a upgradeAction) title() string {
+	return strings.ToUpper(a.verb[:1]) + a.verb[1:]
+}
+
+// confirmation returns the confirmation of the run of the action against the cluster.
+func (a upgradeAction) confirmation(prompt, clusterName string) confirmation {
+	c := confirmation{prompt: prompt}
+	if a.typedName {
+		c.typedName = clusterName
+	}
+	return c
+}
+
+// upgradeServices are services running the 3fs image in the order of upgrade.
+var upgradeServices = []config.ServiceType{
+	config.ServiceMgmtd,
+	config.ServiceMeta,
+	config.ServiceStorage,
+	config.ServiceClient,
+}
+
+// newUpgradeTask returns the task upgrading the service on the nodes.
+func newUpgradeTask(service config.ServiceType, nodes []string) task.Interface {
+	switch service {
+	case config.ServiceMgmtd:
+		return &mgmtd.UpgradeMgmtdServiceTask{Nodes: nodes}
+	case config.ServiceMeta:
+		return &meta.UpgradeMetaServiceTask{Nodes: nodes}
+	case config.ServiceStorage:
+		return &storage.UpgradeStorageServiceTask{Nodes: nodes}
+	case config.ServiceClient:
+		return &fsclient.Upgrade3FSClientServiceTask{Nodes: nodes}
+	default:
+		return nil
+	}
+}
+
+// upgradePhase is the upgrade of a service.
+type upgradePhase struct {
+	service config.ServiceType
+	nodes   []string
+	// from are the recorded images of nodes
+	from []string
+	to   string
+}
+
+// planUpgrade returns phases upgrading services to the 3fs image in the config,
+// services whose nodes already run the image are skipped.
+func planUpgrade(state *task.ClusterState, cfg *config.Config) ([]*upgradePhase, error) {
+	to, err := cfg.Images.GetImage(config.ImageName3FS)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	recorded := make(map[string]map[config.ServiceType]string, len(state.Nodes))
+	for _, node := range state.Nodes {
+		recorded[node.Name] = make(map[config.ServiceType]string, len(node.Services))
+		for _, service := range node.Services {
+			recorded[node.Name][service.Service] = service.Image
+		}
+	}
+
+	var phases []*upgradePhase
+	for _, service := range upgradeServices {
+		phase := &upgradePhase{service: service, to: to}
+		for _, nodeName := range cfg.Services.ServiceNodes(service) {
+			from := recorded[nodeName][service]
+			if from == to {
+				continue
+			}
+			phase.nodes = append(phase.nodes, nodeName)
+			phase.from = append(phase.from, from)
+		}
+		if len(phase.nodes) > 0 {
+			phases = append(phases, phase)
+		}
+	}
+	return phases, nil
+}
+
+func printUpgradePlan(w io.Writer, phases []*upgradePhase) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tNODE\tFROM\tTO")
+	for _, phase := range phases {
+		for i, node := range phase.nodes {
+			from := phase.from[i]
+			if from == "" {
+				from = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", phase.service, node, from, phase.to)
+		}
+	}
+	return errors.Trace(tw.Flush())
+}
+
+// newUpgradeTasks returns tasks upgrading services on nodes of the phases, which are
+// preceded by preflight checks and the import of the artifact. Other nodes of services
+// are untouched. The excluded node is skipped, it's the canary node which has been upgraded.
+func newUpgradeTasks(phases []*upgradePhase, excluded string) ([]task.Interface, map[task.Interface]*upgradePhase) {
+	var tasks []task.Interface
+	if skipPreflight {
+		logrus.Warn("Preflight checks are skipped")
+	} else {
+		tasks = append(tasks, new(preflight.PreflightTask))
+	}
+	if artifactPath != "" {
+		tasks = append(tasks, new(artifact.ImportArtifactTask))
+	}
+	phaseOfTask := make(map[task.Interface]*upgradePhase, len(phases))
+	for _, phase := range phases {
+		nodes := slices.DeleteFunc(slices.Clone(phase.nodes), func(node string) bool { return node == excluded })
+		if len(nodes) == 0 {
+			continue
+		}
+		t := newUpgradeTask(phase.service, nodes)
+		phaseOfTask[t] = phase
+		tasks = append(tasks, t)
+	}
+	return tasks, phaseOfTask
+}
+
+// excludeUpgradeNodes returns phases without the excluded nodes, phases left without
+// nodes are dropped.
+func excludeUpgradeNodes(phases []*upgradePhase, excluded []string) []*upgradePhase {
+	if len(excluded) == 0 {
+		return phases
+	}
+	var kept []*upgradePhase
+	for _, phase := range phases {
+		p := &upgradePhase{service: phase.service, to: phase.to}
+		for i, node := range phase.nodes {
+			if slices.Contains(excluded, node) {
+				continue
+			}
+			p.nodes = append(p.nodes, node)
+			p.from = append(p.from, phase.from[i])
+		}
+		if len(p.nodes) > 0 {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// canaryPhases returns phases upgrading services on the canary node.
+func canaryPhases(phases []*upgradePhase, canary string) []*upgradePhase {
+	var canaryPhases []*upgradePhase
+	for _, phase := range phases {
+		if slices.Contains(phase.nodes, canary) {
+			canaryPhases = append(canaryPhases, phase)
+		}
+	}
+	return canaryPhases
+}
+
+// runCanaryUpgrade upgrades services on the canary node only, and gates the rollout to
+// the rest of the cluster on readiness of them and the optional benchmark. The outcome
+// is recorded in the cluster state, other nodes are untouched if the canary fails.
+func runCanaryUpgrade(ctx context.Context, cfg *config.Config, state *task.ClusterState,
+	command string, action upgradeAction, from, version string, phases []*upgradePhase) error {
+
+	if !slices.ContainsFunc(cfg.Nodes, func(node config.Node) bool { return node.Name == upgradeCanary }) {
+		return errors.Errorf("canary node %s not exists in node list", upgradeCanary)
+	}
+	phases = canaryPhases(phases, upgradeCanary)
+	if len(phases) == 0 {
+		return errors.Errorf("no service on canary node %s needs to be %s", upgradeCanary, action.past)
+	}
+	if upgradeCanaryBenchmark && !slices.Contains(cfg.Services.Storage.Nodes, upgradeCanary) {
+		return errors.Errorf("canary node %s isn't a storage node to benchmark", upgradeCanary)
+	}
+
+	canaryRunID := runID
+	if canaryRunID != "" {
+		canaryRunID += "-canary"
+	}
+	tasks, phaseOfTask := newUpgradeTasks(phases, "")
+	runner, err := newClusterRunnerWithID(cfg, command+" --canary", canaryRunID, tasks...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c := action.confirmation(fmt.Sprintf("%s canary node %s of cluster %s to %s?",
+		action.title(), upgradeCanary, cfg.Name, version), cfg.Name)
+	if err = confirmRun(runner, command, c); err != nil {
+		return errors.Trace(err)
+	}
+	if err = recordPreviousVersion(cfg.WorkDir, state, from, version); err != nil {
+		return errors.Trace(err)
+	}
+	runner.Runtime.NodeFilter = func(node config.Node) bool { return node.Name == upgradeCanary }
+	recordUpgrades(runner, state, phaseOfTask)
+	if artifactPath != "" {
+		if err = runner.Store(task.RuntimeArtifactPathKey, artifactPath); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	err = runner.Run(ctx)
+	if err == nil && upgradeCanaryBenchmark {
+		b := benchmark.NewBenchmark(runner.Runtime)
+		b.Nodes = []string{upgradeCanary}
+		var results []*benchmark.Result
+		if results, err = b.Run(ctx); err == nil {
+			if err = printBenchmarkResultsTable(os.Stdout, results); err == nil {
+				err = checkBenchmarkResults(results)
+			}
+		}
+	}
+
+	state.Canary = &task.CanaryState{
+		Node:    upgradeCanary,
+		Version: version,
+		Status:  task.RunStatusSucceeded,
+		Time:    time.Now(),
+	}
+	if err != nil {
+		state.Canary.Status = task.RunStatusFailed
+		state.Canary.Error = err.Error()
+	}
+	if saveErr := task.SaveClusterState(cfg.WorkDir, state); saveErr != nil {
+		logrus.Warnf("Failed to record the canary outcome: %v", saveErr)
+	}
+	if err != nil {
+		return errors.Annotatef(err, "canary node %s failed, other nodes are untouched", upgradeCanary)
+	}
+	logrus.Infof("Canary node %s is %s to %s and ready", upgradeCanary, action.past, version)
+	return nil
+}
+
+// checkUpgradeCompatibility checks the config matches the deployed cluster except the version,
+// the upgrade only replaces containers of services running the 3fs image. Services of an
+// interrupted upgrade may run either version, so their recorded images aren't compared.
+func checkUpgradeCompatibility(state *task.ClusterState, cfg *config.Config, from string) error {
+	checkCfg := *cfg
+	checkCfg.Images.FFFS.Tag = from
+	fromImage, err := checkCfg.Images.GetImage(config.ImageName3FS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	phases := make([]*upgradePhase, 0, len(upgradeServices))
+	for _, service := range upgradeServices {
+		phases = append(phases, &upgradePhase{
+			service: service,
+			nodes:   checkCfg.Services.ServiceNodes(service),
+			to:      fromImage,
+		})
+	}
+	diffs, err := convergedState(state, phases).Diverge(&checkCfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(diffs) > 0 {
+		return errors.Errorf("config diverges from the deployed cluster, which can't be changed by upgrade:\n%s",
+			strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+// upgradeRecorder records images of nodes in the cluster state as soon as each of them is
+// upgraded, so that a partial upgrade can be resumed or rolled back. It passes progress
+// on to the next reporter.
+type upgradeRecorder struct {
+	next    task.ProgressReporter
+	workDir string
+	// phases are phases of upgrade tasks by names of the tasks
+	phases map[string]*upgradePhase
+
+	mu    sync.Mutex
+	state *task.ClusterState
+}
+
+// recordUpgrades makes the runner record nodes upgraded by tasks of the phases in the state.
+func recordUpgrades(runner *task.Runner, state *task.ClusterState, phaseOfTask map[task.Interface]*upgradePhase) {
+	recorder := &upgradeRecorder{
+		next:    runner.Runtime.Progress,
+		workDir: runner.Runtime.WorkDir,
+		phases:  make(map[string]*upgradePhase, len(phaseOfTask)),
+		state:   state,
+	}
+	for t, phase := range phaseOfTask {
+		recorder.phases[t.Name()] = phase
+	}
+	runner.Runtime.Progress = recorder
+}
+
+// TaskStarted implements task.ProgressReporter.
+func (r *upgradeRecorder) TaskStarted(index, total int, taskName string) {
+	if r.next != nil {
+		r.next.TaskStarted(index, total, taskName)
+	}
+}
+
+// NodeChanged implements task.ProgressReporter.
+func (r *upgradeRecorder) NodeChanged(taskName, node, status string) {
+	if phase, ok := r.phases[taskName]; ok && status == task.NodeStatusOK {
+		r.mu.Lock()
+		r.state.SetNodeImage(node, phase.service, phase.to)
+		r.state.UpdateTime = time.Now()
+		if err := task.SaveClusterState(r.workDir, r.state); err != nil {
+			logrus.Warnf("Failed to record the upgrade of %s on node %s: %v", phase.service, node, err)
+		}
+		r.mu.Unlock()
+	}
+	if r.next != nil {
+		r.next.NodeChanged(taskName, node, status)
+	}
+}
+
+// TaskEnded implements task.ProgressReporter.
+func (r *upgradeRecorder) TaskEnded(taskName string, err error) {
+	if r.next != nil {
+		r.next.TaskEnded(taskName, err)
+	}
+}
+
+// recordPreviousVersion records the version the cluster is upgraded from before any node
+// is touched, so the cluster can be rolled back even if the upgrade fails halfway. The
+// version recorded by a previous run is kept if the cluster is already at the version.
+func recordPreviousVersion(workDir string, state *task.ClusterState, from, version string) error {
+	if from == version {
+		return nil
+	}
+	state.PreviousVersion = from
+	return errors.Annotate(task.SaveClusterState(workDir, state), "record previous version")
+}
+
+func upgradeCluster(ctx *cli.Context) error {
+	return errors.Trace(runUpgrade(ctx.Context, "cluster upgrade", actionUpgrade,
+		func(*task.ClusterState) (string, error) {
+			return upgradeTo, nil
+		}))
+}
+
+func rollbackCluster(ctx *cli.Context) error {
+	return errors.Trace(runUpgrade(ctx.Context, "cluster rollback", actionRollback,
+		func(state *task.ClusterState) (string, error) {
+			if state.PreviousVersion == "" {
+				return "", errors.Errorf("no previous version of cluster %s is recorded", state.Cluster)
+			}
+			return state.PreviousVersion, nil
+		}))
+}
+
+// runUpgrade upgrades 3fs services of the cluster to the version, service by service in the
+// order of mgmtd, meta, storage and client. Nodes of a service are upgraded one by one, or
+// by the deployment strategy of the config if it's set. The run is confirmed with its banner
+// first, and each service before it's upgraded.
+func runUpgrade(ctx context.Context, command string, action upgradeAction,
+	getVersion func(*task.ClusterState) (string, error)) error {
+
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	lock, err := lockCluster(cfg, command)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer unlockCluster(lock)
+
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if state == nil {
+		return errors.Errorf("no recorded state of cluster %s, only clusters created by m3fs can be upgraded",
+			cfg.Name)
+	}
+	version, err := getVersion(state)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	from := state.Version
+	if from == "" {
+		from = cfg.Images.FFFS.Tag
+	}
+	if err = checkUpgradeCompatibility(state, cfg, from); err != nil {
+		return errors.Trace(err)
+	}
+	configuredVersion := cfg.Images.FFFS.Tag
+	cfg.Images.FFFS.Tag = version
+	phases, err := planUpgrade(state, cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cordoned := skippedCordonedNodes(state)
+	if slices.Contains(cordoned, upgradeCanary) {
+		return errors.Errorf("canary node %s is cordoned", upgradeCanary)
+	}
+	phases = excludeUpgradeNodes(phases, cordoned)
+	if len(phases) == 0 {
+		return errors.Errorf("cluster %s is already at version %s", cfg.Name, version)
+	}
+	fmt.Printf("%s cluster %s from %s to %s:\n", action.title(), cfg.Name, from, version)
+	if err = printUpgradePlan(os.Stdout, phases); err != nil {
+		return errors.Trace(err)
+	}
+
+	if upgradeCanary != "" {
+		if err = runCanaryUpgrade(ctx, cfg, state, command, action, from, version, phases); err != nil {
+			return errors.Trace(err)
+		}
+	} else if upgradeCanaryBenchmark {
+		return errors.New("--canary-benchmark requires --canary")
+	}
+
+	tasks, phaseOfTask := newUpgradeTasks(phases, upgradeCanary)
+	runner, err := newClusterRunner(cfg, command, tasks...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Runtime.CordonedNodes = cordoned
+	if upgradeCanary != "" {
+		runner.Runtime.NodeFilter = func(node config.Node) bool { return node.Name != upgradeCanary }
+	}
+	if artifactPath != "" {
+		if err = runner.Store(task.RuntimeArtifactPathKey, artifactPath); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	recordUpgrades(runner, state, phaseOfTask)
+	prompt := fmt.Sprintf("%s cluster %s from %s to %s?", action.title(), cfg.Name, from, version)
+	if upgradeCanary != "" {
+		prompt = fmt.Sprintf("Canary node %s is ready, %s the rest of cluster %s to %s?",
+			upgradeCanary, action.verb, cfg.Name, version)
+	}
+	if err = confirmRun(runner, command, action.confirmation(prompt, cfg.Name)); err != nil {
+		return errors.Trace(err)
+	}
+	runner.SetBeforeTask(func(_ context.Context, t task.Interface) error {
+		phase, ok := phaseOfTask[t]
+		if !ok {
+			return nil
+		}
+		nodeNum := len(phase.nodes)
+		if slices.Contains(phase.nodes, upgradeCanary) {
+			nodeNum--
+		}
+		ok, err := confirm(fmt.Sprintf("%s %s on %d node(s) to %s?", action.title(), phase.service, nodeNum, version))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !ok {
+			return errors.Errorf("%s of %s is aborted", action.noun, phase.service)
+		}
+		return errors.Trace(recordPreviousVersion(cfg.WorkDir, state, from, version))
+	})
+	if err = runner.Run(ctx); err != nil {
+		return errors.Annotatef(err, "%s cluster to %s", action.verb, version)
+	}
+
+	if err = saveUpgradedState(runner.Runtime, state, phases); err != nil {
+		return errors.Trace(err)
+	}
+	logrus.Infof("Cluster %s is %s from %s to %s", cfg.Name, action.past, from, version)
+	if configuredVersion != version {
+		logrus.Warnf("Set images.3fs.tag to %s in %s to keep the config in line with the cluster",
+			version, configFilePath)
+	}
+
+	return nil
+}
+
+// saveUpgradedState records the state of the cluster after services of the phases are
+// upgraded. Other fields of the recorded state are kept, e.g. cordons and images of
+// services on cordoned nodes, which aren't upgraded.
+func saveUpgradedState(r *task.Runtime, state *task.ClusterState, phases []*upgradePhase) error {
+	upgraded, err := task.NewClusterState(r)
+	if err != nil {
+		return errors.Annotate(err, "generate cluster state")
+	}
+	state.RunID = upgraded.RunID
+	state.Version = upgraded.Version
+	state.UpdateTime = upgraded.UpdateTime
+	state.Config = upgraded.Config
+	if upgraded.MgmtdLeader != "" {
+		state.MgmtdLeader = upgraded.MgmtdLeader
+	}
+	for _, phase := range phases {
+		for _, node := range phase.nodes {
+			state.SetNodeImage(node, phase.service, phase.to)
+		}
+	}
+	return errors.Trace(task.SaveClusterState(r.Cfg.WorkDir, state))
+}
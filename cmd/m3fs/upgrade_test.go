@@ -0,0 +1,320 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/storage"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestUpgradeSuite(t *testing.T) {
+	suiteRun(t, &upgradeSuite{})
+}
+
+type upgradeSuite struct {
+	Suite
+
+	cfg   *config.Config
+	state *task.ClusterState
+}
+
+func (s *upgradeSuite) SetupTest() {
+	s.Suite.SetupTest()
+	var err error
+	s.cfg, err = decodeClusterConfig([]byte(`
+name: "open3fs"
+networkType: "RXE"
+nodes:
+  - name: node1
+    host: "192.168.1.1"
+    username: root
+  - name: node2
+    host: "192.168.1.2"
+    username: root
+services:
+  fdb:
+    nodes: [node1]
+  clickhouse:
+    nodes: [node1]
+  monitor:
+    nodes: [node1]
+  mgmtd:
+    nodes: [node1]
+  meta:
+    nodes: [node1]
+  storage:
+    nodes: [node1, node2]
+  client:
+    nodes: [node2]
+images:
+  3fs:
+    repo: "open3fs/3fs"
+    tag: "20250410"
+`), configFormatYAML)
+	s.NoError(err)
+	s.NoError(s.cfg.SetValidate(s.T().TempDir(), ""))
+	s.state, err = task.NewClusterState(&task.Runtime{Cfg: s.cfg, Services: &s.cfg.Services})
+	s.NoError(err)
+}
+
+func (s *upgradeSuite) TearDownTest() {
+	assumeYes = false
+	skipPreflight = false
+	includeCordoned = false
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+func (s *upgradeSuite) TestPlanUpgrade() {
+	s.cfg.Images.FFFS.Tag = "20250501"
+
+	phases, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+
+	s.Len(phases, 4)
+	s.Equal(config.ServiceMgmtd, phases[0].service)
+	s.Equal(config.ServiceMeta, phases[1].service)
+	s.Equal(config.ServiceStorage, phases[2].service)
+	s.Equal([]string{"node1", "node2"}, phases[2].nodes)
+	s.Equal([]string{"open3fs/3fs:20250410", "open3fs/3fs:20250410"}, phases[2].from)
+	s.Equal("open3fs/3fs:20250501", phases[2].to)
+	s.Equal(config.ServiceClient, phases[3].service)
+
+	buf := new(bytes.Buffer)
+	s.NoError(printUpgradePlan(buf, phases))
+	s.Regexp(`storage\s+node2\s+open3fs/3fs:20250410\s+open3fs/3fs:20250501`, buf.String())
+}
+
+func (s *upgradeSuite) TestPlanUpgradeSkipUpgraded() {
+	s.cfg.Images.FFFS.Tag = "20250501"
+	for _, node := range s.state.Nodes {
+		for _, service := range node.Services {
+			if service.Service == config.ServiceMgmtd {
+				service.Image = "open3fs/3fs:20250501"
+			}
+		}
+	}
+
+	phases, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+	s.Len(phases, 3)
+	s.Equal(config.ServiceMeta, phases[0].service)
+
+	s.cfg.Images.FFFS.Tag = "20250410"
+	phases, err = planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+	s.Len(phases, 1)
+	s.Equal(config.ServiceMgmtd, phases[0].service)
+}
+
+func (s *upgradeSuite) TestExcludeCordonedNodes() {
+	s.cfg.Images.FFFS.Tag = "20250501"
+	phases, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+	s.True(s.state.CordonNode("node2"))
+
+	kept := excludeUpgradeNodes(phases, skippedCordonedNodes(s.state))
+	s.Len(kept, 3)
+	s.Equal(config.ServiceStorage, kept[2].service)
+	s.Equal([]string{"node1"}, kept[2].nodes)
+	s.Equal([]string{"open3fs/3fs:20250410"}, kept[2].from)
+	s.Equal([]string{"node1", "node2"}, phases[2].nodes)
+
+	includeCordoned = true
+	s.Len(excludeUpgradeNodes(phases, skippedCordonedNodes(s.state)), 4)
+}
+
+func (s *upgradeSuite) TestCanaryPhases() {
+	s.cfg.Images.FFFS.Tag = "20250501"
+	phases, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+
+	canary := canaryPhases(phases, "node2")
+	s.Len(canary, 2)
+	s.Equal(config.ServiceStorage, canary[0].service)
+	s.Equal(config.ServiceClient, canary[1].service)
+
+	skipPreflight = true
+	tasks, phaseOfTask := newUpgradeTasks(phases, "node2")
+	// the client phase only upgrades the canary node
+	s.Len(tasks, 3)
+	s.Len(phaseOfTask, 3)
+	for t, phase := range phaseOfTask {
+		s.NotEqual(config.ServiceClient, phase.service)
+		if storageTask, ok := t.(*storage.UpgradeStorageServiceTask); ok {
+			s.Equal([]string{"node1"}, storageTask.Nodes)
+		}
+	}
+}
+
+func (s *upgradeSuite) TestUpgradeTasksOnlyPlannedNodes() {
+	s.cfg.Images.FFFS.Tag = "20250501"
+	s.state.SetNodeImage("node1", config.ServiceStorage, "open3fs/3fs:20250501")
+	phases, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+
+	skipPreflight = true
+	tasks, _ := newUpgradeTasks(phases, "")
+	s.Len(tasks, 4)
+	storageTask, ok := tasks[2].(*storage.UpgradeStorageServiceTask)
+	s.True(ok)
+	s.Equal([]string{"node2"}, storageTask.Nodes)
+}
+
+func (s *upgradeSuite) TestCheckUpgradeCompatibilityPartialUpgrade() {
+	s.state.SetNodeImage("node1", config.ServiceMgmtd, "open3fs/3fs:20250501")
+	s.cfg.Images.FFFS.Tag = "20250501"
+	s.NoError(checkUpgradeCompatibility(s.state, s.cfg, "20250410"))
+}
+
+func (s *upgradeSuite) TestUpgradeRecorder() {
+	s.cfg.Images.FFFS.Tag = "20250501"
+	phases, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+	s.NoError(recordPreviousVersion(s.cfg.WorkDir, s.state, "20250410", "20250501"))
+
+	recorder := &upgradeRecorder{
+		workDir: s.cfg.WorkDir,
+		phases:  map[string]*upgradePhase{"UpgradeStorageServiceTask": phases[2]},
+		state:   s.state,
+	}
+	recorder.NodeChanged("UpgradeStorageServiceTask", "node1", task.NodeStatusOK)
+	recorder.NodeChanged("UpgradeStorageServiceTask", "node2", task.NodeStatusFailed)
+
+	saved, err := task.LoadClusterState(s.cfg.WorkDir, s.cfg.Name)
+	s.NoError(err)
+	s.Equal("20250410", saved.PreviousVersion)
+	phases, err = planUpgrade(saved, s.cfg)
+	s.NoError(err)
+	s.Equal([]string{"node2"}, phases[2].nodes)
+}
+
+func (s *upgradeSuite) TestSaveUpgradedStateKeepsFields() {
+	s.state.ClickhouseTopology = "1 shard(s) x 1 replica(s)"
+	s.state.MgmtdLeader = "node1"
+	s.state.FdbClusterFile = "test:abc@192.168.1.1:4500"
+	s.True(s.state.CordonNode("node2"))
+	s.cfg.Images.FFFS.Tag = "20250501"
+	phases, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+	phases = excludeUpgradeNodes(phases, []string{"node2"})
+
+	r := &task.Runtime{Cfg: s.cfg, Services: &s.cfg.Services, WorkDir: s.cfg.WorkDir}
+	s.NoError(saveUpgradedState(r, s.state, phases))
+
+	saved, err := task.LoadClusterState(s.cfg.WorkDir, s.cfg.Name)
+	s.NoError(err)
+	s.Equal("20250501", saved.Version)
+	s.Equal("1 shard(s) x 1 replica(s)", saved.ClickhouseTopology)
+	s.Equal("node1", saved.MgmtdLeader)
+	s.Equal("test:abc@192.168.1.1:4500", saved.FdbClusterFile)
+	s.True(saved.Cordoned("node2"))
+	phases, err = planUpgrade(saved, s.cfg)
+	s.NoError(err)
+	s.Len(phases, 2)
+	s.Equal([]string{"node2"}, phases[0].nodes)
+}
+
+func (s *upgradeSuite) TestCheckUpgradeCompatibility() {
+	s.cfg.Images.FFFS.Tag = "20250501"
+	s.NoError(checkUpgradeCompatibility(s.state, s.cfg, "20250410"))
+
+	s.cfg.Services.Storage.Nodes = []string{"node1"}
+	err := checkUpgradeCompatibility(s.state, s.cfg, "20250410")
+	s.Error(err)
+	s.Contains(err.Error(), "config diverges from the deployed cluster")
+}
+
+func (s *upgradeSuite) TestCheckSyncable() {
+	// storage of node2 is left behind by a partial upgrade
+	s.cfg.Images.FFFS.Tag = "20250501"
+	for _, node := range s.state.Nodes {
+		for _, service := range node.Services {
+			if service.Image == "open3fs/3fs:20250410" {
+				service.Image = "open3fs/3fs:20250501"
+			}
+		}
+	}
+	s.state.SetNodeImage("node2", config.ServiceStorage, "open3fs/3fs:20250410")
+
+	phases, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+	s.Len(phases, 1)
+	s.Equal([]string{"node2"}, phases[0].nodes)
+	s.NoError(checkSyncable(s.state, s.cfg, phases))
+	// only the storage service of node2 is replaced
+	skipPreflight = true
+	tasks, _ := newUpgradeTasks(phases, "")
+	s.Len(tasks, 1)
+	s.Equal([]string{"node2"}, tasks[0].(*storage.UpgradeStorageServiceTask).Nodes)
+	// the recorded state is untouched
+	again, err := planUpgrade(s.state, s.cfg)
+	s.NoError(err)
+	s.Equal(phases, again)
+
+	s.cfg.Images.Fdb.Tag = "7.3.64"
+	err = checkSyncable(s.state, s.cfg, phases)
+	s.Error(err)
+	s.Contains(err.Error(), "image of fdb on node node1")
+}
+
+func (s *upgradeSuite) TestSyncPreviousVersion() {
+	s.state.PreviousVersion = "20250301"
+	s.Equal("20250301", syncPreviousVersion(s.state, "20250410"))
+	s.Equal("20250410", syncPreviousVersion(s.state, "20250501"))
+}
+
+func (s *upgradeSuite) TestUpgradeActionConfirmation() {
+	s.Equal("Upgrade", actionUpgrade.title())
+	s.Equal(confirmation{prompt: "Upgrade?"}, actionUpgrade.confirmation("Upgrade?", "test-cluster"))
+
+	s.Equal("Roll back", actionRollback.title())
+	c := actionRollback.confirmation("Roll back?", "test-cluster")
+	s.Equal("test-cluster", c.typedName)
+	isTerminal := stdinIsTerminal
+	defer func() { stdinIsTerminal = isTerminal }()
+	stdinIsTerminal = func() bool { return true }
+	stdinReader = bufio.NewReader(strings.NewReader("y\n"))
+	s.ErrorContains(c.require("cluster rollback"), "cluster rollback isn't confirmed")
+}
+
+func (s *upgradeSuite) TestGlobalWorkDirNotResetByCommand() {
+	defer func() { workDir, globalWorkDir = "", "" }()
+	var got string
+	app := &cli.App{
+		Flags: []cli.Flag{&cli.StringFlag{Name: "work-dir", Destination: &globalWorkDir}},
+		Commands: []*cli.Command{{
+			Name:  "upgrade",
+			Flags: upgradeFlags(),
+			Action: func(*cli.Context) error {
+				got = workDirOverride()
+				return nil
+			},
+		}},
+	}
+
+	s.NoError(app.Run([]string{"m3fs", "--work-dir", "/global", "upgrade", "-c", "cluster.yml"}))
+	s.Equal("/global", got)
+	s.NoError(app.Run([]string{"m3fs", "--work-dir", "/global", "upgrade", "-c", "cluster.yml", "-w", "/cmd"}))
+	s.Equal("/cmd", got)
+}
@@ -0,0 +1,417 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+var (
+	verifyConfigNodes  string
+	verifyConfigRepair bool
+)
+
+// verifyConfigParallel is the number of nodes whose config files are verified at the same time.
+const verifyConfigParallel = 10
+
+// diffContext is the number of unchanged lines printed around changed lines of a diff.
+const diffContext = 2
+
+// defines statuses of verified config files
+const (
+	configFileOK       = "ok"
+	configFileDrifted  = "drifted"
+	configFileMissing  = "missing"
+	configFileRepaired = "repaired"
+	// configFileSkipped is the status of files containing values generated during deployment,
+	// which can't be rendered again.
+	configFileSkipped = "skipped"
+	configFileFailed  = "failed"
+)
+
+var clusterVerifyConfigCmd = &cli.Command{
+	Name: "verify-config",
+	Usage: "Verify config files on nodes of a 3fs cluster match ones rendered from the cluster config, " +
+		"drifted files are reported with diffs",
+	Action: verifyClusterConfig,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Aliases:     []string{"c"},
+			Usage:       "Path to the cluster configuration file",
+			Destination: &configFilePath,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Aliases:     []string{"w"},
+			Usage:       "Path to the working directory (default is current directory)",
+			Destination: &workDir,
+		},
+		&cli.StringFlag{
+			Name:    "nodes",
+			Aliases: []string{"n"},
+			Usage: "Comma separated node names, hosts, glob patterns of them or service names " +
+				"to select nodes (default is all nodes)",
+			Destination: &verifyConfigNodes,
+		},
+		&cli.BoolFlag{
+			Name: "repair",
+			Usage: "Push rendered config files to replace drifted and missing ones on all nodes or none, " +
+				"services aren't restarted",
+			Destination: &verifyConfigRepair,
+		},
+		newOutputFlag(&outputFormat),
+	},
+}
+
+// configFileCheck is the result of verifying a config file on its node.
+type configFileCheck struct {
+	*nodeRenderedFile
+	status string
+	// diff is lines of the diff from the rendered file to the drifted file
+	diff []string
+	err  error
+}
+
+// configFileResult is the structured output of verifying a config file.
+type configFileResult struct {
+	Node    string             `json:"node"`
+	Service config.ServiceType `json:"service"`
+	Path    string             `json:"path"`
+	Status  string             `json:"status"`
+	Error   string             `json:"error,omitempty"`
+	Diff    []string           `json:"diff,omitempty"`
+}
+
+func verifyClusterConfig(ctx *cli.Context) error {
+	format, err := checkOutputFormat(outputFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	nodes, err := selectNodes(cfg, verifyConfigNodes)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if state == nil {
+		return errors.Errorf("no recorded state of cluster %s, only clusters created by m3fs can be verified",
+			cfg.Name)
+	}
+	if verifyConfigRepair {
+		lock, err := lockCluster(cfg, "cluster verify-config --repair")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer unlockCluster(lock)
+	}
+
+	password := cfg.Services.Clickhouse.Password
+	files, err := renderConfigFiles(cfg, "", true)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner, err := task.NewRunner(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runner.Init()
+	checks := verifyConfigFiles(ctx.Context, runner.Runtime, nodes, files, verifyConfigRepair)
+
+	if password != "" {
+		// diffs mustn't reveal the clickhouse password
+		for _, check := range checks {
+			for i, line := range check.diff {
+				check.diff[i] = strings.ReplaceAll(line, password, clickhousePasswordPlaceholder)
+			}
+		}
+	}
+	if err = printConfigFileChecks(os.Stdout, format, checks); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(configFileChecksError(checks))
+}
+
+// verifyConfigFiles compares sha256 hashes of rendered config files of the nodes to files on
+// the nodes, drifted and missing files are replaced by rendered ones if repair is true.
+// Checks are returned in the order of files.
+func verifyConfigFiles(ctx context.Context, r *task.Runtime, nodes []config.Node,
+	files []*nodeRenderedFile, repair bool) []*configFileCheck {
+
+	selected := make(map[string][]*configFileCheck, len(nodes))
+	for _, node := range nodes {
+		selected[node.Name] = nil
+	}
+	var checks []*configFileCheck
+	for _, file := range files {
+		if _, ok := selected[file.Node]; !ok {
+			continue
+		}
+		check := &configFileCheck{nodeRenderedFile: file}
+		checks = append(checks, check)
+		selected[file.Node] = append(selected[file.Node], check)
+	}
+
+	// checks of a node are only updated by the worker of the node
+	verifyNode := func(ctx context.Context, node config.Node) error {
+		logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+		em, err := r.NodeManager(node, logger)
+		for _, check := range selected[node.Name] {
+			if err != nil {
+				check.status, check.err = configFileFailed, err
+				continue
+			}
+			verifyConfigFile(ctx, em, check)
+		}
+		return nil
+	}
+	workerPool := common.NewWorkerPool(verifyNode, max(min(verifyConfigParallel, len(nodes)), 1))
+	workerPool.Start(ctx)
+	for _, node := range nodes {
+		if len(selected[node.Name]) > 0 {
+			workerPool.Add(node)
+		}
+	}
+	workerPool.Join()
+
+	if repair {
+		repairConfigFiles(ctx, r, nodes, selected)
+	}
+	return checks
+}
+
+// verifyConfigFile verifies the config file on the node of the manager.
+func verifyConfigFile(ctx context.Context, em *external.Manager, check *configFileCheck) {
+	if bytes.Contains(check.Data, []byte(userTokenPlaceholder)) {
+		check.status = configFileSkipped
+		return
+	}
+	hash := sha256.Sum256(check.Data)
+	// the script is quoted for the shell running commands on the node
+	out, err := em.Runner.Exec(ctx, "bash", "-c",
+		fmt.Sprintf("'if [ -f %[1]s ]; then sha256sum %[1]s; fi'", check.Path))
+	if err != nil {
+		check.status, check.err = configFileFailed, errors.Annotatef(err, "hash %s", check.Path)
+		return
+	}
+	fields := strings.Fields(out)
+	switch {
+	case len(fields) == 0:
+		check.status = configFileMissing
+	case fields[0] == hex.EncodeToString(hash[:]):
+		check.status = configFileOK
+	default:
+		check.status = configFileDrifted
+		data, err := em.Runner.Exec(ctx, "cat", check.Path)
+		if err != nil {
+			check.status, check.err = configFileFailed, errors.Annotatef(err, "read %s", check.Path)
+			return
+		}
+		check.diff = diffLines(string(check.Data), data)
+	}
+}
+
+// repairConfigFiles replaces drifted and missing files of the checks by rendered ones,
+// files of all nodes are distributed in two phases, so that either all of them are
+// repaired or none are.
+func repairConfigFiles(ctx context.Context, r *task.Runtime, nodes []config.Node,
+	checks map[string][]*configFileCheck) {
+
+	var (
+		nodeFiles []task.NodeFiles
+		repairing []*configFileCheck
+	)
+	for _, node := range nodes {
+		files := task.NodeFiles{Node: node}
+		for _, check := range checks[node.Name] {
+			if check.status == configFileDrifted || check.status == configFileMissing {
+				files.Files = append(files.Files, check.RenderedFile)
+				repairing = append(repairing, check)
+			}
+		}
+		if len(files.Files) > 0 {
+			nodeFiles = append(nodeFiles, files)
+		}
+	}
+	if len(repairing) == 0 {
+		return
+	}
+
+	err := task.DistributeFiles(ctx, r, nodeFiles, verifyConfigParallel)
+	for _, check := range repairing {
+		if err != nil {
+			check.status, check.err = configFileFailed, errors.Annotatef(err, "repair %s", check.Path)
+		} else {
+			check.status = configFileRepaired
+		}
+	}
+}
+
+func printConfigFileChecks(out io.Writer, format string, checks []*configFileCheck) error {
+	results := make([]configFileResult, len(checks))
+	for i, check := range checks {
+		results[i] = configFileResult{
+			Node:    check.Node,
+			Service: check.Service,
+			Path:    check.Path,
+			Status:  check.status,
+			Diff:    check.diff,
+		}
+		if check.err != nil {
+			results[i].Error = check.err.Error()
+		}
+	}
+	return printOutput(out, format, results, func(out io.Writer) error {
+		return printConfigFileChecksTable(out, checks)
+	})
+}
+
+func printConfigFileChecksTable(out io.Writer, checks []*configFileCheck) error {
+	for _, check := range checks {
+		if check.status != configFileDrifted && check.err == nil {
+			continue
+		}
+		fmt.Fprintf(out, "==> %s %s %s\n", check.Node, check.Path, check.status)
+		if check.err != nil {
+			fmt.Fprintf(out, "error: %v\n", check.err)
+		}
+		for _, line := range check.diff {
+			fmt.Fprintln(out, line)
+		}
+	}
+	w := newTable(out, "NODE", "SERVICE", "FILE", "STATUS")
+	for _, check := range checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check.Node, check.Service, check.Path, check.status)
+	}
+	return errors.Trace(w.Flush())
+}
+
+// configFileChecksError returns the error if any config file is drifted, missing or failed
+// to be verified.
+func configFileChecksError(checks []*configFileCheck) error {
+	var drifted, failed, repaired int
+	for _, check := range checks {
+		switch check.status {
+		case configFileDrifted, configFileMissing:
+			drifted++
+		case configFileFailed:
+			failed++
+		case configFileRepaired:
+			repaired++
+		}
+	}
+	if repaired > 0 {
+		logrus.Warnf("Repaired %d config files, restart their services to apply them", repaired)
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to verify %d of %d config files", failed, len(checks))
+	}
+	if drifted > 0 {
+		return errors.Errorf("%d of %d config files drifted, repair them by --repair", drifted, len(checks))
+	}
+	return nil
+}
+
+// diffLines returns lines of the diff from expected to actual, which are prefixed by "-"
+// for removed lines, "+" for added lines and " " for unchanged lines. Unchanged lines
+// farther than diffContext lines from changes are elided as "...", nil is returned if no
+// line is changed.
+func diffLines(expected, actual string) []string {
+	a := strings.Split(strings.TrimRight(expected, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(actual, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+
+	if !slices.ContainsFunc(lines, func(line string) bool { return line[0] != ' ' }) {
+		return nil
+	}
+	var diff []string
+	elided := false
+	for i, line := range lines {
+		if line[0] == ' ' && !nearChange(lines, i) {
+			if !elided {
+				diff = append(diff, "...")
+				elided = true
+			}
+			continue
+		}
+		diff = append(diff, line)
+		elided = false
+	}
+	return diff
+}
+
+// nearChange returns whether the line is at most diffContext lines away from a changed line.
+func nearChange(lines []string, i int) bool {
+	for j := max(i-diffContext, 0); j <= min(i+diffContext, len(lines)-1); j++ {
+		if lines[j][0] != ' ' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+func TestVerifyConfigSuite(t *testing.T) {
+	suiteRun(t, &verifyConfigSuite{})
+}
+
+type verifyConfigSuite struct {
+	Suite
+
+	script  *externaltest.Script
+	runtime *task.Runtime
+	nodes   []config.Node
+	files   []*nodeRenderedFile
+}
+
+func (s *verifyConfigSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.script = externaltest.NewScript()
+	s.nodes = []config.Node{{Name: "node1"}, {Name: "node2"}}
+	s.runtime = &task.Runtime{
+		LocalEm:        s.script.Manager("local", log.Logger),
+		NewNodeManager: s.script.NodeManager,
+	}
+	s.files = []*nodeRenderedFile{
+		s.file("node1", "/root/3fs/meta/config.d/meta.toml", "a\nb\n"),
+		s.file("node2", "/root/3fs/meta/config.d/meta.toml", "a\nb\n"),
+		s.file("node2", "/root/3fs/meta/config.d/token.txt", userTokenPlaceholder),
+	}
+}
+
+func (s *verifyConfigSuite) file(node, path, data string) *nodeRenderedFile {
+	return &nodeRenderedFile{
+		RenderedFile: &task.RenderedFile{Path: path, Data: []byte(data)},
+		Service:      config.ServiceMeta,
+		Node:         node,
+	}
+}
+
+func (s *verifyConfigSuite) hash(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:]) + "  /root/3fs/meta/config.d/meta.toml\n"
+}
+
+func (s *verifyConfigSuite) statuses(checks []*configFileCheck) []string {
+	statuses := make([]string, len(checks))
+	for i, check := range checks {
+		statuses[i] = check.status
+	}
+	return statuses
+}
+
+func (s *verifyConfigSuite) TestVerify() {
+	s.script.OnNode("node1", `sha256sum`, externaltest.Response{Output: s.hash("a\nb\n")})
+	s.script.OnNode("node2", `sha256sum`, externaltest.Response{Output: s.hash("a\nc\n")})
+	s.script.OnNode("node2", `^cat `, externaltest.Response{Output: "a\nc\n"})
+
+	checks := verifyConfigFiles(s.Ctx(), s.runtime, s.nodes, s.files, false)
+
+	s.Equal([]string{configFileOK, configFileDrifted, configFileSkipped}, s.statuses(checks))
+	s.Equal([]string{" a", "-b", "+c"}, checks[1].diff)
+	s.Zero(s.script.Count("", `^scp`))
+	s.Error(configFileChecksError(checks), "1 of 3 config files drifted, repair them by --repair")
+}
+
+func (s *verifyConfigSuite) TestVerifyRemoteCommands() {
+	s.script.OnNode("node2", `sha256sum`, externaltest.Response{Output: s.hash("a\nc\n")})
+	s.script.OnNode("node2", `^cat `, externaltest.Response{Output: "a\nc\n"})
+
+	verifyConfigFiles(s.Ctx(), s.runtime, s.nodes[1:], s.files, false)
+
+	var commands []string
+	for _, invocation := range s.script.Invocations("node2", "") {
+		commands = append(commands, invocation.CommandLine())
+	}
+	s.Equal([]string{
+		"bash -c 'if [ -f /root/3fs/meta/config.d/meta.toml ]; then " +
+			"sha256sum /root/3fs/meta/config.d/meta.toml; fi'",
+		"cat /root/3fs/meta/config.d/meta.toml",
+	}, commands)
+}
+
+func (s *verifyConfigSuite) TestVerifySelectedNodes() {
+	checks := verifyConfigFiles(s.Ctx(), s.runtime, s.nodes[1:], s.files, false)
+
+	// the missing file has empty output of sha256sum
+	s.Equal([]string{configFileMissing, configFileSkipped}, s.statuses(checks))
+	s.Zero(s.script.Count("node1", ""))
+}
+
+func (s *verifyConfigSuite) TestRepair() {
+	tmpDir := s.T().TempDir()
+	s.script.OnNode("local", `^mktemp`, externaltest.Response{Output: tmpDir})
+	s.script.OnNode("node1", `sha256sum`, externaltest.Response{ExitCode: 1})
+	s.script.OnNode("node2", `^sha256sum \S+\.m3fs-staged$`, externaltest.Response{Output: s.hash("a\nb\n")})
+
+	checks := verifyConfigFiles(s.Ctx(), s.runtime, s.nodes, s.files, true)
+
+	s.Equal([]string{configFileFailed, configFileRepaired, configFileSkipped}, s.statuses(checks))
+	s.Equal(1, s.script.Count("node2", `^mkdir -m 0777 -p /root/3fs/meta/config.d$`))
+	localPath := filepath.Join(tmpDir, "node2", "0-meta.toml")
+	s.Equal(1, s.script.Count("node2", `^scp `+localPath+` /root/3fs/meta/config.d/meta.toml.m3fs-staged$`))
+	s.Equal(1, s.script.Count("node2", `mv -f /root/3fs/meta/config.d/meta.toml.m3fs-staged `))
+	data, err := os.ReadFile(localPath)
+	s.NoError(err)
+	s.Equal("a\nb\n", string(data))
+	s.Error(configFileChecksError(checks), "failed to verify 1 of 3 config files")
+}
+
+func (s *verifyConfigSuite) TestRepairAllOrNone() {
+	s.script.OnNode("local", `^mktemp`, externaltest.Response{Output: s.T().TempDir()})
+	s.script.On(`^sha256sum \S+\.m3fs-staged$`, externaltest.Response{Output: s.hash("a\nb\n")})
+	s.script.OnNode("node2", `mv -f`, externaltest.Response{ExitCode: 1})
+
+	checks := verifyConfigFiles(s.Ctx(), s.runtime, s.nodes, s.files, true)
+
+	// the file committed on node1 is rolled back as node2 fails to commit
+	s.Equal([]string{configFileFailed, configFileFailed, configFileSkipped}, s.statuses(checks))
+	s.Contains(checks[0].err.Error(), "commit phase of distributing files failed on node node2")
+	s.Equal(1, s.script.Count("node1", `then mv -f /root/3fs/meta/config.d/meta.toml.m3fs-backup `))
+}
+
+func (s *verifyConfigSuite) TestPrintJSON() {
+	checks := []*configFileCheck{
+		{nodeRenderedFile: s.files[0], status: configFileDrifted, diff: []string{"-b", "+c"}},
+		{nodeRenderedFile: s.files[1], status: configFileFailed, err: errors.New("timeout")},
+	}
+	out := new(bytes.Buffer)
+
+	s.NoError(printConfigFileChecks(out, outputFormatJSON, checks))
+
+	s.JSONEq(`[
+		{"node": "node1", "service": "meta", "path": "/root/3fs/meta/config.d/meta.toml",
+			"status": "drifted", "diff": ["-b", "+c"]},
+		{"node": "node2", "service": "meta", "path": "/root/3fs/meta/config.d/meta.toml",
+			"status": "failed", "error": "timeout"}
+	]`, out.String())
+}
+
+func (s *verifyConfigSuite) TestDiffLines() {
+	expected := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	actual := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	s.Equal([]string{"...", " 3", " 4", "-5", "+five", " 6", " 7", "...", " 11", " 12", "+13"},
+		diffLines(expected, actual))
+	s.Nil(diffLines("a\n", "a"))
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+const gitShaPrefixLen = 7
+
+var (
+	versionFormat string
+	versionShort  bool
+)
+
+var versionCmd = &cli.Command{
+	Name:   "version",
+	Usage:  "Show version info of m3fs",
+	Action: showVersion,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "format",
+			Aliases:     []string{"f"},
+			Usage:       "Output format: text or json",
+			Value:       "text",
+			Destination: &versionFormat,
+		},
+		&cli.BoolFlag{
+			Name:        "short",
+			Usage:       "Print the version only",
+			Destination: &versionShort,
+		},
+	},
+}
+
+// versionInfo is the version info of this binary.
+type versionInfo struct {
+	Version   string `json:"version"`
+	GitSha    string `json:"gitSha"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+func getVersionInfo() *versionInfo {
+	return &versionInfo{
+		Version:   common.Version,
+		GitSha:    common.GitSha,
+		BuildTime: common.BuildTime,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+}
+
+// getGitShaPrefix returns the abbreviated git sha, a sha shorter than the
+// prefix is returned as is.
+func getGitShaPrefix(sha string) string {
+	if len(sha) <= gitShaPrefixLen {
+		return sha
+	}
+	return sha[:gitShaPrefixLen]
+}
+
+func versionText() string {
+	info := getVersionInfo()
+	return fmt.Sprintf(`%s
+Git SHA: %s
+Build At: %s
+Go Version: %s
+Go OS/Arch: %s/%s`,
+		info.Version,
+		getGitShaPrefix(info.GitSha),
+		info.BuildTime,
+		info.GoVersion,
+		info.OS,
+		info.Arch)
+}
+
+func showVersion(ctx *cli.Context) error {
+	if versionShort {
+		fmt.Println(common.Version)
+		return nil
+	}
+	switch versionFormat {
+	case "text":
+		fmt.Println(versionText())
+	case "json":
+		data, err := json.MarshalIndent(getVersionInfo(), "", "  ")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		fmt.Println(string(data))
+	default:
+		return errors.Errorf("invalid format: %s", versionFormat)
+	}
+	return nil
+}
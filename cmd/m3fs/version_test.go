@@ -0,0 +1,55 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/common"
+)
+
+func TestVersionSuite(t *testing.T) {
+	suiteRun(t, &versionSuite{})
+}
+
+type versionSuite struct {
+	Suite
+}
+
+func (s *versionSuite) TestGetGitShaPrefix() {
+	s.Equal("", getGitShaPrefix(""))
+	s.Equal("abc", getGitShaPrefix("abc"))
+	s.Equal("abcdef1", getGitShaPrefix("abcdef1"))
+	s.Equal("abcdef1", getGitShaPrefix("abcdef1234567890"))
+}
+
+func (s *versionSuite) TestVersionTextWithoutGitSha() {
+	gitSha := common.GitSha
+	defer func() { common.GitSha = gitSha }()
+	common.GitSha = ""
+
+	s.Contains(versionText(), "Git SHA: \n")
+}
+
+func (s *versionSuite) TestVersionInfo() {
+	version, gitSha := common.Version, common.GitSha
+	defer func() { common.Version, common.GitSha = version, gitSha }()
+	common.Version, common.GitSha = "v1.0.0", "abcdef1234567890"
+
+	info := getVersionInfo()
+	s.Equal("v1.0.0", info.Version)
+	s.Equal("abcdef1234567890", info.GitSha)
+	s.Contains(versionText(), "Git SHA: abcdef1\n")
+}
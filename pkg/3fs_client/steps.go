@@ -15,13 +15,58 @@
 package fsclient
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"path"
+	"path/filepath"
 	"strings"
 
+	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
 	"github.com/open3fs/m3fs/pkg/task"
 )
 
+// systemdUnitDir is the dir of systemd units installed by the administrator.
+const systemdUnitDir = "/etc/systemd/system"
+
+// MountUnitName returns name of the systemd unit restoring the mount of the client
+// container after reboot.
+func MountUnitName(containerName string) string {
+	return fmt.Sprintf("m3fs-%s.service", containerName)
+}
+
+// MountedFsType returns the type of the filesystem mounted at the mountpoint on the
+// node of the manager, it's empty if nothing is mounted at the mountpoint.
+func MountedFsType(ctx context.Context, em *external.Manager, mountpoint string) (string, error) {
+	out, err := em.Runner.Exec(ctx, "cat", "/proc/mounts")
+	if err != nil {
+		return "", errors.Annotate(err, "get mounts")
+	}
+	return parseMountedFsType(out, mountpoint), nil
+}
+
+// parseMountedFsType returns the type of the filesystem mounted at the mountpoint from
+// content of /proc/mounts, the last mount wins if the mountpoint is mounted repeatedly.
+func parseMountedFsType(mounts, mountpoint string) string {
+	mountpoint = path.Clean(mountpoint)
+	fsType := ""
+	for _, line := range strings.Split(mounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[1] == mountpoint {
+			fsType = fields[2]
+		}
+	}
+	return fsType
+}
+
+// IsFuseFsType returns whether the filesystem type is mounted by fuse, like the
+// 3fs client does.
+func IsFuseFsType(fsType string) bool {
+	return fsType == "fuse" || strings.HasPrefix(fsType, "fuse.")
+}
+
 type umountHostMountponitStep struct {
 	task.BaseStep
 }
@@ -45,3 +90,137 @@ func (s *umountHostMountponitStep) Execute(ctx context.Context) error {
 	s.Logger.Infof("Successfully umount %s", mp)
 	return nil
 }
+
+type verifyMountStep struct {
+	task.BaseStep
+}
+
+func (s *verifyMountStep) Execute(ctx context.Context) error {
+	mp := s.Runtime.Services.Client.HostMountpoint
+	err := s.Runtime.WaitServiceReady(ctx, config.ServiceClient,
+		func(ctx context.Context) (bool, string, error) {
+			fsType, err := MountedFsType(ctx, s.Em, mp)
+			if err != nil {
+				return false, "", errors.Trace(err)
+			}
+			if fsType == "" {
+				return false, fmt.Sprintf("%s is not mounted", mp), nil
+			}
+			if !IsFuseFsType(fsType) {
+				return false, fmt.Sprintf("%s is mounted by %s instead of 3fs", mp, fsType), nil
+			}
+			// the filesystem is accessible only if the fuse daemon serves it
+			out, err := s.Em.Runner.Exec(ctx, "stat", "-f", mp)
+			return err == nil, out, errors.Trace(err)
+		})
+	if err != nil {
+		return errors.Annotatef(err, "verify mount of %s", mp)
+	}
+	s.Logger.Infof("Verified %s is mounted", mp)
+	return nil
+}
+
+type installMountUnitStep struct {
+	task.BaseStep
+}
+
+func (s *installMountUnitStep) Execute(ctx context.Context) error {
+	client := s.Runtime.Services.Client
+	found, err := s.Runtime.CheckOptionalTool(ctx, s.Em.Runner.Exec, task.ToolSystemctl, s.Logger)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !found {
+		s.Logger.Warnf("%s won't be mounted again after reboot", client.HostMountpoint)
+		return nil
+	}
+	runtime, err := s.Runtime.ContainerRuntime(ctx, s.Em, s.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data, err := renderMountUnit(s.Runtime, runtime)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	localEm := s.Runtime.LocalEm
+	tmpDir, err := localEm.FS.MkdirTemp(ctx, s.Runtime.LocalTempDir(), "3fs-client")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.Runtime.RegisterTempDir("", tmpDir)
+	defer func() {
+		if err := localEm.FS.RemoveAll(ctx, tmpDir); err != nil {
+			s.Logger.Warnf("Failed to remove temporary directory %s: %v", tmpDir, err)
+		}
+	}()
+	unitName := MountUnitName(client.ContainerName)
+	localPath := filepath.Join(tmpDir, unitName)
+	if err = localEm.FS.WriteFile(localPath, data, 0644); err != nil {
+		return errors.Trace(err)
+	}
+	workDir := getServiceWorkDir(s.Runtime.WorkDir)
+	if err = s.Em.FS.MkdirAll(ctx, workDir); err != nil {
+		return errors.Trace(err)
+	}
+	remotePath := path.Join(workDir, unitName)
+	if err = s.Em.Runner.Scp(ctx, localPath, remotePath); err != nil {
+		return errors.Annotatef(err, "copy %s to %s", localPath, remotePath)
+	}
+	if _, err = s.Em.Runner.Exec(ctx, "cp", remotePath, path.Join(systemdUnitDir, unitName)); err != nil {
+		return errors.Annotatef(err, "install systemd unit %s", unitName)
+	}
+	if _, err = s.Em.Runner.Exec(ctx, "systemctl", "daemon-reload"); err != nil {
+		return errors.Annotate(err, "reload systemd")
+	}
+	if _, err = s.Em.Runner.Exec(ctx, "systemctl", "enable", unitName); err != nil {
+		return errors.Annotatef(err, "enable systemd unit %s", unitName)
+	}
+	s.Logger.Infof("Installed systemd unit %s to mount %s after reboot", unitName, client.HostMountpoint)
+	return nil
+}
+
+// renderMountUnit renders the systemd unit starting the client container, which mounts
+// the host mountpoint, after the container runtime on boot.
+func renderMountUnit(r *task.Runtime, runtime config.ContainerRuntime) ([]byte, error) {
+	tmpl, err := r.NewTemplate("hf3fs_fuse_mount.service").Parse(string(ClientMountServiceTmpl))
+	if err != nil {
+		return nil, errors.Annotate(err, "parse template of hf3fs_fuse_mount.service.tmpl")
+	}
+	data := new(bytes.Buffer)
+	err = tmpl.Execute(data, map[string]any{
+		"ClusterName":    r.Cfg.Name,
+		"ContainerName":  r.Services.Client.ContainerName,
+		"HostMountpoint": r.Services.Client.HostMountpoint,
+		"RuntimeCmd":     external.ContainerRuntimeCmd(runtime),
+		"RuntimeService": fmt.Sprintf("%s.service", runtime),
+	})
+	if err != nil {
+		return nil, errors.Annotate(err, "execute template of hf3fs_fuse_mount.service.tmpl")
+	}
+	return data.Bytes(), nil
+}
+
+type removeMountUnitStep struct {
+	task.BaseStep
+}
+
+func (s *removeMountUnitStep) Execute(ctx context.Context) error {
+	unitName := MountUnitName(s.Runtime.Services.Client.ContainerName)
+	unitPath := path.Join(systemdUnitDir, unitName)
+	if _, err := s.Em.Runner.Exec(ctx, "test", "-e", unitPath); err != nil {
+		s.Logger.Infof("Systemd unit %s is not installed, skip removing it", unitName)
+		return nil
+	}
+	if _, err := s.Em.Runner.Exec(ctx, "systemctl", "disable", unitName); err != nil {
+		return errors.Annotatef(err, "disable systemd unit %s", unitName)
+	}
+	if _, err := s.Em.Runner.Exec(ctx, "rm", "-f", unitPath); err != nil {
+		return errors.Annotatef(err, "remove systemd unit %s", unitName)
+	}
+	if _, err := s.Em.Runner.Exec(ctx, "systemctl", "daemon-reload"); err != nil {
+		return errors.Annotate(err, "reload systemd")
+	}
+	s.Logger.Infof("Removed systemd unit %s", unitName)
+	return nil
+}
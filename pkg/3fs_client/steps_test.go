@@ -15,11 +15,15 @@
 package fsclient
 
 import (
+	"os"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
 	ttask "github.com/open3fs/m3fs/tests/task"
 )
 
@@ -60,3 +64,208 @@ func (s *umountHostMountpointSuite) TestWithNotMount() {
 
 	s.MockRunner.AssertExpectations(s.T())
 }
+
+func TestParseMountedFsTypeSuite(t *testing.T) {
+	suiteRun(t, &parseMountedFsTypeSuite{})
+}
+
+type parseMountedFsTypeSuite struct {
+	suite.Suite
+}
+
+func (s *parseMountedFsTypeSuite) Test() {
+	mounts := "/dev/sda1 / ext4 rw 0 0\n" +
+		"tmpfs /mnt/3fs tmpfs rw 0 0\n" +
+		"hf3fs.test /mnt/3fs fuse.hf3fs rw 0 0\n"
+	s.Equal("fuse.hf3fs", parseMountedFsType(mounts, "/mnt/3fs/"))
+	s.Equal("ext4", parseMountedFsType(mounts, "/"))
+	s.Empty(parseMountedFsType(mounts, "/mnt"))
+
+	s.True(IsFuseFsType("fuse.hf3fs"))
+	s.True(IsFuseFsType("fuse"))
+	s.False(IsFuseFsType("fuseblk2"))
+	s.False(IsFuseFsType("tmpfs"))
+}
+
+func TestVerifyMountStepSuite(t *testing.T) {
+	suiteRun(t, &verifyMountStepSuite{})
+}
+
+type verifyMountStepSuite struct {
+	ttask.StepSuite
+
+	step *verifyMountStep
+}
+
+func (s *verifyMountStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Services.Client.HostMountpoint = "/mnt/3fs"
+	s.Cfg.Services.Client.ReadinessTimeout = config.Duration(50 * time.Millisecond)
+	s.Cfg.Services.Client.ReadinessInterval = config.Duration(10 * time.Millisecond)
+	s.SetupRuntime()
+	s.step = &verifyMountStep{}
+	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
+}
+
+func (s *verifyMountStepSuite) Test() {
+	s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).
+		Return("hf3fs.test /mnt/3fs fuse.hf3fs rw 0 0\n", nil)
+	s.MockRunner.On("Exec", "stat", []string{"-f", "/mnt/3fs"}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *verifyMountStepSuite) TestNotMounted() {
+	s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).
+		Return("tmpfs /mnt/3fs tmpfs rw 0 0\n", nil)
+
+	err := s.step.Execute(s.Ctx())
+	s.Error(err)
+	s.Contains(err.Error(), "last probe output: /mnt/3fs is mounted by tmpfs instead of 3fs")
+	s.MockRunner.AssertNotCalled(s.T(), "Exec", "stat", mock.Anything)
+}
+
+func TestInstallMountUnitStepSuite(t *testing.T) {
+	suiteRun(t, &installMountUnitStepSuite{})
+}
+
+type installMountUnitStepSuite struct {
+	ttask.StepSuite
+
+	step *installMountUnitStep
+}
+
+func (s *installMountUnitStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Name = "test-cluster"
+	s.Cfg.ContainerRuntime = config.ContainerRuntimePodman
+	s.Cfg.Services.Client.ContainerName = "3fs-client"
+	s.Cfg.Services.Client.HostMountpoint = "/mnt/3fs"
+	s.SetupRuntime()
+	s.step = &installMountUnitStep{}
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1"}, s.Logger)
+}
+
+func (s *installMountUnitStepSuite) Test() {
+	s.MockRunner.On("Exec", "sh", []string{"-c", "'command -v systemctl'"}).Return("/usr/bin/systemctl", nil)
+	s.MockLocalFS.On("MkdirTemp", os.TempDir(), "3fs-client").Return("/tmp/3fs-client.xxx", nil)
+	unit := `[Unit]
+Description=3FS mount /mnt/3fs of cluster test-cluster
+Wants=network-online.target
+After=network-online.target podman.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/bin/env podman start 3fs-client
+ExecStop=/usr/bin/env podman stop 3fs-client
+ExecStopPost=-/usr/bin/env umount /mnt/3fs
+
+[Install]
+WantedBy=multi-user.target
+`
+	s.MockLocalFS.On("WriteFile", "/tmp/3fs-client.xxx/m3fs-3fs-client.service",
+		[]byte(unit), os.FileMode(0644)).Return(nil)
+	s.MockFS.On("MkdirAll", "/root/3fs/client").Return(nil)
+	s.MockRunner.On("Scp", "/tmp/3fs-client.xxx/m3fs-3fs-client.service",
+		"/root/3fs/client/m3fs-3fs-client.service").Return(nil)
+	s.MockRunner.On("Exec", "cp", []string{"/root/3fs/client/m3fs-3fs-client.service",
+		"/etc/systemd/system/m3fs-3fs-client.service"}).Return("", nil)
+	s.MockRunner.On("Exec", "systemctl", []string{"daemon-reload"}).Return("", nil)
+	s.MockRunner.On("Exec", "systemctl", []string{"enable", "m3fs-3fs-client.service"}).Return("", nil)
+	s.MockLocalFS.On("RemoveAll", "/tmp/3fs-client.xxx").Return(nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockLocalFS.AssertExpectations(s.T())
+	s.MockFS.AssertExpectations(s.T())
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *installMountUnitStepSuite) TestWithoutSystemd() {
+	s.MockRunner.On("Exec", "sh", []string{"-c", "'command -v systemctl'"}).
+		Return("", errors.New("exit status 1"))
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockLocalFS.AssertNotCalled(s.T(), "WriteFile")
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *installMountUnitStepSuite) TestWithoutSystemdRequired() {
+	s.Runtime.RequireOptionalTools = true
+	s.MockRunner.On("Exec", "sh", []string{"-c", "'command -v systemctl'"}).
+		Return("", errors.New("exit status 1"))
+
+	s.ErrorContains(s.step.Execute(s.Ctx()),
+		"systemctl required by mounting the 3fs client again after reboot is not found")
+	s.MockLocalFS.AssertNotCalled(s.T(), "WriteFile")
+}
+
+func TestRemoveMountUnitStepSuite(t *testing.T) {
+	suiteRun(t, &removeMountUnitStepSuite{})
+}
+
+type removeMountUnitStepSuite struct {
+	ttask.StepSuite
+
+	step *removeMountUnitStep
+}
+
+func (s *removeMountUnitStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Services.Client.ContainerName = "3fs-client"
+	s.SetupRuntime()
+	s.step = &removeMountUnitStep{}
+	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
+}
+
+func (s *removeMountUnitStepSuite) Test() {
+	s.MockRunner.On("Exec", "test", []string{"-e", "/etc/systemd/system/m3fs-3fs-client.service"}).
+		Return("", nil)
+	s.MockRunner.On("Exec", "systemctl", []string{"disable", "m3fs-3fs-client.service"}).Return("", nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", "/etc/systemd/system/m3fs-3fs-client.service"}).
+		Return("", nil)
+	s.MockRunner.On("Exec", "systemctl", []string{"daemon-reload"}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *removeMountUnitStepSuite) TestNotInstalled() {
+	s.MockRunner.On("Exec", "test", []string{"-e", "/etc/systemd/system/m3fs-3fs-client.service"}).
+		Return("", errors.New("exit status 1"))
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Exec", "systemctl", mock.Anything)
+}
+
+func TestPrepareConfigSetupSuite(t *testing.T) {
+	suiteRun(t, &prepareConfigSetupSuite{})
+}
+
+type prepareConfigSetupSuite struct {
+	ttask.StepSuite
+}
+
+func (s *prepareConfigSetupSuite) Test() {
+	s.SetupRuntime()
+	s.Runtime.MgmtdProtocol = "RDMA"
+	setup := newPrepareConfigSetup(s.Runtime)
+	s.Empty(setup.MgmtdServerAddresses)
+	s.Equal(map[string]any{"ReadOnly": false}, setup.ExtraMainTomlData)
+	s.Equal(map[string]any{"AllowOther": true}, setup.ExtraLauncherTomlData)
+
+	s.Runtime.Services.Client.MountOptions = []string{"ro"}
+	s.Runtime.Services.Client.MgmtdEndpoints = []string{"10.0.0.1:8000", "10.0.0.2:8000"}
+	setup = newPrepareConfigSetup(s.Runtime)
+	s.Equal(`["RDMA://10.0.0.1:8000","RDMA://10.0.0.2:8000"]`, setup.MgmtdServerAddresses)
+	s.Equal(map[string]any{"ReadOnly": true}, setup.ExtraMainTomlData)
+}
@@ -16,7 +16,9 @@ package fsclient
 
 import (
 	"embed"
+	"fmt"
 	"path"
+	"strings"
 
 	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
@@ -34,6 +36,8 @@ var (
 	ClientFuseMainLauncherTomlTmpl []byte
 	// ClientMainTomlTmpl is the template content of hf3fs_fuse_main.toml
 	ClientMainTomlTmpl []byte
+	// ClientMountServiceTmpl is the template content of the systemd unit restoring the mount
+	ClientMountServiceTmpl []byte
 )
 
 func init() {
@@ -47,6 +51,15 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	ClientMountServiceTmpl, err = templatesFs.ReadFile("templates/hf3fs_fuse_mount.service.tmpl")
+	if err != nil {
+		panic(err)
+	}
+
+	task.RegisterTemplate("hf3fs_fuse_main_launcher.toml.tmpl", &ClientFuseMainLauncherTomlTmpl)
+	task.RegisterTemplate("hf3fs_fuse_main.toml.tmpl", &ClientMainTomlTmpl)
+	task.RegisterTemplate("hf3fs_fuse_mount.service.tmpl", &ClientMountServiceTmpl)
 }
 
 const (
@@ -59,7 +72,59 @@ func getServiceWorkDir(workDir string) string {
 	return path.Join(workDir, "client")
 }
 
-// Create3FSClientServiceTask is a task for creating 3fs client services.
+// mgmtdServerAddresses returns mgmtd server addresses of mgmtd endpoints of the client,
+// it's empty if the endpoints aren't set.
+func mgmtdServerAddresses(r *task.Runtime) string {
+	endpoints := r.Services.Client.MgmtdEndpoints
+	if len(endpoints) == 0 {
+		return ""
+	}
+	addresses := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		addresses[i] = fmt.Sprintf(`"%s://%s"`, r.MgmtdProtocol, endpoint)
+	}
+	return fmt.Sprintf("[%s]", strings.Join(addresses, ","))
+}
+
+func newPrepareConfigSetup(r *task.Runtime) *steps.Prepare3FSConfigStepSetup {
+	mount := r.Services.Client.Mount()
+	return &steps.Prepare3FSConfigStepSetup{
+		Service:              ServiceName,
+		ServiceWorkDir:       getServiceWorkDir(r.WorkDir),
+		MainAppTomlTmpl:      []byte(""),
+		MainLauncherTomlTmpl: ClientFuseMainLauncherTomlTmpl,
+		MainTomlTmpl:         ClientMainTomlTmpl,
+		ExtraMainTomlData: map[string]any{
+			"ReadOnly": mount.ReadOnly,
+		},
+		ExtraLauncherTomlData: map[string]any{
+			"AllowOther": mount.AllowOther,
+		},
+		MgmtdServerAddresses: mgmtdServerAddresses(r),
+		Extra3FSConfigFilesFunc: func(runtime *task.Runtime) []*steps.Extra3FSConfigFile {
+			token, _ := runtime.LoadString(task.RuntimeUserTokenKey)
+			return []*steps.Extra3FSConfigFile{
+				{
+					FileName: "token.txt",
+					Data:     []byte(token),
+				},
+			}
+		},
+		ExtraConfig: r.Services.Client.ExtraConfig,
+	}
+}
+
+// ConfigRenderer renders config files of 3fs client service.
+var ConfigRenderer = &task.ConfigRenderer{
+	Service: config.ServiceClient,
+	Render: func(r *task.Runtime, node config.Node) ([]*task.RenderedFile, error) {
+		return steps.Render3FSConfigs(r, newPrepareConfigSetup(r), node)
+	},
+}
+
+// Create3FSClientServiceTask is a task for creating 3fs client services. The host
+// mountpoint is verified to be mounted, and a systemd unit is installed on each node to
+// restore the mount after reboot.
 type Create3FSClientServiceTask struct {
 	task.BaseTask
 }
@@ -67,42 +132,19 @@ type Create3FSClientServiceTask struct {
 // Init initializes the task.
 func (t *Create3FSClientServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("Create3FSClientServiceTask")
+	t.BaseTask.SetService(config.ServiceClient)
+	t.BaseTask.SetDeps("InitUserAndChainTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Client.Nodes))
 	client := r.Cfg.Services.Client
 	for i, node := range client.Nodes {
 		nodes[i] = r.Nodes[node]
 	}
-	runContainerVolumes := []*external.VolumeArgs{}
-	if client.HostMountpoint != "" {
-		runContainerVolumes = append(runContainerVolumes, &external.VolumeArgs{
-			Source: client.HostMountpoint,
-			Target: client.HostMountpoint,
-			Rshare: common.Pointer(true),
-		})
-	}
 	workDir := getServiceWorkDir(r.WorkDir)
 	t.SetSteps([]task.StepConfig{
 		{
-			Nodes:    nodes,
-			Parallel: true,
-			NewStep: steps.NewPrepare3FSConfigStepFunc(&steps.Prepare3FSConfigStepSetup{
-				Service:              ServiceName,
-				ServiceWorkDir:       workDir,
-				MainAppTomlTmpl:      []byte(""),
-				MainLauncherTomlTmpl: ClientFuseMainLauncherTomlTmpl,
-				MainTomlTmpl:         ClientMainTomlTmpl,
-				Extra3FSConfigFilesFunc: func(runtime *task.Runtime) []*steps.Extra3FSConfigFile {
-					token, _ := r.LoadString(task.RuntimeUserTokenKey)
-					return []*steps.Extra3FSConfigFile{
-						{
-							FileName: "token.txt",
-							Data:     []byte(token),
-						},
-					}
-				},
-			},
-			),
+			Nodes:   []config.Node{nodes[0]},
+			NewStep: steps.NewDistribute3FSConfigStepFunc(newPrepareConfigSetup(r), nodes),
 		},
 		{
 			Nodes: []config.Node{nodes[0]},
@@ -117,15 +159,66 @@ func (t *Create3FSClientServiceTask) Init(r *task.Runtime, logger log.Interface)
 		{
 			Nodes:    nodes,
 			Parallel: true,
-			NewStep: steps.NewRun3FSContainerStepFunc(
-				&steps.Run3FSContainerStepSetup{
-					ImgName:        config.ImageName3FS,
-					ContainerName:  client.ContainerName,
-					Service:        ServiceName,
-					WorkDir:        workDir,
-					ExtraVolumes:   runContainerVolumes,
-					UseRdmaNetwork: true,
-				}),
+			Rollout:  true,
+			NewStep:  steps.NewRun3FSContainerStepFunc(newRunContainerSetup(r)),
+		},
+		{
+			Nodes:    nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return new(verifyMountStep) },
+		},
+		{
+			Nodes:    nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return new(installMountUnitStep) },
+		},
+	})
+}
+
+func newRunContainerSetup(r *task.Runtime) *steps.Run3FSContainerStepSetup {
+	client := r.Services.Client
+	runContainerVolumes := []*external.VolumeArgs{}
+	if client.HostMountpoint != "" {
+		runContainerVolumes = append(runContainerVolumes, &external.VolumeArgs{
+			Source: client.HostMountpoint,
+			Target: client.HostMountpoint,
+			Rshare: common.Pointer(true),
+		})
+	}
+	return &steps.Run3FSContainerStepSetup{
+		ImgName:        config.ImageName3FS,
+		ContainerName:  client.ContainerName,
+		Service:        ServiceName,
+		ServiceType:    config.ServiceClient,
+		WorkDir:        getServiceWorkDir(r.WorkDir),
+		ExtraVolumes:   runContainerVolumes,
+		UseRdmaNetwork: true,
+	}
+}
+
+// Upgrade3FSClientServiceTask is a task for upgrading 3fs client services to the image in the
+// config. Nodes are upgraded one by one unless the deployment strategy of the config is set,
+// the host mountpoint is umounted after the old
+// container is removed, and mounted again by the new container.
+type Upgrade3FSClientServiceTask struct {
+	task.BaseTask
+
+	// Nodes are names of nodes to upgrade, all nodes of the service are upgraded if it's empty.
+	Nodes []string
+}
+
+// Init initializes the task.
+func (t *Upgrade3FSClientServiceTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("Upgrade3FSClientServiceTask")
+	t.BaseTask.SetService(config.ServiceClient)
+	t.BaseTask.Init(r, logger)
+	nodes := r.SelectNodes(r.Services.Client.Nodes, t.Nodes)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:   nodes,
+			Rollout: true,
+			NewStep: steps.NewUpgrade3FSContainerStepFunc(newRunContainerSetup(r),
+				func() task.Step { return new(umountHostMountponitStep) }),
 		},
 	})
 }
@@ -138,6 +231,7 @@ type Delete3FSClientServiceTask struct {
 // Init initializes the task.
 func (t *Delete3FSClientServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("Delete3FSClientServiceTask")
+	t.BaseTask.SetService(config.ServiceClient)
 	t.BaseTask.Init(r, logger)
 	client := r.Services.Client
 	nodes := make([]config.Node, len(client.Nodes))
@@ -152,6 +246,7 @@ func (t *Delete3FSClientServiceTask) Init(r *task.Runtime, logger log.Interface)
 			NewStep: steps.NewRm3FSContainerStepFunc(
 				client.ContainerName,
 				ServiceName,
+				config.ServiceClient,
 				workDir),
 		},
 		{
@@ -159,5 +254,10 @@ func (t *Delete3FSClientServiceTask) Init(r *task.Runtime, logger log.Interface)
 			Parallel: true,
 			NewStep:  func() task.Step { return new(umountHostMountponitStep) },
 		},
+		{
+			Nodes:    nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return new(removeMountUnitStep) },
+		},
 	})
 }
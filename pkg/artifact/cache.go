@@ -0,0 +1,112 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// cacheContainerName is the name of the container of the artifact cache server.
+const cacheContainerName = "m3fs-artifact-cache"
+
+// maxParallelDownloads limits the number of nodes downloading the artifact from the
+// cache node at the same time.
+const maxParallelDownloads = 32
+
+// cacheURL returns the URL of the image file served by the cache server.
+func cacheURL(cfg *config.Config, fileName string) string {
+	host := net.JoinHostPort(cfg.ArtifactCacheNode().Host, strconv.Itoa(cfg.ArtifactCache.Port))
+	return fmt.Sprintf("http://%s/%s", host, fileName)
+}
+
+// startCacheServerStep starts the cache server serving images copied to the cache node,
+// it runs a static file server of python3 in the 3fs image, which has been imported.
+type startCacheServerStep struct {
+	task.BaseStep
+}
+
+func (s *startCacheServerStep) Execute(ctx context.Context) error {
+	tempDir, ok := s.Runtime.LoadString(s.GetNodeKey(task.RuntimeArtifactTmpDirKey))
+	if !ok {
+		return errors.Errorf("Failed to get value of %s", s.GetNodeKey(task.RuntimeArtifactTmpDirKey))
+	}
+	img, err := s.Runtime.Cfg.Images.GetImage(config.ImageName3FS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// the server left by a failed run is replaced
+	if _, err = s.Em.Docker.Rm(ctx, cacheContainerName, true); err != nil {
+		return errors.Trace(err)
+	}
+	port := strconv.Itoa(s.Runtime.Cfg.ArtifactCache.Port)
+	args := &external.RunArgs{
+		Image:       img,
+		Name:        common.Pointer(cacheContainerName),
+		HostNetwork: true,
+		Detach:      common.Pointer(true),
+		Rm:          common.Pointer(true),
+		Volumes: []*external.VolumeArgs{
+			{
+				Source: tempDir,
+				Target: "/srv/artifact",
+			},
+		},
+		Command: []string{"python3", "-m", "http.server", port, "--directory", "/srv/artifact"},
+	}
+	if _, err = s.Em.Docker.Run(ctx, args); err != nil {
+		return errors.Annotate(err, "start artifact cache server")
+	}
+	s.Logger.Infof("Started artifact cache server on %s:%s", s.Node.Host, port)
+	return nil
+}
+
+// downloadArtifactStep downloads images of the artifact missing on the node from the
+// cache server.
+type downloadArtifactStep struct {
+	task.BaseStep
+}
+
+func (s *downloadArtifactStep) Execute(ctx context.Context) error {
+	download := func(image ManifestImage, dstPath string) error {
+		url := cacheURL(s.Runtime.Cfg, image.FileName)
+		_, err := s.Em.Runner.Exec(ctx, "curl", "-fsS", "--retry", "3", "--retry-connrefused",
+			"-o", dstPath, url)
+		return errors.Annotatef(err, "download %s", url)
+	}
+	// the source is verified when it's copied to the cache node
+	return errors.Trace(transferImages(ctx, &s.BaseStep, false, "Downloading", download, nil))
+}
+
+// stopCacheServerStep stops the cache server on the cache node.
+type stopCacheServerStep struct {
+	task.BaseStep
+}
+
+func (s *stopCacheServerStep) Execute(ctx context.Context) error {
+	if _, err := s.Em.Docker.Rm(ctx, cacheContainerName, true); err != nil {
+		return errors.Annotate(err, "stop artifact cache server")
+	}
+	s.Logger.Infof("Stopped artifact cache server")
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/task"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestCacheSuite(t *testing.T) {
+	suiteRun(t, &cacheSuite{})
+}
+
+type cacheSuite struct {
+	ttask.StepSuite
+}
+
+func (s *cacheSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.1"},
+		{Name: "node2", Host: "10.0.0.2"},
+		{Name: "node3", Host: "10.0.0.3"},
+	}
+	s.Cfg.ArtifactCache = config.ArtifactCache{Enabled: true, Node: "node2", Port: 18080}
+	s.SetupRuntime()
+	s.Runtime.Store(task.RuntimeArtifactManifestKey, &Manifest{
+		Images: []ManifestImage{
+			{
+				Name:      "3fs",
+				Image:     "open3fs/3fs:20250410",
+				FileName:  "3fs_20250410_amd64.docker",
+				ID:        "sha256:3fs",
+				Sha256sum: "3fssum",
+				Size:      200,
+			},
+		},
+	})
+}
+
+func (s *cacheSuite) TestCacheSteps() {
+	t := new(ImportArtifactTask)
+	t.Init(s.Runtime, s.Logger)
+
+	s.Equal([]task.StepPlan{
+		{Name: "extract artifact", Nodes: []string{"node1"}},
+		{Name: "distribute artifact", Nodes: []string{"node2"}},
+		{Name: "import artifact", Nodes: []string{"node2"}},
+		{Name: "start cache server", Nodes: []string{"node2"}},
+		{Name: "download artifact", Nodes: []string{"node1", "node3"}, Parallel: true},
+		{Name: "report retransfers", Nodes: []string{"node1"}},
+		{Name: "import artifact", Nodes: []string{"node1", "node3"}, Parallel: true},
+		{Name: "stop cache server", Nodes: []string{"node2"}},
+		{Name: "remove artifact", Nodes: []string{"node1", "node2", "node3"}, Parallel: true},
+		{Name: "cleanup local", Nodes: []string{"node1"}},
+	}, task.StepPlansOf(t))
+}
+
+func (s *cacheSuite) TestDistributeAllImagesToCacheNode() {
+	step := &distributeArtifactStep{cache: true}
+	step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[1], s.Logger)
+	s.Runtime.Store(task.RuntimeArtifactTmpDirKey, "/tmp/m3fs-artifact")
+	s.MockDocker.On("ImageID", "open3fs/3fs:20250410").Return("sha256:3fs", nil)
+	s.MockFS.On("MkdirAll", "/root/3fs").Return(nil)
+	s.MockFS.On("FreeSpace", "/root/3fs").Return(uint64(1<<30), nil)
+	s.MockFS.On("MkdirTemp", "/root/3fs", "artifact").Return("/root/3fs/artifact-xxx", nil)
+	s.MockRunner.On("Scp", "/tmp/m3fs-artifact/3fs_20250410_amd64.docker",
+		"/root/3fs/artifact-xxx/3fs_20250410_amd64.docker").Return(nil)
+	s.MockFS.On("Sha256sum", "/root/3fs/artifact-xxx/3fs_20250410_amd64.docker").Return("3fssum", nil)
+
+	s.NoError(step.Execute(s.Ctx()))
+
+	// the existing image is served to other nodes, but not imported again
+	images, _ := s.Runtime.Load(step.GetNodeKey(task.RuntimeArtifactImagesKey))
+	s.Empty(images)
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *cacheSuite) TestStartCacheServer() {
+	step := new(startCacheServerStep)
+	step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[1], s.Logger)
+	s.Runtime.Store(step.GetNodeKey(task.RuntimeArtifactTmpDirKey), "/root/3fs/artifact-xxx")
+	img, _ := s.Cfg.Images.GetImage(config.ImageName3FS)
+	s.MockDocker.On("Rm", "m3fs-artifact-cache", true).Return("", nil)
+	s.MockDocker.On("Run", &external.RunArgs{
+		Image:       img,
+		Name:        common.Pointer("m3fs-artifact-cache"),
+		HostNetwork: true,
+		Detach:      common.Pointer(true),
+		Rm:          common.Pointer(true),
+		Volumes: []*external.VolumeArgs{
+			{Source: "/root/3fs/artifact-xxx", Target: "/srv/artifact"},
+		},
+		Command: []string{"python3", "-m", "http.server", "18080", "--directory", "/srv/artifact"},
+	}).Return("", nil)
+
+	s.NoError(step.Execute(s.Ctx()))
+
+	s.MockDocker.AssertExpectations(s.T())
+}
+
+func (s *cacheSuite) TestDownloadArtifact() {
+	step := new(downloadArtifactStep)
+	step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[2], s.Logger)
+	s.MockDocker.On("ImageID", "open3fs/3fs:20250410").Return("sha256:old", nil)
+	s.MockFS.On("MkdirAll", "/root/3fs").Return(nil)
+	s.MockFS.On("FreeSpace", "/root/3fs").Return(uint64(1<<30), nil)
+	s.MockFS.On("MkdirTemp", "/root/3fs", "artifact").Return("/root/3fs/artifact-yyy", nil)
+	s.MockRunner.On("Exec", "curl", []string{"-fsS", "--retry", "3", "--retry-connrefused",
+		"-o", "/root/3fs/artifact-yyy/3fs_20250410_amd64.docker",
+		"http://10.0.0.2:18080/3fs_20250410_amd64.docker"}).Return("", nil)
+	s.MockFS.On("Sha256sum", "/root/3fs/artifact-yyy/3fs_20250410_amd64.docker").Return("3fssum", nil)
+
+	s.NoError(step.Execute(s.Ctx()))
+
+	images, _ := s.Runtime.Load(step.GetNodeKey(task.RuntimeArtifactImagesKey))
+	s.Len(images, 1)
+	s.MockRunner.AssertExpectations(s.T())
+	s.MockFS.AssertExpectations(s.T())
+}
+
+func (s *cacheSuite) TestStopCacheServer() {
+	step := new(stopCacheServerStep)
+	step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[1], s.Logger)
+	s.MockDocker.On("Rm", "m3fs-artifact-cache", true).Return("", nil)
+
+	s.NoError(step.Execute(s.Ctx()))
+
+	s.MockDocker.AssertExpectations(s.T())
+}
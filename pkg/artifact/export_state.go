@@ -0,0 +1,98 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// ExportStateFileName is the file name of the checkpoint of exporting an artifact in
+// the tmp dir of the export.
+const ExportStateFileName = ".export-state"
+
+// exportState is the checkpoint of exporting an artifact, it records images which are
+// saved into the tmp dir and verified, so that an interrupted export resumes from them.
+type exportState struct {
+	Images []ManifestImage `json:"images"`
+
+	path string
+}
+
+// loadExportState loads the checkpoint of the export in the dir, it's empty if there's
+// no checkpoint.
+func loadExportState(dir string) (*exportState, error) {
+	state := &exportState{path: filepath.Join(dir, ExportStateFileName)}
+	data, err := os.ReadFile(state.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, errors.Annotatef(err, "read export state %s", state.path)
+	}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, errors.Annotatef(err, "parse export state %s", state.path)
+	}
+	return state, nil
+}
+
+// removeExportState removes the checkpoint of the export in the dir.
+func removeExportState(dir string) error {
+	statePath := filepath.Join(dir, ExportStateFileName)
+	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		return errors.Annotatef(err, "remove export state %s", statePath)
+	}
+	return nil
+}
+
+// image returns the image recorded by the checkpoint if its file is still intact.
+func (s *exportState) image(name, fileName string) *ManifestImage {
+	for i := range s.Images {
+		image := &s.Images[i]
+		if image.Name != name || image.FileName != fileName {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(filepath.Dir(s.path), fileName))
+		if err != nil || info.Size() != image.Size {
+			return nil
+		}
+		return image
+	}
+	return nil
+}
+
+// record records the saved and verified image and saves the checkpoint.
+func (s *exportState) record(image ManifestImage) error {
+	replaced := false
+	for i := range s.Images {
+		if s.Images[i].Name == image.Name {
+			s.Images[i] = image
+			replaced = true
+		}
+	}
+	if !replaced {
+		s.Images = append(s.Images, image)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// the checkpoint is replaced at once, so that it's intact if the export is killed
+	return errors.Annotatef(task.WriteFileAtomic(s.path, data, 0644), "write export state %s", s.path)
+}
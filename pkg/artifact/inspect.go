@@ -0,0 +1,220 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// defines statuses of images in the inspected artifact.
+const (
+	ImageStatusOK       = "ok"
+	ImageStatusMissing  = "missing"
+	ImageStatusMismatch = "checksum mismatch"
+)
+
+// InspectedImage is an image of the inspected artifact.
+type InspectedImage struct {
+	ManifestImage
+	// Status is whether the image file is found with the checksum recorded in the manifest.
+	Status string `json:"status"`
+}
+
+// Inspection describes contents of the artifact, it's read from the artifact file only.
+type Inspection struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Sha256sum string `json:"sha256sum"`
+	// Compression is detected from the artifact file.
+	Compression string `json:"compression"`
+	// HasManifest is false for artifacts exported by old versions, images of them are
+	// found by contents of files.
+	HasManifest   bool             `json:"hasManifest"`
+	M3fsVersion   string           `json:"m3fsVersion,omitempty"`
+	CreatedAt     *time.Time       `json:"createdAt,omitempty"`
+	Architectures []string         `json:"architectures"`
+	Images        []InspectedImage `json:"images"`
+}
+
+// Failed returns images which are missing or whose checksums mismatch.
+func (i *Inspection) Failed() []InspectedImage {
+	var failed []InspectedImage
+	for _, image := range i.Images {
+		if image.Status != ImageStatusOK {
+			failed = append(failed, image)
+		}
+	}
+	return failed
+}
+
+// inspectedFile is a file in the artifact.
+type inspectedFile struct {
+	size      int64
+	sha256sum string
+	image     *imageInfo
+}
+
+// Inspect reads the artifact file and the manifest in it, checksums of image files are
+// computed and compared with the manifest. It needs no container runtime.
+func Inspect(filePath string) (*Inspection, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	hasher := sha256.New()
+	counter := &countWriter{}
+	reader := bufio.NewReader(io.TeeReader(file, io.MultiWriter(hasher, counter)))
+	inspection := &Inspection{Path: filePath, Compression: CompressionNone}
+	var tarStream io.Reader = reader
+	if magic, _ := reader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, errors.Annotatef(err, "read artifact %s", filePath)
+		}
+		inspection.Compression = CompressionGzip
+		tarStream = gzipReader
+	}
+
+	files, manifest, err := readArtifactFiles(tarStream)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read artifact %s", filePath)
+	}
+	// checksum of the artifact covers the padding after the end of the archive
+	if _, err = io.Copy(io.Discard, reader); err != nil {
+		return nil, errors.Annotatef(err, "read artifact %s", filePath)
+	}
+	inspection.Size = counter.n
+	inspection.Sha256sum = hex.EncodeToString(hasher.Sum(nil))
+
+	if manifest != nil {
+		inspection.HasManifest = true
+		inspection.M3fsVersion = manifest.M3fsVersion
+		inspection.CreatedAt = manifest.CreatedAt
+		for _, image := range manifest.Images {
+			inspected := InspectedImage{ManifestImage: image, Status: ImageStatusOK}
+			f, ok := files[image.FileName]
+			switch {
+			case !ok:
+				inspected.Status = ImageStatusMissing
+			case f.sha256sum != image.Sha256sum:
+				inspected.Status = ImageStatusMismatch
+			}
+			if ok && f.image != nil {
+				// manifests of old versions don't record them
+				if len(inspected.Tags) == 0 {
+					inspected.Tags = f.image.Tags
+				}
+				if inspected.Architecture == "" {
+					inspected.Architecture = f.image.Architecture
+				}
+			}
+			inspection.Images = append(inspection.Images, inspected)
+		}
+	} else {
+		for _, name := range slices.Sorted(maps.Keys(files)) {
+			f := files[name]
+			if f.image == nil {
+				continue
+			}
+			image := ManifestImage{
+				FileName:     name,
+				ID:           f.image.ID,
+				Sha256sum:    f.sha256sum,
+				Size:         f.size,
+				Tags:         f.image.Tags,
+				Architecture: f.image.Architecture,
+			}
+			if len(image.Tags) > 0 {
+				image.Image = image.Tags[0]
+			}
+			inspection.Images = append(inspection.Images, InspectedImage{ManifestImage: image, Status: ImageStatusOK})
+		}
+	}
+
+	for _, image := range inspection.Images {
+		if image.Architecture != "" && !slices.Contains(inspection.Architectures, image.Architecture) {
+			inspection.Architectures = append(inspection.Architectures, image.Architecture)
+		}
+	}
+	slices.Sort(inspection.Architectures)
+	return inspection, nil
+}
+
+// readArtifactFiles reads regular files at the top level of the artifact, the manifest
+// is nil if the artifact has no manifest.
+func readArtifactFiles(r io.Reader) (map[string]*inspectedFile, *Manifest, error) {
+	reader := tar.NewReader(r)
+	files := make(map[string]*inspectedFile)
+	var manifest *Manifest
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		hasher := sha256.New()
+		stream := io.TeeReader(reader, hasher)
+		f := &inspectedFile{size: header.Size}
+		if name == ManifestFileName {
+			manifest = new(Manifest)
+			if err = json.NewDecoder(stream).Decode(manifest); err != nil {
+				return nil, nil, errors.Annotate(err, "parse manifest")
+			}
+		} else if info, err := readImageInfoFrom(stream); err == nil {
+			// files which aren't image files are only checksummed
+			f.image = info
+		}
+		if _, err = io.Copy(io.Discard, stream); err != nil {
+			return nil, nil, errors.Annotatef(err, "read %s", name)
+		}
+		f.sha256sum = hex.EncodeToString(hasher.Sum(nil))
+		files[name] = f
+	}
+	// the gzip stream is verified by reading it to the end
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return files, manifest, nil
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestInspect(t *testing.T) {
+	suiteRun(t, &inspectSuite{})
+}
+
+type inspectSuite struct {
+	suite.Suite
+
+	dir       string
+	imageFile string
+	imageSum  string
+}
+
+func (s *inspectSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	imagePath := filepath.Join(s.dir, "image.docker")
+	s.NoError(writeTarFile(imagePath,
+		"manifest.json", `[{"Config":"blobs/sha256/abc","RepoTags":["open3fs/3fs:20250410"]}]`,
+		"blobs/sha256/abc", `{"architecture":"arm64"}`))
+	data, err := os.ReadFile(imagePath)
+	s.NoError(err)
+	s.imageFile = string(data)
+	sum := sha256.Sum256(data)
+	s.imageSum = hex.EncodeToString(sum[:])
+}
+
+func (s *inspectSuite) writeArtifact(gzipped bool, manifest *Manifest) string {
+	files := []string{"3fs_20250410_arm64.docker", s.imageFile}
+	if manifest != nil {
+		data, err := json.Marshal(manifest)
+		s.NoError(err)
+		files = append(files, ManifestFileName, string(data))
+	}
+	artifactPath := filepath.Join(s.dir, "artifact.tar")
+	s.NoError(writeTarFile(artifactPath, files...))
+	if gzipped {
+		data, err := os.ReadFile(artifactPath)
+		s.NoError(err)
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		_, err = writer.Write(data)
+		s.NoError(err)
+		s.NoError(writer.Close())
+		artifactPath += ".gz"
+		s.NoError(os.WriteFile(artifactPath, buf.Bytes(), 0644))
+	}
+	return artifactPath
+}
+
+func (s *inspectSuite) TestExtractedSize() {
+	for _, gzipped := range []bool{false, true} {
+		size, err := ExtractedSize(s.writeArtifact(gzipped, nil))
+		s.NoError(err)
+		s.Equal(int64(len(s.imageFile)), size)
+	}
+}
+
+func (s *inspectSuite) newManifest(sum string) *Manifest {
+	createdAt := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	return &Manifest{
+		Compression: CompressionGzip,
+		M3fsVersion: "v0.1.0",
+		CreatedAt:   &createdAt,
+		Images: []ManifestImage{
+			{
+				Name:      "3fs",
+				Image:     "open3fs/3fs:20250410",
+				FileName:  "3fs_20250410_arm64.docker",
+				ID:        "sha256:abc",
+				Sha256sum: sum,
+				Size:      int64(len(s.imageFile)),
+			},
+			{
+				Name:      "clickhouse",
+				Image:     "open3fs/clickhouse:25.1-jammy",
+				FileName:  "clickhouse_25.1-jammy_arm64.docker",
+				Sha256sum: "xxx",
+			},
+		},
+	}
+}
+
+func (s *inspectSuite) TestWithManifest() {
+	artifactPath := s.writeArtifact(true, s.newManifest(s.imageSum))
+
+	inspection, err := Inspect(artifactPath)
+	s.NoError(err)
+
+	data, err := os.ReadFile(artifactPath)
+	s.NoError(err)
+	sum := sha256.Sum256(data)
+	s.Equal(hex.EncodeToString(sum[:]), inspection.Sha256sum)
+	s.Equal(int64(len(data)), inspection.Size)
+	s.Equal(CompressionGzip, inspection.Compression)
+	s.True(inspection.HasManifest)
+	s.Equal("v0.1.0", inspection.M3fsVersion)
+	s.Equal([]string{"arm64"}, inspection.Architectures)
+	s.Len(inspection.Images, 2)
+	// tags and architecture missing from the manifest are read from the image file
+	s.Equal([]string{"open3fs/3fs:20250410"}, inspection.Images[0].Tags)
+	s.Equal("arm64", inspection.Images[0].Architecture)
+	s.Equal(ImageStatusOK, inspection.Images[0].Status)
+	s.Equal(ImageStatusMissing, inspection.Images[1].Status)
+	s.Equal([]InspectedImage{inspection.Images[1]}, inspection.Failed())
+}
+
+func (s *inspectSuite) TestWithChecksumMismatch() {
+	inspection, err := Inspect(s.writeArtifact(false, s.newManifest("xxx")))
+	s.NoError(err)
+
+	s.Equal(CompressionNone, inspection.Compression)
+	s.Equal(ImageStatusMismatch, inspection.Images[0].Status)
+}
+
+func (s *inspectSuite) TestWithoutManifest() {
+	inspection, err := Inspect(s.writeArtifact(false, nil))
+	s.NoError(err)
+
+	s.False(inspection.HasManifest)
+	s.Equal([]InspectedImage{{
+		ManifestImage: ManifestImage{
+			Image:        "open3fs/3fs:20250410",
+			FileName:     "3fs_20250410_arm64.docker",
+			ID:           "sha256:abc",
+			Sha256sum:    s.imageSum,
+			Size:         int64(len(s.imageFile)),
+			Tags:         []string{"open3fs/3fs:20250410"},
+			Architecture: "arm64",
+		},
+		Status: ImageStatusOK,
+	}}, inspection.Images)
+	s.Empty(inspection.Failed())
+}
+
+func (s *inspectSuite) TestInvalidArtifact() {
+	artifactPath := filepath.Join(s.dir, "invalid.tar.gz")
+	s.NoError(os.WriteFile(artifactPath, []byte{0x1f, 0x8b, 0x00}, 0644))
+
+	_, err := Inspect(artifactPath)
+	s.Error(err)
+}
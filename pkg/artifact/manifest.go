@@ -0,0 +1,172 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// ManifestFileName is the file name of the manifest in the artifact.
+const ManifestFileName = "manifest.json"
+
+// ManifestImage describes an image contained in the artifact.
+type ManifestImage struct {
+	// Name is the image name defined in config package, such as 3fs.
+	Name string `json:"name"`
+	// Image is the image reference without registry.
+	Image    string `json:"image"`
+	FileName string `json:"fileName"`
+	// ID is the content addressed id of the image, it's empty if unknown.
+	ID        string `json:"id,omitempty"`
+	Sha256sum string `json:"sha256sum"`
+	Size      int64  `json:"size"`
+	// Tags are repo tags recorded in the image file.
+	Tags []string `json:"tags,omitempty"`
+	// Architecture is the architecture of the image, it's empty if unknown.
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// Manifest describes contents of the artifact.
+type Manifest struct {
+	Images []ManifestImage `json:"images"`
+	// Compression is the codec compressing the artifact, gzip or none.
+	Compression string `json:"compression,omitempty"`
+	// M3fsVersion is the version of m3fs exporting the artifact.
+	M3fsVersion string     `json:"m3fsVersion,omitempty"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+}
+
+// defines compression codecs of artifacts.
+const (
+	CompressionGzip = "gzip"
+	CompressionNone = "none"
+)
+
+// LoadManifest loads manifest from the file.
+func LoadManifest(filePath string) (*Manifest, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read manifest %s", filePath)
+	}
+	manifest := new(Manifest)
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.Annotatef(err, "parse manifest %s", filePath)
+	}
+	return manifest, nil
+}
+
+// Save saves the manifest into the file.
+func (m *Manifest) Save(filePath string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = os.WriteFile(filePath, data, 0644); err != nil {
+		return errors.Annotatef(err, "write manifest %s", filePath)
+	}
+	return nil
+}
+
+type dockerSaveManifest struct {
+	Config   string
+	RepoTags []string
+}
+
+type imageConfig struct {
+	Architecture string `json:"architecture"`
+}
+
+// imageInfo is the information of an image read from its image file.
+type imageInfo struct {
+	ID           string
+	Tags         []string
+	Architecture string
+}
+
+// maxImageMetadataSize limits the size of files buffered to find the image config.
+const maxImageMetadataSize = 1 << 20
+
+// readImageInfo reads image info from the image file created by docker save.
+func readImageInfo(filePath string) (*imageInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := readImageInfoFrom(file)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read image file %s", filePath)
+	}
+	return info, nil
+}
+
+// readImageInfoFrom reads image info from the stream of the image file created by docker
+// save. The image id is the digest of the image config, which is named after its sha256
+// in both the legacy and the OCI layout.
+func readImageInfoFrom(r io.Reader) (*imageInfo, error) {
+	reader := tar.NewReader(r)
+	var manifests []dockerSaveManifest
+	// the image config may come before or after manifest.json
+	metadata := make(map[string][]byte)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if header.Name == "manifest.json" {
+			if err = json.NewDecoder(reader).Decode(&manifests); err != nil {
+				return nil, errors.Annotate(err, "parse manifest.json")
+			}
+			continue
+		}
+		if header.Typeflag == tar.TypeReg && header.Size <= maxImageMetadataSize {
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			metadata[path.Clean(header.Name)] = data
+		}
+	}
+	if manifests == nil {
+		return nil, errors.New("manifest.json not found")
+	}
+	if len(manifests) == 0 || manifests[0].Config == "" {
+		return nil, errors.New("image config not found")
+	}
+
+	info := &imageInfo{
+		ID:   "sha256:" + strings.TrimSuffix(path.Base(manifests[0].Config), ".json"),
+		Tags: manifests[0].RepoTags,
+	}
+	if data, ok := metadata[path.Clean(manifests[0].Config)]; ok {
+		config := new(imageConfig)
+		if err := json.Unmarshal(data, config); err == nil {
+			info.Architecture = config.Architecture
+		}
+	}
+	return info, nil
+}
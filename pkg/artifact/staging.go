@@ -0,0 +1,83 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+// ExtractedSize returns the total size of files of the artifact after it's extracted.
+func ExtractedSize(filePath string) (int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var stream io.Reader = file
+	reader := bufio.NewReader(file)
+	if magic, _ := reader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, errors.Annotatef(err, "read artifact %s", filePath)
+		}
+		stream = gzipReader
+	} else if _, err = file.Seek(0, io.SeekStart); err != nil {
+		// contents of an uncompressed artifact are skipped by seeking the file
+		return 0, errors.Trace(err)
+	}
+	tarReader := tar.NewReader(stream)
+	var size int64
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, errors.Annotatef(err, "read artifact %s", filePath)
+		}
+		if header.Typeflag == tar.TypeReg {
+			size += header.Size
+		}
+	}
+	return size, nil
+}
+
+// checkStagingSpace creates the staging dir on the node, and fails if its filesystem
+// has less free space than needed, so that staging doesn't fail halfway with ENOSPC.
+func checkStagingSpace(ctx context.Context, em *external.Manager, node, dir string, need int64) error {
+	if err := em.FS.MkdirAll(ctx, dir); err != nil {
+		return errors.Annotatef(err, "create staging dir %s on %s", dir, node)
+	}
+	free, err := em.FS.FreeSpace(ctx, dir)
+	if err != nil {
+		return errors.Annotatef(err, "check free space of %s on %s", dir, node)
+	}
+	if uint64(need) > free {
+		return errors.Errorf("need %s free in %s on %s to stage the artifact, have %s, "+
+			"free up space or set another staging dir by --staging-dir",
+			common.FormatBytes(need), dir, node, common.FormatBytes(int64(free)))
+	}
+	return nil
+}
@@ -17,14 +17,24 @@ package artifact
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/task"
 )
 
+var imageNames = []string{
+	config.ImageNameFdb,
+	config.ImageNameClickhouse,
+	config.ImageName3FS,
+}
+
 type prepareTmpDirStep struct {
 	task.BaseLocalStep
 }
@@ -37,19 +47,31 @@ func (s *prepareTmpDirStep) Execute(ctx context.Context) error {
 	if err := s.Runtime.LocalEm.FS.MkdirAll(ctx, tmpDir); err != nil {
 		return errors.Trace(err)
 	}
+	if restart, _ := s.Runtime.LoadBool(task.RuntimeArtifactRestartExportKey); restart {
+		s.Logger.Infof("Ignore the checkpoint of the previous export in %s", tmpDir)
+		if err := removeExportState(tmpDir); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	return nil
 }
 
 type downloadImagesStep struct {
 	task.BaseLocalStep
+
+	state *exportState
 }
 
 func (s *downloadImagesStep) Execute(ctx context.Context) error {
-	imageNames := []string{
-		config.ImageNameFdb,
-		config.ImageNameClickhouse,
-		config.ImageName3FS,
+	tmpDir, ok := s.Runtime.LoadString(task.RuntimeArtifactTmpDirKey)
+	if !ok {
+		return errors.Errorf("Failed to get tmp dir for artifact")
+	}
+	state, err := loadExportState(tmpDir)
+	if err != nil {
+		return errors.Trace(err)
 	}
+	s.state = state
 	for _, imageName := range imageNames {
 		filePath, err := s.downloadImage(ctx, imageName)
 		if err != nil {
@@ -83,24 +105,28 @@ func (s *downloadImagesStep) downloadImage(ctx context.Context, imageName string
 		return "", errors.Errorf("Failed to get tmp dir for artifact")
 	}
 	dstPath := filepath.Join(tmpDir, imageFileName)
+	if s.state.image(imageName, imageFileName) != nil {
+		s.Logger.Infof("Skip %s image saved and verified by the previous export", imageName)
+		return dstPath, nil
+	}
 	notExisted, err := s.Runtime.LocalEm.FS.IsNotExist(dstPath)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
+	sumContent, err := s.Runtime.LocalEm.FS.ReadRemoteFile(imageSumUrl)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	expectedSum := strings.Split(sumContent, " ")[0]
 	if !notExisted {
 		s.Logger.Infof("File of %s image exists", imageName)
-		sumContent, err := s.Runtime.LocalEm.FS.ReadRemoteFile(imageSumUrl)
-		if err != nil {
-			return "", errors.Trace(err)
-		}
-		expectedSum := strings.Split(sumContent, " ")[0]
 		actualSum, err := s.Runtime.LocalEm.FS.Sha256sum(ctx, dstPath)
 		if err != nil {
 			return "", errors.Trace(err)
 		}
 		if expectedSum == actualSum {
 			s.Logger.Infof("Skip downloading existed %s image", imageName)
-			return dstPath, nil
+			return dstPath, errors.Trace(s.recordImage(imageName, dstPath, actualSum))
 		}
 		s.Logger.Infof("Current sha256sum of file %s is %s, expected %s",
 			dstPath, actualSum, expectedSum)
@@ -110,9 +136,35 @@ func (s *downloadImagesStep) downloadImage(ctx context.Context, imageName string
 	if err := s.Runtime.LocalEm.FS.DownloadFile(imageUrl, dstPath); err != nil {
 		return "", errors.Trace(err)
 	}
+	actualSum, err := s.Runtime.LocalEm.FS.Sha256sum(ctx, dstPath)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if actualSum != expectedSum {
+		return "", errors.Errorf("sha256sum of downloaded %s is %s, expected %s", dstPath, actualSum, expectedSum)
+	}
 	s.Logger.Infof("Downloaded %s image", imageName)
 
-	return dstPath, nil
+	return dstPath, errors.Trace(s.recordImage(imageName, dstPath, actualSum))
+}
+
+// recordImage records the verified image in the checkpoint of the export.
+func (s *downloadImagesStep) recordImage(imageName, filePath, sum string) error {
+	image, err := s.Runtime.Cfg.Images.GetImageWithoutRegistry(imageName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.state.record(ManifestImage{
+		Name:      imageName,
+		Image:     image,
+		FileName:  filepath.Base(filePath),
+		Sha256sum: sum,
+		Size:      info.Size(),
+	}))
 }
 
 type tarFilesStep struct {
@@ -143,121 +195,446 @@ func (s *tarFilesStep) Execute(context.Context) error {
 		return errors.Trace(err)
 	}
 	s.Logger.Infof("Generated tar files %s", dstPath)
-	return nil
+	// the next export verifies images again
+	return errors.Trace(removeExportState(tmpDir))
 }
 
-type sha256sumArtifactStep struct {
-	task.BaseStep
+type genManifestStep struct {
+	task.BaseLocalStep
 }
 
-func (s *sha256sumArtifactStep) Execute(ctx context.Context) error {
-	srcPath, ok := s.Runtime.LoadString(task.RuntimeArtifactPathKey)
+func (s *genManifestStep) Execute(ctx context.Context) error {
+	tmpDir, ok := s.Runtime.LoadString(task.RuntimeArtifactTmpDirKey)
 	if !ok {
-		return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactPathKey)
+		return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactTmpDirKey)
+	}
+	manifest := &Manifest{
+		Compression: CompressionNone,
+		M3fsVersion: common.Version,
+		CreatedAt:   common.Pointer(time.Now().UTC()),
+	}
+	if needGzip, _ := s.Runtime.LoadBool(task.RuntimeArtifactGzipKey); needGzip {
+		manifest.Compression = CompressionGzip
 	}
-	s.Logger.Infof("Generating SHA256 checksum of artifact %s", srcPath)
-	localSum, err := s.Runtime.LocalEm.FS.Sha256sum(ctx, srcPath)
+	state, err := loadExportState(tmpDir)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	s.Runtime.Store(task.RuntimeArtifactSha256sumKey, localSum)
-	s.Logger.Infof("SHA256 checksum of artifact %s is %s", srcPath, localSum)
+	for _, imageName := range imageNames {
+		image, err := newManifestImage(ctx, s.Runtime, imageName, tmpDir, state)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		info, err := readImageInfo(filepath.Join(tmpDir, image.FileName))
+		if err != nil {
+			s.Logger.Warnf("Failed to read id of %s image, it will always be copied: %v",
+				imageName, err)
+		} else {
+			image.ID = info.ID
+			image.Tags = info.Tags
+			image.Architecture = info.Architecture
+		}
+		manifest.Images = append(manifest.Images, *image)
+	}
+	manifestPath := filepath.Join(tmpDir, ManifestFileName)
+	if err := manifest.Save(manifestPath); err != nil {
+		return errors.Trace(err)
+	}
+
+	var filePaths []string
+	if filePathsValue, ok := s.Runtime.Load(task.RuntimeArtifactFilePathsKey); ok {
+		filePaths = filePathsValue.([]string)
+	}
+	s.Runtime.Store(task.RuntimeArtifactFilePathsKey, append(filePaths, manifestPath))
 	return nil
 }
 
-type distributeArtifactStep struct {
+// newManifestImage returns the manifest of the image file in the dir, the checksum recorded
+// by the checkpoint of the export is reused if state isn't nil.
+func newManifestImage(
+	ctx context.Context, r *task.Runtime, imageName, dir string, state *exportState) (*ManifestImage, error) {
+
+	fileName, err := r.Cfg.Images.GetImageFileName(imageName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if state != nil {
+		if image := state.image(imageName, fileName); image != nil {
+			saved := *image
+			return &saved, nil
+		}
+	}
+	image, err := r.Cfg.Images.GetImageWithoutRegistry(imageName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	filePath := filepath.Join(dir, fileName)
+	sum, err := r.LocalEm.FS.Sha256sum(ctx, filePath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &ManifestImage{
+		Name:      imageName,
+		Image:     image,
+		FileName:  fileName,
+		Sha256sum: sum,
+		Size:      fileInfo.Size(),
+	}, nil
+}
+
+type extractArtifactStep struct {
 	task.BaseStep
 }
 
-func (s *distributeArtifactStep) Execute(ctx context.Context) error {
-	localSum, ok := s.Runtime.LoadString(task.RuntimeArtifactSha256sumKey)
+func (s *extractArtifactStep) Execute(ctx context.Context) error {
+	srcPath, ok := s.Runtime.LoadString(task.RuntimeArtifactPathKey)
 	if !ok {
-		return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactSha256sumKey)
+		return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactPathKey)
+	}
+	localEm := s.Runtime.LocalEm
+	stagingDir := s.Runtime.Cfg.LocalStagingDir()
+	if s.Runtime.Cfg.StagingDir == "" {
+		// the artifact is extracted in the run dir to keep it apart from other runs
+		stagingDir = s.Runtime.LocalTempDir()
+	}
+	size, err := ExtractedSize(srcPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkStagingSpace(ctx, localEm, "the deploy host", stagingDir, size); err != nil {
+		return errors.Trace(err)
+	}
+	tmpDir, err := localEm.FS.MkdirTemp(ctx, stagingDir, "m3fs-artifact")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.Runtime.Store(task.RuntimeArtifactTmpDirKey, tmpDir)
+	s.Runtime.RegisterTempDir("", tmpDir)
+	s.Logger.Infof("Extracting the artifact %s to %s", srcPath, tmpDir)
+	if err = localEm.FS.ExtractTar(ctx, srcPath, tmpDir); err != nil {
+		return errors.Trace(err)
 	}
 
-	needCopy := true
-	dstPath := filepath.Join(s.Runtime.WorkDir, "3fs.tar.gz")
-	if remoteSum, err := s.Em.FS.Sha256sum(ctx, dstPath); err == nil {
-		needCopy = remoteSum != localSum
+	manifestPath := filepath.Join(tmpDir, ManifestFileName)
+	notExisted, err := localEm.FS.IsNotExist(manifestPath)
+	if err != nil {
+		return errors.Trace(err)
 	}
-	if needCopy {
-		s.Logger.Infof("Copying the artifact to %s", s.Node.Name)
-		srcPath, ok := s.Runtime.LoadString(task.RuntimeArtifactPathKey)
-		if !ok {
-			return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactPathKey)
-		}
-		if err := s.Em.FS.MkdirAll(ctx, filepath.Dir(dstPath)); err != nil {
-			return errors.Trace(err)
-		}
-		if err := s.Em.Runner.Scp(ctx, srcPath, dstPath); err != nil {
-			return errors.Trace(err)
+	var manifest *Manifest
+	if notExisted {
+		// NOTE: artifacts exported by old versions don't have the manifest, all images of them
+		// are always copied because their ids are unknown.
+		s.Logger.Warnf("Manifest not found in the artifact, all images will be copied")
+		manifest = new(Manifest)
+		for _, imageName := range imageNames {
+			image, err := newManifestImage(ctx, s.Runtime, imageName, tmpDir, nil)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			manifest.Images = append(manifest.Images, *image)
 		}
-	} else {
-		s.Logger.Infof("Skip copying existed artifact to %s", s.Node.Name)
+	} else if manifest, err = LoadManifest(manifestPath); err != nil {
+		return errors.Trace(err)
 	}
+	s.Runtime.Store(task.RuntimeArtifactManifestKey, manifest)
 
 	return nil
 }
 
-type importArtifactStep struct {
+// probeFileSize is the size of the file transferred to probe bandwidth of nodes.
+const probeFileSize = 8 << 20
+
+const probeFileName = ".m3fs-bandwidth-probe"
+
+type genProbeFileStep struct {
 	task.BaseStep
 }
 
-func (s *importArtifactStep) Execute(ctx context.Context) error {
-	tempDir, err := s.Em.FS.MkdirTemp(ctx, s.Runtime.WorkDir, "artifact")
-	if err != nil {
+func (s *genProbeFileStep) Execute(ctx context.Context) error {
+	if probe, _ := s.Runtime.LoadBool(task.RuntimeArtifactProbeBandwidthKey); !probe {
+		return nil
+	}
+	localTmpDir, ok := s.Runtime.LoadString(task.RuntimeArtifactTmpDirKey)
+	if !ok {
+		return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactTmpDirKey)
+	}
+	probeFile := filepath.Join(localTmpDir, probeFileName)
+	if err := s.Runtime.LocalEm.FS.WriteFile(probeFile, make([]byte, probeFileSize), 0644); err != nil {
 		return errors.Trace(err)
 	}
-	s.Runtime.Store(s.GetNodeKey(task.RuntimeArtifactTmpDirKey), tempDir)
-	pkgPath := filepath.Join(s.Runtime.WorkDir, "3fs.tar.gz")
-	s.Logger.Infof("Extracting the artifact to %s on %s", tempDir, s.Node.Name)
-	if err = s.Em.FS.ExtractTar(ctx, pkgPath, tempDir); err != nil {
+	return nil
+}
+
+type probeBandwidthStep struct {
+	task.BaseStep
+}
+
+func (s *probeBandwidthStep) Execute(ctx context.Context) error {
+	if probe, _ := s.Runtime.LoadBool(task.RuntimeArtifactProbeBandwidthKey); !probe {
+		return nil
+	}
+	localTmpDir, ok := s.Runtime.LoadString(task.RuntimeArtifactTmpDirKey)
+	if !ok {
+		return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactTmpDirKey)
+	}
+	if err := s.Em.FS.MkdirAll(ctx, s.Runtime.WorkDir); err != nil {
 		return errors.Trace(err)
 	}
+	dstPath := filepath.Join(s.Runtime.WorkDir, probeFileName)
+	start := time.Now()
+	if err := s.Em.Runner.Scp(ctx, filepath.Join(localTmpDir, probeFileName), dstPath); err != nil {
+		return errors.Annotatef(err, "probe bandwidth of %s", s.Node.Name)
+	}
+	bandwidth := float64(probeFileSize) / max(time.Since(start).Seconds(), 1e-3)
+	if _, err := s.Em.Runner.Exec(ctx, "rm", "-f", dstPath); err != nil {
+		s.Logger.Warnf("Failed to remove probe file %s: %v", dstPath, err)
+	}
+	s.Logger.Infof("Measured throughput to %s: %s/s", s.Node.Name, common.FormatBytes(int64(bandwidth)))
+	s.Runtime.Store(s.GetNodeKey(task.RuntimeArtifactBandwidthKey), bandwidth)
 
-	imageNames := []string{
-		config.ImageNameFdb,
-		config.ImageNameClickhouse,
-		config.ImageName3FS,
+	return nil
+}
+
+// orderNodesBySlowest moves nodes with lower measured bandwidth ahead, so that the slowest
+// transfers start first and overlap with the others. Nodes without measurement come last.
+func orderNodesBySlowest(r *task.Runtime, nodes []config.Node) []config.Node {
+	bandwidths := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		if value, ok := r.Load(fmt.Sprintf("%s/%s", task.RuntimeArtifactBandwidthKey, node.Name)); ok {
+			bandwidths[node.Name] = value.(float64)
+		}
 	}
-	for _, imageName := range imageNames {
-		err := s.loadImage(ctx, imageName, tempDir)
-		if err != nil {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		bi, iok := bandwidths[nodes[i].Name]
+		bj, jok := bandwidths[nodes[j].Name]
+		if iok && jok {
+			return bi < bj
+		}
+		return iok && !jok
+	})
+	return nodes
+}
+
+type distributeArtifactStep struct {
+	task.BaseStep
+
+	// cache makes all images copied to the node, so that it serves them to other nodes.
+	cache bool
+}
+
+func (s *distributeArtifactStep) Execute(ctx context.Context) error {
+	localTmpDir, ok := s.Runtime.LoadString(task.RuntimeArtifactTmpDirKey)
+	if !ok {
+		return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactTmpDirKey)
+	}
+	scp := func(image ManifestImage, dstPath string) error {
+		return errors.Trace(s.Em.Runner.Scp(ctx, filepath.Join(localTmpDir, image.FileName), dstPath))
+	}
+	verifySource := func(image ManifestImage) error {
+		return errors.Trace(verifySourceImage(ctx, s.Runtime, localTmpDir, image))
+	}
+	return errors.Trace(transferImages(ctx, &s.BaseStep, s.cache, "Copying", scp, verifySource))
+}
+
+// verifySourceImage verifies the checksum of the image file extracted on the deploy host,
+// which is computed once for all nodes. The artifact is wrong if it mismatches, so that
+// every node would mismatch.
+func verifySourceImage(ctx context.Context, r *task.Runtime, localTmpDir string, image ManifestImage) error {
+	key := fmt.Sprintf("%s/%s", task.RuntimeArtifactSourceSumKey, image.FileName)
+	sum, ok := r.LoadString(key)
+	if !ok {
+		var err error
+		if sum, err = r.LocalEm.FS.Sha256sum(ctx, filepath.Join(localTmpDir, image.FileName)); err != nil {
 			return errors.Trace(err)
 		}
+		r.Store(key, sum)
+	}
+	if sum != image.Sha256sum {
+		return errors.Errorf("sha256sum of %s in the artifact is %s, expected %s, the artifact is corrupted",
+			image.FileName, sum, image.Sha256sum)
 	}
 	return nil
 }
 
-func (s *importArtifactStep) loadImage(ctx context.Context, imageName, tempDir string) error {
-	imageFileName, err := s.Runtime.Cfg.Images.GetImageFileName(imageName)
-	if err != nil {
+// transferImages transfers images of the artifact missing on the node of the step into
+// a temp dir of the node by the transfer func, and verifies checksums of them. All images
+// are transferred if all is true, but only missing images are recorded for importing.
+// An image whose checksum mismatches is transferred again up to artifactChecksumRetries
+// times, unless verifySource finds the source of it is wrong. verifySource is nil if the
+// source is verified already.
+func transferImages(ctx context.Context, s *task.BaseStep, all bool, verb string,
+	transfer func(image ManifestImage, dstPath string) error, verifySource func(image ManifestImage) error) error {
+
+	manifestValue, ok := s.Runtime.Load(task.RuntimeArtifactManifestKey)
+	if !ok {
+		return errors.Errorf("Failed to get value of %s", task.RuntimeArtifactManifestKey)
+	}
+	manifest := manifestValue.(*Manifest)
+
+	var missingImages []ManifestImage
+	var savedBytes int64
+	for _, image := range manifest.Images {
+		if image.ID != "" {
+			if id, err := s.Em.Docker.ImageID(ctx, image.Image); err == nil && id == image.ID {
+				s.Logger.Infof("Image %s already exists on %s", image.Image, s.Node.Name)
+				savedBytes += image.Size
+				continue
+			}
+		}
+		missingImages = append(missingImages, image)
+	}
+	s.Runtime.Store(s.GetNodeKey(task.RuntimeArtifactImagesKey), missingImages)
+	images := missingImages
+	if all {
+		images = manifest.Images
+	}
+	if len(images) == 0 {
+		s.Logger.Infof("Skip copying the artifact to %s, all images exist, saved %s",
+			s.Node.Name, common.FormatBytes(savedBytes))
+		return nil
+	}
+
+	var need int64
+	for _, image := range images {
+		need += image.Size
+	}
+	stagingDir := s.Runtime.Cfg.NodeStagingDir()
+	if err := checkStagingSpace(ctx, s.Em, s.Node.Name, stagingDir, need); err != nil {
 		return errors.Trace(err)
 	}
-	imageFilePath := filepath.Join(tempDir, imageFileName)
-	s.Logger.Infof("Loading image %s on %s", imageName, s.Node.Name)
-	out, err := s.Em.Docker.Load(ctx, imageFilePath)
+	tempDir, err := s.Em.FS.MkdirTemp(ctx, stagingDir, "artifact")
 	if err != nil {
 		return errors.Trace(err)
 	}
-	s.Logger.Infof("%s ", strings.TrimSpace(out))
-	if s.Runtime.Cfg.Images.Registry != "" {
-		imageWithRegistry, err := s.Runtime.Cfg.Images.GetImage(imageName)
-		if err != nil {
-			return errors.Trace(err)
+	s.Runtime.Store(s.GetNodeKey(task.RuntimeArtifactTmpDirKey), tempDir)
+	s.Runtime.RegisterTempDir(s.Node.Name, tempDir)
+	start := time.Now()
+	var copiedBytes int64
+	retries := s.Runtime.Cfg.ArtifactChecksumRetries
+	retransfers := 0
+	for _, image := range images {
+		s.Logger.Infof("%s %s image to %s", verb, image.Image, s.Node.Name)
+		dstPath := filepath.Join(tempDir, image.FileName)
+		for attempt := 0; ; attempt++ {
+			if err = transfer(image, dstPath); err != nil {
+				return errors.Trace(err)
+			}
+			remoteSum, err := s.Em.FS.Sha256sum(ctx, dstPath)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if remoteSum == image.Sha256sum {
+				break
+			}
+			mismatch := fmt.Sprintf("sha256sum of %s on %s is %s, expected %s",
+				dstPath, s.Node.Name, remoteSum, image.Sha256sum)
+			if verifySource != nil {
+				if err = verifySource(image); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			if attempt >= retries {
+				return errors.Errorf("%s after %d re-transfers", mismatch, retries)
+			}
+			retransfers++
+			s.Logger.Warnf("%s, the image may be corrupted in transfer, transfer it again (%d/%d)",
+				mismatch, attempt+1, retries)
 		}
-		imageWithoutRegistry, err := s.Runtime.Cfg.Images.GetImageWithoutRegistry(imageName)
-		if err != nil {
-			return errors.Trace(err)
+		copiedBytes += image.Size
+	}
+	if retransfers > 0 {
+		s.Runtime.Store(s.GetNodeKey(task.RuntimeArtifactRetransfersKey), retransfers)
+	}
+	if all {
+		savedBytes = 0
+	}
+	throughput := float64(copiedBytes) / max(time.Since(start).Seconds(), 1e-3)
+	s.Logger.Infof("Copied %d images to %s at %s/s, skipped %d existing images, saved %s",
+		len(images), s.Node.Name, common.FormatBytes(int64(throughput)),
+		len(manifest.Images)-len(images), common.FormatBytes(savedBytes))
+
+	return nil
+}
+
+// reportRetransfersStep reports nodes to which images are transferred again because of
+// checksum mismatches, which hints flaky links to them.
+type reportRetransfersStep struct {
+	task.BaseStep
+}
+
+func (s *reportRetransfersStep) Execute(context.Context) error {
+	if nodes := retransferredNodes(s.Runtime); len(nodes) > 0 {
+		s.Logger.Warnf("%d nodes needed re-transfers of images corrupted in transfer: %s",
+			len(nodes), strings.Join(nodes, ", "))
+	}
+	return nil
+}
+
+// retransferredNodes returns nodes with the number of re-transfers to them, like node1(2).
+func retransferredNodes(r *task.Runtime) []string {
+	var nodes []string
+	for _, node := range r.Cfg.Nodes {
+		if value, ok := r.Load(fmt.Sprintf("%s/%s", task.RuntimeArtifactRetransfersKey, node.Name)); ok {
+			nodes = append(nodes, fmt.Sprintf("%s(%d)", node.Name, value.(int)))
 		}
-		if err = s.Em.Docker.Tag(ctx, imageWithoutRegistry, imageWithRegistry); err != nil {
-			return errors.Trace(err)
+	}
+	return nodes
+}
+
+type importArtifactStep struct {
+	task.BaseStep
+}
+
+func (s *importArtifactStep) Execute(ctx context.Context) error {
+	imagesValue, ok := s.Runtime.Load(s.GetNodeKey(task.RuntimeArtifactImagesKey))
+	if !ok {
+		return errors.Errorf("Failed to get value of %s",
+			s.GetNodeKey(task.RuntimeArtifactImagesKey))
+	}
+	images := imagesValue.([]ManifestImage)
+	if len(images) > 0 {
+		tempDir, ok := s.Runtime.LoadString(s.GetNodeKey(task.RuntimeArtifactTmpDirKey))
+		if !ok {
+			return errors.Errorf("Failed to get value of %s",
+				s.GetNodeKey(task.RuntimeArtifactTmpDirKey))
+		}
+		for _, image := range images {
+			s.Logger.Infof("Loading image %s on %s", image.Image, s.Node.Name)
+			out, err := s.Em.Docker.Load(ctx, filepath.Join(tempDir, image.FileName))
+			if err != nil {
+				return errors.Trace(err)
+			}
+			s.Logger.Infof("%s ", strings.TrimSpace(out))
+		}
+	}
+
+	if s.Runtime.Cfg.Images.Registry != "" {
+		for _, imageName := range imageNames {
+			if err := s.tagImage(ctx, imageName); err != nil {
+				return errors.Trace(err)
+			}
 		}
 	}
 
 	return nil
 }
 
+func (s *importArtifactStep) tagImage(ctx context.Context, imageName string) error {
+	imageWithRegistry, err := s.Runtime.Cfg.Images.GetImage(imageName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	imageWithoutRegistry, err := s.Runtime.Cfg.Images.GetImageWithoutRegistry(imageName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.Em.Docker.Tag(ctx, imageWithoutRegistry, imageWithRegistry))
+}
+
 type removeArtifactStep struct {
 	task.BaseStep
 }
@@ -265,8 +642,8 @@ type removeArtifactStep struct {
 func (s *removeArtifactStep) Execute(ctx context.Context) error {
 	tempDir, ok := s.Runtime.LoadString(s.GetNodeKey(task.RuntimeArtifactTmpDirKey))
 	if !ok {
-		return errors.Errorf("Failed to get value of %s",
-			s.GetNodeKey(task.RuntimeArtifactTmpDirKey))
+		// nothing was copied to the node
+		return nil
 	}
 	_, err := s.Em.Runner.Exec(ctx, "rm", "-rf", tempDir)
 	if err != nil {
@@ -15,12 +15,17 @@
 package artifact
 
 import (
+	"archive/tar"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/task"
 	ttask "github.com/open3fs/m3fs/tests/task"
 )
@@ -54,6 +59,19 @@ func (s *prepareTmpDirStepSuite) Test() {
 	s.MockLocalFS.AssertExpectations(s.T())
 }
 
+func (s *prepareTmpDirStepSuite) TestRestartExport() {
+	tmpDir := s.T().TempDir()
+	statePath := filepath.Join(tmpDir, ExportStateFileName)
+	s.NoError(os.WriteFile(statePath, []byte(`{"images":[]}`), 0644))
+	s.Runtime.Store(task.RuntimeArtifactTmpDirKey, tmpDir)
+	s.Runtime.Store(task.RuntimeArtifactRestartExportKey, true)
+	s.MockLocalFS.On("MkdirAll", tmpDir).Return(nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.NoFileExists(statePath)
+}
+
 type downloadImageInfo struct {
 	imageName  string
 	fileName   string
@@ -62,12 +80,12 @@ type downloadImageInfo struct {
 	fileSumUrl string
 }
 
-func newDownloadImageInfo(r *task.Runtime, imageName string) *downloadImageInfo {
+func newDownloadImageInfo(r *task.Runtime, dir, imageName string) *downloadImageInfo {
 	fileName, _ := r.Cfg.Images.GetImageFileName(imageName)
 	return &downloadImageInfo{
 		imageName:  imageName,
 		fileName:   fileName,
-		filePath:   fmt.Sprintf("/tmp/3fs/%s", fileName),
+		filePath:   filepath.Join(dir, fileName),
 		fileUrl:    fmt.Sprintf("https://artifactory.open3fs.com/3fs/%s", fileName),
 		fileSumUrl: fmt.Sprintf("https://artifactory.open3fs.com/3fs/%s.sha256sum", fileName),
 	}
@@ -81,6 +99,7 @@ type downloadImagesStepSuite struct {
 	ttask.StepSuite
 
 	step   *downloadImagesStep
+	tmpDir string
 	images []*downloadImageInfo
 }
 
@@ -90,35 +109,62 @@ func (s *downloadImagesStepSuite) SetupTest() {
 	s.step = &downloadImagesStep{}
 	s.SetupRuntime()
 	s.step.Init(s.Runtime, s.Logger)
-	s.Runtime.Store(task.RuntimeArtifactTmpDirKey, "/tmp/3fs")
+	s.tmpDir = s.T().TempDir()
+	s.Runtime.Store(task.RuntimeArtifactTmpDirKey, s.tmpDir)
 	s.images = []*downloadImageInfo{
-		newDownloadImageInfo(s.Runtime, config.ImageNameFdb),
-		newDownloadImageInfo(s.Runtime, config.ImageNameClickhouse),
-		newDownloadImageInfo(s.Runtime, config.ImageName3FS),
+		newDownloadImageInfo(s.Runtime, s.tmpDir, config.ImageNameFdb),
+		newDownloadImageInfo(s.Runtime, s.tmpDir, config.ImageNameClickhouse),
+		newDownloadImageInfo(s.Runtime, s.tmpDir, config.ImageName3FS),
+	}
+}
+
+// mockDownload mocks downloading the image, which writes the file of the image.
+func (s *downloadImagesStepSuite) mockDownload(image *downloadImageInfo) {
+	s.MockLocalFS.On("IsNotExist", image.filePath).Return(true, nil).Once()
+	s.MockLocalFS.On("ReadRemoteFile", image.fileSumUrl).Return(
+		fmt.Sprintf("sum-%s %s", image.imageName, image.fileName), nil).Once()
+	s.MockLocalFS.On("DownloadFile", image.fileUrl, image.filePath).Return(nil).Once().
+		Run(func(mock.Arguments) {
+			s.NoError(os.WriteFile(image.filePath, []byte(image.imageName), 0644))
+		})
+	s.MockLocalFS.On("Sha256sum", image.filePath).Return("sum-"+image.imageName, nil).Once()
+}
+
+func (s *downloadImagesStepSuite) expectedFilePaths() []string {
+	filePaths := []string{}
+	for _, image := range s.images {
+		filePaths = append(filePaths, image.filePath)
 	}
+	return filePaths
 }
 
 func (s *downloadImagesStepSuite) TestWithNotExisted() {
 	for _, image := range s.images {
-		s.MockLocalFS.On("IsNotExist", image.filePath).Return(true, nil)
-		s.MockLocalFS.On("DownloadFile", image.fileUrl, image.filePath).Return(nil)
+		s.mockDownload(image)
 	}
 
 	s.NoError(s.step.Execute(s.Ctx()))
 
 	filePaths, ok := s.Runtime.Load(task.RuntimeArtifactFilePathsKey)
 	s.True(ok)
-	expectedFilePaths := []string{}
-	for _, image := range s.images {
-		expectedFilePaths = append(expectedFilePaths, image.filePath)
-	}
-	s.Equal(expectedFilePaths, filePaths)
+	s.Equal(s.expectedFilePaths(), filePaths)
+	state, err := loadExportState(s.tmpDir)
+	s.NoError(err)
+	s.Len(state.Images, 3)
+	s.Equal(ManifestImage{
+		Name:      config.ImageNameFdb,
+		Image:     "open3fs/foundationdb:7.3.63",
+		FileName:  s.images[0].fileName,
+		Sha256sum: "sum-foundationdb",
+		Size:      int64(len(config.ImageNameFdb)),
+	}, state.Images[0])
 
 	s.MockLocalFS.AssertExpectations(s.T())
 }
 
 func (s *downloadImagesStepSuite) TestWithExisted() {
 	for _, image := range s.images {
+		s.NoError(os.WriteFile(image.filePath, []byte("xxxx"), 0644))
 		s.MockLocalFS.On("IsNotExist", image.filePath).Return(false, nil)
 		s.MockLocalFS.On("ReadRemoteFile", image.fileSumUrl).Return(
 			fmt.Sprintf("xxxx %s", image.fileName), nil)
@@ -129,13 +175,44 @@ func (s *downloadImagesStepSuite) TestWithExisted() {
 
 	filePaths, ok := s.Runtime.Load(task.RuntimeArtifactFilePathsKey)
 	s.True(ok)
-	expectedFilePaths := []string{}
-	for _, image := range s.images {
-		expectedFilePaths = append(expectedFilePaths, image.filePath)
-	}
-	s.Equal(expectedFilePaths, filePaths)
+	s.Equal(s.expectedFilePaths(), filePaths)
+
+	s.MockLocalFS.AssertExpectations(s.T())
+}
 
+func (s *downloadImagesStepSuite) TestResume() {
+	// the first export is interrupted after the first image
+	s.mockDownload(s.images[0])
+	s.MockLocalFS.On("IsNotExist", s.images[1].filePath).Return(true, nil).Once()
+	s.MockLocalFS.On("ReadRemoteFile", s.images[1].fileSumUrl).Return("", errors.New("timeout")).Once()
+	s.Error(s.step.Execute(s.Ctx()), "timeout")
 	s.MockLocalFS.AssertExpectations(s.T())
+
+	// the first image isn't verified again
+	s.step.Init(s.Runtime, s.Logger)
+	s.Runtime.Store(task.RuntimeArtifactFilePathsKey, []string{})
+	s.mockDownload(s.images[1])
+	s.mockDownload(s.images[2])
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	filePaths, _ := s.Runtime.Load(task.RuntimeArtifactFilePathsKey)
+	s.Equal(s.expectedFilePaths(), filePaths)
+	s.MockLocalFS.AssertNumberOfCalls(s.T(), "DownloadFile", 3)
+	s.MockLocalFS.AssertExpectations(s.T())
+}
+
+func (s *downloadImagesStepSuite) TestChecksumMismatch() {
+	image := s.images[0]
+	s.MockLocalFS.On("IsNotExist", image.filePath).Return(true, nil)
+	s.MockLocalFS.On("ReadRemoteFile", image.fileSumUrl).Return("expected "+image.fileName, nil)
+	s.MockLocalFS.On("DownloadFile", image.fileUrl, image.filePath).Return(nil)
+	s.MockLocalFS.On("Sha256sum", image.filePath).Return("broken", nil)
+
+	s.Error(s.step.Execute(s.Ctx()), fmt.Sprintf("sha256sum of downloaded %s is broken, expected expected",
+		image.filePath))
+	state, err := loadExportState(s.tmpDir)
+	s.NoError(err)
+	s.Empty(state.Images)
 }
 
 func TestTarFilesStep(t *testing.T) {
@@ -188,33 +265,181 @@ func (s *tarFilesStepSuite) TestWithoutGzip() {
 	s.MockLocalFS.AssertExpectations(s.T())
 }
 
-func TestSha256sumArtifactStep(t *testing.T) {
-	suiteRun(t, &sha256sumArtifactStepSuite{})
+func TestGenManifestStep(t *testing.T) {
+	suiteRun(t, &genManifestStepSuite{})
+}
+
+type genManifestStepSuite struct {
+	ttask.StepSuite
+
+	step   *genManifestStep
+	tmpDir string
+}
+
+func (s *genManifestStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &genManifestStep{}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.Logger)
+	s.tmpDir = s.T().TempDir()
+	s.Runtime.Store(task.RuntimeArtifactTmpDirKey, s.tmpDir)
+	s.Runtime.Store(task.RuntimeArtifactFilePathsKey, []string{"/tmp/3fs/3fs_20250410_amd64.docker"})
+}
+
+func (s *genManifestStepSuite) Test() {
+	for _, imageName := range imageNames {
+		fileName, _ := s.Runtime.Cfg.Images.GetImageFileName(imageName)
+		filePath := filepath.Join(s.tmpDir, fileName)
+		if imageName == config.ImageName3FS {
+			s.writeImageFile(filePath, `[{"Config":"blobs/sha256/abc","RepoTags":["open3fs/3fs:20250410"]}]`,
+				"blobs/sha256/abc", `{"architecture":"amd64"}`)
+		} else {
+			s.NoError(os.WriteFile(filePath, []byte("invalid"), 0644))
+		}
+		s.MockLocalFS.On("Sha256sum", filePath).Return("sum-"+imageName, nil)
+	}
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	manifestPath := filepath.Join(s.tmpDir, ManifestFileName)
+	manifest, err := LoadManifest(manifestPath)
+	s.NoError(err)
+	s.Len(manifest.Images, 3)
+	s.Equal(config.ImageNameFdb, manifest.Images[0].Name)
+	s.Equal("open3fs/foundationdb:7.3.63", manifest.Images[0].Image)
+	s.Equal("sum-foundationdb", manifest.Images[0].Sha256sum)
+	s.Equal(int64(7), manifest.Images[0].Size)
+	s.Empty(manifest.Images[0].ID)
+	s.Equal("sha256:abc", manifest.Images[2].ID)
+	s.Equal([]string{"open3fs/3fs:20250410"}, manifest.Images[2].Tags)
+	s.Equal("amd64", manifest.Images[2].Architecture)
+	s.Equal(CompressionNone, manifest.Compression)
+	s.NotNil(manifest.CreatedAt)
+	filePaths, _ := s.Runtime.Load(task.RuntimeArtifactFilePathsKey)
+	s.Equal([]string{"/tmp/3fs/3fs_20250410_amd64.docker", manifestPath}, filePaths)
+	s.MockLocalFS.AssertExpectations(s.T())
+}
+
+func (s *genManifestStepSuite) TestReuseExportState() {
+	state, err := loadExportState(s.tmpDir)
+	s.NoError(err)
+	for _, imageName := range imageNames {
+		fileName, _ := s.Runtime.Cfg.Images.GetImageFileName(imageName)
+		s.NoError(os.WriteFile(filepath.Join(s.tmpDir, fileName), []byte("invalid"), 0644))
+		s.NoError(state.record(ManifestImage{Name: imageName, FileName: fileName, Sha256sum: "saved", Size: 7}))
+	}
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	manifest, err := LoadManifest(filepath.Join(s.tmpDir, ManifestFileName))
+	s.NoError(err)
+	s.Len(manifest.Images, 3)
+	s.Equal("saved", manifest.Images[0].Sha256sum)
+	s.MockLocalFS.AssertNotCalled(s.T(), "Sha256sum", mock.Anything)
+}
+
+// writeImageFile writes a tar file of pairs of names and contents.
+func (s *genManifestStepSuite) writeImageFile(filePath, manifest string, files ...string) {
+	s.NoError(writeTarFile(filePath, append([]string{"manifest.json", manifest}, files...)...))
+}
+
+func writeTarFile(filePath string, files ...string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	writer := tar.NewWriter(file)
+	for i := 0; i+1 < len(files); i += 2 {
+		err = writer.WriteHeader(&tar.Header{
+			Name:     files[i],
+			Mode:     0644,
+			Size:     int64(len(files[i+1])),
+			Typeflag: tar.TypeReg,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = writer.Write([]byte(files[i+1])); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func TestExtractArtifactStep(t *testing.T) {
+	suiteRun(t, &extractArtifactStepSuite{})
 }
 
-type sha256sumArtifactStepSuite struct {
+type extractArtifactStepSuite struct {
 	ttask.StepSuite
 
-	step *sha256sumArtifactStep
+	step   *extractArtifactStep
+	tmpDir string
 }
 
-func (s *sha256sumArtifactStepSuite) SetupTest() {
+func (s *extractArtifactStepSuite) SetupTest() {
 	s.StepSuite.SetupTest()
 
-	s.step = &sha256sumArtifactStep{}
+	s.step = &extractArtifactStep{}
 	s.SetupRuntime()
 	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
-	s.Runtime.Store(task.RuntimeArtifactPathKey, "/root/3fs.tar.gz")
+	artifactPath := filepath.Join(s.T().TempDir(), "3fs.tar")
+	s.NoError(writeTarFile(artifactPath, "3fs_20250410_amd64.docker", "xxxx"))
+	s.Runtime.Store(task.RuntimeArtifactPathKey, artifactPath)
+	s.tmpDir = s.T().TempDir()
+	s.MockLocalFS.On("MkdirAll", os.TempDir()).Return(nil)
+	s.MockLocalFS.On("MkdirTemp", os.TempDir(), "m3fs-artifact").Return(s.tmpDir, nil)
+	s.MockLocalFS.On("ExtractTar", artifactPath, s.tmpDir).Return(nil)
+}
+
+func (s *extractArtifactStepSuite) TestWithoutEnoughSpace() {
+	s.MockLocalFS.On("FreeSpace", os.TempDir()).Return(uint64(3), nil)
+
+	s.Error(s.step.Execute(s.Ctx()), fmt.Sprintf("need 4 B free in %s on the deploy host to stage the artifact, "+
+		"have 3 B, free up space or set another staging dir by --staging-dir", os.TempDir()))
+	s.MockLocalFS.AssertNotCalled(s.T(), "ExtractTar")
+}
+
+func (s *extractArtifactStepSuite) TestWithManifest() {
+	s.MockLocalFS.On("FreeSpace", os.TempDir()).Return(uint64(4), nil)
+	manifestPath := filepath.Join(s.tmpDir, ManifestFileName)
+	expected := &Manifest{Images: []ManifestImage{{Name: "3fs", ID: "sha256:abc"}}}
+	s.NoError(expected.Save(manifestPath))
+	s.MockLocalFS.On("IsNotExist", manifestPath).Return(false, nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	tmpDir, _ := s.Runtime.LoadString(task.RuntimeArtifactTmpDirKey)
+	s.Equal(s.tmpDir, tmpDir)
+	manifest, ok := s.Runtime.Load(task.RuntimeArtifactManifestKey)
+	s.True(ok)
+	s.Equal(expected, manifest)
+	s.MockLocalFS.AssertExpectations(s.T())
 }
 
-func (s *sha256sumArtifactStepSuite) Test() {
-	s.MockLocalFS.On("Sha256sum", "/root/3fs.tar.gz").Return("xxx", nil)
+func (s *extractArtifactStepSuite) TestWithoutManifest() {
+	s.MockLocalFS.On("FreeSpace", os.TempDir()).Return(uint64(1<<30), nil)
+	s.MockLocalFS.On("IsNotExist", filepath.Join(s.tmpDir, ManifestFileName)).Return(true, nil)
+	for _, imageName := range imageNames {
+		fileName, _ := s.Runtime.Cfg.Images.GetImageFileName(imageName)
+		filePath := filepath.Join(s.tmpDir, fileName)
+		s.NoError(os.WriteFile(filePath, []byte("xx"), 0644))
+		s.MockLocalFS.On("Sha256sum", filePath).Return("xxx", nil)
+	}
 
 	s.NoError(s.step.Execute(s.Ctx()))
 
-	sha256sum, ok := s.Runtime.LoadString(task.RuntimeArtifactSha256sumKey)
+	manifestValue, ok := s.Runtime.Load(task.RuntimeArtifactManifestKey)
 	s.True(ok)
-	s.Equal("xxx", sha256sum)
+	manifest := manifestValue.(*Manifest)
+	s.Len(manifest.Images, 3)
+	for _, image := range manifest.Images {
+		s.Empty(image.ID)
+		s.Equal("xxx", image.Sha256sum)
+	}
+	s.MockLocalFS.AssertExpectations(s.T())
 }
 
 func TestDistributeArtifactStep(t *testing.T) {
@@ -232,30 +457,202 @@ func (s *distributeArtifactStepSuite) SetupTest() {
 
 	s.step = &distributeArtifactStep{}
 	s.SetupRuntime()
-	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
-	s.Runtime.Store(task.RuntimeArtifactPathKey, "/root/3fs.tar.gz")
-	s.Runtime.Store(task.RuntimeArtifactSha256sumKey, "xxx")
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1"}, s.Logger)
+	s.Runtime.Store(task.RuntimeArtifactTmpDirKey, "/tmp/m3fs-artifact")
+	s.Runtime.Store(task.RuntimeArtifactManifestKey, &Manifest{
+		Images: []ManifestImage{
+			{
+				Name:      "foundationdb",
+				Image:     "open3fs/foundationdb:7.3.63",
+				FileName:  "foundationdb_7.3.63_amd64.docker",
+				ID:        "sha256:fdb",
+				Sha256sum: "fdbsum",
+				Size:      100,
+			},
+			{
+				Name:      "3fs",
+				Image:     "open3fs/3fs:20250410",
+				FileName:  "3fs_20250410_amd64.docker",
+				ID:        "sha256:3fs",
+				Sha256sum: "3fssum",
+				Size:      200,
+			},
+		},
+	})
+}
+
+func (s *distributeArtifactStepSuite) TestWithAllExisted() {
+	s.MockDocker.On("ImageID", "open3fs/foundationdb:7.3.63").Return("sha256:fdb", nil)
+	s.MockDocker.On("ImageID", "open3fs/3fs:20250410").Return("sha256:3fs", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	images, ok := s.Runtime.Load(s.step.GetNodeKey(task.RuntimeArtifactImagesKey))
+	s.True(ok)
+	s.Empty(images)
+	_, ok = s.Runtime.Load(s.step.GetNodeKey(task.RuntimeArtifactTmpDirKey))
+	s.False(ok)
+	s.MockDocker.AssertExpectations(s.T())
+	s.MockRunner.AssertExpectations(s.T())
 }
 
-func (s *distributeArtifactStepSuite) TestWithExisted() {
-	s.MockFS.On("Sha256sum", "/root/3fs/3fs.tar.gz").Return("xxx", nil)
+func (s *distributeArtifactStepSuite) TestWithMissing() {
+	s.MockDocker.On("ImageID", "open3fs/foundationdb:7.3.63").Return("sha256:fdb", nil)
+	s.MockDocker.On("ImageID", "open3fs/3fs:20250410").Return("sha256:old", nil)
+	s.MockFS.On("MkdirAll", "/root/3fs").Return(nil)
+	s.MockFS.On("FreeSpace", "/root/3fs").Return(uint64(1<<30), nil)
+	s.MockFS.On("MkdirTemp", "/root/3fs", "artifact").Return("/root/3fs/artifact-xxx", nil)
+	s.MockRunner.On("Scp", "/tmp/m3fs-artifact/3fs_20250410_amd64.docker",
+		"/root/3fs/artifact-xxx/3fs_20250410_amd64.docker").Return(nil)
+	s.MockFS.On("Sha256sum", "/root/3fs/artifact-xxx/3fs_20250410_amd64.docker").
+		Return("3fssum", nil)
 
 	s.NoError(s.step.Execute(s.Ctx()))
 
+	images, ok := s.Runtime.Load(s.step.GetNodeKey(task.RuntimeArtifactImagesKey))
+	s.True(ok)
+	s.Len(images, 1)
+	s.Equal("3fs", images.([]ManifestImage)[0].Name)
+	tmpDir, ok := s.Runtime.LoadString(s.step.GetNodeKey(task.RuntimeArtifactTmpDirKey))
+	s.True(ok)
+	s.Equal("/root/3fs/artifact-xxx", tmpDir)
+	s.MockDocker.AssertExpectations(s.T())
 	s.MockFS.AssertExpectations(s.T())
+	s.MockRunner.AssertExpectations(s.T())
 }
 
-func (s *distributeArtifactStepSuite) TestWithNotExisted() {
-	s.MockFS.On("Sha256sum", "/root/3fs/3fs.tar.gz").Return("", fmt.Errorf("Dummy error"))
+func (s *distributeArtifactStepSuite) mockChecksumMismatch() {
+	s.MockDocker.On("ImageID", "open3fs/foundationdb:7.3.63").Return("sha256:fdb", nil)
+	s.MockDocker.On("ImageID", "open3fs/3fs:20250410").Return("", errors.New("no such image"))
 	s.MockFS.On("MkdirAll", "/root/3fs").Return(nil)
-	s.MockRunner.On("Scp", "/root/3fs.tar.gz", "/root/3fs/3fs.tar.gz").Return(nil)
+	s.MockFS.On("FreeSpace", "/root/3fs").Return(uint64(1<<30), nil)
+	s.MockFS.On("MkdirTemp", "/root/3fs", "artifact").Return("/root/3fs/artifact-xxx", nil)
+	s.MockRunner.On("Scp", "/tmp/m3fs-artifact/3fs_20250410_amd64.docker",
+		"/root/3fs/artifact-xxx/3fs_20250410_amd64.docker").Return(nil)
+	s.MockFS.On("Sha256sum", "/root/3fs/artifact-xxx/3fs_20250410_amd64.docker").
+		Return("badsum", nil).Once()
+}
+
+func (s *distributeArtifactStepSuite) TestRetransferOnChecksumMismatch() {
+	s.mockChecksumMismatch()
+	s.MockLocalFS.On("Sha256sum", "/tmp/m3fs-artifact/3fs_20250410_amd64.docker").Return("3fssum", nil).Once()
+	s.MockFS.On("Sha256sum", "/root/3fs/artifact-xxx/3fs_20250410_amd64.docker").
+		Return("3fssum", nil).Once()
 
 	s.NoError(s.step.Execute(s.Ctx()))
 
+	s.MockRunner.AssertNumberOfCalls(s.T(), "Scp", 2)
+	retransfers, ok := s.Runtime.Load(s.step.GetNodeKey(task.RuntimeArtifactRetransfersKey))
+	s.True(ok)
+	s.Equal(1, retransfers)
+}
+
+func (s *distributeArtifactStepSuite) TestWithChecksumMismatch() {
+	s.mockChecksumMismatch()
+	s.MockLocalFS.On("Sha256sum", "/tmp/m3fs-artifact/3fs_20250410_amd64.docker").Return("3fssum", nil).Once()
+	s.MockFS.On("Sha256sum", "/root/3fs/artifact-xxx/3fs_20250410_amd64.docker").
+		Return("badsum", nil).Twice()
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "sha256sum of /root/3fs/artifact-xxx/3fs_20250410_amd64.docker on "+
+		"node1 is badsum, expected 3fssum after 2 re-transfers")
+	s.MockRunner.AssertNumberOfCalls(s.T(), "Scp", 3)
+	// the checksum of the source is computed once
+	s.MockLocalFS.AssertExpectations(s.T())
+}
+
+func (s *distributeArtifactStepSuite) TestWithCorruptedArtifact() {
+	s.mockChecksumMismatch()
+	s.MockLocalFS.On("Sha256sum", "/tmp/m3fs-artifact/3fs_20250410_amd64.docker").Return("badsum", nil)
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "sha256sum of 3fs_20250410_amd64.docker in the artifact is badsum, "+
+		"expected 3fssum, the artifact is corrupted")
+	s.MockRunner.AssertNumberOfCalls(s.T(), "Scp", 1)
+}
+
+func (s *distributeArtifactStepSuite) TestWithoutChecksumRetries() {
+	s.Runtime.Cfg.ArtifactChecksumRetries = 0
+	s.mockChecksumMismatch()
+	s.MockLocalFS.On("Sha256sum", "/tmp/m3fs-artifact/3fs_20250410_amd64.docker").Return("3fssum", nil)
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "after 0 re-transfers")
+	s.MockRunner.AssertNumberOfCalls(s.T(), "Scp", 1)
+}
+
+func (s *distributeArtifactStepSuite) TestRetransferredNodes() {
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}}
+	s.Empty(retransferredNodes(s.Runtime))
+
+	s.Runtime.Store(task.RuntimeArtifactRetransfersKey+"/node3", 1)
+	s.Runtime.Store(task.RuntimeArtifactRetransfersKey+"/node1", 2)
+	s.Equal([]string{"node1(2)", "node3(1)"}, retransferredNodes(s.Runtime))
+}
+
+func (s *distributeArtifactStepSuite) TestWithoutEnoughSpace() {
+	s.Runtime.Cfg.StagingDir = "/data/staging"
+	s.MockDocker.On("ImageID", "open3fs/foundationdb:7.3.63").Return("sha256:old", nil)
+	s.MockDocker.On("ImageID", "open3fs/3fs:20250410").Return("sha256:old", nil)
+	s.MockFS.On("MkdirAll", "/data/staging").Return(nil)
+	s.MockFS.On("FreeSpace", "/data/staging").Return(uint64(299), nil)
+
+	s.Error(s.step.Execute(s.Ctx()), "need 300 B free in /data/staging on node1 to stage the artifact, have 299 B, "+
+		"free up space or set another staging dir by --staging-dir")
+	s.MockFS.AssertNotCalled(s.T(), "MkdirTemp", "/data/staging", "artifact")
+}
+
+func TestProbeBandwidthStep(t *testing.T) {
+	suiteRun(t, &probeBandwidthStepSuite{})
+}
+
+type probeBandwidthStepSuite struct {
+	ttask.StepSuite
+
+	step *probeBandwidthStep
+}
+
+func (s *probeBandwidthStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &probeBandwidthStep{}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1"}, s.Logger)
+	s.Runtime.Store(task.RuntimeArtifactTmpDirKey, "/tmp/m3fs-artifact")
+}
+
+func (s *probeBandwidthStepSuite) TestDisabled() {
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	_, ok := s.Runtime.Load(s.step.GetNodeKey(task.RuntimeArtifactBandwidthKey))
+	s.False(ok)
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *probeBandwidthStepSuite) TestEnabled() {
+	s.Runtime.Store(task.RuntimeArtifactProbeBandwidthKey, true)
+	s.MockFS.On("MkdirAll", "/root/3fs").Return(nil)
+	s.MockRunner.On("Scp", "/tmp/m3fs-artifact/.m3fs-bandwidth-probe",
+		"/root/3fs/.m3fs-bandwidth-probe").Return(nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", "/root/3fs/.m3fs-bandwidth-probe"}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	bandwidth, ok := s.Runtime.Load(s.step.GetNodeKey(task.RuntimeArtifactBandwidthKey))
+	s.True(ok)
+	s.Greater(bandwidth.(float64), float64(0))
 	s.MockFS.AssertExpectations(s.T())
 	s.MockRunner.AssertExpectations(s.T())
 }
 
+func (s *probeBandwidthStepSuite) TestOrderNodesBySlowest() {
+	nodes := []config.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}, {Name: "node4"}}
+	s.Runtime.Store(task.RuntimeArtifactBandwidthKey+"/node1", float64(300))
+	s.Runtime.Store(task.RuntimeArtifactBandwidthKey+"/node3", float64(100))
+	s.Runtime.Store(task.RuntimeArtifactBandwidthKey+"/node4", float64(200))
+
+	ordered := orderNodesBySlowest(s.Runtime, nodes)
+
+	s.Equal([]config.Node{{Name: "node3"}, {Name: "node4"}, {Name: "node1"}, {Name: "node2"}}, ordered)
+}
+
 type importImageInfo struct {
 	imageName string
 	fileName  string
@@ -296,27 +693,29 @@ func (s *importArtifactStepSuite) SetupTest() {
 		newImportImageInfo(s.Runtime, config.ImageNameClickhouse),
 		newImportImageInfo(s.Runtime, config.ImageName3FS),
 	}
+	manifestImages := []ManifestImage{}
+	for _, image := range s.images {
+		manifestImages = append(manifestImages, ManifestImage{
+			Name:     image.imageName,
+			Image:    image.image,
+			FileName: image.fileName,
+		})
+	}
+	s.Runtime.Store(s.step.GetNodeKey(task.RuntimeArtifactImagesKey), manifestImages)
+	s.Runtime.Store(s.step.GetNodeKey(task.RuntimeArtifactTmpDirKey), "/root/3fs/artifact-xxx")
 }
 
 func (s *importArtifactStepSuite) TestWithoutRegistry() {
-	s.MockFS.On("MkdirTemp", "/root/3fs", "artifact").Return("/root/3fs/artifact-xxx", nil)
-	s.MockFS.On("ExtractTar", "/root/3fs/3fs.tar.gz", "/root/3fs/artifact-xxx").Return(nil)
 	for _, image := range s.images {
 		s.MockDocker.On("Load", image.filePath).Return("", nil)
 	}
 
 	s.NoError(s.step.Execute(s.Ctx()))
 
-	tempDir, ok := s.Runtime.LoadString(s.step.GetNodeKey(task.RuntimeArtifactTmpDirKey))
-	s.True(ok)
-	s.Equal("/root/3fs/artifact-xxx", tempDir)
-
 	s.MockDocker.AssertExpectations(s.T())
 }
 
 func (s *importArtifactStepSuite) TestWithReigstry() {
-	s.MockFS.On("MkdirTemp", "/root/3fs", "artifact").Return("/root/3fs/artifact-xxx", nil)
-	s.MockFS.On("ExtractTar", "/root/3fs/3fs.tar.gz", "/root/3fs/artifact-xxx").Return(nil)
 	s.Runtime.Cfg.Images.Registry = "harbor.xxx.com"
 	for _, image := range s.images {
 		s.MockDocker.On("Load", image.filePath).Return("", nil)
@@ -325,9 +724,13 @@ func (s *importArtifactStepSuite) TestWithReigstry() {
 
 	s.NoError(s.step.Execute(s.Ctx()))
 
-	tempDir, ok := s.Runtime.LoadString(s.step.GetNodeKey(task.RuntimeArtifactTmpDirKey))
-	s.True(ok)
-	s.Equal("/root/3fs/artifact-xxx", tempDir)
+	s.MockDocker.AssertExpectations(s.T())
+}
+
+func (s *importArtifactStepSuite) TestWithoutMissingImages() {
+	s.Runtime.Store(s.step.GetNodeKey(task.RuntimeArtifactImagesKey), []ManifestImage{})
+
+	s.NoError(s.step.Execute(s.Ctx()))
 
 	s.MockDocker.AssertExpectations(s.T())
 }
@@ -16,11 +16,13 @@ package artifact
 
 import (
 	"context"
+	"math"
 
 	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/log"
 	"github.com/open3fs/m3fs/pkg/task"
+	"github.com/open3fs/m3fs/pkg/task/steps"
 )
 
 // ExportArtifactTask is a task for exporting a 3fs artifact.
@@ -37,6 +39,7 @@ func (t *ExportArtifactTask) Init(r *task.Runtime, logger log.Interface) {
 	t.localSteps = []task.LocalStep{
 		new(prepareTmpDirStep),
 		new(downloadImagesStep),
+		new(genManifestStep),
 		new(tarFilesStep),
 	}
 }
@@ -52,6 +55,19 @@ func (t *ExportArtifactTask) Run(ctx context.Context) error {
 	return nil
 }
 
+// defaultParallelTransfers limits the number of nodes receiving the artifact at the same
+// time if the prepare phase isn't limited.
+const defaultParallelTransfers = 8
+
+// parallelTransfers returns the max number of nodes receiving the artifact at the same
+// time, maxParallel of the prepare phase or auto parallelism replaces the default.
+func parallelTransfers(r *task.Runtime) int {
+	if limit := r.PhaseParallel(task.PhasePrepare, math.MaxInt); limit != math.MaxInt {
+		return limit
+	}
+	return defaultParallelTransfers
+}
+
 // ImportArtifactTask is a task for importing the 3fs artifact.
 type ImportArtifactTask struct {
 	task.BaseTask
@@ -61,15 +77,34 @@ type ImportArtifactTask struct {
 func (t *ImportArtifactTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("ImportArtifactTask")
 	t.BaseTask.Init(r, logger)
+	if r.Cfg.ArtifactCache.Enabled {
+		t.SetSteps(t.cacheSteps(r))
+		return
+	}
 	t.SetSteps([]task.StepConfig{
 		{
 			Nodes:   []config.Node{r.Cfg.Nodes[0]},
-			NewStep: func() task.Step { return new(sha256sumArtifactStep) },
+			NewStep: func() task.Step { return new(extractArtifactStep) },
+		},
+		{
+			Nodes:   []config.Node{r.Cfg.Nodes[0]},
+			NewStep: func() task.Step { return new(genProbeFileStep) },
 		},
 		{
 			Nodes:    r.Cfg.Nodes,
 			Parallel: true,
-			NewStep:  func() task.Step { return new(distributeArtifactStep) },
+			NewStep:  func() task.Step { return new(probeBandwidthStep) },
+		},
+		{
+			Nodes:       r.Cfg.Nodes,
+			Parallel:    true,
+			MaxParallel: parallelTransfers(r),
+			OrderNodes:  orderNodesBySlowest,
+			NewStep:     func() task.Step { return new(distributeArtifactStep) },
+		},
+		{
+			Nodes:   []config.Node{r.Cfg.Nodes[0]},
+			NewStep: func() task.Step { return new(reportRetransfersStep) },
 		},
 		{
 			Nodes:    r.Cfg.Nodes,
@@ -81,5 +116,68 @@ func (t *ImportArtifactTask) Init(r *task.Runtime, logger log.Interface) {
 			Parallel: true,
 			NewStep:  func() task.Step { return new(removeArtifactStep) },
 		},
+		{
+			Nodes:   []config.Node{r.Cfg.Nodes[0]},
+			NewStep: steps.NewCleanupLocalStepFunc(task.RuntimeArtifactTmpDirKey),
+		},
 	})
 }
+
+// cacheSteps returns steps copying the artifact to the cache node only, other nodes
+// download it from the cache server on the cache node.
+func (t *ImportArtifactTask) cacheSteps(r *task.Runtime) []task.StepConfig {
+	localNode := []config.Node{r.Cfg.Nodes[0]}
+	cacheNode := []config.Node{r.Cfg.ArtifactCacheNode()}
+	var otherNodes []config.Node
+	for _, node := range r.Cfg.Nodes {
+		if node.Name != cacheNode[0].Name {
+			otherNodes = append(otherNodes, node)
+		}
+	}
+	return []task.StepConfig{
+		{
+			Nodes:   localNode,
+			NewStep: func() task.Step { return new(extractArtifactStep) },
+		},
+		{
+			Nodes:   cacheNode,
+			NewStep: func() task.Step { return &distributeArtifactStep{cache: true} },
+		},
+		{
+			Nodes:   cacheNode,
+			NewStep: func() task.Step { return new(importArtifactStep) },
+		},
+		{
+			Nodes:   cacheNode,
+			NewStep: func() task.Step { return new(startCacheServerStep) },
+		},
+		{
+			Nodes:       otherNodes,
+			Parallel:    true,
+			MaxParallel: maxParallelDownloads,
+			NewStep:     func() task.Step { return new(downloadArtifactStep) },
+		},
+		{
+			Nodes:   localNode,
+			NewStep: func() task.Step { return new(reportRetransfersStep) },
+		},
+		{
+			Nodes:    otherNodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return new(importArtifactStep) },
+		},
+		{
+			Nodes:   cacheNode,
+			NewStep: func() task.Step { return new(stopCacheServerStep) },
+		},
+		{
+			Nodes:    r.Cfg.Nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return new(removeArtifactStep) },
+		},
+		{
+			Nodes:   localNode,
+			NewStep: steps.NewCleanupLocalStepFunc(task.RuntimeArtifactTmpDirKey),
+		},
+	}
+}
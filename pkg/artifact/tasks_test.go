@@ -0,0 +1,43 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestImportArtifactTask(t *testing.T) {
+	suiteRun(t, &importArtifactTaskSuite{})
+}
+
+type importArtifactTaskSuite struct {
+	ttask.StepSuite
+}
+
+func (s *importArtifactTaskSuite) TestParallelTransfers() {
+	s.Equal(defaultParallelTransfers, parallelTransfers(s.Runtime))
+
+	s.Cfg.Deployment.MaxParallel = common.Pointer(4)
+	s.SetupRuntime()
+	s.Equal(4, parallelTransfers(s.Runtime))
+
+	s.Cfg.Deployment.Phases = map[string]config.PhaseDeployment{"prepare": {MaxParallel: common.Pointer(32)}}
+	s.SetupRuntime()
+	s.Equal(32, parallelTransfers(s.Runtime))
+}
@@ -0,0 +1,279 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package benchmark benchmarks data disks of storage nodes of a cluster.
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// scratchDirName is the name of the scratch dir on data disks, it's removed after
+// the benchmark.
+const scratchDirName = "m3fs-benchmark"
+
+// Result is the result of benchmarking a data disk of a storage node.
+type Result struct {
+	Node string `json:"node"`
+	Disk int    `json:"disk"`
+
+	ReadIOPS       float64 `json:"readIOPS"`
+	WriteIOPS      float64 `json:"writeIOPS"`
+	ReadBandwidth  float64 `json:"readBandwidthMiBps"`
+	WriteBandwidth float64 `json:"writeBandwidthMiBps"`
+	ReadLatency    float64 `json:"readLatencyUs"`
+	WriteLatency   float64 `json:"writeLatencyUs"`
+
+	// BelowBaseline is true if the disk performs below the baseline.
+	BelowBaseline bool   `json:"belowBaseline"`
+	Error         string `json:"error,omitempty"`
+	// Skipped is the reason the disk isn't benchmarked, e.g. fio is missing.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Baseline is the minimal performance of a data disk, zero values aren't checked.
+type Baseline struct {
+	MinIOPS      float64
+	MinBandwidth float64
+}
+
+func (b Baseline) check(result *Result) bool {
+	if b.MinIOPS > 0 && result.ReadIOPS+result.WriteIOPS < b.MinIOPS {
+		return false
+	}
+	if b.MinBandwidth > 0 && result.ReadBandwidth+result.WriteBandwidth < b.MinBandwidth {
+		return false
+	}
+	return true
+}
+
+// Benchmark runs a bounded fio random read/write test in the storage container of
+// each storage node, against a scratch dir on each data disk.
+type Benchmark struct {
+	runtime *task.Runtime
+
+	// Size is the size of the test file on each disk, in fio size format.
+	Size string
+	// BlockSize is the block size of IOs, in fio size format.
+	BlockSize string
+	// Duration bounds the time of the test on each disk.
+	Duration time.Duration
+	// Parallel is the number of nodes benchmarked at the same time.
+	Parallel int
+	Baseline Baseline
+	// Nodes limits the benchmark to these storage nodes, all storage nodes are
+	// benchmarked if it's empty.
+	Nodes []string
+
+	nodeManager         func(config.Node, log.Interface) (*external.Manager, error)
+	useContainerRuntime func(*external.Manager, config.ContainerRuntime) error
+}
+
+// NewBenchmark creates a benchmark of storage nodes of the cluster of the runtime.
+func NewBenchmark(r *task.Runtime) *Benchmark {
+	return &Benchmark{
+		runtime:             r,
+		Size:                "256M",
+		BlockSize:           "4k",
+		Duration:            10 * time.Second,
+		Parallel:            10,
+		nodeManager:         r.NodeManager,
+		useContainerRuntime: (*external.Manager).UseContainerRuntime,
+	}
+}
+
+// Run benchmarks all storage nodes, results are ordered by nodes and disks.
+func (b *Benchmark) Run(ctx context.Context) ([]*Result, error) {
+	if b.Duration <= 0 {
+		return nil, errors.New("duration of the benchmark must be positive")
+	}
+	storageNodes := b.runtime.Services.Storage.Nodes
+	var nodes []config.Node
+	for _, node := range b.runtime.Cfg.Nodes {
+		if slices.Contains(storageNodes, node.Name) && (len(b.Nodes) == 0 || slices.Contains(b.Nodes, node.Name)) {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no storage node")
+	}
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string][]*Result, len(nodes))
+	)
+	workerPool := common.NewWorkerPool(func(ctx context.Context, node config.Node) error {
+		nodeResults := b.runNode(ctx, node)
+		mu.Lock()
+		defer mu.Unlock()
+		results[node.Name] = nodeResults
+		return nil
+	}, max(1, min(b.Parallel, len(nodes))))
+	workerPool.Start(ctx)
+	for _, node := range nodes {
+		workerPool.Add(node)
+	}
+	workerPool.Join()
+	if ctx.Err() != nil {
+		return nil, errors.Trace(ctx.Err())
+	}
+
+	var allResults []*Result
+	for _, node := range nodes {
+		allResults = append(allResults, results[node.Name]...)
+	}
+	return allResults, nil
+}
+
+func (b *Benchmark) runNode(ctx context.Context, node config.Node) []*Result {
+	diskNum := b.runtime.Services.Storage.DiskNumPerNode
+	results := make([]*Result, diskNum)
+	for i := range results {
+		results[i] = &Result{Node: node.Name, Disk: i}
+	}
+	setError := func(err error) []*Result {
+		for _, result := range results {
+			result.Error = err.Error()
+		}
+		return results
+	}
+
+	logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+	em, err := b.nodeManager(node, logger)
+	if err != nil {
+		return setError(err)
+	}
+	runtime, err := b.runtime.ContainerRuntime(ctx, em, node)
+	if err == nil {
+		err = b.useContainerRuntime(em, runtime)
+	}
+	if err != nil {
+		return setError(err)
+	}
+
+	container := b.runtime.Services.Storage.ContainerName
+	exec := func(ctx context.Context, cmd string, args ...string) (string, error) {
+		return em.Docker.Exec(ctx, container, cmd, args...)
+	}
+	found, err := b.runtime.CheckOptionalTool(ctx, exec, task.ToolFio, logger)
+	if err != nil {
+		return setError(err)
+	}
+	if !found {
+		for _, result := range results {
+			result.Skipped = "fio is not found in " + container
+		}
+		return results
+	}
+	for _, result := range results {
+		if err = b.runDisk(ctx, em, logger, container, result); err != nil {
+			logger.Warnf("Failed to benchmark disk %d: %v", result.Disk, err)
+			result.Error = err.Error()
+			continue
+		}
+		result.BelowBaseline = !b.Baseline.check(result)
+	}
+	return results
+}
+
+func (b *Benchmark) runDisk(ctx context.Context, em *external.Manager, logger log.Interface,
+	container string, result *Result) error {
+
+	// the path of the data disk in the storage container
+	scratchDir := path.Join("/mnt/3fsdata", fmt.Sprintf("data%d", result.Disk), scratchDirName)
+	if _, err := em.Docker.Exec(ctx, container, "mkdir", "-p", scratchDir); err != nil {
+		return errors.Annotatef(err, "create scratch dir %s", scratchDir)
+	}
+	defer func() {
+		if _, err := em.Docker.Exec(ctx, container, "rm", "-rf", scratchDir); err != nil {
+			logger.Warnf("Failed to remove scratch dir %s: %v", scratchDir, err)
+		}
+	}()
+
+	out, err := em.Docker.Exec(ctx, container, "fio",
+		"--name=m3fs-benchmark",
+		"--directory="+scratchDir,
+		"--rw=randrw",
+		"--rwmixread=50",
+		"--bs="+b.BlockSize,
+		"--size="+b.Size,
+		"--ioengine=libaio",
+		"--direct=1",
+		"--iodepth=16",
+		"--time_based",
+		fmt.Sprintf("--runtime=%d", int(b.Duration.Seconds()+0.5)),
+		"--output-format=json")
+	if err != nil {
+		return errors.Annotate(err, "run fio")
+	}
+	return errors.Trace(parseFioOutput(out, result))
+}
+
+type fioStats struct {
+	IOPS  float64 `json:"iops"`
+	BW    float64 `json:"bw"`
+	LatNs struct {
+		Mean float64 `json:"mean"`
+	} `json:"lat_ns"`
+}
+
+type fioOutput struct {
+	Jobs []struct {
+		Error int      `json:"error"`
+		Read  fioStats `json:"read"`
+		Write fioStats `json:"write"`
+	} `json:"jobs"`
+}
+
+// parseFioOutput parses the json output of fio into the result, fio may print
+// warnings before the json.
+func parseFioOutput(out string, result *Result) error {
+	start := strings.Index(out, "{")
+	if start < 0 {
+		return errors.Errorf("no json in fio output: %s", out)
+	}
+	output := new(fioOutput)
+	if err := json.Unmarshal([]byte(out[start:]), output); err != nil {
+		return errors.Annotate(err, "parse fio output")
+	}
+	if len(output.Jobs) == 0 {
+		return errors.New("no job in fio output")
+	}
+	job := output.Jobs[0]
+	if job.Error != 0 {
+		return errors.Errorf("fio job failed with error %d", job.Error)
+	}
+	// bandwidth of fio is in KiB/s
+	result.ReadIOPS = job.Read.IOPS
+	result.WriteIOPS = job.Write.IOPS
+	result.ReadBandwidth = job.Read.BW / 1024
+	result.WriteBandwidth = job.Write.BW / 1024
+	result.ReadLatency = job.Read.LatNs.Mean / 1000
+	result.WriteLatency = job.Write.LatNs.Mean / 1000
+	return nil
+}
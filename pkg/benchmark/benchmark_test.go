@@ -0,0 +1,152 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+var suiteRun = suite.Run
+
+func TestBenchmark(t *testing.T) {
+	suiteRun(t, &benchmarkSuite{})
+}
+
+type benchmarkSuite struct {
+	ttask.StepSuite
+
+	benchmark *Benchmark
+}
+
+const testFioOutput = `fio: warning
+{
+  "jobs": [{
+    "error": 0,
+    "read": {"iops": 1000.5, "bw": 4096, "lat_ns": {"mean": 150000}},
+    "write": {"iops": 999.5, "bw": 2048, "lat_ns": {"mean": 250000}}
+  }]
+}`
+
+func (s *benchmarkSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.ContainerRuntime = config.ContainerRuntimeDocker
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}, {Name: "node2"}}
+	s.Cfg.Services.Storage.Nodes = []string{"node1"}
+	s.Cfg.Services.Storage.DiskNumPerNode = 2
+	s.SetupRuntime()
+
+	s.benchmark = NewBenchmark(s.Runtime)
+	s.benchmark.Duration = 5 * time.Second
+	s.benchmark.nodeManager = func(config.Node, log.Interface) (*external.Manager, error) {
+		return s.MockEm, nil
+	}
+	s.benchmark.useContainerRuntime = func(*external.Manager, config.ContainerRuntime) error {
+		return nil
+	}
+}
+
+func (s *benchmarkSuite) mockFio(err error) {
+	s.MockDocker.On("Exec", "3fs-storage", "sh", []string{"-c", "'command -v fio'"}).Return("/usr/bin/fio", err)
+}
+
+func (s *benchmarkSuite) mockDisk(disk, out string, err error) {
+	dir := "/mnt/3fsdata/data" + disk + "/m3fs-benchmark"
+	s.MockDocker.On("Exec", "3fs-storage", "mkdir", []string{"-p", dir}).Return("", nil)
+	s.MockDocker.On("Exec", "3fs-storage", "fio", mock.MatchedBy(func(args []string) bool {
+		return args[1] == "--directory="+dir
+	})).Return(out, err)
+	s.MockDocker.On("Exec", "3fs-storage", "rm", []string{"-rf", dir}).Return("", nil)
+}
+
+func (s *benchmarkSuite) TestRun() {
+	s.mockFio(nil)
+	s.mockDisk("0", testFioOutput, nil)
+	s.mockDisk("1", "", errors.New("fio failed"))
+
+	results, err := s.benchmark.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Len(results, 2)
+	s.Equal(&Result{
+		Node:           "node1",
+		Disk:           0,
+		ReadIOPS:       1000.5,
+		WriteIOPS:      999.5,
+		ReadBandwidth:  4,
+		WriteBandwidth: 2,
+		ReadLatency:    150,
+		WriteLatency:   250,
+	}, results[0])
+	s.Contains(results[1].Error, "fio failed")
+	s.MockDocker.AssertExpectations(s.T())
+}
+
+func (s *benchmarkSuite) TestBelowBaseline() {
+	s.Cfg.Services.Storage.DiskNumPerNode = 1
+	s.benchmark.Baseline = Baseline{MinIOPS: 1000, MinBandwidth: 10}
+	s.mockFio(nil)
+	s.mockDisk("0", testFioOutput, nil)
+
+	results, err := s.benchmark.Run(s.Ctx())
+	s.NoError(err)
+
+	s.True(results[0].BelowBaseline)
+}
+
+func (s *benchmarkSuite) TestFioNotFound() {
+	s.mockFio(errors.New("exit status 1"))
+
+	results, err := s.benchmark.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Len(results, 2)
+	for _, result := range results {
+		s.Equal("fio is not found in 3fs-storage", result.Skipped)
+		s.Empty(result.Error)
+	}
+	s.MockDocker.AssertNotCalled(s.T(), "Exec", "3fs-storage", "fio", mock.Anything)
+}
+
+func (s *benchmarkSuite) TestFioRequired() {
+	s.Runtime.RequireOptionalTools = true
+	s.mockFio(errors.New("exit status 1"))
+
+	results, err := s.benchmark.Run(s.Ctx())
+	s.NoError(err)
+
+	for _, result := range results {
+		s.Contains(result.Error, "fio required by benchmarking data disks is not found")
+		s.Empty(result.Skipped)
+	}
+}
+
+func (s *benchmarkSuite) TestBaseline() {
+	result := &Result{ReadIOPS: 600, WriteIOPS: 600, ReadBandwidth: 5, WriteBandwidth: 5}
+	s.True(Baseline{}.check(result))
+	s.True(Baseline{MinIOPS: 1000, MinBandwidth: 10}.check(result))
+	s.False(Baseline{MinIOPS: 1500}.check(result))
+	s.False(Baseline{MinBandwidth: 20}.check(result))
+}
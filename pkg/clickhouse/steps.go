@@ -19,12 +19,11 @@ import (
 	"context"
 	"embed"
 	"fmt"
-	"os"
 	"path"
 	"path/filepath"
 	"strconv"
-	"text/template"
-	"time"
+	"strings"
+	"unicode"
 
 	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
@@ -54,6 +53,9 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	task.RegisterTemplate("config.tmpl", &ClickhouseConfigTmpl)
+	task.RegisterTemplate("sql.tmpl", &ClickhouseSQLTmpl)
 }
 
 func getServiceWorkDir(workDir string) string {
@@ -65,47 +67,157 @@ type genClickhouseConfigStep struct {
 }
 
 func (s *genClickhouseConfigStep) Execute(ctx context.Context) error {
-	tempDir, err := s.Runtime.LocalEm.FS.MkdirTemp(ctx, os.TempDir(), "3fs-clickhouse")
+	tempDir, err := s.Runtime.LocalEm.FS.MkdirTemp(ctx, s.Runtime.LocalTempDir(), "3fs-clickhouse")
 	if err != nil {
 		return errors.Trace(err)
 	}
 	s.Runtime.Store(task.RuntimeClickhouseTmpDirKey, tempDir)
+	s.Runtime.RegisterTempDir("", tempDir)
 
-	configFileName := "config.xml"
-	configTmpl, err := template.New(configFileName).Parse(string(ClickhouseConfigTmpl))
-	if err != nil {
-		return errors.Annotate(err, "parse config.xml template")
+	for _, nodeName := range s.Runtime.Services.Clickhouse.TopologyNodes() {
+		configData, err := renderConfig(s.Runtime, nodeName)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		configPath := filepath.Join(tempDir, localConfigFileName(s.Runtime, nodeName))
+		if err = s.Runtime.LocalEm.FS.WriteFile(configPath, configData, 0644); err != nil {
+			return errors.Trace(err)
+		}
 	}
-	configBuffer := new(bytes.Buffer)
-	err = configTmpl.Execute(configBuffer, map[string]string{
-		"TCPPort": strconv.Itoa(s.Runtime.Services.Clickhouse.TCPPort),
-	})
+
+	sqlPath := filepath.Join(tempDir, sqlFileName)
+	sqlData, err := renderSQL(s.Runtime)
 	if err != nil {
-		return errors.Annotate(err, "write config.xml")
+		return errors.Trace(err)
 	}
-	configPath := filepath.Join(tempDir, configFileName)
-	if err = s.Runtime.LocalEm.FS.WriteFile(configPath, configBuffer.Bytes(), 0644); err != nil {
+	if err = s.Runtime.LocalEm.FS.WriteFile(sqlPath, sqlData, 0644); err != nil {
 		return errors.Trace(err)
 	}
 
-	sqlFileName := "3fs-monitor.sql"
-	sqlTmpl, err := template.New(sqlFileName).Parse(string(ClickhouseSQLTmpl))
-	if err != nil {
-		return errors.Annotate(err, "parse 3fs-monitor.sql template")
+	return nil
+}
+
+const (
+	configFileName = "config.xml"
+	sqlFileName    = "3fs-monitor.sql"
+	// clusterName is the name of the cluster definition of replicated clickhouse.
+	clusterName = "m3fs"
+	// maxKeeperServers is the max number of nodes running the embedded keeper, more
+	// servers slow down writes of the raft log without improving availability much.
+	maxKeeperServers = 3
+)
+
+// localConfigFileName returns the name of the rendered config file of the node in
+// the local temp dir, nodes of replicated clickhouse have their own config files.
+func localConfigFileName(r *task.Runtime, nodeName string) string {
+	if !r.Services.Clickhouse.Replicated() {
+		return configFileName
 	}
-	sqlBuffer := new(bytes.Buffer)
-	err = sqlTmpl.Execute(sqlBuffer, map[string]string{
-		"Db": s.Runtime.Services.Clickhouse.Db,
-	})
+	return fmt.Sprintf("config-%s.xml", strings.Map(func(c rune) rune {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '_' || c == '.' {
+			return c
+		}
+		return '_'
+	}, nodeName))
+}
+
+type keeperServer struct {
+	ID   int
+	Host string
+}
+
+func renderConfig(r *task.Runtime, nodeName string) ([]byte, error) {
+	configTmpl, err := r.NewTemplate(configFileName).Parse(string(ClickhouseConfigTmpl))
 	if err != nil {
-		return errors.Annotate(err, "write 3fs-monitor.sql")
+		return nil, errors.Annotate(err, "parse config.xml template")
 	}
-	sqlPath := filepath.Join(tempDir, sqlFileName)
-	if err = s.Runtime.LocalEm.FS.WriteFile(sqlPath, sqlBuffer.Bytes(), 0644); err != nil {
-		return errors.Trace(err)
+	ck := &r.Services.Clickhouse
+	data := map[string]any{
+		"TCPPort":    strconv.Itoa(ck.TCPPort),
+		"Replicated": ck.Replicated(),
+	}
+	if ck.Replicated() {
+		var (
+			shards        [][]string
+			keeperServers []keeperServer
+			shard         int
+			keeperID      int
+		)
+		for i, replicas := range ck.Topology() {
+			hosts := make([]string, len(replicas))
+			for j, replica := range replicas {
+				hosts[j] = r.Nodes[replica].Host
+				if replica == nodeName {
+					shard = i + 1
+				}
+				if len(keeperServers) < maxKeeperServers {
+					keeperServers = append(keeperServers, keeperServer{ID: len(keeperServers) + 1, Host: hosts[j]})
+					if replica == nodeName {
+						keeperID = len(keeperServers)
+					}
+				}
+			}
+			shards = append(shards, hosts)
+		}
+		data["Cluster"] = clusterName
+		data["Host"] = r.Nodes[nodeName].Host
+		data["Shard"] = shard
+		data["Replica"] = nodeName
+		data["Shards"] = shards
+		data["User"] = ck.User
+		data["Password"] = ck.Password
+		data["KeeperServers"] = keeperServers
+		data["KeeperServerID"] = keeperID
+		data["KeeperPort"] = ck.KeeperPort
+		data["KeeperRaftPort"] = ck.KeeperRaftPort
+		data["InterserverPort"] = ck.InterserverPort
 	}
+	configBuffer := new(bytes.Buffer)
+	if err = configTmpl.Execute(configBuffer, data); err != nil {
+		return nil, errors.Annotate(err, "write config.xml")
+	}
+	return configBuffer.Bytes(), nil
+}
 
-	return nil
+func renderSQL(r *task.Runtime) ([]byte, error) {
+	sqlTmpl, err := r.NewTemplate(sqlFileName).Parse(string(ClickhouseSQLTmpl))
+	if err != nil {
+		return nil, errors.Annotate(err, "parse 3fs-monitor.sql template")
+	}
+	ck := &r.Services.Clickhouse
+	data := map[string]any{
+		"Db":                  ck.Db,
+		"Cluster":             clusterName,
+		"OnCluster":           "",
+		"Sharded":             ck.ShardCount() > 1,
+		"CountersTable":       "counters",
+		"DistributionsTable":  "distributions",
+		"CountersEngine":      "MergeTree",
+		"DistributionsEngine": "MergeTree",
+		"TTL":                 "toIntervalMonth(1)",
+	}
+	if ck.RetentionDays > 0 {
+		data["TTL"] = fmt.Sprintf("toIntervalDay(%d)", ck.RetentionDays)
+	}
+	if ck.Replicated() {
+		data["OnCluster"] = " ON CLUSTER " + clusterName
+		for _, table := range []string{"counters", "distributions"} {
+			name := table
+			if ck.ShardCount() > 1 {
+				// the distributed table takes the name written by the monitor
+				name = table + "_local"
+			}
+			key := strings.ToUpper(table[:1]) + table[1:]
+			data[key+"Table"] = name
+			data[key+"Engine"] = fmt.Sprintf("ReplicatedMergeTree('/clickhouse/tables/{shard}/%s/%s', '{replica}')",
+				ck.Db, name)
+		}
+	}
+	sqlBuffer := new(bytes.Buffer)
+	if err = sqlTmpl.Execute(sqlBuffer, data); err != nil {
+		return nil, errors.Annotate(err, "write 3fs-monitor.sql")
+	}
+	return sqlBuffer.Bytes(), nil
 }
 
 type startContainerStep struct {
@@ -130,7 +242,7 @@ func (s *startContainerStep) Execute(ctx context.Context) error {
 	if !ok {
 		return errors.Errorf("Failed to get value of %s", task.RuntimeClickhouseTmpDirKey)
 	}
-	localConfigFile := path.Join(localConfigDir, "config.xml")
+	localConfigFile := path.Join(localConfigDir, localConfigFileName(s.Runtime, s.Node.Name))
 	remoteConfigFile := path.Join(configDir, "config.xml")
 	if err := s.Em.Runner.Scp(ctx, localConfigFile, remoteConfigFile); err != nil {
 		return errors.Annotatef(err, "scp config.xml")
@@ -178,11 +290,22 @@ func (s *startContainerStep) Execute(ctx context.Context) error {
 			},
 		},
 	}
+	args.SetResources(s.Runtime.Services.Clickhouse.Resources)
+	args.SetPassthrough(s.Runtime.Services.Clickhouse.Passthrough)
 	_, err = s.Em.Docker.Run(ctx, args)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	time.Sleep(time.Second * 5)
+	err = s.Runtime.WaitServiceReady(ctx, config.ServiceClickhouse,
+		func(ctx context.Context) (bool, string, error) {
+			out, err := s.Em.Docker.Exec(ctx, s.Runtime.Services.Clickhouse.ContainerName,
+				"clickhouse-client", "--port", strconv.Itoa(s.Runtime.Services.Clickhouse.TCPPort),
+				"--query", "'SELECT 1'")
+			return err == nil, out, err
+		})
+	if err != nil {
+		return errors.Annotate(err, "wait clickhouse ready")
+	}
 
 	s.Logger.Infof("Started clickhouse container %s successfully",
 		s.Runtime.Services.Clickhouse.ContainerName)
@@ -219,6 +342,9 @@ type rmContainerStep struct {
 
 func (s *rmContainerStep) Execute(ctx context.Context) error {
 	containerName := s.Runtime.Services.Clickhouse.ContainerName
+	if err := s.StopContainer(ctx, config.ServiceClickhouse, containerName); err != nil {
+		return errors.Trace(err)
+	}
 	s.Logger.Infof("Removing clickhouse container %s", containerName)
 	_, err := s.Em.Docker.Rm(ctx, containerName, true)
 	if err != nil {
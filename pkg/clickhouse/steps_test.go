@@ -126,6 +126,8 @@ func (s *startContainerStepSuite) TestStartContainerStep() {
 			},
 		},
 	}).Return("", nil)
+	s.MockDocker.On("Exec", "3fs-clickhouse", "clickhouse-client",
+		[]string{"--port", "8999", "--query", "'SELECT 1'"}).Return("1", nil)
 
 	s.NotNil(s.step)
 	s.NoError(s.step.Execute(s.Ctx()))
@@ -190,6 +192,8 @@ func (s *rmContainerStepSuite) SetupTest() {
 	s.logDir = "/root/3fs/clickhouse/log"
 	s.configDir = "/root/3fs/clickhouse/config.d"
 	s.sqlDir = "/root/3fs/clickhouse/sql"
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Clickhouse.ContainerName, "{{.State.Status}}").
+		Return("exited", nil)
 }
 
 func (s *rmContainerStepSuite) TestRmContainerStep() {
@@ -204,3 +208,73 @@ func (s *rmContainerStepSuite) TestRmContainerStep() {
 	s.MockRunner.AssertExpectations(s.T())
 	s.MockDocker.AssertExpectations(s.T())
 }
+
+func TestRenderReplicated(t *testing.T) {
+	suiteRun(t, &renderReplicatedSuite{})
+}
+
+type renderReplicatedSuite struct {
+	ttask.StepSuite
+}
+
+func (s *renderReplicatedSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.1"},
+		{Name: "node2", Host: "10.0.0.2"},
+		{Name: "node3", Host: "10.0.0.3"},
+		{Name: "node4", Host: "10.0.0.4"},
+	}
+	s.Cfg.Services.Clickhouse.Nodes = []string{"node1", "node2", "node3", "node4"}
+	s.Cfg.Services.Clickhouse.Shards = 2
+	s.Cfg.Services.Clickhouse.Replicas = 2
+	s.Cfg.Services.Clickhouse.RetentionDays = 7
+	s.SetupRuntime()
+}
+
+func (s *renderReplicatedSuite) TestConfig() {
+	data, err := renderConfig(s.Runtime, "node3")
+	s.NoError(err)
+	conf := string(data)
+	s.Contains(conf, "<shard>2</shard>")
+	s.Contains(conf, "<replica>node3</replica>")
+	s.Contains(conf, "<interserver_http_host>10.0.0.3</interserver_http_host>")
+	s.Contains(conf, "<host>10.0.0.4</host>")
+	s.Contains(conf, "<server_id>3</server_id>")
+	s.Contains(conf, "<hostname>10.0.0.3</hostname>")
+	s.NotContains(conf, "<hostname>10.0.0.4</hostname>")
+
+	data, err = renderConfig(s.Runtime, "node4")
+	s.NoError(err)
+	s.NotContains(string(data), "<keeper_server>")
+	s.Contains(string(data), "<port>9181</port>")
+	s.Equal("config-node4.xml", localConfigFileName(s.Runtime, "node4"))
+}
+
+func (s *renderReplicatedSuite) TestSQL() {
+	data, err := renderSQL(s.Runtime)
+	s.NoError(err)
+	sql := string(data)
+	s.Contains(sql, "CREATE DATABASE IF NOT EXISTS 3fs ON CLUSTER m3fs;")
+	s.Contains(sql, "CREATE TABLE IF NOT EXISTS 3fs.counters_local ON CLUSTER m3fs (")
+	s.Contains(sql, "ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/3fs/counters_local', '{replica}')")
+	s.Contains(sql, "TTL TIMESTAMP + toIntervalDay(7)")
+	s.Contains(sql, "CREATE TABLE IF NOT EXISTS 3fs.distributions ON CLUSTER m3fs AS 3fs.distributions_local\n"+
+		"ENGINE = Distributed(m3fs, 3fs, distributions_local, rand());")
+}
+
+func (s *renderReplicatedSuite) TestSingleNodeSQL() {
+	s.Cfg.Services.Clickhouse.Shards = 1
+	s.Cfg.Services.Clickhouse.Replicas = 1
+	s.Cfg.Services.Clickhouse.RetentionDays = 0
+
+	data, err := renderSQL(s.Runtime)
+	s.NoError(err)
+	sql := string(data)
+	s.Contains(sql, "CREATE DATABASE IF NOT EXISTS 3fs;")
+	s.Contains(sql, "CREATE TABLE IF NOT EXISTS 3fs.counters (")
+	s.Contains(sql, "ENGINE = MergeTree\n")
+	s.Contains(sql, "TTL TIMESTAMP + toIntervalMonth(1)")
+	s.NotContains(sql, "Distributed")
+}
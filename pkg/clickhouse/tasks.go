@@ -15,12 +15,39 @@
 package clickhouse
 
 import (
+	"path"
+	"slices"
+
 	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/log"
 	"github.com/open3fs/m3fs/pkg/task"
 	"github.com/open3fs/m3fs/pkg/task/steps"
 )
 
+// ConfigRenderer renders config files of clickhouse service.
+var ConfigRenderer = &task.ConfigRenderer{
+	Service: config.ServiceClickhouse,
+	Render: func(r *task.Runtime, node config.Node) ([]*task.RenderedFile, error) {
+		if !slices.Contains(r.Services.Clickhouse.TopologyNodes(), node.Name) {
+			return nil, nil
+		}
+		configData, err := renderConfig(r, node.Name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sqlData, err := renderSQL(r)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		workDir := getServiceWorkDir(r.WorkDir)
+		return []*task.RenderedFile{
+			{Path: path.Join(workDir, "config.d", configFileName), Data: configData},
+			{Path: path.Join(workDir, "sql", sqlFileName), Data: sqlData},
+		}, nil
+	},
+}
+
 // CreateClickhouseClusterTask is a task for creating a new clickhouse cluster.
 type CreateClickhouseClusterTask struct {
 	task.BaseTask
@@ -29,23 +56,32 @@ type CreateClickhouseClusterTask struct {
 // Init initializes the task.
 func (t *CreateClickhouseClusterTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("CreateClickhouseClusterTask")
+	t.BaseTask.SetService(config.ServiceClickhouse)
 	t.BaseTask.Init(r, logger)
-	nodes := make([]config.Node, len(r.Cfg.Services.Clickhouse.Nodes))
-	for i, node := range r.Cfg.Services.Clickhouse.Nodes {
+	ck := &r.Cfg.Services.Clickhouse
+	nodes := make([]config.Node, len(ck.TopologyNodes()))
+	for i, node := range ck.TopologyNodes() {
 		nodes[i] = r.Nodes[node]
 	}
+	initRetryTime := 0
+	if ck.Replicated() {
+		// distributed DDL waits for the keeper quorum formed by starting nodes
+		initRetryTime = 3
+	}
 	t.SetSteps([]task.StepConfig{
 		{
 			Nodes:   []config.Node{nodes[0]},
 			NewStep: func() task.Step { return new(genClickhouseConfigStep) },
 		},
 		{
-			Nodes:   []config.Node{nodes[0]},
-			NewStep: func() task.Step { return new(startContainerStep) },
+			Nodes:    nodes,
+			Parallel: ck.Replicated(),
+			NewStep:  func() task.Step { return new(startContainerStep) },
 		},
 		{
-			Nodes:   []config.Node{nodes[0]},
-			NewStep: func() task.Step { return new(initClusterStep) },
+			Nodes:     []config.Node{nodes[0]},
+			RetryTime: initRetryTime,
+			NewStep:   func() task.Step { return new(initClusterStep) },
 		},
 		{
 			Nodes:   []config.Node{nodes[0]},
@@ -62,6 +98,8 @@ type DeleteClickhouseClusterTask struct {
 // Init initializes the task.
 func (t *DeleteClickhouseClusterTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("DeleteClickhouseClusterTask")
+	t.BaseTask.SetService(config.ServiceClickhouse)
+	t.BaseTask.SetDeps("DeleteMonitorTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Clickhouse.Nodes))
 	for i, node := range r.Cfg.Services.Clickhouse.Nodes {
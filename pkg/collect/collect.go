@@ -0,0 +1,433 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collect collects logs of services from nodes of a cluster into a bundle.
+package collect
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	fsclient "github.com/open3fs/m3fs/pkg/3fs_client"
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+const stateFileName = "log-collection.json"
+
+// DefaultMaxLogBytes is the default limit of bytes collected of each log.
+const DefaultMaxLogBytes = 256 << 20
+
+// StateFilePath returns the path of the file which records high-water marks of
+// log collections of the cluster.
+func StateFilePath(workDir, clusterName string) string {
+	return filepath.Join(task.ClusterStateDir(workDir, clusterName), stateFileName)
+}
+
+// Mark is the high-water mark of log collections of a node.
+type Mark struct {
+	// Time is when logs of the node were collected last time.
+	Time time.Time `json:"time"`
+	// Offsets maps paths of log files to their sizes which have been collected.
+	Offsets map[string]int64 `json:"offsets,omitempty"`
+}
+
+// State records high-water marks of log collections of nodes.
+type State struct {
+	Marks map[string]*Mark `json:"marks"`
+}
+
+// LoadState loads the state from the file, an empty state is returned if the
+// file doesn't exist.
+func LoadState(path string) (*State, error) {
+	state := &State{Marks: make(map[string]*Mark)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, errors.Annotatef(err, "parse %s", path)
+	}
+	if state.Marks == nil {
+		state.Marks = make(map[string]*Mark)
+	}
+	return state, nil
+}
+
+// SaveState saves the state into the file atomically.
+func SaveState(path string, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(task.WriteFileAtomic(path, data, 0644))
+}
+
+// ParseSince parses the time since which logs are collected, it's either a
+// duration before now, or a timestamp in RFC3339, "2006-01-02 15:04:05" or
+// "2006-01-02" format in the local time zone.
+func ParseSince(since string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		if d < 0 {
+			return time.Time{}, errors.Errorf("negative duration %s", since)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, since, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid time %q, it must be a duration like 2h or a timestamp", since)
+}
+
+// NodeResult is the result of collecting logs of a node.
+type NodeResult struct {
+	Node  string
+	Files int
+	Bytes int64
+	// Err is the error which fails the collection of the node.
+	Err error
+}
+
+// Collector collects container logs and log files of services from nodes.
+type Collector struct {
+	runtime *task.Runtime
+
+	// Nodes are nodes whose logs are collected, all nodes by default.
+	Nodes []config.Node
+	// Since makes only logs since the time collected if it isn't zero.
+	Since time.Time
+	// Incremental makes only logs after the last collection of each node collected.
+	Incremental bool
+	// Parallel is the number of nodes collected at the same time.
+	Parallel int
+	// MaxLogBytes limits bytes collected of each log, only the last bytes of a
+	// larger log are collected. It's unlimited if it isn't positive.
+	MaxLogBytes int64
+
+	mu    sync.Mutex
+	state *State
+	tw    *tar.Writer
+	now   time.Time
+
+	nodeManager         func(config.Node, log.Interface) (*external.Manager, error)
+	useContainerRuntime func(*external.Manager, config.ContainerRuntime) error
+}
+
+// NewCollector creates a collector of the cluster of the runtime.
+func NewCollector(r *task.Runtime) *Collector {
+	return &Collector{
+		runtime:             r,
+		Nodes:               r.Cfg.Nodes,
+		Parallel:            10,
+		MaxLogBytes:         DefaultMaxLogBytes,
+		nodeManager:         r.NodeManager,
+		useContainerRuntime: (*external.Manager).UseContainerRuntime,
+	}
+}
+
+// Collect collects logs into the gzipped tar bundle written to out, and records
+// high-water marks of nodes collected successfully.
+func (c *Collector) Collect(ctx context.Context, out io.Writer) ([]*NodeResult, error) {
+	statePath := StateFilePath(c.runtime.WorkDir, c.runtime.Cfg.Name)
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.state = state
+	c.now = time.Now()
+	gw := gzip.NewWriter(out)
+	c.tw = tar.NewWriter(gw)
+
+	var (
+		resultsMu sync.Mutex
+		results   = make(map[string]*NodeResult, len(c.Nodes))
+	)
+	workerPool := common.NewWorkerPool(func(ctx context.Context, node config.Node) error {
+		result := c.collectNode(ctx, node)
+		resultsMu.Lock()
+		defer resultsMu.Unlock()
+		results[node.Name] = result
+		return nil
+	}, max(1, min(c.Parallel, len(c.Nodes))))
+	workerPool.Start(ctx)
+	for _, node := range c.Nodes {
+		workerPool.Add(node)
+	}
+	workerPool.Join()
+	if ctx.Err() != nil {
+		return nil, errors.Trace(ctx.Err())
+	}
+
+	if err = c.tw.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = gw.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = SaveState(statePath, c.state); err != nil {
+		return nil, errors.Annotate(err, "save state of log collection")
+	}
+
+	nodeResults := make([]*NodeResult, 0, len(c.Nodes))
+	for _, node := range c.Nodes {
+		nodeResults = append(nodeResults, results[node.Name])
+	}
+	return nodeResults, nil
+}
+
+// LogDir returns the directory of log files of the service on nodes.
+func LogDir(workDir string, service config.ServiceType) string {
+	if service == config.ServiceFdb {
+		return path.Join(workDir, string(service), "logs")
+	}
+	return path.Join(workDir, string(service), "log")
+}
+
+func (c *Collector) collectNode(ctx context.Context, node config.Node) *NodeResult {
+	result := &NodeResult{Node: node.Name}
+	logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+	em, err := c.nodeManager(node, logger)
+	if err != nil {
+		result.Err = errors.Trace(err)
+		return result
+	}
+	runtime, err := c.runtime.ContainerRuntime(ctx, em, node)
+	if err == nil {
+		err = c.useContainerRuntime(em, runtime)
+	}
+	if err != nil {
+		result.Err = errors.Trace(err)
+		return result
+	}
+
+	c.mu.Lock()
+	lastMark := c.state.Marks[node.Name]
+	c.mu.Unlock()
+	since := c.Since
+	if c.Incremental && lastMark != nil && lastMark.Time.After(since) {
+		since = lastMark.Time
+	}
+	mark := &Mark{Time: c.now, Offsets: make(map[string]int64)}
+
+	for _, service := range config.AllServiceTypes {
+		if !slices.Contains(c.runtime.Services.ServiceNodes(service), node.Name) {
+			continue
+		}
+		name := c.runtime.Services.ContainerName(service)
+		f, err := spool(func(w io.Writer) error {
+			return em.Docker.Logs(ctx, w, name, since, c.MaxLogBytes)
+		})
+		if err != nil {
+			logger.Warnf("Failed to collect logs of container %s: %v", name, err)
+		} else if err = c.addFile(result, path.Join(node.Name, string(service), "container.log"), f); err != nil {
+			result.Err = errors.Trace(err)
+			return result
+		}
+
+		for _, unit := range c.units(service) {
+			if err = c.collectJournal(ctx, em, result, service, unit, since, logger); err != nil {
+				result.Err = errors.Annotatef(err, "collect journal of %s", unit)
+				return result
+			}
+		}
+
+		if err = c.collectLogFiles(ctx, em, result, service, since, lastMark, mark, logger); err != nil {
+			result.Err = errors.Annotatef(err, "collect log files of %s", service)
+			return result
+		}
+	}
+
+	c.mu.Lock()
+	c.state.Marks[node.Name] = mark
+	c.mu.Unlock()
+	return result
+}
+
+// units returns systemd units of the service whose journals are collected.
+func (c *Collector) units(service config.ServiceType) []string {
+	if service == config.ServiceClient {
+		return []string{fsclient.MountUnitName(c.runtime.Services.ContainerName(service))}
+	}
+	return nil
+}
+
+// collectJournal collects the journal of the systemd unit since the time, it's
+// skipped if the unit isn't installed on the node.
+func (c *Collector) collectJournal(ctx context.Context, em *external.Manager, result *NodeResult,
+	service config.ServiceType, unit string, since time.Time, logger log.Interface) error {
+
+	if _, err := em.Runner.Exec(ctx, "systemctl", "cat", unit); err != nil {
+		return nil
+	}
+	streamer, ok := em.Runner.(external.StreamRunner)
+	if !ok {
+		return errors.New("the runner doesn't support streaming outputs of commands")
+	}
+	args := []string{"-u", unit, "--no-pager", "-o", "short-iso"}
+	if !since.IsZero() {
+		args = append(args, "--since", fmt.Sprintf("@%d", since.Unix()))
+	}
+	if c.MaxLogBytes > 0 {
+		args = append(args, "|", "tail", "-c", strconv.FormatInt(c.MaxLogBytes, 10))
+	}
+	f, err := spool(func(w io.Writer) error {
+		return streamer.Stream(ctx, w, "journalctl", args...)
+	})
+	if err != nil {
+		logger.Warnf("Failed to collect journal of %s: %v", unit, err)
+		return nil
+	}
+	return errors.Trace(c.addFile(result, path.Join(result.Node, string(service), unit+".log"), f))
+}
+
+// collectLogFiles collects log files of the service modified since the time. If
+// the collection is incremental, only bytes after offsets of the last collection
+// are collected, a file smaller than its offset is rotated and collected entirely.
+// Only the last MaxLogBytes bytes of a file are collected.
+func (c *Collector) collectLogFiles(ctx context.Context, em *external.Manager, result *NodeResult,
+	service config.ServiceType, since time.Time, lastMark, mark *Mark, logger log.Interface) error {
+
+	dir := LogDir(c.runtime.WorkDir, service)
+	if _, err := em.Runner.Exec(ctx, "test", "-d", dir); err != nil {
+		return nil
+	}
+	out, err := em.Runner.Exec(ctx, "find", dir, "-maxdepth", "1", "-type", "f", "-printf", `'%T@ %s %p\n'`)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	streamer, ok := em.Runner.(external.StreamRunner)
+	if !ok {
+		return errors.New("the runner doesn't support streaming outputs of commands")
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		mtime, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return errors.Annotatef(err, "parse modification time of %s", fields[2])
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return errors.Annotatef(err, "parse size of %s", fields[2])
+		}
+		file := fields[2]
+		mark.Offsets[file] = size
+
+		if !since.IsZero() && time.Unix(0, int64(mtime*float64(time.Second))).Before(since) {
+			continue
+		}
+		var offset int64
+		if c.Incremental && lastMark != nil {
+			if lastOffset, ok := lastMark.Offsets[file]; ok && lastOffset <= size {
+				offset = lastOffset
+			}
+		}
+		if offset == size {
+			continue
+		}
+		if c.MaxLogBytes > 0 && size-offset > c.MaxLogBytes {
+			logger.Warnf("Only the last %s of %s are collected", common.FormatBytes(c.MaxLogBytes), file)
+			offset = size - c.MaxLogBytes
+		}
+		// the file may grow during the collection, bytes after the size are
+		// left for the next collection
+		f, err := spool(func(w io.Writer) error {
+			return streamer.Stream(ctx, w, "tail", "-c", fmt.Sprintf("+%d", offset+1), file,
+				"|", "head", "-c", strconv.FormatInt(size-offset, 10))
+		})
+		if err != nil {
+			return errors.Annotatef(err, "read %s", file)
+		}
+		if err = c.addFile(result, path.Join(result.Node, string(service), path.Base(file)), f); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// spool writes a log into a temp file by write, so that logs aren't held in memory
+// before they're added into the bundle.
+func spool(write func(io.Writer) error) (*os.File, error) {
+	f, err := os.CreateTemp("", "m3fs-log-")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = write(f); err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, errors.Trace(err)
+	}
+	return f, nil
+}
+
+// addFile adds the spooled log into the bundle, and removes the temp file.
+func (c *Collector) addFile(result *NodeResult, name string, f *os.File) error {
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: c.now,
+	}
+	if err = c.tw.WriteHeader(header); err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = io.CopyN(c.tw, f, info.Size()); err != nil {
+		return errors.Trace(err)
+	}
+	result.Files++
+	result.Bytes += info.Size()
+	return nil
+}
@@ -0,0 +1,245 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collect
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	fsclient "github.com/open3fs/m3fs/pkg/3fs_client"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+var suiteRun = suite.Run
+
+func TestCollect(t *testing.T) {
+	suiteRun(t, &collectSuite{})
+}
+
+type collectSuite struct {
+	ttask.StepSuite
+
+	collector *Collector
+	logDir    string
+}
+
+func (s *collectSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.WorkDir = s.T().TempDir()
+	s.Cfg.ContainerRuntime = config.ContainerRuntimeDocker
+	s.Cfg.Nodes = []config.Node{{Name: "node1", Host: "10.0.0.1", Username: "root"}}
+	s.Cfg.Services.Storage.Nodes = []string{"node1"}
+	s.SetupRuntime()
+	s.logDir = s.Cfg.WorkDir + "/storage/log"
+
+	s.collector = NewCollector(s.Runtime)
+	s.collector.nodeManager = func(config.Node, log.Interface) (*external.Manager, error) {
+		return s.MockEm, nil
+	}
+	s.collector.useContainerRuntime = func(*external.Manager, config.ContainerRuntime) error {
+		return nil
+	}
+}
+
+func (s *collectSuite) mockFiles(mtime time.Time, size int) {
+	s.MockRunner.On("Exec", "test", []string{"-d", s.logDir}).Return("", nil)
+	s.MockRunner.On("Exec", "find",
+		[]string{s.logDir, "-maxdepth", "1", "-type", "f", "-printf", `'%T@ %s %p\n'`}).
+		Return(fmt.Sprintf("%d.5 %d %s/storage_main.log\n", mtime.Unix(), size, s.logDir), nil)
+}
+
+func (s *collectSuite) readBundle(data []byte) map[string]string {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	s.NoError(err)
+	tr := tar.NewReader(gr)
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		s.NoError(err)
+		content, err := io.ReadAll(tr)
+		s.NoError(err)
+		files[header.Name] = string(content)
+	}
+	return files
+}
+
+func (s *collectSuite) TestCollect() {
+	s.MockDocker.On("Logs", "3fs-storage", time.Time{}, int64(DefaultMaxLogBytes)).Return("container log\n", nil)
+	s.mockFiles(time.Now(), 10)
+	s.MockRunner.On("Stream", "tail",
+		[]string{"-c", "+1", s.logDir + "/storage_main.log", "|", "head", "-c", "10"}).
+		Return("file log\n", nil)
+
+	out := new(bytes.Buffer)
+	results, err := s.collector.Collect(s.Ctx(), out)
+	s.NoError(err)
+
+	s.Len(results, 1)
+	s.NoError(results[0].Err)
+	s.Equal(2, results[0].Files)
+	s.Equal(map[string]string{
+		"node1/storage/container.log":    "container log\n",
+		"node1/storage/storage_main.log": "file log\n",
+	}, s.readBundle(out.Bytes()))
+	state, err := LoadState(StateFilePath(s.Cfg.WorkDir, s.Cfg.Name))
+	s.NoError(err)
+	s.Equal(map[string]int64{s.logDir + "/storage_main.log": 10}, state.Marks["node1"].Offsets)
+}
+
+func (s *collectSuite) TestIncremental() {
+	lastTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	s.NoError(SaveState(StateFilePath(s.Cfg.WorkDir, s.Cfg.Name), &State{Marks: map[string]*Mark{
+		"node1": {Time: lastTime, Offsets: map[string]int64{s.logDir + "/storage_main.log": 4}},
+	}}))
+	s.collector.Incremental = true
+	s.MockDocker.On("Logs", "3fs-storage", mock.MatchedBy(lastTime.Equal), mock.Anything).Return("", nil)
+	s.mockFiles(time.Now(), 10)
+	s.MockRunner.On("Stream", "tail",
+		[]string{"-c", "+5", s.logDir + "/storage_main.log", "|", "head", "-c", "6"}).
+		Return("delta\n", nil)
+
+	out := new(bytes.Buffer)
+	_, err := s.collector.Collect(s.Ctx(), out)
+	s.NoError(err)
+
+	s.Equal("delta\n", s.readBundle(out.Bytes())["node1/storage/storage_main.log"])
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *collectSuite) TestMaxLogBytes() {
+	s.collector.MaxLogBytes = 4
+	s.MockDocker.On("Logs", "3fs-storage", time.Time{}, int64(4)).Return("", nil)
+	s.mockFiles(time.Now(), 10)
+	s.MockRunner.On("Stream", "tail",
+		[]string{"-c", "+7", s.logDir + "/storage_main.log", "|", "head", "-c", "4"}).
+		Return("tail", nil)
+
+	out := new(bytes.Buffer)
+	_, err := s.collector.Collect(s.Ctx(), out)
+	s.NoError(err)
+
+	s.Equal("tail", s.readBundle(out.Bytes())["node1/storage/storage_main.log"])
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *collectSuite) TestJournal() {
+	s.Cfg.Services.Storage.Nodes = nil
+	s.Cfg.Services.Client.Nodes = []string{"node1"}
+	s.collector.Since = time.Unix(1743494400, 0)
+	unit := fsclient.MountUnitName(s.Cfg.Services.Client.ContainerName)
+	s.MockDocker.On("Logs", s.Cfg.Services.Client.ContainerName, s.collector.Since, mock.Anything).Return("", nil)
+	s.MockRunner.On("Exec", "systemctl", []string{"cat", unit}).Return("", nil)
+	s.MockRunner.On("Stream", "journalctl", []string{"-u", unit, "--no-pager", "-o", "short-iso",
+		"--since", "@1743494400", "|", "tail", "-c", fmt.Sprint(DefaultMaxLogBytes)}).
+		Return("mounted\n", nil)
+	s.MockRunner.On("Exec", "test", []string{"-d", LogDir(s.Cfg.WorkDir, config.ServiceClient)}).
+		Return("", errors.New("exit status 1"))
+
+	out := new(bytes.Buffer)
+	results, err := s.collector.Collect(s.Ctx(), out)
+	s.NoError(err)
+
+	s.NoError(results[0].Err)
+	s.Equal("mounted\n", s.readBundle(out.Bytes())["node1/client/"+unit+".log"])
+}
+
+func (s *collectSuite) TestSaveStateConcurrently() {
+	path := StateFilePath(s.Cfg.WorkDir, s.Cfg.Name)
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = SaveState(path, &State{Marks: map[string]*Mark{"node1": {Offsets: map[string]int64{"f": int64(i)}}}})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		s.NoError(err)
+	}
+
+	// the state is written by one of writers, no temp file is left
+	_, err := LoadState(path)
+	s.NoError(err)
+	matches, err := filepath.Glob(path + "*")
+	s.NoError(err)
+	s.Equal([]string{path}, matches)
+}
+
+func (s *collectSuite) TestSinceSkipsOldFiles() {
+	s.collector.Since = time.Now().Add(-time.Hour)
+	s.MockDocker.On("Logs", "3fs-storage", s.collector.Since, mock.Anything).Return("", nil)
+	s.mockFiles(time.Now().Add(-2*time.Hour), 10)
+
+	out := new(bytes.Buffer)
+	_, err := s.collector.Collect(s.Ctx(), out)
+	s.NoError(err)
+
+	s.NotContains(s.readBundle(out.Bytes()), "node1/storage/storage_main.log")
+}
+
+func (s *collectSuite) TestNodeFailed() {
+	s.collector.nodeManager = func(config.Node, log.Interface) (*external.Manager, error) {
+		return nil, errors.New("dial failed")
+	}
+
+	out := new(bytes.Buffer)
+	results, err := s.collector.Collect(s.Ctx(), out)
+	s.NoError(err)
+
+	s.Error(results[0].Err)
+	state, err := LoadState(StateFilePath(s.Cfg.WorkDir, s.Cfg.Name))
+	s.NoError(err)
+	s.Empty(state.Marks)
+}
+
+func (s *collectSuite) TestParseSince() {
+	now := time.Date(2025, 4, 1, 8, 0, 0, 0, time.UTC)
+	since, err := ParseSince("2h", now)
+	s.NoError(err)
+	s.Equal(now.Add(-2*time.Hour), since)
+
+	since, err = ParseSince("2025-03-31T08:00:00Z", now)
+	s.NoError(err)
+	s.True(since.Equal(now.Add(-24 * time.Hour)))
+
+	since, err = ParseSince("2025-03-31", now)
+	s.NoError(err)
+	s.Equal(time.Date(2025, 3, 31, 0, 0, 0, 0, time.Local), since)
+
+	_, err = ParseSince("yesterday", now)
+	s.Error(err)
+	_, err = ParseSince("-2h", now)
+	s.Error(err)
+}
@@ -15,6 +15,7 @@
 package common
 
 import (
+	"fmt"
 	"math/rand"
 	"time"
 )
@@ -44,3 +45,17 @@ func RandomString(strlen int, withoutNumber ...bool) string {
 	}
 	return string(result)
 }
+
+// FormatBytes formats the size in bytes in binary units.
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// DefaultArtifactCachePort is the default port of the artifact cache server.
+const DefaultArtifactCachePort = 18080
+
+// DefaultArtifactChecksumRetries is the default number of times an image corrupted in
+// transfer is transferred again.
+const DefaultArtifactChecksumRetries = 2
+
+// ArtifactCache is the config of the node caching the artifact for other nodes. The
+// artifact is copied to the cache node once, then other nodes download it from the
+// cache server on the node in parallel over the LAN.
+type ArtifactCache struct {
+	// Enabled makes other nodes download the artifact from the cache node, it's set
+	// by the --parallel-download flag of cluster prepare.
+	Enabled bool `yaml:"-"`
+	// Node is the name of the cache node, default is the first node.
+	Node string `yaml:"node,omitempty"`
+	// Port is the port of the cache server on the cache node.
+	Port int `yaml:"port,omitempty"`
+}
+
+// ArtifactCacheNode returns the node caching the artifact for other nodes.
+func (c *Config) ArtifactCacheNode() Node {
+	for _, node := range c.Nodes {
+		if node.Name == c.ArtifactCache.Node {
+			return node
+		}
+	}
+	return c.Nodes[0]
+}
+
+// validArtifactCache validates the cache node is a node of the cluster.
+func (c *Config) validArtifactCache(v *validator) {
+	v.validPort("artifactCache.port", c.ArtifactCache.Port)
+	if c.ArtifactCache.Node == "" {
+		return
+	}
+	for _, node := range c.Nodes {
+		if node.Name == c.ArtifactCache.Node {
+			return
+		}
+	}
+	v.addf(ValidationCategoryNodes, "artifactCache.node",
+		"artifactCache.node %s isn't a node of the cluster", c.ArtifactCache.Node)
+}
+
+// validArtifactChecksumRetries validates the retries aren't negative.
+func (c *Config) validArtifactChecksumRetries(v *validator) {
+	if c.ArtifactChecksumRetries < 0 {
+		v.addf(ValidationCategoryGeneral, "artifactChecksumRetries",
+			"artifactChecksumRetries must not be negative: %d", c.ArtifactChecksumRetries)
+	}
+}
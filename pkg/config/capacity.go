@@ -0,0 +1,140 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// capacitySkewWarnRatio is the ratio of the largest to the smallest capacity of storage
+// nodes above which the config is warned.
+const capacitySkewWarnRatio = 4
+
+var capacityRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([gtp])$`)
+
+var capacityUnits = map[string]float64{
+	"g": 1 << 30,
+	"t": 1 << 40,
+	"p": 1 << 50,
+}
+
+// ParseCapacity parses the capacity like 800g, 7.68t or 1p into bytes.
+func ParseCapacity(capacity string) (uint64, error) {
+	matches := capacityRegex.FindStringSubmatch(strings.ToLower(capacity))
+	if matches == nil {
+		return 0, errors.Errorf("invalid capacity %q", capacity)
+	}
+	size, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, errors.Annotatef(err, "parse capacity %q", capacity)
+	}
+	bytes := uint64(size * capacityUnits[matches[2]])
+	if bytes == 0 {
+		return 0, errors.Errorf("capacity %q must be positive", capacity)
+	}
+	return bytes, nil
+}
+
+// StorageCapacities returns capacities of storage nodes in bytes by names, it's nil
+// unless capacities of all storage nodes are set and valid.
+func (c *Config) StorageCapacities() map[string]uint64 {
+	capacities := make(map[string]uint64, len(c.Services.Storage.Nodes))
+	for _, node := range c.Nodes {
+		if !slices.Contains(c.Services.Storage.Nodes, node.Name) || node.Capacity == "" {
+			continue
+		}
+		capacity, err := ParseCapacity(node.Capacity)
+		if err != nil {
+			return nil
+		}
+		capacities[node.Name] = capacity
+	}
+	if len(capacities) == 0 || len(capacities) < len(c.Services.Storage.Nodes) {
+		return nil
+	}
+	return capacities
+}
+
+// validCapacity validates capacities of nodes, they must be set for all or none of
+// storage nodes.
+func (c *Config) validCapacity(v *validator) {
+	var withCapacity, withoutCapacity []string
+	for _, node := range c.Nodes {
+		if node.Capacity != "" {
+			if _, err := ParseCapacity(node.Capacity); err != nil {
+				key := fmt.Sprintf("nodes[%s].capacity", node.Name)
+				v.addf(ValidationCategoryNodes, key, "%s: %v", key, err)
+			}
+		}
+		if !slices.Contains(c.Services.Storage.Nodes, node.Name) {
+			continue
+		}
+		if node.Capacity == "" {
+			withoutCapacity = append(withoutCapacity, node.Name)
+		} else {
+			withCapacity = append(withCapacity, node.Name)
+		}
+	}
+	if len(withCapacity) > 0 && len(withoutCapacity) > 0 {
+		v.addf(ValidationCategoryNodes, "nodes.capacity",
+			"storage nodes %s have no capacity, capacity must be set for all or none of storage nodes",
+			strings.Join(withoutCapacity, ","))
+	}
+}
+
+// capacityWarnings returns warnings of capacities of storage nodes which risk hot spots
+// or unusable capacity.
+func (c *Config) capacityWarnings() []string {
+	capacities := c.StorageCapacities()
+	if capacities == nil {
+		return nil
+	}
+	var (
+		warnings          []string
+		total             uint64
+		largest, smallest string
+	)
+	// iterate nodes in config order, so that warnings are deterministic
+	for _, name := range c.Services.Storage.Nodes {
+		capacity := capacities[name]
+		total += capacity
+		if largest == "" || capacity > capacities[largest] {
+			largest = name
+		}
+		if smallest == "" || capacity < capacities[smallest] {
+			smallest = name
+		}
+	}
+	ratio := float64(capacities[largest]) / float64(capacities[smallest])
+	if ratio > capacitySkewWarnRatio {
+		warnings = append(warnings, fmt.Sprintf("capacities of storage nodes are skewed by %.1fx "+
+			"between %s and %s, the largest nodes serve most of the data and may become hot spots",
+			ratio, largest, smallest))
+	}
+	// every chain places one replica on a node, so a node holds at most 1/replicationFactor
+	// of the data
+	if rf := c.Services.Storage.ReplicationFactor; rf > 0 && capacities[largest]*uint64(rf) > total {
+		warnings = append(warnings, fmt.Sprintf("storage node %s has %.0f%% of the capacity, "+
+			"only 1/%d of the capacity of storage nodes can be used by a node with replicationFactor %d",
+			largest, float64(capacities[largest])*100/float64(total), rf, rf))
+	}
+	return warnings
+}
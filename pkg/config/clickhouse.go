@@ -0,0 +1,91 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+)
+
+// ShardCount returns the number of shards of metrics tables, it's at least 1.
+func (c *Clickhouse) ShardCount() int {
+	return max(c.Shards, 1)
+}
+
+// ReplicaCount returns the number of replicas of each shard, it's at least 1.
+func (c *Clickhouse) ReplicaCount() int {
+	return max(c.Replicas, 1)
+}
+
+// Replicated returns whether metrics tables are spread over more than one node, which
+// requires the embedded keeper and the cluster definition.
+func (c *Clickhouse) Replicated() bool {
+	return c.ShardCount()*c.ReplicaCount() > 1
+}
+
+// Topology returns node names of replicas of each shard. Replicas of shards take
+// clickhouse nodes in order, nodes beyond them run no clickhouse.
+func (c *Clickhouse) Topology() [][]string {
+	shards := make([][]string, 0, c.ShardCount())
+	for i := range c.ShardCount() {
+		start := i * c.ReplicaCount()
+		end := min(start+c.ReplicaCount(), len(c.Nodes))
+		if start >= end {
+			break
+		}
+		shards = append(shards, c.Nodes[start:end])
+	}
+	return shards
+}
+
+// TopologyNodes returns names of nodes running clickhouse in order.
+func (c *Clickhouse) TopologyNodes() []string {
+	var nodes []string
+	for _, shard := range c.Topology() {
+		nodes = append(nodes, shard...)
+	}
+	return nodes
+}
+
+// TopologyString describes the topology, e.g. "2 shard(s) x 2 replica(s)".
+func (c *Clickhouse) TopologyString() string {
+	return fmt.Sprintf("%d shard(s) x %d replica(s)", c.ShardCount(), c.ReplicaCount())
+}
+
+func (c *Config) validClickhouse(v *validator, nodesExpanded bool) {
+	ck := &c.Services.Clickhouse
+	if ck.Shards < 0 {
+		v.addf(ValidationCategoryServices, "services.clickhouse.shards",
+			"services.clickhouse.shards must not be negative: %d", ck.Shards)
+	}
+	if ck.Replicas < 0 {
+		v.addf(ValidationCategoryServices, "services.clickhouse.replicas",
+			"services.clickhouse.replicas must not be negative: %d", ck.Replicas)
+	}
+	if ck.RetentionDays < 0 {
+		v.addf(ValidationCategoryServices, "services.clickhouse.retentionDays",
+			"services.clickhouse.retentionDays must not be negative: %d", ck.RetentionDays)
+	}
+	if !nodesExpanded || !c.Services.Enabled(ServiceClickhouse) {
+		return
+	}
+	if ck.ReplicaCount() > len(ck.Nodes) {
+		v.addf(ValidationCategoryServices, "services.clickhouse.replicas",
+			"services.clickhouse.replicas %d exceeds %d clickhouse nodes", ck.ReplicaCount(), len(ck.Nodes))
+	} else if ck.ShardCount()*ck.ReplicaCount() > len(ck.Nodes) {
+		v.addf(ValidationCategoryServices, "services.clickhouse.shards",
+			"%s of clickhouse requires %d nodes, only %d clickhouse nodes", ck.TopologyString(),
+			ck.ShardCount()*ck.ReplicaCount(), len(ck.Nodes))
+	}
+}
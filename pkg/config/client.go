@@ -0,0 +1,86 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defines mount options of the 3fs client.
+const (
+	// MountOptionReadOnly mounts the filesystem read-only.
+	MountOptionReadOnly = "ro"
+	// MountOptionReadWrite mounts the filesystem read-write, it's the default.
+	MountOptionReadWrite = "rw"
+	// MountOptionAllowOther allows users other than the mounting user to access the
+	// filesystem, it's the default.
+	MountOptionAllowOther = "allow_other"
+	// MountOptionNoAllowOther only allows root to access the filesystem.
+	MountOptionNoAllowOther = "noallow_other"
+)
+
+// MountOptions are all supported mount options of the 3fs client.
+var MountOptions = []string{
+	MountOptionReadOnly, MountOptionReadWrite, MountOptionAllowOther, MountOptionNoAllowOther,
+}
+
+// ClientMount is the mount of the 3fs client parsed from mount options.
+type ClientMount struct {
+	ReadOnly   bool
+	AllowOther bool
+}
+
+// Mount returns the mount of the client by its mount options, the later one of
+// conflicting options wins like mount(8). Options must be valid.
+func (c Client) Mount() ClientMount {
+	mount := ClientMount{AllowOther: true}
+	for _, option := range c.MountOptions {
+		switch option {
+		case MountOptionReadOnly:
+			mount.ReadOnly = true
+		case MountOptionReadWrite:
+			mount.ReadOnly = false
+		case MountOptionAllowOther:
+			mount.AllowOther = true
+		case MountOptionNoAllowOther:
+			mount.AllowOther = false
+		}
+	}
+	return mount
+}
+
+func (c *Config) validClient(v *validator) {
+	client := c.Services.Client
+	for _, option := range client.MountOptions {
+		if !slices.Contains(MountOptions, option) {
+			v.addf(ValidationCategoryServices, "services.client.mountOptions",
+				"invalid mount option of services.client: %s, supported options are %s",
+				option, strings.Join(MountOptions, ", "))
+		}
+	}
+	for _, endpoint := range client.MgmtdEndpoints {
+		host, port, err := net.SplitHostPort(endpoint)
+		if err == nil && host != "" {
+			_, err = strconv.ParseUint(port, 10, 16)
+		}
+		if err != nil || host == "" {
+			v.addf(ValidationCategoryServices, "services.client.mgmtdEndpoints",
+				"invalid mgmtd endpoint of services.client: %s, it must be host:port", endpoint)
+		}
+	}
+}
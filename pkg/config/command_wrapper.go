@@ -0,0 +1,80 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// CommandWrapper returns arguments of the command wrapper of the service, which prefix
+// the command launching the service in its container. It's empty if the service isn't
+// wrapped, fdb and clickhouse are launched by their images and can't be wrapped.
+func (s *Services) CommandWrapper(service ServiceType) []string {
+	var wrapper string
+	switch service {
+	case ServiceMonitor:
+		wrapper = s.Monitor.CommandWrapper
+	case ServiceMgmtd:
+		wrapper = s.Mgmtd.CommandWrapper
+	case ServiceMeta:
+		wrapper = s.Meta.CommandWrapper
+	case ServiceStorage:
+		wrapper = s.Storage.CommandWrapper
+	case ServiceClient:
+		wrapper = s.Client.CommandWrapper
+	}
+	return strings.Fields(wrapper)
+}
+
+// CommandWrapperNodes returns nodes running services which are wrapped.
+func (c *Config) CommandWrapperNodes() []Node {
+	var nodes []Node
+	for _, node := range c.Nodes {
+		if len(c.NodeCommandWrappers(node.Name)) > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// NodeCommandWrappers returns command wrappers of services on the node.
+func (c *Config) NodeCommandWrappers(nodeName string) map[ServiceType][]string {
+	wrappers := make(map[ServiceType][]string)
+	for _, service := range AllServiceTypes {
+		wrapper := c.Services.CommandWrapper(service)
+		if len(wrapper) > 0 && slices.Contains(c.Services.ServiceNodes(service), nodeName) {
+			wrappers[service] = wrapper
+		}
+	}
+	return wrappers
+}
+
+func (c *Config) validCommandWrappers(v *validator) {
+	for _, service := range AllServiceTypes {
+		wrapper := c.Services.CommandWrapper(service)
+		if len(wrapper) == 0 {
+			continue
+		}
+		// the executable is mounted into the container from the same path on the node
+		if !path.IsAbs(wrapper[0]) {
+			key := fmt.Sprintf("services.%s.commandWrapper", service)
+			v.addf(ValidationCategoryServices, key, "%s: executable %s must be an absolute path",
+				key, wrapper[0])
+		}
+	}
+}
@@ -16,10 +16,17 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/utils"
 )
@@ -48,6 +55,39 @@ const (
 
 var diskTypes = utils.NewSet(DiskTypeDirectory, DiskTypeNvme)
 
+// ContainerRuntime is the type of container runtime definition
+type ContainerRuntime string
+
+// defines container runtimes
+const (
+	ContainerRuntimeDocker     ContainerRuntime = "docker"
+	ContainerRuntimeContainerd ContainerRuntime = "containerd"
+	ContainerRuntimePodman     ContainerRuntime = "podman"
+)
+
+// ContainerRuntimes are all supported container runtimes in the order of detection.
+var ContainerRuntimes = []ContainerRuntime{
+	ContainerRuntimeDocker, ContainerRuntimeContainerd, ContainerRuntimePodman,
+}
+
+// HostKeyPolicy is the policy of verifying SSH host keys of nodes.
+type HostKeyPolicy string
+
+// defines host key policies
+const (
+	// HostKeyPolicyStrict requires keys of nodes in the known_hosts file.
+	HostKeyPolicyStrict HostKeyPolicy = "strict"
+	// HostKeyPolicyAcceptNew pins keys of nodes on first contact and enforces them thereafter.
+	HostKeyPolicyAcceptNew HostKeyPolicy = "accept-new"
+	// HostKeyPolicyInsecureIgnore doesn't verify keys of nodes.
+	HostKeyPolicyInsecureIgnore HostKeyPolicy = "insecure-ignore"
+)
+
+// HostKeyPolicies are all supported host key policies.
+var HostKeyPolicies = []HostKeyPolicy{
+	HostKeyPolicyStrict, HostKeyPolicyAcceptNew, HostKeyPolicyInsecureIgnore,
+}
+
 // Node is the node config definition
 type Node struct {
 	Name          string
@@ -56,6 +96,14 @@ type Node struct {
 	Username      string
 	Password      *string  `yaml:",omitempty"`
 	RDMAAddresses []string `yaml:"rdmaAddresses,omitempty"`
+	// Env is the environment of commands run on the node, it takes precedence over
+	// the global env of the cluster.
+	Env map[string]string `yaml:"env,omitempty"`
+	// Zone is the availability zone of the node, see Placement.
+	Zone string `yaml:"zone,omitempty"`
+	// Capacity is the capacity of data disks of the storage node, e.g. 8t, data is
+	// placed in proportion to capacities of storage nodes if they're set.
+	Capacity string `yaml:"capacity,omitempty"`
 }
 
 // NodeGroup is the node group config definition
@@ -67,19 +115,86 @@ type NodeGroup struct {
 	IPBegin  string  `yaml:"ipBegin"`
 	IPEnd    string  `yaml:"ipEnd"`
 	Nodes    []Node  `yaml:"-"`
+	// Env is the environment of commands run on nodes of the group.
+	Env map[string]string `yaml:"env,omitempty"`
+	// Zone is the availability zone of nodes of the group.
+	Zone string `yaml:"zone,omitempty"`
+	// Capacity is the capacity of data disks of every storage node of the group.
+	Capacity string `yaml:"capacity,omitempty"`
+}
+
+// defines behaviors when a service isn't ready after the readiness timeout.
+const (
+	// ReadinessFailureModeFatal aborts the deployment, it's the default.
+	ReadinessFailureModeFatal = "fatal"
+	// ReadinessFailureModeWarn logs a warning and continues.
+	ReadinessFailureModeWarn = "warn"
+	// ReadinessFailureModeRetryLater continues and re-checks the service after later
+	// tasks, the run fails if it's still not ready after all tasks.
+	ReadinessFailureModeRetryLater = "retry-later"
+)
+
+// Readiness is the config of polling a service until it's ready after started.
+type Readiness struct {
+	ReadinessTimeout     Duration `yaml:"readinessTimeout,omitempty"`
+	ReadinessInterval    Duration `yaml:"readinessInterval,omitempty"`
+	ReadinessFailureMode string   `yaml:"readinessFailureMode,omitempty"`
+}
+
+// FailureMode returns the readiness failure mode, it's fatal if not set.
+func (r Readiness) FailureMode() string {
+	if r.ReadinessFailureMode == "" {
+		return ReadinessFailureModeFatal
+	}
+	return r.ReadinessFailureMode
+}
+
+// Quarantine is the config of quarantining nodes which become unreachable during a run.
+// Nodes of a parallel step which are unreachable are quarantined while other nodes
+// continue, and they're retried until they recover or the timeout.
+type Quarantine struct {
+	// Timeout is how long a quarantined node is retried before the run fails, nodes
+	// aren't quarantined if it's zero.
+	Timeout Duration `yaml:"timeout,omitempty"`
+	// RetryInterval is the interval of retrying quarantined nodes.
+	RetryInterval Duration `yaml:"retryInterval,omitempty"`
+}
+
+// Enabled returns whether unreachable nodes are quarantined.
+func (q Quarantine) Enabled() bool {
+	return q.Timeout > 0
+}
+
+// Resources is the config of limiting resources of a service container.
+type Resources struct {
+	// Memory is the memory limit like 64g, memory isn't limited if it's empty.
+	Memory string `yaml:"memory,omitempty"`
+	// CPUs is the number of CPUs like 1.5, CPU isn't limited if it's zero.
+	CPUs float64 `yaml:"cpus,omitempty"`
+	// NoFile and NProc are ulimits in the format of soft[:hard].
+	NoFile string `yaml:"nofile,omitempty"`
+	NProc  string `yaml:"nproc,omitempty"`
 }
 
 // Fdb is the fdb config definition
 type Fdb struct {
+	// Enabled is whether the service is deployed, default is true.
+	Enabled            *bool  `yaml:"enabled,omitempty"`
 	ContainerName      string `yaml:"containerName"`
 	Nodes              []string
 	NodeGroups         []string `yaml:"nodeGroups"`
 	Port               int
-	WaitClusterTimeout time.Duration
+	WaitClusterTimeout Duration `yaml:",omitempty"` // Deprecated: use ReadinessTimeout instead.
+	Readiness          `yaml:",inline"`
+	Shutdown           `yaml:",inline"`
+	Passthrough        `yaml:",inline"`
+	Resources          Resources `yaml:"resources,omitempty"`
 }
 
 // Clickhouse is the click house config definition
 type Clickhouse struct {
+	// Enabled is whether the service is deployed, default is true.
+	Enabled       *bool  `yaml:"enabled,omitempty"`
 	ContainerName string `yaml:"containerName"`
 	Nodes         []string
 	NodeGroups    []string `yaml:"nodeGroups"`
@@ -87,18 +202,60 @@ type Clickhouse struct {
 	User          string   `yaml:"user"`
 	Password      string   `yaml:"password"`
 	TCPPort       int      `yaml:"tcpPort"`
+	Readiness     `yaml:",inline"`
+	Shutdown      `yaml:",inline"`
+	Passthrough   `yaml:",inline"`
+	Resources     Resources `yaml:"resources,omitempty"`
+
+	// Shards and Replicas define the topology of metrics tables, replicas of shards
+	// take clickhouse nodes in order. The default is a single node.
+	Shards   int `yaml:"shards,omitempty"`
+	Replicas int `yaml:"replicas,omitempty"`
+	// RetentionDays is the TTL of rows of metrics tables, the default is a month.
+	RetentionDays int `yaml:"retentionDays,omitempty"`
+	// KeeperPort and KeeperRaftPort are ports of the embedded clickhouse keeper which
+	// coordinates replicated tables, replicas fetch parts by InterserverPort.
+	KeeperPort      int `yaml:"keeperPort,omitempty"`
+	KeeperRaftPort  int `yaml:"keeperRaftPort,omitempty"`
+	InterserverPort int `yaml:"interserverPort,omitempty"`
 }
 
 // Monitor is the monitor config definition
 type Monitor struct {
+	// Enabled is whether the service is deployed, default is true.
+	Enabled       *bool  `yaml:"enabled,omitempty"`
 	ContainerName string `yaml:"containerName"`
 	Nodes         []string
 	NodeGroups    []string `yaml:"nodeGroups"`
 	Port          int      `yaml:"port"`
+	Readiness     `yaml:",inline"`
+	Shutdown      `yaml:",inline"`
+	Passthrough   `yaml:",inline"`
+	Resources     Resources `yaml:"resources,omitempty"`
+	// CommandWrapper prefixes the command launching the service, like strace -f.
+	CommandWrapper string `yaml:"commandWrapper,omitempty"`
+	// Alerts are thresholds of alerting rules rendered by cluster alert-rules.
+	Alerts MonitorAlerts `yaml:"alerts,omitempty"`
+}
+
+// MonitorAlerts are thresholds of alerting rules of the cluster for Prometheus.
+type MonitorAlerts struct {
+	// For is how long a condition lasts before it alerts.
+	For Duration `yaml:"for,omitempty"`
+	// UnreachableNodes is the number of nodes which monitor-drift can't reach to alert at.
+	UnreachableNodes int `yaml:"unreachableNodes,omitempty"`
+	// DriftFindings is the number of findings of a drift check to alert at.
+	DriftFindings int `yaml:"driftFindings,omitempty"`
+	// StaleCheck alerts when monitor-drift hasn't checked the cluster for the duration.
+	StaleCheck Duration `yaml:"staleCheck,omitempty"`
+	// SlowTask alerts when a task of m3fs runs longer than the duration, 0 disables it.
+	SlowTask Duration `yaml:"slowTask,omitempty"`
 }
 
 // Mgmtd is the 3fs mgmtd service config definition
 type Mgmtd struct {
+	// Enabled is whether the service is deployed, default is true.
+	Enabled        *bool  `yaml:"enabled,omitempty"`
 	ContainerName  string `yaml:"containerName"`
 	Nodes          []string
 	NodeGroups     []string `yaml:"nodeGroups"`
@@ -106,19 +263,37 @@ type Mgmtd struct {
 	StripeSize     int      `yaml:"stripeSize"`
 	RDMAListenPort int      `yaml:"rdmaListenPort,omitempty"`
 	TCPListenPort  int      `yaml:"tcpListenPort,omitempty"`
+	Readiness      `yaml:",inline"`
+	Shutdown       `yaml:",inline"`
+	Passthrough    `yaml:",inline"`
+	Resources      Resources   `yaml:"resources,omitempty"`
+	ExtraConfig    ExtraConfig `yaml:"extraConfig,omitempty"`
+	// CommandWrapper prefixes the command launching the service, like strace -f.
+	CommandWrapper string `yaml:"commandWrapper,omitempty"`
 }
 
 // Meta is the 3fs meta service config definition
 type Meta struct {
+	// Enabled is whether the service is deployed, default is true.
+	Enabled        *bool  `yaml:"enabled,omitempty"`
 	ContainerName  string `yaml:"containerName"`
 	Nodes          []string
 	NodeGroups     []string `yaml:"nodeGroups"`
 	RDMAListenPort int      `yaml:"rdmaListenPort,omitempty"`
 	TCPListenPort  int      `yaml:"tcpListenPort,omitempty"`
+	Readiness      `yaml:",inline"`
+	Shutdown       `yaml:",inline"`
+	Passthrough    `yaml:",inline"`
+	Resources      Resources   `yaml:"resources,omitempty"`
+	ExtraConfig    ExtraConfig `yaml:"extraConfig,omitempty"`
+	// CommandWrapper prefixes the command launching the service, like strace -f.
+	CommandWrapper string `yaml:"commandWrapper,omitempty"`
 }
 
 // Storage is the 3fs storage config definition
 type Storage struct {
+	// Enabled is whether the service is deployed, default is true.
+	Enabled           *bool  `yaml:"enabled,omitempty"`
 	ContainerName     string `yaml:"containerName"`
 	Nodes             []string
 	NodeGroups        []string `yaml:"nodeGroups"`
@@ -131,14 +306,37 @@ type Storage struct {
 	TargetNumPerDisk  int      `yaml:"targetNumPerDisk,omitempty"`
 	TargetIDPrefix    int      `yaml:"targetIDPrefix,omitempty"`
 	ChainIDPrefix     int      `yaml:"chainIDPrefix,omitempty"`
+	// Placement constrains placing replicas across availability zones.
+	Placement   Placement `yaml:"placement,omitempty"`
+	Readiness   `yaml:",inline"`
+	Shutdown    `yaml:",inline"`
+	Passthrough `yaml:",inline"`
+	Resources   Resources   `yaml:"resources,omitempty"`
+	ExtraConfig ExtraConfig `yaml:"extraConfig,omitempty"`
+	// CommandWrapper prefixes the command launching the service, like strace -f.
+	CommandWrapper string `yaml:"commandWrapper,omitempty"`
 }
 
 // Client is the 3fs client config definition
 type Client struct {
+	// Enabled is whether the service is deployed, default is true.
+	Enabled        *bool  `yaml:"enabled,omitempty"`
 	ContainerName  string `yaml:"containerName"`
 	Nodes          []string
 	NodeGroups     []string `yaml:"nodeGroups"`
 	HostMountpoint string   `yaml:"hostMountpoint"`
+	// MountOptions are options of mounting the filesystem, see MountOptions.
+	MountOptions []string `yaml:"mountOptions,omitempty"`
+	// MgmtdEndpoints are host:port of mgmtd the client connects to, default is
+	// mgmtd services of the cluster.
+	MgmtdEndpoints []string `yaml:"mgmtdEndpoints,omitempty"`
+	Readiness      `yaml:",inline"`
+	Shutdown       `yaml:",inline"`
+	Passthrough    `yaml:",inline"`
+	Resources      Resources   `yaml:"resources,omitempty"`
+	ExtraConfig    ExtraConfig `yaml:"extraConfig,omitempty"`
+	// CommandWrapper prefixes the command launching the service, like strace -f.
+	CommandWrapper string `yaml:"commandWrapper,omitempty"`
 }
 
 // Services is the services config definition
@@ -152,9 +350,155 @@ type Services struct {
 	Client     Client
 }
 
-// UIConfig holds UI related configurations
-type UIConfig struct {
-	TaskInfoColor string `yaml:"taskInfoColor,omitempty"`
+// ServiceNodes returns node names of the service, a disabled service has no nodes.
+func (s *Services) ServiceNodes(service ServiceType) []string {
+	if !s.Enabled(service) {
+		return nil
+	}
+	switch service {
+	case ServiceFdb:
+		return s.Fdb.Nodes
+	case ServiceClickhouse:
+		return s.Clickhouse.Nodes
+	case ServiceMonitor:
+		return s.Monitor.Nodes
+	case ServiceMgmtd:
+		return s.Mgmtd.Nodes
+	case ServiceMeta:
+		return s.Meta.Nodes
+	case ServiceStorage:
+		return s.Storage.Nodes
+	case ServiceClient:
+		return s.Client.Nodes
+	default:
+		return nil
+	}
+}
+
+// ServiceNodeGroups returns names of node groups of the service.
+func (s *Services) ServiceNodeGroups(service ServiceType) []string {
+	switch service {
+	case ServiceFdb:
+		return s.Fdb.NodeGroups
+	case ServiceClickhouse:
+		return s.Clickhouse.NodeGroups
+	case ServiceMonitor:
+		return s.Monitor.NodeGroups
+	case ServiceMgmtd:
+		return s.Mgmtd.NodeGroups
+	case ServiceMeta:
+		return s.Meta.NodeGroups
+	case ServiceStorage:
+		return s.Storage.NodeGroups
+	case ServiceClient:
+		return s.Client.NodeGroups
+	default:
+		return nil
+	}
+}
+
+// AddServiceNode adds the node to nodes of the service if it's absent.
+func (s *Services) AddServiceNode(service ServiceType, nodeName string) error {
+	var nodes *[]string
+	switch service {
+	case ServiceFdb:
+		nodes = &s.Fdb.Nodes
+	case ServiceClickhouse:
+		nodes = &s.Clickhouse.Nodes
+	case ServiceMonitor:
+		nodes = &s.Monitor.Nodes
+	case ServiceMgmtd:
+		nodes = &s.Mgmtd.Nodes
+	case ServiceMeta:
+		nodes = &s.Meta.Nodes
+	case ServiceStorage:
+		nodes = &s.Storage.Nodes
+	case ServiceClient:
+		nodes = &s.Client.Nodes
+	default:
+		return errors.Errorf("invalid service: %s", service)
+	}
+	if !slices.Contains(*nodes, nodeName) {
+		*nodes = append(*nodes, nodeName)
+	}
+	return nil
+}
+
+// Readiness returns the readiness config of the service.
+func (s *Services) Readiness(service ServiceType) Readiness {
+	switch service {
+	case ServiceFdb:
+		return s.Fdb.Readiness
+	case ServiceClickhouse:
+		return s.Clickhouse.Readiness
+	case ServiceMonitor:
+		return s.Monitor.Readiness
+	case ServiceMgmtd:
+		return s.Mgmtd.Readiness
+	case ServiceMeta:
+		return s.Meta.Readiness
+	case ServiceStorage:
+		return s.Storage.Readiness
+	case ServiceClient:
+		return s.Client.Readiness
+	default:
+		return Readiness{}
+	}
+}
+
+// Resources returns the resource limits of the service.
+func (s *Services) Resources(service ServiceType) Resources {
+	switch service {
+	case ServiceFdb:
+		return s.Fdb.Resources
+	case ServiceClickhouse:
+		return s.Clickhouse.Resources
+	case ServiceMonitor:
+		return s.Monitor.Resources
+	case ServiceMgmtd:
+		return s.Mgmtd.Resources
+	case ServiceMeta:
+		return s.Meta.Resources
+	case ServiceStorage:
+		return s.Storage.Resources
+	case ServiceClient:
+		return s.Client.Resources
+	default:
+		return Resources{}
+	}
+}
+
+// ContainerName returns the container name of the service.
+func (s *Services) ContainerName(service ServiceType) string {
+	switch service {
+	case ServiceFdb:
+		return s.Fdb.ContainerName
+	case ServiceClickhouse:
+		return s.Clickhouse.ContainerName
+	case ServiceMonitor:
+		return s.Monitor.ContainerName
+	case ServiceMgmtd:
+		return s.Mgmtd.ContainerName
+	case ServiceMeta:
+		return s.Meta.ContainerName
+	case ServiceStorage:
+		return s.Storage.ContainerName
+	case ServiceClient:
+		return s.Client.ContainerName
+	default:
+		return ""
+	}
+}
+
+// TLSConfig is the config of verifying TLS certificates of the registry and HTTP downloads.
+type TLSConfig struct {
+	// CAFile is the path of the CA bundle, which is trusted in addition to system CAs.
+	CAFile string `yaml:"caFile,omitempty"`
+	// CertFile and KeyFile are paths of the client certificate and key for mutual TLS.
+	CertFile string `yaml:"certFile,omitempty"`
+	KeyFile  string `yaml:"keyFile,omitempty"`
+	// InsecureSkipVerify disables verification of certificates, it's discouraged.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify,omitempty"`
 }
 
 // Config is the 3fs cluster config definition
@@ -164,41 +508,136 @@ type Config struct {
 	NetworkType       NetworkType `yaml:"networkType"`
 	LogLevel          string      `yaml:"logLevel"`
 	Nodes             []Node
-	NodeGroups        []NodeGroup    `yaml:"nodeGroups"`
-	Services          Services       `yaml:"services"`
-	Images            Images         `yaml:"images"`
-	UI                UIConfig       `yaml:"ui,omitempty"`
-	CmdMaxExitTimeout *time.Duration `yaml:",omitempty"`
+	NodeGroups        []NodeGroup `yaml:"nodeGroups"`
+	Services          Services    `yaml:"services"`
+	Images            Images      `yaml:"images"`
+	UI                UIConfig    `yaml:"ui,omitempty"`
+	CmdMaxExitTimeout *Duration   `yaml:",omitempty"`
+	// ConnectTimeout limits connecting to a node by SSH, so that an unreachable node
+	// fails fast.
+	ConnectTimeout Duration `yaml:"connectTimeout,omitempty"`
+	// CommandTimeout limits a command run on a node which isn't limited by its task,
+	// commands aren't limited if it's zero.
+	CommandTimeout Duration `yaml:"commandTimeout,omitempty"`
+	// Quarantine makes unreachable nodes quarantined instead of failing the run at once.
+	Quarantine Quarantine `yaml:"quarantine,omitempty"`
+	// StagingDir is the dir in which artifacts are staged on the deploy host and nodes,
+	// default is the temp dir on the deploy host and the work dir on nodes.
+	StagingDir string `yaml:"stagingDir,omitempty"`
+	// ArtifactCache makes nodes download the artifact from a node caching it.
+	ArtifactCache ArtifactCache `yaml:"artifactCache,omitempty"`
+	// ArtifactChecksumRetries is the number of times an image whose checksum mismatches
+	// after it's transferred to a node is transferred again, before failing.
+	ArtifactChecksumRetries int `yaml:"artifactChecksumRetries,omitempty"`
+	// WatchdogInterval is the interval after which a task without any step or command
+	// starting or ending is warned as stuck, tasks aren't watched if it's zero.
+	WatchdogInterval Duration `yaml:"watchdogInterval,omitempty"`
+	// WatchdogDump makes the watchdog dump goroutines into the run dir on warnings.
+	WatchdogDump bool `yaml:"watchdogDump,omitempty"`
+	// DeploymentWindows refuse mutating commands out of the windows allowing deployment.
+	DeploymentWindows DeploymentWindows `yaml:"deploymentWindows,omitempty"`
+	// StateEncryption encrypts the cluster state and records of runs at rest.
+	StateEncryption StateEncryption `yaml:"stateEncryption,omitempty"`
+
+	// Local deploys a single node cluster on the local host without SSH, it's set by
+	// the --local flag.
+	Local bool `yaml:"local,omitempty"`
+	// localWarnings are warnings of settings relaxed by the local mode.
+	localWarnings []string
+
+	// Env is the environment of commands run on all nodes.
+	Env map[string]string `yaml:"env,omitempty"`
+	// resolvedSecrets are secrets resolved from references by ResolveSecrets.
+	resolvedSecrets []string
+	// resolvedSecretRefs are fields whose values are resolved by ResolveSecrets.
+	resolvedSecretRefs []ResolvedSecretRef
+
+	// HostKeyPolicy is the policy of verifying SSH host keys of nodes, default is accept-new.
+	HostKeyPolicy HostKeyPolicy `yaml:"hostKeyPolicy,omitempty"`
+
+	// TLS is used by HTTP downloads and pulling images from the registry.
+	TLS TLSConfig `yaml:"tls,omitempty"`
+
+	// ManageHosts makes m3fs manage entries of all nodes in /etc/hosts of nodes, so
+	// that nodes can address each other by names without DNS.
+	ManageHosts bool `yaml:"manageHosts,omitempty"`
+
+	// ManageFirewall makes m3fs open ports of services on nodes to all nodes of the cluster
+	// in the detected firewall of nodes.
+	ManageFirewall bool `yaml:"manageFirewall,omitempty"`
+
+	// DNS is the resolver config of nodes managed by m3fs, it isn't managed if it's empty.
+	DNS DNS `yaml:"dns,omitempty"`
+
+	// ContainerRuntime is detected on each node if it's empty.
+	ContainerRuntime ContainerRuntime `yaml:"containerRuntime,omitempty"`
+
+	// PhaseGates makes cluster create pause for approval between phases of tasks.
+	PhaseGates bool `yaml:"phaseGates,omitempty"`
+
+	// Tuning maps services to kernel tuning profiles applied to their nodes by cluster prepare.
+	Tuning map[ServiceType]TuningProfile `yaml:"tuning,omitempty"`
+
+	// Deployment is the strategy of deploying services on their nodes.
+	Deployment Deployment `yaml:"deployment,omitempty"`
+
+	// RunHistory is the number of latest runs kept in the run history of the cluster,
+	// runs aren't appended to the history if it's 0.
+	RunHistory int `yaml:"runHistory,omitempty"`
+
+	// Plugins are site-specific tasks run by cluster create and cluster delete.
+	Plugins []PluginTask `yaml:"plugins,omitempty"`
+
+	// Profile is the profile applied on top of the config, or selected by the config.
+	Profile string `yaml:"profile,omitempty"`
+	// Profiles are overrides of the config keyed by names of profiles, they're removed
+	// from the config once a profile is applied by ApplyProfile.
+	Profiles map[string]yaml.Node `yaml:"profiles,omitempty"`
 }
 
-func (c *Config) parseValidateNodeGroups(hostSet *utils.Set[string]) (map[string]*NodeGroup, error) {
+func (c *Config) parseValidateNodeGroups(v *validator, hostSet *utils.Set[string]) map[string]*NodeGroup {
 	nodeGroups := make(map[string]*NodeGroup, len(c.NodeGroups))
 	for i := range c.NodeGroups {
 		nodeGroup := &c.NodeGroups[i]
+		key := fmt.Sprintf("nodeGroups[%d]", i)
 		if _, ok := nodeGroups[nodeGroup.Name]; ok {
-			return nil, errors.Errorf("duplicate node group name: %s", nodeGroup.Name)
+			v.addf(ValidationCategoryNodes, key+".name", "duplicate node group name: %s", nodeGroup.Name)
+			continue
 		}
 		if nodeGroup.Name == "" {
-			return nil, errors.Errorf("nodeGroup[%d].name is required", i)
+			v.addf(ValidationCategoryNodes, key+".name", "nodeGroup[%d].name is required", i)
+			continue
 		}
 		if nodeGroup.Username == "" {
-			return nil, errors.Errorf("nodeGroup[%d].username is required", i)
+			v.addf(ValidationCategoryNodes, key+".username", "nodeGroup[%d].username is required", i)
 		}
+		v.validPort(key+".port", nodeGroup.Port)
+		nodeGroup.IPBegin = utils.TrimHostBrackets(nodeGroup.IPBegin)
+		nodeGroup.IPEnd = utils.TrimHostBrackets(nodeGroup.IPEnd)
+		overlapped := false
 		for _, existNodeGroup := range nodeGroups {
 			// check range overlap
-			if (nodeGroup.IPBegin <= existNodeGroup.IPBegin && nodeGroup.IPEnd >= existNodeGroup.IPBegin) ||
-				(nodeGroup.IPBegin >= existNodeGroup.IPBegin && nodeGroup.IPBegin <= existNodeGroup.IPEnd) {
-				return nil, errors.Errorf("node group %s and %s ip range overlap",
+			if utils.CompareIP(nodeGroup.IPBegin, existNodeGroup.IPEnd) <= 0 &&
+				utils.CompareIP(existNodeGroup.IPBegin, nodeGroup.IPEnd) <= 0 {
+				v.addf(ValidationCategoryNodes, key, "node group %s and %s ip range overlap",
 					nodeGroup.Name, existNodeGroup.Name)
+				overlapped = true
 			}
 		}
+		// invalid node groups are kept without nodes, so that services referring them
+		// aren't reported as referring unknown node groups
 		nodeGroups[nodeGroup.Name] = nodeGroup
+		if overlapped {
+			continue
+		}
 		nodeGroupIPs, err := utils.GenerateIPRange(nodeGroup.IPBegin, nodeGroup.IPEnd)
 		if err != nil {
-			return nil, errors.Annotatef(err, "generate ip range for node group %s", nodeGroup.Name)
+			v.addf(ValidationCategoryNodes, key, "generate ip range for node group %s: %v", nodeGroup.Name, err)
+			continue
 		}
 		if len(nodeGroupIPs) == 0 {
-			return nil, errors.Errorf("node group %s ip range is empty", nodeGroup.Name)
+			v.addf(ValidationCategoryNodes, key, "node group %s ip range is empty", nodeGroup.Name)
+			continue
 		}
 		c.NodeGroups[i].Nodes = make([]Node, len(nodeGroupIPs))
 		if nodeGroup.Port == 0 {
@@ -206,7 +645,7 @@ func (c *Config) parseValidateNodeGroups(hostSet *utils.Set[string]) (map[string
 		}
 		for j, nodeGroupIP := range nodeGroupIPs {
 			if hostSet.Contains(nodeGroupIP) {
-				return nil, errors.Errorf("node ip %s duplicate with node group %s",
+				v.addf(ValidationCategoryNodes, key, "node ip %s duplicate with node group %s",
 					nodeGroupIP, nodeGroup.Name)
 			}
 			nodeGroup.Nodes[j] = Node{
@@ -215,15 +654,19 @@ func (c *Config) parseValidateNodeGroups(hostSet *utils.Set[string]) (map[string
 				Port:     nodeGroup.Port,
 				Username: nodeGroup.Username,
 				Password: nodeGroup.Password,
+				Env:      nodeGroup.Env,
+				Zone:     nodeGroup.Zone,
+				Capacity: nodeGroup.Capacity,
 			}
 		}
 	}
 
-	return nodeGroups, nil
+	return nodeGroups
 }
 
 func (c *Config) parseNodeGroupToNodes(nodeGroupMap map[string]*NodeGroup) {
-	for _, nodeGroup := range nodeGroupMap {
+	// iterate node groups in config order, so that nodes are in the same order on every load
+	for _, nodeGroup := range c.NodeGroups {
 		c.Nodes = append(c.Nodes, nodeGroup.Nodes...)
 	}
 
@@ -271,10 +714,13 @@ func (c *Config) parseNodeGroupToNodes(nodeGroupMap map[string]*NodeGroup) {
 	}
 }
 
-// SetValidate validates the config and set default values if some fields are missing
+// SetValidate validates the config and set default values if some fields are missing.
+// It doesn't stop at the first error, the returned error is ValidationErrors of all
+// errors found if the config is invalid.
 func (c *Config) SetValidate(workDir, registry string) error {
+	v := new(validator)
 	if c.Name == "" {
-		return errors.New("name is required")
+		v.addf(ValidationCategoryGeneral, "name", "name is required")
 	}
 	if c.LogLevel == "" {
 		c.LogLevel = "INFO"
@@ -292,40 +738,60 @@ func (c *Config) SetValidate(workDir, registry string) error {
 		c.Images.Registry = registry
 	}
 	upperNetwork := NetworkType(strings.ToUpper(string(c.NetworkType)))
-	if !networkTypes.Contains(upperNetwork) {
-		return errors.Errorf("invalid network type: %s", c.NetworkType)
+	if networkTypes.Contains(upperNetwork) {
+		c.NetworkType = upperNetwork
+	} else {
+		v.addf(ValidationCategoryGeneral, "networkType", "invalid network type: %s", c.NetworkType)
+	}
+	if c.ContainerRuntime == "nerdctl" {
+		c.ContainerRuntime = ContainerRuntimeContainerd
+	}
+	if c.ContainerRuntime != "" && !slices.Contains(ContainerRuntimes, c.ContainerRuntime) {
+		v.addf(ValidationCategoryGeneral, "containerRuntime", "invalid container runtime: %s", c.ContainerRuntime)
+	}
+	if c.HostKeyPolicy == "" {
+		c.HostKeyPolicy = HostKeyPolicyAcceptNew
+	}
+	if !slices.Contains(HostKeyPolicies, c.HostKeyPolicy) {
+		v.addf(ValidationCategoryGeneral, "hostKeyPolicy", "invalid host key policy: %s", c.HostKeyPolicy)
+	}
+	if c.Local {
+		c.applyLocal()
 	}
-	c.NetworkType = upperNetwork
 	if len(c.Nodes) == 0 && len(c.NodeGroups) == 0 {
-		return errors.New("nodes or nodeGroups is required")
+		v.addf(ValidationCategoryNodes, "nodes", "nodes or nodeGroups is required")
 	}
 	nodeSet := utils.NewSet[string]()
 	nodeHostSet := utils.NewSet[string]()
 	for i, node := range c.Nodes {
+		key := fmt.Sprintf("nodes[%d]", i)
 		if node.Name == "" {
-			return errors.Errorf("nodes[%d].name is required", i)
-		}
-		if !nodeSet.AddIfNotExists(node.Name) {
-			return errors.Errorf("duplicate node name: %s", node.Name)
+			v.addf(ValidationCategoryNodes, key+".name", "nodes[%d].name is required", i)
+		} else {
+			key = fmt.Sprintf("nodes[%s]", node.Name)
+			if !nodeSet.AddIfNotExists(node.Name) {
+				v.addf(ValidationCategoryNodes, key+".name", "duplicate node name: %s", node.Name)
+			}
 		}
 		if node.Host == "" {
-			return errors.Errorf("nodes[%d].host is required", i)
-		}
-		if !nodeHostSet.AddIfNotExists(node.Host) {
-			return errors.Errorf("duplicate node host: %s", node.Host)
+			v.addf(ValidationCategoryNodes, key+".host", "nodes[%d].host is required", i)
+		} else {
+			node.Host = utils.NormalizeHost(node.Host)
+			c.Nodes[i].Host = node.Host
+			if !nodeHostSet.AddIfNotExists(node.Host) {
+				v.addf(ValidationCategoryNodes, key+".host", "duplicate node host: %s", node.Host)
+			}
 		}
 		if node.Username == "" {
-			return errors.Errorf("nodes[%d].username is required", i)
+			v.addf(ValidationCategoryNodes, key+".username", "nodes[%d].username is required", i)
 		}
+		v.validPort(key+".port", node.Port)
 		if node.Port == 0 {
 			c.Nodes[i].Port = 22
 		}
 	}
 
-	nodeGroupMap, err := c.parseValidateNodeGroups(nodeHostSet)
-	if err != nil {
-		return errors.Trace(err)
-	}
+	nodeGroupMap := c.parseValidateNodeGroups(v, nodeHostSet)
 
 	validSettings := []struct {
 		name       string
@@ -377,65 +843,263 @@ func (c *Config) SetValidate(workDir, registry string) error {
 		},
 	}
 
+	c.validEnabled(v)
+	servicesValid := true
 	for _, s := range validSettings {
-		if err := c.validServiceNodes(s.name, s.nodes, s.nodeGroups, nodeSet,
-			nodeGroupMap, s.require); err != nil {
-
-			return errors.Trace(err)
+		require := s.require && c.Services.Enabled(ServiceType(s.name))
+		if !c.validServiceNodes(v, s.name, s.nodes, s.nodeGroups, nodeSet, nodeGroupMap, require) {
+			servicesValid = false
 		}
 	}
 
-	c.parseNodeGroupToNodes(nodeGroupMap)
+	// nodes of node groups can't be expanded if services refer to unknown node groups
+	if servicesValid {
+		c.parseNodeGroupToNodes(nodeGroupMap)
+	}
+
+	c.validManageHosts(v)
+	c.validDNS(v)
+	c.validManageFirewall(v)
+	c.validArtifactCache(v)
+	c.validArtifactChecksumRetries(v)
+	c.validStagingDir(v)
+	c.validProfiles(v)
+	c.validEnv(v)
 
 	if !diskTypes.Contains(c.Services.Storage.DiskType) {
-		return errors.Errorf("invalid disk type of storage service: %s", c.Services.Storage.DiskType)
+		v.addf(ValidationCategoryServices, "services.storage.diskType",
+			"invalid disk type of storage service: %s", c.Services.Storage.DiskType)
 	}
-	if c.Services.Client.HostMountpoint == "" {
-		return errors.New("services.client.hostMountpoint is required")
+	c.validPorts(v)
+	// the mountpoint of a disabled client isn't used
+	if c.Services.Enabled(ServiceClient) {
+		if c.Services.Client.HostMountpoint == "" {
+			v.addf(ValidationCategoryDirectories, "services.client.hostMountpoint",
+				"services.client.hostMountpoint is required")
+		} else if !filepath.IsAbs(c.Services.Client.HostMountpoint) {
+			v.addf(ValidationCategoryDirectories, "services.client.hostMountpoint",
+				"services.client.hostMountpoint must be an absolute path: %s", c.Services.Client.HostMountpoint)
+		}
 	}
 
-	if err := c.validImages(); err != nil {
-		return errors.Trace(err)
+	c.validClient(v)
+	c.validPlacement(v)
+	c.validCapacity(v)
+	c.validReadiness(v)
+	c.validShutdown(v)
+	c.validCommandWrappers(v)
+	c.validPassthrough(v)
+	c.validDeployment(v)
+	c.validResources(v)
+	c.validTuning(v)
+	c.validExtraConfig(v)
+	c.validClickhouse(v, servicesValid)
+	c.validMonitorAlerts(v)
+	c.validPlugins(v)
+	c.validDeploymentWindows(v)
+	c.validStateEncryption(v)
+	c.validUI(v)
+
+	if c.RunHistory < 0 {
+		v.addf(ValidationCategoryGeneral, "runHistory", "runHistory must not be negative: %d", c.RunHistory)
+	}
+	validDuration(v, ValidationCategoryGeneral, "connectTimeout", c.ConnectTimeout, true)
+	validDuration(v, ValidationCategoryGeneral, "commandTimeout", c.CommandTimeout, true)
+	validDuration(v, ValidationCategoryGeneral, "quarantine.timeout", c.Quarantine.Timeout, true)
+	if c.Quarantine.Enabled() {
+		validDuration(v, ValidationCategoryGeneral, "quarantine.retryInterval", c.Quarantine.RetryInterval, false)
+	}
+	validDuration(v, ValidationCategoryGeneral, "watchdogInterval", c.WatchdogInterval, true)
+	if c.CmdMaxExitTimeout != nil {
+		validDuration(v, ValidationCategoryGeneral, "cmdmaxexittimeout", *c.CmdMaxExitTimeout, true)
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		v.addf(ValidationCategoryGeneral, "tls", "tls.certFile and tls.keyFile must be set together")
 	}
 
-	return nil
+	c.validImages(v)
+
+	return v.err()
+}
+
+// validPorts validates ports of services are in range.
+func (c *Config) validPorts(v *validator) {
+	ports := []struct {
+		key  string
+		port int
+	}{
+		{"services.fdb.port", c.Services.Fdb.Port},
+		{"services.clickhouse.tcpPort", c.Services.Clickhouse.TCPPort},
+		{"services.monitor.port", c.Services.Monitor.Port},
+		{"services.mgmtd.rdmaListenPort", c.Services.Mgmtd.RDMAListenPort},
+		{"services.mgmtd.tcpListenPort", c.Services.Mgmtd.TCPListenPort},
+		{"services.meta.rdmaListenPort", c.Services.Meta.RDMAListenPort},
+		{"services.meta.tcpListenPort", c.Services.Meta.TCPListenPort},
+		{"services.storage.rdmaListenPort", c.Services.Storage.RDMAListenPort},
+		{"services.storage.tcpListenPort", c.Services.Storage.TCPListenPort},
+	}
+	if c.Services.Clickhouse.Replicated() {
+		ports = append(ports, []struct {
+			key  string
+			port int
+		}{
+			{"services.clickhouse.keeperPort", c.Services.Clickhouse.KeeperPort},
+			{"services.clickhouse.keeperRaftPort", c.Services.Clickhouse.KeeperRaftPort},
+			{"services.clickhouse.interserverPort", c.Services.Clickhouse.InterserverPort},
+		}...)
+	}
+	for _, p := range ports {
+		v.validPort(p.key, p.port)
+	}
+}
+
+// validEnv validates names of environment variables, and merges the global env into
+// env of each node. Env of a node takes precedence over the global env.
+func (c *Config) validEnv(v *validator) {
+	validEnvNames(v, "env", c.Env)
+	for i := range c.Nodes {
+		node := &c.Nodes[i]
+		validEnvNames(v, fmt.Sprintf("nodes[%s].env", node.Name), node.Env)
+		if len(c.Env) == 0 {
+			continue
+		}
+		env := maps.Clone(c.Env)
+		maps.Copy(env, node.Env)
+		node.Env = env
+	}
 }
 
-func (c *Config) validServiceNodes(
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validEnvNames(v *validator, field string, env map[string]string) {
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		if !envNameRegex.MatchString(name) {
+			v.addf(ValidationCategoryGeneral, field, "%s: invalid environment variable name %q", field, name)
+		}
+	}
+}
+
+// secretEnvNameParts are parts of environment variable names whose values are secrets.
+var secretEnvNameParts = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "CREDENTIAL", "KEY"}
+
+// IsSecretEnv returns whether the value of the environment variable is a secret.
+func IsSecretEnv(name string) bool {
+	upperName := strings.ToUpper(name)
+	for _, part := range secretEnvNameParts {
+		if strings.Contains(upperName, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactEnvValue returns the value of the environment variable which is safe to be logged.
+// Values of secret variables are masked, and passwords in URLs like HTTP_PROXY are masked.
+func RedactEnvValue(name, value string) string {
+	if IsSecretEnv(name) {
+		return "xxxxx"
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	return value
+}
+
+// Secrets returns secrets in the config, including passwords of nodes and services,
+// values of secret environment variables, passwords in URLs of them and secrets
+// resolved from references.
+func (c *Config) Secrets() []string {
+	var secrets []string
+	addEnv := func(env map[string]string) {
+		for name, value := range env {
+			if IsSecretEnv(name) {
+				secrets = append(secrets, value)
+			} else if u, err := url.Parse(value); err == nil && u.User != nil {
+				if password, ok := u.User.Password(); ok {
+					secrets = append(secrets, password)
+				}
+			}
+		}
+	}
+	for _, node := range c.Nodes {
+		if node.Password != nil {
+			secrets = append(secrets, *node.Password)
+		}
+		addEnv(node.Env)
+	}
+	for _, nodeGroup := range c.NodeGroups {
+		if nodeGroup.Password != nil {
+			secrets = append(secrets, *nodeGroup.Password)
+		}
+		addEnv(nodeGroup.Env)
+	}
+	addEnv(c.Env)
+	secrets = append(secrets, c.Services.Clickhouse.Password, c.StateEncryption.Key)
+	secrets = append(secrets, c.resolvedSecrets...)
+	return slices.DeleteFunc(secrets, func(secret string) bool { return secret == "" })
+}
+
+func (c *Config) validReadiness(v *validator) {
+	if c.Services.Fdb.WaitClusterTimeout > 0 {
+		c.Services.Fdb.ReadinessTimeout = c.Services.Fdb.WaitClusterTimeout
+	}
+	for _, service := range AllServiceTypes {
+		readiness := c.Services.Readiness(service)
+		validDuration(v, ValidationCategoryServices, fmt.Sprintf("services.%s.readinessTimeout", service),
+			readiness.ReadinessTimeout, false)
+		validDuration(v, ValidationCategoryServices, fmt.Sprintf("services.%s.readinessInterval", service),
+			readiness.ReadinessInterval, false)
+		switch readiness.FailureMode() {
+		case ReadinessFailureModeFatal, ReadinessFailureModeWarn, ReadinessFailureModeRetryLater:
+		default:
+			key := fmt.Sprintf("services.%s.readinessFailureMode", service)
+			v.addf(ValidationCategoryServices, key, "%s must be one of %s, %s and %s", key,
+				ReadinessFailureModeFatal, ReadinessFailureModeWarn, ReadinessFailureModeRetryLater)
+		}
+	}
+}
+
+// validServiceNodes validates nodes and node groups of the service, it returns
+// whether they're valid.
+func (c *Config) validServiceNodes(v *validator,
 	service string, nodes []string, nodeGroups []string, nodeSet *utils.Set[string],
-	nodeGroupMap map[string]*NodeGroup, required bool) error {
+	nodeGroupMap map[string]*NodeGroup, required bool) bool {
 
+	errCount := len(v.errs)
 	if required && len(nodes) == 0 && len(nodeGroups) == 0 {
-		return errors.Errorf("nodes or nodeGroups of %s service is required", service)
+		v.addf(ValidationCategoryServices, fmt.Sprintf("services.%s.nodes", service),
+			"nodes or nodeGroups of %s service is required", service)
 	}
 	serviceNodeSet := utils.NewSet[string]()
 	for _, node := range nodes {
 		if !nodeSet.Contains(node) {
-			return errors.Errorf("node %s of  %s service not exists in node list", node, service)
+			v.addf(ValidationCategoryServices, fmt.Sprintf("services.%s.nodes", service),
+				"node %s of  %s service not exists in node list", node, service)
 		}
 		if !serviceNodeSet.AddIfNotExists(node) {
-			return errors.Errorf("duplicate node %s in %s service", node, service)
+			v.addf(ValidationCategoryServices, fmt.Sprintf("services.%s.nodes", service),
+				"duplicate node %s in %s service", node, service)
 		}
 	}
 
 	serviceNodeGroupSet := utils.NewSet[string]()
 	for _, nodeGroup := range nodeGroups {
 		if _, ok := nodeGroupMap[nodeGroup]; !ok {
-			return errors.Errorf("node group %s of %s service not exists in node group list",
-				nodeGroup, service)
+			v.addf(ValidationCategoryServices, fmt.Sprintf("services.%s.nodeGroups", service),
+				"node group %s of %s service not exists in node group list", nodeGroup, service)
 		}
 		if !serviceNodeGroupSet.AddIfNotExists(nodeGroup) {
-			return errors.Errorf("duplicate node group %s in %s service", nodeGroup, service)
-		}
-		for _, node := range nodeGroupMap[nodeGroup].Nodes {
-			nodes = append(nodes, node.Name)
+			v.addf(ValidationCategoryServices, fmt.Sprintf("services.%s.nodeGroups", service),
+				"duplicate node group %s in %s service", nodeGroup, service)
 		}
 	}
 
-	return nil
+	return len(v.errs) == errCount
 }
 
-func (c *Config) validImages() error {
+func (c *Config) validImages(v *validator) {
 	imgs := []struct {
 		imgName string
 		image   Image
@@ -455,27 +1119,38 @@ func (c *Config) validImages() error {
 	}
 	for _, img := range imgs {
 		if img.image.Tag == "" {
-			return errors.Errorf("images.%s.tag is required", img.imgName)
+			key := fmt.Sprintf("images.%s.tag", img.imgName)
+			v.addf(ValidationCategoryImages, key, "%s is required", key)
 		}
 		if img.image.Repo == "" {
-			return errors.Errorf("images.%s.repo is required", img.imgName)
+			key := fmt.Sprintf("images.%s.repo", img.imgName)
+			v.addf(ValidationCategoryImages, key, "%s is required", key)
 		}
 	}
-
-	return nil
 }
 
 // NewConfigWithDefaults creates a new config with default values
 func NewConfigWithDefaults() *Config {
 	return &Config{
-		Name:        "3fs",
-		NetworkType: NetworkTypeRDMA,
-		LogLevel:    "INFO",
+		Name:                    "3fs",
+		NetworkType:             NetworkTypeRDMA,
+		LogLevel:                "INFO",
+		HostKeyPolicy:           HostKeyPolicyAcceptNew,
+		ConnectTimeout:          Duration(30 * time.Second),
+		WatchdogInterval:        Duration(10 * time.Minute),
+		Quarantine:              Quarantine{RetryInterval: Duration(10 * time.Second)},
+		ArtifactCache:           ArtifactCache{Port: DefaultArtifactCachePort},
+		ArtifactChecksumRetries: DefaultArtifactChecksumRetries,
+		Deployment:              Deployment{RetryPolicy: DefaultRetryPolicy},
 		Services: Services{
 			Fdb: Fdb{
-				ContainerName:      "3fs-fdb",
-				Port:               4500,
-				WaitClusterTimeout: 120 * time.Second,
+				ContainerName: "3fs-fdb",
+				Port:          4500,
+				Readiness: Readiness{
+					ReadinessTimeout:  Duration(120 * time.Second),
+					ReadinessInterval: Duration(time.Second),
+				},
+				Shutdown: Shutdown{ShutdownTimeout: Duration(time.Minute)},
 			},
 			Clickhouse: Clickhouse{
 				ContainerName: "3fs-clickhouse",
@@ -483,10 +1158,31 @@ func NewConfigWithDefaults() *Config {
 				User:          "default",
 				Password:      "password",
 				TCPPort:       8999,
+				Readiness: Readiness{
+					ReadinessTimeout:  Duration(60 * time.Second),
+					ReadinessInterval: Duration(time.Second),
+				},
+				Shutdown:        Shutdown{ShutdownTimeout: Duration(time.Minute)},
+				Shards:          1,
+				Replicas:        1,
+				KeeperPort:      9181,
+				KeeperRaftPort:  9234,
+				InterserverPort: 9009,
 			},
 			Monitor: Monitor{
 				ContainerName: "3fs-monitor",
 				Port:          10000,
+				Readiness: Readiness{
+					ReadinessTimeout:  Duration(30 * time.Second),
+					ReadinessInterval: Duration(time.Second),
+				},
+				Shutdown: Shutdown{ShutdownTimeout: Duration(30 * time.Second)},
+				Alerts: MonitorAlerts{
+					For:              Duration(5 * time.Minute),
+					UnreachableNodes: 1,
+					DriftFindings:    1,
+					StaleCheck:       Duration(30 * time.Minute),
+				},
 			},
 			Mgmtd: Mgmtd{
 				ContainerName:  "3fs-mgmtd",
@@ -494,11 +1190,21 @@ func NewConfigWithDefaults() *Config {
 				StripeSize:     16,
 				RDMAListenPort: 8000,
 				TCPListenPort:  9000,
+				Readiness: Readiness{
+					ReadinessTimeout:  Duration(60 * time.Second),
+					ReadinessInterval: Duration(2 * time.Second),
+				},
+				Shutdown: Shutdown{ShutdownTimeout: Duration(30 * time.Second)},
 			},
 			Meta: Meta{
 				ContainerName:  "3fs-meta",
 				RDMAListenPort: 8001,
 				TCPListenPort:  9001,
+				Readiness: Readiness{
+					ReadinessTimeout:  Duration(60 * time.Second),
+					ReadinessInterval: Duration(2 * time.Second),
+				},
+				Shutdown: Shutdown{ShutdownTimeout: Duration(time.Minute)},
 			},
 			Storage: Storage{
 				ContainerName:     "3fs-storage",
@@ -511,10 +1217,22 @@ func NewConfigWithDefaults() *Config {
 				TargetNumPerDisk:  32,
 				TargetIDPrefix:    1,
 				ChainIDPrefix:     9,
+				// formatting disks takes a long time when storage starts
+				Readiness: Readiness{
+					ReadinessTimeout:  Duration(10 * time.Minute),
+					ReadinessInterval: Duration(5 * time.Second),
+				},
+				// storage flushes data before it exits
+				Shutdown: Shutdown{ShutdownTimeout: Duration(5 * time.Minute)},
 			},
 			Client: Client{
 				ContainerName:  "3fs-client",
 				HostMountpoint: "/mnt/3fs",
+				Readiness: Readiness{
+					ReadinessTimeout:  Duration(60 * time.Second),
+					ReadinessInterval: Duration(2 * time.Second),
+				},
+				Shutdown: Shutdown{ShutdownTimeout: Duration(30 * time.Second)},
 			},
 		},
 		Images: Images{
@@ -0,0 +1,255 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// DeploymentStrategy is the strategy of deploying a service on its nodes.
+type DeploymentStrategy string
+
+// defines deployment strategies
+const (
+	// DeploymentStrategyAllAtOnce deploys a service on all nodes in parallel, it's the fastest.
+	DeploymentStrategyAllAtOnce DeploymentStrategy = "all-at-once"
+	// DeploymentStrategyRolling deploys a service in batches of nodes, each batch must
+	// be healthy before the next one.
+	DeploymentStrategyRolling DeploymentStrategy = "rolling"
+	// DeploymentStrategyCanaryThenRolling deploys a service on a single node first, then
+	// rolls out to the rest of nodes like rolling.
+	DeploymentStrategyCanaryThenRolling DeploymentStrategy = "canary-then-rolling"
+)
+
+// DeploymentStrategies are all supported deployment strategies.
+var DeploymentStrategies = []DeploymentStrategy{
+	DeploymentStrategyAllAtOnce, DeploymentStrategyRolling, DeploymentStrategyCanaryThenRolling,
+}
+
+// RolloutServices are services deployed by the deployment strategy, which are
+// 3fs services running on each of their nodes.
+var RolloutServices = []ServiceType{
+	ServiceMgmtd,
+	ServiceMeta,
+	ServiceStorage,
+	ServiceClient,
+}
+
+// Deployment is the config of deploying services on their nodes by cluster create and
+// cluster upgrade. If the strategy isn't set, services are created on all nodes at once
+// and upgraded node by node.
+type Deployment struct {
+	Strategy DeploymentStrategy `yaml:"strategy,omitempty"`
+	// BatchSize is the number of nodes of a batch of rolling strategies, default is 1.
+	BatchSize int `yaml:"batchSize,omitempty"`
+	// RetryPolicy limits retries of failed steps across the run.
+	RetryPolicy RetryPolicy `yaml:"retryPolicy,omitempty"`
+	// MaxParallel is the default max number of nodes running a step in parallel, parallel
+	// steps run on all of their nodes at once if it isn't set.
+	MaxParallel *int `yaml:"maxParallel,omitempty"`
+	// AutoParallel derives the max number of nodes running a step in parallel from the
+	// deploy host for phases without maxParallel, it conflicts with maxParallel.
+	AutoParallel AutoParallel `yaml:"autoParallel,omitempty"`
+	// Phases are settings of phases of tasks keyed by names of phases, which are prepare,
+	// deploy and verify.
+	Phases map[string]PhaseDeployment `yaml:"phases,omitempty"`
+}
+
+// PhaseDeployment is the config of running tasks of a phase.
+type PhaseDeployment struct {
+	// MaxParallel is the max number of nodes running a step of the phase in parallel,
+	// it overrides maxParallel of the deployment.
+	MaxParallel *int `yaml:"maxParallel,omitempty"`
+}
+
+// PhaseMaxParallel returns the max number of nodes running a step of the phase in
+// parallel, 0 means no limit.
+func (d Deployment) PhaseMaxParallel(phase string) int {
+	if limit := d.Phases[phase].MaxParallel; limit != nil {
+		return *limit
+	}
+	if d.MaxParallel != nil {
+		return *d.MaxParallel
+	}
+	return 0
+}
+
+// AutoParallel is the config of deriving the max number of nodes running a step in parallel
+// from the CPUs and the load average of the deploy host, which runs SSH sessions of all nodes.
+type AutoParallel struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Min and Max bound the derived number of nodes, defaults are 1 and 64.
+	Min int `yaml:"min,omitempty"`
+	Max int `yaml:"max,omitempty"`
+	// Backoff lowers the number of nodes of running steps while the deploy host is
+	// overloaded, and raises it again once the load drops.
+	Backoff bool `yaml:"backoff,omitempty"`
+	// BackoffLoad is the load average per CPU of the deploy host above which it's
+	// overloaded, default is 1.5.
+	BackoffLoad float64 `yaml:"backoffLoad,omitempty"`
+}
+
+// defines defaults of auto parallelism
+const (
+	DefaultAutoParallelMin         = 1
+	DefaultAutoParallelMax         = 64
+	DefaultAutoParallelBackoffLoad = 1.5
+)
+
+// Bounds returns the min and max number of nodes of auto parallelism.
+func (a AutoParallel) Bounds() (int, int) {
+	lower, upper := a.Min, a.Max
+	if lower == 0 {
+		lower = DefaultAutoParallelMin
+	}
+	if upper == 0 {
+		upper = max(DefaultAutoParallelMax, lower)
+	}
+	return lower, upper
+}
+
+// OverloadedLoad returns the load average per CPU above which the deploy host is overloaded.
+func (a AutoParallel) OverloadedLoad() float64 {
+	if a.BackoffLoad == 0 {
+		return DefaultAutoParallelBackoffLoad
+	}
+	return a.BackoffLoad
+}
+
+// RetryPolicy is the budget of retries of failed steps on nodes, so that a systemic failure
+// doesn't retry steps on all nodes of a large cluster and hammer the infrastructure. Once
+// the budget is exhausted, failed steps aren't retried until it's replenished.
+type RetryPolicy struct {
+	// Budget is the max ratio of retries to operations, which are runs of steps on nodes
+	// including retries, in the window. The budget is unlimited if it's zero.
+	Budget float64 `yaml:"budget,omitempty"`
+	// Window is the sliding window of operations counted by the budget.
+	Window Duration `yaml:"window,omitempty"`
+	// MinRetries is the number of retries allowed in the window regardless of the ratio,
+	// so that small clusters can retry.
+	MinRetries int `yaml:"minRetries,omitempty"`
+}
+
+// DefaultRetryPolicy allows 10% of operations in 10 minutes to be retries.
+var DefaultRetryPolicy = RetryPolicy{Budget: 0.1, Window: Duration(10 * time.Minute), MinRetries: 10}
+
+// Limited returns whether retries are limited by the budget.
+func (p RetryPolicy) Limited() bool {
+	return p.Budget > 0
+}
+
+// Gated returns whether each batch of the strategy must be healthy before the next one.
+func (d Deployment) Gated() bool {
+	return d.Strategy == DeploymentStrategyRolling || d.Strategy == DeploymentStrategyCanaryThenRolling
+}
+
+// BatchSizes returns sizes of batches deploying a service on n nodes by the strategy.
+func (d Deployment) BatchSizes(n int) []int {
+	if n <= 0 {
+		return nil
+	}
+	if !d.Gated() {
+		return []int{n}
+	}
+	var sizes []int
+	if d.Strategy == DeploymentStrategyCanaryThenRolling {
+		sizes = append(sizes, 1)
+		n--
+	}
+	batchSize := max(d.BatchSize, 1)
+	for ; n > 0; n -= batchSize {
+		sizes = append(sizes, min(batchSize, n))
+	}
+	return sizes
+}
+
+// validDeployment validates the deployment strategy. Rolling strategies gate batches on
+// readiness checks of services, so not ready services must fail the deployment.
+func (c *Config) validDeployment(v *validator) {
+	d := c.Deployment
+	if d.MaxParallel != nil && *d.MaxParallel < 1 {
+		v.addf(ValidationCategoryGeneral, "deployment.maxParallel",
+			"deployment.maxParallel must be at least 1: %d", *d.MaxParallel)
+	}
+	c.validAutoParallel(v)
+	for _, phase := range slices.Sorted(maps.Keys(d.Phases)) {
+		key := fmt.Sprintf("deployment.phases.%s", phase)
+		if !slices.Contains(phaseNames, phase) {
+			v.addf(ValidationCategoryGeneral, key, "invalid phase %s of deployment.phases, must be one of %v",
+				phase, phaseNames)
+		}
+		if limit := d.Phases[phase].MaxParallel; limit != nil && *limit < 1 {
+			v.addf(ValidationCategoryGeneral, key+".maxParallel", "%s.maxParallel must be at least 1: %d",
+				key, *limit)
+		}
+	}
+	if d.Strategy != "" && !slices.Contains(DeploymentStrategies, d.Strategy) {
+		v.addf(ValidationCategoryGeneral, "deployment.strategy", "invalid deployment strategy: %s", d.Strategy)
+		return
+	}
+	if d.BatchSize < 0 {
+		v.addf(ValidationCategoryGeneral, "deployment.batchSize",
+			"deployment.batchSize must not be negative: %d", d.BatchSize)
+	}
+	if p := d.RetryPolicy; p.Budget < 0 || p.Budget > 1 {
+		v.addf(ValidationCategoryGeneral, "deployment.retryPolicy.budget",
+			"deployment.retryPolicy.budget must be between 0 and 1: %v", p.Budget)
+	}
+	if d.RetryPolicy.MinRetries < 0 {
+		v.addf(ValidationCategoryGeneral, "deployment.retryPolicy.minRetries",
+			"deployment.retryPolicy.minRetries must not be negative: %d", d.RetryPolicy.MinRetries)
+	}
+	validDuration(v, ValidationCategoryGeneral, "deployment.retryPolicy.window", d.RetryPolicy.Window,
+		!d.RetryPolicy.Limited())
+	if !d.Gated() {
+		return
+	}
+	for _, service := range RolloutServices {
+		if mode := c.Services.Readiness(service).FailureMode(); mode != ReadinessFailureModeFatal {
+			key := fmt.Sprintf("services.%s.readinessFailureMode", service)
+			v.addf(ValidationCategoryServices, key,
+				"deployment strategy %s requires the health check of %s, but %s is %s instead of %s",
+				d.Strategy, service, key, mode, ReadinessFailureModeFatal)
+		}
+	}
+}
+
+// validAutoParallel validates bounds of auto parallelism.
+func (c *Config) validAutoParallel(v *validator) {
+	a := c.Deployment.AutoParallel
+	if a.Enabled && c.Deployment.MaxParallel != nil {
+		v.addf(ValidationCategoryGeneral, "deployment.autoParallel.enabled",
+			"deployment.autoParallel conflicts with deployment.maxParallel, set maxParallel of phases instead")
+	}
+	if a.Min < 0 {
+		v.addf(ValidationCategoryGeneral, "deployment.autoParallel.min",
+			"deployment.autoParallel.min must not be negative: %d", a.Min)
+	}
+	if a.Max < 0 {
+		v.addf(ValidationCategoryGeneral, "deployment.autoParallel.max",
+			"deployment.autoParallel.max must not be negative: %d", a.Max)
+	}
+	if a.Max > 0 && a.Max < a.Min {
+		v.addf(ValidationCategoryGeneral, "deployment.autoParallel.max",
+			"deployment.autoParallel.max %d must not be less than min %d", a.Max, a.Min)
+	}
+	if a.BackoffLoad < 0 {
+		v.addf(ValidationCategoryGeneral, "deployment.autoParallel.backoffLoad",
+			"deployment.autoParallel.backoffLoad must not be negative: %v", a.BackoffLoad)
+	}
+}
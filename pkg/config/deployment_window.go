@@ -0,0 +1,235 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// timezones of deployment windows are available on nodes without tzdata
+	_ "time/tzdata"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+	// windowDateLayout is the layout of bounds of one-off windows.
+	windowDateLayout = "2006-01-02 15:04"
+)
+
+// DeploymentWindows are windows in which mutating commands are allowed or denied, e.g.
+// no deployment from Friday evening to Monday morning. Commands are refused in denied
+// windows, and outside allowed windows if there are any.
+type DeploymentWindows struct {
+	// Timezone is the IANA timezone of bounds of windows, default is the local timezone.
+	Timezone string       `yaml:"timezone,omitempty"`
+	Allowed  []TimeWindow `yaml:"allowed,omitempty"`
+	Denied   []TimeWindow `yaml:"denied,omitempty"`
+}
+
+// TimeWindow is a time range. Bounds are written as "15:04" for daily windows, "Fri 15:04"
+// for weekly windows or "2006-01-02 15:04" for one-off windows, both bounds of a window
+// are of the same form. The start is included and the end is excluded, a recurring window
+// whose end is before its start wraps around, e.g. from Fri 17:00 to Mon 06:00.
+type TimeWindow struct {
+	Name  string `yaml:"name,omitempty"`
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+}
+
+func (w TimeWindow) String() string {
+	if w.Name != "" {
+		return fmt.Sprintf("%s (%s - %s)", w.Name, w.Start, w.End)
+	}
+	return fmt.Sprintf("%s - %s", w.Start, w.End)
+}
+
+type windowKind int
+
+const (
+	windowDaily windowKind = iota
+	windowWeekly
+	windowOneOff
+)
+
+// windowBound is a parsed bound of a window, minute is the minute of the day or week of
+// recurring windows.
+type windowBound struct {
+	kind   windowKind
+	minute int
+	time   time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+func parseClock(s string) (int, error) {
+	hour, minute, ok := strings.Cut(s, ":")
+	if ok {
+		h, err1 := strconv.Atoi(hour)
+		m, err2 := strconv.Atoi(minute)
+		if err1 == nil && err2 == nil && h >= 0 && h < 24 && m >= 0 && m < 60 && len(minute) == 2 {
+			return h*60 + m, nil
+		}
+	}
+	return 0, errors.Errorf("invalid time %q, must be like 15:04", s)
+}
+
+func parseWindowBound(s string, loc *time.Location) (windowBound, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation(windowDateLayout, s, loc); err == nil {
+		return windowBound{kind: windowOneOff, time: t}, nil
+	}
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		minute, err := parseClock(fields[0])
+		return windowBound{kind: windowDaily, minute: minute}, errors.Trace(err)
+	case 2:
+		weekday, ok := weekdays[strings.ToLower(fields[0])]
+		if !ok {
+			return windowBound{}, errors.Errorf("invalid weekday %q", fields[0])
+		}
+		minute, err := parseClock(fields[1])
+		return windowBound{kind: windowWeekly, minute: int(weekday)*minutesPerDay + minute}, errors.Trace(err)
+	default:
+		return windowBound{}, errors.Errorf("invalid bound %q, must be like 15:04, Fri 15:04 or %s",
+			s, windowDateLayout)
+	}
+}
+
+// parse returns bounds of the window.
+func (w TimeWindow) parse(loc *time.Location) (start, end windowBound, err error) {
+	if start, err = parseWindowBound(w.Start, loc); err != nil {
+		return start, end, errors.Annotatef(err, "start of window %s", w)
+	}
+	if end, err = parseWindowBound(w.End, loc); err != nil {
+		return start, end, errors.Annotatef(err, "end of window %s", w)
+	}
+	if start.kind != end.kind {
+		return start, end, errors.Errorf("bounds of window %s must be of the same form", w)
+	}
+	if start.kind == windowOneOff && !start.time.Before(end.time) {
+		return start, end, errors.Errorf("start of window %s must be before its end", w)
+	}
+	if start.kind != windowOneOff && start.minute == end.minute {
+		return start, end, errors.Errorf("start and end of window %s must differ", w)
+	}
+	return start, end, nil
+}
+
+// contains returns whether the time, which is in the timezone of the window, is in the window.
+func (w TimeWindow) contains(t time.Time) (bool, error) {
+	start, end, err := w.parse(t.Location())
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	minute := t.Hour()*60 + t.Minute()
+	switch start.kind {
+	case windowOneOff:
+		return !t.Before(start.time) && t.Before(end.time), nil
+	case windowWeekly:
+		minute += int(t.Weekday()) * minutesPerDay
+	}
+	if start.minute < end.minute {
+		return minute >= start.minute && minute < end.minute, nil
+	}
+	return minute >= start.minute || minute < end.minute, nil
+}
+
+// IsEmpty returns whether no window is set.
+func (d DeploymentWindows) IsEmpty() bool {
+	return len(d.Allowed) == 0 && len(d.Denied) == 0
+}
+
+// Location returns the timezone of bounds of windows.
+func (d DeploymentWindows) Location() (*time.Location, error) {
+	if d.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return nil, errors.Annotatef(err, "load timezone %s", d.Timezone)
+	}
+	return loc, nil
+}
+
+// Check returns an error describing the freeze if mutating commands aren't allowed at the time.
+func (d DeploymentWindows) Check(now time.Time) error {
+	if d.IsEmpty() {
+		return nil
+	}
+	loc, err := d.Location()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	now = now.In(loc)
+	for _, window := range d.Denied {
+		in, err := window.contains(now)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if in {
+			return errors.Errorf("deployment is frozen by denied window %s at %s",
+				window, now.Format("Mon 2006-01-02 15:04 MST"))
+		}
+	}
+	if len(d.Allowed) == 0 {
+		return nil
+	}
+	allowed := make([]string, len(d.Allowed))
+	for i, window := range d.Allowed {
+		in, err := window.contains(now)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if in {
+			return nil
+		}
+		allowed[i] = window.String()
+	}
+	return errors.Errorf("deployment is frozen outside allowed windows %s at %s",
+		strings.Join(allowed, ", "), now.Format("Mon 2006-01-02 15:04 MST"))
+}
+
+func (c *Config) validDeploymentWindows(v *validator) {
+	windows := c.DeploymentWindows
+	loc, err := windows.Location()
+	if err != nil {
+		v.addf(ValidationCategoryGeneral, "deploymentWindows.timezone", "deploymentWindows.timezone: %v", err)
+		return
+	}
+	for _, kind := range []struct {
+		key     string
+		windows []TimeWindow
+	}{{"allowed", windows.Allowed}, {"denied", windows.Denied}} {
+		for i, window := range kind.windows {
+			if _, _, err := window.parse(loc); err != nil {
+				key := fmt.Sprintf("deploymentWindows.%s[%d]", kind.key, i)
+				v.addf(ValidationCategoryGeneral, key, "%s: %v", key, err)
+			}
+		}
+	}
+}
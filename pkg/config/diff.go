@@ -0,0 +1,266 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// ChangeKind is the kind of a config change.
+type ChangeKind string
+
+// defines change kinds
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is a semantic difference between two configs. Path is the yaml path of the
+// setting, elements of lists of nodes and node groups are addressed by names like
+// nodes[node1].host.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	Path string     `json:"path"`
+	Old  any        `json:"old,omitempty"`
+	New  any        `json:"new,omitempty"`
+}
+
+// String returns the change like "~ services.storage.diskNumPerNode: 4 -> 8".
+func (c *Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, formatDiffValue(c.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, formatDiffValue(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, formatDiffValue(c.Old), formatDiffValue(c.New))
+	}
+}
+
+func formatDiffValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]any, []any:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return "{" + strings.Join(strings.Split(strings.TrimSpace(string(data)), "\n"), ", ") + "}"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// keyedLists are lists whose elements are identified by names, reordering them
+// isn't a change.
+var keyedLists = map[string]bool{
+	"nodes":      true,
+	"nodeGroups": true,
+}
+
+// secretKeys are keys whose values are secrets, changes of them are reported
+// without values.
+var secretKeys = map[string]bool{
+	"password": true,
+}
+
+// redactDiffValue redacts secrets in the value of the key, whose parent key is
+// parentKey. Values of secret environment variables are redacted too.
+func redactDiffValue(parentKey, key string, value any) any {
+	if value == nil {
+		return nil
+	}
+	if secretKeys[key] {
+		return "xxxxx"
+	}
+	if str, ok := value.(string); ok && parentKey == "env" {
+		return RedactEnvValue(key, str)
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, elem := range v {
+			redacted[k] = redactDiffValue(key, k, elem)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, elem := range v {
+			redacted[i] = redactDiffValue(parentKey, key, elem)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// Diff returns semantic differences from the old config to the new config. Configs
+// should be validated, so that both are normalized the same way. Reordering of lists
+// isn't a change, and values of secrets are redacted.
+func Diff(oldCfg, newCfg *Config) ([]*Change, error) {
+	oldTree, err := configTree(oldCfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	newTree, err := configTree(newCfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var changes []*Change
+	diffValue("", "", "", oldTree, newTree, &changes)
+	return changes, nil
+}
+
+func configTree(cfg *Config) (any, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, errors.Annotate(err, "encode config")
+	}
+	var tree any
+	if err = yaml.Unmarshal(data, &tree); err != nil {
+		return nil, errors.Annotate(err, "decode config")
+	}
+	return tree, nil
+}
+
+func joinDiffPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func diffValue(path, parentKey, key string, oldValue, newValue any, changes *[]*Change) {
+	oldMap, oldIsMap := oldValue.(map[string]any)
+	newMap, newIsMap := newValue.(map[string]any)
+	if oldIsMap && newIsMap {
+		for _, k := range slices.Sorted(maps.Keys(oldMap)) {
+			if _, ok := newMap[k]; !ok {
+				diffValue(joinDiffPath(path, k), key, k, oldMap[k], nil, changes)
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(newMap)) {
+			diffValue(joinDiffPath(path, k), key, k, oldMap[k], newMap[k], changes)
+		}
+		return
+	}
+
+	oldList, oldIsList := oldValue.([]any)
+	newList, newIsList := newValue.([]any)
+	if (oldIsList || oldValue == nil) && (newIsList || newValue == nil) && (oldIsList || newIsList) {
+		if keyedLists[key] && isNamedList(oldList) && isNamedList(newList) {
+			diffNamedList(path, oldList, newList, changes)
+			return
+		}
+		if isScalarList(oldList) && isScalarList(newList) {
+			diffScalarList(path, oldList, newList, changes)
+			return
+		}
+	}
+
+	switch {
+	case reflect.DeepEqual(oldValue, newValue):
+	case oldValue == nil:
+		*changes = append(*changes, &Change{Kind: ChangeAdded, Path: path, New: redactDiffValue(parentKey, key, newValue)})
+	case newValue == nil:
+		*changes = append(*changes, &Change{Kind: ChangeRemoved, Path: path, Old: redactDiffValue(parentKey, key, oldValue)})
+	default:
+		*changes = append(*changes, &Change{
+			Kind: ChangeChanged,
+			Path: path,
+			Old:  redactDiffValue(parentKey, key, oldValue),
+			New:  redactDiffValue(parentKey, key, newValue),
+		})
+	}
+}
+
+func isNamedList(list []any) bool {
+	for _, elem := range list {
+		m, ok := elem.(map[string]any)
+		if !ok {
+			return false
+		}
+		if _, ok = m["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isScalarList(list []any) bool {
+	for _, elem := range list {
+		switch elem.(type) {
+		case map[string]any, []any:
+			return false
+		}
+	}
+	return true
+}
+
+func diffNamedList(path string, oldList, newList []any, changes *[]*Change) {
+	oldElems := make(map[string]any, len(oldList))
+	for _, elem := range oldList {
+		oldElems[elem.(map[string]any)["name"].(string)] = elem
+	}
+	newNames := make(map[string]bool, len(newList))
+	for _, elem := range newList {
+		newNames[elem.(map[string]any)["name"].(string)] = true
+	}
+	for _, elem := range oldList {
+		name := elem.(map[string]any)["name"].(string)
+		if !newNames[name] {
+			diffValue(fmt.Sprintf("%s[%s]", path, name), "", "", elem, nil, changes)
+		}
+	}
+	for _, elem := range newList {
+		name := elem.(map[string]any)["name"].(string)
+		diffValue(fmt.Sprintf("%s[%s]", path, name), "", "", oldElems[name], elem, changes)
+	}
+}
+
+func diffScalarList(path string, oldList, newList []any, changes *[]*Change) {
+	count := func(list []any) map[string]int {
+		counts := make(map[string]int, len(list))
+		for _, elem := range list {
+			counts[fmt.Sprint(elem)]++
+		}
+		return counts
+	}
+	oldCounts, newCounts := count(oldList), count(newList)
+	for _, elem := range oldList {
+		s := fmt.Sprint(elem)
+		if newCounts[s] < oldCounts[s] {
+			*changes = append(*changes, &Change{Kind: ChangeRemoved, Path: path, Old: elem})
+			oldCounts[s]--
+		}
+	}
+	for _, elem := range newList {
+		s := fmt.Sprint(elem)
+		if oldCounts[s] < newCounts[s] {
+			*changes = append(*changes, &Change{Kind: ChangeAdded, Path: path, New: elem})
+			newCounts[s]--
+		}
+	}
+}
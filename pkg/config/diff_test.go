@@ -0,0 +1,106 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/tests/base"
+)
+
+func TestDiffSuite(t *testing.T) {
+	suite.Run(t, new(diffSuite))
+}
+
+type diffSuite struct {
+	base.Suite
+}
+
+func (s *diffSuite) newConfig() *Config {
+	cfg := NewConfigWithDefaults()
+	cfg.Name = "test"
+	cfg.WorkDir = "/opt/3fs"
+	cfg.Nodes = []Node{
+		{Name: "node1", Host: "10.0.0.1", Username: "root", Password: common.Pointer("pass1")},
+		{Name: "node2", Host: "10.0.0.2", Username: "root"},
+	}
+	for _, service := range AllServiceTypes {
+		s.NoError(cfg.Services.AddServiceNode(service, "node1"))
+	}
+	s.NoError(cfg.Services.AddServiceNode(ServiceStorage, "node2"))
+	cfg.Services.Client.HostMountpoint = "/mnt/3fs"
+	s.NoError(cfg.SetValidate("", ""))
+	return cfg
+}
+
+func (s *diffSuite) TestNoChanges() {
+	changes, err := Diff(s.newConfig(), s.newConfig())
+	s.NoError(err)
+	s.Empty(changes)
+}
+
+func (s *diffSuite) TestReorderIsNotChange() {
+	newCfg := s.newConfig()
+	newCfg.Nodes[0], newCfg.Nodes[1] = newCfg.Nodes[1], newCfg.Nodes[0]
+	newCfg.Services.Storage.Nodes = []string{"node2", "node1"}
+
+	changes, err := Diff(s.newConfig(), newCfg)
+	s.NoError(err)
+	s.Empty(changes)
+}
+
+func (s *diffSuite) TestChanges() {
+	newCfg := s.newConfig()
+	newCfg.Services.Storage.DiskNumPerNode = 8
+	newCfg.Services.Meta.Nodes = append(newCfg.Services.Meta.Nodes, "node2")
+	newCfg.Services.Client.Nodes = nil
+	newCfg.Nodes = append(newCfg.Nodes, Node{Name: "node3", Host: "10.0.0.3", Username: "root", Port: 22})
+	newCfg.Nodes[1].Host = "10.0.0.20"
+
+	changes, err := Diff(s.newConfig(), newCfg)
+	s.NoError(err)
+	lines := make([]string, len(changes))
+	for i, change := range changes {
+		lines[i] = change.String()
+	}
+	s.Equal([]string{
+		"~ nodes[node2].host: 10.0.0.2 -> 10.0.0.20",
+		"+ nodes[node3]: {host: 10.0.0.3, name: node3, port: 22, username: root}",
+		"- services.client.nodes: node1",
+		"+ services.meta.nodes: node2",
+		"~ services.storage.diskNumPerNode: 1 -> 8",
+	}, lines)
+}
+
+func (s *diffSuite) TestRedactSecrets() {
+	newCfg := s.newConfig()
+	newCfg.Nodes[0].Password = common.Pointer("pass2")
+	newCfg.Nodes[1].Env = map[string]string{"API_TOKEN": "token", "LANG": "C"}
+	newCfg.Services.Clickhouse.Password = "ck-pass"
+
+	changes, err := Diff(s.newConfig(), newCfg)
+	s.NoError(err)
+	s.Len(changes, 3)
+	for _, change := range changes {
+		s.NotContains(change.String(), "pass2")
+		s.NotContains(change.String(), "token")
+		s.NotContains(change.String(), "ck-pass")
+	}
+	s.Equal("~ nodes[node1].password: xxxxx -> xxxxx", changes[0].String())
+	s.Equal("+ nodes[node2].env: {API_TOKEN: xxxxx, LANG: C}", changes[1].String())
+}
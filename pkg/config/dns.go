@@ -0,0 +1,66 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+)
+
+// MaxNameservers is the max number of nameservers used by the resolver of glibc.
+const MaxNameservers = 3
+
+var domainPattern = regexp.MustCompile(
+	`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// DNS is the resolver config managed by m3fs on nodes, so that nodes can address each
+// other by names in environments preferring DNS to /etc/hosts. It's off if it's empty.
+type DNS struct {
+	// Nameservers are IP addresses of nameservers, queried in order.
+	Nameservers []string `yaml:"nameservers,omitempty"`
+	// SearchDomains are appended to names without dots to resolve them, in order.
+	SearchDomains []string `yaml:"searchDomains,omitempty"`
+}
+
+// IsEmpty returns whether the resolver config of nodes isn't managed.
+func (d *DNS) IsEmpty() bool {
+	return len(d.Nameservers) == 0 && len(d.SearchDomains) == 0
+}
+
+// validDNS validates nameservers are IP addresses and search domains are domain names.
+func (c *Config) validDNS(v *validator) {
+	if len(c.DNS.Nameservers) > MaxNameservers {
+		v.addf(ValidationCategoryGeneral, "dns.nameservers",
+			"at most %d nameservers are used by the resolver, got %d", MaxNameservers, len(c.DNS.Nameservers))
+	}
+	for i, ns := range c.DNS.Nameservers {
+		key := fmt.Sprintf("dns.nameservers[%d]", i)
+		if net.ParseIP(ns) == nil {
+			v.addf(ValidationCategoryGeneral, key, "nameserver %s must be an IP address", ns)
+		} else if slices.Index(c.DNS.Nameservers, ns) < i {
+			v.addf(ValidationCategoryGeneral, key, "duplicate nameserver %s", ns)
+		}
+	}
+	for i, domain := range c.DNS.SearchDomains {
+		key := fmt.Sprintf("dns.searchDomains[%d]", i)
+		if len(domain) > 253 || !domainPattern.MatchString(domain) {
+			v.addf(ValidationCategoryGeneral, key, "search domain %s isn't a valid domain name", domain)
+		} else if slices.Index(c.DNS.SearchDomains, domain) < i {
+			v.addf(ValidationCategoryGeneral, key, "duplicate search domain %s", domain)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// MaxDuration is the upper bound of durations of the config, longer ones are taken as
+// mistakes of units.
+const MaxDuration = 24 * time.Hour
+
+// Duration is a duration of the config. It's written as a Go duration like 30s, 5m or
+// 1h30m, or as plain seconds like 30 which configs written before durations took units
+// use.
+type Duration time.Duration
+
+// ParseDuration parses a Go duration or plain seconds.
+func ParseDuration(s string) (Duration, error) {
+	s = strings.TrimSpace(s)
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(seconds) || math.IsInf(seconds, 0) || math.Abs(seconds) > math.MaxInt64/float64(time.Second) {
+			return 0, errors.Errorf("invalid duration %q", s)
+		}
+		return Duration(seconds * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.Errorf("invalid duration %q, use a duration like 30s, 5m or 1h, or plain seconds", s)
+	}
+	return Duration(d), nil
+}
+
+// Duration returns the duration as time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// String returns the duration formatted like time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// DurationError is the error of decoding an invalid duration of the config.
+type DurationError struct {
+	// Key is the dotted path of the duration in the config, it's empty if unknown.
+	Key    string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *DurationError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return &DurationError{Line: node.Line, Column: node.Column,
+			Err: errors.New("invalid duration, use a duration like 30s, 5m or 1h, or plain seconds")}
+	}
+	parsed, err := ParseDuration(node.Value)
+	if err != nil {
+		return &DurationError{Line: node.Line, Column: node.Column, Err: err}
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (d Duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, it names keys of invalid durations.
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	type plain Config
+	err := node.Decode((*plain)(c))
+	var durationErr *DurationError
+	if errors.As(err, &durationErr) && durationErr.Key == "" {
+		durationErr.Key, _ = keyOfNode(node, durationErr.Line, durationErr.Column, "")
+	}
+	return err
+}
+
+// keyOfNode returns the dotted path of the value at the line and column in the document,
+// items of sequences are indexed like nodes[0].
+func keyOfNode(node *yaml.Node, line, column int, path string) (string, bool) {
+	if node.Line == line && node.Column == column && path != "" {
+		return path, true
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if key, ok := keyOfNode(child, line, column, path); ok {
+				return key, true
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := node.Content[i].Value
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			if key, ok := keyOfNode(node.Content[i+1], line, column, childPath); ok {
+				return key, true
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if key, ok := keyOfNode(child, line, column, fmt.Sprintf("%s[%d]", path, i)); ok {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// validDuration checks the duration isn't negative, or is positive if zero isn't allowed,
+// and doesn't exceed MaxDuration.
+func validDuration(v *validator, category ValidationCategory, key string, d Duration, allowZero bool) {
+	switch {
+	case d < 0 && allowZero:
+		v.addf(category, key, "%s must not be negative: %s", key, d)
+	case d <= 0 && !allowZero:
+		v.addf(category, key, "%s must be positive: %s", key, d)
+	case d.Duration() > MaxDuration:
+		v.addf(category, key, "%s must not exceed %s: %s", key, MaxDuration, d)
+	}
+}
+
+// DurationPtr returns the duration as *time.Duration, it's nil if d is nil.
+func (d *Duration) DurationPtr() *time.Duration {
+	if d == nil {
+		return nil
+	}
+	duration := d.Duration()
+	return &duration
+}
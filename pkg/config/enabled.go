@@ -0,0 +1,112 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+)
+
+// serviceDependencies are services each service depends on, a service can't be
+// enabled if any service it depends on is disabled.
+var serviceDependencies = map[ServiceType][]ServiceType{
+	ServiceMonitor: {ServiceClickhouse},
+	ServiceMgmtd:   {ServiceFdb},
+	ServiceMeta:    {ServiceFdb, ServiceMgmtd},
+	ServiceStorage: {ServiceMgmtd},
+	ServiceClient:  {ServiceMgmtd, ServiceMeta, ServiceStorage},
+}
+
+// ServiceDependencies returns services the service depends on. The client depends
+// on none of services of the cluster if it connects to mgmtd endpoints of another one.
+func (s *Services) ServiceDependencies(service ServiceType) []ServiceType {
+	if service == ServiceClient && len(s.Client.MgmtdEndpoints) > 0 {
+		return nil
+	}
+	return serviceDependencies[service]
+}
+
+func (s *Services) enabledRef(service ServiceType) **bool {
+	switch service {
+	case ServiceFdb:
+		return &s.Fdb.Enabled
+	case ServiceClickhouse:
+		return &s.Clickhouse.Enabled
+	case ServiceMonitor:
+		return &s.Monitor.Enabled
+	case ServiceMgmtd:
+		return &s.Mgmtd.Enabled
+	case ServiceMeta:
+		return &s.Meta.Enabled
+	case ServiceStorage:
+		return &s.Storage.Enabled
+	case ServiceClient:
+		return &s.Client.Enabled
+	default:
+		return nil
+	}
+}
+
+// Enabled returns whether the service is enabled, services are enabled by default.
+func (s *Services) Enabled(service ServiceType) bool {
+	ref := s.enabledRef(service)
+	return ref != nil && (*ref == nil || **ref)
+}
+
+// SetEnabled sets whether the service is deployed.
+func (s *Services) SetEnabled(service ServiceType, enabled bool) {
+	if ref := s.enabledRef(service); ref != nil {
+		*ref = &enabled
+	}
+}
+
+// DisabledServices returns disabled services in the order of AllServiceTypes.
+func (s *Services) DisabledServices() []ServiceType {
+	var disabled []ServiceType
+	for _, service := range AllServiceTypes {
+		if !s.Enabled(service) {
+			disabled = append(disabled, service)
+		}
+	}
+	return disabled
+}
+
+// SetEnabledExplicitly sets enabled of services left to the default explicitly, so the
+// encoded config shows the effective enabled set.
+func (s *Services) SetEnabledExplicitly() {
+	for _, service := range AllServiceTypes {
+		ref := s.enabledRef(service)
+		if *ref == nil {
+			enabled := true
+			*ref = &enabled
+		}
+	}
+}
+
+// validEnabled validates services depended on by enabled services are enabled.
+func (c *Config) validEnabled(v *validator) {
+	for _, service := range AllServiceTypes {
+		if !c.Services.Enabled(service) {
+			continue
+		}
+		for _, dependency := range c.Services.ServiceDependencies(service) {
+			if !c.Services.Enabled(dependency) {
+				key := fmt.Sprintf("services.%s.enabled", service)
+				v.addf(ValidationCategoryServices, key,
+					"%s service depends on %s service which is disabled, disable %s service too",
+					service, dependency, service)
+			}
+		}
+	}
+}
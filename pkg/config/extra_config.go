@@ -0,0 +1,155 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExtraConfigValue is a value of native 3fs config set by extraConfig.
+type ExtraConfigValue struct {
+	// Value is a string, number, bool or a list of them.
+	Value any `yaml:"value"`
+	// Override makes the value take precedence over the key managed by m3fs.
+	Override bool `yaml:"override,omitempty"`
+}
+
+// MarshalYAML marshals the value as is unless it overrides.
+func (v ExtraConfigValue) MarshalYAML() (any, error) {
+	if !v.Override {
+		return v.Value, nil
+	}
+	type plain ExtraConfigValue
+	return plain(v), nil
+}
+
+// ExtraConfig maps dotted keys of native 3fs config, like
+// server.base.thread_pool.num_io_threads, to values merged into the main config file of
+// a 3fs service. Nested mappings are flattened into dotted keys, a mapping of value and
+// override is a value.
+type ExtraConfig map[string]ExtraConfigValue
+
+// UnmarshalYAML flattens nested mappings into dotted keys.
+func (c *ExtraConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: extraConfig must be a mapping", node.Line)
+	}
+	*c = make(ExtraConfig)
+	return c.flatten("", node)
+}
+
+func (c ExtraConfig) flatten(prefix string, node *yaml.Node) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if valueNode.Kind == yaml.MappingNode && !isExtraConfigValue(valueNode) {
+			if err := c.flatten(key, valueNode); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := c[key]; ok {
+			return fmt.Errorf("line %d: duplicate extraConfig key %s", keyNode.Line, key)
+		}
+		var value ExtraConfigValue
+		var err error
+		if valueNode.Kind == yaml.MappingNode {
+			type plain ExtraConfigValue
+			err = valueNode.Decode((*plain)(&value))
+		} else {
+			err = valueNode.Decode(&value.Value)
+		}
+		if err != nil {
+			return err
+		}
+		c[key] = value
+	}
+	return nil
+}
+
+// isExtraConfigValue returns whether the mapping is a value with the override flag.
+func isExtraConfigValue(node *yaml.Node) bool {
+	hasValue := false
+	for i := 0; i < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "value":
+			hasValue = true
+		case "override":
+		default:
+			return false
+		}
+	}
+	return hasValue
+}
+
+var extraConfigKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// ExtraConfig returns the extra native config of the 3fs service.
+func (s *Services) ExtraConfig(service ServiceType) ExtraConfig {
+	switch service {
+	case ServiceMgmtd:
+		return s.Mgmtd.ExtraConfig
+	case ServiceMeta:
+		return s.Meta.ExtraConfig
+	case ServiceStorage:
+		return s.Storage.ExtraConfig
+	case ServiceClient:
+		return s.Client.ExtraConfig
+	}
+	return nil
+}
+
+func (c *Config) validExtraConfig(v *validator) {
+	for _, service := range []ServiceType{ServiceMgmtd, ServiceMeta, ServiceStorage, ServiceClient} {
+		extra := c.Services.ExtraConfig(service)
+		field := fmt.Sprintf("services.%s.extraConfig", service)
+		for _, key := range slices.Sorted(maps.Keys(extra)) {
+			if !extraConfigKeyRegex.MatchString(key) {
+				v.addf(ValidationCategoryServices, field, "%s: invalid key %q", field, key)
+				continue
+			}
+			if !validExtraConfigValue(extra[key].Value, true) {
+				v.addf(ValidationCategoryServices, field,
+					"%s: value of %s must be a string, number, bool or a list of them", field, key)
+			}
+		}
+	}
+}
+
+func validExtraConfigValue(value any, allowList bool) bool {
+	switch value := value.(type) {
+	case string, int, int64, uint64, float64, bool:
+		return true
+	case []any:
+		if !allowList {
+			return false
+		}
+		for _, item := range value {
+			if !validExtraConfigValue(item, true) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// validManageFirewall validates that rules of the firewall can be scoped to nodes, only
+// IPv4 addresses of nodes are supported.
+func (c *Config) validManageFirewall(v *validator) {
+	if !c.ManageFirewall {
+		return
+	}
+	for _, node := range c.Nodes {
+		if ip := net.ParseIP(node.Host); ip != nil && ip.To4() == nil {
+			v.addf(ValidationCategoryNodes, fmt.Sprintf("nodes[%s].host", node.Name),
+				"IPv6 host of node %s isn't supported to manage the firewall: %s", node.Name, node.Host)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var invalidHostnameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// HostsName returns the hostname of the node in /etc/hosts managed by m3fs. It's
+// the node name in lower case, runs of characters invalid in hostnames are replaced
+// by '-', e.g. the node "meta-node(10.0.0.1)" of a node group is "meta-node-10-0-0-1".
+func HostsName(nodeName string) string {
+	name := invalidHostnameChars.ReplaceAllString(strings.ToLower(nodeName), "-")
+	return strings.Trim(name, "-")
+}
+
+// validManageHosts validates that nodes can be resolved by names in /etc/hosts,
+// hosts of nodes must be IP addresses and names must not conflict.
+func (c *Config) validManageHosts(v *validator) {
+	if !c.ManageHosts {
+		return
+	}
+	names := make(map[string]string, len(c.Nodes))
+	for _, node := range c.Nodes {
+		key := fmt.Sprintf("nodes[%s]", node.Name)
+		if net.ParseIP(node.Host) == nil {
+			v.addf(ValidationCategoryNodes, key+".host",
+				"host of node %s must be an IP address to manage /etc/hosts: %s", node.Name, node.Host)
+		}
+		name := HostsName(node.Name)
+		if name == "" {
+			v.addf(ValidationCategoryNodes, key+".name", "node name %s isn't a valid hostname", node.Name)
+			continue
+		}
+		if other, ok := names[name]; ok {
+			v.addf(ValidationCategoryNodes, key+".name",
+				"nodes %s and %s conflict with the same hostname %s", other, node.Name, name)
+			continue
+		}
+		names[name] = node.Name
+	}
+}
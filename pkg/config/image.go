@@ -28,6 +28,18 @@ const (
 	ImageName3FS        = "3fs"
 )
 
+// ServiceImageName returns name of the image which the service runs on.
+func ServiceImageName(service ServiceType) string {
+	switch service {
+	case ServiceFdb:
+		return ImageNameFdb
+	case ServiceClickhouse:
+		return ImageNameClickhouse
+	default:
+		return ImageName3FS
+	}
+}
+
 // Image is component container image config
 type Image struct {
 	Repo string
@@ -55,6 +67,21 @@ func (i *Images) getImage(imgName string) (Image, error) {
 	}
 }
 
+// SetImage sets the image of the target component.
+func (i *Images) SetImage(imgName string, img Image) error {
+	switch imgName {
+	case ImageNameFdb:
+		i.Fdb = img
+	case ImageName3FS:
+		i.FFFS = img
+	case ImageNameClickhouse:
+		i.Clickhouse = img
+	default:
+		return errors.Errorf("invalid image name %s", imgName)
+	}
+	return nil
+}
+
 // GetImage get image path of target component
 func (i *Images) GetImage(imgName string) (string, error) {
 	imagePath, err := i.GetImageWithoutRegistry(imgName)
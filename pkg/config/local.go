@@ -0,0 +1,107 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os/user"
+)
+
+// Local mode deploys a single node cluster on the local host, e.g. for smoke tests
+// and demos. All services are collapsed onto the local node, which is addressed by
+// the loopback address, and all commands run on it without SSH.
+const (
+	// LocalNodeName is the name of the local node if the config has no nodes.
+	LocalNodeName = "local"
+	// LocalNodeHost is the address of the local node.
+	LocalNodeHost = "127.0.0.1"
+)
+
+// applyLocal collapses the cluster onto the local node, settings requiring multiple
+// nodes are relaxed with warnings.
+func (c *Config) applyLocal() {
+	c.localWarnings = nil
+	node := Node{Name: LocalNodeName, Host: LocalNodeHost}
+	switch {
+	case len(c.Nodes) > 0:
+		first := c.Nodes[0]
+		node.Name = first.Name
+		node.Username = first.Username
+		node.Password = first.Password
+		node.Env = first.Env
+	case len(c.NodeGroups) > 0:
+		node.Username = c.NodeGroups[0].Username
+		node.Password = c.NodeGroups[0].Password
+	}
+	if node.Name == "" {
+		node.Name = LocalNodeName
+	}
+	if node.Username == "" {
+		node.Username = localUsername()
+	}
+	if len(c.Nodes)+len(c.NodeGroups) > 1 {
+		c.localWarnings = append(c.localWarnings, "local mode deploys all services on the local node, "+
+			"other nodes of the config are ignored")
+	}
+	c.Nodes = []Node{node}
+	c.NodeGroups = nil
+
+	services := &c.Services
+	for _, nodes := range []*[]string{
+		&services.Fdb.Nodes, &services.Clickhouse.Nodes, &services.Monitor.Nodes,
+		&services.Mgmtd.Nodes, &services.Meta.Nodes, &services.Storage.Nodes,
+	} {
+		*nodes = []string{node.Name}
+	}
+	// the client is optional, it's only deployed if the config has client nodes
+	if len(services.Client.Nodes) > 0 || len(services.Client.NodeGroups) > 0 {
+		services.Client.Nodes = []string{node.Name}
+	}
+	services.Fdb.NodeGroups = nil
+	services.Clickhouse.NodeGroups = nil
+	services.Monitor.NodeGroups = nil
+	services.Mgmtd.NodeGroups = nil
+	services.Meta.NodeGroups = nil
+	services.Storage.NodeGroups = nil
+	services.Client.NodeGroups = nil
+	if c.ArtifactCache.Node != "" {
+		c.ArtifactCache.Node = node.Name
+	}
+
+	if services.Storage.ReplicationFactor > 1 {
+		c.localWarnings = append(c.localWarnings, fmt.Sprintf("local mode reduces the replication factor "+
+			"of storage from %d to 1, data isn't replicated", services.Storage.ReplicationFactor))
+		services.Storage.ReplicationFactor = 1
+	}
+	if services.Storage.Placement.Enabled() {
+		c.localWarnings = append(c.localWarnings, "local mode disables placement of storage replicas")
+		services.Storage.Placement = Placement{}
+	}
+	if ck := &services.Clickhouse; ck.Replicated() {
+		c.localWarnings = append(c.localWarnings, fmt.Sprintf("local mode reduces the topology of "+
+			"clickhouse from %s to a single node", ck.TopologyString()))
+		ck.Shards = 0
+		ck.Replicas = 0
+	}
+}
+
+// localUsername returns the name of the current user, commands on the local node
+// run as the user.
+func localUsername() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "root"
+}
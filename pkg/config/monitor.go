@@ -0,0 +1,30 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+func (c *Config) validMonitorAlerts(v *validator) {
+	alerts := &c.Services.Monitor.Alerts
+	validDuration(v, ValidationCategoryServices, "services.monitor.alerts.for", alerts.For, true)
+	validDuration(v, ValidationCategoryServices, "services.monitor.alerts.staleCheck", alerts.StaleCheck, false)
+	validDuration(v, ValidationCategoryServices, "services.monitor.alerts.slowTask", alerts.SlowTask, true)
+	if alerts.UnreachableNodes < 1 {
+		v.addf(ValidationCategoryServices, "services.monitor.alerts.unreachableNodes",
+			"services.monitor.alerts.unreachableNodes must be positive: %d", alerts.UnreachableNodes)
+	}
+	if alerts.DriftFindings < 1 {
+		v.addf(ValidationCategoryServices, "services.monitor.alerts.driftFindings",
+			"services.monitor.alerts.driftFindings must be positive: %d", alerts.DriftFindings)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// defines SELinux labels of bind mounts
+const (
+	// SELinuxLabelShared relabels the source to be shared by containers.
+	SELinuxLabelShared = "z"
+	// SELinuxLabelPrivate relabels the source to be private to the container.
+	SELinuxLabelPrivate = "Z"
+)
+
+// systemDirs are dirs of the node which mustn't be relabeled for SELinux.
+var systemDirs = []string{"/usr", "/etc", "/dev", "/proc", "/sys", "/boot", "/lib", "/lib64", "/bin", "/sbin"}
+
+// isSystemPath returns whether the path is the root dir or in a system dir.
+func isSystemPath(p string) bool {
+	p = path.Clean(p)
+	return p == "/" || slices.ContainsFunc(systemDirs, func(dir string) bool {
+		return p == dir || strings.HasPrefix(p, dir+"/")
+	})
+}
+
+// securityOptPrefixes are prefixes of allowed security options of containers.
+var securityOptPrefixes = []string{"label=", "apparmor=", "seccomp=", "no-new-privileges"}
+
+// Mount is a bind mount of a path on the node into the container of a service.
+type Mount struct {
+	Source string `yaml:"source"`
+	// Target is the path in the container, default is the source.
+	Target   string `yaml:"target,omitempty"`
+	ReadOnly bool   `yaml:"readOnly,omitempty"`
+	// SELinuxLabel relabels the source for SELinux, it's z or Z. Relabeling system dirs
+	// like /usr or /dev breaks the node, so they must not be relabeled.
+	SELinuxLabel string `yaml:"selinuxLabel,omitempty"`
+}
+
+// ContainerPath returns the path of the mount in the container.
+func (m Mount) ContainerPath() string {
+	if m.Target == "" {
+		return m.Source
+	}
+	return m.Target
+}
+
+func (m Mount) String() string {
+	s := fmt.Sprintf("%s:%s", m.Source, m.ContainerPath())
+	if m.ReadOnly {
+		s += " (ro)"
+	}
+	return s
+}
+
+// Device is a device of the node passed into the container of a service, e.g. a data
+// disk or an RDMA device.
+type Device struct {
+	Host string `yaml:"host"`
+	// Container is the path in the container, default is the path on the node.
+	Container string `yaml:"container,omitempty"`
+	// Permissions are cgroup permissions of the device, a combination of r, w and m,
+	// default is rwm.
+	Permissions string `yaml:"permissions,omitempty"`
+}
+
+// ContainerPath returns the path of the device in the container.
+func (d Device) ContainerPath() string {
+	if d.Container == "" {
+		return d.Host
+	}
+	return d.Container
+}
+
+func (d Device) String() string {
+	s := fmt.Sprintf("%s:%s", d.Host, d.ContainerPath())
+	if d.Permissions != "" {
+		s += fmt.Sprintf(" (%s)", d.Permissions)
+	}
+	return s
+}
+
+// Passthrough is the config of passing paths and devices of the node into the container
+// of a service.
+type Passthrough struct {
+	Mounts  []Mount  `yaml:"mounts,omitempty"`
+	Devices []Device `yaml:"devices,omitempty"`
+	// SecurityOpts are security options of the container, e.g. label=disable or
+	// apparmor=unconfined.
+	SecurityOpts []string `yaml:"securityOpts,omitempty"`
+}
+
+// IsEmpty returns whether nothing is passed into the container.
+func (p Passthrough) IsEmpty() bool {
+	return len(p.Mounts) == 0 && len(p.Devices) == 0 && len(p.SecurityOpts) == 0
+}
+
+// Passthrough returns paths and devices passed into the container of the service.
+func (s *Services) Passthrough(service ServiceType) Passthrough {
+	switch service {
+	case ServiceFdb:
+		return s.Fdb.Passthrough
+	case ServiceClickhouse:
+		return s.Clickhouse.Passthrough
+	case ServiceMonitor:
+		return s.Monitor.Passthrough
+	case ServiceMgmtd:
+		return s.Mgmtd.Passthrough
+	case ServiceMeta:
+		return s.Meta.Passthrough
+	case ServiceStorage:
+		return s.Storage.Passthrough
+	case ServiceClient:
+		return s.Client.Passthrough
+	default:
+		return Passthrough{}
+	}
+}
+
+// PassthroughNodes returns nodes running services which have paths or devices passed
+// into their containers.
+func (c *Config) PassthroughNodes() []Node {
+	var nodes []Node
+	for _, node := range c.Nodes {
+		if len(c.NodePassthroughs(node.Name)) > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// NodePassthroughs returns passthroughs of services on the node, services without any
+// are omitted.
+func (c *Config) NodePassthroughs(nodeName string) map[ServiceType]Passthrough {
+	passthroughs := make(map[ServiceType]Passthrough)
+	for _, service := range AllServiceTypes {
+		p := c.Services.Passthrough(service)
+		if !p.IsEmpty() && slices.Contains(c.Services.ServiceNodes(service), nodeName) {
+			passthroughs[service] = p
+		}
+	}
+	return passthroughs
+}
+
+func (c *Config) validPassthrough(v *validator) {
+	for _, service := range AllServiceTypes {
+		p := c.Services.Passthrough(service)
+		targets := make(map[string]bool)
+		for i, m := range p.Mounts {
+			key := fmt.Sprintf("services.%s.mounts[%d]", service, i)
+			if !path.IsAbs(m.Source) {
+				v.addf(ValidationCategoryDirectories, key+".source", "%s.source must be an absolute path: %q",
+					key, m.Source)
+			}
+			if !path.IsAbs(m.ContainerPath()) {
+				v.addf(ValidationCategoryDirectories, key+".target", "%s.target must be an absolute path: %q",
+					key, m.Target)
+			}
+			if targets[path.Clean(m.ContainerPath())] {
+				v.addf(ValidationCategoryDirectories, key+".target", "%s.target is mounted twice: %s",
+					key, m.ContainerPath())
+			}
+			targets[path.Clean(m.ContainerPath())] = true
+			switch {
+			case m.SELinuxLabel == "":
+			case m.SELinuxLabel != SELinuxLabelShared && m.SELinuxLabel != SELinuxLabelPrivate:
+				v.addf(ValidationCategoryDirectories, key+".selinuxLabel", "%s.selinuxLabel must be %s or %s: %q",
+					key, SELinuxLabelShared, SELinuxLabelPrivate, m.SELinuxLabel)
+			case isSystemPath(m.Source):
+				v.addf(ValidationCategoryDirectories, key+".selinuxLabel",
+					"%s.selinuxLabel must not be set, relabeling system path %s breaks the node", key, m.Source)
+			}
+		}
+		for i, d := range p.Devices {
+			key := fmt.Sprintf("services.%s.devices[%d]", service, i)
+			if !strings.HasPrefix(d.Host, "/dev/") {
+				v.addf(ValidationCategoryServices, key+".host", "%s.host must be a path under /dev: %q", key, d.Host)
+			}
+			if !path.IsAbs(d.ContainerPath()) {
+				v.addf(ValidationCategoryServices, key+".container", "%s.container must be an absolute path: %q",
+					key, d.Container)
+			}
+			if strings.Trim(d.Permissions, "rwm") != "" {
+				v.addf(ValidationCategoryServices, key+".permissions",
+					"%s.permissions must be a combination of r, w and m: %q", key, d.Permissions)
+			}
+		}
+		for i, opt := range p.SecurityOpts {
+			if !slices.ContainsFunc(securityOptPrefixes, func(prefix string) bool {
+				return strings.HasPrefix(opt, prefix)
+			}) {
+				key := fmt.Sprintf("services.%s.securityOpts[%d]", service, i)
+				v.addf(ValidationCategoryServices, key, "%s: unsupported security option %q, must start with %s",
+					key, opt, strings.Join(securityOptPrefixes, ", "))
+			}
+		}
+	}
+}
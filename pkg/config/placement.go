@@ -0,0 +1,141 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Placement is the config of placing replicas of storage across availability zones,
+// zones of storage nodes are required if any constraint is set.
+type Placement struct {
+	// SpreadZones places replicas of every chain in distinct zones.
+	SpreadZones bool `yaml:"spreadZones,omitempty"`
+	// MinZones is the minimum number of zones storage nodes span.
+	MinZones int `yaml:"minZones,omitempty"`
+}
+
+// Enabled returns whether any placement constraint is set.
+func (p Placement) Enabled() bool {
+	return p.SpreadZones || p.MinZones > 0
+}
+
+// NodeZones returns zones of the nodes by names, nodes without zones are omitted.
+func (c *Config) NodeZones(nodeNames []string) map[string]string {
+	zones := make(map[string]string, len(nodeNames))
+	for _, node := range c.Nodes {
+		if node.Zone == "" {
+			continue
+		}
+		for _, name := range nodeNames {
+			if name == node.Name {
+				zones[name] = node.Zone
+				break
+			}
+		}
+	}
+	return zones
+}
+
+// zoneNodes returns names of the nodes in each zone, nodes without zones are omitted.
+func zoneNodes(zones map[string]string) map[string][]string {
+	nodes := make(map[string][]string)
+	for node, zone := range zones {
+		nodes[zone] = append(nodes[zone], node)
+	}
+	for _, names := range nodes {
+		slices.Sort(names)
+	}
+	return nodes
+}
+
+// validPlacement validates that storage nodes satisfy the placement constraints.
+func (c *Config) validPlacement(v *validator) {
+	storage := c.Services.Storage
+	placement := storage.Placement
+	if placement.MinZones < 0 {
+		v.addf(ValidationCategoryServices, "services.storage.placement.minZones",
+			"services.storage.placement.minZones must not be negative: %d", placement.MinZones)
+	}
+	if !placement.Enabled() {
+		return
+	}
+
+	zones := c.NodeZones(storage.Nodes)
+	for _, node := range storage.Nodes {
+		if _, ok := zones[node]; !ok {
+			v.addf(ValidationCategoryServices, "services.storage.placement",
+				"storage node %s has no zone, zones are required by services.storage.placement", node)
+		}
+	}
+	if len(zones) < len(storage.Nodes) {
+		return
+	}
+	nodes := zoneNodes(zones)
+	if len(nodes) < placement.MinZones {
+		v.addf(ValidationCategoryServices, "services.storage.placement.minZones",
+			"storage nodes span %d zone(s), fewer than services.storage.placement.minZones %d",
+			len(nodes), placement.MinZones)
+	}
+	if !placement.SpreadZones {
+		return
+	}
+	if len(nodes) < storage.ReplicationFactor {
+		v.addf(ValidationCategoryServices, "services.storage.placement.spreadZones",
+			"storage nodes span %d zone(s), fewer than replicationFactor %d to spread replicas across zones",
+			len(nodes), storage.ReplicationFactor)
+		return
+	}
+	// every chain takes a replica from replicationFactor zones, so a zone can't hold
+	// more targets than the number of chains
+	for _, zone := range slices.Sorted(maps.Keys(nodes)) {
+		names := nodes[zone]
+		if len(names)*storage.ReplicationFactor > len(storage.Nodes) {
+			v.addf(ValidationCategoryServices, "services.storage.placement.spreadZones",
+				"zone %s has %d of %d storage nodes, replicas can't be spread across zones with "+
+					"replicationFactor %d, at most %d storage nodes are allowed in a zone",
+				zone, len(names), len(storage.Nodes), storage.ReplicationFactor,
+				len(storage.Nodes)/storage.ReplicationFactor)
+		}
+	}
+}
+
+// Warnings returns warnings of the valid config which doesn't fail the deployment,
+// e.g. storage nodes are in a single zone.
+func (c *Config) Warnings() []string {
+	if c.Local {
+		// a single node has no zones to spread replicas
+		return append(append([]string(nil), c.localWarnings...), c.capacityWarnings()...)
+	}
+	var warnings []string
+	zones := c.NodeZones(c.Services.Storage.Nodes)
+	nodes := zoneNodes(zones)
+	switch {
+	case len(zones) == 0:
+		warnings = append(warnings, "zones of storage nodes aren't set, "+
+			"replicas may be placed in a single availability zone")
+	case len(nodes) == 1:
+		for zone := range nodes {
+			warnings = append(warnings, fmt.Sprintf("all storage nodes are in the zone %s, "+
+				"replicas are lost with the zone", zone))
+		}
+	case len(zones) < len(c.Services.Storage.Nodes):
+		warnings = append(warnings, fmt.Sprintf("%d of %d storage nodes have no zone",
+			len(c.Services.Storage.Nodes)-len(zones), len(c.Services.Storage.Nodes)))
+	}
+	return append(warnings, c.capacityWarnings()...)
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// PluginTask is a site-specific task running a command, e.g. registering nodes into a
+// CMDB, it runs as a task of cluster create like builtin tasks. The command runs with
+// sudo on each node selected by Nodes and Services, or once on the local host if no
+// node is selected.
+type PluginTask struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command"`
+	// DeleteCommand undoes the command, it runs as a task of cluster delete.
+	DeleteCommand string        `yaml:"deleteCommand,omitempty"`
+	Nodes         []string      `yaml:"nodes,omitempty"`
+	Services      []ServiceType `yaml:"services,omitempty"`
+	// After are names of tasks the task runs right after, it joins the phase of them.
+	After []string `yaml:"after,omitempty"`
+	// Phase is the phase at the end of which the task runs if After is empty, default
+	// is deploy.
+	Phase string `yaml:"phase,omitempty"`
+	// Retries is the number of times the command is retried on a node after it fails.
+	Retries int `yaml:"retries,omitempty"`
+	// MaxParallel limits the number of nodes running the command in parallel, 0 means
+	// no limit.
+	MaxParallel int `yaml:"maxParallel,omitempty"`
+}
+
+// phaseNames are names of phases of tasks, which are defined by the task package.
+var phaseNames = []string{"prepare", "deploy", "verify"}
+
+var pluginNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// PluginNodes returns nodes the command of the plugin task runs on in the order of
+// nodes, it's empty if the task runs on the local host.
+func (c *Config) PluginNodes(plugin *PluginTask) []Node {
+	var nodes []Node
+	for _, node := range c.Nodes {
+		selected := slices.Contains(plugin.Nodes, node.Name)
+		for _, service := range plugin.Services {
+			selected = selected || slices.Contains(c.Services.ServiceNodes(service), node.Name)
+		}
+		if selected {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func (c *Config) validPlugins(v *validator) {
+	names := make(map[string]bool, len(c.Plugins))
+	for i, plugin := range c.Plugins {
+		key := fmt.Sprintf("plugins[%d]", i)
+		if !pluginNameRegex.MatchString(plugin.Name) {
+			v.addf(ValidationCategoryGeneral, key+".name", "%s: invalid name %q", key, plugin.Name)
+		} else if names[plugin.Name] {
+			v.addf(ValidationCategoryGeneral, key+".name", "duplicate plugin name: %s", plugin.Name)
+		} else {
+			key = fmt.Sprintf("plugins[%s]", plugin.Name)
+		}
+		names[plugin.Name] = true
+		if plugin.Command == "" {
+			v.addf(ValidationCategoryGeneral, key+".command", "%s.command is required", key)
+		}
+		for _, name := range plugin.Nodes {
+			if !slices.ContainsFunc(c.Nodes, func(node Node) bool { return node.Name == name }) {
+				v.addf(ValidationCategoryNodes, key+".nodes", "%s.nodes: unknown node %s", key, name)
+			}
+		}
+		for _, service := range plugin.Services {
+			if !slices.Contains(AllServiceTypes, service) {
+				v.addf(ValidationCategoryServices, key+".services", "%s.services: invalid service %s", key, service)
+			}
+		}
+		if plugin.Phase != "" && !slices.Contains(phaseNames, plugin.Phase) {
+			v.addf(ValidationCategoryGeneral, key+".phase", "%s: invalid phase %s, must be one of %v",
+				key, plugin.Phase, phaseNames)
+		}
+		if plugin.Phase != "" && len(plugin.After) > 0 {
+			v.addf(ValidationCategoryGeneral, key+".phase",
+				"%s: phase and after can't be set together, the task joins the phase of tasks it runs after", key)
+		}
+		if plugin.Retries < 0 {
+			v.addf(ValidationCategoryGeneral, key+".retries", "%s.retries must not be negative: %d",
+				key, plugin.Retries)
+		}
+		if plugin.MaxParallel < 0 {
+			v.addf(ValidationCategoryGeneral, key+".maxParallel", "%s.maxParallel must not be negative: %d",
+				key, plugin.MaxParallel)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// keys of the config document which profiles are kept in
+const (
+	profilesKey = "profiles"
+	profileKey  = "profile"
+)
+
+// ApplyProfile applies overrides of the profile in profiles of the config document on top of
+// the document, the profile selected by the profile key of the document is applied if the
+// profile is empty. Mappings are merged recursively, other values including lists replace
+// values of the base config. The applied profile is recorded by the profile key and profiles
+// are removed from the document, so that it's the effective config of the profile.
+func ApplyProfile(doc *yaml.Node, profile string) error {
+	root := doc
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil
+		}
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	if profile == "" {
+		if value := mappingValue(root, profileKey); value != nil {
+			profile = value.Value
+		}
+	}
+	if profile == "" {
+		return nil
+	}
+	profiles := mappingValue(root, profilesKey)
+	if profiles == nil || profiles.Kind != yaml.MappingNode {
+		return errors.Errorf("profile %s not exists in profiles", profile)
+	}
+	overrides := mappingValue(profiles, profile)
+	if overrides == nil {
+		return errors.Errorf("profile %s not exists in profiles, available profiles: %v",
+			profile, mappingKeys(profiles))
+	}
+	if overrides.Kind != yaml.MappingNode {
+		return errors.Errorf("overrides of profile %s must be a mapping", profile)
+	}
+	for _, key := range []string{profilesKey, profileKey} {
+		if mappingValue(overrides, key) != nil {
+			return errors.Errorf("profile %s must not override %s", profile, key)
+		}
+	}
+	mergeMapping(root, overrides)
+	removeMappingKey(root, profilesKey)
+	setMappingValue(root, profileKey, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: profile})
+	return nil
+}
+
+// mergeMapping merges the overrides mapping into the base mapping.
+func mergeMapping(base, overrides *yaml.Node) {
+	for i := 0; i+1 < len(overrides.Content); i += 2 {
+		key, value := overrides.Content[i].Value, overrides.Content[i+1]
+		baseValue := mappingValue(base, key)
+		if baseValue != nil && baseValue.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeMapping(baseValue, value)
+			continue
+		}
+		setMappingValue(base, key, value)
+	}
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func mappingKeys(mapping *yaml.Node) []string {
+	keys := make([]string, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		keys = append(keys, mapping.Content[i].Value)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func removeMappingKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = slices.Delete(mapping.Content, i, i+2)
+			return
+		}
+	}
+}
+
+// validProfiles validates profiles which aren't applied, the profile must be one of them.
+func (c *Config) validProfiles(v *validator) {
+	for _, name := range slices.Sorted(maps.Keys(c.Profiles)) {
+		if c.Profiles[name].Kind != yaml.MappingNode {
+			v.addf(ValidationCategoryGeneral, fmt.Sprintf("profiles.%s", name),
+				"overrides of profile %s must be a mapping", name)
+		}
+	}
+	if c.Profile == "" || len(c.Profiles) == 0 {
+		return
+	}
+	if _, ok := c.Profiles[c.Profile]; !ok {
+		v.addf(ValidationCategoryGeneral, "profile", "profile %s not exists in profiles", c.Profile)
+	}
+}
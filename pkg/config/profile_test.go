@@ -0,0 +1,131 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/tests/base"
+)
+
+func TestProfileSuite(t *testing.T) {
+	suite.Run(t, new(profileSuite))
+}
+
+type profileSuite struct {
+	base.Suite
+}
+
+const profileConfig = `
+name: test
+phaseGates: false
+services:
+  storage:
+    nodes: [node1, node2, node3]
+    replicationFactor: 3
+    resources:
+      memory: 64g
+      cpus: 16
+profiles:
+  dev:
+    services:
+      storage:
+        nodes: [node1]
+        replicationFactor: 1
+        resources:
+          memory: 8g
+  prod:
+    phaseGates: true
+`
+
+// load applies the profile on the config document and decodes the effective config.
+func (s *profileSuite) load(data, profile string) (*Config, error) {
+	var doc yaml.Node
+	s.NoError(yaml.Unmarshal([]byte(data), &doc))
+	if err := ApplyProfile(&doc, profile); err != nil {
+		return nil, err
+	}
+	cfg := NewConfigWithDefaults()
+	s.NoError(doc.Decode(cfg))
+	return cfg, nil
+}
+
+func (s *profileSuite) TestWithoutProfile() {
+	cfg, err := s.load(profileConfig, "")
+	s.NoError(err)
+	s.Empty(cfg.Profile)
+	s.Len(cfg.Profiles, 2)
+	s.Equal(3, cfg.Services.Storage.ReplicationFactor)
+}
+
+func (s *profileSuite) TestOverridePrecedence() {
+	cfg, err := s.load(profileConfig, "dev")
+	s.NoError(err)
+	s.Equal("dev", cfg.Profile)
+	s.Nil(cfg.Profiles)
+	// lists are replaced
+	s.Equal([]string{"node1"}, cfg.Services.Storage.Nodes)
+	s.Equal(1, cfg.Services.Storage.ReplicationFactor)
+	// mappings are merged
+	s.Equal(Resources{Memory: "8g", CPUs: 16}, cfg.Services.Storage.Resources)
+	s.False(cfg.PhaseGates)
+
+	cfg, err = s.load(profileConfig, "prod")
+	s.NoError(err)
+	s.True(cfg.PhaseGates)
+	s.Equal(3, cfg.Services.Storage.ReplicationFactor)
+}
+
+func (s *profileSuite) TestProfileSelectedByConfig() {
+	cfg, err := s.load(profileConfig+"profile: prod\n", "")
+	s.NoError(err)
+	s.Equal("prod", cfg.Profile)
+	s.True(cfg.PhaseGates)
+
+	// the given profile overrides the one selected by the config
+	cfg, err = s.load(profileConfig+"profile: prod\n", "dev")
+	s.NoError(err)
+	s.Equal("dev", cfg.Profile)
+	s.False(cfg.PhaseGates)
+}
+
+func (s *profileSuite) TestUnknownProfile() {
+	_, err := s.load(profileConfig, "staging")
+	s.Error(err, "profile staging not exists in profiles, available profiles: [dev prod]")
+
+	_, err = s.load("name: test\n", "prod")
+	s.Error(err, "profile prod not exists in profiles")
+}
+
+func (s *profileSuite) TestInvalidOverrides() {
+	_, err := s.load("profiles:\n  dev: 1\n", "dev")
+	s.Error(err, "overrides of profile dev must be a mapping")
+
+	_, err = s.load("profiles:\n  dev:\n    profile: prod\n", "dev")
+	s.Error(err, "profile dev must not override profile")
+}
+
+func (s *profileSuite) TestValidProfiles() {
+	cfg, err := s.load(profileConfig+"  qa: []\n", "")
+	s.NoError(err)
+	cfg.Profile = "staging"
+	v := new(validator)
+	cfg.validProfiles(v)
+	s.Error(v.err(), "2 errors in config: overrides of profile qa must be a mapping; "+
+		"profile staging not exists in profiles")
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+var memoryRegex = regexp.MustCompile(`^([0-9]+)([bkmg]?)$`)
+
+var memoryUnits = map[string]uint64{
+	"":  1,
+	"b": 1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+}
+
+// IsEmpty returns whether no resource is limited.
+func (r Resources) IsEmpty() bool {
+	return r == Resources{}
+}
+
+// MemoryBytes returns the memory limit in bytes, 0 means memory isn't limited.
+func (r Resources) MemoryBytes() (uint64, error) {
+	if r.Memory == "" {
+		return 0, nil
+	}
+	matches := memoryRegex.FindStringSubmatch(strings.ToLower(r.Memory))
+	if matches == nil {
+		return 0, errors.Errorf("invalid memory %q", r.Memory)
+	}
+	size, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, errors.Annotatef(err, "parse memory %q", r.Memory)
+	}
+	return size * memoryUnits[matches[2]], nil
+}
+
+// Ulimits returns ulimits of the container in the format of the --ulimit option.
+func (r Resources) Ulimits() map[string]string {
+	ulimits := make(map[string]string)
+	if r.NoFile != "" {
+		ulimits["nofile"] = r.NoFile
+	}
+	if r.NProc != "" {
+		ulimits["nproc"] = r.NProc
+	}
+	return ulimits
+}
+
+// String returns limited resources like "memory=64g cpus=8 nofile=1048576".
+func (r Resources) String() string {
+	var parts []string
+	if r.Memory != "" {
+		parts = append(parts, "memory="+r.Memory)
+	}
+	if r.CPUs > 0 {
+		parts = append(parts, "cpus="+strconv.FormatFloat(r.CPUs, 'f', -1, 64))
+	}
+	if r.NoFile != "" {
+		parts = append(parts, "nofile="+r.NoFile)
+	}
+	if r.NProc != "" {
+		parts = append(parts, "nproc="+r.NProc)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseUlimit parses the ulimit in the format of soft[:hard], the hard limit is
+// the soft limit if it's omitted.
+func ParseUlimit(ulimit string) (soft, hard uint64, err error) {
+	softStr, hardStr, found := strings.Cut(ulimit, ":")
+	if soft, err = strconv.ParseUint(softStr, 10, 64); err != nil {
+		return 0, 0, errors.Errorf("invalid ulimit %q", ulimit)
+	}
+	hard = soft
+	if found {
+		if hard, err = strconv.ParseUint(hardStr, 10, 64); err != nil {
+			return 0, 0, errors.Errorf("invalid ulimit %q", ulimit)
+		}
+	}
+	if soft > hard {
+		return 0, 0, errors.Errorf("soft limit of ulimit %q exceeds the hard limit", ulimit)
+	}
+	return soft, hard, nil
+}
+
+func (c *Config) validResources(v *validator) {
+	for _, service := range AllServiceTypes {
+		res := c.Services.Resources(service)
+		field := fmt.Sprintf("services.%s.resources", service)
+		if _, err := res.MemoryBytes(); err != nil {
+			v.addf(ValidationCategoryServices, field+".memory", "%s.memory: %v", field, err)
+		}
+		if res.CPUs < 0 {
+			v.addf(ValidationCategoryServices, field+".cpus", "%s.cpus must not be negative", field)
+		}
+		ulimits := res.Ulimits()
+		for _, name := range slices.Sorted(maps.Keys(ulimits)) {
+			if _, _, err := ParseUlimit(ulimits[name]); err != nil {
+				v.addf(ValidationCategoryServices, field+"."+name, "%s.%s: %v", field, name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// SecretBackend resolves references of secrets in the form of secret://<backend>/<path>#<key>.
+type SecretBackend interface {
+	Resolve(secretPath, key string) (string, error)
+}
+
+var (
+	secretBackendsMu sync.Mutex
+	secretBackends   = map[string]SecretBackend{
+		"vault": new(VaultSecretBackend),
+	}
+)
+
+// RegisterSecretBackend registers the backend resolving references secret://<name>/...
+func RegisterSecretBackend(name string, backend SecretBackend) {
+	secretBackendsMu.Lock()
+	defer secretBackendsMu.Unlock()
+	secretBackends[name] = backend
+}
+
+func getSecretBackend(name string) (SecretBackend, bool) {
+	secretBackendsMu.Lock()
+	defer secretBackendsMu.Unlock()
+	backend, ok := secretBackends[name]
+	return backend, ok
+}
+
+// secretRefSchemes are schemes of secret references.
+var secretRefSchemes = []string{"env", "file", "secret"}
+
+// IsSecretRef returns whether the value is a reference of a secret, which is one of
+// env://<variable>, file:///<path> and secret://<backend>/<path>#<key>.
+func IsSecretRef(value string) bool {
+	scheme, _, ok := strings.Cut(value, "://")
+	return ok && slices.Contains(secretRefSchemes, scheme)
+}
+
+// ResolveSecretRef resolves the reference of a secret.
+func ResolveSecretRef(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", errors.Annotatef(err, "parse secret reference")
+	}
+	switch u.Scheme {
+	case "env":
+		value, ok := os.LookupEnv(u.Host)
+		if !ok {
+			return "", errors.Errorf("environment variable %s isn't set", u.Host)
+		}
+		return value, nil
+	case "file":
+		data, err := os.ReadFile(u.Path)
+		if err != nil {
+			return "", errors.Annotatef(err, "read secret file")
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case "secret":
+		backend, ok := getSecretBackend(u.Host)
+		if !ok {
+			return "", errors.Errorf("unknown secret backend %s", u.Host)
+		}
+		secretPath := strings.TrimPrefix(u.Path, "/")
+		if secretPath == "" || u.Fragment == "" {
+			return "", errors.Errorf("secret reference must be secret://%s/<path>#<key>", u.Host)
+		}
+		value, err := backend.Resolve(secretPath, u.Fragment)
+		if err != nil {
+			return "", errors.Annotatef(err, "resolve by secret backend %s", u.Host)
+		}
+		return value, nil
+	default:
+		return "", errors.Errorf("unsupported secret reference scheme %s", u.Scheme)
+	}
+}
+
+// ResolvedSecretRef is a field of the config whose value is resolved from the reference.
+type ResolvedSecretRef struct {
+	Field string
+	Ref   string
+}
+
+// ResolveSecrets replaces references of secrets in passwords and env of the config
+// by secrets they refer to. Secrets are only kept in memory, they're returned by Secrets
+// so that they're redacted. It must be called before SetValidate, which copies passwords
+// and env of node groups to their nodes.
+//
+// All env values in the form of references are resolved, resolved fields are returned
+// by ResolvedSecretRefs so that plain values looking like references are noticed.
+func (c *Config) ResolveSecrets() error {
+	resolve := func(field string, value *string) error {
+		if value == nil || !IsSecretRef(*value) {
+			return nil
+		}
+		secret, err := ResolveSecretRef(*value)
+		if err != nil {
+			return errors.Annotatef(err, "resolve secret of %s", field)
+		}
+		c.resolvedSecretRefs = append(c.resolvedSecretRefs, ResolvedSecretRef{Field: field, Ref: *value})
+		*value = secret
+		c.resolvedSecrets = append(c.resolvedSecrets, secret)
+		return nil
+	}
+	resolveEnv := func(field string, env map[string]string) error {
+		for _, name := range slices.Sorted(maps.Keys(env)) {
+			value := env[name]
+			if err := resolve(fmt.Sprintf("%s.%s", field, name), &value); err != nil {
+				return errors.Trace(err)
+			}
+			env[name] = value
+		}
+		return nil
+	}
+
+	for i := range c.Nodes {
+		node := &c.Nodes[i]
+		if err := resolve(fmt.Sprintf("nodes[%s].password", node.Name), node.Password); err != nil {
+			return errors.Trace(err)
+		}
+		if err := resolveEnv(fmt.Sprintf("nodes[%s].env", node.Name), node.Env); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for i := range c.NodeGroups {
+		nodeGroup := &c.NodeGroups[i]
+		if err := resolve(fmt.Sprintf("nodeGroups[%s].password", nodeGroup.Name), nodeGroup.Password); err != nil {
+			return errors.Trace(err)
+		}
+		if err := resolveEnv(fmt.Sprintf("nodeGroups[%s].env", nodeGroup.Name), nodeGroup.Env); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := resolveEnv("env", c.Env); err != nil {
+		return errors.Trace(err)
+	}
+	if err := resolve("stateEncryption.key", &c.StateEncryption.Key); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(resolve("services.clickhouse.password", &c.Services.Clickhouse.Password))
+}
+
+// ResolvedSecretRefs returns fields resolved by ResolveSecrets in the order of resolving.
+func (c *Config) ResolvedSecretRefs() []ResolvedSecretRef {
+	return c.resolvedSecretRefs
+}
+
+// VaultSecretBackend resolves secrets of the key value secrets engine of HashiCorp Vault.
+// The address and the token of Vault are set by VAULT_ADDR and VAULT_TOKEN environment
+// variables, and the namespace by VAULT_NAMESPACE. The path is the API path under /v1,
+// e.g. secret/data/m3fs of the engine of version 2 mounted at secret/.
+type VaultSecretBackend struct {
+	// Client is used to request Vault, a client with a 30s timeout is used if it's nil.
+	Client *http.Client
+}
+
+// Resolve resolves the value of the key of the secret at the path.
+func (b *VaultSecretBackend) Resolve(secretPath, key string) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", errors.New("VAULT_ADDR isn't set")
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", errors.Annotatef(err, "parse VAULT_ADDR")
+	}
+	u.Path = path.Join(u.Path, "v1", secretPath)
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	client := b.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Annotatef(err, "request %s", u.Redacted())
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("request %s: %s", u.Redacted(), resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err = json.Unmarshal(body, &secret); err != nil {
+		return "", errors.Annotatef(err, "decode secret %s", secretPath)
+	}
+	data := secret.Data
+	// secrets of the version 2 engine are nested with their metadata
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, ok = data["metadata"]; ok {
+			data = nested
+		}
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", errors.Errorf("key %s not found in secret %s", key, secretPath)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", errors.Errorf("value of key %s of secret %s isn't a string", key, secretPath)
+	}
+	return str, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/tests/base"
+)
+
+func TestSecretSuite(t *testing.T) {
+	suite.Run(t, new(secretSuite))
+}
+
+type secretSuite struct {
+	base.Suite
+}
+
+func (s *secretSuite) TestIsSecretRef() {
+	s.True(IsSecretRef("env://PASSWORD"))
+	s.True(IsSecretRef("file:///etc/m3fs/password"))
+	s.True(IsSecretRef("secret://vault/secret/data/m3fs#password"))
+	s.False(IsSecretRef("password"))
+	s.False(IsSecretRef("http://proxy:3128"))
+}
+
+func (s *secretSuite) TestResolveSecrets() {
+	s.T().Setenv("M3FS_TEST_PASSWORD", "node-password")
+	secretFile := filepath.Join(s.T().TempDir(), "password")
+	s.NoError(os.WriteFile(secretFile, []byte("ch-password\n"), 0600))
+
+	cfg := NewConfigWithDefaults()
+	cfg.Nodes = []Node{{Name: "node1", Password: common.Pointer("env://M3FS_TEST_PASSWORD")}}
+	cfg.Env = map[string]string{"HTTP_PROXY": "http://proxy:3128", "API": "env://M3FS_TEST_PASSWORD"}
+	cfg.Services.Clickhouse.Password = "file://" + secretFile
+
+	s.NoError(cfg.ResolveSecrets())
+	s.Equal("node-password", *cfg.Nodes[0].Password)
+	s.Equal("node-password", cfg.Env["API"])
+	s.Equal("http://proxy:3128", cfg.Env["HTTP_PROXY"])
+	s.Equal("ch-password", cfg.Services.Clickhouse.Password)
+	s.Contains(cfg.Secrets(), "node-password")
+	s.Contains(cfg.Secrets(), "ch-password")
+	s.Equal([]ResolvedSecretRef{
+		{Field: "nodes[node1].password", Ref: "env://M3FS_TEST_PASSWORD"},
+		{Field: "env.API", Ref: "env://M3FS_TEST_PASSWORD"},
+		{Field: "services.clickhouse.password", Ref: "file://" + secretFile},
+	}, cfg.ResolvedSecretRefs())
+}
+
+func (s *secretSuite) TestResolveSecretsFailed() {
+	cfg := NewConfigWithDefaults()
+	cfg.NodeGroups = []NodeGroup{{Name: "group1", Password: common.Pointer("env://M3FS_TEST_NOT_SET")}}
+
+	s.Error(cfg.ResolveSecrets(),
+		"resolve secret of nodeGroups[group1].password: environment variable M3FS_TEST_NOT_SET isn't set")
+
+	cfg = NewConfigWithDefaults()
+	cfg.Services.Clickhouse.Password = "secret://unknown/path#key"
+	s.Error(cfg.ResolveSecrets(), "resolve secret of services.clickhouse.password: unknown secret backend unknown")
+}
+
+func (s *secretSuite) TestVaultSecretBackend() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/m3fs":
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "kv2-password"}, "metadata": {"version": 1}}}`))
+		case "/v1/kv/m3fs":
+			_, _ = w.Write([]byte(`{"data": {"password": "kv1-password"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	s.T().Setenv("VAULT_ADDR", server.URL)
+	s.T().Setenv("VAULT_TOKEN", "token")
+
+	value, err := ResolveSecretRef("secret://vault/secret/data/m3fs#password")
+	s.NoError(err)
+	s.Equal("kv2-password", value)
+	value, err = ResolveSecretRef("secret://vault/kv/m3fs#password")
+	s.NoError(err)
+	s.Equal("kv1-password", value)
+
+	_, err = ResolveSecretRef("secret://vault/kv/m3fs#user")
+	s.Error(err, "resolve by secret backend vault: key user not found in secret kv/m3fs")
+	_, err = ResolveSecretRef("secret://vault/kv/m3fs")
+	s.Error(err, "secret reference must be secret://vault/<path>#<key>")
+
+	s.T().Setenv("VAULT_TOKEN", "wrong")
+	_, err = ResolveSecretRef("secret://vault/kv/m3fs#password")
+	s.ErrorContains(err, "403 Forbidden")
+}
+
+func (s *secretSuite) TestStateEncryptionKey() {
+	key := strings.Repeat("k", StateEncryptionKeySize)
+	s.T().Setenv("M3FS_TEST_STATE_KEY", base64.StdEncoding.EncodeToString([]byte(key)))
+	cfg := NewConfigWithDefaults()
+	cfg.StateEncryption.Key = "env://M3FS_TEST_STATE_KEY"
+
+	s.NoError(cfg.ResolveSecrets())
+	decoded, err := cfg.StateEncryption.DecodeKey()
+	s.NoError(err)
+	s.Equal([]byte(key), decoded)
+	s.Contains(cfg.Secrets(), cfg.StateEncryption.Key)
+
+	cfg.StateEncryption.Key = base64.StdEncoding.EncodeToString([]byte("short"))
+	_, err = cfg.StateEncryption.DecodeKey()
+	s.Error(err, "key must be 32 bytes, got 5 bytes")
+	cfg.StateEncryption.Key = "not base64"
+	_, err = cfg.StateEncryption.DecodeKey()
+	s.ErrorContains(err, "decode base64 key")
+
+	decoded, err = StateEncryption{}.DecodeKey()
+	s.NoError(err)
+	s.Nil(decoded)
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultShutdownSignal is the default signal asking a service to exit.
+const DefaultShutdownSignal = "SIGTERM"
+
+// shutdownSignals are signals which can ask a service to exit gracefully.
+var shutdownSignals = []string{"SIGTERM", "SIGINT", "SIGQUIT", "SIGHUP", "SIGUSR1", "SIGUSR2"}
+
+// Shutdown is the config of stopping a service gracefully. The service is sent the
+// shutdown signal, then killed by SIGKILL if it doesn't exit within the timeout.
+type Shutdown struct {
+	// ShutdownTimeout is how long the service is waited for to exit, it's killed at once
+	// if it's zero.
+	ShutdownTimeout Duration `yaml:"shutdownTimeout,omitempty"`
+	// ShutdownSignal is the signal asking the service to exit, default is SIGTERM.
+	ShutdownSignal string `yaml:"shutdownSignal,omitempty"`
+}
+
+// Signal returns the name of the shutdown signal with the SIG prefix.
+func (s Shutdown) Signal() string {
+	if s.ShutdownSignal == "" {
+		return DefaultShutdownSignal
+	}
+	signal := strings.ToUpper(s.ShutdownSignal)
+	if !strings.HasPrefix(signal, "SIG") {
+		signal = "SIG" + signal
+	}
+	return signal
+}
+
+// Shutdown returns the shutdown config of the service.
+func (s *Services) Shutdown(service ServiceType) Shutdown {
+	switch service {
+	case ServiceFdb:
+		return s.Fdb.Shutdown
+	case ServiceClickhouse:
+		return s.Clickhouse.Shutdown
+	case ServiceMonitor:
+		return s.Monitor.Shutdown
+	case ServiceMgmtd:
+		return s.Mgmtd.Shutdown
+	case ServiceMeta:
+		return s.Meta.Shutdown
+	case ServiceStorage:
+		return s.Storage.Shutdown
+	case ServiceClient:
+		return s.Client.Shutdown
+	default:
+		return Shutdown{}
+	}
+}
+
+func (c *Config) validShutdown(v *validator) {
+	for _, service := range AllServiceTypes {
+		shutdown := c.Services.Shutdown(service)
+		validDuration(v, ValidationCategoryServices, fmt.Sprintf("services.%s.shutdownTimeout", service),
+			shutdown.ShutdownTimeout, true)
+		if !slices.Contains(shutdownSignals, shutdown.Signal()) {
+			key := fmt.Sprintf("services.%s.shutdownSignal", service)
+			v.addf(ValidationCategoryServices, key, "%s: invalid signal %s, must be one of %s",
+				key, shutdown.ShutdownSignal, strings.Join(shutdownSignals, ", "))
+		}
+	}
+}
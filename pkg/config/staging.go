@@ -0,0 +1,44 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LocalStagingDir returns the dir in which artifacts are staged on the deploy host, it's
+// the temp dir if the staging dir isn't set.
+func (c *Config) LocalStagingDir() string {
+	if c.StagingDir != "" {
+		return c.StagingDir
+	}
+	return os.TempDir()
+}
+
+// NodeStagingDir returns the dir in which artifacts are staged on nodes, it's the work
+// dir if the staging dir isn't set.
+func (c *Config) NodeStagingDir() string {
+	if c.StagingDir != "" {
+		return c.StagingDir
+	}
+	return c.WorkDir
+}
+
+func (c *Config) validStagingDir(v *validator) {
+	if c.StagingDir != "" && !filepath.IsAbs(c.StagingDir) {
+		v.addf(ValidationCategoryDirectories, "stagingDir", "stagingDir must be an absolute path: %s", c.StagingDir)
+	}
+}
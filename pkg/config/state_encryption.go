@@ -0,0 +1,58 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// StateEncryptionKeySize is the size of keys encrypting state files, which are AES-256 keys.
+const StateEncryptionKeySize = 32
+
+// StateEncryption configures encryption of the cluster state and records of runs at rest.
+type StateEncryption struct {
+	// Key is the base64 encoded AES-256 key, e.g. generated by `openssl rand -base64 32`.
+	// It should be a reference of a secret, e.g. env://M3FS_STATE_KEY, file:///etc/m3fs/state.key
+	// or secret://vault/secret/data/m3fs#stateKey of a KMS.
+	Key string `yaml:"key,omitempty"`
+}
+
+// DecodeKey returns the key, it's nil if state files aren't encrypted.
+func (e StateEncryption) DecodeKey() ([]byte, error) {
+	if e.Key == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(e.Key))
+	if err != nil {
+		return nil, errors.Annotatef(err, "decode base64 key")
+	}
+	if len(key) != StateEncryptionKeySize {
+		return nil, errors.Errorf("key must be %d bytes, got %d bytes", StateEncryptionKeySize, len(key))
+	}
+	return key, nil
+}
+
+func (c *Config) validStateEncryption(v *validator) {
+	// the reference is resolved by ResolveSecrets
+	if IsSecretRef(c.StateEncryption.Key) {
+		return
+	}
+	if _, err := c.StateEncryption.DecodeKey(); err != nil {
+		v.addf(ValidationCategoryGeneral, "stateEncryption.key", "stateEncryption.key: %v", err)
+	}
+}
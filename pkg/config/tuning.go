@@ -0,0 +1,131 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// TuningProfile is the kernel tuning of nodes of a service.
+type TuningProfile struct {
+	// Sysctls maps sysctl keys like vm.max_map_count to values.
+	Sysctls map[string]string `yaml:"sysctls,omitempty"`
+	// Modules are kernel modules loaded on nodes and on boot.
+	Modules []string `yaml:"modules,omitempty"`
+}
+
+// IsEmpty returns whether the profile tunes nothing.
+func (p TuningProfile) IsEmpty() bool {
+	return len(p.Sysctls) == 0 && len(p.Modules) == 0
+}
+
+var (
+	sysctlKeyRegex    = regexp.MustCompile(`^[a-z0-9_-]+(\.[a-zA-Z0-9_-]+)+$`)
+	kernelModuleRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// validSysctlValue returns whether the value can be set by sysctl and written into a line
+// of sysctl.d, it must not be blank or contain control characters other than tabs.
+func validSysctlValue(value string) bool {
+	if strings.TrimSpace(value) == "" {
+		return false
+	}
+	return !strings.ContainsFunc(value, func(r rune) bool { return r != '\t' && unicode.IsControl(r) })
+}
+
+// NodeTuning returns the tuning of the node merged from profiles of services on it.
+// Sysctls of a service earlier in AllServiceTypes take precedence, conflicts of them
+// are rejected by validation.
+func (c *Config) NodeTuning(nodeName string) TuningProfile {
+	var tuning TuningProfile
+	for _, service := range slices.Backward(AllServiceTypes) {
+		profile, ok := c.Tuning[service]
+		if !ok || !slices.Contains(c.Services.ServiceNodes(service), nodeName) {
+			continue
+		}
+		if len(profile.Sysctls) > 0 && tuning.Sysctls == nil {
+			tuning.Sysctls = make(map[string]string)
+		}
+		maps.Copy(tuning.Sysctls, profile.Sysctls)
+		for _, module := range profile.Modules {
+			if !slices.Contains(tuning.Modules, module) {
+				tuning.Modules = append(tuning.Modules, module)
+			}
+		}
+	}
+	slices.Sort(tuning.Modules)
+	return tuning
+}
+
+func (c *Config) validTuning(v *validator) {
+	for _, service := range slices.Sorted(maps.Keys(c.Tuning)) {
+		key := fmt.Sprintf("tuning.%s", service)
+		if !slices.Contains(AllServiceTypes, service) {
+			v.addf(ValidationCategoryServices, key, "%s: invalid service %s", key, service)
+			continue
+		}
+		profile := c.Tuning[service]
+		for _, name := range slices.Sorted(maps.Keys(profile.Sysctls)) {
+			if !sysctlKeyRegex.MatchString(name) {
+				v.addf(ValidationCategoryServices, key+".sysctls", "%s.sysctls: invalid sysctl key %q", key, name)
+			}
+			if !validSysctlValue(profile.Sysctls[name]) {
+				v.addf(ValidationCategoryServices, key+".sysctls", "%s.sysctls: invalid value %q of sysctl %s",
+					key, profile.Sysctls[name], name)
+			}
+		}
+		for _, module := range profile.Modules {
+			if !kernelModuleRegex.MatchString(module) {
+				v.addf(ValidationCategoryServices, key+".modules", "%s.modules: invalid kernel module %q", key, module)
+			}
+		}
+	}
+
+	// sysctls of services sharing a node must agree
+	for _, node := range c.Nodes {
+		values := make(map[string]ServiceType)
+		for _, service := range AllServiceTypes {
+			profile, ok := c.Tuning[service]
+			if !ok || !slices.Contains(c.Services.ServiceNodes(service), node.Name) {
+				continue
+			}
+			for _, name := range slices.Sorted(maps.Keys(profile.Sysctls)) {
+				other, ok := values[name]
+				if ok && c.Tuning[other].Sysctls[name] != profile.Sysctls[name] {
+					v.addf(ValidationCategoryServices, fmt.Sprintf("tuning.%s.sysctls", service),
+						"sysctl %s of %s and %s conflict on node %s", name, other, service, node.Name)
+					continue
+				}
+				values[name] = service
+			}
+		}
+	}
+}
+
+// TunedNodes returns nodes having a non-empty tuning.
+func (c *Config) TunedNodes() []Node {
+	var nodes []Node
+	for _, node := range c.Nodes {
+		if !c.NodeTuning(node.Name).IsEmpty() {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// TaskMessageQuiet is the value of a task message which suppresses it.
+const TaskMessageQuiet = "quiet"
+
+// DefaultTaskStartMessage is the default template of the message logged when a task starts.
+const DefaultTaskStartMessage = "Running task {{.Name}}"
+
+// UIConfig holds UI related configurations
+type UIConfig struct {
+	TaskInfoColor string `yaml:"taskInfoColor,omitempty"`
+	// TaskStartMessage is the template of the message logged when a task starts, default
+	// is DefaultTaskStartMessage. It's suppressed if it's quiet.
+	TaskStartMessage string `yaml:"taskStartMessage,omitempty"`
+	// TaskEndMessage is the template of the message logged when a task ends, no message
+	// is logged if it's empty or quiet.
+	TaskEndMessage string `yaml:"taskEndMessage,omitempty"`
+}
+
+// TaskMessageFields are fields which templates of task messages are rendered with.
+type TaskMessageFields struct {
+	Name string
+	// Index is the 1-based index of the task in the run, Total is the number of tasks.
+	Index int
+	Total int
+	// Elapsed is how long the task has run, it's zero when the task starts.
+	Elapsed time.Duration
+	// Time is when the message is logged.
+	Time time.Time
+	// Failed is whether the task failed, it's only set when the task ends.
+	Failed bool
+}
+
+// TaskMessage is a parsed template of task messages.
+type TaskMessage struct {
+	tmpl *template.Template
+}
+
+// ParseTaskMessage parses the template of task messages, the default template is used if
+// text is empty. It returns nil if the message is suppressed.
+func ParseTaskMessage(text, defaultText string) (*TaskMessage, error) {
+	if text == "" {
+		text = defaultText
+	}
+	if text == "" || text == TaskMessageQuiet {
+		return nil, nil
+	}
+	tmpl, err := template.New("taskMessage").Parse(text)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	m := &TaskMessage{tmpl: tmpl}
+	// execute it once, so that unknown fields fail before the run
+	if _, err = m.Render(TaskMessageFields{Name: "task", Index: 1, Total: 1, Time: time.Now()}); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return m, nil
+}
+
+// Render renders the message with fields of a task.
+func (m *TaskMessage) Render(fields TaskMessageFields) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := m.tmpl.Execute(buf, fields); err != nil {
+		return "", errors.Trace(err)
+	}
+	return buf.String(), nil
+}
+
+// TaskMessages returns parsed templates of messages logged when a task starts and ends, nil
+// templates are suppressed.
+func (u *UIConfig) TaskMessages() (start, end *TaskMessage, err error) {
+	if start, err = ParseTaskMessage(u.TaskStartMessage, DefaultTaskStartMessage); err != nil {
+		return nil, nil, errors.Annotate(err, "ui.taskStartMessage")
+	}
+	if end, err = ParseTaskMessage(u.TaskEndMessage, ""); err != nil {
+		return nil, nil, errors.Annotate(err, "ui.taskEndMessage")
+	}
+	return start, end, nil
+}
+
+func (c *Config) validUI(v *validator) {
+	if _, err := ParseTaskMessage(c.UI.TaskStartMessage, DefaultTaskStartMessage); err != nil {
+		v.addf(ValidationCategoryGeneral, "ui.taskStartMessage", "invalid ui.taskStartMessage: %v", err)
+	}
+	if _, err := ParseTaskMessage(c.UI.TaskEndMessage, ""); err != nil {
+		v.addf(ValidationCategoryGeneral, "ui.taskEndMessage", "invalid ui.taskEndMessage: %v", err)
+	}
+}
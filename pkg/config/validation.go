@@ -0,0 +1,106 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationCategory is the category of a validation error of the config.
+type ValidationCategory string
+
+// defines validation categories, errors are grouped by categories in this order
+const (
+	ValidationCategoryGeneral     ValidationCategory = "general"
+	ValidationCategoryNodes       ValidationCategory = "nodes"
+	ValidationCategoryServices    ValidationCategory = "services"
+	ValidationCategoryPorts       ValidationCategory = "ports"
+	ValidationCategoryDirectories ValidationCategory = "directories"
+	ValidationCategoryImages      ValidationCategory = "images"
+)
+
+// ValidationCategories are all validation categories in order.
+var ValidationCategories = []ValidationCategory{
+	ValidationCategoryGeneral,
+	ValidationCategoryNodes,
+	ValidationCategoryServices,
+	ValidationCategoryPorts,
+	ValidationCategoryDirectories,
+	ValidationCategoryImages,
+}
+
+// ValidationError is an error of the config found by validation. Key is the path of
+// the invalid field in the config, like nodes[node1].host.
+type ValidationError struct {
+	Category ValidationCategory
+	Key      string
+	Message  string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors are all errors found by validating the config, validation doesn't
+// stop at the first error so that all of them can be fixed in one pass.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors in config: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+// Group returns errors of each category, categories without errors are omitted.
+func (errs ValidationErrors) Group() map[ValidationCategory]ValidationErrors {
+	groups := make(map[ValidationCategory]ValidationErrors)
+	for _, err := range errs {
+		groups[err.Category] = append(groups[err.Category], err)
+	}
+	return groups
+}
+
+// validator accumulates errors found by validating the config.
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) addf(category ValidationCategory, key, format string, a ...any) {
+	v.errs = append(v.errs, &ValidationError{
+		Category: category,
+		Key:      key,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// validPort validates the port is in range, 0 means the default port.
+func (v *validator) validPort(key string, port int) {
+	if port < 0 || port > 65535 {
+		v.addf(ValidationCategoryPorts, key, "%s is out of range: %d", key, port)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
@@ -0,0 +1,341 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package discovery discovers services deployed on nodes of an existing cluster by their
+// containers, so that a cluster which wasn't deployed by m3fs can be imported.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// inspectFormat is the format of inspecting a container, which prints what's discovered
+// from it as a json object.
+const inspectFormat = `{"status":{{json .State.Status}},"image":{{json .Config.Image}},` +
+	`"env":{{json .Config.Env}},"mounts":{{json .Mounts}}}`
+
+// layout is where m3fs places files of a service, which locates them in its container.
+type layout struct {
+	// dir is the directory of the service in the work dir.
+	dir string
+	// workDirMount is the target of the mount of a directory in the work dir, and
+	// workDirSource is the path of the directory in the work dir, so that the source of
+	// the mount locates the work dir on the node.
+	workDirMount  string
+	workDirSource string
+	// dataMount is the target of the mount of the data dir, dataDir is its source under
+	// the service dir.
+	dataMount string
+	dataDir   string
+	// configFile is the config file in the container which defines ports of the service.
+	configFile string
+}
+
+var layouts = map[config.ServiceType]layout{
+	config.ServiceFdb: {dir: "fdb", workDirMount: "/var/fdb/data", workDirSource: "fdb/data",
+		dataMount: "/var/fdb/data", dataDir: "data"},
+	config.ServiceClickhouse: {dir: "clickhouse", workDirMount: "/etc/clickhouse-server/config.d",
+		workDirSource: "clickhouse/config.d", dataMount: "/var/lib/clickhouse", dataDir: "data",
+		configFile: "/etc/clickhouse-server/config.d/config.xml"},
+	config.ServiceMonitor: {dir: "monitor", workDirMount: "/opt/3fs/etc", workDirSource: "monitor/etc",
+		configFile: "/opt/3fs/etc/monitor_collector_main.toml"},
+	config.ServiceMgmtd: {dir: "mgmtd", workDirMount: "/opt/3fs/etc", workDirSource: "mgmtd/config.d",
+		configFile: "/opt/3fs/etc/mgmtd_main.toml"},
+	config.ServiceMeta: {dir: "meta", workDirMount: "/opt/3fs/etc", workDirSource: "meta/config.d",
+		configFile: "/opt/3fs/etc/meta_main.toml"},
+	config.ServiceStorage: {dir: "storage", workDirMount: "/opt/3fs/etc", workDirSource: "storage/config.d",
+		dataMount: "/mnt/3fsdata", dataDir: "3fsdata", configFile: "/opt/3fs/etc/storage_main.toml"},
+	config.ServiceClient: {dir: "client", workDirMount: "/opt/3fs/etc", workDirSource: "client/config.d",
+		configFile: "/opt/3fs/etc/hf3fs_fuse_main_launcher.toml"},
+}
+
+// fdbClusterFileEnv is the env of the fdb container which contains the fdb cluster file.
+const fdbClusterFileEnv = "FDB_CLUSTER_FILE_CONTENTS"
+
+var (
+	listenPortRegexp  = regexp.MustCompile(`(?m)^\s*listen_port\s*=\s*(\d+)`)
+	tcpPortRegexp     = regexp.MustCompile(`<tcp_port>\s*(\d+)\s*</tcp_port>`)
+	mountpointRegexp  = regexp.MustCompile(`(?m)^\s*mountpoint\s*=\s*'([^']*)'`)
+	storageDiskRegexp = regexp.MustCompile(`^data\d+$`)
+)
+
+// Service is a service discovered on a node.
+type Service struct {
+	Service   config.ServiceType `json:"service"`
+	Container string             `json:"container"`
+	Status    string             `json:"status"`
+	Image     string             `json:"image"`
+	// Ports are ports the service listens on in the order of task.ServicePorts, they're
+	// unknown if the container isn't running.
+	Ports []int `json:"ports,omitempty"`
+	// WorkDir is the work dir of the node located by mounts of the container, it's empty
+	// if files of the service aren't placed by m3fs.
+	WorkDir string `json:"workDir,omitempty"`
+	// DataDir is the directory on the node mounted as the data dir of the service.
+	DataDir string `json:"dataDir,omitempty"`
+	// FdbClusterFile is the fdb cluster file of fdb.
+	FdbClusterFile string `json:"fdbClusterFile,omitempty"`
+	// HostMountpoint is the mountpoint of the client.
+	HostMountpoint string `json:"hostMountpoint,omitempty"`
+	// Disks is the number of data disks of storage.
+	Disks int `json:"disks,omitempty"`
+}
+
+// Running returns whether the container of the service is running.
+func (s *Service) Running() bool {
+	return s.Status == "running"
+}
+
+// Node is what's discovered on a node.
+type Node struct {
+	Node     string          `json:"node"`
+	Host     string          `json:"host"`
+	Facts    *task.NodeFacts `json:"facts"`
+	Services []*Service      `json:"services"`
+	// Err is the error which fails the discovery of the node.
+	Err error `json:"-"`
+}
+
+// Service returns the service discovered on the node, it's nil if it isn't deployed.
+func (n *Node) Service(service config.ServiceType) *Service {
+	for _, s := range n.Services {
+		if s.Service == service {
+			return s
+		}
+	}
+	return nil
+}
+
+// Discoverer discovers services deployed on nodes by containers named by containerName
+// of services of the config.
+type Discoverer struct {
+	runtime *task.Runtime
+
+	// Nodes are nodes which are probed, all nodes by default.
+	Nodes []config.Node
+	// Parallel is the number of nodes probed at the same time.
+	Parallel int
+
+	nodeManager         func(config.Node, log.Interface) (*external.Manager, error)
+	useContainerRuntime func(*external.Manager, config.ContainerRuntime) error
+}
+
+// NewDiscoverer creates a discoverer of the cluster of the runtime.
+func NewDiscoverer(r *task.Runtime) *Discoverer {
+	return &Discoverer{
+		runtime:             r,
+		Nodes:               r.Cfg.Nodes,
+		Parallel:            10,
+		nodeManager:         r.NodeManager,
+		useContainerRuntime: (*external.Manager).UseContainerRuntime,
+	}
+}
+
+// Discover probes nodes and returns what's discovered on them in the order of nodes.
+func (d *Discoverer) Discover(ctx context.Context) ([]*Node, error) {
+	var (
+		mu      sync.Mutex
+		results = make(map[string]*Node, len(d.Nodes))
+	)
+	workerPool := common.NewWorkerPool(func(ctx context.Context, node config.Node) error {
+		result := d.probeNode(ctx, node)
+		mu.Lock()
+		defer mu.Unlock()
+		results[node.Name] = result
+		return nil
+	}, max(1, min(d.Parallel, len(d.Nodes))))
+	workerPool.Start(ctx)
+	for _, node := range d.Nodes {
+		workerPool.Add(node)
+	}
+	workerPool.Join()
+	if ctx.Err() != nil {
+		return nil, errors.Trace(ctx.Err())
+	}
+
+	nodes := make([]*Node, 0, len(d.Nodes))
+	for _, node := range d.Nodes {
+		nodes = append(nodes, results[node.Name])
+	}
+	return nodes, nil
+}
+
+// probeNode gathers facts of the node and probes containers of all services on it.
+func (d *Discoverer) probeNode(ctx context.Context, node config.Node) *Node {
+	result := &Node{Node: node.Name, Host: node.Host}
+	logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+	em, err := d.nodeManager(node, logger)
+	if err == nil {
+		result.Facts, err = d.runtime.NodeFacts(ctx, em, node)
+	}
+	if err == nil {
+		err = d.useContainerRuntime(em, result.Facts.ContainerRuntime)
+	}
+	if err != nil {
+		result.Err = errors.Trace(err)
+		return result
+	}
+	for _, service := range config.AllServiceTypes {
+		s, err := probeService(ctx, em, node, service, d.runtime.Services.ContainerName(service))
+		if err != nil {
+			result.Err = errors.Annotatef(err, "probe %s", service)
+			return result
+		}
+		if s != nil {
+			logger.Debugf("Discovered %s container %s (%s) of %s", service, s.Container, s.Status, s.Image)
+			result.Services = append(result.Services, s)
+		}
+	}
+	return result
+}
+
+// containerInfo is the output of inspecting a container by inspectFormat.
+type containerInfo struct {
+	Status string   `json:"status"`
+	Image  string   `json:"image"`
+	Env    []string `json:"env"`
+	Mounts []struct {
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	} `json:"mounts"`
+}
+
+// mountSource returns the source of the mount of the target in the container.
+func (info *containerInfo) mountSource(target string) string {
+	for _, mount := range info.Mounts {
+		if path.Clean(mount.Destination) == target {
+			return path.Clean(mount.Source)
+		}
+	}
+	return ""
+}
+
+// probeService probes the container of the service on the node, it returns nil if the
+// container doesn't exist.
+func probeService(ctx context.Context, em *external.Manager, node config.Node, service config.ServiceType,
+	container string) (*Service, error) {
+
+	out, err := em.Docker.InspectContainer(ctx, container, inspectFormat)
+	if err != nil {
+		// the container doesn't exist, like checks of services by cluster doctor
+		return nil, nil
+	}
+	info := new(containerInfo)
+	if err = json.Unmarshal([]byte(out), info); err != nil {
+		return nil, errors.Annotatef(err, "parse output of inspecting container %s", container)
+	}
+	s := &Service{Service: service, Container: container, Status: info.Status, Image: info.Image}
+	l := layouts[service]
+	if workDir, ok := strings.CutSuffix(info.mountSource(l.workDirMount), "/"+l.workDirSource); ok {
+		s.WorkDir = workDir
+	}
+	if l.dataMount != "" {
+		s.DataDir = info.mountSource(l.dataMount)
+	}
+	if service == config.ServiceFdb {
+		for _, env := range info.Env {
+			if value, ok := strings.CutPrefix(env, fdbClusterFileEnv+"="); ok {
+				s.FdbClusterFile = value
+				s.Ports = fdbPorts(value, node.Host)
+			}
+		}
+	}
+	if !s.Running() || l.configFile == "" {
+		return s, nil
+	}
+
+	if out, err = em.Docker.Exec(ctx, container, "cat", l.configFile); err != nil {
+		return nil, errors.Annotatef(err, "read %s of container %s", l.configFile, container)
+	}
+	switch service {
+	case config.ServiceClickhouse:
+		s.Ports = matchPorts(tcpPortRegexp, out, 1)
+	case config.ServiceMonitor:
+		s.Ports = matchPorts(listenPortRegexp, out, 1)
+	case config.ServiceMgmtd, config.ServiceMeta, config.ServiceStorage:
+		// the rdma listener precedes the tcp listener
+		s.Ports = matchPorts(listenPortRegexp, out, 2)
+	case config.ServiceClient:
+		if match := mountpointRegexp.FindStringSubmatch(out); match != nil {
+			s.HostMountpoint = match[1]
+		}
+	}
+	if service == config.ServiceStorage {
+		if out, err = em.Docker.Exec(ctx, container, "ls", l.dataMount); err != nil {
+			return nil, errors.Annotatef(err, "list %s of container %s", l.dataMount, container)
+		}
+		for _, name := range strings.Fields(out) {
+			if storageDiskRegexp.MatchString(name) {
+				s.Disks++
+			}
+		}
+	}
+	return s, nil
+}
+
+// matchPorts returns the first n ports matched in the config file, it returns nil if
+// there're fewer ports.
+func matchPorts(re *regexp.Regexp, content string, n int) []int {
+	matches := re.FindAllStringSubmatch(content, n)
+	if len(matches) < n {
+		return nil
+	}
+	ports := make([]int, n)
+	for i, match := range matches {
+		ports[i], _ = strconv.Atoi(match[1])
+	}
+	return ports
+}
+
+// fdbPorts returns the port of fdb on the host in the fdb cluster file, which is like
+// desc:id@10.0.0.1:4500,10.0.0.2:4500.
+func fdbPorts(clusterFile, host string) []int {
+	_, addresses, ok := strings.Cut(strings.TrimSpace(clusterFile), "@")
+	if !ok {
+		return nil
+	}
+	var ports []int
+	for _, address := range strings.Split(addresses, ",") {
+		addrHost, portStr, ok := strings.Cut(strings.TrimSpace(address), ":")
+		if !ok {
+			continue
+		}
+		// addresses may have suffixes like :tls
+		portStr, _, _ = strings.Cut(portStr, ":")
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			continue
+		}
+		if addrHost == host {
+			return []int{port}
+		}
+		if ports == nil {
+			ports = []int{port}
+		}
+	}
+	return ports
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+	texternal "github.com/open3fs/m3fs/tests/external"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+var suiteRun = suite.Run
+
+func TestDiscovery(t *testing.T) {
+	suiteRun(t, &discoverySuite{})
+}
+
+type discoverySuite struct {
+	ttask.StepSuite
+
+	dockers map[string]*texternal.MockDocker
+}
+
+func (s *discoverySuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.WorkDir = "/opt/3fs"
+	s.Cfg.ContainerRuntime = config.ContainerRuntimeDocker
+	s.Cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.1", Username: "root"},
+		{Name: "node2", Host: "10.0.0.2", Username: "root"},
+	}
+	s.SetupRuntime()
+	s.dockers = map[string]*texternal.MockDocker{"node1": new(texternal.MockDocker), "node2": new(texternal.MockDocker)}
+	for _, node := range s.Cfg.Nodes {
+		s.Runtime.SetNodeFacts(&task.NodeFacts{Node: node.Name, Host: node.Host,
+			ContainerRuntime: config.ContainerRuntimeDocker})
+	}
+}
+
+func (s *discoverySuite) newDiscoverer() *Discoverer {
+	d := NewDiscoverer(s.Runtime)
+	d.nodeManager = func(node config.Node, _ log.Interface) (*external.Manager, error) {
+		return &external.Manager{Runner: s.MockRunner, Docker: s.dockers[node.Name]}, nil
+	}
+	d.useContainerRuntime = func(*external.Manager, config.ContainerRuntime) error {
+		return nil
+	}
+	return d
+}
+
+func (s *discoverySuite) mockContainer(node string, service config.ServiceType, status, image, mounts string,
+	env ...string) {
+
+	envJSON := "[]"
+	if len(env) > 0 {
+		envJSON = fmt.Sprintf(`[%q]`, env[0])
+	}
+	s.dockers[node].On("InspectContainer", s.Cfg.Services.ContainerName(service), inspectFormat).Return(
+		fmt.Sprintf(`{"status":%q,"image":%q,"env":%s,"mounts":%s}`, status, image, envJSON, mounts), nil)
+}
+
+func (s *discoverySuite) mockNoContainers(node string, services ...config.ServiceType) {
+	for _, service := range services {
+		s.dockers[node].On("InspectContainer", s.Cfg.Services.ContainerName(service), inspectFormat).Return(
+			"", errors.New("no such container"))
+	}
+}
+
+func (s *discoverySuite) TestDiscover() {
+	clusterFile := "test:abc@10.0.0.1:4500,10.0.0.2:4501"
+	s.mockContainer("node1", config.ServiceFdb, "running", "open3fs/foundationdb:7.3.63",
+		`[{"Source":"/opt/3fs/fdb/data","Destination":"/var/fdb/data"}]`, fdbClusterFileEnv+"="+clusterFile)
+	s.mockContainer("node1", config.ServiceMgmtd, "running", "open3fs/3fs:20250410",
+		`[{"Source":"/dev","Destination":"/dev"},{"Source":"/opt/3fs/mgmtd/config.d","Destination":"/opt/3fs/etc/"}]`)
+	s.dockers["node1"].On("Exec", "3fs-mgmtd", "cat", []string{"/opt/3fs/etc/mgmtd_main.toml"}).Return(
+		"[server.base.groups.listener]\nlisten_port = 8100\n[server.base.groups.listener]\nlisten_port = 9100\n", nil)
+	s.mockContainer("node1", config.ServiceClient, "running", "open3fs/3fs:20250410",
+		`[{"Source":"/opt/3fs/client/config.d","Destination":"/opt/3fs/etc"}]`)
+	s.dockers["node1"].On("Exec", "3fs-client", "cat", []string{"/opt/3fs/etc/hf3fs_fuse_main_launcher.toml"}).
+		Return("cluster_id = 'test'\nmountpoint = '/mnt/fs'\n", nil)
+	s.mockNoContainers("node1", config.ServiceStorage, config.ServiceMeta, config.ServiceMonitor,
+		config.ServiceClickhouse)
+	s.mockContainer("node2", config.ServiceFdb, "running", "open3fs/foundationdb:7.3.63",
+		`[{"Source":"/opt/3fs/fdb/data","Destination":"/var/fdb/data"}]`, fdbClusterFileEnv+"="+clusterFile)
+	s.mockContainer("node2", config.ServiceStorage, "running", "open3fs/3fs:20250410",
+		`[{"Source":"/opt/3fs/storage/config.d","Destination":"/opt/3fs/etc"},`+
+			`{"Source":"/data/3fs","Destination":"/mnt/3fsdata"}]`)
+	s.dockers["node2"].On("Exec", "3fs-storage", "cat", []string{"/opt/3fs/etc/storage_main.toml"}).Return(
+		"listen_port = 8002\nlisten_port = 9002\n", nil)
+	s.dockers["node2"].On("Exec", "3fs-storage", "ls", []string{"/mnt/3fsdata"}).Return("data0\ndata1\nlost+found\n", nil)
+	s.mockNoContainers("node2", config.ServiceMgmtd, config.ServiceMeta, config.ServiceMonitor,
+		config.ServiceClickhouse, config.ServiceClient)
+
+	nodes, err := s.newDiscoverer().Discover(s.Ctx())
+	s.NoError(err)
+	s.Len(nodes, 2)
+	s.NoError(nodes[0].Err)
+	s.Equal("node1", nodes[0].Node)
+	s.Equal(&Service{
+		Service:        config.ServiceFdb,
+		Container:      "3fs-fdb",
+		Status:         "running",
+		Image:          "open3fs/foundationdb:7.3.63",
+		Ports:          []int{4500},
+		WorkDir:        "/opt/3fs",
+		DataDir:        "/opt/3fs/fdb/data",
+		FdbClusterFile: clusterFile,
+	}, nodes[0].Service(config.ServiceFdb))
+	s.Equal([]int{8100, 9100}, nodes[0].Service(config.ServiceMgmtd).Ports)
+	s.Equal("/opt/3fs", nodes[0].Service(config.ServiceMgmtd).WorkDir)
+	s.Equal("/mnt/fs", nodes[0].Service(config.ServiceClient).HostMountpoint)
+	s.Nil(nodes[0].Service(config.ServiceStorage))
+
+	s.NoError(nodes[1].Err)
+	s.Equal([]int{4501}, nodes[1].Service(config.ServiceFdb).Ports)
+	storage := nodes[1].Service(config.ServiceStorage)
+	s.Equal([]int{8002, 9002}, storage.Ports)
+	s.Equal("/data/3fs", storage.DataDir)
+	s.Equal(2, storage.Disks)
+	s.Len(nodes[1].Services, 2)
+}
+
+func (s *discoverySuite) TestDiscoverStoppedContainer() {
+	s.mockContainer("node1", config.ServiceMeta, "exited", "open3fs/3fs:20250410",
+		`[{"Source":"/srv/meta/config","Destination":"/opt/3fs/etc"}]`)
+	s.mockNoContainers("node1", config.ServiceFdb, config.ServiceMgmtd, config.ServiceStorage, config.ServiceMonitor,
+		config.ServiceClickhouse, config.ServiceClient)
+	d := s.newDiscoverer()
+	d.Nodes = s.Cfg.Nodes[:1]
+
+	nodes, err := d.Discover(s.Ctx())
+	s.NoError(err)
+	s.NoError(nodes[0].Err)
+	meta := nodes[0].Service(config.ServiceMeta)
+	s.False(meta.Running())
+	// ports of a stopped container are unknown, and its files aren't placed by m3fs
+	s.Nil(meta.Ports)
+	s.Empty(meta.WorkDir)
+	s.dockers["node1"].AssertNotCalled(s.T(), "Exec")
+}
+
+func (s *discoverySuite) TestFdbPorts() {
+	s.Equal([]int{4500}, fdbPorts("desc:id@10.0.0.1:4500,10.0.0.2:4501\n", "10.0.0.1"))
+	s.Equal([]int{4501}, fdbPorts("desc:id@10.0.0.1:4500,10.0.0.2:4501:tls", "10.0.0.2"))
+	// the first port is used if the host isn't in the cluster file
+	s.Equal([]int{4500}, fdbPorts("desc:id@10.0.0.1:4500", "10.0.0.3"))
+	s.Nil(fdbPorts("invalid", "10.0.0.1"))
+}
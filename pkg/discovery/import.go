@@ -0,0 +1,306 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/mgmtd"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// images are names of images and their keys in the config.
+var images = []struct{ name, key string }{
+	{config.ImageName3FS, "images.3fs"},
+	{config.ImageNameFdb, "images.fdb"},
+	{config.ImageNameClickhouse, "images.clickhouse"},
+}
+
+// Discrepancy is a difference between discovered services and the config, or among
+// services of nodes, which the import can't resolve and needs manual input.
+type Discrepancy struct {
+	// Key is the dotted path of the config it's about.
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+func (d *Discrepancy) String() string {
+	return fmt.Sprintf("%s: %s", d.Key, d.Message)
+}
+
+// Result is the result of completing a config by services discovered on nodes.
+type Result struct {
+	// FdbClusterFile is the fdb cluster file shared by fdb of the cluster.
+	FdbClusterFile string
+	Discrepancies  []*Discrepancy
+}
+
+func (r *Result) addf(key, format string, a ...any) {
+	r.Discrepancies = append(r.Discrepancies, &Discrepancy{Key: key, Message: fmt.Sprintf(format, a...)})
+}
+
+// discovered are distinct values of a setting of services discovered on nodes, and nodes
+// each value is discovered on.
+type discovered struct {
+	values []string
+	nodes  map[string][]string
+}
+
+// collect collects values of services of nodes, empty values are skipped.
+func collect(nodes []*Node, services []config.ServiceType, value func(*Service) string) *discovered {
+	d := &discovered{nodes: make(map[string][]string)}
+	for _, node := range nodes {
+		for _, s := range node.Services {
+			v := value(s)
+			if v == "" || !slices.Contains(services, s.Service) {
+				continue
+			}
+			if !slices.Contains(d.values, v) {
+				d.values = append(d.values, v)
+			}
+			if !slices.Contains(d.nodes[v], node.Node) {
+				d.nodes[v] = append(d.nodes[v], node.Node)
+			}
+		}
+	}
+	return d
+}
+
+func (d *discovered) String() string {
+	parts := make([]string, len(d.values))
+	for i, v := range d.values {
+		parts[i] = fmt.Sprintf("%s on %s", v, strings.Join(d.nodes[v], ","))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// CompleteConfig completes the partial config by services discovered on nodes, settings
+// which are consistent across nodes are filled, others are reported as discrepancies.
+// Services whose nodes are set by the partial config keep them, configured is the
+// validated copy of the partial config whose node groups are expanded. The work dir is
+// discovered unless it's set by the partial config.
+func CompleteConfig(cfg, configured *config.Config, nodes []*Node) *Result {
+	result := new(Result)
+	for _, service := range config.AllServiceTypes {
+		result.completeService(cfg, configured, nodes, service)
+	}
+
+	for _, image := range images {
+		var services []config.ServiceType
+		for _, service := range config.AllServiceTypes {
+			if config.ServiceImageName(service) == image.name {
+				services = append(services, service)
+			}
+		}
+		discoveredImages := collect(nodes, services, func(s *Service) string { return s.Image })
+		if len(discoveredImages.values) > 1 {
+			result.addf(image.key, "services run different images: %s", discoveredImages)
+			continue
+		}
+		if len(discoveredImages.values) == 0 {
+			continue
+		}
+		img, ok := parseImage(discoveredImages.values[0], cfg.Images.Registry)
+		if !ok {
+			result.addf("images.registry", "image %s isn't in registry %s", discoveredImages.values[0],
+				cfg.Images.Registry)
+			continue
+		}
+		_ = cfg.Images.SetImage(image.name, img)
+	}
+
+	workDirs := collect(nodes, config.AllServiceTypes, func(s *Service) string { return s.WorkDir })
+	switch {
+	case len(workDirs.values) > 1:
+		result.addf("workDir", "services are placed in different work dirs: %s", workDirs)
+	case len(workDirs.values) == 0:
+	case cfg.WorkDir != "" && path.Clean(cfg.WorkDir) != workDirs.values[0]:
+		// the work dir is shared by the deploy host and nodes
+		result.addf("workDir", "work dir is %s but services are placed in %s", cfg.WorkDir, workDirs)
+	default:
+		cfg.WorkDir = workDirs.values[0]
+	}
+	return result
+}
+
+func (r *Result) completeService(cfg, configured *config.Config, nodes []*Node, service config.ServiceType) {
+	key := fmt.Sprintf("services.%s", service)
+	var (
+		found     []string
+		instances []*Service
+	)
+	for _, node := range nodes {
+		if s := node.Service(service); s != nil {
+			found = append(found, node.Node)
+			instances = append(instances, s)
+		}
+	}
+	switch {
+	case !cfg.Services.Enabled(service):
+		if len(found) > 0 {
+			r.addf(key+".enabled", "%s is disabled but discovered on nodes %s", service, strings.Join(found, ","))
+		}
+		return
+	case len(cfg.Services.ServiceNodes(service)) > 0 || len(cfg.Services.ServiceNodeGroups(service)) > 0:
+		expected := configured.Services.ServiceNodes(service)
+		if !slices.Equal(slices.Sorted(slices.Values(expected)), slices.Sorted(slices.Values(found))) {
+			r.addf(key+".nodes", "%s is configured on nodes %s but discovered on nodes %s", service,
+				strings.Join(expected, ","), strings.Join(found, ","))
+		}
+	case len(found) == 0:
+		cfg.Services.SetEnabled(service, false)
+		return
+	default:
+		for _, name := range found {
+			_ = cfg.Services.AddServiceNode(service, name)
+		}
+	}
+
+	l := layouts[service]
+	for i, s := range instances {
+		if !s.Running() {
+			r.addf(key, "%s container %s on node %s is %s, start it to discover its settings", service,
+				s.Container, found[i], s.Status)
+		}
+		if s.WorkDir == "" {
+			r.addf(key, "%s container %s on node %s isn't deployed by m3fs, %s isn't mounted from %s of a work dir",
+				service, s.Container, found[i], l.workDirMount, l.workDirSource)
+		} else if expected := path.Join(s.WorkDir, l.dir, l.dataDir); l.dataMount != "" && s.DataDir != expected {
+			r.addf(key, "data dir of %s on node %s is %s, but m3fs places it in %s", service, found[i],
+				s.DataDir, expected)
+		}
+	}
+
+	services := []config.ServiceType{service}
+	ports := collect(nodes, services, func(s *Service) string { return joinPorts(s.Ports) })
+	if len(ports.values) > 1 {
+		r.addf(key, "%s listens on different ports: %s", service, ports)
+	} else if len(ports.values) == 1 {
+		setServicePorts(&cfg.Services, service, splitPorts(ports.values[0]))
+	}
+	switch service {
+	case config.ServiceFdb:
+		clusterFiles := collect(nodes, services, func(s *Service) string { return s.FdbClusterFile })
+		if len(clusterFiles.values) > 1 {
+			r.addf(key, "fdb nodes have different cluster files: %s", clusterFiles)
+		} else if len(clusterFiles.values) == 1 {
+			r.FdbClusterFile = clusterFiles.values[0]
+		}
+	case config.ServiceClickhouse:
+		ck := &cfg.Services.Clickhouse
+		if len(found) > 1 && ck.ShardCount()*ck.ReplicaCount() == 1 {
+			r.addf(key+".shards", "clickhouse is discovered on %d nodes, set shards and replicas of its topology",
+				len(found))
+		}
+	case config.ServiceStorage:
+		disks := collect(nodes, services, func(s *Service) string {
+			if s.Disks == 0 {
+				return ""
+			}
+			return strconv.Itoa(s.Disks)
+		})
+		if len(disks.values) > 1 {
+			r.addf(key+".diskNumPerNode", "storage nodes have different numbers of disks: %s", disks)
+		} else if len(disks.values) == 1 {
+			cfg.Services.Storage.DiskNumPerNode, _ = strconv.Atoi(disks.values[0])
+		}
+	case config.ServiceClient:
+		mountpoints := collect(nodes, services, func(s *Service) string { return s.HostMountpoint })
+		if len(mountpoints.values) > 1 {
+			r.addf(key+".hostMountpoint", "clients are mounted at different mountpoints: %s", mountpoints)
+		} else if len(mountpoints.values) == 1 {
+			cfg.Services.Client.HostMountpoint = mountpoints.values[0]
+		}
+	}
+}
+
+func joinPorts(ports []int) string {
+	strs := make([]string, len(ports))
+	for i, port := range ports {
+		strs[i] = strconv.Itoa(port)
+	}
+	return strings.Join(strs, ",")
+}
+
+func splitPorts(s string) []int {
+	var ports []int
+	for _, str := range strings.Split(s, ",") {
+		port, _ := strconv.Atoi(str)
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// setServicePorts sets ports of the service in the order of task.ServicePorts.
+func setServicePorts(services *config.Services, service config.ServiceType, ports []int) {
+	switch service {
+	case config.ServiceFdb:
+		services.Fdb.Port = ports[0]
+	case config.ServiceClickhouse:
+		services.Clickhouse.TCPPort = ports[0]
+	case config.ServiceMonitor:
+		services.Monitor.Port = ports[0]
+	case config.ServiceMgmtd:
+		services.Mgmtd.RDMAListenPort, services.Mgmtd.TCPListenPort = ports[0], ports[1]
+	case config.ServiceMeta:
+		services.Meta.RDMAListenPort, services.Meta.TCPListenPort = ports[0], ports[1]
+	case config.ServiceStorage:
+		services.Storage.RDMAListenPort, services.Storage.TCPListenPort = ports[0], ports[1]
+	}
+}
+
+// parseImage parses the image of a container into the repo and the tag, the registry
+// of the config is stripped. It returns false if the image isn't in the registry.
+func parseImage(image, registry string) (config.Image, bool) {
+	if registry != "" {
+		prefix := strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+		var ok bool
+		if image, ok = strings.CutPrefix(image, strings.TrimSuffix(prefix, "/")+"/"); !ok {
+			return config.Image{}, false
+		}
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return config.Image{Repo: image, Tag: "latest"}, true
+	}
+	return config.Image{Repo: image[:i], Tag: image[i+1:]}, true
+}
+
+// NewClusterState creates the state of the imported cluster of the runtime, whose config
+// is completed by CompleteConfig. Images of services are recorded as discovered.
+func NewClusterState(r *task.Runtime, result *Result, nodes []*Node) (*task.ClusterState, error) {
+	if result.FdbClusterFile != "" {
+		r.Store(task.RuntimeFdbClusterFileContentKey, result.FdbClusterFile)
+	}
+	if len(r.Services.ServiceNodes(config.ServiceMgmtd)) > 0 {
+		r.Store(task.RuntimeMgmtdServerAddressesKey, mgmtd.ServerAddresses(r))
+	}
+	state, err := task.NewClusterState(r)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, node := range nodes {
+		for _, s := range node.Services {
+			state.SetNodeImage(node.Node, s.Service, s.Image)
+		}
+	}
+	return state, nil
+}
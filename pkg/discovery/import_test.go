@@ -0,0 +1,161 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/task"
+	"github.com/open3fs/m3fs/tests/base"
+)
+
+func TestImport(t *testing.T) {
+	suiteRun(t, &importSuite{})
+}
+
+type importSuite struct {
+	base.Suite
+}
+
+func (s *importSuite) newConfig() *config.Config {
+	cfg := config.NewConfigWithDefaults()
+	cfg.Name = "brownfield"
+	cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.1", Username: "root"},
+		{Name: "node2", Host: "10.0.0.2", Username: "root"},
+	}
+	return cfg
+}
+
+func (s *importSuite) newService(service config.ServiceType, image string, ports ...int) *Service {
+	l := layouts[service]
+	svc := &Service{Service: service, Container: "3fs-" + string(service), Status: "running", Image: image,
+		Ports: ports, WorkDir: "/opt/3fs"}
+	if l.dataMount != "" {
+		svc.DataDir = "/opt/3fs/" + l.dir + "/" + l.dataDir
+	}
+	return svc
+}
+
+func (s *importSuite) discoveredNodes() []*Node {
+	const img = "open3fs/3fs:20250410"
+	fdb := s.newService(config.ServiceFdb, "open3fs/foundationdb:7.3.63", 4600)
+	fdb.FdbClusterFile = "brownfield:abc@10.0.0.1:4600"
+	storage1 := s.newService(config.ServiceStorage, img, 8012, 9012)
+	storage1.Disks = 2
+	storage2 := s.newService(config.ServiceStorage, img, 8012, 9012)
+	storage2.Disks = 2
+	client := s.newService(config.ServiceClient, img)
+	client.HostMountpoint = "/mnt/brownfield"
+	return []*Node{
+		{Node: "node1", Host: "10.0.0.1", Services: []*Service{
+			fdb,
+			s.newService(config.ServiceMgmtd, img, 8010, 9010),
+			s.newService(config.ServiceMeta, img, 8011, 9011),
+			storage1,
+			client,
+		}},
+		{Node: "node2", Host: "10.0.0.2", Services: []*Service{storage2}},
+	}
+}
+
+func (s *importSuite) TestCompleteConfig() {
+	cfg := s.newConfig()
+	nodes := s.discoveredNodes()
+	result := CompleteConfig(cfg, s.newConfig(), nodes)
+
+	s.Empty(result.Discrepancies)
+	s.Equal("brownfield:abc@10.0.0.1:4600", result.FdbClusterFile)
+	s.Equal("/opt/3fs", cfg.WorkDir)
+	s.Equal([]string{"node1"}, cfg.Services.Fdb.Nodes)
+	s.Equal(4600, cfg.Services.Fdb.Port)
+	s.Equal([]string{"node1"}, cfg.Services.Mgmtd.Nodes)
+	s.Equal(8010, cfg.Services.Mgmtd.RDMAListenPort)
+	s.Equal(9010, cfg.Services.Mgmtd.TCPListenPort)
+	s.Equal(8011, cfg.Services.Meta.RDMAListenPort)
+	s.Equal([]string{"node1", "node2"}, cfg.Services.Storage.Nodes)
+	s.Equal(9012, cfg.Services.Storage.TCPListenPort)
+	s.Equal(2, cfg.Services.Storage.DiskNumPerNode)
+	s.Equal("/mnt/brownfield", cfg.Services.Client.HostMountpoint)
+	s.Equal(config.Image{Repo: "open3fs/3fs", Tag: "20250410"}, cfg.Images.FFFS)
+	s.Equal(config.Image{Repo: "open3fs/foundationdb", Tag: "7.3.63"}, cfg.Images.Fdb)
+	// services which aren't discovered are disabled
+	s.Equal([]config.ServiceType{config.ServiceMonitor, config.ServiceClickhouse}, cfg.Services.DisabledServices())
+	s.NoError(cfg.SetValidate("", ""))
+
+	r := &task.Runtime{Cfg: cfg, WorkDir: cfg.WorkDir, Services: &cfg.Services, MgmtdProtocol: "RDMA",
+		Nodes: map[string]config.Node{"node1": cfg.Nodes[0], "node2": cfg.Nodes[1]}}
+	nodes[1].Services[0].Image = "open3fs/3fs:20250101"
+	state, err := NewClusterState(r, result, nodes)
+	s.NoError(err)
+	s.Equal("brownfield", state.Cluster)
+	s.Equal("20250410", state.Version)
+	s.Equal("brownfield:abc@10.0.0.1:4600", state.FdbClusterFile)
+	s.Equal(`["RDMA://10.0.0.1:8010"]`, state.MgmtdServerAddresses)
+	s.Len(state.Nodes, 2)
+	s.Equal("open3fs/3fs:20250101", state.Nodes[1].Services[0].Image)
+	s.Equal([]int{8012, 9012}, state.Nodes[1].Services[0].Ports)
+}
+
+func (s *importSuite) TestDiscrepancies() {
+	cfg := s.newConfig()
+	cfg.WorkDir = "/data/m3fs"
+	cfg.Services.Mgmtd.Nodes = []string{"node2"}
+	configured := s.newConfig()
+	configured.Services.Mgmtd.Nodes = []string{"node2"}
+	nodes := s.discoveredNodes()
+	nodes[1].Services[0].Ports = []int{8002, 9002}
+	nodes[1].Services[0].Image = "open3fs/3fs:20250101"
+	nodes[1].Services[0].Status = "exited"
+	nodes[1].Services = append(nodes[1].Services, &Service{Service: config.ServiceFdb, Container: "3fs-fdb",
+		Status: "running", Image: "open3fs/foundationdb:7.3.63", WorkDir: "/opt/3fs", DataDir: "/data/fdb",
+		FdbClusterFile: "brownfield:def@10.0.0.2:4600", Ports: []int{4600}})
+
+	result := CompleteConfig(cfg, configured, nodes)
+	var discrepancies []string
+	for _, d := range result.Discrepancies {
+		discrepancies = append(discrepancies, d.String())
+	}
+	s.Equal([]string{
+		"services.storage: storage container 3fs-storage on node node2 is exited, " +
+			"start it to discover its settings",
+		"services.storage: storage listens on different ports: 8012,9012 on node1; 8002,9002 on node2",
+		"services.fdb: data dir of fdb on node node2 is /data/fdb, but m3fs places it in /opt/3fs/fdb/data",
+		"services.fdb: fdb nodes have different cluster files: brownfield:abc@10.0.0.1:4600 on node1; " +
+			"brownfield:def@10.0.0.2:4600 on node2",
+		"services.mgmtd.nodes: mgmtd is configured on nodes node2 but discovered on nodes node1",
+		"images.3fs: services run different images: open3fs/3fs:20250410 on node1; open3fs/3fs:20250101 on node2",
+		"workDir: work dir is /data/m3fs but services are placed in /opt/3fs on node1,node2",
+	}, discrepancies)
+	s.Empty(result.FdbClusterFile)
+	// nodes set by the partial config are kept
+	s.Equal([]string{"node2"}, cfg.Services.Mgmtd.Nodes)
+	s.Equal("/data/m3fs", cfg.WorkDir)
+}
+
+func (s *importSuite) TestParseImage() {
+	img, ok := parseImage("open3fs/3fs:20250410", "")
+	s.True(ok)
+	s.Equal(config.Image{Repo: "open3fs/3fs", Tag: "20250410"}, img)
+	img, ok = parseImage("harbor.local:5000/open3fs/3fs:20250410", "https://harbor.local:5000/")
+	s.True(ok)
+	s.Equal(config.Image{Repo: "open3fs/3fs", Tag: "20250410"}, img)
+	img, ok = parseImage("harbor.local:5000/open3fs/3fs", "")
+	s.True(ok)
+	s.Equal(config.Image{Repo: "harbor.local:5000/open3fs/3fs", Tag: "latest"}, img)
+	_, ok = parseImage("open3fs/3fs:20250410", "harbor.local")
+	s.False(ok)
+}
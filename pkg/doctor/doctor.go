@@ -0,0 +1,644 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	fsclient "github.com/open3fs/m3fs/pkg/3fs_client"
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/preflight"
+	"github.com/open3fs/m3fs/pkg/storage"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// Status is the status of a check, statuses are in the order of severity.
+type Status int
+
+// defines statuses of checks.
+const (
+	StatusPass Status = iota
+	StatusWarn
+	StatusFail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPass:
+		return "PASS"
+	case StatusWarn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// defines names of checks.
+const (
+	CheckConnectivity     = "connectivity"
+	CheckSudo             = preflight.CheckSudo
+	CheckContainerRuntime = preflight.CheckContainerRuntime
+	CheckClockSkew        = "clock-skew"
+	CheckDiskSpace        = "disk-space"
+	CheckService          = "service"
+	CheckVersionSkew      = "version-skew"
+	CheckMgmtdQuorum      = "mgmtd-quorum"
+	CheckFdbQuorum        = "fdb-quorum"
+	CheckConfigDrift      = "config-drift"
+	CheckStateDrift       = "state-drift"
+	CheckClientMount      = "client-mount"
+	CheckStorageCapacity  = "storage-capacity"
+)
+
+// Result is the result of a check.
+type Result struct {
+	Check string `json:"check"`
+	// Node is empty for checks of the whole cluster.
+	Node    string `json:"node,omitempty"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	// Fix is the suggested fix of the problem.
+	Fix string `json:"fix,omitempty"`
+}
+
+// Report is the report of all checks.
+type Report struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+func (r *Report) add(result Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+}
+
+// Results returns results in the order of severity, then check and node.
+func (r *Report) Results() []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := slices.Clone(r.results)
+	slices.SortStableFunc(results, func(a, b Result) int {
+		if a.Status != b.Status {
+			return int(b.Status - a.Status)
+		}
+		if a.Check != b.Check {
+			return strings.Compare(a.Check, b.Check)
+		}
+		return strings.Compare(a.Node, b.Node)
+	})
+	return results
+}
+
+// Count returns the number of checks in the status.
+func (r *Report) Count(status Status) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, result := range r.results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Verdict returns the most severe status of all checks.
+func (r *Report) Verdict() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	verdict := StatusPass
+	for _, result := range r.results {
+		verdict = max(verdict, result.Status)
+	}
+	return verdict
+}
+
+// defines thresholds of used space of the work dir.
+const (
+	diskUsageWarnPercent = 85
+	diskUsageFailPercent = 95
+)
+
+// Doctor checks connectivity, health and drift of a cluster, drift of the config and
+// of running containers from the recorded state of the cluster.
+type Doctor struct {
+	runtime *task.Runtime
+	report  *Report
+
+	// Fix makes the doctor remediate problems which are safe to fix, it only
+	// starts stopped service containers now.
+	Fix bool
+	// Parallel is the number of nodes checked at the same time.
+	Parallel int
+	// MaxClockSkew is the max allowed clock difference between nodes and the local node.
+	MaxClockSkew time.Duration
+
+	// state is the recorded state of the cluster, it's nil if no state is recorded.
+	state *task.ClusterState
+	// preflightChecks are preflight checks which still hold after deployment.
+	preflightChecks []preflight.Check
+
+	mu           sync.Mutex
+	running      map[config.ServiceType]int
+	skewed       bool
+	stateDrifted bool
+
+	nodeManager         func(config.Node, log.Interface) (*external.Manager, error)
+	useContainerRuntime func(*external.Manager, config.ContainerRuntime) error
+}
+
+// NewDoctor creates a doctor of the cluster of the runtime.
+func NewDoctor(r *task.Runtime) *Doctor {
+	return &Doctor{
+		runtime:             r,
+		Parallel:            10,
+		MaxClockSkew:        time.Second,
+		nodeManager:         r.NodeManager,
+		useContainerRuntime: (*external.Manager).UseContainerRuntime,
+	}
+}
+
+// Run runs all checks and returns the report.
+func (d *Doctor) Run(ctx context.Context) (*Report, error) {
+	d.report = new(Report)
+	d.running = make(map[config.ServiceType]int)
+	d.skewed = false
+	d.stateDrifted = false
+	d.preflightChecks = nil
+	for _, check := range preflight.Checks(d.runtime.Cfg) {
+		if check.Deployed {
+			d.preflightChecks = append(d.preflightChecks, check)
+		}
+	}
+
+	if err := d.checkConfigDrift(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	nodes := d.runtime.Cfg.Nodes
+	workerPool := common.NewWorkerPool(func(ctx context.Context, node config.Node) error {
+		d.checkNode(ctx, node)
+		return nil
+	}, max(1, min(d.Parallel, len(nodes))))
+	workerPool.Start(ctx)
+	for _, node := range nodes {
+		workerPool.Add(node)
+	}
+	workerPool.Join()
+	if ctx.Err() != nil {
+		return nil, errors.Trace(ctx.Err())
+	}
+
+	if !d.skewed {
+		d.report.add(Result{
+			Check:   CheckVersionSkew,
+			Status:  StatusPass,
+			Message: "running containers use images of the config",
+		})
+	}
+	if d.state != nil && !d.stateDrifted {
+		d.report.add(Result{
+			Check:   CheckStateDrift,
+			Status:  StatusPass,
+			Message: "running containers use images of the recorded state",
+		})
+	}
+	d.checkQuorum()
+
+	return d.report, nil
+}
+
+// checkConfigDrift compares the config to the recorded state of the cluster, the live
+// cluster is compared to the recorded state by checkRecordedImage.
+func (d *Doctor) checkConfigDrift() error {
+	cfg := d.runtime.Cfg
+	state, err := task.LoadClusterState(cfg.WorkDir, cfg.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	d.state = state
+	if state == nil {
+		d.report.add(Result{
+			Check:   CheckConfigDrift,
+			Status:  StatusWarn,
+			Message: "no recorded state of the cluster",
+			Fix:     "Create the cluster by `m3fs cluster create` which records its state",
+		})
+		return nil
+	}
+	diffs, err := state.Diverge(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if state.Version != "" && state.Version != cfg.Images.FFFS.Tag {
+		diffs = append(diffs, fmt.Sprintf("3fs version is %s in the config, but %s is deployed",
+			cfg.Images.FFFS.Tag, state.Version))
+	}
+	for _, diff := range diffs {
+		d.report.add(Result{
+			Check:   CheckConfigDrift,
+			Status:  StatusWarn,
+			Message: diff,
+			Fix:     "Make the config consistent with the deployed cluster",
+		})
+	}
+	if len(diffs) == 0 {
+		d.report.add(Result{
+			Check:   CheckConfigDrift,
+			Status:  StatusPass,
+			Message: "config matches the recorded state of the cluster",
+		})
+	}
+	return nil
+}
+
+func (d *Doctor) add(node config.Node, check string, status Status, message, fix string) {
+	d.report.add(Result{
+		Check:   check,
+		Node:    node.Name,
+		Status:  status,
+		Message: message,
+		Fix:     fix,
+	})
+}
+
+func (d *Doctor) checkNode(ctx context.Context, node config.Node) {
+	logger := log.Logger.Subscribe(log.FieldKeyNode, node.Name)
+	em, err := d.nodeManager(node, logger)
+	if err != nil {
+		d.add(node, CheckConnectivity, StatusFail, fmt.Sprintf("connect to %s: %v", node.Host, err),
+			"Check the node is up and the SSH account of it in the config")
+		return
+	}
+	before := time.Now()
+	out, err := em.Runner.NonSudoExec(ctx, "date", "+%s.%N")
+	after := time.Now()
+	if err != nil {
+		d.add(node, CheckConnectivity, StatusFail, fmt.Sprintf("run command: %v", err),
+			"Check the node is up and the SSH account of it in the config")
+		return
+	}
+	d.add(node, CheckConnectivity, StatusPass, "node is reachable", "")
+	d.checkClockSkew(node, out, before.Add(after.Sub(before)/2))
+
+	d.checkDiskSpace(ctx, em, node)
+	if capacity, ok := d.runtime.Cfg.StorageCapacities()[node.Name]; ok {
+		d.checkStorageCapacity(ctx, em, node, capacity)
+	}
+
+	if !d.checkPreflight(ctx, em, node, logger) {
+		return
+	}
+	runtime, err := d.runtime.ContainerRuntime(ctx, em, node)
+	if err == nil {
+		err = d.useContainerRuntime(em, runtime)
+	}
+	if err != nil {
+		d.add(node, CheckContainerRuntime, StatusFail, err.Error(),
+			"Install one of docker, containerd with nerdctl or podman")
+		return
+	}
+
+	for _, service := range config.AllServiceTypes {
+		if slices.Contains(d.runtime.Services.ServiceNodes(service), node.Name) {
+			d.checkService(ctx, em, node, runtime, service)
+		}
+	}
+	if slices.Contains(d.runtime.Services.ServiceNodes(config.ServiceClient), node.Name) {
+		d.checkClientMount(ctx, em, node, runtime)
+	}
+}
+
+// checkPreflight runs preflight checks of the node, which are reported by their names.
+// It returns false if the container runtime check fails, services can't be checked then.
+func (d *Doctor) checkPreflight(ctx context.Context, em *external.Manager, node config.Node,
+	logger log.Interface) bool {
+
+	runtimeFound := true
+	for _, check := range d.preflightChecks {
+		if !slices.ContainsFunc(check.Nodes, func(n config.Node) bool { return n.Name == node.Name }) {
+			continue
+		}
+		step := check.NewStep()
+		step.Init(d.runtime, em, node, logger)
+		if err := step.Execute(ctx); err != nil {
+			d.add(node, check.Name, StatusFail, err.Error(), check.Fix)
+			if check.Name == CheckContainerRuntime {
+				runtimeFound = false
+			}
+			continue
+		}
+		d.add(node, check.Name, StatusPass, "preflight check passed", "")
+	}
+	return runtimeFound
+}
+
+func (d *Doctor) checkClientMount(ctx context.Context, em *external.Manager, node config.Node,
+	runtime config.ContainerRuntime) {
+
+	mp := d.runtime.Services.Client.HostMountpoint
+	fsType, err := fsclient.MountedFsType(ctx, em, mp)
+	switch {
+	case err != nil:
+		d.add(node, CheckClientMount, StatusWarn, fmt.Sprintf("check mount of %s: %v", mp, err), "")
+	case fsType == "":
+		d.add(node, CheckClientMount, StatusFail, fmt.Sprintf("%s is not mounted", mp),
+			fmt.Sprintf("Check logs of the client container by `%s logs %s`",
+				external.ContainerRuntimeCmd(runtime), d.runtime.Services.Client.ContainerName))
+	case !fsclient.IsFuseFsType(fsType):
+		d.add(node, CheckClientMount, StatusFail, fmt.Sprintf("%s is mounted by %s instead of 3fs", mp, fsType),
+			fmt.Sprintf("Umount %s and restart the client container", mp))
+	default:
+		d.add(node, CheckClientMount, StatusPass, fmt.Sprintf("%s is mounted by %s", mp, fsType), "")
+	}
+}
+
+func (d *Doctor) checkClockSkew(node config.Node, out string, localTime time.Time) {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+	if err != nil {
+		d.add(node, CheckClockSkew, StatusWarn, fmt.Sprintf("parse time of node: %v", err), "")
+		return
+	}
+	sec, frac := math.Modf(seconds)
+	nodeTime := time.Unix(int64(sec), int64(frac*float64(time.Second)))
+	skew := nodeTime.Sub(localTime)
+	if skew.Abs() <= d.MaxClockSkew {
+		d.add(node, CheckClockSkew, StatusPass,
+			fmt.Sprintf("clock skew is %s", skew.Round(time.Millisecond)), "")
+		return
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	d.add(node, CheckClockSkew, StatusFail,
+		fmt.Sprintf("clock is %s %s the local node", skew.Abs().Round(time.Millisecond), direction),
+		"Synchronize clocks of nodes by NTP, e.g. chrony")
+}
+
+func (d *Doctor) checkDiskSpace(ctx context.Context, em *external.Manager, node config.Node) {
+	workDir := d.runtime.WorkDir
+	out, err := em.Runner.NonSudoExec(ctx, "df", "-P", "-k", workDir)
+	if err != nil {
+		d.add(node, CheckDiskSpace, StatusWarn, fmt.Sprintf("check disk space of %s: %v", workDir, err),
+			fmt.Sprintf("Create the work dir %s on the node", workDir))
+		return
+	}
+	usage, err := parseDfUsage(out)
+	if err != nil {
+		d.add(node, CheckDiskSpace, StatusWarn, err.Error(), "")
+		return
+	}
+	status := StatusPass
+	switch {
+	case usage >= diskUsageFailPercent:
+		status = StatusFail
+	case usage >= diskUsageWarnPercent:
+		status = StatusWarn
+	}
+	fix := ""
+	if status != StatusPass {
+		fix = fmt.Sprintf("Free up space of the filesystem of %s", workDir)
+	}
+	d.add(node, CheckDiskSpace, status, fmt.Sprintf("%d%% of the filesystem of %s is used", usage, workDir), fix)
+}
+
+// checkStorageCapacity reports the size and the usage of data disks of the storage node
+// along with its declared capacity.
+func (d *Doctor) checkStorageCapacity(ctx context.Context, em *external.Manager, node config.Node, capacity uint64) {
+	dataDir := storage.DataDir(d.runtime.WorkDir)
+	dirs := []string{dataDir}
+	if d.runtime.Services.Storage.DiskType == config.DiskTypeNvme {
+		dirs = nil
+		for i := 0; i < d.runtime.Services.Storage.DiskNumPerNode; i++ {
+			dirs = append(dirs, path.Join(dataDir, "data"+strconv.Itoa(i)))
+		}
+	}
+	out, err := em.Runner.NonSudoExec(ctx, "df", append([]string{"-P", "-k"}, dirs...)...)
+	if err != nil {
+		d.add(node, CheckStorageCapacity, StatusWarn, fmt.Sprintf("check size of data disks: %v", err), "")
+		return
+	}
+	size, used, err := parseDfSize(out)
+	if err != nil {
+		d.add(node, CheckStorageCapacity, StatusWarn, err.Error(), "")
+		return
+	}
+	usage := 0
+	if size > 0 {
+		usage = int(used * 100 / size)
+	}
+	message := fmt.Sprintf("declared capacity is %s, data disks hold %s of which %d%% is used",
+		common.FormatBytes(int64(capacity)), common.FormatBytes(int64(size)), usage)
+	status, fix := StatusPass, ""
+	switch {
+	case usage >= diskUsageFailPercent:
+		status, fix = StatusFail, "Add storage nodes or remove data of the file system"
+	case usage >= diskUsageWarnPercent:
+		status, fix = StatusWarn, "Add storage nodes or remove data of the file system"
+	case float64(capacity) > float64(size)*1.1:
+		status, fix = StatusWarn, "Set the capacity of the node to the size of its data disks"
+	}
+	d.add(node, CheckStorageCapacity, status, message, fix)
+}
+
+// parseDfSize parses the total size and used space in bytes of all filesystems from
+// the output of `df -P -k`.
+func parseDfSize(out string) (uint64, uint64, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return 0, 0, errors.Errorf("unexpected output of df: %s", out)
+	}
+	var size, used uint64
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return 0, 0, errors.Errorf("unexpected output of df: %s", out)
+		}
+		blocks, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, errors.Annotatef(err, "parse output of df: %s", out)
+		}
+		usedBlocks, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return 0, 0, errors.Annotatef(err, "parse output of df: %s", out)
+		}
+		size += blocks << 10
+		used += usedBlocks << 10
+	}
+	return size, used, nil
+}
+
+// parseDfUsage parses the used percentage from the output of `df -P`.
+func parseDfUsage(out string) (int, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return 0, errors.Errorf("unexpected output of df: %s", out)
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 5 {
+		return 0, errors.Errorf("unexpected output of df: %s", out)
+	}
+	usage, err := strconv.Atoi(strings.TrimSuffix(fields[4], "%"))
+	if err != nil {
+		return 0, errors.Annotatef(err, "parse output of df: %s", out)
+	}
+	return usage, nil
+}
+
+func (d *Doctor) checkService(
+	ctx context.Context, em *external.Manager, node config.Node, runtime config.ContainerRuntime,
+	service config.ServiceType) {
+
+	name := d.runtime.Services.ContainerName(service)
+	status, err := em.Docker.InspectContainer(ctx, name, "{{.State.Status}}")
+	if err != nil {
+		d.add(node, CheckService, StatusFail, fmt.Sprintf("%s container %s is not found", service, name),
+			"Deploy the service by `m3fs cluster create`")
+		return
+	}
+	if status != "running" {
+		if !d.Fix {
+			d.add(node, CheckService, StatusFail, fmt.Sprintf("%s container %s is %s", service, name, status),
+				"Start the container by `m3fs cluster doctor --fix`")
+			return
+		}
+		if _, err = em.Docker.Start(ctx, name); err != nil {
+			d.add(node, CheckService, StatusFail,
+				fmt.Sprintf("%s container %s is %s, failed to start it: %v", service, name, status, err),
+				fmt.Sprintf("Check logs of the container by `%s logs %s`",
+					external.ContainerRuntimeCmd(runtime), name))
+			return
+		}
+		d.add(node, CheckService, StatusWarn,
+			fmt.Sprintf("%s container %s was %s, it's started", service, name, status), "")
+	} else {
+		d.add(node, CheckService, StatusPass, fmt.Sprintf("%s container %s is running", service, name), "")
+	}
+
+	d.mu.Lock()
+	d.running[service]++
+	d.mu.Unlock()
+
+	image, err := em.Docker.InspectContainer(ctx, name, "{{.Config.Image}}")
+	if err != nil {
+		return
+	}
+	d.checkRecordedImage(node, service, name, image)
+	expected, err := d.runtime.Cfg.Images.GetImage(config.ServiceImageName(service))
+	if err != nil || image == expected {
+		return
+	}
+	d.mu.Lock()
+	d.skewed = true
+	d.mu.Unlock()
+	d.add(node, CheckVersionSkew, StatusWarn,
+		fmt.Sprintf("%s container %s runs %s, but %s is configured", service, name, image, expected),
+		"Upgrade the service by `m3fs cluster upgrade`")
+}
+
+// checkRecordedImage compares the image the container runs to the recorded image of the
+// service on the node, so that changes of the cluster made outside m3fs are found.
+func (d *Doctor) checkRecordedImage(node config.Node, service config.ServiceType, name, image string) {
+	if d.state == nil {
+		return
+	}
+	recorded := d.state.NodeImage(node.Name, service)
+	if recorded == "" || recorded == image {
+		return
+	}
+	d.mu.Lock()
+	d.stateDrifted = true
+	d.mu.Unlock()
+	d.add(node, CheckStateDrift, StatusWarn,
+		fmt.Sprintf("%s container %s runs %s, but %s is recorded", service, name, image, recorded),
+		"The container was changed outside m3fs, redeploy the service by `m3fs cluster upgrade`")
+}
+
+// checkQuorum checks quorums of mgmtd and foundationdb, a disabled service is skipped,
+// e.g. in a client only cluster.
+func (d *Doctor) checkQuorum() {
+	if d.runtime.Services.Enabled(config.ServiceMgmtd) {
+		d.checkMgmtdQuorum()
+	}
+	if d.runtime.Services.Enabled(config.ServiceFdb) {
+		d.checkFdbQuorum()
+	}
+}
+
+func (d *Doctor) checkMgmtdQuorum() {
+	mgmtdNum := len(d.runtime.Services.ServiceNodes(config.ServiceMgmtd))
+	running := d.running[config.ServiceMgmtd]
+	switch {
+	case running == 0:
+		d.report.add(Result{
+			Check:   CheckMgmtdQuorum,
+			Status:  StatusFail,
+			Message: "no mgmtd is running, the cluster is unavailable",
+			Fix:     "Start mgmtd containers by `m3fs cluster doctor --fix`",
+		})
+	case running < mgmtdNum:
+		d.report.add(Result{
+			Check:   CheckMgmtdQuorum,
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("%d of %d mgmtd are running, there's no standby for failover", running, mgmtdNum),
+			Fix:     "Start mgmtd containers by `m3fs cluster doctor --fix`",
+		})
+	default:
+		d.report.add(Result{
+			Check:   CheckMgmtdQuorum,
+			Status:  StatusPass,
+			Message: fmt.Sprintf("%d of %d mgmtd are running", running, mgmtdNum),
+		})
+	}
+}
+
+func (d *Doctor) checkFdbQuorum() {
+	fdbNum := len(d.runtime.Services.ServiceNodes(config.ServiceFdb))
+	running := d.running[config.ServiceFdb]
+	status := StatusPass
+	fix := ""
+	if running <= fdbNum/2 {
+		status = StatusFail
+		fix = "Start foundationdb containers by `m3fs cluster doctor --fix`"
+	} else if running < fdbNum {
+		status = StatusWarn
+		fix = "Start foundationdb containers by `m3fs cluster doctor --fix`"
+	}
+	d.report.add(Result{
+		Check:   CheckFdbQuorum,
+		Status:  status,
+		Message: fmt.Sprintf("%d of %d foundationdb are running", running, fdbNum),
+		Fix:     fix,
+	})
+}
@@ -0,0 +1,374 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+var suiteRun = suite.Run
+
+func TestDoctor(t *testing.T) {
+	suiteRun(t, &doctorSuite{})
+}
+
+type doctorSuite struct {
+	ttask.StepSuite
+
+	doctor *Doctor
+}
+
+func (s *doctorSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.WorkDir = s.T().TempDir()
+	s.Cfg.ContainerRuntime = config.ContainerRuntimeDocker
+	s.Cfg.Nodes = []config.Node{{Name: "node1", Host: "10.0.0.1", Username: "root"}}
+	for _, nodes := range []*[]string{
+		&s.Cfg.Services.Fdb.Nodes, &s.Cfg.Services.Clickhouse.Nodes, &s.Cfg.Services.Monitor.Nodes,
+		&s.Cfg.Services.Mgmtd.Nodes, &s.Cfg.Services.Meta.Nodes, &s.Cfg.Services.Storage.Nodes,
+	} {
+		*nodes = []string{"node1"}
+	}
+	s.SetupRuntime()
+
+	s.doctor = NewDoctor(s.Runtime)
+	s.doctor.nodeManager = func(config.Node, log.Interface) (*external.Manager, error) {
+		return s.MockEm, nil
+	}
+	s.doctor.useContainerRuntime = func(*external.Manager, config.ContainerRuntime) error {
+		return nil
+	}
+}
+
+func (s *doctorSuite) saveState() {
+	state, err := task.NewClusterState(s.Runtime)
+	s.NoError(err)
+	s.NoError(task.SaveClusterState(s.Cfg.WorkDir, state))
+}
+
+func (s *doctorSuite) mockNode(nodeTime time.Time, diskUsage string) {
+	s.MockRunner.On("NonSudoExec", "date", []string{"+%s.%N"}).
+		Return(fmt.Sprintf("%d.%09d\n", nodeTime.Unix(), nodeTime.Nanosecond()), nil)
+	s.MockRunner.On("Exec", "true", []string(nil)).Return("", nil)
+	// no firewall is active
+	s.MockRunner.On("Exec", mock.MatchedBy(func(cmd string) bool {
+		return slices.Contains([]string{"firewall-cmd", "ufw", "nft", "iptables"}, cmd)
+	}), mock.Anything).Return("", errors.New("command not found"))
+	s.MockRunner.On("NonSudoExec", "df", []string{"-P", "-k", s.Cfg.WorkDir}).Return(
+		"Filesystem 1024-blocks Used Available Capacity Mounted on\n"+
+			"/dev/sda1 100 50 50 "+diskUsage+" /\n", nil)
+}
+
+func (s *doctorSuite) mockServices(stoppedContainer string) {
+	for _, service := range []config.ServiceType{
+		config.ServiceStorage, config.ServiceFdb, config.ServiceMeta, config.ServiceMgmtd,
+		config.ServiceMonitor, config.ServiceClickhouse,
+	} {
+		name := s.Cfg.Services.ContainerName(service)
+		image, err := s.Cfg.Images.GetImage(config.ServiceImageName(service))
+		s.NoError(err)
+		status := "running"
+		if name == stoppedContainer {
+			status = "exited"
+		}
+		s.MockDocker.On("InspectContainer", name, "{{.State.Status}}").Return(status, nil)
+		s.MockDocker.On("InspectContainer", name, "{{.Config.Image}}").Return(image, nil)
+	}
+}
+
+func (s *doctorSuite) findResult(report *Report, check string) Result {
+	for _, result := range report.Results() {
+		if result.Check == check {
+			return result
+		}
+	}
+	s.FailNow("result not found", check)
+	return Result{}
+}
+
+func (s *doctorSuite) TestHealthy() {
+	s.saveState()
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("")
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusPass, report.Verdict(), report.Results())
+	s.Zero(report.Count(StatusWarn))
+}
+
+func (s *doctorSuite) TestWithoutState() {
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("")
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusWarn, report.Verdict())
+	s.Equal(StatusWarn, s.findResult(report, CheckConfigDrift).Status)
+}
+
+func (s *doctorSuite) TestWithPreflightFailed() {
+	s.saveState()
+	s.MockRunner.On("Exec", "true", []string(nil)).Return("", errors.New("sudo: a password is required")).Once()
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("")
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusFail, report.Verdict())
+	result := s.findResult(report, CheckSudo)
+	s.Equal(StatusFail, result.Status)
+	s.Contains(result.Message, "sudo: a password is required")
+	s.Equal("Grant sudo permission to the SSH user of the node", result.Fix)
+	s.Equal(StatusPass, s.findResult(report, CheckContainerRuntime).Status)
+}
+
+func (s *doctorSuite) TestWithStateDrift() {
+	state, err := task.NewClusterState(s.Runtime)
+	s.NoError(err)
+	state.SetNodeImage("node1", config.ServiceStorage, "open3fs/3fs:old")
+	s.NoError(task.SaveClusterState(s.Cfg.WorkDir, state))
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("")
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	// the container runs the image of the config, but not the recorded one
+	s.Equal(StatusPass, s.findResult(report, CheckVersionSkew).Status)
+	result := s.findResult(report, CheckStateDrift)
+	s.Equal(StatusWarn, result.Status)
+	s.Equal("node1", result.Node)
+	s.Contains(result.Message, "storage container 3fs-storage runs ")
+	s.Contains(result.Message, ", but open3fs/3fs:old is recorded")
+}
+
+func (s *doctorSuite) TestWithStoppedContainer() {
+	s.saveState()
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("3fs-mgmtd")
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusFail, report.Verdict())
+	results := report.Results()
+	s.Equal(StatusFail, results[0].Status)
+	s.Equal(CheckMgmtdQuorum, results[0].Check)
+	s.Equal(CheckService, results[1].Check)
+	s.Equal("mgmtd container 3fs-mgmtd is exited", results[1].Message)
+	s.MockDocker.AssertNotCalled(s.T(), "Start", mock.Anything)
+}
+
+func (s *doctorSuite) TestFixStoppedContainer() {
+	s.saveState()
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("3fs-mgmtd")
+	s.MockDocker.On("Start", "3fs-mgmtd").Return("3fs-mgmtd", nil)
+	s.doctor.Fix = true
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusWarn, report.Verdict())
+	s.Equal("mgmtd container 3fs-mgmtd was exited, it's started", s.findResult(report, CheckService).Message)
+	s.Equal(StatusPass, s.findResult(report, CheckMgmtdQuorum).Status)
+	s.MockDocker.AssertExpectations(s.T())
+}
+
+func (s *doctorSuite) TestFixStoppedContainerFailedWithPodman() {
+	s.Cfg.ContainerRuntime = config.ContainerRuntimePodman
+	s.saveState()
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("3fs-mgmtd")
+	s.MockDocker.On("Start", "3fs-mgmtd").Return("", errors.New("no such container"))
+	s.doctor.Fix = true
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusFail, report.Verdict())
+	s.Equal("Check logs of the container by `podman logs 3fs-mgmtd`", s.findResult(report, CheckService).Fix)
+}
+
+func (s *doctorSuite) TestClientMount() {
+	s.saveState()
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("")
+	s.Runtime.Services.Client.Nodes = []string{"node1"}
+	s.MockDocker.On("InspectContainer", "3fs-client", "{{.State.Status}}").Return("running", nil)
+	image, err := s.Cfg.Images.GetImage(config.ImageName3FS)
+	s.NoError(err)
+	s.MockDocker.On("InspectContainer", "3fs-client", "{{.Config.Image}}").Return(image, nil)
+	mountsCall := s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).
+		Return("/dev/sda1 / ext4 rw 0 0\nhf3fs.test /mnt/3fs fuse.hf3fs rw 0 0\n", nil)
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+	s.Equal(StatusPass, s.findResult(report, CheckClientMount).Status)
+	s.Equal("/mnt/3fs is mounted by fuse.hf3fs", s.findResult(report, CheckClientMount).Message)
+
+	mountsCall.Unset()
+	s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).Return("/dev/sda1 / ext4 rw 0 0\n", nil)
+	report, err = s.doctor.Run(s.Ctx())
+	s.NoError(err)
+	s.Equal(StatusFail, report.Verdict())
+	s.Equal("/mnt/3fs is not mounted", s.findResult(report, CheckClientMount).Message)
+	s.Equal("Check logs of the client container by `docker logs 3fs-client`",
+		s.findResult(report, CheckClientMount).Fix)
+}
+
+func (s *doctorSuite) TestClientOnly() {
+	for _, service := range []config.ServiceType{
+		config.ServiceFdb, config.ServiceClickhouse, config.ServiceMonitor,
+		config.ServiceMgmtd, config.ServiceMeta, config.ServiceStorage,
+	} {
+		s.Runtime.Services.SetEnabled(service, false)
+	}
+	s.Runtime.Services.Client.Nodes = []string{"node1"}
+	s.Runtime.Services.Client.MgmtdEndpoints = []string{"RDMA://10.0.0.9:8000"}
+	s.saveState()
+	s.mockNode(time.Now(), "50%")
+	s.MockDocker.On("InspectContainer", "3fs-client", "{{.State.Status}}").Return("running", nil)
+	image, err := s.Cfg.Images.GetImage(config.ImageName3FS)
+	s.NoError(err)
+	s.MockDocker.On("InspectContainer", "3fs-client", "{{.Config.Image}}").Return(image, nil)
+	s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).
+		Return("hf3fs.test /mnt/3fs fuse.hf3fs rw 0 0\n", nil)
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusPass, report.Verdict(), report.Results())
+	for _, result := range report.Results() {
+		s.NotContains([]string{CheckMgmtdQuorum, CheckFdbQuorum}, result.Check)
+	}
+}
+
+func (s *doctorSuite) TestDisabledClientMountSkipped() {
+	s.saveState()
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("")
+	s.Runtime.Services.Client.Nodes = []string{"node1"}
+	s.Runtime.Services.SetEnabled(config.ServiceClient, false)
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	for _, result := range report.Results() {
+		s.NotEqual(CheckClientMount, result.Check)
+	}
+	s.MockRunner.AssertNotCalled(s.T(), "Exec", "cat", []string{"/proc/mounts"})
+}
+
+func (s *doctorSuite) TestWithClockSkewAndFullDisk() {
+	s.saveState()
+	s.mockNode(time.Now().Add(-time.Minute), "96%")
+	s.mockServices("")
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusFail, report.Verdict())
+	s.Contains(s.findResult(report, CheckClockSkew).Message, "behind the local node")
+	s.Equal(StatusFail, s.findResult(report, CheckClockSkew).Status)
+	s.Equal(StatusFail, s.findResult(report, CheckDiskSpace).Status)
+}
+
+func (s *doctorSuite) TestStorageCapacity() {
+	s.Cfg.Nodes[0].Capacity = "2t"
+	s.Cfg.Services.Storage.DiskType = config.DiskTypeNvme
+	s.Cfg.Services.Storage.DiskNumPerNode = 2
+	s.SetupRuntime()
+	s.doctor.runtime = s.Runtime
+	s.saveState()
+	s.mockNode(time.Now(), "50%")
+	s.mockServices("")
+	dataDir := path.Join(s.Cfg.WorkDir, "storage", "3fsdata")
+	s.MockRunner.On("NonSudoExec", "df",
+		[]string{"-P", "-k", path.Join(dataDir, "data0"), path.Join(dataDir, "data1")}).Return(
+		"Filesystem 1024-blocks Used Available Capacity Mounted on\n"+
+			"/dev/nvme0n1 1073741824 943718400 130023424 88% "+path.Join(dataDir, "data0")+"\n"+
+			"/dev/nvme1n1 1073741824 943718400 130023424 88% "+path.Join(dataDir, "data1")+"\n", nil)
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	result := s.findResult(report, CheckStorageCapacity)
+	s.Equal(StatusWarn, result.Status)
+	s.Equal("declared capacity is 2.0 TiB, data disks hold 2.0 TiB of which 87% is used", result.Message)
+}
+
+func (s *doctorSuite) TestWithUnreachableNode() {
+	s.saveState()
+	s.doctor.nodeManager = func(config.Node, log.Interface) (*external.Manager, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	report, err := s.doctor.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal(StatusFail, s.findResult(report, CheckConnectivity).Status)
+	s.Equal(StatusFail, s.findResult(report, CheckMgmtdQuorum).Status)
+	s.Equal(StatusFail, s.findResult(report, CheckFdbQuorum).Status)
+}
+
+func TestParseDfUsage(t *testing.T) {
+	suiteRun(t, &parseDfUsageSuite{})
+}
+
+type parseDfUsageSuite struct {
+	suite.Suite
+}
+
+func (s *parseDfUsageSuite) Test() {
+	usage, err := parseDfUsage("Filesystem 1024-blocks Used Available Capacity Mounted on\n" +
+		"/dev/sda1 100 85 15 85% /\n")
+	s.NoError(err)
+	s.Equal(85, usage)
+
+	_, err = parseDfUsage("df: /opt/3fs: No such file or directory")
+	s.Error(err)
+}
+
+func (s *parseDfUsageSuite) TestSize() {
+	size, used, err := parseDfSize("Filesystem 1024-blocks Used Available Capacity Mounted on\n" +
+		"/dev/nvme0n1 100 85 15 85% /data0\n" +
+		"/dev/nvme1n1 200 15 185 8% /data1\n")
+	s.NoError(err)
+	s.Equal(uint64(300<<10), size)
+	s.Equal(uint64(100<<10), used)
+
+	_, _, err = parseDfSize("df: /opt/3fs: No such file or directory")
+	s.Error(err)
+}
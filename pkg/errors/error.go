@@ -254,3 +254,9 @@ func StackTrace(err error) string {
 func Is(err, target error) bool {
 	return errors.Is(err, target)
 }
+
+// As finds the first error in err's tree that matches target, and if one is found,
+// sets target to that error value and returns true.
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// InFlightCommand is a command being run on a node.
+type InFlightCommand struct {
+	Host    string
+	Command string
+	Start   time.Time
+}
+
+// Activity tracks the time of the last activity of a task and commands being run
+// by it, e.g. to detect the task making no progress. It's safe for concurrent use.
+type Activity struct {
+	mu       sync.Mutex
+	last     time.Time
+	nextID   int
+	inFlight map[int]*InFlightCommand
+}
+
+// NewActivity creates an activity tracker, the creation is the first activity.
+func NewActivity() *Activity {
+	return &Activity{
+		last:     time.Now(),
+		inFlight: make(map[int]*InFlightCommand),
+	}
+}
+
+// Touch records an activity.
+func (a *Activity) Touch() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.last = time.Now()
+}
+
+// Last returns the time of the last activity.
+func (a *Activity) Last() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.last
+}
+
+// InFlight returns commands being run, the longest running one first.
+func (a *Activity) InFlight() []InFlightCommand {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	commands := make([]InFlightCommand, 0, len(a.inFlight))
+	for _, command := range a.inFlight {
+		commands = append(commands, *command)
+	}
+	slices.SortFunc(commands, func(a, b InFlightCommand) int {
+		return a.Start.Compare(b.Start)
+	})
+	return commands
+}
+
+// startCommand records the start of the command, the returned function records
+// the end of it. Both are activities.
+func (a *Activity) startCommand(host, command string) func() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.last = time.Now()
+	id := a.nextID
+	a.nextID++
+	a.inFlight[id] = &InFlightCommand{Host: host, Command: Redact(command), Start: a.last}
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.last = time.Now()
+		delete(a.inFlight, id)
+	}
+}
+
+type activityKey struct{}
+
+// WithActivity returns the context whose commands and steps are recorded as
+// activities of the tracker.
+func WithActivity(ctx context.Context, activity *Activity) context.Context {
+	return context.WithValue(ctx, activityKey{}, activity)
+}
+
+// TouchActivity records an activity to the tracker of the context if any.
+func TouchActivity(ctx context.Context) {
+	if activity, ok := ctx.Value(activityKey{}).(*Activity); ok {
+		activity.Touch()
+	}
+}
+
+// trackCommand records the command run on the host as in flight to the tracker
+// of the context if any, the returned function must be called when it ends.
+func trackCommand(ctx context.Context, host, command string) func() {
+	activity, ok := ctx.Value(activityKey{}).(*Activity)
+	if !ok {
+		return func() {}
+	}
+	return activity.startCommand(host, command)
+}
@@ -17,6 +17,7 @@ package external_test
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 )
@@ -247,6 +248,15 @@ func (mr *MockedRunner) Exec(ctx context.Context, command string, args ...string
 	return "", fmt.Errorf("Unknown cmd: %s", cmdLine)
 }
 
+// Stream writes the output of the mocked command into w.
+func (mr *MockedRunner) Stream(ctx context.Context, w io.Writer, command string, args ...string) error {
+	out, err := mr.Exec(ctx, command, args...)
+	if _, writeErr := io.WriteString(w, out); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
 // Add add mocked command prefix
 func (mr *MockedRunner) AddScp(local, remote string, returnError error,
 	checkFunc ScpCheckFunc, times ...int) {
@@ -0,0 +1,140 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// UnreachableError is the error of a node which can't be connected, or whose connection
+// is lost while running a command. It's usually transient, e.g. a network blip.
+type UnreachableError struct {
+	Host string
+	Err  error
+}
+
+func (e *UnreachableError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *UnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// newUnreachableError returns the error of the host being unreachable.
+func newUnreachableError(host string, err error) error {
+	return &UnreachableError{Host: host, Err: err}
+}
+
+// IsUnreachable returns whether the error is caused by an unreachable node, errors
+// of commands failing on a reachable node aren't.
+func IsUnreachable(err error) bool {
+	for err != nil {
+		var unreachable *UnreachableError
+		if errors.As(err, &unreachable) {
+			return true
+		}
+		underlying, ok := err.(errors.Underlying)
+		if !ok {
+			return false
+		}
+		err = underlying.Underlie()
+	}
+	return false
+}
+
+// ConnectFailure is the reason of failing to connect to a node.
+type ConnectFailure string
+
+// defines reasons of failing to connect to a node
+const (
+	ConnectFailureNone           ConnectFailure = ""
+	ConnectFailureDNS            ConnectFailure = "dns"
+	ConnectFailureRefused        ConnectFailure = "connection refused"
+	ConnectFailureTimeout        ConnectFailure = "timeout"
+	ConnectFailureUnreachable    ConnectFailure = "unreachable"
+	ConnectFailureReset          ConnectFailure = "connection reset"
+	ConnectFailureAuth           ConnectFailure = "auth failed"
+	ConnectFailureHostKeyChanged ConnectFailure = "host key mismatch"
+	ConnectFailureHostKeyUnknown ConnectFailure = "host key unknown"
+	ConnectFailureOther          ConnectFailure = "error"
+)
+
+// Reachable returns whether the node was reached despite the failure, i.e. the
+// failure happened after the TCP connection was established.
+func (f ConnectFailure) Reachable() bool {
+	switch f {
+	case ConnectFailureDNS, ConnectFailureRefused, ConnectFailureTimeout, ConnectFailureUnreachable:
+		return false
+	}
+	return true
+}
+
+// ClassifyConnectError returns the reason of the error of connecting to a node and
+// running a command on it.
+func ClassifyConnectError(err error) ConnectFailure {
+	if err == nil {
+		return ConnectFailureNone
+	}
+	for cause := err; cause != nil; {
+		var dnsErr *net.DNSError
+		if errors.As(cause, &dnsErr) {
+			return ConnectFailureDNS
+		}
+		if errors.Is(cause, syscall.ECONNREFUSED) {
+			return ConnectFailureRefused
+		}
+		if errors.Is(cause, syscall.ECONNRESET) {
+			return ConnectFailureReset
+		}
+		var netErr net.Error
+		if errors.As(cause, &netErr) && netErr.Timeout() {
+			return ConnectFailureTimeout
+		}
+		underlying, ok := cause.(errors.Underlying)
+		if !ok {
+			break
+		}
+		cause = underlying.Underlie()
+	}
+
+	// errors of the SSH handshake and host key checks are only known by messages
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "unable to authenticate"):
+		return ConnectFailureAuth
+	case strings.Contains(msg, "HAS CHANGED"):
+		return ConnectFailureHostKeyChanged
+	case strings.Contains(msg, "is unknown, add it to"), strings.Contains(msg, "it must contain host keys"):
+		return ConnectFailureHostKeyUnknown
+	case strings.Contains(msg, "no such host"):
+		return ConnectFailureDNS
+	case strings.Contains(msg, "connection refused"):
+		return ConnectFailureRefused
+	case strings.Contains(msg, "connection reset"):
+		return ConnectFailureReset
+	case strings.Contains(msg, "timed out"), strings.Contains(msg, "i/o timeout"),
+		strings.Contains(msg, "failed to connect to"):
+		return ConnectFailureTimeout
+	case IsUnreachable(err):
+		return ConnectFailureUnreachable
+	}
+	return ConnectFailureOther
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+// containerRuntimeCmds maps container runtimes to their docker compatible command line tools.
+var containerRuntimeCmds = map[config.ContainerRuntime]string{
+	config.ContainerRuntimeDocker:     "docker",
+	config.ContainerRuntimeContainerd: "nerdctl",
+	config.ContainerRuntimePodman:     "podman",
+}
+
+// ContainerRuntimeCmd returns the command line tool of the container runtime.
+func ContainerRuntimeCmd(runtime config.ContainerRuntime) string {
+	return containerRuntimeCmds[runtime]
+}
+
+// nerdctlExternal operates containerd with nerdctl.
+type nerdctlExternal struct {
+	dockerExternal
+}
+
+func (ne *nerdctlExternal) init(em *Manager, logger log.Interface) {
+	ne.externalBase.init(em, logger)
+	ne.cmd = containerRuntimeCmds[config.ContainerRuntimeContainerd]
+	em.Docker = ne
+}
+
+// podmanExternal operates podman.
+type podmanExternal struct {
+	dockerExternal
+}
+
+func (pe *podmanExternal) init(em *Manager, logger log.Interface) {
+	pe.externalBase.init(em, logger)
+	pe.cmd = containerRuntimeCmds[config.ContainerRuntimePodman]
+	em.Docker = pe
+}
+
+func (pe *podmanExternal) ImageID(ctx context.Context, image string) (string, error) {
+	id, err := pe.dockerExternal.ImageID(ctx, image)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	// podman prints the image id without the digest algorithm
+	if !strings.Contains(id, ":") {
+		id = "sha256:" + id
+	}
+	return id, nil
+}
+
+// UseContainerRuntime makes the container operations of the manager use the container runtime.
+func (em *Manager) UseContainerRuntime(runtime config.ContainerRuntime) error {
+	var ext externalInterface
+	switch runtime {
+	case config.ContainerRuntimeDocker:
+		ext = new(dockerExternal)
+	case config.ContainerRuntimeContainerd:
+		ext = new(nerdctlExternal)
+	case config.ContainerRuntimePodman:
+		ext = new(podmanExternal)
+	default:
+		return errors.Errorf("unsupported container runtime: %s", runtime)
+	}
+	ext.init(em, em.logger)
+	return nil
+}
+
+// DetectContainerRuntime detects the container runtime installed on the node of the manager.
+func DetectContainerRuntime(ctx context.Context, em *Manager) (config.ContainerRuntime, error) {
+	for _, runtime := range config.ContainerRuntimes {
+		_, err := em.Runner.Exec(ctx, "sh", "-c", fmt.Sprintf("'command -v %s'", containerRuntimeCmds[runtime]))
+		if err == nil {
+			return runtime, nil
+		}
+	}
+	return "", errors.New("no container runtime found, one of docker, nerdctl and podman is required")
+}
+
+// UseDetectedContainerRuntime makes the container operations of the manager use the
+// container runtime returned by detect, which is called at the first container operation.
+// So the manager works on nodes without any container runtime until containers are
+// operated, e.g. by preflight checks and preparation of nodes.
+func (em *Manager) UseDetectedContainerRuntime(detect func(context.Context) (config.ContainerRuntime, error)) {
+	em.Docker = &lazyContainerRuntime{em: em, detect: detect}
+}
+
+// lazyContainerRuntime detects the container runtime of the manager at the first container
+// operation, and passes operations on to the external of the detected runtime.
+type lazyContainerRuntime struct {
+	em     *Manager
+	detect func(context.Context) (config.ContainerRuntime, error)
+
+	mu       sync.Mutex
+	resolved DockerInterface
+}
+
+func (l *lazyContainerRuntime) get(ctx context.Context) (DockerInterface, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.resolved != nil {
+		return l.resolved, nil
+	}
+	runtime, err := l.detect(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = l.em.UseContainerRuntime(runtime); err != nil {
+		return nil, errors.Trace(err)
+	}
+	l.resolved = l.em.Docker
+	return l.resolved, nil
+}
+
+func (l *lazyContainerRuntime) GetContainer(string) string {
+	return ""
+}
+
+func (l *lazyContainerRuntime) Run(ctx context.Context, args *RunArgs) (string, error) {
+	d, err := l.get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return d.Run(ctx, args)
+}
+
+func (l *lazyContainerRuntime) Rm(ctx context.Context, name string, force bool) (string, error) {
+	d, err := l.get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return d.Rm(ctx, name, force)
+}
+
+func (l *lazyContainerRuntime) Exec(ctx context.Context, container, cmd string, args ...string) (string, error) {
+	d, err := l.get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return d.Exec(ctx, container, cmd, args...)
+}
+
+func (l *lazyContainerRuntime) Load(ctx context.Context, path string) (string, error) {
+	d, err := l.get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return d.Load(ctx, path)
+}
+
+func (l *lazyContainerRuntime) Tag(ctx context.Context, src, dst string) error {
+	d, err := l.get(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return d.Tag(ctx, src, dst)
+}
+
+func (l *lazyContainerRuntime) ImageID(ctx context.Context, image string) (string, error) {
+	d, err := l.get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return d.ImageID(ctx, image)
+}
+
+func (l *lazyContainerRuntime) Start(ctx context.Context, name string) (string, error) {
+	d, err := l.get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return d.Start(ctx, name)
+}
+
+func (l *lazyContainerRuntime) Kill(ctx context.Context, name, signal string) (string, error) {
+	d, err := l.get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return d.Kill(ctx, name, signal)
+}
+
+func (l *lazyContainerRuntime) InspectContainer(ctx context.Context, name, format string) (string, error) {
+	d, err := l.get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return d.InspectContainer(ctx, name, format)
+}
+
+func (l *lazyContainerRuntime) Logs(
+	ctx context.Context, w io.Writer, name string, since time.Time, limit int64) error {
+
+	d, err := l.get(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return d.Logs(ctx, w, name, since, limit)
+}
+
+func (l *lazyContainerRuntime) StreamLogs(ctx context.Context, w io.Writer, name string, tail int, follow bool) error {
+	d, err := l.get(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return d.StreamLogs(ctx, w, name, tail, follow)
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+func TestContainerRuntimeSuite(t *testing.T) {
+	suiteRun(t, new(containerRuntimeSuite))
+}
+
+type containerRuntimeSuite struct {
+	Suite
+}
+
+func (s *containerRuntimeSuite) TestUseContainerd() {
+	s.NoError(s.em.UseContainerRuntime(config.ContainerRuntimeContainerd))
+
+	s.r.MockExec("nerdctl load -i /tmp/3fs.docker", "", nil)
+	_, err := s.em.Docker.Load(s.Ctx(), "/tmp/3fs.docker")
+	s.NoError(err)
+}
+
+func (s *containerRuntimeSuite) TestUsePodman() {
+	s.NoError(s.em.UseContainerRuntime(config.ContainerRuntimePodman))
+
+	s.r.MockExec("podman image inspect --format '{{.Id}}' open3fs/3fs:20250410", "xxx\n", nil)
+	id, err := s.em.Docker.ImageID(s.Ctx(), "open3fs/3fs:20250410")
+	s.NoError(err)
+	s.Equal("sha256:xxx", id)
+}
+
+func (s *containerRuntimeSuite) TestUseUnsupported() {
+	s.Error(s.em.UseContainerRuntime("rkt"), "unsupported container runtime: rkt")
+}
+
+func (s *containerRuntimeSuite) TestDetect() {
+	s.r.MockExec("sh -c 'command -v docker'", "", errors.New("exit status 1"))
+	s.r.MockExec("sh -c 'command -v nerdctl'", "", errors.New("exit status 1"))
+	s.r.MockExec("sh -c 'command -v podman'", "/usr/bin/podman", nil)
+
+	runtime, err := external.DetectContainerRuntime(s.Ctx(), s.em)
+	s.NoError(err)
+	s.Equal(config.ContainerRuntimePodman, runtime)
+}
+
+func (s *containerRuntimeSuite) TestDetectNone() {
+	s.r.MockExec("sh -c 'command -v", "", errors.New("exit status 1"))
+
+	_, err := external.DetectContainerRuntime(s.Ctx(), s.em)
+	s.Error(err)
+}
+
+func (s *containerRuntimeSuite) TestUseDetectedContainerRuntime() {
+	detected := 0
+	s.em.UseDetectedContainerRuntime(func(context.Context) (config.ContainerRuntime, error) {
+		detected++
+		return config.ContainerRuntimePodman, nil
+	})
+	s.Equal(0, detected)
+
+	s.r.MockExec("podman image inspect --format '{{.Id}}' open3fs/3fs:20250410", "xxx\n", nil)
+	for i := 0; i < 2; i++ {
+		id, err := s.em.Docker.ImageID(s.Ctx(), "open3fs/3fs:20250410")
+		s.NoError(err)
+		s.Equal("sha256:xxx", id)
+	}
+	s.Equal(1, detected)
+}
+
+func (s *containerRuntimeSuite) TestUseDetectedContainerRuntimeNone() {
+	s.em.UseDetectedContainerRuntime(func(context.Context) (config.ContainerRuntime, error) {
+		return "", errors.New("no container runtime found")
+	})
+
+	_, err := s.em.Docker.ImageID(s.Ctx(), "open3fs/3fs:20250410")
+	s.ErrorContains(err, "no container runtime found")
+}
@@ -17,12 +17,21 @@ package external
 import (
 	"context"
 	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/log"
 )
 
-// DockerInterface provides interface about docker.
+// DockerInterface provides interface about docker. It's also implemented by
+// other docker compatible container runtimes, see UseContainerRuntime.
 type DockerInterface interface {
 	GetContainer(string) string
 	Run(ctx context.Context, args *RunArgs) (out string, err error)
@@ -30,14 +39,24 @@ type DockerInterface interface {
 	Exec(context.Context, string, string, ...string) (out string, err error)
 	Load(ctx context.Context, path string) (out string, err error)
 	Tag(ctx context.Context, src, dst string) error
+	ImageID(ctx context.Context, image string) (string, error)
+	Start(ctx context.Context, name string) (out string, err error)
+	Kill(ctx context.Context, name, signal string) (out string, err error)
+	InspectContainer(ctx context.Context, name, format string) (string, error)
+	Logs(ctx context.Context, w io.Writer, name string, since time.Time, limit int64) error
+	StreamLogs(ctx context.Context, w io.Writer, name string, tail int, follow bool) error
 }
 
 type dockerExternal struct {
 	externalBase
+
+	// cmd is the command line tool of the container runtime.
+	cmd string
 }
 
 func (de *dockerExternal) init(em *Manager, logger log.Interface) {
 	de.externalBase.init(em, logger)
+	de.cmd = "docker"
 	em.Docker = de
 }
 
@@ -55,11 +74,76 @@ type RunArgs struct {
 	Command     []string
 	Privileged  *bool
 	Ulimits     map[string]string
+	Memory      string
+	CPUs        float64
 	Name        *string
 	Detach      *bool
 	Publish     []*PublishArgs
 	Volumes     []*VolumeArgs
 	Envs        map[string]string
+	Devices     []*DeviceArgs
+	// SecurityOpts are security options of the container, e.g. label=disable.
+	SecurityOpts []string
+}
+
+// SetResources limits resources of the container, ulimits of the resources take
+// precedence over existing ones.
+func (args *RunArgs) SetResources(res config.Resources) {
+	args.Memory = res.Memory
+	args.CPUs = res.CPUs
+	ulimits := res.Ulimits()
+	if len(ulimits) == 0 {
+		return
+	}
+	if args.Ulimits == nil {
+		args.Ulimits = make(map[string]string, len(ulimits))
+	}
+	maps.Copy(args.Ulimits, ulimits)
+}
+
+// SetPassthrough passes paths and devices of the host into the container, mounts of the
+// passthrough replace existing volumes with the same targets.
+func (args *RunArgs) SetPassthrough(p config.Passthrough) {
+	for _, m := range p.Mounts {
+		target := m.ContainerPath()
+		args.Volumes = slices.DeleteFunc(args.Volumes, func(volume *VolumeArgs) bool {
+			return path.Clean(volume.Target) == path.Clean(target)
+		})
+		args.Volumes = append(args.Volumes, &VolumeArgs{
+			Source:       m.Source,
+			Target:       target,
+			ReadOnly:     m.ReadOnly,
+			SELinuxLabel: m.SELinuxLabel,
+		})
+	}
+	for _, d := range p.Devices {
+		args.Devices = append(args.Devices, &DeviceArgs{
+			Host:        d.Host,
+			Container:   d.ContainerPath(),
+			Permissions: d.Permissions,
+		})
+	}
+	args.SecurityOpts = append(args.SecurityOpts, p.SecurityOpts...)
+}
+
+// SetCommandWrapper prefixes the command with the wrapper, the executable of the wrapper
+// is mounted read-only into the container from the same path on the node. Only the
+// executable is mounted, so it must be a static binary, libraries of the node aren't in
+// the container. Arguments of the wrapper are quoted for the shell running docker.
+func (args *RunArgs) SetCommandWrapper(wrapper []string) {
+	if len(wrapper) == 0 {
+		return
+	}
+	command := make([]string, 0, len(wrapper)+len(args.Command))
+	for _, arg := range wrapper {
+		command = append(command, ShellQuote(arg))
+	}
+	args.Command = append(command, args.Command...)
+	args.Volumes = append(args.Volumes, &VolumeArgs{
+		Source:   wrapper[0],
+		Target:   wrapper[0],
+		ReadOnly: true,
+	})
 }
 
 // PublishArgs defines args for publishing a container port.
@@ -75,6 +159,18 @@ type VolumeArgs struct {
 	Source string
 	Target string
 	Rshare *bool
+	// ReadOnly mounts the volume read-only.
+	ReadOnly bool
+	// SELinuxLabel is z or Z to relabel the source for SELinux.
+	SELinuxLabel string
+}
+
+// DeviceArgs defines args for passing a device of the host into the container.
+type DeviceArgs struct {
+	Host      string
+	Container string
+	// Permissions are cgroup permissions of the device, default is rwm.
+	Permissions string
 }
 
 func (de *dockerExternal) Run(ctx context.Context, args *RunArgs) (out string, err error) {
@@ -100,13 +196,24 @@ func (de *dockerExternal) Run(ctx context.Context, args *RunArgs) (out string, e
 	if args.Privileged != nil && *args.Privileged {
 		params = append(params, "--privileged")
 	}
-	for key, val := range args.Ulimits {
-		params = append(params, "--ulimit", fmt.Sprintf("%s=%s", key, val))
+	for _, key := range slices.Sorted(maps.Keys(args.Ulimits)) {
+		params = append(params, "--ulimit", fmt.Sprintf("%s=%s", key, args.Ulimits[key]))
+	}
+	if args.Memory != "" {
+		params = append(params, "--memory", args.Memory)
+	}
+	if args.CPUs > 0 {
+		params = append(params, "--cpus", strconv.FormatFloat(args.CPUs, 'f', -1, 64))
 	}
 	for _, publishArg := range args.Publish {
 		publishInfo := fmt.Sprintf("%d:%d", publishArg.HostPort, publishArg.ContainerPort)
 		if publishArg.HostAddress != nil {
-			publishInfo = *publishArg.HostAddress + ":" + publishInfo
+			hostAddress := *publishArg.HostAddress
+			if strings.Contains(hostAddress, ":") {
+				// IPv6 address must be bracketed
+				hostAddress = "[" + hostAddress + "]"
+			}
+			publishInfo = hostAddress + ":" + publishInfo
 		}
 		if publishArg.Protocol != nil {
 			publishInfo = publishInfo + "/" + *publishArg.Protocol
@@ -115,17 +222,37 @@ func (de *dockerExternal) Run(ctx context.Context, args *RunArgs) (out string, e
 	}
 	for _, volumeArg := range args.Volumes {
 		volBind := fmt.Sprintf("%s:%s", volumeArg.Source, volumeArg.Target)
+		var options []string
+		if volumeArg.ReadOnly {
+			options = append(options, "ro")
+		}
 		if volumeArg.Rshare != nil && *volumeArg.Rshare {
-			volBind += ":rshared"
+			options = append(options, "rshared")
+		}
+		if volumeArg.SELinuxLabel != "" {
+			options = append(options, volumeArg.SELinuxLabel)
+		}
+		if len(options) > 0 {
+			volBind += ":" + strings.Join(options, ",")
 		}
 		params = append(params, "--volume", volBind)
 	}
+	for _, device := range args.Devices {
+		deviceBind := fmt.Sprintf("%s:%s", device.Host, device.Container)
+		if device.Permissions != "" {
+			deviceBind += ":" + device.Permissions
+		}
+		params = append(params, "--device", deviceBind)
+	}
+	for _, opt := range args.SecurityOpts {
+		params = append(params, "--security-opt", opt)
+	}
 	params = append(params, args.Image)
 	if len(args.Command) > 0 {
 		params = append(params, args.Command...)
 	}
-	out, err = de.run(ctx, "docker", params...)
-	return out, errors.Trace(err)
+	result, err := de.run(ctx, de.cmd, params...)
+	return result.Stdout, errors.Trace(err)
 }
 
 func (de *dockerExternal) Rm(ctx context.Context, name string, force bool) (out string, err error) {
@@ -134,8 +261,8 @@ func (de *dockerExternal) Rm(ctx context.Context, name string, force bool) (out
 		args = append(args, "--force")
 	}
 	args = append(args, name)
-	out, err = de.run(ctx, "docker", args...)
-	return out, errors.Trace(err)
+	result, err := de.run(ctx, de.cmd, args...)
+	return result.Stdout, errors.Trace(err)
 }
 
 func (de *dockerExternal) Exec(
@@ -143,17 +270,88 @@ func (de *dockerExternal) Exec(
 
 	params := []string{"exec", container, cmd}
 	params = append(params, args...)
-	out, err = de.run(ctx, "docker", params...)
-	return out, errors.Trace(err)
+	result, err := de.run(ctx, de.cmd, params...)
+	return result.Stdout, errors.Trace(err)
 }
 
 func (de *dockerExternal) Load(ctx context.Context, path string) (out string, err error) {
-	out, err = de.run(ctx, "docker", "load", "-i", path)
-	return out, errors.Trace(err)
+	result, err := de.run(ctx, de.cmd, "load", "-i", path)
+	return result.Stdout, errors.Trace(err)
 }
 
 func (de *dockerExternal) Tag(ctx context.Context, src, dst string) error {
-	_, err := de.run(ctx, "docker", "tag", src, dst)
+	_, err := de.run(ctx, de.cmd, "tag", src, dst)
+	return errors.Trace(err)
+}
+
+func (de *dockerExternal) ImageID(ctx context.Context, image string) (string, error) {
+	result, err := de.run(ctx, de.cmd, "image", "inspect", "--format", "'{{.Id}}'", image)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return result.TrimmedStdout(), nil
+}
+
+func (de *dockerExternal) Start(ctx context.Context, name string) (out string, err error) {
+	result, err := de.run(ctx, de.cmd, "start", name)
+	return result.Stdout, errors.Trace(err)
+}
+
+// Kill sends the signal to the main process of the container.
+func (de *dockerExternal) Kill(ctx context.Context, name, signal string) (out string, err error) {
+	result, err := de.run(ctx, de.cmd, "kill", "--signal", signal, name)
+	return result.Stdout, errors.Trace(err)
+}
+
+// InspectContainer returns the output of inspecting the container with the go template format.
+func (de *dockerExternal) InspectContainer(ctx context.Context, name, format string) (string, error) {
+	result, err := de.run(ctx, de.cmd, "container", "inspect", "--format", "'"+format+"'", name)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return result.TrimmedStdout(), nil
+}
+
+// Logs writes timestamped stdout and stderr of the container since the time into w,
+// all logs are written if since is zero. If limit is positive, only the last limit
+// bytes of logs are written.
+func (de *dockerExternal) Logs(ctx context.Context, w io.Writer, name string, since time.Time, limit int64) error {
+	streamer, ok := de.em.Runner.(StreamRunner)
+	if !ok {
+		return errors.New("the runner doesn't support streaming outputs of commands")
+	}
+	args := []string{"logs", "--timestamps"}
+	if !since.IsZero() {
+		args = append(args, "--since", since.UTC().Format(time.RFC3339))
+	}
+	args = append(args, name, "2>&1")
+	if limit > 0 {
+		args = append(args, "|", "tail", "-c", strconv.FormatInt(limit, 10))
+	}
+	return errors.Trace(streamer.Stream(ctx, w, de.cmd, args...))
+}
+
+// StreamLogs writes the last tail lines of stdout and stderr of the container into w,
+// all lines are written if tail is negative. If follow is set, new lines are written
+// as they're produced until the context is done.
+func (de *dockerExternal) StreamLogs(ctx context.Context, w io.Writer, name string, tail int, follow bool) error {
+	streamer, ok := de.em.Runner.(StreamRunner)
+	if !ok {
+		return errors.New("the runner doesn't support streaming outputs of commands")
+	}
+	args := []string{"logs", "--tail", "all"}
+	if tail >= 0 {
+		args[2] = strconv.Itoa(tail)
+	}
+	if follow {
+		args = append(args, "--follow")
+	}
+	args = append(args, name, "2>&1")
+	err := streamer.Stream(ctx, w, de.cmd, args...)
+	if ctx.Err() != nil {
+		// following logs stops when the context is done
+		return nil
+	}
 	return errors.Trace(err)
 }
 
@@ -15,9 +15,13 @@
 package external_test
 
 import (
+	"bytes"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/external"
 )
 
@@ -73,6 +77,73 @@ func (s *dockerRunSuite) Test() {
 	s.NoError(err)
 }
 
+func (s *dockerRunSuite) TestWithResources() {
+	args := &external.RunArgs{
+		Image: "open3fs/3fs:latest",
+		Ulimits: map[string]string{
+			"nofile": "1048576:1048576",
+		},
+	}
+	args.SetResources(config.Resources{Memory: "64g", CPUs: 1.5, NoFile: "65536", NProc: "4096"})
+	mockCmd := "docker run --ulimit nofile=65536 --ulimit nproc=4096 --memory 64g --cpus 1.5 open3fs/3fs:latest"
+	s.r.MockExec(mockCmd, "", nil)
+	_, err := s.em.Docker.Run(s.Ctx(), args)
+	s.NoError(err)
+}
+
+func (s *dockerRunSuite) TestWithCommandWrapper() {
+	args := &external.RunArgs{
+		Image:   "open3fs/3fs:latest",
+		Command: []string{"/opt/3fs/bin/meta_main", "--app_cfg", "/opt/3fs/etc/meta_main_app.toml"},
+	}
+	args.SetCommandWrapper([]string{"/usr/local/bin/strace", "-f", "-e", "trace=open;reboot"})
+	mockCmd := "docker run --volume /usr/local/bin/strace:/usr/local/bin/strace:ro open3fs/3fs:latest " +
+		"'/usr/local/bin/strace' '-f' '-e' 'trace=open;reboot' " +
+		"/opt/3fs/bin/meta_main --app_cfg /opt/3fs/etc/meta_main_app.toml"
+	s.r.MockExec(mockCmd, "", nil)
+	_, err := s.em.Docker.Run(s.Ctx(), args)
+	s.NoError(err)
+}
+
+func (s *dockerRunSuite) TestWithPassthrough() {
+	args := &external.RunArgs{
+		Image: "open3fs/3fs:latest",
+		Volumes: []*external.VolumeArgs{
+			{Source: "/root/3fs/storage/log", Target: "/var/log/3fs"},
+		},
+	}
+	args.SetPassthrough(config.Passthrough{
+		Mounts: []config.Mount{
+			{Source: "/data/log", Target: "/var/log/3fs/", SELinuxLabel: "Z"},
+			{Source: "/mnt/nvme0", ReadOnly: true, SELinuxLabel: "z"},
+		},
+		Devices:      []config.Device{{Host: "/dev/infiniband/uverbs0"}, {Host: "/dev/nvme0n1", Permissions: "rw"}},
+		SecurityOpts: []string{"label=disable"},
+	})
+	mockCmd := "docker run --volume /data/log:/var/log/3fs/:Z --volume /mnt/nvme0:/mnt/nvme0:ro,z " +
+		"--device /dev/infiniband/uverbs0:/dev/infiniband/uverbs0 --device /dev/nvme0n1:/dev/nvme0n1:rw " +
+		"--security-opt label=disable open3fs/3fs:latest"
+	s.r.MockExec(mockCmd, "", nil)
+	_, err := s.em.Docker.Run(s.Ctx(), args)
+	s.NoError(err)
+}
+
+func (s *dockerRunSuite) TestWithIPv6HostAddress() {
+	args := &external.RunArgs{
+		Image: "clickhouse/clickhouse-server:latest",
+		Publish: []*external.PublishArgs{
+			{
+				HostAddress:   common.Pointer("::1"),
+				HostPort:      9000,
+				ContainerPort: 9000,
+			},
+		},
+	}
+	s.r.MockExec("docker run -p [::1]:9000:9000 clickhouse/clickhouse-server:latest", "", nil)
+	_, err := s.em.Docker.Run(s.Ctx(), args)
+	s.NoError(err)
+}
+
 func TestDockerRmSuite(t *testing.T) {
 	suiteRun(t, new(dockerRmSuite))
 }
@@ -102,3 +173,76 @@ func (s *dockerExecSuite) Test() {
 	_, err := s.em.Docker.Exec(s.Ctx(), "fdb", "fdbcli", "--exec", "status")
 	s.NoError(err)
 }
+
+func TestDockerImageIDSuite(t *testing.T) {
+	suiteRun(t, new(dockerImageIDSuite))
+}
+
+type dockerImageIDSuite struct {
+	Suite
+}
+
+func (s *dockerImageIDSuite) Test() {
+	mockCmd := "docker image inspect --format '{{.Id}}' open3fs/3fs:20250410"
+	s.r.MockExec(mockCmd, "sha256:xxx\n", nil)
+	id, err := s.em.Docker.ImageID(s.Ctx(), "open3fs/3fs:20250410")
+	s.NoError(err)
+	s.Equal("sha256:xxx", id)
+}
+
+func TestDockerStartSuite(t *testing.T) {
+	suiteRun(t, new(dockerStartSuite))
+}
+
+type dockerStartSuite struct {
+	Suite
+}
+
+func (s *dockerStartSuite) Test() {
+	s.r.MockExec("docker start 3fs-meta", "3fs-meta\n", nil)
+	_, err := s.em.Docker.Start(s.Ctx(), "3fs-meta")
+	s.NoError(err)
+}
+
+func TestDockerInspectContainerSuite(t *testing.T) {
+	suiteRun(t, new(dockerInspectContainerSuite))
+}
+
+type dockerInspectContainerSuite struct {
+	Suite
+}
+
+func (s *dockerInspectContainerSuite) Test() {
+	mockCmd := "docker container inspect --format '{{.State.Status}}' 3fs-meta"
+	s.r.MockExec(mockCmd, "running\n", nil)
+	out, err := s.em.Docker.InspectContainer(s.Ctx(), "3fs-meta", "{{.State.Status}}")
+	s.NoError(err)
+	s.Equal("running", out)
+}
+
+func TestDockerLogsSuite(t *testing.T) {
+	suiteRun(t, new(dockerLogsSuite))
+}
+
+type dockerLogsSuite struct {
+	Suite
+}
+
+func (s *dockerLogsSuite) TestAll() {
+	s.r.MockExec("docker logs --timestamps 3fs-fdb 2>&1", "log\n", nil)
+	out := new(bytes.Buffer)
+	s.NoError(s.em.Docker.Logs(s.Ctx(), out, "3fs-fdb", time.Time{}, 0))
+	s.Equal("log\n", out.String())
+}
+
+func (s *dockerLogsSuite) TestSince() {
+	since := time.Date(2025, 4, 1, 8, 0, 0, 0, time.UTC)
+	s.r.MockExec("docker logs --timestamps --since 2025-04-01T08:00:00Z 3fs-fdb 2>&1", "log\n", nil)
+	s.NoError(s.em.Docker.Logs(s.Ctx(), io.Discard, "3fs-fdb", since, 0))
+}
+
+func (s *dockerLogsSuite) TestLimit() {
+	s.r.MockExec("docker logs --timestamps 3fs-fdb 2>&1 | tail -c 1024", "log\n", nil)
+	s.NoError(s.em.Docker.Logs(s.Ctx(), io.Discard, "3fs-fdb", time.Time{}, 1024))
+	s.Equal(1, s.r.CalledExecCount("docker logs --timestamps 3fs-fdb 2>&1 | tail -c 1024"))
+}
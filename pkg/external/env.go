@@ -0,0 +1,51 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+// ShellQuote quotes the string as a single word of shell.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// envCommand returns the `env` command which runs a command with the environment,
+// it returns an empty string if the environment is empty.
+func envCommand(env map[string]string) string {
+	if len(env) == 0 {
+		return ""
+	}
+	parts := []string{"env"}
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, ShellQuote(env[name])))
+	}
+	return strings.Join(parts, " ")
+}
+
+// redactedEnv returns the environment in a form which is safe to be logged.
+func redactedEnv(env map[string]string) string {
+	parts := make([]string, 0, len(env))
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, config.RedactEnvValue(name, env[name])))
+	}
+	return strings.Join(parts, " ")
+}
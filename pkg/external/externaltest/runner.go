@@ -0,0 +1,211 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package externaltest provides runners returning scripted outputs of commands, so
+// that tasks and steps can be tested without real hosts.
+//
+// A Script holds responses of commands and records commands run by runners created
+// from it. Managers of the script can be injected as task.Runtime.LocalEm and by
+// task.Runtime.NewNodeManager:
+//
+//	script := externaltest.NewScript()
+//	script.On(`^docker ps`, externaltest.Response{Output: "3fs-meta"})
+//	script.OnNode("node2", `^systemctl is-active`, externaltest.Response{ExitCode: 3})
+//	runtime.NewNodeManager = script.NodeManager
+//	...
+//	count := script.Count("node2", `^systemctl`)
+package externaltest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+// Invocation is a command run by a runner of the script.
+type Invocation struct {
+	Node    string
+	Command string
+	Args    []string
+	Sudo    bool
+}
+
+// CommandLine returns the command and args joined by spaces.
+func (i Invocation) CommandLine() string {
+	return strings.Join(append([]string{i.Command}, i.Args...), " ")
+}
+
+// Response is the scripted result of commands.
+type Response struct {
+	Output string
+	// ExitCode makes the command fail with the exit code if it isn't 0.
+	ExitCode int
+	// Err makes the command fail with the error, it takes precedence over ExitCode.
+	Err error
+	// Times limits how many times the response is returned, 0 means unlimited.
+	Times int
+}
+
+type rule struct {
+	node     string
+	pattern  *regexp.Regexp
+	response Response
+	used     int
+}
+
+// Script holds responses of commands and records invocations of runners created from
+// it. It's safe for concurrent use.
+type Script struct {
+	// Strict makes commands matching no response fail, otherwise they succeed with
+	// empty output.
+	Strict bool
+
+	mu          sync.Mutex
+	rules       []*rule
+	invocations []Invocation
+}
+
+// NewScript creates an empty script.
+func NewScript() *Script {
+	return new(Script)
+}
+
+// On adds the response of commands whose command lines match the regular expression
+// on any node. Responses are matched in the order they're added, and a response used
+// up by Times is skipped.
+func (s *Script) On(pattern string, response Response) *Script {
+	return s.OnNode("", pattern, response)
+}
+
+// OnNode is On for commands run on the node only.
+func (s *Script) OnNode(node, pattern string, response Response) *Script {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, &rule{node: node, pattern: regexp.MustCompile(pattern), response: response})
+	return s
+}
+
+// Runner returns the runner of the node.
+func (s *Script) Runner(node string) *Runner {
+	return &Runner{node: node, script: s}
+}
+
+// Manager returns the external manager whose commands are run by the runner of the node,
+// including commands of docker and fs interfaces.
+func (s *Script) Manager(node string, logger log.Interface) *external.Manager {
+	em := external.NewManager(s.Runner(node), logger)
+	em.Node = node
+	return em
+}
+
+// NodeManager returns the manager of the node, it matches task.Runtime.NewNodeManager.
+func (s *Script) NodeManager(node config.Node, logger log.Interface) (*external.Manager, error) {
+	return s.Manager(node.Name, logger), nil
+}
+
+// Invocations returns invocations on the node matching the regular expression in order,
+// the empty node matches all nodes and the empty pattern matches all commands.
+func (s *Script) Invocations(node, pattern string) []Invocation {
+	re := regexp.MustCompile(pattern)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var invocations []Invocation
+	for _, invocation := range s.invocations {
+		if (node == "" || invocation.Node == node) && re.MatchString(invocation.CommandLine()) {
+			invocations = append(invocations, invocation)
+		}
+	}
+	return invocations
+}
+
+// Count returns the number of invocations on the node matching the regular expression.
+func (s *Script) Count(node, pattern string) int {
+	return len(s.Invocations(node, pattern))
+}
+
+func (s *Script) run(invocation Invocation) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invocations = append(s.invocations, invocation)
+	cmdline := invocation.CommandLine()
+	for _, r := range s.rules {
+		if r.node != "" && r.node != invocation.Node {
+			continue
+		}
+		if r.response.Times > 0 && r.used >= r.response.Times {
+			continue
+		}
+		if !r.pattern.MatchString(cmdline) {
+			continue
+		}
+		r.used++
+		switch {
+		case r.response.Err != nil:
+			return r.response.Output, r.response.Err
+		case r.response.ExitCode != 0:
+			return r.response.Output, external.NewRunError(r.response.ExitCode,
+				fmt.Sprintf("%s: exit status %d: %s", cmdline, r.response.ExitCode, r.response.Output))
+		default:
+			return r.response.Output, nil
+		}
+	}
+	if s.Strict {
+		return "", fmt.Errorf("no scripted response of %q on node %q", cmdline, invocation.Node)
+	}
+	return "", nil
+}
+
+// Runner implements external.RunnerInterface by responses of the script.
+type Runner struct {
+	node   string
+	script *Script
+}
+
+var (
+	_ external.RunnerInterface = (*Runner)(nil)
+	_ external.StreamRunner    = (*Runner)(nil)
+)
+
+// NonSudoExec returns the scripted response of the command.
+func (r *Runner) NonSudoExec(ctx context.Context, command string, args ...string) (string, error) {
+	return r.script.run(Invocation{Node: r.node, Command: command, Args: slices.Clone(args)})
+}
+
+// Exec returns the scripted response of the command run with sudo.
+func (r *Runner) Exec(ctx context.Context, command string, args ...string) (string, error) {
+	return r.script.run(Invocation{Node: r.node, Command: command, Args: slices.Clone(args), Sudo: true})
+}
+
+// Stream writes the scripted output of the command run with sudo into w.
+func (r *Runner) Stream(ctx context.Context, w io.Writer, command string, args ...string) error {
+	out, err := r.script.run(Invocation{Node: r.node, Command: command, Args: slices.Clone(args), Sudo: true})
+	if _, writeErr := io.WriteString(w, out); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// Scp is recorded as the scp command with the local and remote paths as args.
+func (r *Runner) Scp(ctx context.Context, local, remote string) error {
+	_, err := r.script.run(Invocation{Node: r.node, Command: "scp", Args: []string{local, remote}})
+	return err
+}
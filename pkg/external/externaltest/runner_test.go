@@ -0,0 +1,97 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externaltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+var suiteRun = suite.Run
+
+func TestScriptSuite(t *testing.T) {
+	suiteRun(t, new(scriptSuite))
+}
+
+type scriptSuite struct {
+	suite.Suite
+	script *Script
+	ctx    context.Context
+}
+
+func (s *scriptSuite) SetupTest() {
+	s.script = NewScript()
+	s.ctx = context.Background()
+}
+
+func (s *scriptSuite) TestResponses() {
+	s.script.
+		OnNode("node2", `^docker ps`, Response{Output: "node2"}).
+		On(`^docker ps`, Response{Output: "any"}).
+		On(`^systemctl`, Response{Output: "inactive", ExitCode: 3})
+
+	out, err := s.script.Runner("node1").Exec(s.ctx, "docker", "ps", "-a")
+	s.NoError(err)
+	s.Equal("any", out)
+	out, err = s.script.Runner("node2").Exec(s.ctx, "docker", "ps")
+	s.NoError(err)
+	s.Equal("node2", out)
+
+	out, err = s.script.Runner("node1").NonSudoExec(s.ctx, "systemctl", "is-active", "docker")
+	s.Error(err)
+	s.Equal(3, external.ExitCode(err))
+	s.Equal("inactive", out)
+
+	out, err = s.script.Runner("node1").Exec(s.ctx, "hostname")
+	s.NoError(err)
+	s.Empty(out)
+}
+
+func (s *scriptSuite) TestTimes() {
+	errBusy := errors.New("busy")
+	s.script.On(`^mkdir`, Response{Err: errBusy, Times: 2})
+	runner := s.script.Runner("node1")
+
+	for range 2 {
+		s.NoError(runner.Scp(s.ctx, "a", "b"))
+		_, err := runner.Exec(s.ctx, "mkdir", "/tmp/a")
+		s.ErrorIs(err, errBusy)
+	}
+	_, err := runner.Exec(s.ctx, "mkdir", "/tmp/a")
+	s.NoError(err)
+	s.Equal(3, s.script.Count("node1", `^mkdir /tmp/a$`))
+	s.Equal(2, s.script.Count("", `^scp a b$`))
+}
+
+func (s *scriptSuite) TestStrict() {
+	s.script.Strict = true
+	s.script.On(`^true$`, Response{})
+
+	em := s.script.Manager("node1", log.Logger)
+	_, err := em.Runner.Exec(s.ctx, "true")
+	s.NoError(err)
+	_, err = em.Runner.Exec(s.ctx, "false")
+	s.Error(err)
+	s.Equal([]Invocation{
+		{Node: "node1", Command: "true", Sudo: true},
+		{Node: "node1", Command: "false", Sudo: true},
+	}, s.script.Invocations("node1", ""))
+}
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/open3fs/m3fs/pkg/errors"
@@ -40,6 +41,7 @@ type FSInterface interface {
 	ReadRemoteFile(string) (string, error)
 	IsNotExist(string) (bool, error)
 	Sha256sum(context.Context, string) (string, error)
+	FreeSpace(context.Context, string) (uint64, error)
 	Tar(srcPaths []string, basePath, dstPath string, needGzip bool) error
 	ExtractTar(ctx context.Context, srcPath, dstDir string) error
 }
@@ -59,11 +61,11 @@ func (fe *fsExternal) init(em *Manager, logger log.Interface) {
 }
 
 func (fe *fsExternal) MkdirTemp(ctx context.Context, dir, prefix string) (string, error) {
-	out, err := fe.run(ctx, "mktemp", "-d", "-p", dir, "-t", prefix+".XXXXXX")
+	result, err := fe.run(ctx, "mktemp", "-d", "-p", dir, "-t", prefix+".XXXXXX")
 	if err != nil {
 		return "", errors.Trace(err)
 	}
-	dirPaht := strings.TrimSpace(out)
+	dirPaht := result.TrimmedStdout()
 	_, err = fe.run(ctx, "chmod", "0777", dirPaht)
 	if err != nil {
 		return "", errors.Trace(err)
@@ -72,11 +74,11 @@ func (fe *fsExternal) MkdirTemp(ctx context.Context, dir, prefix string) (string
 }
 
 func (fe *fsExternal) MkTempFile(ctx context.Context, dir string) (string, error) {
-	out, err := fe.run(ctx, "mktemp", "-p", dir)
+	result, err := fe.run(ctx, "mktemp", "-p", dir)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
-	filePath := strings.TrimSpace(out)
+	filePath := result.TrimmedStdout()
 	_, err = fe.run(ctx, "chmod", "0777", filePath)
 	if err != nil {
 		return "", errors.Trace(err)
@@ -104,11 +106,18 @@ func (fe *fsExternal) RemoveAll(ctx context.Context, dir string) error {
 	return nil
 }
 
+func (fe *fsExternal) httpClient() *http.Client {
+	if fe.em.HTTPClient != nil {
+		return fe.em.HTTPClient
+	}
+	return http.DefaultClient
+}
+
 func (fe *fsExternal) DownloadFile(url, dstPath string) error {
 	if fe.returnUnimplemented {
 		return errors.New("unimplemented")
 	}
-	resp, err := http.Get(url)
+	resp, err := fe.httpClient().Get(url)
 	if err != nil {
 		return err
 	}
@@ -134,7 +143,7 @@ func (fe *fsExternal) ReadRemoteFile(url string) (string, error) {
 	if fe.returnUnimplemented {
 		return "", errors.New("unimplemented")
 	}
-	resp, err := http.Get(url)
+	resp, err := fe.httpClient().Get(url)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
@@ -163,17 +172,35 @@ func (fe *fsExternal) IsNotExist(path string) (bool, error) {
 }
 
 func (fe *fsExternal) Sha256sum(ctx context.Context, path string) (string, error) {
-	out, err := fe.run(ctx, "sha256sum", path)
+	result, err := fe.run(ctx, "sha256sum", path)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
-	parts := strings.Fields(out)
+	parts := strings.Fields(result.Stdout)
 	if len(parts) < 1 {
 		return "", fmt.Errorf("Unexpected output: %s", path)
 	}
 	return parts[0], nil
 }
 
+// FreeSpace returns the free space in bytes of the filesystem holding the existing dir.
+func (fe *fsExternal) FreeSpace(ctx context.Context, dir string) (uint64, error) {
+	result, err := fe.run(ctx, "df", "-P", "-k", dir)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	lines := result.Lines()
+	if len(lines) < 2 || len(strings.Fields(lines[len(lines)-1])) < 4 {
+		return 0, errors.Errorf("unexpected output of df: %s", result.Stdout)
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	kb, err := strconv.ParseUint(fields[3], 10, 64)
+	if err != nil {
+		return 0, errors.Annotatef(err, "parse output of df: %s", result.Stdout)
+	}
+	return kb << 10, nil
+}
+
 func (fe *fsExternal) Tar(srcPaths []string, basePath, dstPath string, needGzip bool) error {
 	if fe.returnUnimplemented {
 		return errors.New("unimplemented")
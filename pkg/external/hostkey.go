@@ -0,0 +1,132 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+// HostKeyCfg defines how host keys of remote nodes are verified.
+type HostKeyCfg struct {
+	Policy config.HostKeyPolicy
+	// KnownHostsFile is the known_hosts file managed by m3fs.
+	KnownHostsFile string
+}
+
+// knownHostsLock serializes reading and pinning keys of the known_hosts file,
+// because nodes are connected in parallel.
+var knownHostsLock sync.Mutex
+
+// NewHostKeyCallback creates the callback verifying host keys by the policy.
+func NewHostKeyCallback(cfg *HostKeyCfg, logger log.Interface) (ssh.HostKeyCallback, error) {
+	switch cfg.Policy {
+	case config.HostKeyPolicyInsecureIgnore:
+		logger.Warnf("Host keys of nodes are NOT verified by the insecure-ignore host key policy, " +
+			"SSH connections are vulnerable to man-in-the-middle attacks")
+		return ssh.InsecureIgnoreHostKey(), nil
+	case config.HostKeyPolicyStrict, config.HostKeyPolicyAcceptNew:
+	default:
+		return nil, errors.Errorf("invalid host key policy: %s", cfg.Policy)
+	}
+	if cfg.KnownHostsFile == "" {
+		return nil, errors.New("known_hosts file is required")
+	}
+
+	verifier := &hostKeyVerifier{
+		policy:         cfg.Policy,
+		knownHostsFile: cfg.KnownHostsFile,
+		logger:         logger,
+	}
+	return verifier.verify, nil
+}
+
+type hostKeyVerifier struct {
+	policy         config.HostKeyPolicy
+	knownHostsFile string
+	logger         log.Interface
+}
+
+func (v *hostKeyVerifier) verify(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsLock.Lock()
+	defer knownHostsLock.Unlock()
+
+	if _, err := os.Stat(v.knownHostsFile); os.IsNotExist(err) {
+		if v.policy == config.HostKeyPolicyStrict {
+			return errors.Errorf("known_hosts file %s doesn't exist, it must contain host keys of "+
+				"nodes with the strict host key policy", v.knownHostsFile)
+		}
+		return errors.Trace(v.pin(hostname, key))
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+
+	callback, err := knownhosts.New(v.knownHostsFile)
+	if err != nil {
+		return errors.Annotatef(err, "load known_hosts file %s", v.knownHostsFile)
+	}
+	err = callback(hostname, remote, key)
+	if err == nil {
+		return nil
+	}
+	keyErr, ok := err.(*knownhosts.KeyError)
+	if !ok {
+		return errors.Annotatef(err, "verify host key of %s", hostname)
+	}
+	if len(keyErr.Want) > 0 {
+		want := keyErr.Want[0]
+		return errors.Errorf("REMOTE HOST IDENTIFICATION OF %s HAS CHANGED! Someone could be "+
+			"eavesdropping on you right now (man-in-the-middle attack), or the host key has just "+
+			"been changed. The %s key fingerprint is %s, but %s is expected by %s:%d. Remove the line "+
+			"if the host is reinstalled", hostname, key.Type(), ssh.FingerprintSHA256(key),
+			ssh.FingerprintSHA256(want.Key), want.Filename, want.Line)
+	}
+	if v.policy == config.HostKeyPolicyStrict {
+		return errors.Errorf("host key of %s is unknown, add it to %s with the strict host key policy",
+			hostname, v.knownHostsFile)
+	}
+	return errors.Trace(v.pin(hostname, key))
+}
+
+// pin appends the key of the host to the known_hosts file.
+func (v *hostKeyVerifier) pin(hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(v.knownHostsFile), 0755); err != nil {
+		return errors.Annotatef(err, "create directory of %s", v.knownHostsFile)
+	}
+	f, err := os.OpenFile(v.knownHostsFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Annotatef(err, "open known_hosts file %s", v.knownHostsFile)
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if _, err = fmt.Fprintln(f, line); err != nil {
+		_ = f.Close()
+		return errors.Annotatef(err, "write known_hosts file %s", v.knownHostsFile)
+	}
+	if err = f.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	v.logger.Infof("Pinned %s host key %s of %s", key.Type(), ssh.FingerprintSHA256(key), hostname)
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestHostKeyCallbackSuite(t *testing.T) {
+	suiteRun(t, &hostKeyCallbackSuite{})
+}
+
+type hostKeyCallbackSuite struct {
+	Suite
+
+	knownHostsFile string
+	remote         net.Addr
+}
+
+func (s *hostKeyCallbackSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.knownHostsFile = filepath.Join(s.T().TempDir(), ".m3fs", "known_hosts")
+	s.remote = &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+}
+
+func (s *hostKeyCallbackSuite) newKey() ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	s.R().NoError(err)
+	key, err := ssh.NewPublicKey(pub)
+	s.R().NoError(err)
+	return key
+}
+
+func (s *hostKeyCallbackSuite) newCallback(policy config.HostKeyPolicy) ssh.HostKeyCallback {
+	callback, err := external.NewHostKeyCallback(&external.HostKeyCfg{
+		Policy:         policy,
+		KnownHostsFile: s.knownHostsFile,
+	}, log.Logger)
+	s.R().NoError(err)
+	return callback
+}
+
+func (s *hostKeyCallbackSuite) TestAcceptNew() {
+	callback := s.newCallback(config.HostKeyPolicyAcceptNew)
+	key := s.newKey()
+
+	s.NoError(callback("10.0.0.1:22", s.remote, key))
+	s.NoError(callback("10.0.0.1:22", s.remote, key))
+	s.NoError(callback("10.0.0.2:2222", s.remote, s.newKey()))
+
+	data, err := os.ReadFile(s.knownHostsFile)
+	s.NoError(err)
+	s.Contains(string(data), "10.0.0.1 ssh-ed25519 ")
+	s.Contains(string(data), "[10.0.0.2]:2222 ssh-ed25519 ")
+
+	err = callback("10.0.0.1:22", s.remote, s.newKey())
+	s.Error(err)
+	s.Contains(err.Error(), "man-in-the-middle attack")
+}
+
+func (s *hostKeyCallbackSuite) TestStrict() {
+	callback := s.newCallback(config.HostKeyPolicyStrict)
+	key := s.newKey()
+
+	err := callback("10.0.0.1:22", s.remote, key)
+	s.Error(err)
+	s.Contains(err.Error(), "doesn't exist")
+
+	s.R().NoError(s.newCallback(config.HostKeyPolicyAcceptNew)("10.0.0.1:22", s.remote, key))
+	s.NoError(callback("10.0.0.1:22", s.remote, key))
+
+	err = callback("10.0.0.2:22", s.remote, key)
+	s.Error(err)
+	s.Contains(err.Error(), "host key of 10.0.0.2:22 is unknown")
+
+	err = callback("10.0.0.1:22", s.remote, s.newKey())
+	s.Error(err)
+	s.Contains(err.Error(), "REMOTE HOST IDENTIFICATION OF 10.0.0.1:22 HAS CHANGED")
+}
+
+func (s *hostKeyCallbackSuite) TestInsecureIgnore() {
+	callback := s.newCallback(config.HostKeyPolicyInsecureIgnore)
+
+	s.NoError(callback("10.0.0.1:22", s.remote, s.newKey()))
+	_, err := os.Stat(s.knownHostsFile)
+	s.True(os.IsNotExist(err))
+}
+
+func (s *hostKeyCallbackSuite) TestInvalidPolicy() {
+	_, err := external.NewHostKeyCallback(&external.HostKeyCfg{Policy: "invalid"}, log.Logger)
+	s.Error(err)
+}
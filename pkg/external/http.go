@@ -0,0 +1,71 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// NewTLSConfig creates the tls config by the cluster config. The CA bundle is
+// trusted in addition to system CAs.
+func NewTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		caData, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, errors.Annotate(err, "read CA file")
+		}
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, errors.Errorf("no certificate is found in CA file %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, errors.Annotate(err, "load client certificate")
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.InsecureSkipVerify {
+		logrus.Warn("!!! TLS certificate verification is DISABLED, connections to the registry and " +
+			"download servers can be intercepted. Use tls.caFile to trust a private CA instead !!!")
+		tlsCfg.InsecureSkipVerify = true
+	}
+	return tlsCfg, nil
+}
+
+// NewHTTPClient creates the http client used by HTTP downloads.
+func NewHTTPClient(cfg *config.TLSConfig) (*http.Client, error) {
+	tlsCfg, err := NewTLSConfig(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external_test
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+func TestHTTPClientSuite(t *testing.T) {
+	suiteRun(t, &httpClientSuite{})
+}
+
+type httpClientSuite struct {
+	Suite
+
+	server *httptest.Server
+	caFile string
+}
+
+func (s *httpClientSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	s.caFile = filepath.Join(s.T().TempDir(), "ca.crt")
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.server.Certificate().Raw})
+	s.R().NoError(os.WriteFile(s.caFile, caData, 0644))
+}
+
+func (s *httpClientSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *httpClientSuite) TestWithCAFile() {
+	client, err := external.NewHTTPClient(&config.TLSConfig{CAFile: s.caFile})
+	s.R().NoError(err)
+
+	resp, err := client.Get(s.server.URL)
+	s.R().NoError(err)
+	s.NoError(resp.Body.Close())
+}
+
+func (s *httpClientSuite) TestWithoutCAFile() {
+	client, err := external.NewHTTPClient(&config.TLSConfig{})
+	s.R().NoError(err)
+
+	_, err = client.Get(s.server.URL)
+	s.Error(err)
+}
+
+func (s *httpClientSuite) TestInsecureSkipVerify() {
+	client, err := external.NewHTTPClient(&config.TLSConfig{InsecureSkipVerify: true})
+	s.R().NoError(err)
+
+	resp, err := client.Get(s.server.URL)
+	s.R().NoError(err)
+	s.NoError(resp.Body.Close())
+}
+
+func (s *httpClientSuite) TestWithInvalidCAFile() {
+	s.R().NoError(os.WriteFile(s.caFile, []byte("invalid"), 0644))
+
+	_, err := external.NewHTTPClient(&config.TLSConfig{CAFile: s.caFile})
+	s.Error(err)
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// JournalEntry is an entry of the command journal. The schema is stable, fields
+// are only added but never changed.
+type JournalEntry struct {
+	Time    time.Time `json:"time"`
+	Node    string    `json:"node"`
+	Task    string    `json:"task,omitempty"`
+	Command string    `json:"command"`
+	Sudo    bool      `json:"sudo"`
+	// ExitCode is -1 if the command isn't run, e.g. the connection is broken.
+	ExitCode   int   `json:"exitCode"`
+	DurationMs int64 `json:"durationMs"`
+	// FreezeOverride is the reason of overriding the deployment freeze, it's only set in
+	// the entry of the override, which has no command.
+	FreezeOverride string `json:"freezeOverride,omitempty"`
+}
+
+// Journal is the append-only journal of commands executed on nodes, entries are
+// written as JSON lines. The file is created on the first entry.
+type Journal struct {
+	mu       sync.Mutex
+	path     string
+	observer func(*JournalEntry)
+}
+
+// NewJournal creates a journal written into the file.
+func NewJournal(path string) *Journal {
+	return &Journal{path: path}
+}
+
+// SetObserver sets the function called with each entry appended to the journal, e.g.
+// to record commands as events of the run.
+func (j *Journal) SetObserver(observer func(*JournalEntry)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.observer = observer
+}
+
+// Append appends the entry to the journal.
+func (j *Journal) Append(entry *JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data = append(data, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.observer != nil {
+		j.observer(entry)
+	}
+	if err = os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return errors.Annotatef(err, "create directory of %s", j.path)
+	}
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return errors.Trace(err)
+	}
+	return errors.Trace(f.Close())
+}
+
+// ReadJournal reads all entries of the journal file.
+func ReadJournal(path string) ([]*JournalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var entries []*JournalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		entry := new(JournalEntry)
+		if err = json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return nil, errors.Annotatef(err, "decode line %d of %s", line, path)
+		}
+		entries = append(entries, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return entries, nil
+}
+
+type journalTaskKey struct{}
+
+// WithTask returns the context of running the task, commands run with it are
+// journaled with the task name.
+func WithTask(ctx context.Context, task string) context.Context {
+	return context.WithValue(ctx, journalTaskKey{}, task)
+}
+
+func taskFromContext(ctx context.Context) string {
+	task, _ := ctx.Value(journalTaskKey{}).(string)
+	return task
+}
+
+// commandRecorder records commands run by a runner into the journal and latency stats.
+type commandRecorder struct {
+	journal *Journal
+	node    string
+	stats   *CommandStats
+}
+
+func (jr *commandRecorder) setJournal(journal *Journal, node string) {
+	jr.journal = journal
+	jr.node = node
+}
+
+func (jr *commandRecorder) setStats(stats *CommandStats) {
+	jr.stats = stats
+}
+
+func (jr *commandRecorder) record(ctx context.Context, command string, sudo bool, start time.Time, err error) {
+	duration := time.Since(start)
+	if jr.stats != nil {
+		jr.stats.add(duration)
+	}
+	if jr.journal == nil {
+		return
+	}
+	entry := &JournalEntry{
+		Time:       start.UTC(),
+		Node:       jr.node,
+		Task:       taskFromContext(ctx),
+		Command:    Redact(command),
+		Sudo:       sudo,
+		ExitCode:   ExitCode(err),
+		DurationMs: duration.Milliseconds(),
+	}
+	if appendErr := jr.journal.Append(entry); appendErr != nil {
+		logrus.Warnf("Failed to append to command journal: %v", appendErr)
+	}
+}
+
+// UseJournal makes commands run by the runner of the manager journaled as
+// commands of the node.
+func (em *Manager) UseJournal(journal *Journal, node string) {
+	if r, ok := em.Runner.(interface{ setJournal(*Journal, string) }); ok {
+		r.setJournal(journal, node)
+	}
+}
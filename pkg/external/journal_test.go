@@ -0,0 +1,81 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestJournalSuite(t *testing.T) {
+	suiteRun(t, new(journalSuite))
+}
+
+type journalSuite struct {
+	Suite
+	path string
+}
+
+func (s *journalSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.path = filepath.Join(s.T().TempDir(), "run", "journal.jsonl")
+}
+
+func (s *journalSuite) TestAppendAndRead() {
+	journal := external.NewJournal(s.path)
+	s.NoError(journal.Append(&external.JournalEntry{Node: "node1", Task: "task1", Command: "ls"}))
+	s.NoError(journal.Append(&external.JournalEntry{Node: "node2", Command: "false", ExitCode: 1}))
+
+	entries, err := external.ReadJournal(s.path)
+	s.NoError(err)
+	s.Len(entries, 2)
+	s.Equal("node1", entries[0].Node)
+	s.Equal("task1", entries[0].Task)
+	s.Equal("ls", entries[0].Command)
+	s.Equal(1, entries[1].ExitCode)
+}
+
+func (s *journalSuite) TestRunnerRecordsCommands() {
+	logger := log.Logger.Subscribe(log.FieldKeyNode, "local")
+	em := external.NewManager(external.NewLocalRunner(&external.LocalRunnerCfg{Logger: logger}), logger)
+	em.UseJournal(external.NewJournal(s.path), "local")
+	external.RegisterSecrets("journal-secret")
+
+	ctx := external.WithTask(s.Ctx(), "test task")
+	_, err := em.Runner.NonSudoExec(ctx, "/bin/sh", "-c", "true journal-secret")
+	s.NoError(err)
+	_, err = em.Runner.NonSudoExec(s.Ctx(), "/bin/sh", "-c", "exit 3")
+	s.Error(err)
+
+	entries, err := external.ReadJournal(s.path)
+	s.NoError(err)
+	s.Len(entries, 2)
+	s.Equal("local", entries[0].Node)
+	s.Equal("test task", entries[0].Task)
+	s.Equal("/bin/sh -c true xxxxx", entries[0].Command)
+	s.False(entries[0].Sudo)
+	s.Equal(0, entries[0].ExitCode)
+	s.Empty(entries[1].Task)
+	s.Equal(3, entries[1].ExitCode)
+}
+
+func (s *journalSuite) TestRedact() {
+	external.RegisterSecrets("redact-secret", "")
+	s.Equal("echo xxxxx", external.Redact("echo redact-secret"))
+	s.Equal("echo plain", external.Redact("echo plain"))
+}
@@ -20,31 +20,68 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
+	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
+	"golang.org/x/crypto/ssh"
+
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/log"
 )
 
+// localHost is the host of commands run by the local runner in activities.
+const localHost = "localhost"
+
 // LocalRunner implements RunInterface by running command on local host.
 type LocalRunner struct {
 	logger         log.Interface
 	maxExitTimeout time.Duration
+	commandTimeout time.Duration
 	user           string
 	password       string
+	env            map[string]string
+	commandRecorder
 }
 
 // NonSudoExec executes a command.
 func (r *LocalRunner) NonSudoExec(ctx context.Context, command string, args ...string) (string, error) {
+	start := time.Now()
+	done := trackCommand(ctx, localHost, strings.Join(append([]string{command}, args...), " "))
+	out, err := r.run(ctx, fmt.Sprintf("%s %s", command, strings.Join(args, " ")), command, args...)
+	done()
+	r.record(ctx, strings.Join(append([]string{command}, args...), " "), false, start, err)
+	return out, err
+}
+
+// run runs the command, cmdStr is the command in logs which never contains the env.
+func (r *LocalRunner) run(ctx context.Context, cmdStr, command string, args ...string) (string, error) {
+	return r.runTo(ctx, nil, cmdStr, command, args...)
+}
+
+// runTo runs the command, the stdout is written into stream as it's produced instead
+// of being returned if stream isn't nil. A streamed command isn't limited by the command
+// timeout.
+func (r *LocalRunner) runTo(ctx context.Context, stream io.Writer, cmdStr, command string, args ...string) (
+	string, error) {
+
+	if stream == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = Timeouts{Command: r.commandTimeout}.commandContext(ctx)
+		defer cancel()
+	}
+	start := time.Now()
 	checkErr := func(err error, errOut string) RunError {
 		switch err {
 		case context.Canceled:
 			return NewRunError(int(syscall.ECANCELED), "process canceled")
 		case context.DeadlineExceeded:
-			return NewRunError(int(syscall.ETIMEDOUT), "process timeout")
+			return NewRunError(int(syscall.ETIMEDOUT),
+				fmt.Sprintf("command exceeded %s", time.Since(start).Round(time.Second)))
 		default:
 			if err == nil {
 				return nil
@@ -52,21 +89,28 @@ func (r *LocalRunner) NonSudoExec(ctx context.Context, command string, args ...s
 
 			if msg, ok := err.(*exec.ExitError); ok {
 				return &runErrorImpl{
-					code: msg.Sys().(syscall.WaitStatus).ExitStatus(),
-					msg:  fmt.Sprintf("%s\n%s", err, errOut),
+					code:   msg.Sys().(syscall.WaitStatus).ExitStatus(),
+					msg:    fmt.Sprintf("%s\n%s", err, errOut),
+					stderr: errOut,
 				}
 			}
 			return &runErrorImpl{
-				code: -1,
-				msg:  fmt.Sprintf("%s\n%s", err, errOut),
+				code:   -1,
+				msg:    fmt.Sprintf("%s\n%s", err, errOut),
+				stderr: errOut,
 			}
 		}
 	}
 
-	cmdStr := fmt.Sprintf("%s %s", command, strings.Join(args, " "))
 	r.logger.Debugf("Run command: %s", cmdStr)
 	out := new(bytes.Buffer)
 	cmd := exec.Command(command, args...)
+	if len(r.env) > 0 {
+		cmd.Env = os.Environ()
+		for _, name := range slices.Sorted(maps.Keys(r.env)) {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", name, r.env[name]))
+		}
+	}
 	in, err := cmd.StdinPipe()
 	if err != nil {
 		return "", errors.Annotate(err, "get cmd stdinpipe")
@@ -76,9 +120,12 @@ func (r *LocalRunner) NonSudoExec(ctx context.Context, command string, args ...s
 		return "", errors.Annotate(err, "get cmd stderrpipe")
 	}
 	cmd.Stdout = out
+	if stream != nil {
+		cmd.Stdout = stream
+	}
 	errOutStr, err := r.runCtx(ctx, cmd, in, errOut)
 	if err != nil {
-		return "", checkErr(err, errOutStr)
+		return out.String(), checkErr(err, errOutStr)
 	}
 
 	if _, err = out.WriteString(errOutStr); err != nil {
@@ -92,13 +139,37 @@ func (r *LocalRunner) NonSudoExec(ctx context.Context, command string, args ...s
 
 // Exec executes a command with sudo
 func (r *LocalRunner) Exec(ctx context.Context, cmd string, args ...string) (string, error) {
-	return r.NonSudoExec(ctx, "sudo",
+	return r.execTo(ctx, nil, cmd, args...)
+}
+
+// Stream executes a command with sudo, and writes its stdout into w as it's produced
+// until the command exits or the context is done. It isn't limited by the command
+// timeout, so that it can follow logs.
+func (r *LocalRunner) Stream(ctx context.Context, w io.Writer, cmd string, args ...string) error {
+	_, err := r.execTo(ctx, w, cmd, args...)
+	return errors.Trace(err)
+}
+
+func (r *LocalRunner) execTo(ctx context.Context, stream io.Writer, cmd string, args ...string) (string, error) {
+	cmdStr := strings.Join(append([]string{cmd}, args...), " ")
+	logCmdStr := fmt.Sprintf("sudo -S /bin/bash -c %s", cmdStr)
+	journalCmdStr := cmdStr
+	// sudo resets the environment
+	if envCmd := envCommand(r.env); envCmd != "" {
+		cmdStr = fmt.Sprintf("%s %s", envCmd, cmdStr)
+	}
+	start := time.Now()
+	done := trackCommand(ctx, localHost, "sudo "+journalCmdStr)
+	out, err := r.runTo(ctx, stream, logCmdStr, "sudo",
 		[]string{
 			"-S",
 			"/bin/bash",
 			"-c",
-			strings.Join(append([]string{cmd}, args...), " "),
+			cmdStr,
 		}...)
+	done()
+	r.record(ctx, journalCmdStr, true, start, err)
+	return out, err
 }
 
 // Scp copy local file or dir to remote host.
@@ -127,16 +198,53 @@ func (r *LocalRunner) runCtx(ctx context.Context, cmd *exec.Cmd,
 		return "", err
 	}
 
+	requirePasswordPrefix := "[sudo] password for "
+	if r.user != "" {
+		requirePasswordPrefix = fmt.Sprintf("[sudo] password for %s: ", r.user)
+	}
+	// stderr is read along with waiting, so that the context interrupts a running command
+	var output []byte
+	done := make(chan error, 1)
+	go func() {
+		output = r.readErrOutput(errOut, in, requirePasswordPrefix)
+		done <- cmd.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		d := time.Since(startTime)
+		if err = cmd.Process.Kill(); err != nil {
+			return errOutStr, err
+		}
+		select {
+		case <-done:
+			errOutStr = strings.ReplaceAll(string(output), requirePasswordPrefix, "")
+		case <-time.After(maxExitTimeout):
+			r.logger.Warnf("Wait for command to exit timeout: %s", maxExitTimeout)
+			return errOutStr, errors.Errorf("wait process to exit timeout after %s", maxExitTimeout)
+		}
+		if b, ok := cmd.Stdout.(*bytes.Buffer); ok {
+			r.logger.Warnf("Process was killed after %v: %s %s\nstdout: %v\nstderr: %v",
+				d.Round(100*time.Millisecond), cmd.Path, cmd.Args, b.String(), cmd.Stderr)
+		} else {
+			// Reduce time accuracy, avoid frequent log changes that affect logger rate limit
+			r.logger.Warnf("Process was killed after %v: %s %s\nstdout: %v\nstderr: %v",
+				d.Round(100*time.Millisecond), cmd.Path, cmd.Args, cmd.Stdout, cmd.Stderr)
+		}
+		return errOutStr, ctx.Err()
+	case err = <-done:
+		return strings.ReplaceAll(string(output), requirePasswordPrefix, ""), err
+	}
+}
+
+// readErrOutput reads stderr until EOF, the sudo password is input when it's required.
+func (r *LocalRunner) readErrOutput(errOut io.Reader, in io.Writer, requirePasswordPrefix string) []byte {
 	var (
 		output       []byte
 		line         = ""
 		errOutReader = bufio.NewReader(errOut)
 	)
-
-	requirePasswordPrefix := "[sudo] password for "
-	if r.user != "" {
-		requirePasswordPrefix = fmt.Sprintf("[sudo] password for %s: ", r.user)
-	}
 	for {
 		b, err := errOutReader.ReadByte()
 		if err != nil {
@@ -165,38 +273,7 @@ func (r *LocalRunner) runCtx(ctx context.Context, cmd *exec.Cmd,
 			}
 		}
 	}
-	errOutStr = strings.ReplaceAll(string(output), requirePasswordPrefix, "")
-
-	done := make(chan error)
-	go func() {
-		done <- cmd.Wait()
-		close(done)
-	}()
-
-	select {
-	case <-ctx.Done():
-		d := time.Since(startTime)
-		if err = cmd.Process.Kill(); err != nil {
-			return errOutStr, err
-		}
-		select {
-		case <-done:
-		case <-time.After(maxExitTimeout):
-			r.logger.Warnf("Wait for command to exit timeout: %s", maxExitTimeout)
-			return errOutStr, errors.Errorf("wait process to exit timeout after %s", maxExitTimeout)
-		}
-		if b, ok := cmd.Stdout.(*bytes.Buffer); ok {
-			r.logger.Warnf("Process was killed after %v: %s %s\nstdout: %v\nstderr: %v",
-				d.Round(100*time.Millisecond), cmd.Path, cmd.Args, b.String(), cmd.Stderr)
-		} else {
-			// Reduce time accuracy, avoid frequent log changes that affect logger rate limit
-			r.logger.Warnf("Process was killed after %v: %s %s\nstdout: %v\nstderr: %v",
-				d.Round(100*time.Millisecond), cmd.Path, cmd.Args, cmd.Stdout, cmd.Stderr)
-		}
-		return errOutStr, ctx.Err()
-	case err = <-done:
-		return errOutStr, err
-	}
+	return output
 }
 
 // RunError is the wrapper of os.exec error, it export error code
@@ -207,6 +284,22 @@ type RunError interface {
 	ExitCodeIn(...syscall.Errno) bool
 }
 
+// ExitCode returns exit code of the command which failed with the error of
+// runners. It returns 0 if err is nil, and -1 if the exit code is unknown.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	switch e := errors.Cause(err).(type) {
+	case RunError:
+		return e.ExitCode()
+	case *ssh.ExitError:
+		return e.ExitStatus()
+	default:
+		return -1
+	}
+}
+
 // NewRunError is used to get the RunError
 func NewRunError(code int, msg string) (err RunError) {
 	return &runErrorImpl{
@@ -216,14 +309,20 @@ func NewRunError(code int, msg string) (err RunError) {
 }
 
 type runErrorImpl struct {
-	code int
-	msg  string
+	code   int
+	msg    string
+	stderr string
 }
 
 func (e runErrorImpl) Error() string {
 	return e.msg
 }
 
+// Stderr returns stderr of the failed command.
+func (e runErrorImpl) Stderr() string {
+	return e.stderr
+}
+
 func (e runErrorImpl) ExitCode() int {
 	return e.code
 }
@@ -245,8 +344,12 @@ func (e runErrorImpl) ExitCodeIn(errnos ...syscall.Errno) bool {
 type LocalRunnerCfg struct {
 	Logger         log.Interface
 	MaxExitTimeout *time.Duration
+	// CommandTimeout limits a command whose context has no deadline, zero means no limit.
+	CommandTimeout time.Duration
 	User           string
 	Password       string
+	// Env is the environment of all commands run by the runner.
+	Env map[string]string
 }
 
 // NewLocalRunner creates a local runner.
@@ -259,7 +362,9 @@ func NewLocalRunner(cfg *LocalRunnerCfg) *LocalRunner {
 	return &LocalRunner{
 		logger:         cfg.Logger,
 		maxExitTimeout: maxExitTimeout,
+		commandTimeout: cfg.CommandTimeout,
 		user:           cfg.User,
 		password:       cfg.Password,
+		env:            cfg.Env,
 	}
 }
@@ -0,0 +1,145 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestLocalRunnerSuite(t *testing.T) {
+	suiteRun(t, new(localRunnerSuite))
+}
+
+type localRunnerSuite struct {
+	Suite
+	runner *external.LocalRunner
+}
+
+func (s *localRunnerSuite) SetupTest() {
+	s.Suite.SetupTest()
+	s.runner = external.NewLocalRunner(&external.LocalRunnerCfg{
+		Logger: log.Logger.Subscribe(log.FieldKeyNode, "local"),
+	})
+}
+
+func (s *localRunnerSuite) TestExitCode() {
+	_, err := s.runner.NonSudoExec(s.Ctx(), "/bin/sh", "-c", "exit 3")
+	s.Error(err)
+	s.Equal(3, external.ExitCode(errors.Trace(err)))
+
+	_, err = s.runner.NonSudoExec(s.Ctx(), "/bin/sh", "-c", "true")
+	s.NoError(err)
+	s.Equal(0, external.ExitCode(err))
+
+	s.Equal(int(syscall.ETIMEDOUT), external.ExitCode(external.NewRunError(int(syscall.ETIMEDOUT), "timeout")))
+	s.Equal(-1, external.ExitCode(errors.New("dummy error")))
+}
+
+func (s *localRunnerSuite) TestOutputOnFailure() {
+	out, err := s.runner.NonSudoExec(s.Ctx(), "/bin/sh", "-c", "echo out; exit 1")
+	s.Error(err)
+	s.Equal("out\n", out)
+}
+
+func (s *localRunnerSuite) TestEnv() {
+	runner := external.NewLocalRunner(&external.LocalRunnerCfg{
+		Logger: log.Logger.Subscribe(log.FieldKeyNode, "local"),
+		Env:    map[string]string{"M3FS_TEST_ENV": "it's a value"},
+	})
+
+	out, err := runner.NonSudoExec(s.Ctx(), "/bin/sh", "-c", "echo $M3FS_TEST_ENV")
+	s.NoError(err)
+	s.Equal("it's a value\n", out)
+}
+
+func (s *localRunnerSuite) TestCommandTimeout() {
+	runner := external.NewLocalRunner(&external.LocalRunnerCfg{
+		Logger:         log.Logger.Subscribe(log.FieldKeyNode, "local"),
+		CommandTimeout: 100 * time.Millisecond,
+	})
+
+	_, err := runner.NonSudoExec(s.Ctx(), "sleep", "5")
+	s.Error(err)
+	s.Contains(err.Error(), "command exceeded")
+	s.Equal(int(syscall.ETIMEDOUT), external.ExitCode(err))
+
+	// the deadline of the context takes precedence
+	ctx, cancel := context.WithTimeout(s.Ctx(), 5*time.Second)
+	defer cancel()
+	_, err = runner.NonSudoExec(ctx, "sleep", "0.2")
+	s.NoError(err)
+}
+
+func (s *localRunnerSuite) TestActivity() {
+	activity := external.NewActivity()
+	ctx := external.WithActivity(s.Ctx(), activity)
+	before := activity.Last()
+
+	done := make(chan error)
+	go func() {
+		_, err := s.runner.NonSudoExec(ctx, "sleep", "0.3")
+		done <- err
+	}()
+	s.Eventually(func() bool { return len(activity.InFlight()) == 1 }, time.Second, 10*time.Millisecond)
+	inFlight := activity.InFlight()[0]
+	s.Equal("localhost", inFlight.Host)
+	s.Equal("sleep 0.3", inFlight.Command)
+
+	s.NoError(<-done)
+	s.Empty(activity.InFlight())
+	s.True(activity.Last().After(before))
+}
+
+func (s *localRunnerSuite) TestStats() {
+	logger := log.Logger.Subscribe(log.FieldKeyNode, "local")
+	em := external.NewManager(s.runner, logger)
+	em.Node = "node1"
+	s.Equal(external.StatsSummary{Node: "node1"}, em.Stats())
+
+	for range 3 {
+		_, err := s.runner.NonSudoExec(s.Ctx(), "/bin/sh", "-c", "true")
+		s.NoError(err)
+	}
+	_, err := s.runner.NonSudoExec(s.Ctx(), "/bin/sh", "-c", "sleep 0.2")
+	s.NoError(err)
+
+	stats := em.Stats()
+	s.Equal("node1", stats.Node)
+	s.Equal(4, stats.Count)
+	s.Less(stats.P50, 200*time.Millisecond)
+	s.GreaterOrEqual(stats.P95, 200*time.Millisecond)
+	s.Equal(stats.P95, stats.Max)
+	s.GreaterOrEqual(stats.Total, stats.Max)
+	s.Regexp(`^node1: 4 commands, total \S+, p50 \S+, p95 \S+, max \S+$`, stats.String())
+
+	// managers of the same node share stats
+	shared := external.NewCommandStats()
+	em.UseStats(shared)
+	other := external.NewManager(external.NewLocalRunner(&external.LocalRunnerCfg{Logger: logger}), logger)
+	other.UseStats(shared)
+	_, err = s.runner.NonSudoExec(s.Ctx(), "true")
+	s.NoError(err)
+	_, err = other.Runner.NonSudoExec(s.Ctx(), "true")
+	s.NoError(err)
+	s.Equal(2, em.Stats().Count)
+	s.Equal(2, shared.Summary("node1").Count)
+}
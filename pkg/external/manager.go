@@ -16,6 +16,7 @@ package external
 
 import (
 	"context"
+	"net/http"
 	"sync"
 
 	"github.com/open3fs/m3fs/pkg/config"
@@ -37,20 +38,11 @@ func (eb *externalBase) init(em *Manager, logger log.Interface) {
 	eb.logger = logger
 }
 
-func (eb *externalBase) run(ctx context.Context, cmdName string, args ...string) (string, error) {
-	anyArgs := []any{}
-	for _, arg := range args {
-		anyArgs = append(anyArgs, arg)
-	}
-
-	cmd := NewCommand(cmdName, anyArgs...)
-	cmd.runner = eb.em.Runner
-	out, err := cmd.SudoExec(ctx)
-	if err != nil {
-		return out, errors.Annotatef(err, "sudo run cmd [%s]", cmd.String())
-	}
-	return out, nil
-
+// run runs the command with sudo, the result is returned along with the error if the
+// command fails.
+func (eb *externalBase) run(ctx context.Context, cmdName string, args ...string) (*CommandResult, error) {
+	result, err := eb.em.Run(ctx, cmdName, args...)
+	return result, errors.Trace(err)
 }
 
 // create a new external
@@ -70,11 +62,19 @@ func registerNewExternalFunc(f newExternalFunc) {
 // Manager provides a way to use all external interfaces
 type Manager struct {
 	Runner RunnerInterface
+	// Node is the name of the node commands are run on, it's carried by results of commands.
+	Node string
 
 	Net    NetInterface
 	Docker DockerInterface
 	Disk   DiskInterface
 	FS     FSInterface
+
+	// HTTPClient is used by HTTP downloads, http.DefaultClient is used if it's nil.
+	HTTPClient *http.Client
+
+	logger log.Interface
+	stats  *CommandStats
 }
 
 // NewManagerFunc type of new manager func.
@@ -84,17 +84,21 @@ type NewManagerFunc func() *Manager
 func NewManager(runner RunnerInterface, logger log.Interface) (em *Manager) {
 	em = &Manager{
 		Runner: runner,
+		logger: logger,
 	}
 	for _, newExternal := range newExternals {
 		newExternal().init(em, logger)
 	}
+	em.UseStats(NewCommandStats())
 	return em
 }
 
 var remoteManagerCache sync.Map
 
 // NewRemoteRunnerManager create a new remote runner manager
-func NewRemoteRunnerManager(node *config.Node, logger log.Interface) (*Manager, error) {
+func NewRemoteRunnerManager(
+	node *config.Node, hostKey *HostKeyCfg, timeouts Timeouts, logger log.Interface) (*Manager, error) {
+
 	mgr, ok := remoteManagerCache.Load(node)
 	if ok {
 		return mgr.(*Manager), nil
@@ -105,11 +109,23 @@ func NewRemoteRunnerManager(node *config.Node, logger log.Interface) (*Manager,
 		TargetHost: node.Host,
 		TargetPort: node.Port,
 		Logger:     logger,
-		// TODO: add timeout config
+		Env:        node.Env,
+		HostKey:    hostKey,
+		Timeouts:   timeouts,
 	})
 	if err != nil {
 		return nil, errors.Annotatef(err, "create remote runner for node [%s]", node.Name)
 	}
 
-	return NewManager(runner, logger), nil
+	em := NewManager(runner, logger)
+	em.Node = node.Name
+	return em, nil
+}
+
+// Close closes the connection of the runner to the node if it has one, the manager can't
+// run commands afterwards.
+func (em *Manager) Close() {
+	if closer, ok := em.Runner.(interface{ Close() }); ok {
+		closer.Close()
+	}
 }
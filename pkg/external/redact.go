@@ -0,0 +1,50 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"strings"
+	"sync"
+)
+
+// redactedText replaces secrets in redacted text.
+const redactedText = "xxxxx"
+
+// secretRegistry is the registry of secrets which are redacted from outputs.
+var secretRegistry = struct {
+	sync.RWMutex
+	secrets map[string]struct{}
+}{secrets: make(map[string]struct{})}
+
+// RegisterSecrets registers secrets which are redacted by Redact.
+func RegisterSecrets(secrets ...string) {
+	secretRegistry.Lock()
+	defer secretRegistry.Unlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			secretRegistry.secrets[secret] = struct{}{}
+		}
+	}
+}
+
+// Redact replaces registered secrets in the text.
+func Redact(text string) string {
+	secretRegistry.RLock()
+	defer secretRegistry.RUnlock()
+	for secret := range secretRegistry.secrets {
+		text = strings.ReplaceAll(text, secret, redactedText)
+	}
+	return text
+}
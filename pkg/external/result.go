@@ -0,0 +1,142 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// maxStderrExcerpt is the maximum length of the excerpt of stderr in errors of commands.
+const maxStderrExcerpt = 512
+
+// CommandResult is the result of a command run on a node.
+type CommandResult struct {
+	Node    string
+	Command string
+	Stdout  string
+	// Stderr is the stderr of the command if the runner separates it from stdout, remote
+	// commands run in a pty which merges stderr into stdout.
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+}
+
+// Success returns whether the command exited with code 0.
+func (r *CommandResult) Success() bool {
+	return r.ExitCode == 0
+}
+
+// TrimmedStdout returns stdout without leading and trailing white spaces.
+func (r *CommandResult) TrimmedStdout() string {
+	return strings.TrimSpace(r.Stdout)
+}
+
+// Lines returns non-empty lines of stdout without leading and trailing white spaces.
+func (r *CommandResult) Lines() []string {
+	var lines []string
+	for _, line := range strings.Split(r.Stdout, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// JSON unmarshals stdout as JSON into v.
+func (r *CommandResult) JSON(v any) error {
+	if err := json.Unmarshal([]byte(r.TrimmedStdout()), v); err != nil {
+		return errors.Annotatef(err, "parse output of `%s` on %s as JSON", r.Command, r.Node)
+	}
+	return nil
+}
+
+// stderrExcerpt returns the tail of stderr of at most maxStderrExcerpt bytes.
+func (r *CommandResult) stderrExcerpt() string {
+	stderr := strings.TrimSpace(r.Stderr)
+	if len(stderr) > maxStderrExcerpt {
+		stderr = "..." + stderr[len(stderr)-maxStderrExcerpt:]
+	}
+	return stderr
+}
+
+// annotate annotates the error of the command with the node, exit code and the excerpt of
+// stderr unless the error has carried it.
+func (r *CommandResult) annotate(err error) error {
+	msg := fmt.Sprintf("run `%s` on %s", r.Command, r.Node)
+	if r.ExitCode > 0 {
+		msg += fmt.Sprintf(", exit code %d", r.ExitCode)
+	}
+	if excerpt := r.stderrExcerpt(); excerpt != "" && !strings.Contains(err.Error(), excerpt) {
+		msg += fmt.Sprintf(", stderr: %s", excerpt)
+	}
+	return errors.Annotate(err, msg)
+}
+
+// Run runs the command with sudo and returns its result, which is returned along with
+// the error if the command fails.
+func (em *Manager) Run(ctx context.Context, command string, args ...string) (*CommandResult, error) {
+	return em.runCommand(ctx, true, command, args...)
+}
+
+// RunNonSudo is Run without sudo.
+func (em *Manager) RunNonSudo(ctx context.Context, command string, args ...string) (*CommandResult, error) {
+	return em.runCommand(ctx, false, command, args...)
+}
+
+func (em *Manager) runCommand(ctx context.Context, sudo bool, command string, args ...string) (
+	*CommandResult, error) {
+
+	result := &CommandResult{
+		Node:    em.nodeName(),
+		Command: strings.Join(append([]string{command}, args...), " "),
+	}
+	start := time.Now()
+	var err error
+	if sudo {
+		result.Stdout, err = em.Runner.Exec(ctx, command, args...)
+	} else {
+		result.Stdout, err = em.Runner.NonSudoExec(ctx, command, args...)
+	}
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.ExitCode = ExitCode(err)
+		result.Stderr = Stderr(err)
+		return result, result.annotate(err)
+	}
+	return result, nil
+}
+
+// nodeName returns the name of the node of the manager, which is localhost if it's unknown.
+func (em *Manager) nodeName() string {
+	if em.Node != "" {
+		return em.Node
+	}
+	return localHost
+}
+
+// Stderr returns stderr of the command which failed with the error of runners, it's
+// empty if the runner doesn't separate stderr.
+func Stderr(err error) string {
+	if e, ok := errors.Cause(err).(interface{ Stderr() string }); ok {
+		return e.Stderr()
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external_test
+
+import (
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestCommandResultSuite(t *testing.T) {
+	suiteRun(t, new(commandResultSuite))
+}
+
+type commandResultSuite struct {
+	Suite
+}
+
+func (s *commandResultSuite) TestHelpers() {
+	result := &external.CommandResult{
+		Node:    "node1",
+		Command: "docker inspect",
+		Stdout:  "\n  {\"id\": \"abc\"}  \n\n line2\n",
+	}
+	s.True(result.Success())
+	s.Equal("{\"id\": \"abc\"}  \n\n line2", result.TrimmedStdout())
+	s.Equal([]string{"{\"id\": \"abc\"}", "line2"}, result.Lines())
+
+	result.ExitCode = 1
+	s.False(result.Success())
+}
+
+func (s *commandResultSuite) TestJSON() {
+	result := &external.CommandResult{Node: "node1", Command: "docker inspect", Stdout: " {\"id\": \"abc\"}\n"}
+	var v struct {
+		ID string `json:"id"`
+	}
+	s.NoError(result.JSON(&v))
+	s.Equal("abc", v.ID)
+
+	result.Stdout = "not json"
+	err := result.JSON(&v)
+	s.Error(err)
+	s.Contains(err.Error(), "parse output of `docker inspect` on node1 as JSON")
+}
+
+func (s *commandResultSuite) TestRun() {
+	s.em.Node = "node1"
+	s.r.MockExec("ls /tmp", "a\nb\n", nil)
+
+	result, err := s.em.Run(s.Ctx(), "ls", "/tmp")
+	s.NoError(err)
+	s.Equal("node1", result.Node)
+	s.Equal("ls /tmp", result.Command)
+	s.Equal([]string{"a", "b"}, result.Lines())
+	s.Equal(0, result.ExitCode)
+}
+
+func (s *commandResultSuite) TestRunFailed() {
+	s.r.MockExec("ls /none", "", external.NewRunError(2, "no such file"))
+
+	result, err := s.em.Run(s.Ctx(), "ls", "/none")
+	s.Error(err)
+	s.Equal(2, result.ExitCode)
+	s.Contains(err.Error(), "run `ls /none` on localhost, exit code 2")
+	s.Equal(2, external.ExitCode(errors.Trace(err)))
+}
+
+func (s *commandResultSuite) TestRunStderr() {
+	em := external.NewManager(external.NewLocalRunner(&external.LocalRunnerCfg{
+		Logger: log.Logger.Subscribe(log.FieldKeyNode, "local"),
+	}), log.Logger)
+	em.Node = "local"
+
+	result, err := em.RunNonSudo(s.Ctx(), "/bin/sh", "-c", "echo out; echo oops >&2; exit 3")
+	s.Error(err)
+	s.Equal("out\n", result.Stdout)
+	s.Equal("oops\n", result.Stderr)
+	s.Equal(3, result.ExitCode)
+	s.Equal("oops\n", external.Stderr(err))
+	// stderr carried by the error of the runner isn't repeated
+	s.NotContains(err.Error(), "stderr:")
+	s.Contains(err.Error(), "on local, exit code 3")
+}
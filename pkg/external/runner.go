@@ -16,6 +16,7 @@ package external
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -42,6 +43,30 @@ type RunnerInterface interface {
 	Scp(ctx context.Context, local, remote string) error
 }
 
+// StreamRunner is implemented by runners which stream outputs of commands.
+type StreamRunner interface {
+	// Stream executes a command with sudo, and writes its output into w line by line
+	// as it's produced until the command exits or the context is done.
+	Stream(ctx context.Context, w io.Writer, command string, args ...string) error
+}
+
+// Timeouts defines timeouts of running commands on a node, zero means no timeout.
+type Timeouts struct {
+	// Connect limits connecting to the node, including the SSH handshake.
+	Connect time.Duration
+	// Command limits a command whose context has no deadline.
+	Command time.Duration
+}
+
+// commandContext returns the context limited by the command timeout if the context
+// has no deadline.
+func (t Timeouts) commandContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || t.Command <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, t.Command)
+}
+
 // RemoteRunner implements RunInterface by running command on a remote host.
 type RemoteRunner struct {
 	mu         sync.Mutex
@@ -50,12 +75,33 @@ type RemoteRunner struct {
 	sftpClient *sftp.Client
 	user       string
 	password   string
+	envCmd     string
+	timeouts   Timeouts
+	host       string
+	commandRecorder
+}
+
+func (r *RemoteRunner) exec(ctx context.Context, cmd string, sudo bool) (string, error) {
+	return r.execTo(ctx, cmd, sudo, nil)
 }
 
-func (r *RemoteRunner) exec(cmd string) (string, error) {
+// execTo runs the command, the output is written into stream line by line as it's
+// produced instead of being returned if stream isn't nil. A streamed command isn't
+// limited by the command timeout.
+func (r *RemoteRunner) execTo(ctx context.Context, cmd string, sudo bool, stream io.Writer) (string, error) {
+	runCmd := cmd
+	if r.envCmd != "" {
+		runCmd = fmt.Sprintf("%s %s", r.envCmd, cmd)
+	}
+	if sudo {
+		cmd = fmt.Sprintf("sudo %s", cmd)
+		runCmd = fmt.Sprintf("sudo %s", runCmd)
+	}
+
 	session, err := r.newSession()
 	if err != nil {
-		return "", errors.Trace(err)
+		// the connection is lost if a session can't be opened on it
+		return "", newUnreachableError(r.host, errors.Trace(err))
 	}
 	defer func() {
 		if err := session.Close(); err != nil && !errors.Is(err, io.EOF) {
@@ -73,18 +119,61 @@ func (r *RemoteRunner) exec(cmd string) (string, error) {
 	}
 
 	r.log.Debugf("Run command: %s", cmd)
-	err = session.Start(cmd)
+	err = session.Start(runCmd)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
 
+	if stream == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = r.timeouts.commandContext(ctx)
+		defer cancel()
+	}
+	start := time.Now()
+	requirePasswordPrefix := fmt.Sprintf("[sudo] password for %s: ", r.user)
+	var output []byte
+	done := make(chan error, 1)
+	go func() {
+		output = r.readOutput(out, in, requirePasswordPrefix, stream)
+		done <- session.Wait()
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// closing the session stops reading the output
+		_ = session.Signal(ssh.SIGKILL)
+		_ = session.Close()
+		<-done
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = errors.Errorf("command exceeded %s", time.Since(start).Round(time.Second))
+		} else {
+			err = ctx.Err()
+		}
+	}
+	outStr := strings.ReplaceAll(string(output), requirePasswordPrefix, "")
+	if stream == nil {
+		r.log.Debugf("Output of `%s`: %s", cmd, outStr)
+	}
+	var exitMissingErr *ssh.ExitMissingError
+	if errors.As(err, &exitMissingErr) {
+		// the command is gone without exit status when the connection is lost
+		err = newUnreachableError(r.host, err)
+	}
+	if err != nil {
+		return outStr, errors.Annotatef(err, "run `%s` failed", cmd)
+	}
+
+	return outStr, nil
+}
+
+// readOutput reads the output until EOF, the sudo password is input when it's required.
+// If stream isn't nil, lines of the output are written into it instead of being returned.
+func (r *RemoteRunner) readOutput(out io.Reader, in io.Writer, requirePasswordPrefix string, stream io.Writer) []byte {
 	var (
 		output    []byte
 		line      = ""
 		outReader = bufio.NewReader(out)
 	)
-
-	requirePasswordPrefix := fmt.Sprintf("[sudo] password for %s: ", r.user)
 	for {
 		b, err := outReader.ReadByte()
 		if err != nil {
@@ -97,6 +186,13 @@ func (r *RemoteRunner) exec(cmd string) (string, error) {
 		output = append(output, b)
 		if b == byte('\n') {
 			line = ""
+			if stream != nil {
+				if _, err = stream.Write(bytes.ReplaceAll(output, []byte(requirePasswordPrefix), nil)); err != nil {
+					r.log.Debugf("Failed to write streamed output: %s", err)
+					break
+				}
+				output = output[:0]
+			}
 			continue
 		}
 
@@ -113,34 +209,45 @@ func (r *RemoteRunner) exec(cmd string) (string, error) {
 			}
 		}
 	}
-
-	err = session.Wait()
-	outStr := strings.ReplaceAll(string(output), requirePasswordPrefix, "")
-	r.log.Debugf("Output of `%s`: %s", cmd, outStr)
-	if err != nil {
-		return "", errors.Annotatef(err, "run `%s` failed", cmd)
-	}
-
-	return outStr, nil
+	return output
 }
 
 // NonSudoExec executes a command.
 func (r *RemoteRunner) NonSudoExec(ctx context.Context, command string, args ...string) (string, error) {
 	cmdStr := strings.Join(append([]string{command}, args...), " ")
-	out, err := r.exec(cmdStr)
+	start := time.Now()
+	done := trackCommand(ctx, r.host, cmdStr)
+	out, err := r.exec(ctx, cmdStr, false)
+	done()
+	r.record(ctx, cmdStr, false, start, err)
 	if err != nil {
-		return "", errors.Trace(err)
+		return out, errors.Trace(err)
 	}
 
 	return out, nil
 }
 
+// Stream executes a command with sudo, and writes its output into w line by line as
+// it's produced until the command exits or the context is done. It isn't limited by
+// the command timeout, so that it can follow logs.
+func (r *RemoteRunner) Stream(ctx context.Context, w io.Writer, command string, args ...string) error {
+	cmdStr := strings.Join(append([]string{command}, args...), " ")
+	start := time.Now()
+	_, err := r.execTo(ctx, cmdStr, true, w)
+	r.record(ctx, cmdStr, true, start, err)
+	return errors.Trace(err)
+}
+
 // Exec executes a command with sudo.
 func (r *RemoteRunner) Exec(ctx context.Context, command string, args ...string) (string, error) {
 	cmdStr := strings.Join(append([]string{command}, args...), " ")
-	out, err := r.exec(fmt.Sprintf("sudo %s", cmdStr))
+	start := time.Now()
+	done := trackCommand(ctx, r.host, "sudo "+cmdStr)
+	out, err := r.exec(ctx, cmdStr, true)
+	done()
+	r.record(ctx, cmdStr, true, start, err)
 	if err != nil {
-		return "", errors.Trace(err)
+		return out, errors.Trace(err)
 	}
 
 	return out, nil
@@ -186,8 +293,14 @@ func (r *RemoteRunner) Close() {
 }
 
 // Scp copy local file or dir to remote host.
-func (r *RemoteRunner) Scp(ctx context.Context, local, remote string) error {
+func (r *RemoteRunner) Scp(ctx context.Context, local, remote string) (err error) {
 	r.log.Debugf("Scp %s on the local node to %s on the remote node", local, remote)
+	start := time.Now()
+	done := trackCommand(ctx, r.host, fmt.Sprintf("scp %s %s", local, remote))
+	defer func() {
+		done()
+		r.record(ctx, fmt.Sprintf("scp %s %s", local, remote), false, start, err)
+	}()
 	f, err := os.Stat(local)
 	if err != nil {
 		return errors.Trace(err)
@@ -266,7 +379,11 @@ type RemoteRunnerCfg struct {
 	TargetPort int
 	PrivateKey *string
 	Logger     log.Interface
-	Timeout    time.Duration
+	Timeouts   Timeouts
+	// Env is the environment of all commands run by the runner.
+	Env map[string]string
+	// HostKey defines how the host key is verified, it's required.
+	HostKey *HostKeyCfg
 }
 
 // NewRemoteRunner creates a remote runner.
@@ -286,16 +403,22 @@ func NewRemoteRunner(cfg *RemoteRunnerCfg) (*RemoteRunner, error) {
 	if cfg.Password != nil {
 		authMethods = append(authMethods, ssh.Password(*cfg.Password))
 	}
+	if cfg.HostKey == nil {
+		return nil, errors.New("host key config is required")
+	}
+	hostKeyCallback, err := NewHostKeyCallback(cfg.HostKey, cfg.Logger)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	sshConfig := &ssh.ClientConfig{
 		User:            cfg.Username,
-		Timeout:         cfg.Timeout,
 		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: hostKeyCallback,
 	}
 	endpoint := net.JoinHostPort(cfg.TargetHost, strconv.Itoa(cfg.TargetPort))
-	sshClient, err := ssh.Dial("tcp", endpoint, sshConfig)
+	sshClient, err := dialSSH(endpoint, sshConfig, cfg.Timeouts.Connect)
 	if err != nil {
-		return nil, errors.Annotatef(err, "establish connection to %s", endpoint)
+		return nil, errors.Trace(err)
 	}
 	sftpClient, err := sftp.NewClient(sshClient)
 	if err != nil {
@@ -306,10 +429,52 @@ func NewRemoteRunner(cfg *RemoteRunnerCfg) (*RemoteRunner, error) {
 		log:        cfg.Logger,
 		sshClient:  sshClient,
 		sftpClient: sftpClient,
+		envCmd:     envCommand(cfg.Env),
+		timeouts:   cfg.Timeouts,
+		host:       cfg.TargetHost,
 	}
 	if cfg.Password != nil {
 		runner.password = *cfg.Password
 	}
+	if len(cfg.Env) > 0 {
+		cfg.Logger.Debugf("Run commands with env: %s", redactedEnv(cfg.Env))
+	}
 
 	return runner, nil
 }
+
+// dialSSH connects to the endpoint by SSH, the timeout limits both dialing and the
+// handshake, so that an unreachable or hanging node fails fast.
+func dialSSH(endpoint string, sshConfig *ssh.ClientConfig, timeout time.Duration) (*ssh.Client, error) {
+	conn, err := net.DialTimeout("tcp", endpoint, timeout)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, newUnreachableError(endpoint,
+				errors.Errorf("failed to connect to %s within %s", endpoint, timeout))
+		}
+		return nil, newUnreachableError(endpoint, errors.Annotatef(err, "establish connection to %s", endpoint))
+	}
+	if timeout > 0 {
+		if err = conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			_ = conn.Close()
+			return nil, errors.Trace(err)
+		}
+	}
+	start := time.Now()
+	c, chans, reqs, err := ssh.NewClientConn(conn, endpoint, sshConfig)
+	if err != nil {
+		_ = conn.Close()
+		// the error of the handshake interrupted by the deadline isn't always a timeout error
+		if timeout > 0 && time.Since(start) >= timeout {
+			return nil, newUnreachableError(endpoint,
+				errors.Errorf("failed to connect to %s within %s: SSH handshake timed out", endpoint, timeout))
+		}
+		return nil, errors.Annotatef(err, "establish connection to %s", endpoint)
+	}
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		_ = c.Close()
+		return nil, errors.Trace(err)
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestRemoteRunnerSuite(t *testing.T) {
+	suiteRun(t, new(remoteRunnerSuite))
+}
+
+type remoteRunnerSuite struct {
+	Suite
+}
+
+func (s *remoteRunnerSuite) TestConnectTimeout() {
+	// the listener accepts connections but never starts the SSH handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.NoError(err)
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	start := time.Now()
+	_, err = external.NewRemoteRunner(&external.RemoteRunnerCfg{
+		Username:   "root",
+		Password:   common.Pointer("password"),
+		TargetHost: addr.IP.String(),
+		TargetPort: addr.Port,
+		Logger:     log.Logger.Subscribe(log.FieldKeyNode, "node1"),
+		Timeouts:   external.Timeouts{Connect: 200 * time.Millisecond},
+		HostKey:    &external.HostKeyCfg{Policy: config.HostKeyPolicyInsecureIgnore},
+	})
+	s.Error(err)
+	s.Contains(err.Error(), "failed to connect to "+addr.String()+" within 200ms")
+	s.Less(time.Since(start), 5*time.Second)
+	s.True(external.IsUnreachable(err))
+	s.Equal(external.ConnectFailureTimeout, external.ClassifyConnectError(err))
+}
+
+func (s *remoteRunnerSuite) TestConnectRefused() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.NoError(err)
+	addr := listener.Addr().(*net.TCPAddr)
+	s.NoError(listener.Close())
+
+	_, err = external.NewRemoteRunner(&external.RemoteRunnerCfg{
+		Username:   "root",
+		Password:   common.Pointer("password"),
+		TargetHost: addr.IP.String(),
+		TargetPort: addr.Port,
+		Logger:     log.Logger.Subscribe(log.FieldKeyNode, "node1"),
+		Timeouts:   external.Timeouts{Connect: time.Second},
+		HostKey:    &external.HostKeyCfg{Policy: config.HostKeyPolicyInsecureIgnore},
+	})
+	s.Error(err)
+	s.True(external.IsUnreachable(errors.Annotate(err, "create remote runner")))
+	s.False(external.IsUnreachable(errors.New("run `false` failed: exit status 1")))
+	s.Equal(external.ConnectFailureRefused,
+		external.ClassifyConnectError(errors.Annotate(err, "create remote runner")))
+}
+
+func (s *remoteRunnerSuite) TestClassifyConnectError() {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "node1.invalid", IsNotFound: true}
+	cases := []struct {
+		err      error
+		expected external.ConnectFailure
+	}{
+		{nil, external.ConnectFailureNone},
+		{errors.Annotate(&net.OpError{Op: "dial", Net: "tcp", Err: dnsErr}, "establish connection"),
+			external.ConnectFailureDNS},
+		{errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none password], " +
+			"no supported methods remain"), external.ConnectFailureAuth},
+		{errors.New("ssh: handshake failed: REMOTE HOST IDENTIFICATION OF 10.0.0.1:22 HAS CHANGED!"),
+			external.ConnectFailureHostKeyChanged},
+		{errors.New("ssh: handshake failed: host key of 10.0.0.1:22 is unknown, add it to " +
+			"/root/.ssh/known_hosts with the strict host key policy"), external.ConnectFailureHostKeyUnknown},
+		{errors.New("ssh: handshake failed: read tcp 10.0.0.2:48712->10.0.0.1:22: read: connection reset by peer"),
+			external.ConnectFailureReset},
+		{errors.New("run `true` failed: exit status 1"), external.ConnectFailureOther},
+	}
+	for _, c := range cases {
+		failure := external.ClassifyConnectError(c.err)
+		s.Equal(c.expected, failure, "%v", c.err)
+		if c.expected != external.ConnectFailureDNS {
+			s.True(failure.Reachable())
+		} else {
+			s.False(failure.Reachable())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CommandStats collects latencies of commands run on a node, it's safe for concurrent use.
+type CommandStats struct {
+	mu        sync.Mutex
+	durations []time.Duration
+	total     time.Duration
+}
+
+// NewCommandStats returns empty stats.
+func NewCommandStats() *CommandStats {
+	return new(CommandStats)
+}
+
+func (s *CommandStats) add(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.durations = append(s.durations, d)
+	s.total += d
+}
+
+// Summary returns the summary of latencies of commands of the node.
+func (s *CommandStats) Summary(node string) StatsSummary {
+	s.mu.Lock()
+	durations := slices.Clone(s.durations)
+	summary := StatsSummary{Node: node, Count: len(durations), Total: s.total}
+	s.mu.Unlock()
+
+	if len(durations) == 0 {
+		return summary
+	}
+	slices.Sort(durations)
+	summary.P50 = percentile(durations, 50)
+	summary.P95 = percentile(durations, 95)
+	summary.Max = durations[len(durations)-1]
+	return summary
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (len(sorted)*p + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// StatsSummary is the summary of latencies of commands run on a node.
+type StatsSummary struct {
+	Node  string        `json:"node"`
+	Count int           `json:"count"`
+	Total time.Duration `json:"total"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	Max   time.Duration `json:"max"`
+}
+
+func (s StatsSummary) String() string {
+	round := func(d time.Duration) time.Duration {
+		return d.Round(time.Millisecond)
+	}
+	return fmt.Sprintf("%s: %d commands, total %s, p50 %s, p95 %s, max %s",
+		s.Node, s.Count, round(s.Total), round(s.P50), round(s.P95), round(s.Max))
+}
+
+// UseStats makes latencies of commands run by the runner of the manager collected
+// into the stats, so that managers of the same node share their stats.
+func (em *Manager) UseStats(stats *CommandStats) {
+	if r, ok := em.Runner.(interface{ setStats(*CommandStats) }); ok {
+		r.setStats(stats)
+		em.stats = stats
+	}
+}
+
+// Stats returns the summary of latencies of commands run by the runner of the manager,
+// it's empty if the runner doesn't collect stats.
+func (em *Manager) Stats() StatsSummary {
+	if em.stats == nil {
+		return StatsSummary{Node: em.nodeName()}
+	}
+	return em.stats.Summary(em.nodeName())
+}
@@ -21,7 +21,6 @@ import (
 	"path"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
@@ -35,21 +34,31 @@ type genClusterFileContentStep struct {
 }
 
 func (s *genClusterFileContentStep) Execute(context.Context) error {
-	nodes := make([]string, len(s.Runtime.Services.Fdb.Nodes))
-	fdb := s.Runtime.Services.Fdb
+	clusterFileContent := genClusterFileContent(s.Runtime)
+	s.Logger.Debugf("fdb cluster file content: %s", clusterFileContent)
+	return nil
+}
+
+// genClusterFileContent generates content of fdb cluster file and stores it into the runtime.
+// The description:ID part of the cluster file is random unless it's stored in the runtime.
+func genClusterFileContent(r *task.Runtime) string {
+	nodes := make([]string, len(r.Services.Fdb.Nodes))
+	fdb := r.Services.Fdb
 	for i, fdbNode := range fdb.Nodes {
-		for _, node := range s.Runtime.Nodes {
+		for _, node := range r.Nodes {
 			if node.Name == fdbNode {
 				nodes[i] = net.JoinHostPort(node.Host, strconv.Itoa(fdb.Port))
 			}
 		}
 	}
 
-	clusterFileContent := fmt.Sprintf("%s:%s@%s",
-		common.RandomString(10), common.RandomString(10), strings.Join(nodes, ","))
-	s.Logger.Debugf("fdb cluster file content: %s", clusterFileContent)
-	s.Runtime.Store(task.RuntimeFdbClusterFileContentKey, clusterFileContent)
-	return nil
+	clusterID, ok := r.LoadString(task.RuntimeFdbClusterIDKey)
+	if !ok {
+		clusterID = fmt.Sprintf("%s:%s", common.RandomString(10), common.RandomString(10))
+	}
+	clusterFileContent := fmt.Sprintf("%s@%s", clusterID, strings.Join(nodes, ","))
+	r.Store(task.RuntimeFdbClusterFileContentKey, clusterFileContent)
+	return clusterFileContent
 }
 
 func getServiceWorkDir(workDir string) string {
@@ -97,6 +106,8 @@ func (s *runContainerStep) Execute(ctx context.Context) error {
 			},
 		},
 	}
+	args.SetResources(s.Runtime.Services.Fdb.Resources)
+	args.SetPassthrough(s.Runtime.Services.Fdb.Passthrough)
 	_, err = s.Em.Docker.Run(ctx, args)
 	if err != nil {
 		return errors.Trace(err)
@@ -133,19 +144,14 @@ func (s *initClusterStep) initCluster(ctx context.Context) error {
 
 func (s *initClusterStep) waitClusterInitialized(ctx context.Context) error {
 	s.Logger.Infof("Waiting for fdb cluster initialized")
-	tctx, cancel := context.WithTimeout(ctx, s.Runtime.Services.Fdb.WaitClusterTimeout)
-	defer cancel()
-
-	for {
-		out, err := s.Em.Docker.Exec(tctx, s.Runtime.Services.Fdb.ContainerName,
-			"fdbcli", "--exec", "'status minimal'")
-		if err != nil {
-			return errors.Annotate(err, "wait fdb cluster initialized")
-		}
-		if strings.Contains(out, "The database is available.") {
-			break
-		}
-		time.Sleep(time.Second)
+	err := s.Runtime.WaitServiceReady(ctx, config.ServiceFdb,
+		func(ctx context.Context) (bool, string, error) {
+			out, err := s.Em.Docker.Exec(ctx, s.Runtime.Services.Fdb.ContainerName,
+				"fdbcli", "--exec", "'status minimal'")
+			return strings.Contains(out, "The database is available."), out, err
+		})
+	if err != nil {
+		return errors.Annotate(err, "wait fdb cluster initialized")
 	}
 
 	s.Logger.Infof("Initialized fdb cluster")
@@ -158,6 +164,9 @@ type rmContainerStep struct {
 
 func (s *rmContainerStep) Execute(ctx context.Context) error {
 	containerName := s.Runtime.Services.Fdb.ContainerName
+	if err := s.StopContainer(ctx, config.ServiceFdb, containerName); err != nil {
+		return errors.Trace(err)
+	}
 	s.Logger.Infof("Removing fdb container %s", containerName)
 	_, err := s.Em.Docker.Rm(ctx, containerName, true)
 	if err != nil {
@@ -17,6 +17,7 @@ package fdb
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 
@@ -68,6 +69,16 @@ func (s *genClusterFileContentStepSuite) TestGenClusterFileContentStep() {
 	s.True(strings.Contains(contentI.(string), "@1.1.1.1:4500,1.1.1.2:4500"))
 }
 
+func (s *genClusterFileContentStepSuite) TestGenClusterFileContentWithClusterID() {
+	s.Runtime.Store(task.RuntimeFdbClusterIDKey, "desc:id")
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	content, ok := s.Runtime.LoadString(task.RuntimeFdbClusterFileContentKey)
+	s.True(ok)
+	s.Equal("desc:id@1.1.1.1:4500,1.1.1.2:4500", content)
+}
+
 func TestRunContainerStep(t *testing.T) {
 	suiteRun(t, &runContainerStepSuite{})
 }
@@ -204,6 +215,8 @@ func (s *initClusterStepSuite) TestInitClusterFailed() {
 }
 
 func (s *initClusterStepSuite) TestWaitClusterInitializedFailed() {
+	s.Runtime.Services.Fdb.ReadinessTimeout = config.Duration(50 * time.Millisecond)
+	s.Runtime.Services.Fdb.ReadinessInterval = config.Duration(10 * time.Millisecond)
 	s.MockDocker.On("Exec", s.Runtime.Services.Fdb.ContainerName,
 		"fdbcli", []string{"--exec", "'configure new single ssd'"}).
 		Return("", nil)
@@ -211,7 +224,10 @@ func (s *initClusterStepSuite) TestWaitClusterInitializedFailed() {
 		"fdbcli", []string{"--exec", "'status minimal'"}).
 		Return(nil, errors.New("dummy error"))
 
-	s.Error(s.step.Execute(s.Ctx()), "dummy error")
+	err := s.step.Execute(s.Ctx())
+	s.Error(err)
+	s.Contains(err.Error(), "fdb is not ready after waiting")
+	s.Contains(err.Error(), "last probe output: dummy error")
 
 	s.MockDocker.AssertExpectations(s.T())
 }
@@ -237,6 +253,8 @@ func (s *rmContainerStepSuite) SetupTest() {
 	s.SetupRuntime()
 	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
 	s.Runtime.Store(task.RuntimeFdbClusterFileContentKey, "xxxx")
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Fdb.ContainerName, "{{.State.Status}}").
+		Return("exited", nil)
 }
 
 func (s *rmContainerStepSuite) TestRmContainerStep() {
@@ -20,6 +20,19 @@ import (
 	"github.com/open3fs/m3fs/pkg/task"
 )
 
+// ConfigRenderer prepares the fdb cluster file content, which is rendered into
+// config dirs of 3fs services.
+var ConfigRenderer = &task.ConfigRenderer{
+	Service: config.ServiceFdb,
+	Prepare: func(r *task.Runtime) error {
+		genClusterFileContent(r)
+		return nil
+	},
+	Render: func(*task.Runtime, config.Node) ([]*task.RenderedFile, error) {
+		return nil, nil
+	},
+}
+
 // CreateFdbClusterTask is a task for creating a new FoundationDB cluster.
 type CreateFdbClusterTask struct {
 	task.BaseTask
@@ -28,6 +41,7 @@ type CreateFdbClusterTask struct {
 // Init initializes the task.
 func (t *CreateFdbClusterTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("CreateFdbClusterTask")
+	t.BaseTask.SetService(config.ServiceFdb)
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Fdb.Nodes))
 	for i, node := range r.Cfg.Services.Fdb.Nodes {
@@ -59,6 +73,8 @@ type DeleteFdbClusterTask struct {
 // Init initializes the task.
 func (t *DeleteFdbClusterTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("DeleteFdbClusterTask")
+	t.BaseTask.SetService(config.ServiceFdb)
+	t.BaseTask.SetDeps("DeleteMgmtdServiceTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Fdb.Nodes))
 	for i, node := range r.Cfg.Services.Fdb.Nodes {
@@ -0,0 +1,84 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// document is the JSON document of the inventory.
+type document struct {
+	Nodes []Node `json:"nodes"`
+}
+
+// Decode decodes nodes from the JSON inventory like {"nodes": [...]}.
+func Decode(data []byte) ([]Node, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Annotate(err, "decode inventory")
+	}
+	for i, node := range doc.Nodes {
+		if node.Name == "" {
+			return nil, errors.Errorf("nodes[%d].name of inventory is required", i)
+		}
+		if node.Host == "" {
+			return nil, errors.Errorf("nodes[%d].host of inventory is required", i)
+		}
+		if node.Password != nil {
+			return nil, errors.Errorf("password of node %s is embedded in inventory, "+
+				"reference it by passwordRef instead", node.Name)
+		}
+	}
+	return doc.Nodes, nil
+}
+
+// HTTPProvider fetches the inventory from an HTTP endpoint returning JSON.
+type HTTPProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+// Fetch fetches the inventory.
+func (p *HTTPProvider) Fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get %s: %s", p.URL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read response of %s", p.URL)
+	}
+	return data, nil
+}
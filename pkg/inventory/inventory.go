@@ -0,0 +1,157 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// Node is a node of the inventory. Credentials aren't embedded, they're
+// resolved from the reference.
+type Node struct {
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	// PasswordRef references the password, env:<NAME> reads the environment
+	// variable, file:<PATH> reads the file on the local node. Secret references
+	// of the cluster config like secret://vault/<path>#<key> are accepted too.
+	PasswordRef string `json:"passwordRef,omitempty"`
+	// Roles are services running on the node.
+	Roles []string `json:"roles,omitempty"`
+	// Zone is the availability zone of the node.
+	Zone string `json:"zone,omitempty"`
+
+	// Password must not be set, it's only decoded to reject embedded passwords.
+	Password *string `json:"password,omitempty"`
+}
+
+// Provider is the source of nodes of the cluster.
+type Provider interface {
+	// Fetch returns the raw inventory, which is decoded by Decode.
+	Fetch(ctx context.Context) ([]byte, error)
+}
+
+// Load fetches nodes from the provider, the fetched inventory is cached into
+// the cache file. The cached inventory is used if the provider is unavailable.
+func Load(ctx context.Context, provider Provider, cacheFile string) ([]Node, error) {
+	data, err := provider.Fetch(ctx)
+	if err != nil {
+		cached, readErr := os.ReadFile(cacheFile)
+		if readErr != nil {
+			return nil, errors.Annotate(err, "fetch inventory")
+		}
+		logrus.Warnf("Failed to fetch inventory, using the cached inventory %s: %v", cacheFile, err)
+		data = cached
+	}
+	nodes, err := Decode(data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = writeCache(cacheFile, data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return nodes, nil
+}
+
+func writeCache(cacheFile string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		return errors.Annotatef(err, "create directory of %s", cacheFile)
+	}
+	return errors.Annotatef(task.WriteFileAtomic(cacheFile, data, 0644), "write inventory cache %s", cacheFile)
+}
+
+// Merge merges nodes of the inventory into the config. A node of the config with
+// the same name is replaced, and roles of nodes add them to nodes of services.
+func Merge(cfg *config.Config, nodes []Node) error {
+	for _, node := range nodes {
+		password, err := resolveRef(node.PasswordRef)
+		if err != nil {
+			return errors.Annotatef(err, "resolve password of node %s", node.Name)
+		}
+		cfgNode := config.Node{
+			Name:     node.Name,
+			Host:     node.Host,
+			Port:     node.Port,
+			Username: node.Username,
+			Zone:     node.Zone,
+		}
+		if node.PasswordRef != "" {
+			cfgNode.Password = &password
+		}
+		replaced := false
+		for i := range cfg.Nodes {
+			if cfg.Nodes[i].Name == node.Name {
+				cfgNode.Env = cfg.Nodes[i].Env
+				cfgNode.RDMAAddresses = cfg.Nodes[i].RDMAAddresses
+				if cfgNode.Zone == "" {
+					cfgNode.Zone = cfg.Nodes[i].Zone
+				}
+				cfg.Nodes[i] = cfgNode
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			cfg.Nodes = append(cfg.Nodes, cfgNode)
+		}
+		for _, role := range node.Roles {
+			if err = cfg.Services.AddServiceNode(config.ServiceType(role), node.Name); err != nil {
+				return errors.Annotatef(err, "role of node %s", node.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveRef resolves the reference of a credential.
+func resolveRef(ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	if config.IsSecretRef(ref) {
+		secret, err := config.ResolveSecretRef(ref)
+		return secret, errors.Trace(err)
+	}
+	kind, value, ok := strings.Cut(ref, ":")
+	if !ok || value == "" {
+		return "", errors.Errorf("invalid credential reference %q", ref)
+	}
+	switch kind {
+	case "env":
+		secret, ok := os.LookupEnv(value)
+		if !ok {
+			return "", errors.Errorf("environment variable %s isn't set", value)
+		}
+		return secret, nil
+	case "file":
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return "", errors.Annotate(err, "read credential file")
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return "", errors.Errorf("unsupported credential reference %q, must be env:<NAME> or file:<PATH>", ref)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/tests/base"
+)
+
+var suiteRun = suite.Run
+
+func TestInventory(t *testing.T) {
+	suiteRun(t, &inventorySuite{})
+}
+
+type inventorySuite struct {
+	base.Suite
+}
+
+const inventoryJSON = `{"nodes": [
+  {"name": "node1", "host": "10.0.0.1", "username": "root", "passwordRef": "env:M3FS_TEST_PASSWORD",
+   "roles": ["mgmtd", "meta"], "rack": "r1"},
+  {"name": "node2", "host": "10.0.0.2", "username": "root", "roles": ["storage"], "zone": "az2"}
+]}`
+
+func (s *inventorySuite) TestDecodeWithEmbeddedPassword() {
+	_, err := Decode([]byte(`{"nodes": [{"name": "node1", "host": "10.0.0.1", "password": "xxx"}]}`))
+	s.Error(err)
+	s.Contains(err.Error(), "reference it by passwordRef instead")
+}
+
+func (s *inventorySuite) TestMerge() {
+	s.T().Setenv("M3FS_TEST_PASSWORD", "secret")
+	nodes, err := Decode([]byte(inventoryJSON))
+	s.NoError(err)
+	cfg := config.NewConfigWithDefaults()
+	cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.100", Username: "admin", Env: map[string]string{"LANG": "C"}, Zone: "az1"},
+	}
+	cfg.Services.Mgmtd.Nodes = []string{"node1"}
+
+	s.NoError(Merge(cfg, nodes))
+
+	s.Equal([]config.Node{
+		{
+			Name:     "node1",
+			Host:     "10.0.0.1",
+			Username: "root",
+			Password: common.Pointer("secret"),
+			Env:      map[string]string{"LANG": "C"},
+			Zone:     "az1",
+		},
+		{Name: "node2", Host: "10.0.0.2", Username: "root", Zone: "az2"},
+	}, cfg.Nodes)
+	s.Equal([]string{"node1"}, cfg.Services.Mgmtd.Nodes)
+	s.Equal([]string{"node1"}, cfg.Services.Meta.Nodes)
+	s.Equal([]string{"node2"}, cfg.Services.Storage.Nodes)
+}
+
+func (s *inventorySuite) TestMergeWithInvalidRole() {
+	nodes, err := Decode([]byte(`{"nodes": [{"name": "node1", "host": "10.0.0.1", "roles": ["web"]}]}`))
+	s.NoError(err)
+
+	s.Error(Merge(config.NewConfigWithDefaults(), nodes))
+}
+
+func (s *inventorySuite) TestMergeWithUnsetPasswordRef() {
+	nodes, err := Decode([]byte(`{"nodes": [{"name": "node1", "host": "10.0.0.1", ` +
+		`"passwordRef": "env:M3FS_TEST_UNSET_PASSWORD"}]}`))
+	s.NoError(err)
+
+	s.Error(Merge(config.NewConfigWithDefaults(), nodes))
+}
+
+func (s *inventorySuite) TestResolveFileRef() {
+	passwordFile := filepath.Join(s.T().TempDir(), "password")
+	s.NoError(os.WriteFile(passwordFile, []byte("secret\n"), 0600))
+
+	password, err := resolveRef("file:" + passwordFile)
+	s.NoError(err)
+	s.Equal("secret", password)
+
+	_, err = resolveRef("vault:secret/node1")
+	s.Error(err)
+}
+
+func (s *inventorySuite) TestLoadWithCache() {
+	var unavailable atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, inventoryJSON)
+	}))
+	defer server.Close()
+	provider := &HTTPProvider{URL: server.URL}
+	cacheFile := filepath.Join(s.T().TempDir(), ".m3fs", "3fs", "inventory.json")
+
+	nodes, err := Load(context.TODO(), provider, cacheFile)
+	s.NoError(err)
+	s.Len(nodes, 2)
+	data, err := os.ReadFile(cacheFile)
+	s.NoError(err)
+	s.Equal(inventoryJSON, string(data))
+
+	unavailable.Store(true)
+	nodes, err = Load(context.TODO(), provider, cacheFile)
+	s.NoError(err)
+	s.Len(nodes, 2)
+
+	s.NoError(os.Remove(cacheFile))
+	_, err = Load(context.TODO(), provider, cacheFile)
+	s.Error(err)
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Aggregator aggregates identical info messages logged by loggers of nodes running a
+// step in parallel, so that a message is printed once with a running count of nodes
+// instead of once per node, e.g. "Install package ... 143/200 done". Messages logged
+// again by a node, e.g. when the step is retried, are counted once. Only the output is
+// aggregated, the log file still has info messages of each node with its node field,
+// but no aggregated ones. Warnings, errors and debug messages are logged per node as
+// usual.
+type Aggregator struct {
+	logger Interface
+	// output logs aggregated messages to the output only.
+	output Interface
+	total  int
+	// step is the number of nodes between logged counts of a message.
+	step int
+
+	mu    sync.Mutex
+	nodes map[string]map[string]struct{}
+}
+
+// NewAggregator creates an aggregator logging aggregated messages of the given number
+// of nodes by the logger.
+func NewAggregator(logger Interface, total int) *Aggregator {
+	return &Aggregator{
+		logger: logger,
+		output: logger.Subscribe(fieldKeyOutputOnly, "true"),
+		total:  total,
+		step:   max(total/10, 1),
+		nodes:  make(map[string]map[string]struct{}),
+	}
+}
+
+// Logger returns the logger of the node.
+func (a *Aggregator) Logger(node string) Interface {
+	return &aggregateLogger{
+		Interface:  a.logger.Subscribe(FieldKeyNode, node),
+		aggregator: a,
+		node:       node,
+	}
+}
+
+// info logs the message of the node to the file, and the count of nodes which logged the
+// message to the output.
+func (a *Aggregator) info(logger Interface, node, msg string) {
+	logger.Subscribe(fieldKeyFileOnly, "true").Info(msg)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	nodes, ok := a.nodes[msg]
+	if !ok {
+		nodes = make(map[string]struct{})
+		a.nodes[msg] = nodes
+	}
+	if _, ok = nodes[node]; ok {
+		return
+	}
+	nodes[node] = struct{}{}
+	count := len(nodes)
+	if count == 1 || count == a.total || count%a.step == 0 {
+		a.output.Infof("%s ... %d/%d done", msg, count, a.total)
+	}
+}
+
+// aggregateLogger logs info messages through the aggregator.
+type aggregateLogger struct {
+	Interface
+	aggregator *Aggregator
+	node       string
+}
+
+// Subscribe adds a field base on current logger and returns a new logger.
+func (l *aggregateLogger) Subscribe(key, val string) Interface {
+	return &aggregateLogger{
+		Interface:  l.Interface.Subscribe(key, val),
+		aggregator: l.aggregator,
+		node:       l.node,
+	}
+}
+
+// Infof logs the message through the aggregator.
+func (l *aggregateLogger) Infof(format string, args ...any) {
+	l.aggregator.info(l.Interface, l.node, fmt.Sprintf(format, args...))
+}
+
+// Info logs the message through the aggregator.
+func (l *aggregateLogger) Info(args ...any) {
+	l.aggregator.info(l.Interface, l.node, fmt.Sprint(args...))
+}
+
+// Infoln logs the message through the aggregator.
+func (l *aggregateLogger) Infoln(args ...any) {
+	l.aggregator.info(l.Interface, l.node, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAggregatorSuite(t *testing.T) {
+	suite.Run(t, new(aggregatorSuite))
+}
+
+type aggregatorSuite struct {
+	suite.Suite
+	buf    *bytes.Buffer
+	file   *bytes.Buffer
+	logger Interface
+}
+
+func (s *aggregatorSuite) SetupTest() {
+	s.buf = new(bytes.Buffer)
+	s.file = new(bytes.Buffer)
+	l := logrus.New()
+	l.Out = s.buf
+	formatter := &logrus.TextFormatter{DisableTimestamp: true, DisableColors: true}
+	l.Formatter = &outputFormatter{formatter}
+	l.AddHook(&fileHook{out: s.file, formatter: formatter})
+	s.logger = &logger{Logger: l, fields: map[string]any{FieldKeyTask: "task"}}
+}
+
+func (s *aggregatorSuite) lines(buf *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func (s *aggregatorSuite) TestAggregate() {
+	aggregator := NewAggregator(s.logger, 20)
+	for i := 1; i <= 20; i++ {
+		logger := aggregator.Logger(fmt.Sprintf("node%d", i))
+		logger.Infof("Install package %s", "fio")
+		// retries of the node are counted once
+		logger.Info("Install package fio")
+	}
+
+	s.Equal([]string{
+		`level=info msg="Install package fio ... 1/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 2/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 4/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 6/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 8/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 10/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 12/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 14/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 16/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 18/20 done" TASK=task`,
+		`level=info msg="Install package fio ... 20/20 done" TASK=task`,
+	}, s.lines(s.buf))
+
+	fileLines := s.lines(s.file)
+	s.Len(fileLines, 40)
+	s.Equal(`level=info msg="Install package fio" NODE=node1 TASK=task`, fileLines[0])
+	s.Equal(`level=info msg="Install package fio" NODE=node20 TASK=task`, fileLines[39])
+}
+
+func (s *aggregatorSuite) TestWarningsPerNode() {
+	aggregator := NewAggregator(s.logger, 2)
+	aggregator.Logger("node1").Subscribe(FieldKeyStep, "step").Infoln("Start", "service")
+	aggregator.Logger("node2").Warnf("Step failed, retrying: %s", "timeout")
+	aggregator.Logger("node2").Errorf("Step failed: %s", "timeout")
+
+	s.Equal([]string{
+		`level=info msg="Start service ... 1/2 done" TASK=task`,
+		`level=warning msg="Step failed, retrying: timeout" NODE=node2 TASK=task`,
+		`level=error msg="Step failed: timeout" NODE=node2 TASK=task`,
+	}, s.lines(s.buf))
+	s.Equal([]string{
+		`level=info msg="Start service" NODE=node1 STEP=step TASK=task`,
+		`level=warning msg="Step failed, retrying: timeout" NODE=node2 TASK=task`,
+		`level=error msg="Step failed: timeout" NODE=node2 TASK=task`,
+	}, s.lines(s.file))
+}
@@ -15,7 +15,16 @@
 package log
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -154,19 +163,205 @@ func (l *logger) Subscribe(key, val string) Interface {
 	}
 }
 
+// Options are options of the global logger.
+type Options struct {
+	Level logrus.Level
+	// JSON formats logs in JSON instead of text.
+	JSON bool
+	// Timestamps logs full timestamps in RFC3339Nano format, text logs in a terminal
+	// only have seconds since start by default.
+	Timestamps bool
+	// Caller logs the file:line of the caller of each log.
+	Caller bool
+	// UTC logs timestamps in UTC instead of the local timezone.
+	UTC bool
+	// File is written logs in addition to the output, e.g. a log file. Text logs in it
+	// always have full timestamps and no colors.
+	File io.Writer
+}
+
 // InitLogger initializes the global logger.
 func InitLogger(level logrus.Level) {
+	InitLoggerWithOptions(Options{Level: level})
+}
+
+// InitLoggerWithOptions initializes the global logger by the options, they're also
+// applied to the standard logger of logrus.
+func InitLoggerWithOptions(opts Options) {
 	l := &logrus.Logger{
 		Out:          os.Stderr,
-		Formatter:    new(logrus.TextFormatter),
 		Hooks:        make(logrus.LevelHooks),
 		Level:        logrus.InfoLevel,
 		ExitFunc:     os.Exit,
 		ReportCaller: false,
 	}
-	l.SetLevel(level)
+	configure(l, opts)
+	configure(logrus.StandardLogger(), opts)
 	Logger = &logger{
 		Logger: l,
 		fields: map[string]any{},
 	}
 }
+
+// configure sets the level, the formatter and hooks of the logger by the options.
+func configure(l *logrus.Logger, opts Options) {
+	l.SetLevel(opts.Level)
+	timestampFormat := ""
+	if opts.Timestamps {
+		timestampFormat = time.RFC3339Nano
+	}
+	if opts.JSON {
+		l.SetFormatter(&outputFormatter{&logrus.JSONFormatter{TimestampFormat: timestampFormat}})
+	} else {
+		l.SetFormatter(&outputFormatter{
+			&logrus.TextFormatter{FullTimestamp: opts.Timestamps, TimestampFormat: timestampFormat}})
+	}
+	hooks := make(logrus.LevelHooks)
+	if opts.Caller || opts.UTC {
+		hooks.Add(&optionsHook{caller: opts.Caller, utc: opts.UTC})
+	}
+	if opts.File != nil {
+		// hooks fire in order, the entry has the caller and the timezone set already
+		hook := &fileHook{out: opts.File, formatter: &logrus.TextFormatter{
+			FullTimestamp: true, TimestampFormat: timestampFormat, DisableColors: true}}
+		if opts.JSON {
+			hook.formatter = &logrus.JSONFormatter{TimestampFormat: timestampFormat}
+		}
+		hooks.Add(hook)
+	}
+	l.ReplaceHooks(hooks)
+}
+
+// keys of fields marking logs written to either the output or the file only, they're
+// removed from logs.
+const (
+	fieldKeyOutputOnly = "m3fs_output_only"
+	fieldKeyFileOnly   = "m3fs_file_only"
+)
+
+// withoutField returns the entry without the field, the entry is copied if it has the field.
+func withoutField(entry *logrus.Entry, key string) *logrus.Entry {
+	if _, ok := entry.Data[key]; !ok {
+		return entry
+	}
+	e := *entry
+	e.Data = make(logrus.Fields, len(entry.Data)-1)
+	for k, v := range entry.Data {
+		if k != key {
+			e.Data[k] = v
+		}
+	}
+	return &e
+}
+
+// outputFormatter formats logs written to the output of the logger, logs for the file
+// only are skipped.
+type outputFormatter struct {
+	logrus.Formatter
+}
+
+// Format formats the log entry.
+func (f *outputFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if _, ok := entry.Data[fieldKeyFileOnly]; ok {
+		return nil, nil
+	}
+	return f.Formatter.Format(withoutField(entry, fieldKeyOutputOnly))
+}
+
+// fileMu serializes writes of hooks of the global logger and the standard logger of
+// logrus to the same file.
+var fileMu sync.Mutex
+
+// fileHook writes logs to the file in addition to the output of the logger.
+type fileHook struct {
+	out       io.Writer
+	formatter logrus.Formatter
+}
+
+// Levels returns all levels.
+func (h *fileHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire writes the log entry to the file, logs for the output only are skipped.
+func (h *fileHook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[fieldKeyOutputOnly]; ok {
+		return nil
+	}
+	data, err := h.formatter.Format(withoutField(entry, fieldKeyFileOnly))
+	if err != nil {
+		return err
+	}
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	_, err = h.out.Write(data)
+	return err
+}
+
+// FieldKeyCaller is the key of the field of the caller of a log.
+const FieldKeyCaller = "caller"
+
+// optionsHook sets the caller and the timezone of logs.
+type optionsHook struct {
+	caller bool
+	utc    bool
+}
+
+// Levels returns all levels.
+func (h *optionsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire sets the caller and the timezone of the log entry.
+func (h *optionsHook) Fire(entry *logrus.Entry) error {
+	if h.utc {
+		entry.Time = entry.Time.UTC()
+	}
+	if h.caller {
+		if caller := callerOf(); caller != "" {
+			entry.Data[FieldKeyCaller] = caller
+		}
+	}
+	return nil
+}
+
+// wrapperPrefixes are prefixes of functions logging on behalf of callers, they're
+// skipped to find the caller.
+var wrapperPrefixes = []string{
+	"github.com/sirupsen/logrus.",
+	pkgPath + ".(*logger).",
+	pkgPath + ".(*aggregateLogger).",
+	pkgPath + ".(*Aggregator).",
+	pkgPath + ".(*optionsHook).",
+	pkgPath + ".callerOf",
+}
+
+var pkgPath = reflect.TypeOf(logger{}).PkgPath()
+
+// callerOf returns the file:line of the first function out of loggers in the stack, the
+// file is prefixed by its dir, e.g. task/runner.go:123.
+func callerOf() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !slices.ContainsFunc(wrapperPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(frame.Function, prefix)
+		}) {
+			file := filepath.Join(filepath.Base(filepath.Dir(frame.File)), filepath.Base(frame.File))
+			return fmt.Sprintf("%s:%d", file, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// SetOutput sets the output of the global logger and the standard logger of logrus, e.g.
+// to keep logs from breaking what's rendered in the terminal.
+func SetOutput(out io.Writer) {
+	if l, ok := Logger.(*logger); ok {
+		l.SetOutput(out)
+	}
+	logrus.SetOutput(out)
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLoggerSuite(t *testing.T) {
+	suite.Run(t, new(loggerSuite))
+}
+
+type loggerSuite struct {
+	suite.Suite
+	buf *bytes.Buffer
+}
+
+func (s *loggerSuite) SetupTest() {
+	s.buf = new(bytes.Buffer)
+}
+
+func (s *loggerSuite) newLogger(opts Options) Interface {
+	l := logrus.New()
+	l.Out = s.buf
+	configure(l, opts)
+	return &logger{Logger: l, fields: map[string]any{FieldKeyTask: "task"}}
+}
+
+func (s *loggerSuite) jsonEntry() map[string]any {
+	entry := make(map[string]any)
+	s.NoError(json.Unmarshal(s.buf.Bytes(), &entry))
+	return entry
+}
+
+func (s *loggerSuite) TestJSON() {
+	s.newLogger(Options{Level: logrus.InfoLevel, JSON: true, Timestamps: true, Caller: true, UTC: true}).
+		Infof("hello %s", "world")
+
+	entry := s.jsonEntry()
+	s.Equal("hello world", entry["msg"])
+	s.Equal("task", entry[FieldKeyTask])
+	s.Regexp(`^log/logger_test\.go:\d+$`, entry[FieldKeyCaller])
+	timestamp, err := time.Parse(time.RFC3339Nano, entry["time"].(string))
+	s.NoError(err)
+	s.Equal(time.UTC, timestamp.Location())
+	s.Regexp(`\.\d+Z$`, entry["time"])
+}
+
+func (s *loggerSuite) TestText() {
+	s.newLogger(Options{Level: logrus.InfoLevel, Timestamps: true, Caller: true}).Warn("hello")
+
+	s.Regexp(`^time="[^"]+" level=warning msg=hello TASK=task caller="log/logger_test\.go:\d+"\n$`, s.buf.String())
+	timestamp := regexp.MustCompile(`time="([^"]+)"`).FindStringSubmatch(s.buf.String())[1]
+	_, err := time.Parse(time.RFC3339Nano, timestamp)
+	s.NoError(err)
+}
+
+func (s *loggerSuite) TestDefault() {
+	s.newLogger(Options{Level: logrus.InfoLevel, JSON: true}).Debug("hidden")
+	s.Empty(s.buf.String())
+
+	s.newLogger(Options{Level: logrus.DebugLevel, JSON: true}).Debug("shown")
+	entry := s.jsonEntry()
+	s.Equal("shown", entry["msg"])
+	s.NotContains(entry, FieldKeyCaller)
+	s.Regexp(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-])`, entry["time"])
+}
+
+func (s *loggerSuite) TestCallerOfAggregatedLogs() {
+	aggregator := NewAggregator(s.newLogger(Options{Level: logrus.InfoLevel, JSON: true, Caller: true}), 1)
+	aggregator.Logger("node1").Infof("installed")
+
+	entry := s.jsonEntry()
+	s.Equal("installed ... 1/1 done", entry["msg"])
+	s.Regexp(`^log/logger_test\.go:\d+$`, entry[FieldKeyCaller])
+}
+
+func (s *loggerSuite) TestFile() {
+	file := new(bytes.Buffer)
+	s.newLogger(Options{Level: logrus.InfoLevel, File: file}).Warn("hello")
+
+	s.Contains(s.buf.String(), "msg=hello")
+	s.Regexp(`^time="[^"]+" level=warning msg=hello TASK=task\n$`, file.String())
+
+	file.Reset()
+	s.newLogger(Options{Level: logrus.InfoLevel, JSON: true, File: file}).Debug("hidden")
+	s.Empty(file.String())
+}
@@ -16,6 +16,8 @@ package meta
 
 import (
 	"embed"
+
+	"github.com/open3fs/m3fs/pkg/task"
 )
 
 var (
@@ -46,4 +48,8 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	task.RegisterTemplate("meta_main_app.toml.tmpl", &MetaMainAppTomlTmpl)
+	task.RegisterTemplate("meta_main_launcher.toml.tmpl", &MetaMainLauncherTomlTmpl)
+	task.RegisterTemplate("meta_main.toml.tmpl", &MetaMainTomlTmpl)
 }
@@ -27,12 +27,52 @@ const (
 	// ServiceName is the name of the meta service.
 	ServiceName = "meta_main"
 	serviceType = "META"
+	// NodeIDBegin is the 3fs node id of the first meta node, ids of meta nodes are
+	// consecutive in order of nodes of the service.
+	NodeIDBegin = 100
 )
 
 func getServiceWorkDir(workDir string) string {
 	return path.Join(workDir, "meta")
 }
 
+func newPrepareConfigSetup(r *task.Runtime) *steps.Prepare3FSConfigStepSetup {
+	return &steps.Prepare3FSConfigStepSetup{
+		Service:              ServiceName,
+		ServiceWorkDir:       getServiceWorkDir(r.WorkDir),
+		MainAppTomlTmpl:      MetaMainAppTomlTmpl,
+		MainLauncherTomlTmpl: MetaMainLauncherTomlTmpl,
+		MainTomlTmpl:         MetaMainTomlTmpl,
+		RDMAListenPort:       r.Services.Meta.RDMAListenPort,
+		TCPListenPort:        r.Services.Meta.TCPListenPort,
+		ExtraConfig:          r.Services.Meta.ExtraConfig,
+	}
+}
+
+// ConfigRenderer renders config files of meta service.
+var ConfigRenderer = &task.ConfigRenderer{
+	Service: config.ServiceMeta,
+	Prepare: func(r *task.Runtime) error {
+		steps.Gen3FSNodeIDs(r, ServiceName, NodeIDBegin, r.Services.Meta.Nodes)
+		return nil
+	},
+	Render: func(r *task.Runtime, node config.Node) ([]*task.RenderedFile, error) {
+		return steps.Render3FSConfigs(r, newPrepareConfigSetup(r), node)
+	},
+}
+
+func newRunContainerSetup(r *task.Runtime) *steps.Run3FSContainerStepSetup {
+	return &steps.Run3FSContainerStepSetup{
+		ImgName:        config.ImageName3FS,
+		ContainerName:  r.Services.Meta.ContainerName,
+		Service:        ServiceName,
+		ServiceType:    config.ServiceMeta,
+		WorkDir:        getServiceWorkDir(r.WorkDir),
+		UseRdmaNetwork: true,
+		ListenPort:     r.Services.Meta.TCPListenPort,
+	}
+}
+
 // CreateMetaServiceTask is a task for creating 3fs meta services.
 type CreateMetaServiceTask struct {
 	task.BaseTask
@@ -41,6 +81,8 @@ type CreateMetaServiceTask struct {
 // Init initializes the task.
 func (t *CreateMetaServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("CreateMetaServiceTask")
+	t.BaseTask.SetService(config.ServiceMeta)
+	t.BaseTask.SetDeps("CreateMgmtdServiceTask")
 	t.BaseTask.Init(r, logger)
 
 	workDir := getServiceWorkDir(r.WorkDir)
@@ -51,20 +93,11 @@ func (t *CreateMetaServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	t.SetSteps([]task.StepConfig{
 		{
 			Nodes:   []config.Node{nodes[0]},
-			NewStep: steps.NewGen3FSNodeIDStepFunc(ServiceName, 100, r.Cfg.Services.Meta.Nodes),
+			NewStep: steps.NewGen3FSNodeIDStepFunc(ServiceName, NodeIDBegin, r.Cfg.Services.Meta.Nodes),
 		},
 		{
-			Nodes:    nodes,
-			Parallel: true,
-			NewStep: steps.NewPrepare3FSConfigStepFunc(&steps.Prepare3FSConfigStepSetup{
-				Service:              ServiceName,
-				ServiceWorkDir:       workDir,
-				MainAppTomlTmpl:      MetaMainAppTomlTmpl,
-				MainLauncherTomlTmpl: MetaMainLauncherTomlTmpl,
-				MainTomlTmpl:         MetaMainTomlTmpl,
-				RDMAListenPort:       r.Services.Meta.RDMAListenPort,
-				TCPListenPort:        r.Services.Meta.TCPListenPort,
-			}),
+			Nodes:   []config.Node{nodes[0]},
+			NewStep: steps.NewDistribute3FSConfigStepFunc(newPrepareConfigSetup(r), nodes),
 		},
 		{
 			Nodes: []config.Node{nodes[0]},
@@ -79,15 +112,33 @@ func (t *CreateMetaServiceTask) Init(r *task.Runtime, logger log.Interface) {
 		{
 			Nodes:    nodes,
 			Parallel: true,
-			NewStep: steps.NewRun3FSContainerStepFunc(
-				&steps.Run3FSContainerStepSetup{
-					ImgName:        config.ImageName3FS,
-					ContainerName:  r.Services.Meta.ContainerName,
-					Service:        ServiceName,
-					WorkDir:        workDir,
-					UseRdmaNetwork: true,
-				},
-			),
+			Rollout:  true,
+			NewStep:  steps.NewRun3FSContainerStepFunc(newRunContainerSetup(r)),
+		},
+	})
+}
+
+// UpgradeMetaServiceTask is a task for upgrading 3fs meta services to the image in the
+// config. Nodes are upgraded one by one, each one must be ready before the next one, unless
+// the deployment strategy of the config is set.
+type UpgradeMetaServiceTask struct {
+	task.BaseTask
+
+	// Nodes are names of nodes to upgrade, all nodes of the service are upgraded if it's empty.
+	Nodes []string
+}
+
+// Init initializes the task.
+func (t *UpgradeMetaServiceTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("UpgradeMetaServiceTask")
+	t.BaseTask.SetService(config.ServiceMeta)
+	t.BaseTask.Init(r, logger)
+	nodes := r.SelectNodes(r.Cfg.Services.Meta.Nodes, t.Nodes)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:   nodes,
+			Rollout: true,
+			NewStep: steps.NewUpgrade3FSContainerStepFunc(newRunContainerSetup(r), nil),
 		},
 	})
 }
@@ -100,6 +151,8 @@ type DeleteMetaServiceTask struct {
 // Init initializes the task.
 func (t *DeleteMetaServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("DeleteMetaServiceTask")
+	t.BaseTask.SetService(config.ServiceMeta)
+	t.BaseTask.SetDeps("Delete3FSClientServiceTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Meta.Nodes))
 	for i, node := range r.Cfg.Services.Meta.Nodes {
@@ -112,6 +165,7 @@ func (t *DeleteMetaServiceTask) Init(r *task.Runtime, logger log.Interface) {
 			NewStep: steps.NewRm3FSContainerStepFunc(
 				r.Services.Meta.ContainerName,
 				ServiceName,
+				config.ServiceMeta,
 				getServiceWorkDir(r.WorkDir)),
 		},
 	})
@@ -0,0 +1,49 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgmtd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/mgmtd/leader"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// ServerAddresses returns addresses of all mgmtd of the cluster in the format of
+// mgmtd_server_addresses of config files of 3fs services.
+func ServerAddresses(r *task.Runtime) string {
+	return leader.Addresses(r, r.Services.Mgmtd.Nodes...)
+}
+
+// NewLeaderRouter creates a router routing admin operations to the mgmtd leader,
+// admin_cli runs in the mgmtd container of the node of the manager.
+func NewLeaderRouter(r *task.Runtime, em *external.Manager, logger log.Interface) *leader.Router {
+	return leader.NewRouter(r, containerAdminCli(r, em), logger)
+}
+
+// containerAdminCli runs admin_cli in the mgmtd container of the node of the manager.
+func containerAdminCli(r *task.Runtime, em *external.Manager) leader.AdminCli {
+	return func(ctx context.Context, addr, command string) (string, error) {
+		return em.Docker.Exec(ctx, r.Services.Mgmtd.ContainerName,
+			"/opt/3fs/bin/admin_cli",
+			"-cfg", "/opt/3fs/etc/admin_cli.toml",
+			"--config.mgmtd_client.mgmtd_server_addresses", fmt.Sprintf(`'%s'`, addr),
+			fmt.Sprintf(`"%s"`, command),
+		)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package leader routes admin operations of 3fs to the mgmtd leader.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// waitTimeout and waitInterval bound the wait for a leader to emerge, they're variables
+// for tests.
+var (
+	waitTimeout  = 30 * time.Second
+	waitInterval = 2 * time.Second
+)
+
+// PrimaryMgmtdStatus is the status of the mgmtd leader in output of list-nodes.
+const PrimaryMgmtdStatus = "PRIMARY_MGMTD"
+
+// Addresses returns addresses of mgmtd on the nodes in the format of admin_cli.
+func Addresses(r *task.Runtime, nodeNames ...string) string {
+	port := strconv.Itoa(r.Services.Mgmtd.RDMAListenPort)
+	addresses := make([]string, len(nodeNames))
+	for i, nodeName := range nodeNames {
+		addresses[i] = fmt.Sprintf(`"%s://%s"`, r.MgmtdProtocol, net.JoinHostPort(r.Nodes[nodeName].Host, port))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(addresses, ","))
+}
+
+// AdminCli runs the admin_cli command, e.g. list-nodes, by mgmtd of the addresses in the
+// format of admin_cli.
+type AdminCli func(ctx context.Context, addr, command string) (string, error)
+
+// Router routes admin operations to the mgmtd leader, the leader is detected by running
+// list-nodes by each mgmtd node.
+type Router struct {
+	runtime  *task.Runtime
+	adminCli AdminCli
+	logger   log.Interface
+	leader   string
+}
+
+// NewRouter creates a router running admin_cli by the function. The leader detected by
+// another router of the runtime is used first.
+func NewRouter(r *task.Runtime, adminCli AdminCli, logger log.Interface) *Router {
+	leader, _ := r.LoadString(task.RuntimeMgmtdLeaderKey)
+	return &Router{runtime: r, adminCli: adminCli, logger: logger, leader: leader}
+}
+
+// Leader returns the name of the mgmtd node being the leader. It waits a short while
+// for a leader to emerge if there's no leader or more than one node claims to be.
+func (l *Router) Leader(ctx context.Context) (string, error) {
+	if l.leader != "" {
+		return l.leader, nil
+	}
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		leader, err := l.detect(ctx)
+		if err == nil {
+			l.leader = leader
+			l.runtime.Store(task.RuntimeMgmtdLeaderKey, leader)
+			l.logger.Infof("Mgmtd leader is %s", leader)
+			return leader, nil
+		}
+		if time.Now().After(deadline) {
+			return "", errors.Annotatef(err, "no mgmtd leader emerged in %s", waitTimeout)
+		}
+		l.logger.Debugf("Waiting for mgmtd leader: %v", err)
+		select {
+		case <-ctx.Done():
+			return "", errors.Trace(ctx.Err())
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// detect asks each mgmtd node for the leader it knows.
+func (l *Router) detect(ctx context.Context) (string, error) {
+	var claims []string
+	for _, nodeName := range l.runtime.Services.Mgmtd.Nodes {
+		out, err := l.adminCli(ctx, Addresses(l.runtime, nodeName), "list-nodes")
+		if err != nil {
+			l.logger.Debugf("Failed to list nodes by mgmtd %s: %v", nodeName, err)
+			continue
+		}
+		if leader := l.parsePrimary(out); leader != "" && !slices.Contains(claims, leader) {
+			claims = append(claims, leader)
+		}
+	}
+	switch len(claims) {
+	case 0:
+		return "", errors.New("no mgmtd node is the leader")
+	case 1:
+		return claims[0], nil
+	default:
+		return "", errors.Errorf("split brain, mgmtd nodes %s are all reported as the leader",
+			strings.Join(claims, ", "))
+	}
+}
+
+// parsePrimary returns the mgmtd node which is the primary in output of list-nodes, e.g.
+// Id  Type   Status         Hostname  ...
+// 1   MGMTD  PRIMARY_MGMTD   node1     ...
+// Ids of mgmtd nodes start from 1 in order of nodes of the service.
+func (l *Router) parsePrimary(output string) string {
+	nodes := l.runtime.Services.Mgmtd.Nodes
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "MGMTD" || fields[2] != PrimaryMgmtdStatus {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil || id < 1 || id > len(nodes) {
+			l.logger.Warnf("Unknown mgmtd node %s is the leader", fields[0])
+			return ""
+		}
+		return nodes[id-1]
+	}
+	return ""
+}
+
+// Do runs the operation with the address of the mgmtd leader in the format of admin_cli.
+// If the operation fails and the leader has changed, it's retried once with the new
+// leader, so the operation must be idempotent, e.g. list-nodes or set-config.
+func (l *Router) Do(ctx context.Context, op func(addr string) (string, error)) (string, error) {
+	leader, err := l.Leader(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	out, err := op(Addresses(l.runtime, leader))
+	if err == nil {
+		return out, nil
+	}
+	l.leader = ""
+	newLeader, detectErr := l.Leader(ctx)
+	if detectErr != nil || newLeader == leader {
+		return out, errors.Trace(err)
+	}
+	l.logger.Warnf("Mgmtd leader changed from %s to %s, retrying: %v", leader, newLeader, err)
+	out, err = op(Addresses(l.runtime, newLeader))
+	return out, errors.Trace(err)
+}
+
+// DoOnce runs the operation with the address of the mgmtd leader like Do, but it isn't
+// retried, e.g. user-add or creating targets, which may have been applied by the old
+// leader before it failed. The leader is detected again by the next operation if it
+// fails.
+func (l *Router) DoOnce(ctx context.Context, op func(addr string) (string, error)) (string, error) {
+	leader, err := l.Leader(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	out, err := op(Addresses(l.runtime, leader))
+	if err != nil {
+		l.leader = ""
+		return out, errors.Trace(err)
+	}
+	return out, nil
+}
+
+// Run runs the idempotent admin_cli command by the mgmtd leader like Do.
+func (l *Router) Run(ctx context.Context, command string) (string, error) {
+	out, err := l.Do(ctx, func(addr string) (string, error) {
+		return l.adminCli(ctx, addr, command)
+	})
+	return out, errors.Trace(err)
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestRouterSuite(t *testing.T) {
+	suite.Run(t, &routerSuite{})
+}
+
+type routerSuite struct {
+	ttask.StepSuite
+
+	// primaryIDs are ids of primaries reported by mgmtd of hosts, no mgmtd is the
+	// primary if it's empty.
+	primaryIDs map[string]string
+	commands   []string
+	router     *Router
+}
+
+func (s *routerSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.1"},
+		{Name: "node2", Host: "10.0.0.2"},
+	}
+	s.Cfg.Services.Mgmtd.Nodes = []string{"node1", "node2"}
+	s.Cfg.Services.Mgmtd.RDMAListenPort = 8000
+	s.SetupRuntime()
+	s.Runtime.MgmtdProtocol = "RDMA"
+	s.primaryIDs = make(map[string]string)
+	s.commands = nil
+	s.router = NewRouter(s.Runtime, s.adminCli, s.Logger)
+
+	timeout, interval := waitTimeout, waitInterval
+	waitTimeout, waitInterval = 10*time.Millisecond, time.Millisecond
+	s.T().Cleanup(func() {
+		waitTimeout, waitInterval = timeout, interval
+	})
+}
+
+func (s *routerSuite) adminCli(_ context.Context, addr, command string) (string, error) {
+	s.commands = append(s.commands, addr+" "+command)
+	if command != "list-nodes" {
+		return "ok", nil
+	}
+	for host, primaryID := range s.primaryIDs {
+		if addr != fmt.Sprintf(`["RDMA://%s:8000"]`, host) {
+			continue
+		}
+		out := "Id  Type   Status               Hostname\n"
+		for _, id := range []string{"1", "2"} {
+			status := "HEARTBEAT_CONNECTED"
+			if id == primaryID {
+				status = PrimaryMgmtdStatus
+			}
+			out += fmt.Sprintf("%s   MGMTD  %s  node%s\n", id, status, id)
+		}
+		return out, nil
+	}
+	return "", errors.New("connection refused")
+}
+
+func (s *routerSuite) setPrimary(primaryID string) {
+	s.primaryIDs["10.0.0.1"] = primaryID
+	s.primaryIDs["10.0.0.2"] = primaryID
+}
+
+func (s *routerSuite) TestAddresses() {
+	s.Equal(`["RDMA://10.0.0.1:8000","RDMA://10.0.0.2:8000"]`, Addresses(s.Runtime, "node1", "node2"))
+}
+
+func (s *routerSuite) TestLeader() {
+	s.setPrimary("2")
+
+	leader, err := s.router.Leader(s.Ctx())
+	s.NoError(err)
+	s.Equal("node2", leader)
+	stored, _ := s.Runtime.LoadString(task.RuntimeMgmtdLeaderKey)
+	s.Equal("node2", stored)
+
+	// routers of the runtime share the leader
+	s.commands = nil
+	leader, err = NewRouter(s.Runtime, s.adminCli, s.Logger).Leader(s.Ctx())
+	s.NoError(err)
+	s.Equal("node2", leader)
+	s.Empty(s.commands)
+}
+
+func (s *routerSuite) TestSplitBrain() {
+	s.primaryIDs["10.0.0.1"] = "1"
+	s.primaryIDs["10.0.0.2"] = "2"
+
+	_, err := s.router.Leader(s.Ctx())
+	s.ErrorContains(err, "no mgmtd leader emerged")
+	s.ErrorContains(err, "split brain, mgmtd nodes node1, node2 are all reported as the leader")
+}
+
+func (s *routerSuite) TestNoLeader() {
+	s.setPrimary("")
+
+	_, err := s.router.Leader(s.Ctx())
+	s.ErrorContains(err, "no mgmtd node is the leader")
+}
+
+func (s *routerSuite) TestDoRetriesOnNewLeader() {
+	s.setPrimary("1")
+	var addrs []string
+	_, err := s.router.Do(s.Ctx(), func(addr string) (string, error) {
+		addrs = append(addrs, addr)
+		if len(addrs) == 1 {
+			// the leader moves to node2 while the operation runs
+			s.setPrimary("2")
+			return "", errors.New("not primary")
+		}
+		return "ok", nil
+	})
+
+	s.NoError(err)
+	s.Equal([]string{`["RDMA://10.0.0.1:8000"]`, `["RDMA://10.0.0.2:8000"]`}, addrs)
+}
+
+func (s *routerSuite) TestDoFailsWithSameLeader() {
+	s.setPrimary("1")
+
+	calls := 0
+	_, err := s.router.Do(s.Ctx(), func(string) (string, error) {
+		calls++
+		return "", errors.New("failed")
+	})
+
+	s.ErrorContains(err, "failed")
+	s.Equal(1, calls)
+}
+
+func (s *routerSuite) TestDoOnceNotRetried() {
+	s.setPrimary("1")
+	calls := 0
+	_, err := s.router.DoOnce(s.Ctx(), func(string) (string, error) {
+		calls++
+		s.setPrimary("2")
+		return "", errors.New("not primary")
+	})
+
+	s.ErrorContains(err, "not primary")
+	s.Equal(1, calls)
+	// the next operation runs by the new leader
+	leader, err := s.router.Leader(s.Ctx())
+	s.NoError(err)
+	s.Equal("node2", leader)
+}
+
+func (s *routerSuite) TestRun() {
+	s.setPrimary("2")
+
+	out, err := s.router.Run(s.Ctx(), "set-config --type META")
+	s.NoError(err)
+	s.Equal("ok", out)
+	s.Equal(`["RDMA://10.0.0.2:8000"] set-config --type META`, s.commands[len(s.commands)-1])
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgmtd
+
+import (
+	"cmp"
+	"context"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/storage"
+)
+
+// defines files generated by gen_chain_table.py in the mgmtd container.
+const (
+	createTargetCmdFile = "output/create_target_cmd.txt"
+	generatedChainsFile = "output/generated_chains.csv"
+)
+
+// chainTarget is a storage target created by a line of create_target_cmd.txt, e.g.
+// "create-target --node-id 10001 --disk-index 0 --target-id 101000100101 --chain-id 900100001".
+type chainTarget struct {
+	nodeID   int
+	targetID string
+	// line is the index of the line creating the target.
+	line int
+}
+
+// parseCreateTargetCmds parses targets created by lines of create_target_cmd.txt.
+func parseCreateTargetCmds(lines []string) ([]*chainTarget, error) {
+	var targets []*chainTarget
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "create-target" {
+			continue
+		}
+		target := &chainTarget{nodeID: -1, line: i}
+		for j := 1; j < len(fields)-1; j++ {
+			switch fields[j] {
+			case "--node-id":
+				nodeID, err := strconv.Atoi(fields[j+1])
+				if err != nil {
+					return nil, errors.Annotatef(err, "parse node id of %s", line)
+				}
+				target.nodeID = nodeID
+			case "--target-id":
+				target.targetID = fields[j+1]
+			}
+		}
+		if target.nodeID < 0 || target.targetID == "" {
+			return nil, errors.Errorf("unexpected create target command: %s", line)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// spreadChains regroups targets into chains so that targets of every chain are in
+// distinct zones, chain ids are kept and targets are taken from zones and nodes with
+// the most remaining targets, so that targets of zones and nodes are balanced among
+// chains. It returns lines of create_target_cmd.txt and generated_chains.csv with
+// chains regrouped.
+func spreadChains(cmdLines, chainLines []string, replicationFactor int,
+	zoneOf func(nodeID int) string) ([]string, []string, error) {
+
+	targets, err := parseCreateTargetCmds(cmdLines)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	var chainIDs []string
+	for _, line := range chainLines[min(1, len(chainLines)):] {
+		if line = strings.TrimSpace(line); line != "" {
+			chainIDs = append(chainIDs, strings.Split(line, ",")[0])
+		}
+	}
+	if len(chainIDs)*replicationFactor != len(targets) {
+		return nil, nil, errors.Errorf("%d targets can't be grouped into %d chains with replicationFactor %d",
+			len(targets), len(chainIDs), replicationFactor)
+	}
+
+	// remaining targets of nodes of zones in order of creation
+	zoneTargets := make(map[string]map[int][]*chainTarget)
+	for _, target := range targets {
+		zone := zoneOf(target.nodeID)
+		if zoneTargets[zone] == nil {
+			zoneTargets[zone] = make(map[int][]*chainTarget)
+		}
+		zoneTargets[zone][target.nodeID] = append(zoneTargets[zone][target.nodeID], target)
+	}
+	zoneRemaining := func(zone string) int {
+		count := 0
+		for _, nodeTargets := range zoneTargets[zone] {
+			count += len(nodeTargets)
+		}
+		return count
+	}
+
+	cmdLines = slices.Clone(cmdLines)
+	newChainLines := []string{chainLines[0]}
+	for _, chainID := range chainIDs {
+		zones := make([]string, 0, len(zoneTargets))
+		for zone := range zoneTargets {
+			if zoneRemaining(zone) > 0 {
+				zones = append(zones, zone)
+			}
+		}
+		if len(zones) < replicationFactor {
+			return nil, nil, errors.Errorf("chain %s can't be spread across %d zones, only %d zone(s) have targets left",
+				chainID, replicationFactor, len(zones))
+		}
+		slices.SortFunc(zones, func(a, b string) int {
+			return cmp.Or(cmp.Compare(zoneRemaining(b), zoneRemaining(a)), cmp.Compare(a, b))
+		})
+
+		row := []string{chainID}
+		for _, zone := range zones[:replicationFactor] {
+			nodeID := -1
+			for id, nodeTargets := range zoneTargets[zone] {
+				if len(nodeTargets) == 0 {
+					continue
+				}
+				if nodeID < 0 || len(nodeTargets) > len(zoneTargets[zone][nodeID]) ||
+					len(nodeTargets) == len(zoneTargets[zone][nodeID]) && id < nodeID {
+					nodeID = id
+				}
+			}
+			target := zoneTargets[zone][nodeID][0]
+			zoneTargets[zone][nodeID] = zoneTargets[zone][nodeID][1:]
+			row = append(row, target.targetID)
+			cmdLines[target.line] = replaceFlagValue(cmdLines[target.line], "--chain-id", chainID)
+		}
+		newChainLines = append(newChainLines, strings.Join(row, ","))
+	}
+	return cmdLines, newChainLines, nil
+}
+
+// replaceFlagValue replaces the value of the flag in the command line.
+func replaceFlagValue(line, flag, value string) string {
+	fields := strings.Fields(line)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == flag {
+			fields[i+1] = value
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// spreadChainsAcrossZones regroups chains generated by gen_chain_table.py, so that
+// replicas of every chain are placed in distinct zones of storage nodes.
+func (s *initUserAndChainStep) spreadChainsAcrossZones(ctx context.Context) error {
+	storageNodes := s.Runtime.Services.Storage.Nodes
+	zones := s.Runtime.Cfg.NodeZones(storageNodes)
+	zoneOf := func(nodeID int) string {
+		if i := nodeID - storage.NodeIDBegin; i >= 0 && i < len(storageNodes) {
+			return zones[storageNodes[i]]
+		}
+		return ""
+	}
+
+	cmdLines, err := s.readChainFile(ctx, createTargetCmdFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	chainLines, err := s.readChainFile(ctx, generatedChainsFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cmdLines, chainLines, err = spreadChains(cmdLines, chainLines,
+		s.Runtime.Services.Storage.ReplicationFactor, zoneOf)
+	if err != nil {
+		return errors.Annotate(err, "spread chains across zones")
+	}
+	if err = s.writeChainFile(ctx, createTargetCmdFile, cmdLines); err != nil {
+		return errors.Trace(err)
+	}
+	if err = s.writeChainFile(ctx, generatedChainsFile, chainLines); err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("Spread replicas of %d chains across zones of storage nodes", len(chainLines)-1)
+	return nil
+}
+
+// readChainFile returns lines of the file generated by gen_chain_table.py.
+func (s *initUserAndChainStep) readChainFile(ctx context.Context, file string) ([]string, error) {
+	output, err := s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName, "cat", file)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read %s", file)
+	}
+	return strings.Split(strings.TrimSpace(output), "\n"), nil
+}
+
+// writeChainFile overwrites the file generated by gen_chain_table.py with the lines. The
+// file is copied into the config dir mounted into the mgmtd container and moved there,
+// since it exceeds the limit of the size of a command argument on large clusters.
+func (s *initUserAndChainStep) writeChainFile(ctx context.Context, file string, lines []string) error {
+	localEm := s.Runtime.LocalEm
+	tmpDir, err := localEm.FS.MkdirTemp(ctx, s.Runtime.LocalTempDir(), "3fs-mgmtd")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.Runtime.RegisterTempDir("", tmpDir)
+	defer func() {
+		if err := localEm.FS.RemoveAll(ctx, tmpDir); err != nil {
+			s.Logger.Warnf("Failed to remove temporary directory %s: %v", tmpDir, err)
+		}
+	}()
+	name := path.Base(file)
+	localPath := filepath.Join(tmpDir, name)
+	if err = localEm.FS.WriteFile(localPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return errors.Trace(err)
+	}
+	remotePath := path.Join(getServiceWorkDir(s.Runtime.WorkDir), "config.d", name)
+	if err = s.Em.Runner.Scp(ctx, localPath, remotePath); err != nil {
+		return errors.Annotatef(err, "copy %s to %s", localPath, remotePath)
+	}
+	_, err = s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
+		"mv", path.Join("/opt/3fs/etc", name), file)
+	return errors.Annotatef(err, "write %s", file)
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgmtd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestPlacementSuite(t *testing.T) {
+	suiteRun(t, &placementSuite{})
+}
+
+type placementSuite struct {
+	ttask.StepSuite
+
+	step *initUserAndChainStep
+}
+
+func (s *placementSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.1", Zone: "az1"},
+		{Name: "node2", Host: "10.0.0.2", Zone: "az1"},
+		{Name: "node3", Host: "10.0.0.3", Zone: "az2"},
+		{Name: "node4", Host: "10.0.0.4", Zone: "az2"},
+	}
+	s.Cfg.Services.Mgmtd.Nodes = []string{"node1"}
+	s.Cfg.Services.Storage.Nodes = []string{"node1", "node2", "node3", "node4"}
+	s.Cfg.Services.Storage.ReplicationFactor = 2
+	s.Cfg.Services.Storage.Placement.SpreadZones = true
+	s.SetupRuntime()
+	s.step = &initUserAndChainStep{}
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+}
+
+// mockWriteChainFile mocks writing the lines into the file in the mgmtd container.
+func (s *placementSuite) mockWriteChainFile(file string, lines []string) {
+	name := file[strings.LastIndex(file, "/")+1:]
+	tmpDir := "/tmp/3fs-mgmtd." + name
+	s.MockLocalFS.On("MkdirTemp", os.TempDir(), "3fs-mgmtd").Return(tmpDir, nil).Once()
+	s.MockLocalFS.On("WriteFile", tmpDir+"/"+name,
+		[]byte(strings.Join(lines, "\n")+"\n"), os.FileMode(0644)).Return(nil)
+	s.MockRunner.On("Scp", tmpDir+"/"+name, "/root/3fs/mgmtd/config.d/"+name).Return(nil)
+	s.MockDocker.On("Exec", s.Runtime.Services.Mgmtd.ContainerName, "mv",
+		[]string{"/opt/3fs/etc/" + name, file}).Return("", nil)
+	s.MockLocalFS.On("RemoveAll", tmpDir).Return(nil)
+}
+
+// chains of targets in the same zone generated by gen_chain_table.py
+var (
+	testCreateTargetCmds = []string{
+		"create-target --node-id 10001 --disk-index 0 --target-id 1001 --chain-id 9001",
+		"create-target --node-id 10002 --disk-index 0 --target-id 1002 --chain-id 9001",
+		"create-target --node-id 10001 --disk-index 0 --target-id 1011 --chain-id 9002",
+		"create-target --node-id 10002 --disk-index 0 --target-id 1012 --chain-id 9002",
+		"create-target --node-id 10003 --disk-index 0 --target-id 1003 --chain-id 9003",
+		"create-target --node-id 10004 --disk-index 0 --target-id 1004 --chain-id 9003",
+		"create-target --node-id 10003 --disk-index 0 --target-id 1013 --chain-id 9004",
+		"create-target --node-id 10004 --disk-index 0 --target-id 1014 --chain-id 9004",
+	}
+	testGeneratedChains = []string{
+		"ChainId,TargetId,TargetId",
+		"9001,1001,1002",
+		"9002,1011,1012",
+		"9003,1003,1004",
+		"9004,1013,1014",
+	}
+)
+
+func testZoneOf(nodeID int) string {
+	if nodeID <= 10002 {
+		return "az1"
+	}
+	return "az2"
+}
+
+func (s *placementSuite) TestSpreadChains() {
+	cmds, chains, err := spreadChains(testCreateTargetCmds, testGeneratedChains, 2, testZoneOf)
+	s.NoError(err)
+
+	s.Equal([]string{
+		"ChainId,TargetId,TargetId",
+		"9001,1001,1003",
+		"9002,1002,1004",
+		"9003,1011,1013",
+		"9004,1012,1014",
+	}, chains)
+	s.Equal([]string{
+		"create-target --node-id 10001 --disk-index 0 --target-id 1001 --chain-id 9001",
+		"create-target --node-id 10002 --disk-index 0 --target-id 1002 --chain-id 9002",
+		"create-target --node-id 10001 --disk-index 0 --target-id 1011 --chain-id 9003",
+		"create-target --node-id 10002 --disk-index 0 --target-id 1012 --chain-id 9004",
+		"create-target --node-id 10003 --disk-index 0 --target-id 1003 --chain-id 9001",
+		"create-target --node-id 10004 --disk-index 0 --target-id 1004 --chain-id 9002",
+		"create-target --node-id 10003 --disk-index 0 --target-id 1013 --chain-id 9003",
+		"create-target --node-id 10004 --disk-index 0 --target-id 1014 --chain-id 9004",
+	}, cmds)
+	// the input isn't modified
+	s.Equal("create-target --node-id 10002 --disk-index 0 --target-id 1002 --chain-id 9001", testCreateTargetCmds[1])
+}
+
+func (s *placementSuite) TestSpreadChainsWithTooFewZones() {
+	_, _, err := spreadChains(testCreateTargetCmds, testGeneratedChains, 2,
+		func(int) string { return "az1" })
+
+	s.Error(err, "chain 9001 can't be spread across 2 zones, only 1 zone(s) have targets left")
+}
+
+func (s *placementSuite) TestSpreadChainsWithUnexpectedCmd() {
+	_, _, err := spreadChains([]string{"create-target --node-id 10001"}, testGeneratedChains, 2, testZoneOf)
+
+	s.Error(err, "unexpected create target command: create-target --node-id 10001")
+}
+
+func (s *placementSuite) TestSpreadChainsAcrossZones() {
+	containerName := s.Runtime.Services.Mgmtd.ContainerName
+	s.MockDocker.On("Exec", containerName, "cat", []string{"output/create_target_cmd.txt"}).
+		Return(strings.Join(testCreateTargetCmds, "\n")+"\n", nil)
+	s.MockDocker.On("Exec", containerName, "cat", []string{"output/generated_chains.csv"}).
+		Return(strings.Join(testGeneratedChains, "\n")+"\n", nil)
+	cmds, chains, err := spreadChains(testCreateTargetCmds, testGeneratedChains, 2, testZoneOf)
+	s.NoError(err)
+	s.mockWriteChainFile("output/create_target_cmd.txt", cmds)
+	s.mockWriteChainFile("output/generated_chains.csv", chains)
+
+	s.NoError(s.step.spreadChainsAcrossZones(s.Ctx()))
+
+	s.MockDocker.AssertExpectations(s.T())
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *placementSuite) TestWriteLargeChainFile() {
+	// targets of 8 nodes with 8 disks and 32 targets per disk exceed 128 KiB
+	var cmds []string
+	for i := range 8 * 8 * 32 {
+		cmds = append(cmds, fmt.Sprintf("create-target --node-id %d --disk-index %d --target-id %d "+
+			"--chain-id %d --use-new-chunk-engine", 10001+i%8, i/8%8, 101000100001+i, 900100001+i/2))
+	}
+	s.Greater(len(strings.Join(cmds, "\n")), 128<<10)
+	s.mockWriteChainFile("output/create_target_cmd.txt", cmds)
+
+	s.NoError(s.step.writeChainFile(s.Ctx(), "output/create_target_cmd.txt", cmds))
+
+	s.MockLocalFS.AssertExpectations(s.T())
+	s.MockRunner.AssertExpectations(s.T())
+	s.MockDocker.AssertExpectations(s.T())
+}
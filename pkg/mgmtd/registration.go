@@ -0,0 +1,193 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgmtd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/meta"
+	"github.com/open3fs/m3fs/pkg/mgmtd/leader"
+	"github.com/open3fs/m3fs/pkg/storage"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// RegisteredNode is a node registered with mgmtd.
+type RegisteredNode struct {
+	ID       int
+	Type     string
+	Status   string
+	Hostname string
+}
+
+// parseListNodes parses output of list-nodes, e.g.
+// Id     Type     Status               Hostname  Pid  ...
+// 1      MGMTD    PRIMARY_MGMTD        node1     1    ...
+// 100    META     HEARTBEAT_CONNECTED  node1     1    ...
+// Lines not starting with a node id are skipped.
+func parseListNodes(output string) []RegisteredNode {
+	var nodes []RegisteredNode
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		node := RegisteredNode{ID: id, Type: fields[1], Status: fields[2]}
+		if len(fields) > 3 {
+			node.Hostname = fields[3]
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// listNodes lists nodes registered with the mgmtd leader.
+func listNodes(ctx context.Context, router *leader.Router) ([]RegisteredNode, error) {
+	out, err := router.Run(ctx, "list-nodes")
+	if err != nil {
+		return nil, errors.Annotate(err, "list nodes")
+	}
+	return parseListNodes(out), nil
+}
+
+// serviceRegistration is the registration of a service node expected by the config.
+type serviceRegistration struct {
+	id       int
+	nodeType string
+	node     string
+}
+
+// expectedRegistrations returns registrations of meta and storage nodes of the config.
+func expectedRegistrations(r *task.Runtime) []serviceRegistration {
+	var regs []serviceRegistration
+	for i, node := range r.Services.Meta.Nodes {
+		regs = append(regs, serviceRegistration{id: meta.NodeIDBegin + i, nodeType: "META", node: node})
+	}
+	for i, node := range r.Services.Storage.Nodes {
+		regs = append(regs, serviceRegistration{id: storage.NodeIDBegin + i, nodeType: "STORAGE", node: node})
+	}
+	return regs
+}
+
+// RegistrationReport counts service nodes by the outcome of registering them with mgmtd.
+type RegistrationReport struct {
+	Registered int
+	Present    int
+	Updated    int
+}
+
+// String returns the report like "2 newly registered, 3 already present, 1 updated".
+func (r RegistrationReport) String() string {
+	return fmt.Sprintf("%d newly registered, %d already present, %d updated", r.Registered, r.Present, r.Updated)
+}
+
+func hostnameKey(node string) string {
+	return fmt.Sprintf("hostname/%s", node)
+}
+
+type getHostnameStep struct {
+	task.BaseStep
+}
+
+func (s *getHostnameStep) Execute(ctx context.Context) error {
+	out, err := s.Em.Runner.NonSudoExec(ctx, "hostname")
+	if err != nil {
+		return errors.Annotate(err, "get hostname")
+	}
+	s.Runtime.Store(hostnameKey(s.Node.Name), strings.TrimSpace(out))
+	return nil
+}
+
+// registerServices registers meta and storage nodes with mgmtd idempotently. Nodes which
+// are already registered are kept, a node registered with another type or by another host
+// is registered again, so reruns don't leave duplicated or stale registrations.
+func (s *initUserAndChainStep) registerServices(ctx context.Context, token string) (RegistrationReport, error) {
+	var report RegistrationReport
+	registered, err := listNodes(ctx, s.router)
+	if err != nil {
+		return report, errors.Trace(err)
+	}
+	registeredByID := make(map[int]RegisteredNode, len(registered))
+	for _, node := range registered {
+		registeredByID[node.ID] = node
+	}
+
+	for _, reg := range expectedRegistrations(s.Runtime) {
+		existing, ok := registeredByID[reg.id]
+		if ok {
+			hostname, _ := s.Runtime.LoadString(hostnameKey(reg.node))
+			if existing.Type == reg.nodeType &&
+				(hostname == "" || existing.Hostname == "" || existing.Hostname == hostname) {
+				report.Present++
+				continue
+			}
+			s.Logger.Infof("Node %d of %s is registered as %s on %s, registering it again",
+				reg.id, reg.node, existing.Type, existing.Hostname)
+			if _, err = s.adminCli(ctx, token, fmt.Sprintf("unregister-node %d", reg.id)); err != nil {
+				return report, errors.Annotatef(err, "unregister node %d", reg.id)
+			}
+		}
+		if _, err = s.adminCli(ctx, token, fmt.Sprintf("register-node %d %s", reg.id, reg.nodeType)); err != nil {
+			return report, errors.Annotatef(err, "register node %d of %s", reg.id, reg.node)
+		}
+		if ok {
+			report.Updated++
+		} else {
+			report.Registered++
+		}
+	}
+	return report, nil
+}
+
+// adminCli runs the admin_cli command with the token by the mgmtd leader. It isn't retried
+// if the leader changes, registering a node again fails if the old leader registered it.
+func (s *initUserAndChainStep) adminCli(ctx context.Context, token, command string) (string, error) {
+	out, err := s.router.DoOnce(ctx, func(addr string) (string, error) {
+		return s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
+			"/opt/3fs/bin/admin_cli",
+			"--cfg", "/opt/3fs/etc/admin_cli.toml",
+			"--config.mgmtd_client.mgmtd_server_addresses", fmt.Sprintf(`'%s'`, addr),
+			"--config.user_info.token", token,
+			fmt.Sprintf(`"%s"`, command),
+		)
+	})
+	return out, errors.Trace(err)
+}
+
+// newGetHostnameStepConfig returns the step getting hostnames of meta and storage nodes,
+// which are compared with hostnames of registered nodes.
+func newGetHostnameStepConfig(r *task.Runtime) task.StepConfig {
+	var nodes []config.Node
+	seen := make(map[string]bool)
+	for _, reg := range expectedRegistrations(r) {
+		if !seen[reg.node] {
+			seen[reg.node] = true
+			nodes = append(nodes, r.Nodes[reg.node])
+		}
+	}
+	return task.StepConfig{
+		Nodes:    nodes,
+		Parallel: true,
+		NewStep:  func() task.Step { return new(getHostnameStep) },
+	}
+}
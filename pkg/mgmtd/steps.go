@@ -19,20 +19,17 @@ import (
 	"context"
 	"embed"
 	"fmt"
-	"net"
-	"os"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"text/template"
 
 	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/mgmtd/leader"
 	"github.com/open3fs/m3fs/pkg/task"
-	"github.com/open3fs/m3fs/pkg/task/steps"
 )
 
 var (
@@ -77,6 +74,12 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	task.RegisterTemplate("mgmtd_main_app.toml.tmpl", &MgmtdMainAppTomlTmpl)
+	task.RegisterTemplate("mgmtd_main_launcher.toml.tmpl", &MgmtdMainLauncherTomlTmpl)
+	task.RegisterTemplate("mgmtd_main.toml.tmpl", &MgmtdMainTomlTmpl)
+	task.RegisterTemplate("admin_cli.toml.tmpl", &AdminCliTomlTmpl)
+	task.RegisterTemplate("admin_cli.sh.tmpl", &AdminCliShellTmpl)
 }
 
 type genAdminCliConfigStep struct {
@@ -84,22 +87,19 @@ type genAdminCliConfigStep struct {
 }
 
 func (s *genAdminCliConfigStep) Execute(ctx context.Context) error {
-	mgmtdServerAddresses := make([]string, len(s.Runtime.Services.Mgmtd.Nodes))
-	port := strconv.Itoa(s.Runtime.Services.Mgmtd.RDMAListenPort)
-	for i, nodeName := range s.Runtime.Services.Mgmtd.Nodes {
-		node := s.Runtime.Nodes[nodeName]
-		mgmtdServerAddresses[i] = fmt.Sprintf(`"%s://%s"`,
-			s.Runtime.MgmtdProtocol, net.JoinHostPort(node.Host, port))
-	}
-	mgmtdServerAddressesStr := fmt.Sprintf("[%s]", strings.Join(mgmtdServerAddresses, ","))
-	s.Runtime.Store(task.RuntimeMgmtdServerAddressesKey, mgmtdServerAddressesStr)
+	return errors.Trace(genAdminCliConfig(s.Runtime))
+}
+
+// genAdminCliConfig generates mgmtd server addresses and admin_cli.toml into the runtime.
+func genAdminCliConfig(r *task.Runtime) error {
+	mgmtdServerAddressesStr := ServerAddresses(r)
+	r.Store(task.RuntimeMgmtdServerAddressesKey, mgmtdServerAddressesStr)
 
 	adminCliData := map[string]any{
-		"ClusterID":            s.Runtime.Cfg.Name,
+		"ClusterID":            r.Cfg.Name,
 		"MgmtdServerAddresses": mgmtdServerAddressesStr,
 	}
-	s.Logger.Debugf("Admin cli config template data: %v", adminCliData)
-	t, err := template.New("admin_cli.toml").Parse(string(AdminCliTomlTmpl))
+	t, err := r.NewTemplate("admin_cli.toml").Parse(string(AdminCliTomlTmpl))
 	if err != nil {
 		return errors.Annotatef(err, "parse template of admin_cli.toml.tmpl")
 	}
@@ -108,7 +108,7 @@ func (s *genAdminCliConfigStep) Execute(ctx context.Context) error {
 	if err != nil {
 		return errors.Annotate(err, "execute template of admin_cli.toml.tmpl")
 	}
-	s.Runtime.Store(task.RuntimeAdminCliTomlKey, data.Bytes())
+	r.Store(task.RuntimeAdminCliTomlKey, data.Bytes())
 
 	return nil
 }
@@ -174,29 +174,18 @@ type genAdminCliShellStep struct {
 }
 
 func (s *genAdminCliShellStep) Execute(ctx context.Context) error {
-	tempDir, err := s.Runtime.LocalEm.FS.MkdirTemp(ctx, os.TempDir(), "3fs-mgmtd")
+	tempDir, err := s.Runtime.LocalEm.FS.MkdirTemp(ctx, s.Runtime.LocalTempDir(), "3fs-mgmtd")
 	if err != nil {
 		return errors.Trace(err)
 	}
+	s.Runtime.RegisterTempDir("", tempDir)
 
-	mgmtdServerAddresses, ok := s.Runtime.LoadString(task.RuntimeMgmtdServerAddressesKey)
-	if !ok {
-		return errors.Errorf("Failed to value of %s", task.RuntimeMgmtdServerAddressesKey)
-	}
-
-	t, err := template.New("admin_cli.sh").Parse(string(AdminCliShellTmpl))
-	if err != nil {
-		return errors.Annotatef(err, "parse template of admin_cli.sh.tmpl")
-	}
-	data := new(bytes.Buffer)
-	err = t.Execute(data, map[string]string{
-		"MgmtdServerAddresses": mgmtdServerAddresses,
-	})
+	data, err := renderAdminCliShell(s.Runtime)
 	if err != nil {
-		return errors.Annotate(err, "execute template of admin_cli.sh.tmpl")
+		return errors.Trace(err)
 	}
 	srcShellPath := filepath.Join(tempDir, "admin_cli.sh")
-	if err = s.Runtime.LocalEm.FS.WriteFile(srcShellPath, data.Bytes(), 0777); err != nil {
+	if err = s.Runtime.LocalEm.FS.WriteFile(srcShellPath, data, 0777); err != nil {
 		return errors.Trace(err)
 	}
 	dstShellPath := filepath.Join(s.Runtime.WorkDir, "admin_cli.sh")
@@ -210,15 +199,44 @@ func (s *genAdminCliShellStep) Execute(ctx context.Context) error {
 	return nil
 }
 
+func renderAdminCliShell(r *task.Runtime) ([]byte, error) {
+	mgmtdServerAddresses, ok := r.LoadString(task.RuntimeMgmtdServerAddressesKey)
+	if !ok {
+		return nil, errors.Errorf("Failed to value of %s", task.RuntimeMgmtdServerAddressesKey)
+	}
+
+	t, err := r.NewTemplate("admin_cli.sh").Parse(string(AdminCliShellTmpl))
+	if err != nil {
+		return nil, errors.Annotatef(err, "parse template of admin_cli.sh.tmpl")
+	}
+	data := new(bytes.Buffer)
+	err = t.Execute(data, map[string]string{
+		"MgmtdServerAddresses": mgmtdServerAddresses,
+	})
+	if err != nil {
+		return nil, errors.Annotate(err, "execute template of admin_cli.sh.tmpl")
+	}
+	return data.Bytes(), nil
+}
+
 type initUserAndChainStep struct {
 	task.BaseStep
+
+	router *leader.Router
 }
 
 func (s *initUserAndChainStep) Execute(ctx context.Context) error {
+	s.router = NewLeaderRouter(s.Runtime, s.Em, s.Logger)
 	token, err := s.initUser(ctx)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	// nodes must be registered before targets are created on them
+	report, err := s.registerServices(ctx, token)
+	if err != nil {
+		return errors.Annotate(err, "register services")
+	}
+	s.Logger.Infof("Registered services with mgmtd: %s", report)
 	if err = s.initChainFiles(ctx); err != nil {
 		return errors.Trace(err)
 	}
@@ -229,13 +247,15 @@ func (s *initUserAndChainStep) Execute(ctx context.Context) error {
 }
 
 func (s *initUserAndChainStep) initUser(ctx context.Context) (token string, err error) {
-	addr := steps.GetMgmtdServerAddresses(s.Runtime)
-	output, err := s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
-		"/opt/3fs/bin/admin_cli",
-		"-cfg", "/opt/3fs/etc/admin_cli.toml",
-		"--config.mgmtd_client.mgmtd_server_addresses", fmt.Sprintf(`'%s'`, addr),
-		`"user-add --root --admin 0 root"`,
-	)
+	// adding the user again fails if the old leader added it
+	output, err := s.router.DoOnce(ctx, func(addr string) (string, error) {
+		return s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
+			"/opt/3fs/bin/admin_cli",
+			"-cfg", "/opt/3fs/etc/admin_cli.toml",
+			"--config.mgmtd_client.mgmtd_server_addresses", fmt.Sprintf(`'%s'`, addr),
+			`"user-add --root --admin 0 root"`,
+		)
+	})
 	if err != nil {
 		return "", errors.Annotate(err, "add user")
 	}
@@ -310,41 +330,56 @@ func (s *initUserAndChainStep) initChainFiles(ctx context.Context) error {
 	if err != nil {
 		return errors.Annotatef(err, "run gen_chain_table.py")
 	}
+	if s.Runtime.Services.Storage.Placement.SpreadZones {
+		if err = s.spreadChainsAcrossZones(ctx); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if s.Runtime.Cfg.StorageCapacities() != nil {
+		return errors.Trace(s.weightChainsByCapacity(ctx))
+	}
 
 	return nil
 }
 
 func (s *initUserAndChainStep) uploadChainFiles(ctx context.Context, token string) error {
-	addr := steps.GetMgmtdServerAddresses(s.Runtime)
-	escapedAddr := strings.Replace(addr, `"`, `\"`, -1)
-	_, err := s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
-		"bash", "-c",
-		fmt.Sprintf(
-			`"/opt/3fs/bin/admin_cli --cfg /opt/3fs/etc/admin_cli.toml `+
-				`--config.mgmtd_client.mgmtd_server_addresses '%s' `+
-				`--config.user_info.token %s < output/create_target_cmd.txt"`,
-			escapedAddr, token),
-	)
+	// creating targets again fails if the old leader created them, while uploading the
+	// same chains and chain table again is idempotent
+	_, err := s.router.DoOnce(ctx, func(addr string) (string, error) {
+		escapedAddr := strings.Replace(addr, `"`, `\"`, -1)
+		return s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
+			"bash", "-c",
+			fmt.Sprintf(
+				`"/opt/3fs/bin/admin_cli --cfg /opt/3fs/etc/admin_cli.toml `+
+					`--config.mgmtd_client.mgmtd_server_addresses '%s' `+
+					`--config.user_info.token %s < output/create_target_cmd.txt"`,
+				escapedAddr, token),
+		)
+	})
 	if err != nil {
 		return errors.Annotatef(err, "create targets")
 	}
-	_, err = s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
-		"/opt/3fs/bin/admin_cli",
-		"--cfg", "/opt/3fs/etc/admin_cli.toml",
-		"--config.mgmtd_client.mgmtd_server_addresses", fmt.Sprintf(`'%s'`, addr),
-		"--config.user_info.token", token,
-		`"upload-chains output/generated_chains.csv"`,
-	)
+	_, err = s.router.Do(ctx, func(addr string) (string, error) {
+		return s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
+			"/opt/3fs/bin/admin_cli",
+			"--cfg", "/opt/3fs/etc/admin_cli.toml",
+			"--config.mgmtd_client.mgmtd_server_addresses", fmt.Sprintf(`'%s'`, addr),
+			"--config.user_info.token", token,
+			`"upload-chains output/generated_chains.csv"`,
+		)
+	})
 	if err != nil {
 		return errors.Annotatef(err, "upload-chains output/generated_chains.csv")
 	}
-	_, err = s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
-		"/opt/3fs/bin/admin_cli",
-		"--cfg", "/opt/3fs/etc/admin_cli.toml",
-		"--config.mgmtd_client.mgmtd_server_addresses", fmt.Sprintf(`'%s'`, addr),
-		"--config.user_info.token", token,
-		`"upload-chain-table --desc stage 1 output/generated_chain_table.csv"`,
-	)
+	_, err = s.router.Do(ctx, func(addr string) (string, error) {
+		return s.Em.Docker.Exec(ctx, s.Runtime.Services.Mgmtd.ContainerName,
+			"/opt/3fs/bin/admin_cli",
+			"--cfg", "/opt/3fs/etc/admin_cli.toml",
+			"--config.mgmtd_client.mgmtd_server_addresses", fmt.Sprintf(`'%s'`, addr),
+			"--config.user_info.token", token,
+			`"upload-chain-table --desc stage 1 output/generated_chain_table.csv"`,
+		)
+	})
 	if err != nil {
 		return errors.Annotatef(err, "upload-chain-table output/generated_chain_table.csv")
 	}
@@ -505,12 +505,22 @@ func (s *initUserAndChainStepSuite) SetupTest() {
 	s.StepSuite.SetupTest()
 
 	s.step = &initUserAndChainStep{}
+	s.Cfg.Nodes = []config.Node{{Name: "node1", Host: "10.16.28.58"}}
+	s.Cfg.Services.Mgmtd.Nodes = []string{"node1"}
+	s.Cfg.Services.Mgmtd.RDMAListenPort = 8000
+	s.SetupRuntime()
+	s.Runtime.MgmtdProtocol = "RDMA"
 	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
 	s.Runtime.Store(task.RuntimeMgmtdServerAddressesKey, `["RDMA://10.16.28.58:8000"]`)
 }
 
 func (s *initUserAndChainStepSuite) Test() {
 	containerName := s.Runtime.Services.Mgmtd.ContainerName
+	s.MockDocker.On("Exec", containerName, "/opt/3fs/bin/admin_cli", []string{
+		"-cfg", "/opt/3fs/etc/admin_cli.toml",
+		"--config.mgmtd_client.mgmtd_server_addresses", `'["RDMA://10.16.28.58:8000"]'`,
+		`"list-nodes"`,
+	}).Return("Id  Type   Status         Hostname\n1   MGMTD  PRIMARY_MGMTD  node1\n", nil)
 	s.MockDocker.On("Exec", containerName, "/opt/3fs/bin/admin_cli", []string{
 		"-cfg", "/opt/3fs/etc/admin_cli.toml",
 		"--config.mgmtd_client.mgmtd_server_addresses", `'["RDMA://10.16.28.58:8000"]'`,
@@ -568,4 +578,75 @@ SupplementaryGids`, nil)
 	}).Return("", nil)
 
 	s.NoError(s.step.Execute(s.Ctx()))
+	leader, _ := s.Runtime.LoadString(task.RuntimeMgmtdLeaderKey)
+	s.Equal("node1", leader)
+}
+
+func (s *initUserAndChainStepSuite) TestRegisterServices() {
+	s.Cfg.Nodes = append(s.Cfg.Nodes, config.Node{Name: "node2", Host: "10.16.28.59"})
+	s.Cfg.Services.Meta.Nodes = []string{"node1"}
+	s.Cfg.Services.Storage.Nodes = []string{"node1", "node2"}
+	s.Runtime.Store(hostnameKey("node1"), "host-a")
+	s.Runtime.Store(hostnameKey("node2"), "host-b")
+	s.Runtime.Store(task.RuntimeMgmtdLeaderKey, "node1")
+	s.step.router = NewLeaderRouter(s.Runtime, s.MockEm, s.Logger)
+	containerName := s.Runtime.Services.Mgmtd.ContainerName
+	adminCli := func(command string) []string {
+		return []string{
+			"--cfg", "/opt/3fs/etc/admin_cli.toml",
+			"--config.mgmtd_client.mgmtd_server_addresses", `'["RDMA://10.16.28.58:8000"]'`,
+			"--config.user_info.token", "token",
+			command,
+		}
+	}
+	s.MockDocker.On("Exec", containerName, "/opt/3fs/bin/admin_cli", []string{
+		"-cfg", "/opt/3fs/etc/admin_cli.toml",
+		"--config.mgmtd_client.mgmtd_server_addresses", `'["RDMA://10.16.28.58:8000"]'`,
+		`"list-nodes"`,
+	}).Return(`Id     Type     Status               Hostname  Pid
+1      MGMTD    PRIMARY_MGMTD        host-a    1
+100    META     HEARTBEAT_CONNECTED  host-a    1
+10002  STORAGE  HEARTBEAT_CONNECTED  host-c    1
+`, nil)
+	s.MockDocker.On("Exec", containerName, "/opt/3fs/bin/admin_cli",
+		adminCli(`"register-node 10001 STORAGE"`)).Return("", nil)
+	s.MockDocker.On("Exec", containerName, "/opt/3fs/bin/admin_cli",
+		adminCli(`"unregister-node 10002"`)).Return("", nil)
+	s.MockDocker.On("Exec", containerName, "/opt/3fs/bin/admin_cli",
+		adminCli(`"register-node 10002 STORAGE"`)).Return("", nil)
+
+	report, err := s.step.registerServices(s.Ctx(), "token")
+	s.NoError(err)
+	s.Equal(RegistrationReport{Registered: 1, Present: 1, Updated: 1}, report)
+	s.Equal("1 newly registered, 1 already present, 1 updated", report.String())
+	s.MockDocker.AssertExpectations(s.T())
+}
+
+func TestConfigRendererSuite(t *testing.T) {
+	suiteRun(t, &configRendererSuite{})
+}
+
+type configRendererSuite struct {
+	ttask.StepSuite
+}
+
+func (s *configRendererSuite) TestRenderWithExtraConfig() {
+	s.Cfg.Nodes = []config.Node{{Name: "node1", Host: "1.1.1.1"}}
+	s.Cfg.Services.Mgmtd.Nodes = []string{"node1"}
+	s.Cfg.Services.Mgmtd.ExtraConfig = config.ExtraConfig{
+		"server.service.authenticate":       {Value: true},
+		"server.service.user_cache.buckets": {Value: 255},
+		"common.monitor.collect_period":     {Value: "5s"},
+	}
+	s.SetupRuntime()
+
+	s.NoError(ConfigRenderer.Prepare(s.Runtime))
+	files, err := ConfigRenderer.Render(s.Runtime, s.Cfg.Nodes[0])
+	s.NoError(err)
+
+	mainToml := string(files[2].Data)
+	s.Contains(mainToml, "[server.service]\nallow_heartbeat_from_unregistered = true\nauthenticate = true\n")
+	s.Contains(mainToml, "[server.service.user_cache]\nbuckets = 255\n")
+	s.Contains(mainToml, "collect_period = '5s'\n")
+	s.NotContains(mainToml, "authenticate = false")
 }
@@ -18,6 +18,7 @@ import (
 	"path"
 
 	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/log"
 	"github.com/open3fs/m3fs/pkg/task"
 	"github.com/open3fs/m3fs/pkg/task/steps"
@@ -30,6 +31,54 @@ func getServiceWorkDir(workDir string) string {
 	return path.Join(workDir, "mgmtd")
 }
 
+func newPrepareConfigSetup(r *task.Runtime) *steps.Prepare3FSConfigStepSetup {
+	return &steps.Prepare3FSConfigStepSetup{
+		Service:              ServiceName,
+		ServiceWorkDir:       getServiceWorkDir(r.WorkDir),
+		MainAppTomlTmpl:      MgmtdMainAppTomlTmpl,
+		MainLauncherTomlTmpl: MgmtdMainLauncherTomlTmpl,
+		MainTomlTmpl:         MgmtdMainTomlTmpl,
+		RDMAListenPort:       r.Services.Mgmtd.RDMAListenPort,
+		TCPListenPort:        r.Services.Mgmtd.TCPListenPort,
+		ExtraConfig:          r.Services.Mgmtd.ExtraConfig,
+	}
+}
+
+func newRunContainerSetup(r *task.Runtime) *steps.Run3FSContainerStepSetup {
+	return &steps.Run3FSContainerStepSetup{
+		ImgName:        config.ImageName3FS,
+		ContainerName:  r.Services.Mgmtd.ContainerName,
+		Service:        ServiceName,
+		ServiceType:    config.ServiceMgmtd,
+		WorkDir:        getServiceWorkDir(r.WorkDir),
+		UseRdmaNetwork: true,
+		ListenPort:     r.Services.Mgmtd.TCPListenPort,
+	}
+}
+
+// ConfigRenderer renders config files of mgmtd service.
+var ConfigRenderer = &task.ConfigRenderer{
+	Service: config.ServiceMgmtd,
+	Prepare: func(r *task.Runtime) error {
+		steps.Gen3FSNodeIDs(r, ServiceName, 1, r.Services.Mgmtd.Nodes)
+		return errors.Trace(genAdminCliConfig(r))
+	},
+	Render: func(r *task.Runtime, node config.Node) ([]*task.RenderedFile, error) {
+		files, err := steps.Render3FSConfigs(r, newPrepareConfigSetup(r), node)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		shell, err := renderAdminCliShell(r)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return append(files, &task.RenderedFile{
+			Path: path.Join(r.WorkDir, "admin_cli.sh"),
+			Data: shell,
+		}), nil
+	},
+}
+
 // CreateMgmtdServiceTask is a task for creating 3fs mgmtd services.
 type CreateMgmtdServiceTask struct {
 	task.BaseTask
@@ -38,6 +87,8 @@ type CreateMgmtdServiceTask struct {
 // Init initializes the task.
 func (t *CreateMgmtdServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("CreateMgmtdServiceTask")
+	t.BaseTask.SetService(config.ServiceMgmtd)
+	t.BaseTask.SetDeps("CreateFdbClusterTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Mgmtd.Nodes))
 	for i, node := range r.Cfg.Services.Mgmtd.Nodes {
@@ -53,17 +104,8 @@ func (t *CreateMgmtdServiceTask) Init(r *task.Runtime, logger log.Interface) {
 			NewStep: func() task.Step { return new(genAdminCliConfigStep) },
 		},
 		{
-			Nodes:    nodes,
-			Parallel: true,
-			NewStep: steps.NewPrepare3FSConfigStepFunc(&steps.Prepare3FSConfigStepSetup{
-				Service:              ServiceName,
-				ServiceWorkDir:       getServiceWorkDir(r.WorkDir),
-				MainAppTomlTmpl:      MgmtdMainAppTomlTmpl,
-				MainLauncherTomlTmpl: MgmtdMainLauncherTomlTmpl,
-				MainTomlTmpl:         MgmtdMainTomlTmpl,
-				RDMAListenPort:       r.Services.Mgmtd.RDMAListenPort,
-				TCPListenPort:        r.Services.Mgmtd.TCPListenPort,
-			}),
+			Nodes:   []config.Node{nodes[0]},
+			NewStep: steps.NewDistribute3FSConfigStepFunc(newPrepareConfigSetup(r), nodes),
 		},
 		{
 			Nodes:   []config.Node{nodes[0]},
@@ -72,14 +114,8 @@ func (t *CreateMgmtdServiceTask) Init(r *task.Runtime, logger log.Interface) {
 		{
 			Nodes:    nodes,
 			Parallel: true,
-			NewStep: steps.NewRun3FSContainerStepFunc(
-				&steps.Run3FSContainerStepSetup{
-					ImgName:        config.ImageName3FS,
-					ContainerName:  r.Services.Mgmtd.ContainerName,
-					Service:        ServiceName,
-					WorkDir:        getServiceWorkDir(r.WorkDir),
-					UseRdmaNetwork: true,
-				}),
+			Rollout:  true,
+			NewStep:  steps.NewRun3FSContainerStepFunc(newRunContainerSetup(r)),
 		},
 		{
 			Nodes:    nodes,
@@ -89,6 +125,31 @@ func (t *CreateMgmtdServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	})
 }
 
+// UpgradeMgmtdServiceTask is a task for upgrading 3fs mgmtd services to the image in the
+// config. Nodes are upgraded one by one, each one must be ready before the next one, unless
+// the deployment strategy of the config is set.
+type UpgradeMgmtdServiceTask struct {
+	task.BaseTask
+
+	// Nodes are names of nodes to upgrade, all nodes of the service are upgraded if it's empty.
+	Nodes []string
+}
+
+// Init initializes the task.
+func (t *UpgradeMgmtdServiceTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("UpgradeMgmtdServiceTask")
+	t.BaseTask.SetService(config.ServiceMgmtd)
+	t.BaseTask.Init(r, logger)
+	nodes := r.SelectNodes(r.Cfg.Services.Mgmtd.Nodes, t.Nodes)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:   nodes,
+			Rollout: true,
+			NewStep: steps.NewUpgrade3FSContainerStepFunc(newRunContainerSetup(r), nil),
+		},
+	})
+}
+
 // DeleteMgmtdServiceTask is a task for deleting a mgmtd services.
 type DeleteMgmtdServiceTask struct {
 	task.BaseTask
@@ -97,6 +158,8 @@ type DeleteMgmtdServiceTask struct {
 // Init initializes the task.
 func (t *DeleteMgmtdServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("DeleteMgmtdServiceTask")
+	t.BaseTask.SetService(config.ServiceMgmtd)
+	t.BaseTask.SetDeps("DeleteMetaServiceTask", "DeleteStorageServiceTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Mgmtd.Nodes))
 	for i, node := range r.Cfg.Services.Mgmtd.Nodes {
@@ -109,6 +172,7 @@ func (t *DeleteMgmtdServiceTask) Init(r *task.Runtime, logger log.Interface) {
 			NewStep: steps.NewRm3FSContainerStepFunc(
 				r.Services.Mgmtd.ContainerName,
 				ServiceName,
+				config.ServiceMgmtd,
 				getServiceWorkDir(r.WorkDir)),
 		},
 	})
@@ -122,12 +186,15 @@ type InitUserAndChainTask struct {
 // Init initializes the task.
 func (t *InitUserAndChainTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("InitUserAndChainTask")
+	t.BaseTask.SetService(config.ServiceMgmtd)
+	t.BaseTask.SetDeps("CreateMetaServiceTask", "CreateStorageServiceTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Mgmtd.Nodes))
 	for i, node := range r.Cfg.Services.Mgmtd.Nodes {
 		nodes[i] = r.Nodes[node]
 	}
 	t.SetSteps([]task.StepConfig{
+		newGetHostnameStepConfig(r),
 		{
 			Nodes:   []config.Node{nodes[0]},
 			NewStep: func() task.Step { return new(initUserAndChainStep) },
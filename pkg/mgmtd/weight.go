@@ -0,0 +1,186 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgmtd
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/storage"
+)
+
+// generatedChainTableFile is the chain table generated by gen_chain_table.py in the mgmtd container.
+const generatedChainTableFile = "output/generated_chain_table.csv"
+
+const (
+	// maxChainWeight is the maximum times a chain appears in the chain table.
+	maxChainWeight = 8
+	// chainWeightIterations is the number of iterations fitting weights of chains.
+	chainWeightIterations = 100
+)
+
+// chainWeights returns times each chain appears in the chain table, so that data placed
+// on nodes is in proportion to their capacities. Chains are given as node ids of their
+// targets. Weights are fitted by scaling weights of chains by the mean ratio of the
+// desired to the current load of their nodes iteratively, then rounded so that the
+// lightest chain appears once.
+func chainWeights(chainNodes [][]int, capacities map[int]float64) []int {
+	var totalCapacity float64
+	for _, capacity := range capacities {
+		totalCapacity += capacity
+	}
+	weights := make([]float64, len(chainNodes))
+	for i := range weights {
+		weights[i] = 1
+	}
+	for range chainWeightIterations {
+		load := make(map[int]float64)
+		var totalLoad float64
+		for i, nodes := range chainNodes {
+			for _, node := range nodes {
+				load[node] += weights[i]
+				totalLoad += weights[i]
+			}
+		}
+		for i, nodes := range chainNodes {
+			var ratio float64
+			for _, node := range nodes {
+				ratio += capacities[node] / totalCapacity * totalLoad / load[node]
+			}
+			weights[i] *= ratio / float64(len(nodes))
+		}
+	}
+
+	lightest := math.Inf(1)
+	for _, weight := range weights {
+		lightest = min(lightest, weight)
+	}
+	rounded := make([]int, len(weights))
+	for i, weight := range weights {
+		rounded[i] = min(max(int(math.Round(weight/lightest)), 1), maxChainWeight)
+	}
+	return rounded
+}
+
+// weightedChainTable returns chain ids of the chain table in which every chain appears
+// by its weight. Appearances of a chain are spread over the table by smooth weighted
+// round-robin, so that chains of a file are mostly distinct.
+func weightedChainTable(chainIDs []string, weights []int) []string {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	current := make([]int, len(weights))
+	table := make([]string, 0, total)
+	for range total {
+		best := -1
+		for i, weight := range weights {
+			current[i] += weight
+			if best < 0 || current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		table = append(table, chainIDs[best])
+	}
+	return table
+}
+
+// weightChains regroups lines of generated_chain_table.csv, so that chains appear in the
+// chain table by weights fitting capacities of nodes of them. Lines of create_target_cmd.txt
+// and generated_chains.csv give nodes of chains.
+func weightChains(cmdLines, chainLines, tableLines []string, capacities map[int]float64) ([]string, error) {
+	targets, err := parseCreateTargetCmds(cmdLines)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	nodeOfTarget := make(map[string]int, len(targets))
+	for _, target := range targets {
+		nodeOfTarget[target.targetID] = target.nodeID
+	}
+	nodesOfChain := make(map[string][]int)
+	for _, line := range chainLines[min(1, len(chainLines)):] {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) < 2 {
+			continue
+		}
+		for _, targetID := range fields[1:] {
+			nodeID, ok := nodeOfTarget[targetID]
+			if !ok {
+				return nil, errors.Errorf("target %s of chain %s isn't created", targetID, fields[0])
+			}
+			nodesOfChain[fields[0]] = append(nodesOfChain[fields[0]], nodeID)
+		}
+	}
+
+	var chainIDs []string
+	var chainNodes [][]int
+	for _, line := range tableLines[min(1, len(tableLines)):] {
+		chainID := strings.TrimSpace(line)
+		if chainID == "" {
+			continue
+		}
+		nodes, ok := nodesOfChain[chainID]
+		if !ok {
+			return nil, errors.Errorf("chain %s of the chain table isn't generated", chainID)
+		}
+		chainIDs = append(chainIDs, chainID)
+		chainNodes = append(chainNodes, nodes)
+	}
+	for _, nodes := range chainNodes {
+		for _, node := range nodes {
+			if _, ok := capacities[node]; !ok {
+				return nil, errors.Errorf("capacity of storage node %d is unknown", node)
+			}
+		}
+	}
+	table := weightedChainTable(chainIDs, chainWeights(chainNodes, capacities))
+	return append([]string{tableLines[0]}, table...), nil
+}
+
+// weightChainsByCapacity rewrites the chain table generated by gen_chain_table.py, so
+// that storage nodes receive data in proportion to their capacities.
+func (s *initUserAndChainStep) weightChainsByCapacity(ctx context.Context) error {
+	storageNodes := s.Runtime.Services.Storage.Nodes
+	nodeCapacities := s.Runtime.Cfg.StorageCapacities()
+	capacities := make(map[int]float64, len(storageNodes))
+	for i, name := range storageNodes {
+		capacities[storage.NodeIDBegin+i] = float64(nodeCapacities[name])
+	}
+
+	cmdLines, err := s.readChainFile(ctx, createTargetCmdFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	chainLines, err := s.readChainFile(ctx, generatedChainsFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tableLines, err := s.readChainFile(ctx, generatedChainTableFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tableLines, err = weightChains(cmdLines, chainLines, tableLines, capacities)
+	if err != nil {
+		return errors.Annotate(err, "weight chains by capacity")
+	}
+	if err = s.writeChainFile(ctx, generatedChainTableFile, tableLines); err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("Weighted chain table by capacities of storage nodes, it has %d entries", len(tableLines)-1)
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgmtd
+
+import "strings"
+
+var testGeneratedChainTable = []string{"ChainId", "9001", "9002", "9003", "9004"}
+
+func (s *placementSuite) TestChainWeights() {
+	chainNodes := [][]int{{1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}}
+
+	s.Equal([]int{1, 1, 1, 1, 1, 1}, chainWeights(chainNodes, map[int]float64{1: 4, 2: 4, 3: 4, 4: 4}))
+	// loads of nodes are 14, 14, 7 and 7
+	weights := chainWeights(chainNodes, map[int]float64{1: 2, 2: 2, 3: 1, 4: 1})
+	s.Equal([]int{8, 3, 3, 3, 3, 1}, weights)
+}
+
+func (s *placementSuite) TestWeightedChainTable() {
+	s.Equal([]string{"a", "b", "c", "d", "a", "b"},
+		weightedChainTable([]string{"a", "b", "c", "d"}, []int{2, 2, 1, 1}))
+	s.Equal([]string{"a", "b", "c"}, weightedChainTable([]string{"a", "b", "c"}, []int{1, 1, 1}))
+}
+
+func (s *placementSuite) TestWeightChains() {
+	capacities := map[int]float64{10001: 2 << 40, 10002: 2 << 40, 10003: 1 << 40, 10004: 1 << 40}
+
+	table, err := weightChains(testCreateTargetCmds, testGeneratedChains, testGeneratedChainTable, capacities)
+	s.NoError(err)
+	s.Equal([]string{"ChainId", "9001", "9002", "9003", "9004", "9001", "9002"}, table)
+
+	_, err = weightChains(testCreateTargetCmds, testGeneratedChains, []string{"ChainId", "9009"}, capacities)
+	s.Error(err, "chain 9009 of the chain table isn't generated")
+}
+
+func (s *placementSuite) TestWeightChainsByCapacity() {
+	s.Cfg.Nodes[0].Capacity = "2t"
+	s.Cfg.Nodes[1].Capacity = "2t"
+	s.Cfg.Nodes[2].Capacity = "1t"
+	s.Cfg.Nodes[3].Capacity = "1t"
+	containerName := s.Runtime.Services.Mgmtd.ContainerName
+	for file, lines := range map[string][]string{
+		"output/create_target_cmd.txt":     testCreateTargetCmds,
+		"output/generated_chains.csv":      testGeneratedChains,
+		"output/generated_chain_table.csv": testGeneratedChainTable,
+	} {
+		s.MockDocker.On("Exec", containerName, "cat", []string{file}).Return(strings.Join(lines, "\n")+"\n", nil)
+	}
+	s.mockWriteChainFile("output/generated_chain_table.csv",
+		[]string{"ChainId", "9001", "9002", "9003", "9004", "9001", "9002"})
+
+	s.NoError(s.step.weightChainsByCapacity(s.Ctx()))
+
+	s.MockDocker.AssertExpectations(s.T())
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+const alertRulesTmplName = "alert_rules.yml.tmpl"
+
+// AlertRulesTmpl is the template content of alerting rules of the cluster
+var AlertRulesTmpl []byte
+
+func init() {
+	var err error
+	AlertRulesTmpl, err = templatesFs.ReadFile("templates/" + alertRulesTmplName)
+	if err != nil {
+		panic(err)
+	}
+
+	task.RegisterTemplate(alertRulesTmplName, &AlertRulesTmpl)
+}
+
+// AlertRulesFileName returns the name of the file of alerting rules of the cluster.
+func AlertRulesFileName(cluster string) string {
+	return fmt.Sprintf("m3fs-%s.rules.yml", cluster)
+}
+
+// RenderAlertRules renders Prometheus alerting rules of the cluster with thresholds of
+// services.monitor.alerts. The rules alert on metrics which m3fs produces, 3fs services
+// write their metrics into clickhouse instead of Prometheus.
+func RenderAlertRules(r *task.Runtime) ([]byte, error) {
+	tmpl, err := r.NewTemplate(alertRulesTmplName).Parse(string(AlertRulesTmpl))
+	if err != nil {
+		return nil, errors.Annotate(err, "parse alert_rules.yml template")
+	}
+	alerts := r.Services.Monitor.Alerts
+	// durations of Prometheus don't have fractions
+	seconds := func(d config.Duration) int64 {
+		return int64(d.Duration().Seconds())
+	}
+	data := new(bytes.Buffer)
+	err = tmpl.Execute(data, map[string]any{
+		"Cluster":           r.Cfg.Name,
+		"ClusterLabel":      strconv.Quote(r.Cfg.Name),
+		"NodeCount":         len(r.Cfg.Nodes),
+		"MajorityNodes":     len(r.Cfg.Nodes)/2 + 1,
+		"For":               fmt.Sprintf("%ds", seconds(alerts.For)),
+		"UnreachableNodes":  alerts.UnreachableNodes,
+		"DriftFindings":     alerts.DriftFindings,
+		"StaleCheck":        alerts.StaleCheck.String(),
+		"StaleCheckSeconds": seconds(alerts.StaleCheck),
+		"SlowTask":          alerts.SlowTask.String(),
+		"SlowTaskSeconds":   seconds(alerts.SlowTask),
+	})
+	if err != nil {
+		return nil, errors.Annotate(err, "render alert_rules.yml")
+	}
+	return data.Bytes(), nil
+}
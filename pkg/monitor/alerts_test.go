@@ -0,0 +1,98 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestRenderAlertRules(t *testing.T) {
+	suiteRun(t, &renderAlertRulesSuite{})
+}
+
+type renderAlertRulesSuite struct {
+	ttask.StepSuite
+}
+
+type renderedAlertRules struct {
+	Groups []struct {
+		Name  string
+		Rules []struct {
+			Alert       string
+			Expr        string
+			For         string
+			Labels      map[string]string
+			Annotations map[string]string
+		}
+	}
+}
+
+func (s *renderAlertRulesSuite) render() map[string]string {
+	s.SetupRuntime()
+	data, err := RenderAlertRules(s.Runtime)
+	s.NoError(err)
+	var rules renderedAlertRules
+	s.NoError(yaml.Unmarshal(data, &rules))
+	s.Len(rules.Groups, 1)
+	s.Equal("m3fs-"+s.Cfg.Name, rules.Groups[0].Name)
+	exprs := make(map[string]string)
+	for _, rule := range rules.Groups[0].Rules {
+		exprs[rule.Alert] = rule.Expr
+		if rule.Alert == "M3fsNodesUnreachable" {
+			s.Equal("300s", rule.For)
+			s.Equal("{{ $value }} of 3 node(s) can't be reached by m3fs cluster monitor-drift.",
+				rule.Annotations["description"])
+		}
+	}
+	return exprs
+}
+
+func (s *renderAlertRulesSuite) TestDefaults() {
+	s.Cfg.Name = "open3fs"
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}}
+
+	s.Equal(map[string]string{
+		"M3fsNodesUnreachable":         `m3fs_drift_unreachable_nodes{cluster="open3fs"} >= 1`,
+		"M3fsMajorityNodesUnreachable": `m3fs_drift_unreachable_nodes{cluster="open3fs"} >= 2`,
+		"M3fsDriftFound":               `m3fs_drift_findings{cluster="open3fs"} >= 1`,
+		"M3fsDriftCheckStale":          `time() - m3fs_drift_last_check_timestamp_seconds{cluster="open3fs"} > 1800`,
+		"M3fsTaskIncomplete":           `m3fs_task_completed{cluster="open3fs"} == 0`,
+	}, s.render())
+}
+
+func (s *renderAlertRulesSuite) TestThresholds() {
+	s.Cfg.Name = "open3fs"
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}}
+	s.Cfg.Services.Monitor.Alerts = config.MonitorAlerts{
+		For:              config.Duration(5 * time.Minute),
+		UnreachableNodes: 2,
+		DriftFindings:    3,
+		StaleCheck:       config.Duration(time.Hour),
+		SlowTask:         config.Duration(90 * time.Second),
+	}
+
+	exprs := s.render()
+	s.Equal(`m3fs_drift_unreachable_nodes{cluster="open3fs"} >= 2`, exprs["M3fsNodesUnreachable"])
+	s.Equal(`m3fs_drift_findings{cluster="open3fs"} >= 3`, exprs["M3fsDriftFound"])
+	s.Equal(`time() - m3fs_drift_last_check_timestamp_seconds{cluster="open3fs"} > 3600`,
+		exprs["M3fsDriftCheckStale"])
+	s.Equal(`m3fs_task_duration_seconds{cluster="open3fs"} > 90`, exprs["M3fsTaskSlow"])
+}
@@ -18,11 +18,10 @@ import (
 	"bytes"
 	"context"
 	"embed"
-	"os"
 	"path"
 	"path/filepath"
 	"strconv"
-	"text/template"
+	"strings"
 
 	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
@@ -45,6 +44,8 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	task.RegisterTemplate("monitor_collector_main.tmpl", &MonitorCollectorMainTmpl)
 }
 
 func getServiceWorkDir(workDir string) string {
@@ -56,20 +57,35 @@ type genMonitorConfigStep struct {
 }
 
 func (s *genMonitorConfigStep) Execute(ctx context.Context) error {
-	tempDir, err := s.Runtime.LocalEm.FS.MkdirTemp(ctx, os.TempDir(), "3fs-monitor")
+	tempDir, err := s.Runtime.LocalEm.FS.MkdirTemp(ctx, s.Runtime.LocalTempDir(), "3fs-monitor")
 	if err != nil {
 		return errors.Trace(err)
 	}
 	s.Runtime.Store(task.RuntimeMonitorTmpDirKey, tempDir)
+	s.Runtime.RegisterTempDir("", tempDir)
+
+	data, err := renderCollectorConfig(s.Runtime)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	configPath := filepath.Join(tempDir, collectorConfigFileName)
+	if err = s.Runtime.LocalEm.FS.WriteFile(configPath, data, 0644); err != nil {
+		return errors.Trace(err)
+	}
+
+	return nil
+}
 
-	fileName := "monitor_collector_main.toml"
-	tmpl, err := template.New(fileName).Parse(string(MonitorCollectorMainTmpl))
+const collectorConfigFileName = "monitor_collector_main.toml"
+
+func renderCollectorConfig(r *task.Runtime) ([]byte, error) {
+	tmpl, err := r.NewTemplate(collectorConfigFileName).Parse(string(MonitorCollectorMainTmpl))
 	if err != nil {
-		return errors.Annotate(err, "parse monitor_collector_main.toml template")
+		return nil, errors.Annotate(err, "parse monitor_collector_main.toml template")
 	}
 	var clickhouseHost string
-	for _, clickhouseNode := range s.Runtime.Services.Clickhouse.Nodes {
-		for _, node := range s.Runtime.Nodes {
+	for _, clickhouseNode := range r.Services.Clickhouse.Nodes {
+		for _, node := range r.Nodes {
 			if node.Name == clickhouseNode {
 				clickhouseHost = node.Host
 			}
@@ -77,22 +93,17 @@ func (s *genMonitorConfigStep) Execute(ctx context.Context) error {
 	}
 	data := new(bytes.Buffer)
 	err = tmpl.Execute(data, map[string]string{
-		"Port":               strconv.Itoa(s.Runtime.Services.Monitor.Port),
-		"ClickhouseDb":       s.Runtime.Services.Clickhouse.Db,
+		"Port":               strconv.Itoa(r.Services.Monitor.Port),
+		"ClickhouseDb":       r.Services.Clickhouse.Db,
 		"ClickhouseHost":     clickhouseHost,
-		"ClickhousePassword": s.Runtime.Services.Clickhouse.Password,
-		"ClickhousePort":     strconv.Itoa(s.Runtime.Services.Clickhouse.TCPPort),
-		"ClickhouseUser":     s.Runtime.Services.Clickhouse.User,
+		"ClickhousePassword": r.Services.Clickhouse.Password,
+		"ClickhousePort":     strconv.Itoa(r.Services.Clickhouse.TCPPort),
+		"ClickhouseUser":     r.Services.Clickhouse.User,
 	})
 	if err != nil {
-		return errors.Annotate(err, "write monitor_collector_main.toml")
-	}
-	configPath := filepath.Join(tempDir, fileName)
-	if err = s.Runtime.LocalEm.FS.WriteFile(configPath, data.Bytes(), 0644); err != nil {
-		return errors.Trace(err)
+		return nil, errors.Annotate(err, "write monitor_collector_main.toml")
 	}
-
-	return nil
+	return data.Bytes(), nil
 }
 
 type runContainerStep struct {
@@ -152,10 +163,23 @@ func (s *runContainerStep) Execute(ctx context.Context) error {
 		return errors.Trace(err)
 	}
 	args.Volumes = append(args.Volumes, s.GetRdmaVolumes()...)
+	args.SetResources(s.Runtime.Services.Monitor.Resources)
+	args.SetPassthrough(s.Runtime.Services.Monitor.Passthrough)
+	if wrapper := s.Runtime.Services.CommandWrapper(config.ServiceMonitor); len(wrapper) > 0 {
+		s.Logger.Warnf("Command of monitor is wrapped by %q, clear services.monitor.commandWrapper to remove it",
+			strings.Join(wrapper, " "))
+		args.SetCommandWrapper(wrapper)
+	}
 	_, err = s.Em.Docker.Run(ctx, args)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	monitor := s.Runtime.Services.Monitor
+	err = s.Runtime.WaitServiceReady(ctx, config.ServiceMonitor,
+		task.ServiceListenProbe(s.Em, monitor.ContainerName, "monitor_collector_main", monitor.Port))
+	if err != nil {
+		return errors.Annotate(err, "wait monitor ready")
+	}
 
 	s.Logger.Infof("Started monitor container %s successfully",
 		s.Runtime.Services.Monitor.ContainerName)
@@ -168,6 +192,9 @@ type rmContainerStep struct {
 
 func (s *rmContainerStep) Execute(ctx context.Context) error {
 	containerName := s.Runtime.Services.Monitor.ContainerName
+	if err := s.StopContainer(ctx, config.ServiceMonitor, containerName); err != nil {
+		return errors.Trace(err)
+	}
 	s.Logger.Infof("Removing monitor container %s", containerName)
 	_, err := s.Em.Docker.Rm(ctx, containerName, true)
 	if err != nil {
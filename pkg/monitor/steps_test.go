@@ -17,12 +17,14 @@ package monitor
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/external"
 	"github.com/open3fs/m3fs/pkg/task"
 	ttask "github.com/open3fs/m3fs/tests/task"
@@ -61,6 +63,24 @@ func (s *genMonitorConfigStepSuite) Test() {
 	s.Equal("/tmp/3fs-monitor.xxx", tmpDir)
 }
 
+func (s *genMonitorConfigStepSuite) TestConfigRenderer() {
+	s.Cfg.Nodes = []config.Node{{Name: "node1", Host: "1.1.1.1"}, {Name: "node2", Host: "1.1.1.2"}}
+	s.Cfg.Services.Monitor.Nodes = []string{"node1", "node2"}
+	s.SetupRuntime()
+
+	files, err := ConfigRenderer.Render(s.Runtime, s.Runtime.Nodes["node1"])
+	s.NoError(err)
+	s.Len(files, 1)
+	s.Equal("/root/3fs/monitor/etc/monitor_collector_main.toml", files[0].Path)
+	expected, err := renderCollectorConfig(s.Runtime)
+	s.NoError(err)
+	s.Equal(expected, files[0].Data)
+
+	files, err = ConfigRenderer.Render(s.Runtime, s.Runtime.Nodes["node2"])
+	s.NoError(err)
+	s.Empty(files)
+}
+
 func TestRunContainerStep(t *testing.T) {
 	suiteRun(t, &runContainerStepSuite{})
 }
@@ -119,6 +139,9 @@ func (s *runContainerStepSuite) Test() {
 		"/usr/lib/x86_64-linux-gnu/libibverbs/liberdma-rdmav34.so")
 	args.Volumes = append(args.Volumes, s.step.GetRdmaVolumes()...)
 	s.MockDocker.On("Run", args).Return("", nil)
+	s.MockDocker.On("InspectContainer", "3fs-monitor", "{{.State.Status}}").Return("running", nil)
+	s.MockRunner.On("Exec", "ss", []string{"-Hltn", "sport = :10000"}).
+		Return("LISTEN 0 4096 0.0.0.0:10000 0.0.0.0:*", nil)
 
 	s.NoError(s.step.Execute(s.Ctx()))
 
@@ -127,6 +150,22 @@ func (s *runContainerStepSuite) Test() {
 	s.MockDocker.AssertExpectations(s.T())
 }
 
+func (s *runContainerStepSuite) TestNotReadyWithWarn() {
+	s.Cfg.Services.Monitor.ReadinessTimeout = config.Duration(30 * time.Millisecond)
+	s.Cfg.Services.Monitor.ReadinessInterval = config.Duration(10 * time.Millisecond)
+	s.Cfg.Services.Monitor.ReadinessFailureMode = config.ReadinessFailureModeWarn
+	s.MockFS.On("MkdirAll", mock.Anything).Return(nil)
+	s.MockRunner.On("Scp", mock.Anything, mock.Anything).Return(nil)
+	s.Runtime.Store(s.step.GetErdmaSoPathKey(), "")
+	s.MockDocker.On("Run", mock.Anything).Return("", nil)
+	s.MockDocker.On("InspectContainer", "3fs-monitor", "{{.State.Status}}").
+		Return("", errors.New("no such container"))
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockDocker.AssertExpectations(s.T())
+}
+
 func TestRmContainerStep(t *testing.T) {
 	suiteRun(t, &rmContainerStepSuite{})
 }
@@ -147,6 +186,8 @@ func (s *rmContainerStepSuite) SetupTest() {
 	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
 	s.etcDir = "/root/3fs/monitor/etc"
 	s.logDir = "/root/3fs/monitor/log"
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Monitor.ContainerName, "{{.State.Status}}").
+		Return("exited", nil)
 }
 
 func (s *rmContainerStepSuite) TestRmContainerStep() {
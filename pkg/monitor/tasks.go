@@ -15,12 +15,33 @@
 package monitor
 
 import (
+	"path"
+
 	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/log"
 	"github.com/open3fs/m3fs/pkg/task"
 	"github.com/open3fs/m3fs/pkg/task/steps"
 )
 
+// ConfigRenderer renders config files of monitor service.
+var ConfigRenderer = &task.ConfigRenderer{
+	Service: config.ServiceMonitor,
+	Render: func(r *task.Runtime, node config.Node) ([]*task.RenderedFile, error) {
+		if node.Name != r.Services.Monitor.Nodes[0] {
+			return nil, nil
+		}
+		data, err := renderCollectorConfig(r)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return []*task.RenderedFile{{
+			Path: path.Join(getServiceWorkDir(r.WorkDir), "etc", collectorConfigFileName),
+			Data: data,
+		}}, nil
+	},
+}
+
 // CreateMonitorTask is a task for creating a 3fs monitor.
 type CreateMonitorTask struct {
 	task.BaseTask
@@ -29,6 +50,8 @@ type CreateMonitorTask struct {
 // Init initializes the task.
 func (t *CreateMonitorTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("CreateMonitorTask")
+	t.BaseTask.SetService(config.ServiceMonitor)
+	t.BaseTask.SetDeps("CreateClickhouseClusterTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Monitor.Nodes))
 	for i, node := range r.Cfg.Services.Monitor.Nodes {
@@ -58,6 +81,7 @@ type DeleteMonitorTask struct {
 // Init initializes the task.
 func (t *DeleteMonitorTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("DeleteMonitorTask")
+	t.BaseTask.SetService(config.ServiceMonitor)
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Monitor.Nodes))
 	for i, node := range r.Cfg.Services.Monitor.Nodes {
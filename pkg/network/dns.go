@@ -0,0 +1,241 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+const (
+	resolvConfPath     = "/etc/resolv.conf"
+	resolvedDropInDir  = "/etc/systemd/resolved.conf.d"
+	resolvedDropInHead = "# m3fs managed resolver config of cluster %s"
+)
+
+// ResolverBackend is the service configuring the resolver of a node.
+type ResolverBackend string
+
+// defines resolver backends
+const (
+	ResolverResolved   ResolverBackend = "systemd-resolved"
+	ResolverResolvConf ResolverBackend = "resolv.conf"
+)
+
+// ManageDNSTask is a task for writing nameservers and search domains of the dns config
+// into the resolver config of nodes.
+type ManageDNSTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *ManageDNSTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("ManageDNSTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.Nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return &updateDNSStep{} },
+		},
+	})
+}
+
+// RemoveDNSTask is a task for removing the resolver config written by ManageDNSTask.
+type RemoveDNSTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *RemoveDNSTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("RemoveDNSTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.Nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return &updateDNSStep{remove: true} },
+		},
+	})
+}
+
+// DetectResolver returns systemd-resolved if it's active on the node of the runner,
+// in which case /etc/resolv.conf is generated by it, classic resolv.conf otherwise.
+func DetectResolver(ctx context.Context, runner external.RunnerInterface) ResolverBackend {
+	out, err := runner.Exec(ctx, "systemctl", "is-active", "systemd-resolved")
+	if err == nil && strings.TrimSpace(out) == "active" {
+		return ResolverResolved
+	}
+	return ResolverResolvConf
+}
+
+// resolvedDropInPath returns the path of the drop-in of systemd-resolved of the cluster.
+func resolvedDropInPath(cluster string) string {
+	return path.Join(resolvedDropInDir, "m3fs-"+config.HostsName(cluster)+".conf")
+}
+
+// resolvedDropIn returns the drop-in of systemd-resolved of the dns config.
+func resolvedDropIn(cfg *config.Config) string {
+	lines := []string{fmt.Sprintf(resolvedDropInHead, cfg.Name), "[Resolve]"}
+	if len(cfg.DNS.Nameservers) > 0 {
+		lines = append(lines, "DNS="+strings.Join(cfg.DNS.Nameservers, " "))
+	}
+	if len(cfg.DNS.SearchDomains) > 0 {
+		lines = append(lines, "Domains="+strings.Join(cfg.DNS.SearchDomains, " "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// resolvConfBlockMarkers returns lines delimiting the block of the cluster in /etc/resolv.conf.
+func resolvConfBlockMarkers(cluster string) (string, string) {
+	return fmt.Sprintf("# BEGIN m3fs managed resolver config of cluster %s", cluster),
+		fmt.Sprintf("# END m3fs managed resolver config of cluster %s", cluster)
+}
+
+// resolvConfBlock returns the block of nameservers and search domains of the dns config.
+func resolvConfBlock(cfg *config.Config) string {
+	begin, end := resolvConfBlockMarkers(cfg.Name)
+	lines := []string{begin}
+	for _, ns := range cfg.DNS.Nameservers {
+		lines = append(lines, "nameserver "+ns)
+	}
+	if len(cfg.DNS.SearchDomains) > 0 {
+		lines = append(lines, "search "+strings.Join(cfg.DNS.SearchDomains, " "))
+	}
+	lines = append(lines, end)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// replaceResolvConfBlock replaces the block of the cluster in the content of
+// /etc/resolv.conf with the block. The block is added before other lines, so that
+// nameservers of it are queried first, and removed if the block is empty.
+func replaceResolvConfBlock(content, cluster, block string) string {
+	begin, end := resolvConfBlockMarkers(cluster)
+	return replaceManagedBlock(content, begin, end, block, true)
+}
+
+// overridingSearchLines returns search and domain lines of /etc/resolv.conf outside the
+// block of the cluster, the last of which overrides search domains of the block.
+func overridingSearchLines(content, cluster string) []string {
+	begin, end := resolvConfBlockMarkers(cluster)
+	var lines []string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == begin:
+			inBlock = true
+		case line == end:
+			inBlock = false
+		case !inBlock && (strings.HasPrefix(line, "search ") || strings.HasPrefix(line, "domain ")):
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+type updateDNSStep struct {
+	task.BaseStep
+
+	remove bool
+}
+
+func (s *updateDNSStep) Execute(ctx context.Context) error {
+	if s.remove {
+		if err := s.updateResolved(ctx, ""); err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(s.updateResolvConf(ctx, ""))
+	}
+
+	backend := DetectResolver(ctx, s.Em.Runner)
+	s.Logger.Debugf("Resolver of %s is configured by %s", s.Node.Name, backend)
+	if backend == ResolverResolved {
+		return errors.Trace(s.updateResolved(ctx, resolvedDropIn(s.Runtime.Cfg)))
+	}
+	return errors.Trace(s.updateResolvConf(ctx, resolvConfBlock(s.Runtime.Cfg)))
+}
+
+// updateResolved writes the drop-in of systemd-resolved, it's removed if the drop-in is
+// empty. systemd-resolved is restarted if the drop-in is changed.
+func (s *updateDNSStep) updateResolved(ctx context.Context, dropIn string) error {
+	dropInPath := resolvedDropInPath(s.Runtime.Cfg.Name)
+	existing := ""
+	if _, err := s.Em.Runner.Exec(ctx, "test", "-e", dropInPath); err == nil {
+		if existing, err = readNodeFile(ctx, &s.BaseStep, dropInPath); err != nil {
+			return errors.Annotatef(err, "read %s", dropInPath)
+		}
+	}
+	if existing == dropIn {
+		s.Logger.Debugf("%s is up to date", dropInPath)
+		return nil
+	}
+
+	if dropIn == "" {
+		if _, err := s.Em.Runner.Exec(ctx, "rm", "-f", dropInPath); err != nil {
+			return errors.Annotatef(err, "remove %s", dropInPath)
+		}
+		s.Logger.Infof("Removed m3fs managed resolver config %s", dropInPath)
+	} else {
+		if err := s.Em.FS.MkdirAll(ctx, resolvedDropInDir); err != nil {
+			return errors.Trace(err)
+		}
+		if err := writeNodeFile(ctx, &s.BaseStep, dropInPath, dropIn); err != nil {
+			return errors.Trace(err)
+		}
+		s.Logger.Infof("Updated m3fs managed resolver config %s", dropInPath)
+	}
+	if DetectResolver(ctx, s.Em.Runner) != ResolverResolved {
+		return nil
+	}
+	if _, err := s.Em.Runner.Exec(ctx, "systemctl", "restart", "systemd-resolved"); err != nil {
+		return errors.Annotate(err, "restart systemd-resolved")
+	}
+	return nil
+}
+
+// updateResolvConf replaces the block of the cluster in /etc/resolv.conf.
+func (s *updateDNSStep) updateResolvConf(ctx context.Context, block string) error {
+	content, err := readNodeFile(ctx, &s.BaseStep, resolvConfPath)
+	if err != nil {
+		return errors.Annotatef(err, "read %s", resolvConfPath)
+	}
+	newContent := replaceResolvConfBlock(content, s.Runtime.Cfg.Name, block)
+	if block != "" && len(s.Runtime.Cfg.DNS.SearchDomains) > 0 {
+		if lines := overridingSearchLines(newContent, s.Runtime.Cfg.Name); len(lines) > 0 {
+			s.Logger.Warnf("%q in %s of %s overrides search domains of the dns config",
+				lines[len(lines)-1], resolvConfPath, s.Node.Name)
+		}
+	}
+	if newContent == content {
+		s.Logger.Debugf("%s is up to date", resolvConfPath)
+		return nil
+	}
+
+	// the file is copied to keep the inode of /etc/resolv.conf, which may be bind mounted into containers
+	if err = writeNodeFile(ctx, &s.BaseStep, resolvConfPath, newContent); err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("Updated m3fs managed resolver config in %s", resolvConfPath)
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestUpdateDNSStep(t *testing.T) {
+	suiteRun(t, &updateDNSStepSuite{})
+}
+
+type updateDNSStepSuite struct {
+	ttask.StepSuite
+
+	step *updateDNSStep
+}
+
+const (
+	testResolvConfBlock = "# BEGIN m3fs managed resolver config of cluster test-cluster\n" +
+		"nameserver 10.0.0.53\n" +
+		"nameserver 10.0.0.54\n" +
+		"search cluster.example.com example.com\n" +
+		"# END m3fs managed resolver config of cluster test-cluster\n"
+	testResolvConfContent = "nameserver 8.8.8.8\noptions edns0\n"
+	testResolvedDropIn    = "# m3fs managed resolver config of cluster test-cluster\n" +
+		"[Resolve]\n" +
+		"DNS=10.0.0.53 10.0.0.54\n" +
+		"Domains=cluster.example.com example.com\n"
+	testDropInPath = "/etc/systemd/resolved.conf.d/m3fs-test-cluster.conf"
+)
+
+func (s *updateDNSStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &updateDNSStep{}
+	s.Cfg.Nodes = []config.Node{{Name: "node1", Host: "10.0.0.1"}}
+	s.Cfg.DNS = config.DNS{
+		Nameservers:   []string{"10.0.0.53", "10.0.0.54"},
+		SearchDomains: []string{"cluster.example.com", "example.com"},
+	}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+}
+
+func (s *updateDNSStepSuite) mockResolved(active bool) {
+	if active {
+		s.MockRunner.On("Exec", "systemctl", []string{"is-active", "systemd-resolved"}).Return("active\n", nil)
+	} else {
+		s.MockRunner.On("Exec", "systemctl", []string{"is-active", "systemd-resolved"}).
+			Return("inactive\n", errors.New("exit status 3"))
+	}
+}
+
+func (s *updateDNSStepSuite) mockWrite(path, content string) {
+	s.MockLocalFS.On("MkTempFile", os.TempDir()).Return("/tmp/local", nil)
+	s.MockLocalFS.On("WriteFile", "/tmp/local", []byte(content), os.FileMode(0644)).Return(nil)
+	s.MockLocalFS.On("RemoveAll", "/tmp/local").Return(nil)
+	s.MockFS.On("MkTempFile", os.TempDir()).Return("/tmp/remote", nil)
+	s.MockRunner.On("Scp", "/tmp/local", "/tmp/remote").Return(nil)
+	s.MockRunner.On("Exec", "cp", []string{"/tmp/remote", path}).Return("", nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", "/tmp/remote"}).Return("", nil)
+}
+
+func (s *updateDNSStepSuite) TestResolvConf() {
+	s.mockResolved(false)
+	s.MockRunner.On("Exec", "cat", []string{"/etc/resolv.conf"}).Return(testResolvConfContent, nil)
+	s.mockWrite("/etc/resolv.conf", testResolvConfBlock+testResolvConfContent)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+	s.MockLocalFS.AssertExpectations(s.T())
+}
+
+func (s *updateDNSStepSuite) TestResolvConfUpToDate() {
+	s.mockResolved(false)
+	s.MockRunner.On("Exec", "cat", []string{"/etc/resolv.conf"}).
+		Return(testResolvConfBlock+testResolvConfContent, nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *updateDNSStepSuite) TestResolved() {
+	s.mockResolved(true)
+	s.MockRunner.On("Exec", "test", []string{"-e", testDropInPath}).Return("", errors.New("exit status 1"))
+	s.MockFS.On("MkdirAll", "/etc/systemd/resolved.conf.d").Return(nil)
+	s.mockWrite(testDropInPath, testResolvedDropIn)
+	s.MockRunner.On("Exec", "systemctl", []string{"restart", "systemd-resolved"}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+	s.MockFS.AssertExpectations(s.T())
+	s.MockRunner.AssertNotCalled(s.T(), "Exec", "cat", []string{"/etc/resolv.conf"})
+}
+
+func (s *updateDNSStepSuite) TestResolvedUpToDate() {
+	s.mockResolved(true)
+	s.MockRunner.On("Exec", "test", []string{"-e", testDropInPath}).Return("", nil)
+	s.MockRunner.On("Exec", "cat", []string{testDropInPath}).Return(testResolvedDropIn, nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+	s.MockRunner.AssertNotCalled(s.T(), "Exec", "systemctl", []string{"restart", "systemd-resolved"})
+}
+
+func (s *updateDNSStepSuite) TestResolvedUpToDateWithCRLF() {
+	s.mockResolved(true)
+	s.MockRunner.On("Exec", "test", []string{"-e", testDropInPath}).Return("", nil)
+	s.MockRunner.On("Exec", "cat", []string{testDropInPath}).
+		Return(strings.ReplaceAll(testResolvedDropIn, "\n", "\r\n"), nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *updateDNSStepSuite) TestRemove() {
+	s.step.remove = true
+	s.mockResolved(true)
+	s.MockRunner.On("Exec", "test", []string{"-e", testDropInPath}).Return("", nil)
+	s.MockRunner.On("Exec", "cat", []string{testDropInPath}).Return(testResolvedDropIn, nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", testDropInPath}).Return("", nil)
+	s.MockRunner.On("Exec", "systemctl", []string{"restart", "systemd-resolved"}).Return("", nil)
+	s.MockRunner.On("Exec", "cat", []string{"/etc/resolv.conf"}).
+		Return(testResolvConfBlock+testResolvConfContent, nil)
+	s.mockWrite("/etc/resolv.conf", testResolvConfContent)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateDNSStepSuite) TestOverridingSearchLines() {
+	content := testResolvConfBlock + "search corp.example.com\nnameserver 8.8.8.8\ndomain local\n"
+	s.Equal([]string{"search corp.example.com", "domain local"}, overridingSearchLines(content, "test-cluster"))
+	s.Empty(overridingSearchLines(testResolvConfBlock+testResolvConfContent, "test-cluster"))
+}
@@ -0,0 +1,635 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// FirewallBackend is the firewall filtering incoming connections of a node.
+type FirewallBackend string
+
+// defines firewall backends, in the order of detection
+const (
+	FirewallNone      FirewallBackend = ""
+	FirewallFirewalld FirewallBackend = "firewalld"
+	FirewallUfw       FirewallBackend = "ufw"
+	FirewallNftables  FirewallBackend = "nftables"
+	FirewallIptables  FirewallBackend = "iptables"
+)
+
+// maxMultiPorts is the max number of ports of a rule of iptables multiport and ufw.
+const maxMultiPorts = 15
+
+// lookupIP resolves hosts of nodes which aren't IP addresses, it's replaced in tests.
+var lookupIP = net.DefaultResolver.LookupIP
+
+// ManageFirewallTask is a task for opening ports of services on nodes to all nodes of
+// the cluster in the firewall of nodes.
+type ManageFirewallTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *ManageFirewallTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("ManageFirewallTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.Nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return &updateFirewallStep{} },
+		},
+	})
+}
+
+// RemoveFirewallTask is a task for removing firewall rules added by ManageFirewallTask.
+type RemoveFirewallTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *RemoveFirewallTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("RemoveFirewallTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.Nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return &updateFirewallStep{remove: true} },
+		},
+	})
+}
+
+// FirewallPorts returns sorted TCP ports of services on the node which other nodes
+// connect to.
+func FirewallPorts(cfg *config.Config, nodeName string) []int {
+	var ports []int
+	for _, service := range config.AllServiceTypes {
+		if slices.Contains(cfg.Services.ServiceNodes(service), nodeName) {
+			ports = append(ports, task.ServicePorts(&cfg.Services, service)...)
+		}
+	}
+	if ck := &cfg.Services.Clickhouse; ck.Replicated() && slices.Contains(ck.TopologyNodes(), nodeName) {
+		ports = append(ports, ck.KeeperPort, ck.KeeperRaftPort, ck.InterserverPort)
+	}
+	slices.Sort(ports)
+	return slices.Compact(slices.DeleteFunc(ports, func(port int) bool { return port <= 0 }))
+}
+
+// DetectFirewall returns the active firewall of the node of the runner. nftables is
+// detected by the input chain of the inet filter table, which native rulesets use,
+// rules of iptables are used otherwise.
+func DetectFirewall(ctx context.Context, runner external.RunnerInterface) FirewallBackend {
+	if out, err := runner.Exec(ctx, "firewall-cmd", "--state"); err == nil && strings.TrimSpace(out) == "running" {
+		return FirewallFirewalld
+	}
+	if out, err := runner.Exec(ctx, "ufw", "status"); err == nil &&
+		strings.HasPrefix(strings.TrimSpace(out), "Status: active") {
+		return FirewallUfw
+	}
+	if _, err := runner.Exec(ctx, "nft", "list", "chain", "inet", "filter", "input"); err == nil {
+		return FirewallNftables
+	}
+	if _, err := runner.Exec(ctx, "iptables", "-S", "INPUT"); err == nil {
+		return FirewallIptables
+	}
+	return FirewallNone
+}
+
+// ClosedPorts returns ports which the firewall of the node of the runner doesn't accept
+// connections to. Rules are matched by ports only, sources and interfaces of rules
+// are ignored.
+func ClosedPorts(ctx context.Context, runner external.RunnerInterface, backend FirewallBackend,
+	ports []int) ([]int, error) {
+
+	fw := newFirewall(backend, runner, "")
+	if fw == nil {
+		return nil, nil
+	}
+	closed, err := fw.closedPorts(ctx, ports)
+	return closed, errors.Trace(err)
+}
+
+// firewall manages rules of a cluster in a firewall backend.
+type firewall interface {
+	// apply makes the firewall accept TCP connections from the peers to the ports, rules
+	// of the cluster not matching them are removed, all rules of the cluster are removed
+	// if peers are empty. It returns whether rules are changed.
+	apply(ctx context.Context, peers []string, ports []int) (bool, error)
+	closedPorts(ctx context.Context, ports []int) ([]int, error)
+}
+
+func newFirewall(backend FirewallBackend, runner external.RunnerInterface, cluster string) firewall {
+	tag := firewallTag(cluster)
+	switch backend {
+	case FirewallFirewalld:
+		return &firewalld{runner: runner, ipset: tag[:min(len(tag), 31)]}
+	case FirewallUfw:
+		return &ufw{runner: runner, tag: tag}
+	case FirewallNftables:
+		return &nftables{runner: runner, tag: tag}
+	case FirewallIptables:
+		return &iptables{runner: runner, tag: tag}
+	default:
+		return nil
+	}
+}
+
+// firewallTag returns the tag of rules of the cluster, it's used as the comment of rules
+// and the name of the ipset of firewalld.
+func firewallTag(cluster string) string {
+	return "m3fs-" + config.HostsName(cluster)
+}
+
+// peerIPs returns sorted IPv4 addresses of all nodes of the cluster, hosts which aren't
+// IP addresses are resolved.
+func peerIPs(ctx context.Context, nodes []config.Node) ([]string, error) {
+	var ips []net.IP
+	for _, node := range nodes {
+		if ip := net.ParseIP(node.Host); ip != nil {
+			if ip.To4() == nil {
+				return nil, errors.Errorf("IPv6 host %s of node %s isn't supported", node.Host, node.Name)
+			}
+			ips = append(ips, ip.To4())
+			continue
+		}
+		resolved, err := lookupIP(ctx, "ip4", node.Host)
+		if err != nil {
+			return nil, errors.Annotatef(err, "resolve host %s of node %s", node.Host, node.Name)
+		}
+		for _, ip := range resolved {
+			ips = append(ips, ip.To4())
+		}
+	}
+	slices.SortFunc(ips, func(a, b net.IP) int { return bytes.Compare(a, b) })
+	ips = slices.CompactFunc(ips, net.IP.Equal)
+	peers := make([]string, len(ips))
+	for i, ip := range ips {
+		peers[i] = ip.String()
+	}
+	return peers, nil
+}
+
+// chunkPorts splits ports into comma separated lists of at most maxMultiPorts ports.
+func chunkPorts(ports []int) []string {
+	var lists []string
+	for chunk := range slices.Chunk(ports, maxMultiPorts) {
+		strs := make([]string, len(chunk))
+		for i, port := range chunk {
+			strs[i] = strconv.Itoa(port)
+		}
+		lists = append(lists, strings.Join(strs, ","))
+	}
+	return lists
+}
+
+// portListContains returns whether the list of ports contains the port, elements of the
+// list are separated by sep and ranges of ports are delimited by rangeSep.
+func portListContains(list, sep, rangeSep string, port int) bool {
+	for _, elem := range strings.Split(list, sep) {
+		elem = strings.TrimSpace(elem)
+		low, high, isRange := strings.Cut(elem, rangeSep)
+		if !isRange {
+			high = low
+		}
+		lowPort, err1 := strconv.Atoi(strings.TrimSpace(low))
+		highPort, err2 := strconv.Atoi(strings.TrimSpace(high))
+		if err1 == nil && err2 == nil && port >= lowPort && port <= highPort {
+			return true
+		}
+	}
+	return false
+}
+
+// syncRules adds desired rules which don't exist before removing existing rules which
+// aren't desired, so that connections aren't refused while rules are replaced.
+func syncRules(existing, desired []string, add, remove func(string) error) (bool, error) {
+	changed := false
+	for _, rule := range desired {
+		if slices.Contains(existing, rule) {
+			continue
+		}
+		if err := add(rule); err != nil {
+			return changed, errors.Trace(err)
+		}
+		changed = true
+	}
+	for _, rule := range existing {
+		if slices.Contains(desired, rule) {
+			continue
+		}
+		if err := remove(rule); err != nil {
+			return changed, errors.Trace(err)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// firewalld accepts peers in an ipset by a rich rule for each port.
+type firewalld struct {
+	runner external.RunnerInterface
+	ipset  string
+}
+
+func (f *firewalld) cmd(ctx context.Context, args ...string) (string, error) {
+	out, err := f.runner.Exec(ctx, "firewall-cmd", append([]string{"--permanent"}, args...)...)
+	if err != nil {
+		return out, errors.Annotatef(err, "firewall-cmd %s", strings.Join(args, " "))
+	}
+	return out, nil
+}
+
+func (f *firewalld) apply(ctx context.Context, peers []string, ports []int) (bool, error) {
+	out, err := f.cmd(ctx, "--get-ipsets")
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	ipsetExists := slices.Contains(strings.Fields(out), f.ipset)
+	out, err = f.cmd(ctx, "--list-rich-rules")
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	var rules []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); strings.Contains(line, fmt.Sprintf(`ipset="%s"`, f.ipset)) {
+			rules = append(rules, line)
+		}
+	}
+
+	changed := false
+	var desired []string
+	if len(peers) > 0 {
+		var entries []string
+		if ipsetExists {
+			if out, err = f.cmd(ctx, "--ipset="+f.ipset, "--get-entries"); err != nil {
+				return false, errors.Trace(err)
+			}
+			entries = strings.Fields(out)
+		} else {
+			if _, err = f.cmd(ctx, "--new-ipset="+f.ipset, "--type=hash:ip"); err != nil {
+				return false, errors.Trace(err)
+			}
+			changed = true
+		}
+		entriesChanged, err := syncRules(entries, peers,
+			func(ip string) error {
+				_, err := f.cmd(ctx, "--ipset="+f.ipset, "--add-entry="+ip)
+				return err
+			},
+			func(ip string) error {
+				_, err := f.cmd(ctx, "--ipset="+f.ipset, "--remove-entry="+ip)
+				return err
+			})
+		changed = changed || entriesChanged
+		if err != nil {
+			return changed, errors.Trace(err)
+		}
+		for _, port := range ports {
+			desired = append(desired, fmt.Sprintf(
+				`rule family="ipv4" source ipset="%s" port port="%d" protocol="tcp" accept`, f.ipset, port))
+		}
+	}
+	rulesChanged, err := syncRules(rules, desired,
+		func(rule string) error {
+			_, err := f.cmd(ctx, "--add-rich-rule="+external.ShellQuote(rule))
+			return err
+		},
+		func(rule string) error {
+			_, err := f.cmd(ctx, "--remove-rich-rule="+external.ShellQuote(rule))
+			return err
+		})
+	changed = changed || rulesChanged
+	if err != nil {
+		return changed, errors.Trace(err)
+	}
+	if len(peers) == 0 && ipsetExists {
+		if _, err = f.cmd(ctx, "--delete-ipset="+f.ipset); err != nil {
+			return changed, errors.Trace(err)
+		}
+		changed = true
+	}
+	if changed {
+		// permanent rules take effect after reloading
+		if _, err = f.runner.Exec(ctx, "firewall-cmd", "--reload"); err != nil {
+			return changed, errors.Annotate(err, "reload firewalld")
+		}
+	}
+	return changed, nil
+}
+
+func (f *firewalld) closedPorts(ctx context.Context, ports []int) ([]int, error) {
+	rules, err := f.runner.Exec(ctx, "firewall-cmd", "--list-rich-rules")
+	if err != nil {
+		return nil, errors.Annotate(err, "list rich rules of firewalld")
+	}
+	var closed []int
+	for _, port := range ports {
+		// --query-port fails if the port isn't open
+		if _, err = f.runner.Exec(ctx, "firewall-cmd", fmt.Sprintf("--query-port=%d/tcp", port)); err == nil {
+			continue
+		}
+		if strings.Contains(rules, fmt.Sprintf(`port port="%d"`, port)) {
+			continue
+		}
+		closed = append(closed, port)
+	}
+	return closed, nil
+}
+
+// ufw accepts each peer by a rule of lists of ports.
+type ufw struct {
+	runner external.RunnerInterface
+	tag    string
+}
+
+func (f *ufw) apply(ctx context.Context, peers []string, ports []int) (bool, error) {
+	out, err := f.runner.Exec(ctx, "ufw", "status")
+	if err != nil {
+		return false, errors.Annotate(err, "get status of ufw")
+	}
+	// rules of the cluster are listed as "8000,9000/tcp  ALLOW  10.0.0.1  # m3fs-cluster"
+	var existing []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 5 && fields[1] == "ALLOW" && fields[3] == "#" && fields[4] == f.tag {
+			existing = append(existing, fmt.Sprintf("proto tcp from %s to any port %s",
+				fields[2], strings.TrimSuffix(fields[0], "/tcp")))
+		}
+	}
+	var desired []string
+	for _, peer := range peers {
+		for _, list := range chunkPorts(ports) {
+			desired = append(desired, fmt.Sprintf("proto tcp from %s to any port %s", peer, list))
+		}
+	}
+	changed, err := syncRules(existing, desired,
+		func(rule string) error {
+			args := append(append([]string{"allow"}, strings.Fields(rule)...), "comment", f.tag)
+			_, err := f.runner.Exec(ctx, "ufw", args...)
+			return errors.Annotatef(err, "ufw allow %s", rule)
+		},
+		func(rule string) error {
+			args := append([]string{"--force", "delete", "allow"}, strings.Fields(rule)...)
+			_, err := f.runner.Exec(ctx, "ufw", args...)
+			return errors.Annotatef(err, "ufw delete allow %s", rule)
+		})
+	return changed, errors.Trace(err)
+}
+
+func (f *ufw) closedPorts(ctx context.Context, ports []int) ([]int, error) {
+	out, err := f.runner.Exec(ctx, "ufw", "status", "verbose")
+	if err != nil {
+		return nil, errors.Annotate(err, "get status of ufw")
+	}
+	if strings.Contains(out, "allow (incoming)") {
+		return nil, nil
+	}
+	var closed []int
+	for _, port := range ports {
+		open := false
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 3 || fields[1] != "ALLOW" {
+				continue
+			}
+			to := strings.TrimSuffix(fields[0], "/tcp")
+			if portListContains(to, ",", ":", port) {
+				open = true
+				break
+			}
+		}
+		if !open {
+			closed = append(closed, port)
+		}
+	}
+	return closed, nil
+}
+
+var (
+	nftHandleRegexp = regexp.MustCompile(`^(.*\S)\s+# handle (\d+)$`)
+	nftDportRegexp  = regexp.MustCompile(`dport (\{[^}]*\}|\S+)`)
+)
+
+// nftables accepts all peers by a rule inserted into the input chain of the inet filter
+// table, another table can't accept connections dropped by the chain.
+type nftables struct {
+	runner external.RunnerInterface
+	tag    string
+}
+
+// nftSet formats elements as nft lists them, a single element isn't enclosed in braces.
+func nftSet(elems []string) string {
+	if len(elems) == 1 {
+		return elems[0]
+	}
+	return "{ " + strings.Join(elems, ", ") + " }"
+}
+
+func (f *nftables) apply(ctx context.Context, peers []string, ports []int) (bool, error) {
+	out, err := f.runner.Exec(ctx, "nft", "-a", "list", "chain", "inet", "filter", "input")
+	if err != nil {
+		return false, errors.Annotate(err, "list input chain of nftables")
+	}
+	comment := fmt.Sprintf(`comment "%s"`, f.tag)
+	handles := make(map[string]string)
+	var existing []string
+	for _, line := range strings.Split(out, "\n") {
+		m := nftHandleRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if m != nil && strings.HasSuffix(m[1], comment) {
+			handles[m[1]] = m[2]
+			existing = append(existing, m[1])
+		}
+	}
+	var desired []string
+	if len(peers) > 0 && len(ports) > 0 {
+		strs := make([]string, len(ports))
+		for i, port := range ports {
+			strs[i] = strconv.Itoa(port)
+		}
+		desired = append(desired, fmt.Sprintf("ip saddr %s tcp dport %s accept %s",
+			nftSet(peers), nftSet(strs), comment))
+	}
+	changed, err := syncRules(existing, desired,
+		func(rule string) error {
+			_, err := f.runner.Exec(ctx, "nft", "insert", "rule", "inet", "filter", "input",
+				external.ShellQuote(rule))
+			return errors.Annotate(err, "insert rule into nftables")
+		},
+		func(rule string) error {
+			_, err := f.runner.Exec(ctx, "nft", "delete", "rule", "inet", "filter", "input",
+				"handle", handles[rule])
+			return errors.Annotate(err, "delete rule from nftables")
+		})
+	return changed, errors.Trace(err)
+}
+
+func (f *nftables) closedPorts(ctx context.Context, ports []int) ([]int, error) {
+	out, err := f.runner.Exec(ctx, "nft", "list", "chain", "inet", "filter", "input")
+	if err != nil {
+		return nil, errors.Annotate(err, "list input chain of nftables")
+	}
+	var accepts []string
+	blocking := strings.Contains(out, "policy drop")
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[0] == "counter" {
+			fields = fields[1:]
+		}
+		switch {
+		case len(fields) == 0:
+		// rules dropping matched connections, e.g. of invalid states, don't block others
+		case fields[0] == "drop" || fields[0] == "reject":
+			blocking = true
+		case slices.Contains(fields, "accept"):
+			if m := nftDportRegexp.FindStringSubmatch(line); m != nil {
+				accepts = append(accepts, strings.Trim(m[1], "{ }"))
+			}
+		}
+	}
+	return closedByAccepts(blocking, accepts, ",", "-", ports), nil
+}
+
+var iptablesDportRegexp = regexp.MustCompile(`--dports? (\S+)`)
+
+// iptables accepts each peer by a rule of lists of ports inserted into the INPUT chain.
+type iptables struct {
+	runner external.RunnerInterface
+	tag    string
+}
+
+func (f *iptables) apply(ctx context.Context, peers []string, ports []int) (bool, error) {
+	out, err := f.runner.Exec(ctx, "iptables", "-S", "INPUT")
+	if err != nil {
+		return false, errors.Annotate(err, "list INPUT chain of iptables")
+	}
+	var existing []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 2 && fields[0] == "-A" && strings.Contains(line, "--comment "+f.tag+" ") {
+			existing = append(existing, strings.Join(fields[2:], " "))
+		}
+	}
+	var desired []string
+	for _, peer := range peers {
+		for _, list := range chunkPorts(ports) {
+			desired = append(desired, fmt.Sprintf(
+				"-s %s/32 -p tcp -m multiport --dports %s -m comment --comment %s -j ACCEPT", peer, list, f.tag))
+		}
+	}
+	changed, err := syncRules(existing, desired,
+		func(rule string) error {
+			_, err := f.runner.Exec(ctx, "iptables", append([]string{"-I", "INPUT"}, strings.Fields(rule)...)...)
+			return errors.Annotate(err, "insert rule into iptables")
+		},
+		func(rule string) error {
+			_, err := f.runner.Exec(ctx, "iptables", append([]string{"-D", "INPUT"}, strings.Fields(rule)...)...)
+			return errors.Annotate(err, "delete rule from iptables")
+		})
+	return changed, errors.Trace(err)
+}
+
+func (f *iptables) closedPorts(ctx context.Context, ports []int) ([]int, error) {
+	out, err := f.runner.Exec(ctx, "iptables", "-S", "INPUT")
+	if err != nil {
+		return nil, errors.Annotate(err, "list INPUT chain of iptables")
+	}
+	var accepts []string
+	blocking := false
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "-P INPUT "):
+			blocking = blocking || !strings.Contains(line, "ACCEPT")
+		// rules dropping matched connections, e.g. of invalid states, don't block others
+		case strings.HasPrefix(line, "-A INPUT -j DROP") || strings.HasPrefix(line, "-A INPUT -j REJECT"):
+			blocking = true
+		case strings.Contains(line, "-j ACCEPT"):
+			if m := iptablesDportRegexp.FindStringSubmatch(line); m != nil {
+				accepts = append(accepts, m[1])
+			}
+		}
+	}
+	return closedByAccepts(blocking, accepts, ",", ":", ports), nil
+}
+
+// closedByAccepts returns ports not in port lists of accepting rules if the firewall
+// blocks connections by default.
+func closedByAccepts(blocking bool, accepts []string, sep, rangeSep string, ports []int) []int {
+	if !blocking {
+		return nil
+	}
+	var closed []int
+	for _, port := range ports {
+		if !slices.ContainsFunc(accepts, func(list string) bool {
+			return portListContains(list, sep, rangeSep, port)
+		}) {
+			closed = append(closed, port)
+		}
+	}
+	return closed
+}
+
+type updateFirewallStep struct {
+	task.BaseStep
+
+	remove bool
+}
+
+func (s *updateFirewallStep) Execute(ctx context.Context) error {
+	backend := DetectFirewall(ctx, s.Em.Runner)
+	if backend == FirewallNone {
+		s.Logger.Infof("No active firewall on %s", s.Node.Name)
+		return nil
+	}
+	var (
+		peers []string
+		ports []int
+		err   error
+	)
+	if !s.remove {
+		if ports = FirewallPorts(s.Runtime.Cfg, s.Node.Name); len(ports) > 0 {
+			if peers, err = peerIPs(ctx, s.Runtime.Cfg.Nodes); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	changed, err := newFirewall(backend, s.Em.Runner, s.Runtime.Cfg.Name).apply(ctx, peers, ports)
+	if err != nil {
+		return errors.Annotatef(err, "update rules of %s", backend)
+	}
+	switch {
+	case !changed:
+		s.Logger.Debugf("Rules of %s are up to date", backend)
+	case len(peers) == 0:
+		s.Logger.Infof("Removed m3fs managed rules of %s", backend)
+	default:
+		s.Logger.Infof("Opened ports %v to cluster nodes in %s", ports, backend)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestUpdateFirewallStep(t *testing.T) {
+	suiteRun(t, &updateFirewallStepSuite{})
+}
+
+type updateFirewallStepSuite struct {
+	ttask.StepSuite
+
+	step *updateFirewallStep
+}
+
+const (
+	testIptablesRule = "-s 10.0.0.1/32 -p tcp -m multiport --dports 8000,9000 " +
+		"-m comment --comment m3fs-test-cluster -j ACCEPT"
+	testRichRule = `rule family="ipv4" source ipset="m3fs-test-cluster" port port="8000" protocol="tcp" accept`
+)
+
+func (s *updateFirewallStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &updateFirewallStep{}
+	s.Cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.1"},
+		{Name: "node2", Host: "node2.example.com"},
+	}
+	s.Cfg.Services = config.Services{}
+	s.Cfg.Services.Mgmtd.Nodes = []string{"node1"}
+	s.Cfg.Services.Mgmtd.RDMAListenPort = 8000
+	s.Cfg.Services.Mgmtd.TCPListenPort = 9000
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+
+	lookupIP = func(_ context.Context, network, host string) ([]net.IP, error) {
+		s.Equal("ip4", network)
+		s.Equal("node2.example.com", host)
+		return []net.IP{net.ParseIP("10.0.0.2")}, nil
+	}
+	s.T().Cleanup(func() { lookupIP = net.DefaultResolver.LookupIP })
+}
+
+// mockDetect makes the backend detected, backends detected before it are inactive.
+func (s *updateFirewallStepSuite) mockDetect(backend FirewallBackend) {
+	notFound := errors.New("command not found")
+	detects := []struct {
+		backend FirewallBackend
+		cmd     string
+		args    []string
+		out     string
+	}{
+		{FirewallFirewalld, "firewall-cmd", []string{"--state"}, "running\n"},
+		{FirewallUfw, "ufw", []string{"status"}, "Status: active\n"},
+		{FirewallNftables, "nft", []string{"list", "chain", "inet", "filter", "input"}, ""},
+		{FirewallIptables, "iptables", []string{"-S", "INPUT"}, ""},
+	}
+	for _, detect := range detects {
+		if detect.backend == backend {
+			s.MockRunner.On("Exec", detect.cmd, detect.args).Return(detect.out, nil).Once()
+			return
+		}
+		s.MockRunner.On("Exec", detect.cmd, detect.args).Return("", notFound).Once()
+	}
+}
+
+func (s *updateFirewallStepSuite) TestFirewallPorts() {
+	s.Equal([]int{8000, 9000}, FirewallPorts(s.Cfg, "node1"))
+	s.Empty(FirewallPorts(s.Cfg, "node2"))
+
+	s.Cfg.Services.Clickhouse = config.Clickhouse{
+		Nodes: []string{"node1", "node2"}, Replicas: 2, TCPPort: 8999,
+		KeeperPort: 9181, KeeperRaftPort: 9234, InterserverPort: 9009,
+	}
+	s.Equal([]int{8999, 9009, 9181, 9234}, FirewallPorts(s.Cfg, "node2"))
+}
+
+func (s *updateFirewallStepSuite) TestNoFirewall() {
+	s.mockDetect(FirewallNone)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateFirewallStepSuite) TestIptables() {
+	s.mockDetect(FirewallIptables)
+	stale := "-s 10.0.0.3/32 -p tcp -m multiport --dports 8000,9000 " +
+		"-m comment --comment m3fs-test-cluster -j ACCEPT"
+	s.MockRunner.On("Exec", "iptables", []string{"-S", "INPUT"}).Return("-P INPUT DROP\n"+
+		"-A INPUT -s 10.0.0.9/32 -j ACCEPT\n"+
+		"-A INPUT "+testIptablesRule+"\n"+
+		"-A INPUT "+stale+"\n", nil)
+	added := "-s 10.0.0.2/32 -p tcp -m multiport --dports 8000,9000 " +
+		"-m comment --comment m3fs-test-cluster -j ACCEPT"
+	insert := s.MockRunner.On("Exec", "iptables", append([]string{"-I", "INPUT"}, strings.Fields(added)...)).
+		Return("", nil)
+	s.MockRunner.On("Exec", "iptables", append([]string{"-D", "INPUT"}, strings.Fields(stale)...)).
+		Return("", nil).NotBefore(insert)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateFirewallStepSuite) TestUfwUpToDate() {
+	s.mockDetect(FirewallUfw)
+	s.MockRunner.On("Exec", "ufw", []string{"status"}).Return("Status: active\n\n"+
+		"To                         Action      From\n"+
+		"--                         ------      ----\n"+
+		"22/tcp                     ALLOW       Anywhere\n"+
+		"8000,9000/tcp              ALLOW       10.0.0.1                   # m3fs-test-cluster\n"+
+		"8000,9000/tcp              ALLOW       10.0.0.2                   # m3fs-test-cluster\n", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateFirewallStepSuite) TestUfw() {
+	s.mockDetect(FirewallUfw)
+	s.MockRunner.On("Exec", "ufw", []string{"status"}).Return("Status: active\n", nil)
+	for _, peer := range []string{"10.0.0.1", "10.0.0.2"} {
+		s.MockRunner.On("Exec", "ufw", []string{"allow", "proto", "tcp", "from", peer, "to", "any", "port",
+			"8000,9000", "comment", "m3fs-test-cluster"}).Return("Rule added\n", nil)
+	}
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateFirewallStepSuite) TestFirewalld() {
+	s.mockDetect(FirewallFirewalld)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent", "--get-ipsets"}).Return("\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent", "--list-rich-rules"}).Return("\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent", "--new-ipset=m3fs-test-cluster",
+		"--type=hash:ip"}).Return("success\n", nil)
+	for _, peer := range []string{"10.0.0.1", "10.0.0.2"} {
+		s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent", "--ipset=m3fs-test-cluster",
+			"--add-entry=" + peer}).Return("success\n", nil)
+	}
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent",
+		"--add-rich-rule='" + testRichRule + "'"}).Return("success\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent",
+		`--add-rich-rule='rule family="ipv4" source ipset="m3fs-test-cluster" port port="9000" ` +
+			`protocol="tcp" accept'`}).Return("success\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--reload"}).Return("success\n", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateFirewallStepSuite) TestRemoveFirewalld() {
+	s.step.remove = true
+	s.mockDetect(FirewallFirewalld)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent", "--get-ipsets"}).
+		Return("m3fs-test-cluster other\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent", "--list-rich-rules"}).
+		Return(testRichRule+"\n"+`rule family="ipv4" source address="10.0.0.9" accept`+"\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent",
+		"--remove-rich-rule='" + testRichRule + "'"}).Return("success\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--permanent", "--delete-ipset=m3fs-test-cluster"}).
+		Return("success\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--reload"}).Return("success\n", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateFirewallStepSuite) TestNftables() {
+	s.mockDetect(FirewallNftables)
+	s.MockRunner.On("Exec", "nft", []string{"-a", "list", "chain", "inet", "filter", "input"}).Return(
+		"table inet filter {\n"+
+			"\tchain input { # handle 1\n"+
+			"\t\ttype filter hook input priority filter; policy drop;\n"+
+			"\t\tct state established,related accept # handle 4\n"+
+			"\t\tip saddr 10.0.0.1 tcp dport { 8000, 9000 } accept comment \"m3fs-test-cluster\" # handle 7\n"+
+			"\t}\n}\n", nil)
+	insert := s.MockRunner.On("Exec", "nft", []string{"insert", "rule", "inet", "filter", "input",
+		`'ip saddr { 10.0.0.1, 10.0.0.2 } tcp dport { 8000, 9000 } accept comment "m3fs-test-cluster"'`}).
+		Return("", nil)
+	s.MockRunner.On("Exec", "nft", []string{"delete", "rule", "inet", "filter", "input", "handle", "7"}).
+		Return("", nil).NotBefore(insert)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateFirewallStepSuite) TestClosedPorts() {
+	s.MockRunner.On("Exec", "iptables", []string{"-S", "INPUT"}).Return("-P INPUT ACCEPT\n"+
+		"-A INPUT -m state --state INVALID -j DROP\n", nil).Once()
+	closed, err := ClosedPorts(s.Ctx(), s.MockRunner, FirewallIptables, []int{8000, 9000})
+	s.NoError(err)
+	s.Empty(closed)
+
+	s.MockRunner.On("Exec", "iptables", []string{"-S", "INPUT"}).Return("-P INPUT ACCEPT\n"+
+		"-A INPUT -p tcp -m tcp --dport 8000 -j ACCEPT\n"+
+		"-A INPUT -j REJECT --reject-with icmp-host-prohibited\n", nil).Once()
+	closed, err = ClosedPorts(s.Ctx(), s.MockRunner, FirewallIptables, []int{8000, 9000})
+	s.NoError(err)
+	s.Equal([]int{9000}, closed)
+
+	s.MockRunner.On("Exec", "nft", []string{"list", "chain", "inet", "filter", "input"}).Return(
+		"table inet filter {\n\tchain input {\n\t\ttype filter hook input priority filter; policy drop;\n"+
+			"\t\ttcp dport 8990-9100 accept\n\t}\n}\n", nil)
+	closed, err = ClosedPorts(s.Ctx(), s.MockRunner, FirewallNftables, []int{8000, 9000})
+	s.NoError(err)
+	s.Equal([]int{8000}, closed)
+
+	s.MockRunner.On("Exec", "ufw", []string{"status", "verbose"}).Return("Status: active\n"+
+		"Default: deny (incoming), allow (outgoing), disabled (routed)\n\n"+
+		"To                         Action      From\n"+
+		"--                         ------      ----\n"+
+		"8000:8005/tcp              ALLOW IN    Anywhere\n", nil)
+	closed, err = ClosedPorts(s.Ctx(), s.MockRunner, FirewallUfw, []int{8000, 9000})
+	s.NoError(err)
+	s.Equal([]int{9000}, closed)
+
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--list-rich-rules"}).Return(testRichRule+"\n", nil)
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--query-port=8000/tcp"}).Return("", errors.New("no"))
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--query-port=9000/tcp"}).Return("", errors.New("no"))
+	closed, err = ClosedPorts(s.Ctx(), s.MockRunner, FirewallFirewalld, []int{8000, 9000})
+	s.NoError(err)
+	s.Equal([]int{9000}, closed)
+}
+
+func (s *updateFirewallStepSuite) TestResolveFailed() {
+	lookupIP = func(context.Context, string, string) ([]net.IP, error) {
+		return nil, errors.New("no such host")
+	}
+	s.mockDetect(FirewallIptables)
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "resolve host node2.example.com of node node2")
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+const hostsFilePath = "/etc/hosts"
+
+// ManageHostsTask is a task for writing entries of all nodes into /etc/hosts of nodes.
+type ManageHostsTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *ManageHostsTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("ManageHostsTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.Nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return &updateHostsStep{} },
+		},
+	})
+}
+
+// RemoveHostsTask is a task for removing entries of nodes written by ManageHostsTask.
+type RemoveHostsTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *RemoveHostsTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("RemoveHostsTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.Nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return &updateHostsStep{remove: true} },
+		},
+	})
+}
+
+// hostsBlockMarkers returns lines delimiting the block of the cluster in /etc/hosts.
+func hostsBlockMarkers(cluster string) (string, string) {
+	return fmt.Sprintf("# BEGIN m3fs managed hosts of cluster %s", cluster),
+		fmt.Sprintf("# END m3fs managed hosts of cluster %s", cluster)
+}
+
+// hostsBlock returns the block of entries of all nodes of the cluster.
+func hostsBlock(cfg *config.Config) string {
+	begin, end := hostsBlockMarkers(cfg.Name)
+	lines := []string{begin}
+	for _, node := range cfg.Nodes {
+		lines = append(lines, fmt.Sprintf("%s %s", node.Host, config.HostsName(node.Name)))
+	}
+	lines = append(lines, end)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// replaceHostsBlock replaces the block of the cluster in the content of /etc/hosts
+// with the block, other lines are preserved. The block is appended if it doesn't
+// exist, and removed if the block is empty.
+func replaceHostsBlock(content, cluster, block string) string {
+	begin, end := hostsBlockMarkers(cluster)
+	return replaceManagedBlock(content, begin, end, block, false)
+}
+
+// replaceManagedBlock replaces the block delimited by the begin and end lines in the
+// content with the block, other lines are preserved. The block is added if it doesn't
+// exist, before other lines if prepend is true, and removed if the block is empty.
+func replaceManagedBlock(content, begin, end, block string, prepend bool) string {
+	var (
+		out      strings.Builder
+		inBlock  bool
+		replaced bool
+	)
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		switch strings.TrimSpace(line) {
+		case begin:
+			inBlock = true
+			continue
+		case end:
+			if inBlock {
+				inBlock = false
+				if !replaced {
+					out.WriteString(block)
+					replaced = true
+				}
+				continue
+			}
+		}
+		if !inBlock {
+			out.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				out.WriteString("\n")
+			}
+		}
+	}
+	if !replaced {
+		if prepend {
+			return block + out.String()
+		}
+		out.WriteString(block)
+	}
+	return out.String()
+}
+
+type updateHostsStep struct {
+	task.BaseStep
+
+	remove bool
+}
+
+func (s *updateHostsStep) Execute(ctx context.Context) error {
+	content, err := readNodeFile(ctx, &s.BaseStep, hostsFilePath)
+	if err != nil {
+		return errors.Annotatef(err, "read %s", hostsFilePath)
+	}
+	block := ""
+	if !s.remove {
+		block = hostsBlock(s.Runtime.Cfg)
+	}
+	newContent := replaceHostsBlock(content, s.Runtime.Cfg.Name, block)
+	if newContent == content {
+		s.Logger.Debugf("%s is up to date", hostsFilePath)
+		return nil
+	}
+
+	// the file is copied to keep the inode of /etc/hosts, which may be bind mounted into containers
+	if err = writeNodeFile(ctx, &s.BaseStep, hostsFilePath, newContent); err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("Updated m3fs managed entries in %s", hostsFilePath)
+	return nil
+}
+
+// readNodeFile reads the file on the node of the step. Outputs of commands of remote
+// nodes come through a pty, which turns line endings into "\r\n", so they're turned
+// back, otherwise the content never equals the one to write.
+func readNodeFile(ctx context.Context, s *task.BaseStep, path string) (string, error) {
+	content, err := s.Em.Runner.Exec(ctx, "cat", path)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.ReplaceAll(content, "\r\n", "\n"), nil
+}
+
+// writeNodeFile writes the content into the file on the node of the step. The content
+// is uploaded to a temp file and copied over the file, so that the inode of the file
+// is kept.
+func writeNodeFile(ctx context.Context, s *task.BaseStep, path, content string) error {
+	localFile, err := s.Runtime.LocalEm.FS.MkTempFile(ctx, s.Runtime.LocalTempDir())
+	if err != nil {
+		return errors.Annotate(err, "make local temp file")
+	}
+	defer func() {
+		if err := s.Runtime.LocalEm.FS.RemoveAll(ctx, localFile); err != nil {
+			s.Logger.Warnf("Failed to remove local file %s: %v", localFile, err)
+		}
+	}()
+	if err = s.Runtime.LocalEm.FS.WriteFile(localFile, []byte(content), 0644); err != nil {
+		return errors.Trace(err)
+	}
+	remoteFile, err := s.Em.FS.MkTempFile(ctx, os.TempDir())
+	if err != nil {
+		return errors.Annotate(err, "make temp file")
+	}
+	defer func() {
+		if _, err := s.Em.Runner.Exec(ctx, "rm", "-f", remoteFile); err != nil {
+			s.Logger.Errorf("Failed to remove remote file %s: %v", remoteFile, err)
+		}
+	}()
+	if err = s.Em.Runner.Scp(ctx, localFile, remoteFile); err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = s.Em.Runner.Exec(ctx, "cp", remoteFile, path); err != nil {
+		return errors.Annotatef(err, "write %s", path)
+	}
+	return nil
+}
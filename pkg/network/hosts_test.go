@@ -0,0 +1,126 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestUpdateHostsStep(t *testing.T) {
+	suiteRun(t, &updateHostsStepSuite{})
+}
+
+type updateHostsStepSuite struct {
+	ttask.StepSuite
+
+	step *updateHostsStep
+}
+
+const (
+	testHostsBlock = "# BEGIN m3fs managed hosts of cluster test-cluster\n" +
+		"10.0.0.1 node1\n" +
+		"10.0.0.2 meta-node-10-0-0-2\n" +
+		"# END m3fs managed hosts of cluster test-cluster\n"
+	testHostsContent = "127.0.0.1 localhost\n10.0.0.9 manual\n"
+)
+
+func (s *updateHostsStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &updateHostsStep{}
+	s.Cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "10.0.0.1"},
+		{Name: "meta-node(10.0.0.2)", Host: "10.0.0.2"},
+	}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+}
+
+func (s *updateHostsStepSuite) mockWrite(content string) {
+	s.MockLocalFS.On("MkTempFile", os.TempDir()).Return("/tmp/local", nil)
+	s.MockLocalFS.On("WriteFile", "/tmp/local", []byte(content), os.FileMode(0644)).Return(nil)
+	s.MockLocalFS.On("RemoveAll", "/tmp/local").Return(nil)
+	s.MockFS.On("MkTempFile", os.TempDir()).Return("/tmp/remote", nil)
+	s.MockRunner.On("Scp", "/tmp/local", "/tmp/remote").Return(nil)
+	s.MockRunner.On("Exec", "cp", []string{"/tmp/remote", "/etc/hosts"}).Return("", nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", "/tmp/remote"}).Return("", nil)
+}
+
+func (s *updateHostsStepSuite) TestAppend() {
+	s.MockRunner.On("Exec", "cat", []string{"/etc/hosts"}).Return(testHostsContent, nil)
+	s.mockWrite(testHostsContent + testHostsBlock)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+	s.MockLocalFS.AssertExpectations(s.T())
+}
+
+func (s *updateHostsStepSuite) TestReplace() {
+	oldBlock := "# BEGIN m3fs managed hosts of cluster test-cluster\n" +
+		"10.0.0.3 node1\n" +
+		"# END m3fs managed hosts of cluster test-cluster\n"
+	s.MockRunner.On("Exec", "cat", []string{"/etc/hosts"}).
+		Return("127.0.0.1 localhost\n"+oldBlock+"10.0.0.9 manual\n", nil)
+	s.mockWrite("127.0.0.1 localhost\n" + testHostsBlock + "10.0.0.9 manual\n")
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *updateHostsStepSuite) TestUpToDate() {
+	s.MockRunner.On("Exec", "cat", []string{"/etc/hosts"}).Return(testHostsContent+testHostsBlock, nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *updateHostsStepSuite) TestUpToDateWithCRLF() {
+	// outputs of remote commands come through a pty with CRLF line endings
+	content := strings.ReplaceAll(testHostsContent+testHostsBlock, "\n", "\r\n")
+	s.MockRunner.On("Exec", "cat", []string{"/etc/hosts"}).Return(content, nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *updateHostsStepSuite) TestReplaceWithCRLF() {
+	content := strings.ReplaceAll(testHostsContent, "\n", "\r\n")
+	s.MockRunner.On("Exec", "cat", []string{"/etc/hosts"}).Return(content, nil)
+	s.mockWrite(testHostsContent + testHostsBlock)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+	s.MockLocalFS.AssertExpectations(s.T())
+}
+
+func (s *updateHostsStepSuite) TestRemove() {
+	s.step.remove = true
+	s.MockRunner.On("Exec", "cat", []string{"/etc/hosts"}).Return(testHostsBlock+testHostsContent, nil)
+	s.mockWrite(testHostsContent)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
@@ -16,7 +16,6 @@ package network
 
 import (
 	"context"
-	"os"
 	"path"
 	"strings"
 
@@ -107,7 +106,7 @@ type genIbdev2netdevScriptStep struct {
 func (s *genIbdev2netdevScriptStep) Execute(ctx context.Context) error {
 	s.Logger.Debugf("Generating ibdev2netdev script for %s", s.Node.Host)
 	localEm := s.Runtime.LocalEm
-	tmpDir, err := localEm.FS.MkdirTemp(ctx, os.TempDir(), "m3fs-prepare-network")
+	tmpDir, err := localEm.FS.MkdirTemp(ctx, s.Runtime.LocalTempDir(), "m3fs-prepare-network")
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -192,7 +191,7 @@ type createRdmaRxeLinkStep struct {
 func (s *createRdmaRxeLinkStep) Execute(ctx context.Context) error {
 	s.Logger.Debugf("Creating rdma link for %s", s.Node.Host)
 	localEm := s.Runtime.LocalEm
-	tmpDir, err := localEm.FS.MkdirTemp(ctx, os.TempDir(), "m3fs-prepare-network")
+	tmpDir, err := localEm.FS.MkdirTemp(ctx, s.Runtime.LocalTempDir(), "m3fs-prepare-network")
 	if err != nil {
 		return errors.Trace(err)
 	}
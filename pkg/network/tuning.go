@@ -0,0 +1,196 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// TuneKernelTask is a task for applying and persisting tuning of nodes, see config.TuningProfile.
+type TuneKernelTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *TuneKernelTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("TuneKernelTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.TunedNodes(),
+			Parallel: true,
+			NewStep:  func() task.Step { return &tuneKernelStep{} },
+		},
+	})
+}
+
+// RemoveTuningTask is a task for removing files persisting tuning written by TuneKernelTask.
+// Values applied to the running kernel are kept until nodes reboot.
+type RemoveTuningTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *RemoveTuningTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("RemoveTuningTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.TunedNodes(),
+			Parallel: true,
+			NewStep:  func() task.Step { return &removeTuningStep{} },
+		},
+	})
+}
+
+// SysctlFilePath returns the path of the file persisting sysctls of the cluster.
+func SysctlFilePath(cluster string) string {
+	return fmt.Sprintf("/etc/sysctl.d/99-m3fs-%s.conf", cluster)
+}
+
+// ModulesFilePath returns the path of the file loading kernel modules of the cluster on boot.
+func ModulesFilePath(cluster string) string {
+	return fmt.Sprintf("/etc/modules-load.d/m3fs-%s.conf", cluster)
+}
+
+// LoadedModules returns kernel modules loaded on the node of the runner.
+func LoadedModules(ctx context.Context, runner external.RunnerInterface) (map[string]bool, error) {
+	out, err := runner.NonSudoExec(ctx, "ls", "/sys/module")
+	if err != nil {
+		return nil, errors.Annotate(err, "list loaded kernel modules")
+	}
+	loaded := make(map[string]bool)
+	for _, module := range strings.Fields(out) {
+		loaded[module] = true
+	}
+	return loaded, nil
+}
+
+// ModuleLoaded returns whether the module is in loaded modules, dashes in module names
+// are replaced by underscores under /sys/module.
+func ModuleLoaded(loaded map[string]bool, module string) bool {
+	return loaded[strings.ReplaceAll(module, "-", "_")]
+}
+
+type tuneKernelStep struct {
+	task.BaseStep
+}
+
+func (s *tuneKernelStep) Execute(ctx context.Context) error {
+	tuning := s.Runtime.Cfg.NodeTuning(s.Node.Name)
+	if err := s.loadModules(ctx, tuning.Modules); err != nil {
+		return errors.Trace(err)
+	}
+	if err := s.applySysctls(ctx, tuning.Sysctls); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func (s *tuneKernelStep) loadModules(ctx context.Context, modules []string) error {
+	if len(modules) > 0 {
+		loaded, err := LoadedModules(ctx, s.Em.Runner)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, module := range modules {
+			if ModuleLoaded(loaded, module) {
+				continue
+			}
+			if _, err = s.Em.Runner.Exec(ctx, "modprobe", module); err != nil {
+				return errors.Annotatef(err, "load kernel module %s", module)
+			}
+			s.Logger.Infof("Loaded kernel module %s", module)
+		}
+	}
+
+	content := ""
+	if len(modules) > 0 {
+		content = fmt.Sprintf("# m3fs managed kernel modules of cluster %s\n%s\n",
+			s.Runtime.Cfg.Name, strings.Join(modules, "\n"))
+	}
+	return errors.Trace(s.syncFile(ctx, ModulesFilePath(s.Runtime.Cfg.Name), content))
+}
+
+func (s *tuneKernelStep) applySysctls(ctx context.Context, sysctls map[string]string) error {
+	lines := []string{fmt.Sprintf("# m3fs managed sysctls of cluster %s", s.Runtime.Cfg.Name)}
+	for _, key := range slices.Sorted(maps.Keys(sysctls)) {
+		value := sysctls[key]
+		current, err := s.Em.Runner.Exec(ctx, "sysctl", "-n", key)
+		if err != nil {
+			// sysctl fails on keys unknown to the kernel, e.g. of modules not loaded
+			s.Logger.Warnf("Skip sysctl %s unknown to the kernel: %v", key, err)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s = %s", key, value))
+		if strings.Join(strings.Fields(current), " ") == strings.Join(strings.Fields(value), " ") {
+			continue
+		}
+		arg := external.ShellQuote(fmt.Sprintf("%s=%s", key, value))
+		if _, err = s.Em.Runner.Exec(ctx, "sysctl", "-w", arg); err != nil {
+			return errors.Annotatef(err, "set sysctl %s", key)
+		}
+		s.Logger.Infof("Set sysctl %s from %s to %s", key, strings.TrimSpace(current), value)
+	}
+
+	content := ""
+	if len(lines) > 1 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	return errors.Trace(s.syncFile(ctx, SysctlFilePath(s.Runtime.Cfg.Name), content))
+}
+
+// syncFile writes the content into the file if it differs, or removes the file if the
+// content is empty.
+func (s *tuneKernelStep) syncFile(ctx context.Context, path, content string) error {
+	if content == "" {
+		if _, err := s.Em.Runner.Exec(ctx, "rm", "-f", path); err != nil {
+			return errors.Annotatef(err, "remove %s", path)
+		}
+		return nil
+	}
+	// the file doesn't exist on the first run
+	if current, err := readNodeFile(ctx, &s.BaseStep, path); err == nil && current == content {
+		s.Logger.Debugf("%s is up to date", path)
+		return nil
+	}
+	if err := writeNodeFile(ctx, &s.BaseStep, path, content); err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("Updated %s", path)
+	return nil
+}
+
+type removeTuningStep struct {
+	task.BaseStep
+}
+
+func (s *removeTuningStep) Execute(ctx context.Context) error {
+	for _, path := range []string{SysctlFilePath(s.Runtime.Cfg.Name), ModulesFilePath(s.Runtime.Cfg.Name)} {
+		if _, err := s.Em.Runner.Exec(ctx, "rm", "-f", path); err != nil {
+			return errors.Annotatef(err, "remove %s", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+func TestTuneKernelStep(t *testing.T) {
+	suiteRun(t, &tuneKernelStepSuite{})
+}
+
+type tuneKernelStepSuite struct {
+	ttask.StepSuite
+
+	step *tuneKernelStep
+}
+
+const (
+	testSysctlFile  = "/etc/sysctl.d/99-m3fs-test-cluster.conf"
+	testModulesFile = "/etc/modules-load.d/m3fs-test-cluster.conf"
+	testSysctls     = "# m3fs managed sysctls of cluster test-cluster\n" +
+		"net.core.rmem_max = 16777216\n" +
+		"vm.max_map_count = 262144\n"
+	testModules = "# m3fs managed kernel modules of cluster test-cluster\nib_umad\nrdma_ucm\n"
+)
+
+func (s *tuneKernelStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &tuneKernelStep{}
+	s.Cfg.Nodes = []config.Node{{Name: "node1", Host: "10.0.0.1"}}
+	s.Cfg.Services.Storage.Nodes = []string{"node1"}
+	s.Cfg.Tuning = map[config.ServiceType]config.TuningProfile{
+		config.ServiceStorage: {
+			Sysctls: map[string]string{
+				"vm.max_map_count":   "262144",
+				"net.core.rmem_max":  "16777216",
+				"net.unknown.option": "1",
+			},
+			Modules: []string{"rdma_ucm", "ib_umad"},
+		},
+	}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+}
+
+func (s *tuneKernelStepSuite) mockSysctls() {
+	s.MockRunner.On("Exec", "sysctl", []string{"-n", "net.core.rmem_max"}).Return("16777216\n", nil)
+	s.MockRunner.On("Exec", "sysctl", []string{"-n", "net.unknown.option"}).
+		Return("", errors.New("sysctl: cannot stat /proc/sys/net/unknown/option"))
+	s.MockRunner.On("Exec", "sysctl", []string{"-n", "vm.max_map_count"}).Return("65530\n", nil)
+	s.MockRunner.On("Exec", "sysctl", []string{"-w", "'vm.max_map_count=262144'"}).Return("", nil)
+}
+
+func (s *tuneKernelStepSuite) mockWrite(path, content string) {
+	s.MockLocalFS.On("MkTempFile", os.TempDir()).Return("/tmp/local", nil)
+	s.MockLocalFS.On("WriteFile", "/tmp/local", []byte(content), os.FileMode(0644)).Return(nil).Once()
+	s.MockLocalFS.On("RemoveAll", "/tmp/local").Return(nil)
+	s.MockFS.On("MkTempFile", os.TempDir()).Return("/tmp/remote", nil)
+	s.MockRunner.On("Scp", "/tmp/local", "/tmp/remote").Return(nil)
+	s.MockRunner.On("Exec", "cp", []string{"/tmp/remote", path}).Return("", nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", "/tmp/remote"}).Return("", nil)
+}
+
+func (s *tuneKernelStepSuite) TestApply() {
+	s.MockRunner.On("NonSudoExec", "ls", []string{"/sys/module"}).Return("ib_core\nib_umad\n", nil)
+	s.MockRunner.On("Exec", "modprobe", []string{"rdma_ucm"}).Return("", nil)
+	s.MockRunner.On("Exec", "cat", []string{testModulesFile}).Return("", errors.New("no such file"))
+	s.mockWrite(testModulesFile, testModules)
+	s.mockSysctls()
+	s.MockRunner.On("Exec", "cat", []string{testSysctlFile}).Return("", errors.New("no such file"))
+	s.mockWrite(testSysctlFile, testSysctls)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+	s.MockLocalFS.AssertExpectations(s.T())
+	s.MockRunner.AssertNotCalled(s.T(), "Exec", "modprobe", []string{"ib_umad"})
+}
+
+func (s *tuneKernelStepSuite) TestUpToDate() {
+	s.MockRunner.On("NonSudoExec", "ls", []string{"/sys/module"}).Return("ib_umad\nrdma_ucm\n", nil)
+	s.MockRunner.On("Exec", "cat", []string{testModulesFile}).Return(testModules, nil)
+	s.mockSysctls()
+	s.MockRunner.On("Exec", "cat", []string{testSysctlFile}).Return(testSysctls, nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *tuneKernelStepSuite) TestUpToDateWithCRLF() {
+	s.MockRunner.On("NonSudoExec", "ls", []string{"/sys/module"}).Return("ib_umad\nrdma_ucm\n", nil)
+	s.MockRunner.On("Exec", "cat", []string{testModulesFile}).
+		Return(strings.ReplaceAll(testModules, "\n", "\r\n"), nil)
+	s.mockSysctls()
+	s.MockRunner.On("Exec", "cat", []string{testSysctlFile}).
+		Return(strings.ReplaceAll(testSysctls, "\n", "\r\n"), nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *tuneKernelStepSuite) TestLoadModuleFailed() {
+	s.MockRunner.On("NonSudoExec", "ls", []string{"/sys/module"}).Return("", nil)
+	s.MockRunner.On("Exec", "modprobe", []string{"ib_umad"}).Return("", errors.New("module not found"))
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "load kernel module ib_umad")
+}
+
+func TestRemoveTuningStep(t *testing.T) {
+	suiteRun(t, &removeTuningStepSuite{})
+}
+
+type removeTuningStepSuite struct {
+	ttask.StepSuite
+
+	step *removeTuningStep
+}
+
+func (s *removeTuningStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &removeTuningStep{}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1"}, s.Logger)
+}
+
+func (s *removeTuningStepSuite) Test() {
+	s.MockRunner.On("Exec", "rm", []string{"-f", testSysctlFile}).Return("", nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", testModulesFile}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
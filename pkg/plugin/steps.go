@@ -0,0 +1,63 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// commandScript returns the script running the command with the environment describing
+// where it runs, the node and the host are empty on the local host.
+func commandScript(cluster, node, host, command string) string {
+	return fmt.Sprintf("export M3FS_CLUSTER=%s M3FS_NODE=%s M3FS_HOST=%s; %s",
+		external.ShellQuote(cluster), external.ShellQuote(node), external.ShellQuote(host), command)
+}
+
+type runCommandStep struct {
+	task.BaseStep
+
+	plugin  string
+	command string
+}
+
+func (s *runCommandStep) Execute(ctx context.Context) error {
+	script := commandScript(s.Runtime.Cfg.Name, s.Node.Name, s.Node.Host, s.command)
+	// both runners run the command line by shell with sudo
+	if _, err := s.Em.Runner.Exec(ctx, "bash", "-c", external.ShellQuote(script)); err != nil {
+		return errors.Annotatef(err, "run command of plugin %s", s.plugin)
+	}
+	return nil
+}
+
+type runLocalCommandStep struct {
+	task.BaseLocalStep
+
+	plugin  string
+	command string
+}
+
+func (s *runLocalCommandStep) Execute(ctx context.Context) error {
+	script := commandScript(s.Runtime.Cfg.Name, "", "", s.command)
+	// the local runner runs the command without shell and sudo
+	if _, err := s.Runtime.LocalEm.Runner.NonSudoExec(ctx, "/bin/bash", "-c", script); err != nil {
+		return errors.Annotatef(err, "run command of plugin %s", s.plugin)
+	}
+	return nil
+}
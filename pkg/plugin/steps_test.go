@@ -0,0 +1,85 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+var suiteRun = suite.Run
+
+func TestPlugin(t *testing.T) {
+	suiteRun(t, &pluginSuite{})
+}
+
+type pluginSuite struct {
+	ttask.StepSuite
+}
+
+func (s *pluginSuite) TestRunCommandStep() {
+	step := &runCommandStep{plugin: "cmdb", command: "register --id 'x'"}
+	step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1", Host: "10.0.0.1"}, s.Logger)
+	s.MockRunner.On("Exec", "bash", []string{"-c", `'export M3FS_CLUSTER='\''test-cluster'\'' ` +
+		`M3FS_NODE='\''node1'\'' M3FS_HOST='\''10.0.0.1'\''; register --id '\''x'\'''`}).Return("", nil)
+
+	s.NoError(step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *pluginSuite) TestRunLocalTaskWithRetries() {
+	t := &RunPluginTask{Plugin: config.PluginTask{Name: "notify", Command: "notify", Retries: 1}}
+	t.Init(s.Runtime, s.Logger)
+	script := "export M3FS_CLUSTER='test-cluster' M3FS_NODE='' M3FS_HOST=''; notify"
+	s.MockLocalRunner.On("NonSudoExec", "/bin/bash", []string{"-c", script}).
+		Return("", errors.New("exit 1")).Once()
+	s.MockLocalRunner.On("NonSudoExec", "/bin/bash", []string{"-c", script}).Return("", nil).Once()
+
+	s.NoError(t.Run(s.Ctx()))
+
+	s.MockLocalRunner.AssertExpectations(s.T())
+	s.Equal("PluginTask[notify]", t.Name())
+	s.Equal(task.Metadata{Name: "PluginTask[notify]", Phase: task.PhaseDeploy, Scope: task.ScopeCluster},
+		t.Metadata())
+}
+
+func (s *pluginSuite) TestNewTasks() {
+	s.Cfg.Plugins = []config.PluginTask{
+		{Name: "cmdb", Command: "register", DeleteCommand: "unregister", Phase: "verify"},
+		{Name: "notify", Command: "notify"},
+		{Name: "monitor", Command: "add", DeleteCommand: "remove", After: []string{"PluginTask[cmdb]"}},
+	}
+	names := func(tasks []task.Interface) []string {
+		var names []string
+		for _, t := range tasks {
+			t.Init(s.Runtime, s.Logger)
+			names = append(names, t.Name())
+		}
+		return names
+	}
+
+	s.Equal([]string{"PluginTask[cmdb]", "PluginTask[notify]", "PluginTask[monitor]"},
+		names(NewRunPluginTasks(s.Cfg)))
+	deleteTasks := NewDeletePluginTasks(s.Cfg)
+	s.Equal([]string{"DeletePluginTask[monitor]", "DeletePluginTask[cmdb]"}, names(deleteTasks))
+	s.Equal(task.PhaseDeploy, task.MetadataOf(deleteTasks[1]).Phase)
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// NewRunPluginTasks generates a task running the command of each plugin task of the config.
+// They float into the order of builtin tasks by their phases and tasks they run after.
+func NewRunPluginTasks(cfg *config.Config) []task.Interface {
+	tasks := make([]task.Interface, len(cfg.Plugins))
+	for i, plugin := range cfg.Plugins {
+		tasks[i] = &RunPluginTask{Plugin: plugin}
+	}
+	return tasks
+}
+
+// NewDeletePluginTasks generates a task running the delete command of each plugin task
+// of the config having it. They run in the reverse order of plugin tasks before services
+// are deleted, which undoes them in the reverse order they were done.
+func NewDeletePluginTasks(cfg *config.Config) []task.Interface {
+	var tasks []task.Interface
+	for _, plugin := range slices.Backward(cfg.Plugins) {
+		if plugin.DeleteCommand != "" {
+			tasks = append(tasks, &RunPluginTask{Plugin: plugin, Delete: true})
+		}
+	}
+	return tasks
+}
+
+// TaskName returns the name of the task running the command of the plugin task.
+func TaskName(name string) string {
+	return fmt.Sprintf("PluginTask[%s]", name)
+}
+
+// DeleteTaskName returns the name of the task running the delete command of the plugin task.
+func DeleteTaskName(name string) string {
+	return fmt.Sprintf("DeletePluginTask[%s]", name)
+}
+
+// RunPluginTask is a task running the command or the delete command of a plugin task,
+// on selected nodes or once on the local host.
+type RunPluginTask struct {
+	task.BaseTask
+
+	Plugin config.PluginTask
+	// Delete makes the task run the delete command.
+	Delete bool
+
+	command string
+	local   bool
+}
+
+// Init initializes the task.
+func (t *RunPluginTask) Init(r *task.Runtime, logger log.Interface) {
+	t.command = t.Plugin.Command
+	if t.Delete {
+		t.BaseTask.SetName(DeleteTaskName(t.Plugin.Name))
+		t.command = t.Plugin.DeleteCommand
+		t.BaseTask.SetPhase(task.PhaseDeploy)
+	} else {
+		t.BaseTask.SetName(TaskName(t.Plugin.Name))
+		t.BaseTask.SetDeps(t.Plugin.After...)
+		t.BaseTask.SetFloating()
+		phase := task.PhaseDeploy
+		if t.Plugin.Phase != "" {
+			phase = task.Phase(t.Plugin.Phase)
+		}
+		t.BaseTask.SetPhase(phase)
+	}
+	t.BaseTask.Init(r, logger)
+
+	nodes := r.Cfg.PluginNodes(&t.Plugin)
+	t.local = len(nodes) == 0
+	if t.local {
+		return
+	}
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:       nodes,
+			Parallel:    true,
+			MaxParallel: t.Plugin.MaxParallel,
+			RetryTime:   t.Plugin.Retries,
+			NewStep: func() task.Step {
+				return &runCommandStep{plugin: t.Plugin.Name, command: t.command}
+			},
+		},
+	})
+}
+
+// Run runs the command on selected nodes, or once on the local host.
+func (t *RunPluginTask) Run(ctx context.Context) error {
+	if !t.local {
+		return errors.Trace(t.BaseTask.Run(ctx))
+	}
+	step := &runLocalCommandStep{plugin: t.Plugin.Name, command: t.command}
+	step.Init(t.Runtime, log.Logger.Subscribe(log.FieldKeyNode, "<LOCAL>"))
+	var err error
+	for i := 0; i <= t.Plugin.Retries; i++ {
+		if err = step.Execute(ctx); err != nil && i != t.Plugin.Retries {
+			t.Logger.Warnf("Step failed, retrying: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		break
+	}
+	return errors.Trace(err)
+}
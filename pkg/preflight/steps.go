@@ -0,0 +1,397 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/network"
+	"github.com/open3fs/m3fs/pkg/storage"
+	"github.com/open3fs/m3fs/pkg/task"
+	"github.com/open3fs/m3fs/pkg/utils"
+)
+
+type checkSudoStep struct {
+	task.BaseStep
+}
+
+func (s *checkSudoStep) Execute(ctx context.Context) error {
+	if _, err := s.Em.Runner.Exec(ctx, "true"); err != nil {
+		return errors.Annotatef(err, "run command with sudo on %s", s.Node.Name)
+	}
+	s.Logger.Infof("Sudo is available on %s", s.Node.Name)
+	return nil
+}
+
+// gatherFactsStep gathers facts of the node, which later checks of the node consume.
+type gatherFactsStep struct {
+	task.BaseStep
+}
+
+func (s *gatherFactsStep) Execute(ctx context.Context) error {
+	facts, err := s.Runtime.FreshNodeFacts(ctx, s.Em, s.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("%s runs %s, kernel %s on %s", s.Node.Name, facts.OS, facts.Kernel, facts.Arch)
+	return nil
+}
+
+type checkContainerRuntimeStep struct {
+	task.BaseStep
+}
+
+func (s *checkContainerRuntimeStep) Execute(ctx context.Context) error {
+	runtime, err := s.Runtime.ContainerRuntime(ctx, s.Em, s.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("Container runtime of %s is %s", s.Node.Name, runtime)
+	return nil
+}
+
+// checkResourcesStep checks that the node can accommodate resource limits of
+// services on it.
+type checkResourcesStep struct {
+	task.BaseStep
+}
+
+func (s *checkResourcesStep) Execute(ctx context.Context) error {
+	var (
+		services    []config.ServiceType
+		memory      uint64
+		cpus        float64
+		nofile      uint64
+		nproc       uint64
+		nofileOwner config.ServiceType
+		nprocOwner  config.ServiceType
+		cpusOwner   config.ServiceType
+	)
+	for _, service := range config.AllServiceTypes {
+		res := s.Runtime.Services.Resources(service)
+		if res.IsEmpty() || !utils.NewSet(s.Runtime.Services.ServiceNodes(service)...).Contains(s.Node.Name) {
+			continue
+		}
+		services = append(services, service)
+		// containers on the node share the memory of it
+		bytes, err := res.MemoryBytes()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		memory += bytes
+		if res.CPUs > cpus {
+			cpus, cpusOwner = res.CPUs, service
+		}
+		if res.NoFile != "" {
+			if _, hard, _ := config.ParseUlimit(res.NoFile); hard > nofile {
+				nofile, nofileOwner = hard, service
+			}
+		}
+		if res.NProc != "" {
+			if _, hard, _ := config.ParseUlimit(res.NProc); hard > nproc {
+				nproc, nprocOwner = hard, service
+			}
+		}
+	}
+	if len(services) == 0 {
+		return nil
+	}
+
+	facts, err := s.Runtime.FreshNodeFacts(ctx, s.Em, s.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if memory > facts.MemoryBytes {
+		return errors.Errorf("memory limits of %v total %d bytes, exceeding %d bytes memory of %s",
+			services, memory, facts.MemoryBytes, s.Node.Name)
+	}
+	if cpus > float64(facts.CPUs) {
+		return errors.Errorf("cpus limit %v of %s exceeds %d CPUs of %s",
+			cpus, cpusOwner, facts.CPUs, s.Node.Name)
+	}
+	if nofile > facts.NrOpen {
+		return errors.Errorf("nofile limit %d of %s exceeds fs.nr_open %d of %s",
+			nofile, nofileOwner, facts.NrOpen, s.Node.Name)
+	}
+	if nproc > facts.ThreadsMax {
+		return errors.Errorf("nproc limit %d of %s exceeds kernel.threads-max %d of %s",
+			nproc, nprocOwner, facts.ThreadsMax, s.Node.Name)
+	}
+	s.Logger.Infof("Resource limits of %v are accommodated by %s", services, s.Node.Name)
+	return nil
+}
+
+// checkTuningStep reports current and desired values of the tuning of the node, which
+// are applied by network.TuneKernelTask. Sysctl keys unknown to the kernel are warned.
+type checkTuningStep struct {
+	task.BaseStep
+}
+
+func (s *checkTuningStep) Execute(ctx context.Context) error {
+	tuning := s.Runtime.Cfg.NodeTuning(s.Node.Name)
+	for _, key := range slices.Sorted(maps.Keys(tuning.Sysctls)) {
+		desired := tuning.Sysctls[key]
+		current, err := s.Em.Runner.NonSudoExec(ctx, "sysctl", "-n", key)
+		if err != nil {
+			s.Logger.Warnf("Sysctl %s is unknown to the kernel of %s, it will be skipped", key, s.Node.Name)
+			continue
+		}
+		current = strings.Join(strings.Fields(current), " ")
+		if current == strings.Join(strings.Fields(desired), " ") {
+			s.Logger.Infof("Sysctl %s of %s is %s as desired", key, s.Node.Name, current)
+		} else {
+			s.Logger.Infof("Sysctl %s of %s is %s, desired %s", key, s.Node.Name, current, desired)
+		}
+	}
+	if len(tuning.Modules) == 0 {
+		return nil
+	}
+	loaded, err := network.LoadedModules(ctx, s.Em.Runner)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var missing []string
+	for _, module := range tuning.Modules {
+		if !network.ModuleLoaded(loaded, module) {
+			missing = append(missing, module)
+		}
+	}
+	if len(missing) > 0 {
+		s.Logger.Infof("Kernel modules %v of %s aren't loaded, they will be loaded", missing, s.Node.Name)
+	} else {
+		s.Logger.Infof("Kernel modules %v of %s are loaded", tuning.Modules, s.Node.Name)
+	}
+	return nil
+}
+
+// checkFirewallStep checks that the firewall of the node accepts connections to ports of
+// services on the node, which are opened by network.ManageFirewallTask of cluster prepare
+// if the firewall is managed.
+type checkFirewallStep struct {
+	task.BaseStep
+}
+
+func (s *checkFirewallStep) Execute(ctx context.Context) error {
+	backend := network.DetectFirewall(ctx, s.Em.Runner)
+	if backend == network.FirewallNone {
+		s.Logger.Infof("No active firewall on %s", s.Node.Name)
+		return nil
+	}
+	ports := network.FirewallPorts(s.Runtime.Cfg, s.Node.Name)
+	closed, err := network.ClosedPorts(ctx, s.Em.Runner, backend, ports)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(closed) == 0 {
+		s.Logger.Infof("Ports %v of %s are open in %s", ports, s.Node.Name, backend)
+		return nil
+	}
+	hint := "open them or set manageFirewall to let cluster prepare open them"
+	if s.Runtime.Cfg.ManageFirewall {
+		hint = "run cluster prepare to open them"
+	}
+	return errors.Errorf("ports %v of %s are closed by %s, %s", closed, s.Node.Name, backend, hint)
+}
+
+// checkCommandWrapperStep checks that executables of command wrappers of services on the
+// node exist, they're mounted into containers of the services. If the image of services
+// is on the node, executables are run with --version in containers of the image, the
+// same way they're mounted into containers of the services, so that executables which
+// aren't static binaries are found before services fail to start.
+type checkCommandWrapperStep struct {
+	task.BaseStep
+}
+
+func (s *checkCommandWrapperStep) Execute(ctx context.Context) error {
+	img, err := s.Runtime.Cfg.Images.GetImage(config.ImageName3FS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = s.Em.Docker.ImageID(ctx, img)
+	imageFound := err == nil
+	if !imageFound {
+		s.Logger.Warnf("Image %s isn't on %s yet, command wrappers aren't run in its containers", img, s.Node.Name)
+	}
+	wrappers := s.Runtime.Cfg.NodeCommandWrappers(s.Node.Name)
+	for _, service := range slices.Sorted(maps.Keys(wrappers)) {
+		executable := wrappers[service][0]
+		if _, err := s.Em.Runner.Exec(ctx, "test", "-x", executable); err != nil {
+			return errors.Errorf("executable %s of the command wrapper of %s isn't found on %s",
+				executable, service, s.Node.Name)
+		}
+		if imageFound {
+			args := &external.RunArgs{
+				Image:   img,
+				Rm:      common.Pointer(true),
+				Command: []string{"--version"},
+			}
+			args.SetCommandWrapper([]string{executable})
+			if _, err := s.Em.Docker.Run(ctx, args); err != nil {
+				return errors.Errorf("executable %s of the command wrapper of %s doesn't run in image %s on %s, "+
+					"it must be a static binary: %v", executable, service, img, s.Node.Name, err)
+			}
+		}
+		s.Logger.Warnf("Command of %s on %s is wrapped by %q", service, s.Node.Name,
+			strings.Join(wrappers[service], " "))
+	}
+	return nil
+}
+
+// checkPassthroughStep checks that paths and devices passed into containers of services
+// on the node exist.
+type checkPassthroughStep struct {
+	task.BaseStep
+}
+
+func (s *checkPassthroughStep) Execute(ctx context.Context) error {
+	passthroughs := s.Runtime.Cfg.NodePassthroughs(s.Node.Name)
+	var missing []string
+	for _, service := range slices.Sorted(maps.Keys(passthroughs)) {
+		p := passthroughs[service]
+		for _, m := range p.Mounts {
+			if _, err := s.Em.Runner.Exec(ctx, "test", "-e", m.Source); err != nil {
+				missing = append(missing, fmt.Sprintf("mount %s of %s", m.Source, service))
+			}
+		}
+		for _, d := range p.Devices {
+			if _, err := s.Em.Runner.Exec(ctx, "test", "-b", d.Host, "-o", "-c", d.Host); err != nil {
+				missing = append(missing, fmt.Sprintf("device %s of %s", d.Host, service))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("%s not found on %s", strings.Join(missing, ", "), s.Node.Name)
+	}
+	s.Logger.Infof("Paths and devices passed into containers exist on %s", s.Node.Name)
+	return nil
+}
+
+// checkNameResolutionStep checks that names of all nodes of the cluster resolve on the
+// node, when the resolver config of nodes is managed by m3fs.
+type checkNameResolutionStep struct {
+	task.BaseStep
+}
+
+func (s *checkNameResolutionStep) Execute(ctx context.Context) error {
+	var unresolved []string
+	for _, node := range s.Runtime.Cfg.Nodes {
+		name := config.HostsName(node.Name)
+		if _, err := s.Em.Runner.Exec(ctx, "getent", "hosts", name); err != nil {
+			unresolved = append(unresolved, name)
+		}
+	}
+	if len(unresolved) > 0 {
+		return errors.Errorf("names %s don't resolve on %s, check nameservers and search domains of dns",
+			strings.Join(unresolved, ", "), s.Node.Name)
+	}
+	s.Logger.Infof("Names of all nodes resolve on %s", s.Node.Name)
+	return nil
+}
+
+// capacityTolerance is the tolerated relative difference between the declared and the
+// detected capacity of a storage node.
+const capacityTolerance = 0.1
+
+// checkCapacityStep cross-checks the declared capacity of the storage node against the
+// capacity of its data disks, which is the size of NVMe disks, or the free space of the
+// filesystem holding the data dir of directory disks.
+type checkCapacityStep struct {
+	task.BaseStep
+}
+
+func (s *checkCapacityStep) Execute(ctx context.Context) error {
+	declared, err := config.ParseCapacity(s.Node.Capacity)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var detected uint64
+	if s.Runtime.Services.Storage.DiskType == config.DiskTypeNvme {
+		detected, err = s.nvmeCapacity(ctx)
+	} else {
+		detected, err = s.dirCapacity(ctx)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	declaredStr := common.FormatBytes(int64(declared))
+	detectedStr := common.FormatBytes(int64(detected))
+	switch {
+	case float64(declared) > float64(detected)*(1+capacityTolerance):
+		return errors.Errorf("declared capacity %s of %s exceeds %s detected on its data disks",
+			declaredStr, s.Node.Name, detectedStr)
+	case float64(declared) < float64(detected)*(1-capacityTolerance):
+		s.Logger.Warnf("Declared capacity %s of %s is less than %s detected on its data disks, "+
+			"it receives less data than it can hold", declaredStr, s.Node.Name, detectedStr)
+	default:
+		s.Logger.Infof("Declared capacity %s of %s matches %s detected on its data disks",
+			declaredStr, s.Node.Name, detectedStr)
+	}
+	return nil
+}
+
+// nvmeCapacity returns the total size of NVMe disks used as data disks, which are the
+// first ones in facts of the node like the disk tool takes them.
+func (s *checkCapacityStep) nvmeCapacity(ctx context.Context) (uint64, error) {
+	facts, err := s.Runtime.FreshNodeFacts(ctx, s.Em, s.Node)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	var sizes []uint64
+	for _, disk := range facts.NVMeDisks() {
+		sizes = append(sizes, disk.Size)
+	}
+	diskNum := s.Runtime.Services.Storage.DiskNumPerNode
+	if len(sizes) < diskNum {
+		return 0, errors.Errorf("%s has %d NVMe disks, fewer than diskNumPerNode %d",
+			s.Node.Name, len(sizes), diskNum)
+	}
+	var total uint64
+	for _, size := range sizes[:diskNum] {
+		total += size
+	}
+	return total, nil
+}
+
+// dirCapacity returns the free space of the filesystem holding the data dir, or its
+// nearest existing parent before the data dir is created.
+func (s *checkCapacityStep) dirCapacity(ctx context.Context) (uint64, error) {
+	dataDir := storage.DataDir(s.Runtime.WorkDir)
+	// the script is quoted for the shell running commands on the node
+	out, err := s.Em.Runner.NonSudoExec(ctx, "bash", "-c",
+		fmt.Sprintf(`'d=%s; while [ ! -e "$d" ]; do d=$(dirname "$d"); done; df -P -k "$d"'`, dataDir))
+	if err != nil {
+		return 0, errors.Annotatef(err, "check free space of %s", dataDir)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(lines) < 2 || len(fields) < 4 {
+		return 0, errors.Errorf("unexpected output of df: %s", out)
+	}
+	kb, err := strconv.ParseUint(fields[3], 10, 64)
+	if err != nil {
+		return 0, errors.Annotatef(err, "parse output of df: %s", out)
+	}
+	return kb << 10, nil
+}
@@ -0,0 +1,472 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/task"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+var suiteRun = suite.Run
+
+func TestCheckSudoStep(t *testing.T) {
+	suiteRun(t, &checkSudoStepSuite{})
+}
+
+type checkSudoStepSuite struct {
+	ttask.StepSuite
+
+	step *checkSudoStep
+}
+
+func (s *checkSudoStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkSudoStep{}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1"}, s.Logger)
+}
+
+func (s *checkSudoStepSuite) Test() {
+	s.MockRunner.On("Exec", "true", []string(nil)).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *checkSudoStepSuite) TestFailed() {
+	s.MockRunner.On("Exec", "true", []string(nil)).Return("", errors.New("sudo: a password is required"))
+
+	s.Error(s.step.Execute(s.Ctx()))
+}
+
+func TestCheckContainerRuntimeStep(t *testing.T) {
+	suiteRun(t, &checkContainerRuntimeStepSuite{})
+}
+
+type checkContainerRuntimeStepSuite struct {
+	ttask.StepSuite
+
+	step *checkContainerRuntimeStep
+}
+
+func (s *checkContainerRuntimeStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkContainerRuntimeStep{}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1"}, s.Logger)
+}
+
+func (s *checkContainerRuntimeStepSuite) TestNotFound() {
+	s.MockRunner.On("Exec", "sh", []string{"-c", "'command -v docker'"}).Return("", errors.New("exit 1"))
+	s.MockRunner.On("Exec", "sh", []string{"-c", "'command -v nerdctl'"}).Return("", errors.New("exit 1"))
+	s.MockRunner.On("Exec", "sh", []string{"-c", "'command -v podman'"}).Return("", errors.New("exit 1"))
+
+	s.Error(s.step.Execute(s.Ctx()))
+}
+
+func (s *checkContainerRuntimeStepSuite) TestFromConfig() {
+	s.Cfg.ContainerRuntime = config.ContainerRuntimePodman
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "Exec")
+}
+
+func TestCheckResourcesStep(t *testing.T) {
+	suiteRun(t, &checkResourcesStepSuite{})
+}
+
+type checkResourcesStepSuite struct {
+	ttask.StepSuite
+
+	step *checkResourcesStep
+}
+
+func (s *checkResourcesStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkResourcesStep{}
+	s.Cfg.Services.Storage.Nodes = []string{"node1"}
+	s.Cfg.Services.Meta.Nodes = []string{"node1"}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1"}, s.Logger)
+}
+
+func (s *checkResourcesStepSuite) mockHost() {
+	s.Runtime.SetNodeFacts(&task.NodeFacts{
+		Node:        "node1",
+		CPUs:        16,
+		MemoryBytes: 65536000 << 10,
+		NrOpen:      1048576,
+		ThreadsMax:  1048576,
+	})
+}
+
+func (s *checkResourcesStepSuite) TestNoLimits() {
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertNotCalled(s.T(), "NonSudoExec")
+}
+
+func (s *checkResourcesStepSuite) TestAccommodated() {
+	s.Cfg.Services.Storage.Resources = config.Resources{Memory: "32g", CPUs: 8, NoFile: "1048576"}
+	s.Cfg.Services.Meta.Resources = config.Resources{Memory: "16g"}
+	s.mockHost()
+
+	s.NoError(s.step.Execute(s.Ctx()))
+}
+
+func (s *checkResourcesStepSuite) TestMemoryExceeded() {
+	s.Cfg.Services.Storage.Resources = config.Resources{Memory: "48g"}
+	s.Cfg.Services.Meta.Resources = config.Resources{Memory: "16g"}
+	s.mockHost()
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "exceeding 67108864000 bytes memory of node1")
+}
+
+func (s *checkResourcesStepSuite) TestCPUsExceeded() {
+	s.Cfg.Services.Storage.Resources = config.Resources{CPUs: 32}
+	s.mockHost()
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "cpus limit 32 of storage exceeds 16 CPUs of node1")
+}
+
+func (s *checkResourcesStepSuite) TestNoFileExceeded() {
+	s.Cfg.Services.Storage.Resources = config.Resources{NoFile: "1024:2097152"}
+	s.mockHost()
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "nofile limit 2097152 of storage exceeds fs.nr_open 1048576 of node1")
+}
+
+func TestCheckTuningStep(t *testing.T) {
+	suiteRun(t, &checkTuningStepSuite{})
+}
+
+type checkTuningStepSuite struct {
+	ttask.StepSuite
+
+	step *checkTuningStep
+}
+
+func (s *checkTuningStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkTuningStep{}
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}}
+	s.Cfg.Services.Storage.Nodes = []string{"node1"}
+	s.Cfg.Tuning = map[config.ServiceType]config.TuningProfile{
+		config.ServiceStorage: {
+			Sysctls: map[string]string{"vm.max_map_count": "262144", "net.unknown.option": "1"},
+			Modules: []string{"rdma-ucm"},
+		},
+	}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+}
+
+func (s *checkTuningStepSuite) Test() {
+	s.MockRunner.On("NonSudoExec", "sysctl", []string{"-n", "net.unknown.option"}).
+		Return("", errors.New("unknown key"))
+	s.MockRunner.On("NonSudoExec", "sysctl", []string{"-n", "vm.max_map_count"}).Return("65530\n", nil)
+	s.MockRunner.On("NonSudoExec", "ls", []string{"/sys/module"}).Return("rdma_ucm\n", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *checkTuningStepSuite) TestListModulesFailed() {
+	s.MockRunner.On("NonSudoExec", "sysctl", []string{"-n", "net.unknown.option"}).Return("1", nil)
+	s.MockRunner.On("NonSudoExec", "sysctl", []string{"-n", "vm.max_map_count"}).Return("262144", nil)
+	s.MockRunner.On("NonSudoExec", "ls", []string{"/sys/module"}).Return("", errors.New("permission denied"))
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "list loaded kernel modules")
+}
+
+func TestCheckFirewallStep(t *testing.T) {
+	suiteRun(t, &checkFirewallStepSuite{})
+}
+
+type checkFirewallStepSuite struct {
+	ttask.StepSuite
+
+	step *checkFirewallStep
+}
+
+func (s *checkFirewallStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkFirewallStep{}
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}}
+	s.Cfg.Services = config.Services{}
+	s.Cfg.Services.Monitor.Nodes = []string{"node1"}
+	s.Cfg.Services.Monitor.Port = 10000
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+
+	s.MockRunner.On("Exec", "firewall-cmd", []string{"--state"}).Return("", errors.New("not found"))
+	s.MockRunner.On("Exec", "ufw", []string{"status"}).Return("Status: active\n", nil)
+}
+
+func (s *checkFirewallStepSuite) TestOpen() {
+	s.MockRunner.On("Exec", "ufw", []string{"status", "verbose"}).
+		Return("Status: active\nDefault: allow (incoming), allow (outgoing)\n", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *checkFirewallStepSuite) TestClosed() {
+	s.MockRunner.On("Exec", "ufw", []string{"status", "verbose"}).
+		Return("Status: active\nDefault: deny (incoming), allow (outgoing)\n", nil)
+
+	s.ErrorContains(s.step.Execute(s.Ctx()), "ports [10000] of node1 are closed by ufw, open them or set manageFirewall")
+
+	s.Cfg.ManageFirewall = true
+	s.ErrorContains(s.step.Execute(s.Ctx()), "run cluster prepare to open them")
+}
+
+func TestCheckCommandWrapperStep(t *testing.T) {
+	suiteRun(t, &checkCommandWrapperStepSuite{})
+}
+
+type checkCommandWrapperStepSuite struct {
+	ttask.StepSuite
+
+	step *checkCommandWrapperStep
+}
+
+func (s *checkCommandWrapperStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkCommandWrapperStep{}
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}}
+	s.Cfg.Services = config.Services{}
+	s.Cfg.Services.Meta.Nodes = []string{"node1"}
+	s.Cfg.Services.Meta.CommandWrapper = "/usr/bin/numactl --interleave=all"
+	s.Cfg.Services.Storage.Nodes = []string{"node2"}
+	s.Cfg.Services.Storage.CommandWrapper = "/usr/local/bin/strace -f"
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+}
+
+func (s *checkCommandWrapperStepSuite) wrapperRunArgs() *external.RunArgs {
+	img, err := s.Cfg.Images.GetImage(config.ImageName3FS)
+	s.NoError(err)
+	args := &external.RunArgs{
+		Image:   img,
+		Rm:      common.Pointer(true),
+		Command: []string{"--version"},
+	}
+	args.SetCommandWrapper([]string{"/usr/bin/numactl"})
+	return args
+}
+
+func (s *checkCommandWrapperStepSuite) TestFound() {
+	s.MockDocker.On("ImageID", mock.Anything).Return("sha256:abc", nil)
+	s.MockRunner.On("Exec", "test", []string{"-x", "/usr/bin/numactl"}).Return("", nil)
+	s.MockDocker.On("Run", s.wrapperRunArgs()).Return("numactl 2.0.16", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+	s.MockDocker.AssertExpectations(s.T())
+}
+
+func (s *checkCommandWrapperStepSuite) TestImageNotFound() {
+	s.MockDocker.On("ImageID", mock.Anything).Return("", errors.New("no such image"))
+	s.MockRunner.On("Exec", "test", []string{"-x", "/usr/bin/numactl"}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockDocker.AssertNotCalled(s.T(), "Run", mock.Anything)
+}
+
+func (s *checkCommandWrapperStepSuite) TestNotFound() {
+	s.MockDocker.On("ImageID", mock.Anything).Return("sha256:abc", nil)
+	s.MockRunner.On("Exec", "test", []string{"-x", "/usr/bin/numactl"}).Return("", errors.New("exit status 1"))
+
+	s.ErrorContains(s.step.Execute(s.Ctx()),
+		"executable /usr/bin/numactl of the command wrapper of meta isn't found on node1")
+}
+
+func (s *checkCommandWrapperStepSuite) TestNotRunInImage() {
+	s.MockDocker.On("ImageID", mock.Anything).Return("sha256:abc", nil)
+	s.MockRunner.On("Exec", "test", []string{"-x", "/usr/bin/numactl"}).Return("", nil)
+	s.MockDocker.On("Run", s.wrapperRunArgs()).Return("", errors.New("libnuma.so.1: cannot open shared object file"))
+
+	s.ErrorContains(s.step.Execute(s.Ctx()),
+		"executable /usr/bin/numactl of the command wrapper of meta doesn't run in image")
+}
+
+func TestCheckPassthroughStep(t *testing.T) {
+	suiteRun(t, &checkPassthroughStepSuite{})
+}
+
+type checkPassthroughStepSuite struct {
+	ttask.StepSuite
+
+	step *checkPassthroughStep
+}
+
+func (s *checkPassthroughStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkPassthroughStep{}
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}}
+	s.Cfg.Services = config.Services{}
+	s.Cfg.Services.Storage.Nodes = []string{"node1"}
+	s.Cfg.Services.Storage.Mounts = []config.Mount{{Source: "/data1"}}
+	s.Cfg.Services.Storage.Devices = []config.Device{{Host: "/dev/nvme0n1"}}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+}
+
+func (s *checkPassthroughStepSuite) TestFound() {
+	s.MockRunner.On("Exec", "test", []string{"-e", "/data1"}).Return("", nil)
+	s.MockRunner.On("Exec", "test", []string{"-b", "/dev/nvme0n1", "-o", "-c", "/dev/nvme0n1"}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *checkPassthroughStepSuite) TestNotFound() {
+	s.MockRunner.On("Exec", "test", []string{"-e", "/data1"}).Return("", errors.New("exit status 1"))
+	s.MockRunner.On("Exec", "test", []string{"-b", "/dev/nvme0n1", "-o", "-c", "/dev/nvme0n1"}).
+		Return("", errors.New("exit status 1"))
+
+	s.ErrorContains(s.step.Execute(s.Ctx()),
+		"mount /data1 of storage, device /dev/nvme0n1 of storage not found on node1")
+}
+
+func TestCheckNameResolutionStep(t *testing.T) {
+	suiteRun(t, &checkNameResolutionStepSuite{})
+}
+
+type checkNameResolutionStepSuite struct {
+	ttask.StepSuite
+
+	step *checkNameResolutionStep
+}
+
+func (s *checkNameResolutionStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkNameResolutionStep{}
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}, {Name: "Meta_Node"}}
+	s.Cfg.DNS.SearchDomains = []string{"example.com"}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+}
+
+func (s *checkNameResolutionStepSuite) TestResolved() {
+	s.MockRunner.On("Exec", "getent", []string{"hosts", "node1"}).Return("10.0.0.1 node1.example.com", nil)
+	s.MockRunner.On("Exec", "getent", []string{"hosts", "meta-node"}).Return("10.0.0.2 meta-node.example.com", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *checkNameResolutionStepSuite) TestUnresolved() {
+	s.MockRunner.On("Exec", "getent", []string{"hosts", "node1"}).Return("10.0.0.1 node1.example.com", nil)
+	s.MockRunner.On("Exec", "getent", []string{"hosts", "meta-node"}).Return("", errors.New("exit status 2"))
+
+	s.ErrorContains(s.step.Execute(s.Ctx()),
+		"names meta-node don't resolve on node1, check nameservers and search domains of dns")
+}
+
+func TestCheckCapacityStep(t *testing.T) {
+	suiteRun(t, &checkCapacityStepSuite{})
+}
+
+type checkCapacityStepSuite struct {
+	ttask.StepSuite
+
+	step *checkCapacityStep
+}
+
+func (s *checkCapacityStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.step = &checkCapacityStep{}
+	s.Cfg.Services.Storage.DiskType = config.DiskTypeDirectory
+	s.Cfg.Services.Storage.DiskNumPerNode = 2
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1", Capacity: "1T"}, s.Logger)
+}
+
+func (s *checkCapacityStepSuite) mockDf(availKB string) {
+	script := `'d=/root/3fs/storage/3fsdata; while [ ! -e "$d" ]; do d=$(dirname "$d"); done; df -P -k "$d"'`
+	s.MockRunner.On("NonSudoExec", "bash", []string{"-c", script}).Return(
+		"Filesystem 1024-blocks Used Available Capacity Mounted on\n"+
+			"/dev/sda1 2147483648 0 "+availKB+" 0% /\n", nil)
+}
+
+func (s *checkCapacityStepSuite) TestDirMatched() {
+	s.mockDf("1073741824")
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *checkCapacityStepSuite) TestDirLessThanDeclared() {
+	s.mockDf("536870912")
+
+	s.Error(s.step.Execute(s.Ctx()), "declared capacity 1.0 TiB of node1 exceeds 512.0 GiB detected on its data disks")
+}
+
+func (s *checkCapacityStepSuite) TestDirMoreThanDeclared() {
+	s.mockDf("2147483648")
+
+	s.NoError(s.step.Execute(s.Ctx()))
+}
+
+func (s *checkCapacityStepSuite) TestNvme() {
+	s.Runtime.Services.Storage.DiskType = config.DiskTypeNvme
+	s.Runtime.SetNodeFacts(&task.NodeFacts{Node: "node1", Disks: []task.DiskFacts{
+		{Name: "sda", Size: 107374182400},
+		{Name: "nvme0n1", Size: 549755813888},
+		{Name: "nvme1n1", Size: 549755813888},
+		{Name: "nvme2n1", Size: 549755813888},
+	}})
+
+	s.NoError(s.step.Execute(s.Ctx()))
+}
+
+func (s *checkCapacityStepSuite) TestNvmeTooFewDisks() {
+	s.Runtime.Services.Storage.DiskType = config.DiskTypeNvme
+	s.Runtime.SetNodeFacts(&task.NodeFacts{Node: "node1", Disks: []task.DiskFacts{
+		{Name: "sda", Size: 107374182400},
+		{Name: "nvme0n1", Size: 549755813888},
+	}})
+
+	s.Error(s.step.Execute(s.Ctx()), "node1 has 1 NVMe disks, fewer than diskNumPerNode 2")
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/network"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// connectTimeout is short so that unreachable nodes are flagged quickly.
+const connectTimeout = 10 * time.Second
+
+// PreflightTask is a task for checking nodes are ready to deploy a 3fs cluster.
+type PreflightTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *PreflightTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("PreflightTask")
+	t.BaseTask.Init(r, logger)
+	checks := Checks(r.Cfg)
+	steps := make([]task.StepConfig, len(checks))
+	for i, check := range checks {
+		steps[i] = task.StepConfig{
+			Nodes:          check.Nodes,
+			Parallel:       true,
+			NewStep:        check.NewStep,
+			ConnectTimeout: connectTimeout,
+		}
+	}
+	t.SetSteps(steps)
+}
+
+// defines names of preflight checks.
+const (
+	CheckSudo             = "sudo"
+	CheckFacts            = "facts"
+	CheckContainerRuntime = "container-runtime"
+	CheckResources        = "resources"
+	CheckTuning           = "tuning"
+	CheckFirewall         = "firewall"
+	CheckCommandWrapper   = "command-wrapper"
+	CheckPassthrough      = "passthrough"
+	CheckNameResolution   = "name-resolution"
+	CheckCapacity         = "capacity"
+)
+
+// Check is a preflight check, which runs its step on its nodes. The step fails if the
+// check fails.
+type Check struct {
+	Name    string
+	Nodes   []config.Node
+	NewStep func() task.Step
+	// Fix is the suggested fix if the check fails.
+	Fix string
+	// Deployed is set if the check still holds after the cluster is deployed, so that
+	// it also diagnoses deployed clusters.
+	Deployed bool
+}
+
+// Checks returns preflight checks of the config in order.
+func Checks(cfg *config.Config) []Check {
+	return []Check{
+		{
+			Name:     CheckSudo,
+			Nodes:    cfg.Nodes,
+			NewStep:  func() task.Step { return new(checkSudoStep) },
+			Fix:      "Grant sudo permission to the SSH user of the node",
+			Deployed: true,
+		},
+		{
+			Name:    CheckFacts,
+			Nodes:   cfg.Nodes,
+			NewStep: func() task.Step { return new(gatherFactsStep) },
+		},
+		{
+			Name:     CheckContainerRuntime,
+			Nodes:    cfg.Nodes,
+			NewStep:  func() task.Step { return new(checkContainerRuntimeStep) },
+			Fix:      "Install one of docker, containerd with nerdctl or podman",
+			Deployed: true,
+		},
+		{
+			Name:     CheckResources,
+			Nodes:    cfg.Nodes,
+			NewStep:  func() task.Step { return new(checkResourcesStep) },
+			Fix:      "Lower resource limits of services on the node",
+			Deployed: true,
+		},
+		{
+			Name:     CheckTuning,
+			Nodes:    cfg.TunedNodes(),
+			NewStep:  func() task.Step { return new(checkTuningStep) },
+			Deployed: true,
+		},
+		{
+			Name:     CheckFirewall,
+			Nodes:    firewallNodes(cfg),
+			NewStep:  func() task.Step { return new(checkFirewallStep) },
+			Fix:      "Open ports of services in the firewall, or run cluster prepare with manageFirewall",
+			Deployed: true,
+		},
+		{
+			Name:     CheckCommandWrapper,
+			Nodes:    cfg.CommandWrapperNodes(),
+			NewStep:  func() task.Step { return new(checkCommandWrapperStep) },
+			Fix:      "Install static binaries of command wrappers on the node",
+			Deployed: true,
+		},
+		{
+			Name:     CheckPassthrough,
+			Nodes:    cfg.PassthroughNodes(),
+			NewStep:  func() task.Step { return new(checkPassthroughStep) },
+			Fix:      "Create paths and devices passed into containers on the node",
+			Deployed: true,
+		},
+		{
+			Name:     CheckNameResolution,
+			Nodes:    dnsNodes(cfg),
+			NewStep:  func() task.Step { return new(checkNameResolutionStep) },
+			Fix:      "Check nameservers and search domains of dns of the config",
+			Deployed: true,
+		},
+		{
+			// free space of data disks shrinks as data is written, so it's only checked
+			// before deployment
+			Name:    CheckCapacity,
+			Nodes:   capacityNodes(cfg),
+			NewStep: func() task.Step { return new(checkCapacityStep) },
+		},
+	}
+}
+
+// capacityNodes returns storage nodes whose capacities are cross-checked, which are none
+// unless capacities of storage nodes are set.
+func capacityNodes(cfg *config.Config) []config.Node {
+	capacities := cfg.StorageCapacities()
+	var nodes []config.Node
+	for _, node := range cfg.Nodes {
+		if _, ok := capacities[node.Name]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// dnsNodes returns nodes on which names of nodes are checked to resolve, which are none
+// unless the resolver config of nodes is managed.
+func dnsNodes(cfg *config.Config) []config.Node {
+	if cfg.DNS.IsEmpty() {
+		return nil
+	}
+	return cfg.Nodes
+}
+
+// firewallNodes returns nodes running services which other nodes connect to.
+func firewallNodes(cfg *config.Config) []config.Node {
+	var nodes []config.Node
+	for _, node := range cfg.Nodes {
+		if len(network.FirewallPorts(cfg, node.Name)) > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// registryHost returns host[:port] of the registry, which names the certs dir of it.
+func registryHost(registry string) string {
+	if i := strings.Index(registry, "://"); i >= 0 {
+		registry = registry[i+3:]
+	}
+	host, _, _ := strings.Cut(registry, "/")
+	return host
+}
+
+// certsDir returns the dir where the container runtime looks up certificates of the registry.
+// nerdctl falls back to the docker one if hosts.toml of containerd isn't configured.
+func certsDir(runtime config.ContainerRuntime, host string) string {
+	if runtime == config.ContainerRuntimePodman {
+		return path.Join("/etc/containers/certs.d", host)
+	}
+	return path.Join("/etc/docker/certs.d", host)
+}
+
+type installRegistryCertsStep struct {
+	task.BaseStep
+}
+
+func (s *installRegistryCertsStep) Execute(ctx context.Context) error {
+	tlsCfg := s.Runtime.Cfg.TLS
+	host := registryHost(s.Runtime.Cfg.Images.Registry)
+	if tlsCfg.InsecureSkipVerify {
+		s.Logger.Warnf("tls.insecureSkipVerify doesn't apply to pulling images, "+
+			"add %s to insecure registries of the container runtime on %s to skip verification",
+			host, s.Node.Name)
+	}
+
+	files := []struct {
+		src  string
+		name string
+		mode string
+	}{
+		{tlsCfg.CAFile, "ca.crt", "0644"},
+		{tlsCfg.CertFile, "client.cert", "0644"},
+		{tlsCfg.KeyFile, "client.key", "0600"},
+	}
+	runtime, err := s.Runtime.ContainerRuntime(ctx, s.Em, s.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dir := certsDir(runtime, host)
+	for _, file := range files {
+		if file.src == "" {
+			continue
+		}
+		if err = s.installFile(ctx, file.src, path.Join(dir, file.name), file.mode); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *installRegistryCertsStep) installFile(ctx context.Context, src, dst, mode string) error {
+	tmpFile, err := s.Em.FS.MkTempFile(ctx, os.TempDir())
+	if err != nil {
+		return errors.Annotate(err, "make temp file")
+	}
+	defer func() {
+		if _, err := s.Em.Runner.Exec(ctx, "rm", "-f", tmpFile); err != nil {
+			s.Logger.Errorf("Failed to remove remote file %s: %v", tmpFile, err)
+		}
+	}()
+
+	if err = s.Em.Runner.Scp(ctx, src, tmpFile); err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("Install %s to %s", src, dst)
+	if _, err = s.Em.Runner.Exec(ctx, "install", "-D", "-m", mode, tmpFile, dst); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
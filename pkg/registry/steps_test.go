@@ -0,0 +1,99 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+var suiteRun = suite.Run
+
+func TestRegistryHost(t *testing.T) {
+	suiteRun(t, &registryHostSuite{})
+}
+
+type registryHostSuite struct {
+	suite.Suite
+}
+
+func (s *registryHostSuite) Test() {
+	s.Equal("harbor.example.com", registryHost("harbor.example.com"))
+	s.Equal("harbor.example.com:5000", registryHost("harbor.example.com:5000/open3fs"))
+	s.Equal("harbor.example.com", registryHost("https://harbor.example.com/open3fs"))
+}
+
+func TestInstallRegistryCertsStep(t *testing.T) {
+	suiteRun(t, &installRegistryCertsStepSuite{})
+}
+
+type installRegistryCertsStepSuite struct {
+	ttask.StepSuite
+
+	step *installRegistryCertsStep
+}
+
+func (s *installRegistryCertsStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Images.Registry = "harbor.example.com:5000/open3fs"
+	s.Cfg.TLS = config.TLSConfig{
+		CAFile:   "/etc/m3fs/ca.crt",
+		CertFile: "/etc/m3fs/client.crt",
+		KeyFile:  "/etc/m3fs/client.key",
+	}
+	s.step = &installRegistryCertsStep{}
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, config.Node{Name: "node1"}, s.Logger)
+}
+
+func (s *installRegistryCertsStepSuite) mockInstall(src, dst, mode string) {
+	s.MockFS.On("MkTempFile", os.TempDir()).Return("/tmp/xxx", nil).Once()
+	s.MockRunner.On("Scp", src, "/tmp/xxx").Return(nil).Once()
+	s.MockRunner.On("Exec", "install", []string{"-D", "-m", mode, "/tmp/xxx", dst}).
+		Return("", nil).Once()
+}
+
+func (s *installRegistryCertsStepSuite) TestWithDocker() {
+	s.Cfg.ContainerRuntime = config.ContainerRuntimeDocker
+	dir := "/etc/docker/certs.d/harbor.example.com:5000/"
+	s.mockInstall("/etc/m3fs/ca.crt", dir+"ca.crt", "0644")
+	s.mockInstall("/etc/m3fs/client.crt", dir+"client.cert", "0644")
+	s.mockInstall("/etc/m3fs/client.key", dir+"client.key", "0600")
+	s.MockRunner.On("Exec", "rm", []string{"-f", "/tmp/xxx"}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockFS.AssertExpectations(s.T())
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *installRegistryCertsStepSuite) TestWithPodmanAndCAOnly() {
+	s.Cfg.ContainerRuntime = config.ContainerRuntimePodman
+	s.Cfg.TLS.CertFile = ""
+	s.Cfg.TLS.KeyFile = ""
+	s.mockInstall("/etc/m3fs/ca.crt", "/etc/containers/certs.d/harbor.example.com:5000/ca.crt", "0644")
+	s.MockRunner.On("Exec", "rm", []string{"-f", "/tmp/xxx"}).Return("", nil)
+
+	s.NoError(s.step.Execute(s.Ctx()))
+
+	s.MockFS.AssertExpectations(s.T())
+	s.MockRunner.AssertExpectations(s.T())
+}
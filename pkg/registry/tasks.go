@@ -0,0 +1,49 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// NeedConfigTLS returns whether the container runtime of nodes needs to be
+// configured to verify TLS certificates of the registry.
+func NeedConfigTLS(cfg *config.Config) bool {
+	if cfg.Images.Registry == "" {
+		return false
+	}
+	return cfg.TLS.CAFile != "" || cfg.TLS.CertFile != "" || cfg.TLS.InsecureSkipVerify
+}
+
+// ConfigRegistryTLSTask is a task for installing the CA and client certificate
+// of the registry for container runtimes of nodes.
+type ConfigRegistryTLSTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *ConfigRegistryTLSTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("ConfigRegistryTLSTask")
+	t.BaseTask.Init(r, logger)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:    r.Cfg.Nodes,
+			Parallel: true,
+			NewStep:  func() task.Step { return new(installRegistryCertsStep) },
+		},
+	})
+}
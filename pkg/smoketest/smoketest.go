@@ -0,0 +1,209 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package smoketest checks a deployed cluster is functional end to end, by writing a
+// file through the client and reading it back.
+package smoketest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	fsclient "github.com/open3fs/m3fs/pkg/3fs_client"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// fileNamePrefix is the prefix of the name of the test file in the mountpoint.
+const fileNamePrefix = ".m3fs-smoke-test-"
+
+// defines steps of the smoke test.
+const (
+	StepCheckMount = "check mount"
+	StepWrite      = "write file"
+	StepRead       = "read file"
+	StepReadOther  = "read from another client"
+	StepRemove     = "remove file"
+)
+
+// StepResult is the result of a step of the smoke test.
+type StepResult struct {
+	Step     string        `json:"step"`
+	Node     string        `json:"node"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	// Skipped is true if the step isn't run because a step before it failed.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// Failed returns whether the step failed.
+func (r *StepResult) Failed() bool {
+	return r.Error != ""
+}
+
+// SmokeTest writes a small file into the mountpoint of the client on a client node,
+// reads it back, reads it from another client node if there's one, then removes it.
+type SmokeTest struct {
+	runtime *task.Runtime
+
+	nodeManager func(config.Node, log.Interface) (*external.Manager, error)
+	newContent  func() (string, error)
+}
+
+// NewSmokeTest creates a smoke test of the cluster of the runtime.
+func NewSmokeTest(r *task.Runtime) *SmokeTest {
+	return &SmokeTest{
+		runtime:     r,
+		nodeManager: r.NodeManager,
+		newContent:  randomContent,
+	}
+}
+
+// randomContent returns random content of the test file, so that stale content of a
+// former test is never taken as the content written.
+func randomContent() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", errors.Trace(err)
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// smokeStep is a step of the smoke test, a cleanup step runs even if a step before
+// it failed.
+type smokeStep struct {
+	name    string
+	node    config.Node
+	cleanup bool
+	run     func(context.Context, *external.Manager) error
+}
+
+// Run runs the smoke test, results are in the order of steps. The test file is removed
+// even if a step fails.
+func (t *SmokeTest) Run(ctx context.Context) ([]*StepResult, error) {
+	var nodes []config.Node
+	for _, node := range t.runtime.Cfg.Nodes {
+		if slices.Contains(t.runtime.Services.Client.Nodes, node.Name) {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no client node, the smoke test reads and writes files through the client")
+	}
+	content, err := t.newContent()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	mp := t.runtime.Services.Client.HostMountpoint
+	filePath := path.Join(mp, fileNamePrefix+content)
+
+	steps := []smokeStep{
+		{name: StepCheckMount, node: nodes[0], run: t.checkMount},
+		{name: StepWrite, node: nodes[0], run: func(ctx context.Context, em *external.Manager) error {
+			script := fmt.Sprintf("printf %%s %s > %s", content, external.ShellQuote(filePath))
+			_, err := em.Runner.Exec(ctx, "bash", "-c", external.ShellQuote(script))
+			return errors.Annotatef(err, "write %s", filePath)
+		}},
+		{name: StepRead, node: nodes[0], run: func(ctx context.Context, em *external.Manager) error {
+			return errors.Trace(readFile(ctx, em, filePath, content))
+		}},
+	}
+	if len(nodes) > 1 {
+		steps = append(steps,
+			smokeStep{name: StepCheckMount, node: nodes[1], run: t.checkMount},
+			smokeStep{name: StepReadOther, node: nodes[1], run: func(ctx context.Context, em *external.Manager) error {
+				return errors.Trace(readFile(ctx, em, filePath, content))
+			}})
+	}
+	steps = append(steps, smokeStep{name: StepRemove, node: nodes[0], cleanup: true,
+		run: func(ctx context.Context, em *external.Manager) error {
+			_, err := em.Runner.Exec(ctx, "rm", "-f", external.ShellQuote(filePath))
+			return errors.Annotatef(err, "remove %s", filePath)
+		}})
+
+	var (
+		results = make([]*StepResult, 0, len(steps))
+		failed  bool
+		written bool
+	)
+	for _, step := range steps {
+		result := &StepResult{Step: step.name, Node: step.node.Name}
+		results = append(results, result)
+		// the file is removed if writing it has been tried, it may be written partially
+		if (failed && !step.cleanup) || (step.cleanup && !written) {
+			result.Skipped = true
+			continue
+		}
+		if step.name == StepWrite {
+			written = true
+		}
+		if err = t.runStep(ctx, step, result); err != nil {
+			result.Error = err.Error()
+			failed = true
+		}
+	}
+	return results, nil
+}
+
+func (t *SmokeTest) runStep(ctx context.Context, step smokeStep, result *StepResult) error {
+	logger := log.Logger.Subscribe(log.FieldKeyNode, step.node.Name)
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+	em, err := t.nodeManager(step.node, logger)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = step.run(ctx, em); err != nil {
+		logger.Warnf("Smoke test step %s failed: %v", step.name, err)
+		return errors.Trace(err)
+	}
+	logger.Infof("Smoke test step %s passed in %s", step.name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// checkMount checks the mountpoint of the client is mounted by 3fs.
+func (t *SmokeTest) checkMount(ctx context.Context, em *external.Manager) error {
+	mp := t.runtime.Services.Client.HostMountpoint
+	fsType, err := fsclient.MountedFsType(ctx, em, mp)
+	switch {
+	case err != nil:
+		return errors.Trace(err)
+	case fsType == "":
+		return errors.Errorf("%s is not mounted", mp)
+	case !fsclient.IsFuseFsType(fsType):
+		return errors.Errorf("%s is mounted by %s instead of 3fs", mp, fsType)
+	}
+	return nil
+}
+
+// readFile reads the file and verifies its content.
+func readFile(ctx context.Context, em *external.Manager, filePath, content string) error {
+	out, err := em.Runner.Exec(ctx, "cat", external.ShellQuote(filePath))
+	if err != nil {
+		return errors.Annotatef(err, "read %s", filePath)
+	}
+	if got := strings.TrimSpace(out); got != content {
+		return errors.Errorf("content of %s is %q, expected %q", filePath, got, content)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smoketest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	ttask "github.com/open3fs/m3fs/tests/task"
+)
+
+var suiteRun = suite.Run
+
+func TestSmokeTest(t *testing.T) {
+	suiteRun(t, &smokeTestSuite{})
+}
+
+type smokeTestSuite struct {
+	ttask.StepSuite
+
+	smokeTest *SmokeTest
+	nodes     []string
+}
+
+const (
+	testContent  = "0123456789abcdef"
+	testFilePath = "'/mnt/3fs/.m3fs-smoke-test-0123456789abcdef'"
+	testMounts   = "hf3fs.test /mnt/3fs fuse.hf3fs rw 0 0\n"
+)
+
+func (s *smokeTestSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.Cfg.Nodes = []config.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}}
+	s.Cfg.Services.Client.Nodes = []string{"node2", "node3"}
+	s.SetupRuntime()
+
+	s.nodes = nil
+	s.smokeTest = NewSmokeTest(s.Runtime)
+	s.smokeTest.nodeManager = func(node config.Node, _ log.Interface) (*external.Manager, error) {
+		s.nodes = append(s.nodes, node.Name)
+		return s.MockEm, nil
+	}
+	s.smokeTest.newContent = func() (string, error) { return testContent, nil }
+}
+
+func (s *smokeTestSuite) mockWrite(err error) {
+	s.MockRunner.On("Exec", "bash", []string{"-c",
+		`'printf %s 0123456789abcdef > '\''/mnt/3fs/.m3fs-smoke-test-0123456789abcdef'\'''`}).Return("", err)
+}
+
+func (s *smokeTestSuite) TestRun() {
+	s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).Return(testMounts, nil)
+	s.mockWrite(nil)
+	s.MockRunner.On("Exec", "cat", []string{testFilePath}).Return(testContent+"\n", nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", testFilePath}).Return("", nil)
+
+	results, err := s.smokeTest.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Len(results, 6)
+	for i, step := range []string{StepCheckMount, StepWrite, StepRead, StepCheckMount, StepReadOther, StepRemove} {
+		s.Equal(step, results[i].Step)
+		s.False(results[i].Failed(), results[i].Error)
+		s.False(results[i].Skipped)
+	}
+	s.Equal([]string{"node2", "node2", "node2", "node3", "node3", "node2"}, s.nodes)
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *smokeTestSuite) TestReadMismatch() {
+	s.Cfg.Services.Client.Nodes = []string{"node2"}
+	s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).Return(testMounts, nil)
+	s.mockWrite(nil)
+	s.MockRunner.On("Exec", "cat", []string{testFilePath}).Return("stale", nil)
+	s.MockRunner.On("Exec", "rm", []string{"-f", testFilePath}).Return("", nil)
+
+	results, err := s.smokeTest.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Len(results, 4)
+	s.Equal(`content of /mnt/3fs/.m3fs-smoke-test-0123456789abcdef is "stale", expected "0123456789abcdef"`,
+		results[2].Error)
+	// the file is removed even if reading it failed
+	s.Equal(StepRemove, results[3].Step)
+	s.False(results[3].Skipped)
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *smokeTestSuite) TestNotMounted() {
+	s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).Return("/dev/sda1 / ext4 rw 0 0\n", nil)
+
+	results, err := s.smokeTest.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Equal("/mnt/3fs is not mounted", results[0].Error)
+	for _, result := range results[1:] {
+		s.True(result.Skipped, result.Step)
+	}
+	s.MockRunner.AssertNotCalled(s.T(), "Exec", "rm", []string{"-f", testFilePath})
+}
+
+func (s *smokeTestSuite) TestWriteFailed() {
+	s.MockRunner.On("Exec", "cat", []string{"/proc/mounts"}).Return(testMounts, nil)
+	s.mockWrite(errors.New("no space"))
+	s.MockRunner.On("Exec", "rm", []string{"-f", testFilePath}).Return("", nil)
+
+	results, err := s.smokeTest.Run(s.Ctx())
+	s.NoError(err)
+
+	s.Contains(results[1].Error, "no space")
+	s.True(results[2].Skipped)
+	s.False(results[5].Skipped)
+	s.MockRunner.AssertExpectations(s.T())
+}
+
+func (s *smokeTestSuite) TestWithoutClient() {
+	s.Cfg.Services.Client.Nodes = nil
+
+	_, err := s.smokeTest.Run(s.Ctx())
+	s.Error(err)
+}
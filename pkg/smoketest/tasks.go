@@ -0,0 +1,62 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smoketest
+
+import (
+	"context"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/task"
+)
+
+// TaskName is the name of SmokeTestTask.
+const TaskName = "SmokeTestTask"
+
+// SmokeTestTask is a task verifying the deployed cluster in the verify phase. It waits
+// services whose readiness checks are deferred, then runs the smoke test.
+type SmokeTestTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *SmokeTestTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName(TaskName)
+	t.BaseTask.SetPhase(task.PhaseVerify)
+	t.BaseTask.SetDeps("Create3FSClientServiceTask")
+	t.BaseTask.Init(r, logger)
+}
+
+// Run runs the task.
+func (t *SmokeTestTask) Run(ctx context.Context) error {
+	if err := t.Runtime.WaitDeferredReadiness(ctx); err != nil {
+		return errors.Trace(err)
+	}
+	results, err := NewSmokeTest(t.Runtime).Run(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(CheckResults(results))
+}
+
+// CheckResults returns an error of the first failed step.
+func CheckResults(results []*StepResult) error {
+	for _, result := range results {
+		if result.Failed() {
+			return errors.Errorf("smoke test failed: %s on %s: %s", result.Step, result.Node, result.Error)
+		}
+	}
+	return nil
+}
@@ -63,6 +63,11 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	task.RegisterTemplate("storage_main_app.toml.tmpl", &StorageMainAppTomlTmpl)
+	task.RegisterTemplate("storage_main_launcher.toml.tmpl", &StorageMainLauncherTomlTmpl)
+	task.RegisterTemplate("storage_main.toml.tmpl", &StorageMainTomlTmpl)
+	task.RegisterTemplate("disk_tool.sh.tmpl", &DiskToolScriptTmpl)
 }
 
 func makeTargetPaths(diskNum int) string {
@@ -79,42 +84,105 @@ const (
 	// ServiceName is the name of the storage service.
 	ServiceName = "storage_main"
 	serviceType = "STORAGE"
+	// NodeIDBegin is the 3fs node id of the first storage node, ids of storage nodes are
+	// consecutive in order of nodes of the service.
+	NodeIDBegin = 10001
 )
 
 func getServiceWorkDir(workDir string) string {
 	return path.Join(workDir, "storage")
 }
 
-// CreateStorageServiceTask is a task for creating 3fs storage services.
-type CreateStorageServiceTask struct {
+// DataDir returns the dir in which data disks are mounted on storage nodes.
+func DataDir(workDir string) string {
+	return path.Join(getServiceWorkDir(workDir), "3fsdata")
+}
+
+func newPrepareConfigSetup(r *task.Runtime) *steps.Prepare3FSConfigStepSetup {
+	storage := r.Services.Storage
+	return &steps.Prepare3FSConfigStepSetup{
+		Service:              ServiceName,
+		ServiceWorkDir:       getServiceWorkDir(r.WorkDir),
+		MainAppTomlTmpl:      StorageMainAppTomlTmpl,
+		MainLauncherTomlTmpl: StorageMainLauncherTomlTmpl,
+		MainTomlTmpl:         StorageMainTomlTmpl,
+		RDMAListenPort:       storage.RDMAListenPort,
+		TCPListenPort:        storage.TCPListenPort,
+		ExtraMainTomlData: map[string]any{
+			"TargetPaths": makeTargetPaths(storage.DiskNumPerNode),
+		},
+		ExtraConfig: storage.ExtraConfig,
+	}
+}
+
+func newRunContainerSetup(r *task.Runtime) *steps.Run3FSContainerStepSetup {
+	workDir := getServiceWorkDir(r.WorkDir)
+	return &steps.Run3FSContainerStepSetup{
+		ImgName:        config.ImageName3FS,
+		ContainerName:  r.Services.Storage.ContainerName,
+		Service:        ServiceName,
+		ServiceType:    config.ServiceStorage,
+		WorkDir:        workDir,
+		UseRdmaNetwork: true,
+		ListenPort:     r.Services.Storage.TCPListenPort,
+		ExtraVolumes: []*external.VolumeArgs{
+			{
+				Source: DataDir(r.WorkDir),
+				Target: "/mnt/3fsdata",
+			},
+		},
+	}
+}
+
+// ConfigRenderer renders config files of storage service.
+var ConfigRenderer = &task.ConfigRenderer{
+	Service: config.ServiceStorage,
+	Prepare: func(r *task.Runtime) error {
+		steps.Gen3FSNodeIDs(r, ServiceName, NodeIDBegin, r.Services.Storage.Nodes)
+		return nil
+	},
+	Render: func(r *task.Runtime, node config.Node) ([]*task.RenderedFile, error) {
+		return steps.Render3FSConfigs(r, newPrepareConfigSetup(r), node)
+	},
+}
+
+// NewPrepareStorageDisksTasks generates a task preparing data disks for each storage node,
+// so progress of nodes is shown individually. Storage services are created by
+// CreateStorageServiceTask after all of them.
+var NewPrepareStorageDisksTasks = task.PerNode(
+	func(cfg *config.Config) []string { return cfg.Services.Storage.Nodes },
+	func(node string) task.Interface { return &PrepareStorageDisksTask{Node: node} },
+)
+
+func prepareDisksTaskName(node string) string {
+	return fmt.Sprintf("PrepareStorageDisksTask[%s]", node)
+}
+
+// PrepareStorageDisksTask is a task for preparing data disks of the storage service on a node.
+type PrepareStorageDisksTask struct {
 	task.BaseTask
+
+	// Node is the name of the storage node whose disks are prepared.
+	Node string
 }
 
 // Init initializes the task.
-func (t *CreateStorageServiceTask) Init(r *task.Runtime, logger log.Interface) {
-	t.BaseTask.SetName("CreateStorageServiceTask")
+func (t *PrepareStorageDisksTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName(prepareDisksTaskName(t.Node))
+	t.BaseTask.SetService(config.ServiceStorage)
 	t.BaseTask.Init(r, logger)
 
 	storage := r.Cfg.Services.Storage
 	workDir := getServiceWorkDir(r.WorkDir)
-	nodes := make([]config.Node, len(storage.Nodes))
-	for i, node := range storage.Nodes {
-		nodes[i] = r.Nodes[node]
-	}
 	t.SetSteps([]task.StepConfig{
 		{
-			Nodes:   []config.Node{nodes[0]},
-			NewStep: steps.NewGen3FSNodeIDStepFunc(ServiceName, 10001, storage.Nodes),
-		},
-		{
-			Nodes:    nodes,
-			Parallel: true,
+			Nodes: []config.Node{r.Nodes[t.Node]},
 			NewStep: steps.NewRemoteRunScriptStepFunc(
 				workDir,
 				"disk_tool.sh",
 				DiskToolScriptTmpl,
 				map[string]any{
-					"SectorSize": t.Runtime.Cfg.Services.Storage.SectorSize,
+					"SectorSize": storage.SectorSize,
 				},
 				[]string{
 					workDir,
@@ -123,21 +191,39 @@ func (t *CreateStorageServiceTask) Init(r *task.Runtime, logger log.Interface) {
 					"prepare",
 				}),
 		},
+	})
+}
+
+// CreateStorageServiceTask is a task for creating 3fs storage services.
+type CreateStorageServiceTask struct {
+	task.BaseTask
+}
+
+// Init initializes the task.
+func (t *CreateStorageServiceTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("CreateStorageServiceTask")
+	t.BaseTask.SetService(config.ServiceStorage)
+	deps := []string{"CreateMgmtdServiceTask"}
+	for _, node := range r.Cfg.Services.Storage.Nodes {
+		deps = append(deps, prepareDisksTaskName(node))
+	}
+	t.BaseTask.SetDeps(deps...)
+	t.BaseTask.Init(r, logger)
+
+	storage := r.Cfg.Services.Storage
+	workDir := getServiceWorkDir(r.WorkDir)
+	nodes := make([]config.Node, len(storage.Nodes))
+	for i, node := range storage.Nodes {
+		nodes[i] = r.Nodes[node]
+	}
+	t.SetSteps([]task.StepConfig{
 		{
-			Nodes:    nodes,
-			Parallel: true,
-			NewStep: steps.NewPrepare3FSConfigStepFunc(&steps.Prepare3FSConfigStepSetup{
-				Service:              ServiceName,
-				ServiceWorkDir:       workDir,
-				MainAppTomlTmpl:      StorageMainAppTomlTmpl,
-				MainLauncherTomlTmpl: StorageMainLauncherTomlTmpl,
-				MainTomlTmpl:         StorageMainTomlTmpl,
-				RDMAListenPort:       storage.RDMAListenPort,
-				TCPListenPort:        storage.TCPListenPort,
-				ExtraMainTomlData: map[string]any{
-					"TargetPaths": makeTargetPaths(storage.DiskNumPerNode),
-				},
-			}),
+			Nodes:   []config.Node{nodes[0]},
+			NewStep: steps.NewGen3FSNodeIDStepFunc(ServiceName, NodeIDBegin, storage.Nodes),
+		},
+		{
+			Nodes:   []config.Node{nodes[0]},
+			NewStep: steps.NewDistribute3FSConfigStepFunc(newPrepareConfigSetup(r), nodes),
 		},
 		{
 			Nodes: []config.Node{nodes[0]},
@@ -152,20 +238,33 @@ func (t *CreateStorageServiceTask) Init(r *task.Runtime, logger log.Interface) {
 		{
 			Nodes:    nodes,
 			Parallel: true,
-			NewStep: steps.NewRun3FSContainerStepFunc(
-				&steps.Run3FSContainerStepSetup{
-					ImgName:        config.ImageName3FS,
-					ContainerName:  storage.ContainerName,
-					Service:        ServiceName,
-					WorkDir:        workDir,
-					UseRdmaNetwork: true,
-					ExtraVolumes: []*external.VolumeArgs{
-						{
-							Source: path.Join(workDir, "3fsdata"),
-							Target: "/mnt/3fsdata",
-						},
-					},
-				}),
+			Rollout:  true,
+			NewStep:  steps.NewRun3FSContainerStepFunc(newRunContainerSetup(r)),
+		},
+	})
+}
+
+// UpgradeStorageServiceTask is a task for upgrading 3fs storage services to the image in the
+// config. Nodes are upgraded one by one, each one must be ready before the next one, unless
+// the deployment strategy of the config is set.
+type UpgradeStorageServiceTask struct {
+	task.BaseTask
+
+	// Nodes are names of nodes to upgrade, all nodes of the service are upgraded if it's empty.
+	Nodes []string
+}
+
+// Init initializes the task.
+func (t *UpgradeStorageServiceTask) Init(r *task.Runtime, logger log.Interface) {
+	t.BaseTask.SetName("UpgradeStorageServiceTask")
+	t.BaseTask.SetService(config.ServiceStorage)
+	t.BaseTask.Init(r, logger)
+	nodes := r.SelectNodes(r.Cfg.Services.Storage.Nodes, t.Nodes)
+	t.SetSteps([]task.StepConfig{
+		{
+			Nodes:   nodes,
+			Rollout: true,
+			NewStep: steps.NewUpgrade3FSContainerStepFunc(newRunContainerSetup(r), nil),
 		},
 	})
 }
@@ -178,6 +277,8 @@ type DeleteStorageServiceTask struct {
 // Init initializes the task.
 func (t *DeleteStorageServiceTask) Init(r *task.Runtime, logger log.Interface) {
 	t.BaseTask.SetName("DeleteStorageServiceTask")
+	t.BaseTask.SetService(config.ServiceStorage)
+	t.BaseTask.SetDeps("Delete3FSClientServiceTask")
 	t.BaseTask.Init(r, logger)
 	nodes := make([]config.Node, len(r.Cfg.Services.Storage.Nodes))
 	for i, node := range r.Cfg.Services.Storage.Nodes {
@@ -192,6 +293,7 @@ func (t *DeleteStorageServiceTask) Init(r *task.Runtime, logger log.Interface) {
 			NewStep: steps.NewRm3FSContainerStepFunc(
 				r.Services.Storage.ContainerName,
 				ServiceName,
+				config.ServiceStorage,
 				workDir),
 		},
 		{
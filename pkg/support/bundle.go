@@ -0,0 +1,202 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// NotesFileName is the name of the file of notes of things failing to be gathered.
+const NotesFileName = "NOTES.txt"
+
+// scrubbedText replaces secrets in files of bundles.
+const scrubbedText = "xxxxx"
+
+// tokenRegexes match tokens in files which aren't known secrets, groups around the
+// token are kept.
+var tokenRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+()`),
+	regexp.MustCompile(`(?i)(authorization:\s*basic\s+)[A-Za-z0-9+/=]+()`),
+	regexp.MustCompile(`(://[^/:@\s]+:)[^/@\s]+(@)`),
+}
+
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// Bundle is a support archive of files, secrets and tokens in them are scrubbed. Failures
+// of gathering files are noted in the bundle instead of failing it, so that it can
+// gather whatever exists.
+type Bundle struct {
+	// Dir is the directory in the archive which files are put in.
+	Dir string
+
+	secrets []string
+	files   []bundleFile
+	notes   []string
+	now     time.Time
+}
+
+// NewBundle creates a bundle scrubbing the secrets.
+func NewBundle(dir string, secrets []string, now time.Time) *Bundle {
+	secrets = slices.DeleteFunc(slices.Clone(secrets), func(secret string) bool { return secret == "" })
+	// scrub longer secrets first, in case a secret contains another
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+	return &Bundle{Dir: dir, secrets: secrets, now: now}
+}
+
+// Scrub replaces secrets and tokens in the data.
+func (b *Bundle) Scrub(data []byte) []byte {
+	for _, secret := range b.secrets {
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(scrubbedText))
+	}
+	for _, re := range tokenRegexes {
+		data = re.ReplaceAll(data, []byte("${1}"+scrubbedText+"${2}"))
+	}
+	return data
+}
+
+// Add adds the file of the data to the bundle.
+func (b *Bundle) Add(name string, data []byte) {
+	b.files = append(b.files, bundleFile{name: name, data: b.Scrub(data)})
+}
+
+// AddRedacted adds the file of the data whose secrets are redacted already to the bundle,
+// it isn't scrubbed in case secrets are common words like keys of the data.
+func (b *Bundle) AddRedacted(name string, data []byte) {
+	b.files = append(b.files, bundleFile{name: name, data: data})
+}
+
+// AddJSON adds the file of the value encoded in JSON to the bundle.
+func (b *Bundle) AddJSON(name string, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		b.Notef("encode %s: %v", name, err)
+		return
+	}
+	b.Add(name, append(data, '\n'))
+}
+
+// AddFile adds the file of the path to the bundle, read by read which is os.ReadFile if
+// it's nil. A missing file is skipped.
+func (b *Bundle) AddFile(name, filePath string, read func(string) ([]byte, error)) {
+	if read == nil {
+		read = os.ReadFile
+	}
+	data, err := read(filePath)
+	if err != nil {
+		if !os.IsNotExist(errors.Cause(err)) {
+			b.Notef("read %s: %v", filePath, err)
+		}
+		return
+	}
+	b.Add(name, data)
+}
+
+// AddDir adds files under the directory to the bundle in the directory of the name,
+// files are read by read like AddFile. Entries are skipped if skip returns true for
+// their paths relative to the directory, a skipped directory is skipped entirely.
+func (b *Bundle) AddDir(name, dir string, read func(string) ([]byte, error), skip func(rel string) bool) {
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filePath == dir {
+				return nil
+			}
+			b.Notef("walk %s: %v", filePath, err)
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil || rel == "." {
+			return nil
+		}
+		if skip != nil && skip(filepath.ToSlash(rel)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			b.AddFile(path.Join(name, filepath.ToSlash(rel)), filePath, read)
+		}
+		return nil
+	})
+	if err != nil {
+		b.Notef("walk %s: %v", dir, err)
+	}
+}
+
+// Notef notes a failure of gathering, notes are written into NotesFileName.
+func (b *Bundle) Notef(format string, args ...any) {
+	b.notes = append(b.notes, string(b.Scrub([]byte(fmt.Sprintf(format, args...)))))
+}
+
+// Notes returns notes of the bundle.
+func (b *Bundle) Notes() []string {
+	return b.notes
+}
+
+// Names returns names of files of the bundle in the order of adding.
+func (b *Bundle) Names() []string {
+	names := make([]string, len(b.files))
+	for i, f := range b.files {
+		names[i] = f.name
+	}
+	return names
+}
+
+// Write writes the bundle as a gzipped tarball to out.
+func (b *Bundle) Write(out io.Writer) error {
+	files := b.files
+	if len(b.notes) > 0 {
+		files = append(slices.Clone(files),
+			bundleFile{name: NotesFileName, data: []byte(strings.Join(b.notes, "\n") + "\n")})
+	}
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		header := &tar.Header{
+			Name:    path.Join(b.Dir, f.name),
+			Mode:    0644,
+			Size:    int64(len(f.data)),
+			ModTime: b.now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(gw.Close())
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+// bannerServices lists services shown in the banner in deploy order.
+var bannerServices = []config.ServiceType{
+	config.ServiceFdb,
+	config.ServiceClickhouse,
+	config.ServiceMonitor,
+	config.ServiceMgmtd,
+	config.ServiceMeta,
+	config.ServiceStorage,
+	config.ServiceClient,
+}
+
+// Banner returns the summary of the cluster the runner is going to operate, which
+// helps operators to confirm the config before any task runs.
+func (r *Runner) Banner() string {
+	version := common.Version
+	if version == "" {
+		version = "unknown"
+	}
+	if len(common.GitSha) >= 7 {
+		version = fmt.Sprintf("%s (%s)", version, common.GitSha[:7])
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Cluster:\t%s\n", r.cfg.Name)
+	if r.command != "" {
+		fmt.Fprintf(w, "Command:\t%s\n", r.command)
+	}
+	if r.runID != "" {
+		fmt.Fprintf(w, "Run ID:\t%s\n", r.runID)
+	}
+	fmt.Fprintf(w, "m3fs version:\t%s\n", version)
+	fmt.Fprintf(w, "Work dir:\t%s\n", r.cfg.WorkDir)
+	fmt.Fprintf(w, "Nodes:\t%d\n", len(r.cfg.Nodes))
+	if r.Runtime != nil && len(r.Runtime.CordonedNodes) > 0 {
+		fmt.Fprintf(w, "Cordoned:\t%s (skipped)\n", strings.Join(r.Runtime.CordonedNodes, ","))
+	}
+	fmt.Fprintln(w, "Services:")
+	for _, service := range bannerServices {
+		nodes := r.cfg.Services.ServiceNodes(service)
+		if len(nodes) == 0 {
+			continue
+		}
+		img, err := r.cfg.Images.GetImage(config.ServiceImageName(service))
+		if err != nil {
+			img = "unknown"
+		}
+		fmt.Fprintf(w, "  %s\t%d node(s)\t%s\t%s\n", config.ServiceDisplayNames[service], len(nodes), img,
+			r.cfg.Services.Resources(service))
+		if service == config.ServiceClickhouse && r.cfg.Services.Clickhouse.Replicated() {
+			fmt.Fprintf(w, "    topology\t%s\n", r.cfg.Services.Clickhouse.TopologyString())
+		}
+	}
+	_ = w.Flush()
+
+	line := strings.Repeat("=", 60)
+	return fmt.Sprintf("%s\n%s%s\n", line, sb.String(), line)
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+// nodeCommandStats returns latency stats of commands of the node, which are shared by
+// all managers of the node.
+func (r *Runtime) nodeCommandStats(node string) *external.CommandStats {
+	r.commandStatsMu.Lock()
+	defer r.commandStatsMu.Unlock()
+	if r.commandStats == nil {
+		r.commandStats = make(map[string]*external.CommandStats)
+	}
+	stats, ok := r.commandStats[node]
+	if !ok {
+		stats = external.NewCommandStats()
+		r.commandStats[node] = stats
+	}
+	return stats
+}
+
+// CommandStats returns summaries of latencies of commands run on nodes, sorted by node
+// names. Nodes without commands are excluded.
+func (r *Runtime) CommandStats() []external.StatsSummary {
+	r.commandStatsMu.Lock()
+	defer r.commandStatsMu.Unlock()
+	var summaries []external.StatsSummary
+	for _, node := range slices.Sorted(maps.Keys(r.commandStats)) {
+		if summary := r.commandStats[node].Summary(node); summary.Count > 0 {
+			summaries = append(summaries, summary)
+		}
+	}
+	return summaries
+}
+
+// SetLogCommandStats logs latencies of commands of nodes at the end of the run, the
+// slowest nodes first.
+func (r *Runner) SetLogCommandStats(logStats bool) {
+	r.logCommandStats = logStats
+}
+
+func (r *Runner) printCommandStats() {
+	summaries := r.Runtime.CommandStats()
+	slices.SortStableFunc(summaries, func(a, b external.StatsSummary) int {
+		return cmp.Compare(b.P95, a.P95)
+	})
+	for _, summary := range summaries {
+		logrus.Infof("Command stats of %s", summary)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"slices"
+	"time"
+)
+
+// Cordon records a node cordoned to exclude it from deployment and upgrade, until
+// it's uncordoned.
+type Cordon struct {
+	Node string    `json:"node"`
+	Time time.Time `json:"time"`
+}
+
+// Cordoned returns whether the node is cordoned.
+func (s *ClusterState) Cordoned(node string) bool {
+	return slices.ContainsFunc(s.Cordons, func(c *Cordon) bool { return c.Node == node })
+}
+
+// CordonNode cordons the node, it returns false if the node has been cordoned.
+func (s *ClusterState) CordonNode(node string) bool {
+	if s.Cordoned(node) {
+		return false
+	}
+	s.Cordons = append(s.Cordons, &Cordon{Node: node, Time: time.Now()})
+	return true
+}
+
+// UncordonNode uncordons the node, it returns false if the node isn't cordoned.
+func (s *ClusterState) UncordonNode(node string) bool {
+	n := len(s.Cordons)
+	s.Cordons = slices.DeleteFunc(s.Cordons, func(c *Cordon) bool { return c.Node == node })
+	return len(s.Cordons) < n
+}
+
+// CordonedNodes returns names of cordoned nodes in order of cordoning.
+func (s *ClusterState) CordonedNodes() []string {
+	nodes := make([]string, len(s.Cordons))
+	for i, c := range s.Cordons {
+		nodes[i] = c.Node
+	}
+	return nodes
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+// defines phases of distributing files to nodes
+const (
+	// DistributePhaseStage copies files to staged paths next to their paths.
+	DistributePhaseStage = "stage"
+	// DistributePhaseVerify compares sha256 hashes of staged files to rendered ones.
+	DistributePhaseVerify = "verify"
+	// DistributePhaseCommit moves staged files into place.
+	DistributePhaseCommit = "commit"
+)
+
+// suffixes of paths of staged files and backups of replaced files
+const (
+	stagedFileSuffix = ".m3fs-staged"
+	backupFileSuffix = ".m3fs-backup"
+)
+
+// distributeCleanupTimeout bounds cleaning up and rolling back a failed distribution,
+// which run even if the distribution is canceled, e.g. by Ctrl-C.
+var distributeCleanupTimeout = 2 * time.Minute
+
+// cleanupContext returns a context for cleaning up after the context is done, it keeps
+// values of the context, e.g. the task journaling commands.
+func cleanupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), distributeCleanupTimeout)
+}
+
+// DistributeError is the error of distributing files, Phase is the phase in which the
+// node failed. Files of all nodes are rolled back unless RollbackErr is set.
+type DistributeError struct {
+	Phase string
+	Node  string
+	// Failed is the number of nodes failed in the phase.
+	Failed      int
+	Err         error
+	RollbackErr error
+}
+
+func (e *DistributeError) Error() string {
+	msg := fmt.Sprintf("%s phase of distributing files failed on node %s", e.Phase, e.Node)
+	if e.Failed > 1 {
+		msg += fmt.Sprintf(" and %d other nodes", e.Failed-1)
+	}
+	msg += fmt.Sprintf(": %v", e.Err)
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf(", roll back failed: %v", e.RollbackErr)
+	}
+	return msg
+}
+
+// Unwrap returns the error of the node.
+func (e *DistributeError) Unwrap() error {
+	return e.Err
+}
+
+// NodeFiles are rendered files distributed to the node.
+type NodeFiles struct {
+	Node  config.Node
+	Files []*RenderedFile
+}
+
+// distribution distributes files to nodes.
+type distribution struct {
+	r        *Runtime
+	nodes    []NodeFiles
+	parallel int
+	localDir string
+
+	mu  sync.Mutex
+	ems map[string]*external.Manager
+	// committed are files moved into place of nodes.
+	committed map[string][]*RenderedFile
+}
+
+// DistributeFiles distributes files to their nodes in two phases, so that either all
+// nodes get the files or none do. Files are staged next to their paths and verified on
+// all nodes first, staged files are removed if any node fails. Then staged files are
+// moved into place on all nodes, files replaced on all nodes are restored from their
+// backups if any node fails to commit. The returned error is a *DistributeError if a
+// node fails.
+func DistributeFiles(ctx context.Context, r *Runtime, nodes []NodeFiles, parallel int) error {
+	d := &distribution{
+		r:         r,
+		nodes:     nodes,
+		parallel:  parallel,
+		ems:       make(map[string]*external.Manager, len(nodes)),
+		committed: make(map[string][]*RenderedFile, len(nodes)),
+	}
+	localDir, err := r.LocalEm.FS.MkdirTemp(ctx, r.LocalTempDir(), "distribute-files")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		if err := r.LocalEm.FS.RemoveAll(context.WithoutCancel(ctx), localDir); err != nil {
+			log.Logger.Warnf("Failed to remove temporary directory %s: %v", localDir, err)
+		}
+	}()
+	d.localDir = localDir
+
+	distErr := d.run(ctx, DistributePhaseStage, d.stage)
+	if distErr == nil {
+		distErr = d.run(ctx, DistributePhaseVerify, d.verify)
+	}
+	if distErr != nil {
+		cctx, cancel := cleanupContext(ctx)
+		defer cancel()
+		if err := d.run(cctx, "clean up", d.removeStaged); err != nil {
+			distErr.RollbackErr = err
+		}
+		return distErr
+	}
+	if distErr = d.run(ctx, DistributePhaseCommit, d.commit); distErr != nil {
+		cctx, cancel := cleanupContext(ctx)
+		defer cancel()
+		if err := d.run(cctx, "rollback", d.rollback); err != nil {
+			distErr.RollbackErr = err
+		}
+		return distErr
+	}
+	if err := d.run(ctx, "clean up", d.removeBackups); err != nil {
+		log.Logger.Warnf("Failed to remove backups of replaced files: %v", err)
+	}
+	return nil
+}
+
+// run runs the phase on all nodes, the error of the first failed node is returned.
+func (d *distribution) run(ctx context.Context, phase string,
+	do func(context.Context, NodeFiles) error) *DistributeError {
+
+	var mu sync.Mutex
+	failed := make(map[string]error)
+	workerPool := common.NewWorkerPool(func(ctx context.Context, nf NodeFiles) error {
+		if err := do(ctx, nf); err != nil {
+			mu.Lock()
+			failed[nf.Node.Name] = err
+			mu.Unlock()
+		}
+		return nil
+	}, max(min(d.parallel, len(d.nodes)), 1))
+	workerPool.Start(ctx)
+	for _, nf := range d.nodes {
+		workerPool.Add(nf)
+	}
+	workerPool.Join()
+
+	for _, nf := range d.nodes {
+		if err, ok := failed[nf.Node.Name]; ok {
+			return &DistributeError{Phase: phase, Node: nf.Node.Name, Failed: len(failed), Err: err}
+		}
+	}
+	return nil
+}
+
+func (d *distribution) nodeManager(node config.Node) (*external.Manager, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if em, ok := d.ems[node.Name]; ok {
+		return em, nil
+	}
+	em, err := d.r.NodeManager(node, log.Logger.Subscribe(log.FieldKeyNode, node.Name))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	d.ems[node.Name] = em
+	return em, nil
+}
+
+func (d *distribution) stage(ctx context.Context, nf NodeFiles) error {
+	em, err := d.nodeManager(nf.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	localDir := path.Join(d.localDir, nf.Node.Name)
+	if err = os.MkdirAll(localDir, 0755); err != nil {
+		return errors.Trace(err)
+	}
+	for i, file := range nf.Files {
+		// files of a node may have the same base name in different dirs
+		localPath := path.Join(localDir, fmt.Sprintf("%d-%s", i, path.Base(file.Path)))
+		if err = d.r.LocalEm.FS.WriteFile(localPath, file.Data, 0644); err != nil {
+			return errors.Trace(err)
+		}
+		if err = em.FS.MkdirAll(ctx, path.Dir(file.Path)); err != nil {
+			return errors.Trace(err)
+		}
+		// backups left by an interrupted distribution mustn't be restored
+		if _, err = em.Runner.Exec(ctx, "rm", "-f", file.Path+backupFileSuffix); err != nil {
+			return errors.Trace(err)
+		}
+		if err = em.Runner.Scp(ctx, localPath, file.Path+stagedFileSuffix); err != nil {
+			return errors.Annotatef(err, "stage %s", file.Path)
+		}
+	}
+	return nil
+}
+
+func (d *distribution) verify(ctx context.Context, nf NodeFiles) error {
+	em, err := d.nodeManager(nf.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, file := range nf.Files {
+		staged := file.Path + stagedFileSuffix
+		hash, err := em.FS.Sha256sum(ctx, staged)
+		if err != nil {
+			return errors.Annotatef(err, "hash %s", staged)
+		}
+		expected := sha256.Sum256(file.Data)
+		if hash != hex.EncodeToString(expected[:]) {
+			return errors.Errorf("sha256 of %s is %s, expected %s", staged, hash, hex.EncodeToString(expected[:]))
+		}
+	}
+	return nil
+}
+
+func (d *distribution) commit(ctx context.Context, nf NodeFiles) error {
+	em, err := d.nodeManager(nf.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, file := range nf.Files {
+		// the replaced file is kept as the backup to roll back, the script is quoted for
+		// the shell running commands on the node
+		script := fmt.Sprintf("'if [ -f %[1]s ]; then cp -p %[1]s %[1]s%[2]s; fi && mv -f %[1]s%[3]s %[1]s'",
+			file.Path, backupFileSuffix, stagedFileSuffix)
+		if _, err = em.Runner.Exec(ctx, "bash", "-c", script); err != nil {
+			return errors.Annotatef(err, "commit %s", file.Path)
+		}
+		d.mu.Lock()
+		d.committed[nf.Node.Name] = append(d.committed[nf.Node.Name], file)
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// rollback restores committed files from their backups, files without backups didn't
+// exist before the commit, so they're removed. Staged files which aren't committed are
+// removed too.
+func (d *distribution) rollback(ctx context.Context, nf NodeFiles) error {
+	em, err := d.nodeManager(nf.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	d.mu.Lock()
+	committed := d.committed[nf.Node.Name]
+	d.mu.Unlock()
+	for _, file := range committed {
+		script := fmt.Sprintf("'if [ -f %[1]s%[2]s ]; then mv -f %[1]s%[2]s %[1]s; else rm -f %[1]s; fi'",
+			file.Path, backupFileSuffix)
+		if _, err = em.Runner.Exec(ctx, "bash", "-c", script); err != nil {
+			return errors.Annotatef(err, "restore %s", file.Path)
+		}
+	}
+	return errors.Trace(d.removeStaged(ctx, nf))
+}
+
+func (d *distribution) removeStaged(ctx context.Context, nf NodeFiles) error {
+	return errors.Trace(d.removeWithSuffix(ctx, nf, stagedFileSuffix))
+}
+
+func (d *distribution) removeBackups(ctx context.Context, nf NodeFiles) error {
+	return errors.Trace(d.removeWithSuffix(ctx, nf, backupFileSuffix))
+}
+
+func (d *distribution) removeWithSuffix(ctx context.Context, nf NodeFiles, suffix string) error {
+	em, err := d.nodeManager(nf.Node)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	args := []string{"-f"}
+	for _, file := range nf.Files {
+		args = append(args, file.Path+suffix)
+	}
+	_, err = em.Runner.Exec(ctx, "rm", args...)
+	return errors.Trace(err)
+}
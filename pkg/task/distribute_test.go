@@ -0,0 +1,138 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestDistributeSuite(t *testing.T) {
+	suiteRun(t, new(distributeSuite))
+}
+
+type distributeSuite struct {
+	baseSuite
+	script  *externaltest.Script
+	runtime *Runtime
+	nodes   []NodeFiles
+}
+
+func (s *distributeSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.script = externaltest.NewScript()
+	s.script.OnNode("local", `^mktemp`, externaltest.Response{Output: s.T().TempDir()})
+	s.runtime = &Runtime{
+		LocalEm:        s.script.Manager("local", log.Logger),
+		NewNodeManager: s.script.NodeManager,
+	}
+	file := &RenderedFile{Path: "/root/3fs/meta/config.d/meta.toml", Data: []byte("a = 1\n")}
+	s.nodes = []NodeFiles{
+		{Node: config.Node{Name: "node1"}, Files: []*RenderedFile{file}},
+		{Node: config.Node{Name: "node2"}, Files: []*RenderedFile{file}},
+	}
+}
+
+// distribute distributes files after responses of the test are scripted.
+func (s *distributeSuite) distribute(parallel int) error {
+	file := s.nodes[0].Files[0]
+	hash := sha256.Sum256(file.Data)
+	s.script.On(`^sha256sum `, externaltest.Response{Output: hex.EncodeToString(hash[:]) + "  " + file.Path})
+	return DistributeFiles(s.Ctx(), s.runtime, s.nodes, parallel)
+}
+
+func (s *distributeSuite) distributeError(err error) *DistributeError {
+	var distErr *DistributeError
+	s.True(errors.As(err, &distErr))
+	return distErr
+}
+
+func (s *distributeSuite) TestDistribute() {
+	s.NoError(s.distribute(2))
+
+	for _, node := range []string{"node1", "node2"} {
+		s.Equal(1, s.script.Count(node, `^scp \S+/0-meta.toml /root/3fs/meta/config.d/meta.toml.m3fs-staged$`))
+		s.Equal(1, s.script.Count(node, `^sha256sum /root/3fs/meta/config.d/meta.toml.m3fs-staged$`))
+		s.Equal(1, s.script.Count(node, `mv -f /root/3fs/meta/config.d/meta.toml.m3fs-staged `+
+			`/root/3fs/meta/config.d/meta.toml'$`))
+		// stale backups are removed before staging, and backups are removed after the commit
+		s.Equal(2, s.script.Count(node, `^rm -f /root/3fs/meta/config.d/meta.toml.m3fs-backup$`))
+		s.Zero(s.script.Count(node, `^rm -f \S+\.m3fs-staged$|else rm`))
+	}
+}
+
+func (s *distributeSuite) TestVerifyFailure() {
+	s.script.OnNode("node2", `^sha256sum `, externaltest.Response{Output: "0000  /root/3fs/meta/config.d/meta.toml"})
+
+	err := s.distribute(2)
+	distErr := s.distributeError(err)
+	s.Equal(DistributePhaseVerify, distErr.Phase)
+	s.Equal("node2", distErr.Node)
+	s.NoError(distErr.RollbackErr)
+	s.Contains(err.Error(), "verify phase of distributing files failed on node node2: sha256 of ")
+	for _, node := range []string{"node1", "node2"} {
+		s.Zero(s.script.Count(node, `mv -f`))
+		s.Equal(1, s.script.Count(node, `^rm -f /root/3fs/meta/config.d/meta.toml.m3fs-staged$`))
+	}
+}
+
+func (s *distributeSuite) TestCommitFailure() {
+	s.script.OnNode("node2", `mv -f`, externaltest.Response{ExitCode: 1})
+
+	err := s.distribute(2)
+	distErr := s.distributeError(err)
+	s.Equal(DistributePhaseCommit, distErr.Phase)
+	s.Equal("node2", distErr.Node)
+	s.NoError(distErr.RollbackErr)
+	// only the committed file of node1 is restored
+	s.Equal(1, s.script.Count("node1", `then mv -f /root/3fs/meta/config.d/meta.toml.m3fs-backup `))
+	s.Zero(s.script.Count("node2", `then mv -f /root/3fs/meta/config.d/meta.toml.m3fs-backup `))
+	for _, node := range []string{"node1", "node2"} {
+		s.Equal(1, s.script.Count(node, `^rm -f /root/3fs/meta/config.d/meta.toml.m3fs-staged$`))
+		// backups are only removed before staging
+		s.Equal(1, s.script.Count(node, `^rm -f /root/3fs/meta/config.d/meta.toml.m3fs-backup$`))
+	}
+}
+
+func (s *distributeSuite) TestRollbackFailure() {
+	s.script.OnNode("node2", `mv -f`, externaltest.Response{ExitCode: 1})
+	s.script.OnNode("node1", `then mv -f`, externaltest.Response{ExitCode: 1})
+
+	err := s.distribute(1)
+	distErr := s.distributeError(err)
+	s.Equal(DistributePhaseCommit, distErr.Phase)
+	s.Error(distErr.RollbackErr)
+	s.Contains(err.Error(), ", roll back failed: rollback phase of distributing files failed on node node1")
+}
+
+func (s *distributeSuite) TestCleanupContext() {
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(s.Ctx(), key{}, "value"))
+	cancel()
+
+	cctx, cleanupCancel := cleanupContext(ctx)
+	defer cleanupCancel()
+	s.NoError(cctx.Err())
+	s.Equal("value", cctx.Value(key{}))
+	_, ok := cctx.Deadline()
+	s.True(ok)
+}
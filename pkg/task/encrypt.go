@@ -0,0 +1,171 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// encryptedFileHeader prefixes state files encrypted at rest, it's followed by the nonce
+// and the data sealed by AES-256-GCM.
+const encryptedFileHeader = "m3fs-encrypted-v1\n"
+
+var (
+	stateKeyMu sync.Mutex
+	stateAEAD  cipher.AEAD
+)
+
+// SetStateKey sets the key encrypting the cluster state and records of runs as they're
+// saved, they're saved in plain if the key is nil. Encrypted files are decrypted by the
+// key as they're loaded, while plain files are always readable, so files saved before
+// encryption is enabled are encrypted as they're saved again.
+func SetStateKey(key []byte) error {
+	var aead cipher.AEAD
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return errors.Annotatef(err, "create cipher of state key")
+		}
+		if aead, err = cipher.NewGCM(block); err != nil {
+			return errors.Annotatef(err, "create cipher of state key")
+		}
+	}
+	stateKeyMu.Lock()
+	defer stateKeyMu.Unlock()
+	stateAEAD = aead
+	return nil
+}
+
+func getStateAEAD() cipher.AEAD {
+	stateKeyMu.Lock()
+	defer stateKeyMu.Unlock()
+	return stateAEAD
+}
+
+// IsEncrypted returns whether data of a state file is encrypted.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedFileHeader))
+}
+
+// sealState encrypts data of a state file by the state key, the data is returned as is
+// if the key isn't set.
+func sealState(data []byte) ([]byte, error) {
+	aead := getStateAEAD()
+	if aead == nil {
+		return data, nil
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Annotatef(err, "generate nonce")
+	}
+	sealed := append([]byte(encryptedFileHeader), nonce...)
+	return aead.Seal(sealed, nonce, data, []byte(encryptedFileHeader)), nil
+}
+
+// openState decrypts data of a state file by the state key if it's encrypted.
+func openState(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	aead := getStateAEAD()
+	if aead == nil {
+		return nil, errors.New("it's encrypted, set stateEncryption.key of the config to decrypt it")
+	}
+	data = data[len(encryptedFileHeader):]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(encryptedFileHeader))
+	if err != nil {
+		return nil, errors.New("decrypt by stateEncryption.key of the config: wrong key or corrupted data")
+	}
+	return plain, nil
+}
+
+// ReadStateFile reads the state file, which is decrypted if it's encrypted.
+func ReadStateFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data, err = openState(data)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read %s", path)
+	}
+	return data, nil
+}
+
+// StateFiles returns paths of existing state files of the cluster, which are encrypted at
+// rest if the state key is set: the cluster state and records of runs.
+func StateFiles(workDir, clusterName string) ([]string, error) {
+	var paths []string
+	if _, err := os.Stat(ClusterStateFilePath(workDir, clusterName)); err == nil {
+		paths = append(paths, ClusterStateFilePath(workDir, clusterName))
+	}
+	records, err := filepath.Glob(filepath.Join(RunsDir(workDir, clusterName), "*", runRecordFileName))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return append(paths, records...), nil
+}
+
+// RewriteStateFiles rewrites state files of the cluster encrypted by the state key, or in
+// plain if encrypt isn't set, to migrate files saved before encryption is enabled, or
+// before it's disabled. It returns paths of rewritten files, files already in the form
+// are kept.
+func RewriteStateFiles(workDir, clusterName string, encrypt bool) ([]string, error) {
+	if encrypt && getStateAEAD() == nil {
+		return nil, errors.New("stateEncryption.key of the config isn't set")
+	}
+	paths, err := StateFiles(workDir, clusterName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var rewritten []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return rewritten, errors.Trace(err)
+		}
+		if IsEncrypted(data) == encrypt {
+			continue
+		}
+		if data, err = openState(data); err != nil {
+			return rewritten, errors.Annotatef(err, "read %s", path)
+		}
+		if encrypt {
+			if data, err = sealState(data); err != nil {
+				return rewritten, errors.Annotatef(err, "encrypt %s", path)
+			}
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return rewritten, errors.Trace(err)
+		}
+		if err = WriteFileAtomic(path, data, info.Mode().Perm()); err != nil {
+			return rewritten, errors.Annotatef(err, "write %s", path)
+		}
+		rewritten = append(rewritten, path)
+	}
+	return rewritten, nil
+}
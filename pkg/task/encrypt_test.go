@@ -0,0 +1,133 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+func TestStateEncryptionSuite(t *testing.T) {
+	suiteRun(t, new(stateEncryptionSuite))
+}
+
+type stateEncryptionSuite struct {
+	baseSuite
+	workDir string
+	state   *ClusterState
+	record  *RunRecord
+}
+
+func (s *stateEncryptionSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.workDir = s.T().TempDir()
+	s.state = &ClusterState{Cluster: "test", RunID: "run1", FdbClusterFile: "test:test@192.168.1.1:4500"}
+	s.record = &RunRecord{ID: "run1", Cluster: "test", Command: "cluster create", Status: RunStatusSucceeded,
+		StartTime: time.Now()}
+}
+
+func (s *stateEncryptionSuite) TearDownTest() {
+	s.NoError(SetStateKey(nil))
+}
+
+func (s *stateEncryptionSuite) setKey(b byte) {
+	s.NoError(SetStateKey(bytes.Repeat([]byte{b}, config.StateEncryptionKeySize)))
+}
+
+func (s *stateEncryptionSuite) save() {
+	s.NoError(SaveClusterState(s.workDir, s.state))
+	s.NoError(SaveRunRecord(RunDir(s.workDir, "test", "run1"), s.record))
+}
+
+func (s *stateEncryptionSuite) readRaw(path string) string {
+	data, err := os.ReadFile(path)
+	s.NoError(err)
+	return string(data)
+}
+
+func (s *stateEncryptionSuite) TestSaveLoadEncrypted() {
+	s.setKey(1)
+	s.save()
+
+	statePath := ClusterStateFilePath(s.workDir, "test")
+	s.True(IsEncrypted([]byte(s.readRaw(statePath))))
+	s.NotContains(s.readRaw(statePath), "192.168.1.1")
+	s.NotContains(s.readRaw(RunRecordFilePath(RunDir(s.workDir, "test", "run1"))), "cluster create")
+
+	state, err := LoadClusterState(s.workDir, "test")
+	s.NoError(err)
+	s.Equal("test:test@192.168.1.1:4500", state.FdbClusterFile)
+	records, err := LoadRunRecords(s.workDir, "test")
+	s.NoError(err)
+	s.Len(records, 1)
+	s.Equal("cluster create", records[0].Command)
+
+	data, err := ReadStateFile(statePath)
+	s.NoError(err)
+	s.Contains(string(data), "192.168.1.1")
+
+	s.setKey(2)
+	_, err = LoadClusterState(s.workDir, "test")
+	s.ErrorContains(err, "wrong key or corrupted data")
+
+	s.NoError(SetStateKey(nil))
+	_, err = LoadRunRecords(s.workDir, "test")
+	s.ErrorContains(err, "it's encrypted, set stateEncryption.key of the config to decrypt it")
+}
+
+func (s *stateEncryptionSuite) TestLoadPlainWithKey() {
+	s.save()
+	s.setKey(1)
+
+	state, err := LoadClusterState(s.workDir, "test")
+	s.NoError(err)
+	s.Equal("run1", state.RunID)
+	s.False(IsEncrypted([]byte(s.readRaw(ClusterStateFilePath(s.workDir, "test")))))
+
+	s.NoError(SaveClusterState(s.workDir, state))
+	s.True(IsEncrypted([]byte(s.readRaw(ClusterStateFilePath(s.workDir, "test")))))
+}
+
+func (s *stateEncryptionSuite) TestRewriteStateFiles() {
+	_, err := RewriteStateFiles(s.workDir, "test", true)
+	s.ErrorContains(err, "stateEncryption.key of the config isn't set")
+
+	s.save()
+	s.setKey(1)
+	statePath := ClusterStateFilePath(s.workDir, "test")
+	recordPath := RunRecordFilePath(RunDir(s.workDir, "test", "run1"))
+	paths, err := RewriteStateFiles(s.workDir, "test", true)
+	s.NoError(err)
+	s.Equal([]string{statePath, recordPath}, paths)
+	s.True(IsEncrypted([]byte(s.readRaw(statePath))))
+	s.True(IsEncrypted([]byte(s.readRaw(recordPath))))
+	info, err := os.Stat(statePath)
+	s.NoError(err)
+	s.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	paths, err = RewriteStateFiles(s.workDir, "test", true)
+	s.NoError(err)
+	s.Empty(paths)
+
+	paths, err = RewriteStateFiles(s.workDir, "test", false)
+	s.NoError(err)
+	s.Len(paths, 2)
+	s.Contains(s.readRaw(statePath), "192.168.1.1")
+	s.Contains(s.readRaw(recordPath), "cluster create")
+}
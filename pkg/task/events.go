@@ -0,0 +1,319 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+// EventsVersion is the version of the format of recorded events, it's increased when
+// fields of events are changed incompatibly. Adding fields doesn't change it.
+const EventsVersion = 1
+
+// defines types of recorded events
+const (
+	// EventHeader is the first event of a recording, it carries the version of the format.
+	EventHeader      = "header"
+	EventRunStarted  = "run_started"
+	EventRunEnded    = "run_ended"
+	EventTaskStarted = "task_started"
+	EventNodeChanged = "node_changed"
+	EventTaskEnded   = "task_ended"
+	// EventCommand is a command run on a node, it's recorded from the command journal.
+	EventCommand = "command"
+)
+
+// Event is an event of a run, events are recorded as JSON lines.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Version is the version of the format of the header.
+	Version int `json:"version,omitempty"`
+	// Cluster and Command are the cluster and the m3fs command of the run, or Command
+	// is the command run on Node.
+	Cluster string `json:"cluster,omitempty"`
+	Command string `json:"command,omitempty"`
+	// Index and Total are the 1-based index of the started task and the number of tasks.
+	Index  int    `json:"index,omitempty"`
+	Total  int    `json:"total,omitempty"`
+	Task   string `json:"task,omitempty"`
+	Node   string `json:"node,omitempty"`
+	Status string `json:"status,omitempty"`
+	// Error is the error of the ended task or run.
+	Error      string `json:"error,omitempty"`
+	Sudo       bool   `json:"sudo,omitempty"`
+	ExitCode   int    `json:"exitCode,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+// EventRecorder records events of a run into a writer. It's a ProgressReporter which
+// passes progress to the next reporter after recording it.
+type EventRecorder struct {
+	mu   sync.Mutex
+	enc  *json.Encoder
+	next ProgressReporter
+	now  func() time.Time
+	// err is the first error of writing events, events after it aren't recorded.
+	err error
+}
+
+var _ ProgressReporter = new(EventRecorder)
+
+// NewEventRecorder creates a recorder writing events into the writer, the header is
+// written first.
+func NewEventRecorder(w io.Writer) *EventRecorder {
+	r := &EventRecorder{enc: json.NewEncoder(w), now: time.Now}
+	r.record(&Event{Type: EventHeader, Version: EventsVersion})
+	return r
+}
+
+// Err returns the error of writing events.
+func (r *EventRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *EventRecorder) setNext(next ProgressReporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next = next
+}
+
+func (r *EventRecorder) record(event *Event) ProgressReporter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		event.Time = r.now().UTC()
+		if err := r.enc.Encode(event); err != nil {
+			r.err = errors.Annotate(err, "record event")
+			logrus.Warnf("Failed to record events: %v", err)
+		}
+	}
+	return r.next
+}
+
+// RunStarted records the start of the run.
+func (r *EventRecorder) RunStarted(cluster, command string) {
+	r.record(&Event{Type: EventRunStarted, Cluster: cluster, Command: command})
+}
+
+// RunEnded records the end of the run, err is the error of the run if it failed.
+func (r *EventRecorder) RunEnded(err error) {
+	r.record(&Event{Type: EventRunEnded, Error: errorString(err)})
+}
+
+// TaskStarted records the start of the task.
+func (r *EventRecorder) TaskStarted(index, total int, task string) {
+	if next := r.record(&Event{Type: EventTaskStarted, Index: index, Total: total, Task: task}); next != nil {
+		next.TaskStarted(index, total, task)
+	}
+}
+
+// NodeChanged records the status of the node in the task.
+func (r *EventRecorder) NodeChanged(task, node, status string) {
+	if next := r.record(&Event{Type: EventNodeChanged, Task: task, Node: node, Status: status}); next != nil {
+		next.NodeChanged(task, node, status)
+	}
+}
+
+// TaskEnded records the end of the task.
+func (r *EventRecorder) TaskEnded(task string, err error) {
+	if next := r.record(&Event{Type: EventTaskEnded, Task: task, Error: errorString(err)}); next != nil {
+		next.TaskEnded(task, err)
+	}
+}
+
+// CommandRun records the command of the journal entry.
+func (r *EventRecorder) CommandRun(entry *external.JournalEntry) {
+	r.record(&Event{
+		Type:       EventCommand,
+		Task:       entry.Task,
+		Node:       entry.Node,
+		Command:    entry.Command,
+		Sudo:       entry.Sudo,
+		ExitCode:   entry.ExitCode,
+		DurationMs: entry.DurationMs,
+	})
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// ReadEvents reads recorded events, it fails if the version of the recording is newer
+// than EventsVersion.
+func ReadEvents(r io.Reader) ([]*Event, error) {
+	var events []*Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		event := new(Event)
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			return nil, errors.Annotatef(err, "decode event of line %d", line)
+		}
+		if len(events) == 0 {
+			if event.Type != EventHeader {
+				return nil, errors.Errorf("the first event is %s instead of the header", event.Type)
+			}
+			if event.Version > EventsVersion {
+				return nil, errors.Errorf("version %d of events is newer than the supported version %d",
+					event.Version, EventsVersion)
+			}
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(events) == 0 {
+		return nil, errors.New("no events are recorded")
+	}
+	return events, nil
+}
+
+// ReadEventsFile reads recorded events of the file, see ReadEvents.
+func ReadEventsFile(path string) ([]*Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	events, err := ReadEvents(f)
+	return events, errors.Annotatef(err, "read events of %s", path)
+}
+
+// EventReplayer feeds recorded events into a progress reporter, so that rendering of
+// progress can be tested without a cluster.
+type EventReplayer struct {
+	mu     sync.Mutex
+	events []*Event
+	speed  float64
+	now    time.Time
+}
+
+// NewEventReplayer creates a replayer of the events which replays them without delays.
+func NewEventReplayer(events []*Event) *EventReplayer {
+	p := &EventReplayer{events: events}
+	if len(events) > 0 {
+		p.now = events[0].Time
+	}
+	return p
+}
+
+// SetSpeed makes events replayed with delays between them, which are intervals between
+// them divided by the speed. Events are replayed without delays if it's 0.
+func (p *EventReplayer) SetSpeed(speed float64) {
+	p.speed = speed
+}
+
+// Now returns the time of the replayed event. It's the clock of reporters to render
+// elapsed times deterministically.
+func (p *EventReplayer) Now() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.now
+}
+
+// Replay feeds progress events into the reporter in order, other events are passed to
+// the optional function, e.g. to print commands.
+func (p *EventReplayer) Replay(ctx context.Context, reporter ProgressReporter, other func(*Event)) error {
+	for _, event := range p.events {
+		if p.speed > 0 && event.Time.After(p.Now()) {
+			select {
+			case <-ctx.Done():
+				return errors.Trace(ctx.Err())
+			case <-time.After(time.Duration(float64(event.Time.Sub(p.Now())) / p.speed)):
+			}
+		}
+		p.mu.Lock()
+		p.now = event.Time
+		p.mu.Unlock()
+
+		switch event.Type {
+		case EventTaskStarted:
+			reporter.TaskStarted(event.Index, event.Total, event.Task)
+		case EventNodeChanged:
+			reporter.NodeChanged(event.Task, event.Node, event.Status)
+		case EventTaskEnded:
+			var err error
+			if event.Error != "" {
+				err = errors.New(event.Error)
+			}
+			reporter.TaskEnded(event.Task, err)
+		default:
+			if other != nil {
+				other(event)
+			}
+		}
+	}
+	return nil
+}
+
+// SetEventsOut sets the file which events of the run are recorded into, it's replaced
+// if it exists.
+func (r *Runner) SetEventsOut(path string) {
+	r.eventsOut = path
+}
+
+// recordEvents makes progress and commands of the run recorded into the events file,
+// the returned function records the end of the run and closes the file.
+func (r *Runner) recordEvents() (func(error), error) {
+	if r.Runtime == nil {
+		return nil, errors.New("events are recorded only after the runner is initialized")
+	}
+	f, err := os.Create(r.eventsOut)
+	if err != nil {
+		return nil, errors.Annotate(err, "create events file")
+	}
+	recorder := NewEventRecorder(f)
+	next := r.Runtime.Progress
+	recorder.setNext(next)
+	r.Runtime.Progress = recorder
+	if r.Runtime.Journal != nil {
+		r.Runtime.Journal.SetObserver(recorder.CommandRun)
+	}
+	recorder.RunStarted(r.cfg.Name, r.command)
+	return func(runErr error) {
+		recorder.RunEnded(runErr)
+		r.Runtime.Progress = next
+		if r.Runtime.Journal != nil {
+			r.Runtime.Journal.SetObserver(nil)
+		}
+		if err := f.Close(); err != nil {
+			logrus.Warnf("Failed to close events file %s: %v", r.eventsOut, err)
+		}
+	}, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+func TestEventsSuite(t *testing.T) {
+	suiteRun(t, new(eventsSuite))
+}
+
+type eventsSuite struct {
+	baseSuite
+}
+
+// progressLog is a ProgressReporter logging calls as lines.
+type progressLog struct {
+	lines []string
+}
+
+func (p *progressLog) TaskStarted(index, total int, task string) {
+	p.lines = append(p.lines, fmt.Sprintf("start %d/%d %s", index, total, task))
+}
+
+func (p *progressLog) NodeChanged(task, node, status string) {
+	p.lines = append(p.lines, fmt.Sprintf("node %s %s %s", task, node, status))
+}
+
+func (p *progressLog) TaskEnded(task string, err error) {
+	p.lines = append(p.lines, fmt.Sprintf("end %s %v", task, err))
+}
+
+func (s *eventsSuite) TestRecordAndReplay() {
+	out := new(bytes.Buffer)
+	recorder := NewEventRecorder(out)
+	now := time.Date(2025, 4, 10, 8, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time { return now }
+	next := new(progressLog)
+	recorder.setNext(next)
+
+	recorder.RunStarted("test", "cluster create")
+	recorder.TaskStarted(1, 2, "task1")
+	now = now.Add(time.Second)
+	recorder.NodeChanged("task1", "node1", NodeStatusRunning)
+	recorder.CommandRun(&external.JournalEntry{Node: "node1", Task: "task1", Command: "true", Sudo: true,
+		DurationMs: 5})
+	now = now.Add(time.Second)
+	recorder.NodeChanged("task1", "node1", NodeStatusFailed)
+	recorder.TaskEnded("task1", errors.New("node1 failed"))
+	recorder.RunEnded(errors.New("node1 failed"))
+	s.NoError(recorder.Err())
+	expected := []string{"start 1/2 task1", "node task1 node1 running", "node task1 node1 failed",
+		"end task1 node1 failed"}
+	s.Equal(expected, next.lines)
+
+	events, err := ReadEvents(out)
+	s.NoError(err)
+	s.Len(events, 8)
+	s.Equal(EventHeader, events[0].Type)
+	s.Equal(EventsVersion, events[0].Version)
+	s.Equal(&Event{Type: EventCommand, Time: now.Add(-time.Second), Task: "task1", Node: "node1",
+		Command: "true", Sudo: true, DurationMs: 5}, events[4])
+
+	replayed := new(progressLog)
+	var others []string
+	replayer := NewEventReplayer(events)
+	s.NoError(replayer.Replay(s.Ctx(), replayed, func(event *Event) { others = append(others, event.Type) }))
+	s.Equal(expected, replayed.lines)
+	s.Equal([]string{EventHeader, EventRunStarted, EventCommand, EventRunEnded}, others)
+	s.Equal(now, replayer.Now())
+}
+
+func (s *eventsSuite) TestReplayWithSpeed() {
+	start := time.Date(2025, 4, 10, 8, 0, 0, 0, time.UTC)
+	replayer := NewEventReplayer([]*Event{
+		{Type: EventHeader, Time: start, Version: EventsVersion},
+		{Type: EventTaskStarted, Time: start.Add(time.Second), Index: 1, Total: 1, Task: "task1"},
+	})
+	replayer.SetSpeed(100)
+	begin := time.Now()
+
+	s.NoError(replayer.Replay(s.Ctx(), new(progressLog), nil))
+	s.GreaterOrEqual(time.Since(begin), 10*time.Millisecond)
+}
+
+func (s *eventsSuite) TestReadEventsInvalid() {
+	_, err := ReadEvents(strings.NewReader(`{"type":"header","version":2}` + "\n"))
+	s.ErrorContains(err, "version 2 of events is newer than the supported version 1")
+
+	_, err = ReadEvents(strings.NewReader(`{"type":"task_started"}` + "\n"))
+	s.ErrorContains(err, "the first event is task_started instead of the header")
+
+	_, err = ReadEvents(strings.NewReader("\n"))
+	s.ErrorContains(err, "no events are recorded")
+}
+
+func (s *eventsSuite) TestRunnerRecordEvents() {
+	mockTask := new(mockTask)
+	runner := &Runner{
+		tasks: []Interface{mockTask},
+		cfg:   &config.Config{Name: "test", WorkDir: s.T().TempDir()},
+	}
+	s.NoError(runner.SetRun("cluster create", "run1"))
+	eventsOut := filepath.Join(s.T().TempDir(), "events.jsonl")
+	runner.SetEventsOut(eventsOut)
+	mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	mockTask.On("Name").Return("mockTask")
+	runner.Init()
+	mockTask.On("Run").Run(func(mock.Arguments) {
+		s.NoError(runner.Runtime.Journal.Append(&external.JournalEntry{Node: "node1", Command: "ls"}))
+	}).Return(nil)
+
+	s.NoError(runner.Run(s.Ctx()))
+
+	events, err := ReadEventsFile(eventsOut)
+	s.NoError(err)
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	s.Equal([]string{EventHeader, EventRunStarted, EventTaskStarted, EventCommand, EventTaskEnded,
+		EventRunEnded}, types)
+	s.Equal("cluster create", events[1].Command)
+	s.Equal("ls", events[3].Command)
+	s.Nil(runner.Runtime.Progress)
+}
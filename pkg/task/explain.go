@@ -0,0 +1,57 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+)
+
+// TaskPlan tells whether a task will run and why.
+type TaskPlan struct {
+	Metadata
+	Run    bool   `json:"run"`
+	Reason string `json:"reason"`
+}
+
+// Explain returns plans of tasks of the initialized runner as Run would run them,
+// nothing is run.
+func (r *Runner) Explain() []TaskPlan {
+	plans := make([]TaskPlan, 0, len(r.tasks))
+	var (
+		phase   Phase
+		stopped bool
+	)
+	for i, t := range r.tasks {
+		plan := TaskPlan{Metadata: MetadataOf(t), Run: true, Reason: "will run"}
+		newPhase := i == 0 || plan.Phase != phase
+		phase = plan.Phase
+		if newPhase && r.until != "" && phaseIndex(phase) > phaseIndex(r.until) {
+			stopped = true
+		}
+		selector, ok := t.(interface{ selectsNoNode() bool })
+		switch {
+		case stopped:
+			plan.Run = false
+			plan.Reason = fmt.Sprintf("skipped: the run stops after phase %s", r.until)
+		case ok && selector.selectsNoNode():
+			plan.Run = false
+			plan.Reason = "skipped: node selector matches no nodes"
+		case newPhase && i > 0 && r.phaseGate != nil:
+			plan.Reason = fmt.Sprintf("will run after phase %s is approved", phase)
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
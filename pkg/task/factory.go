@@ -0,0 +1,181 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/utils"
+)
+
+// Factory generates tasks from the config. It must be deterministic, the same config
+// always generates the same tasks in the same order.
+type Factory func(cfg *config.Config) []Interface
+
+// Single returns a factory generating one task created by newTask.
+func Single(newTask func() Interface) Factory {
+	return func(*config.Config) []Interface {
+		return []Interface{newTask()}
+	}
+}
+
+// When returns a factory generating tasks of the factory only if the condition holds.
+func When(cond func(cfg *config.Config) bool, factory Factory) Factory {
+	return func(cfg *config.Config) []Interface {
+		if !cond(cfg) {
+			return nil
+		}
+		return factory(cfg)
+	}
+}
+
+// IfEnabled returns a factory generating tasks of the factory only if the service is enabled.
+func IfEnabled(service config.ServiceType, factory Factory) Factory {
+	return When(func(cfg *config.Config) bool { return cfg.Services.Enabled(service) }, factory)
+}
+
+// PerNode returns a factory generating a task for each node returned by nodes in order.
+func PerNode(nodes func(cfg *config.Config) []string, newTask func(node string) Interface) Factory {
+	return func(cfg *config.Config) []Interface {
+		names := nodes(cfg)
+		tasks := make([]Interface, len(names))
+		for i, name := range names {
+			tasks[i] = newTask(name)
+		}
+		return tasks
+	}
+}
+
+// GenerateTasks returns tasks generated by factories in order.
+func GenerateTasks(cfg *config.Config, factories ...Factory) []Interface {
+	var tasks []Interface
+	for _, factory := range factories {
+		tasks = append(tasks, factory(cfg)...)
+	}
+	return tasks
+}
+
+// SetFloating makes the task float right after the last task it depends on instead of
+// staying at its position in the list, and join the phase of that task. A floating task
+// depending on none of the tasks runs at the end of tasks of its phase.
+func (t *BaseTask) SetFloating() {
+	t.floating = true
+}
+
+func (t *BaseTask) isFloating() bool {
+	return t.floating
+}
+
+func isFloating(t Interface) bool {
+	f, ok := t.(interface{ isFloating() bool })
+	return ok && f.isFloating()
+}
+
+// orderTasks returns initialized tasks ordered by their dependencies, then floating
+// tasks are inserted in order.
+func orderTasks(tasks []Interface) []Interface {
+	var fixed, floating []Interface
+	for _, t := range tasks {
+		if isFloating(t) {
+			floating = append(floating, t)
+		} else {
+			fixed = append(fixed, t)
+		}
+	}
+	ordered := orderTasksByDeps(fixed)
+	for _, t := range floating {
+		ordered = insertFloatingTask(ordered, t)
+	}
+	return ordered
+}
+
+// insertFloatingTask inserts the floating task right after the last task it depends
+// on, or at the end of tasks of its phase. It follows floating tasks inserted at the
+// same position before, so that floating tasks keep their order.
+func insertFloatingTask(tasks []Interface, t Interface) []Interface {
+	meta := MetadataOf(t)
+	pos := -1
+	for i, other := range tasks {
+		if slices.Contains(meta.Deps, other.Name()) {
+			pos = i
+		}
+	}
+	if pos >= 0 {
+		if setter, ok := t.(interface{ SetPhase(Phase) }); ok {
+			setter.SetPhase(MetadataOf(tasks[pos]).Phase)
+		}
+	} else {
+		if len(meta.Deps) > 0 {
+			logrus.Warnf("Task %s runs after %s which are not tasks of the run, it runs at the end of phase %s",
+				t.Name(), strings.Join(meta.Deps, ","), meta.Phase)
+		}
+		for i, other := range tasks {
+			if phaseIndex(MetadataOf(other).Phase) <= phaseIndex(meta.Phase) {
+				pos = i
+			}
+		}
+	}
+	pos++
+	for pos < len(tasks) && isFloating(tasks[pos]) {
+		pos++
+	}
+	return slices.Insert(tasks, pos, t)
+}
+
+// orderTasksByDeps returns initialized tasks ordered by their dependencies. The order of
+// tasks is kept unless a task precedes the one it depends on, so the order is stable.
+// Dependencies on tasks not in the list are ignored, a cycle of dependencies is broken
+// by the given order.
+func orderTasksByDeps(tasks []Interface) []Interface {
+	pending := make(map[string]int, len(tasks))
+	for _, t := range tasks {
+		pending[t.Name()]++
+	}
+	ready := func(t Interface) bool {
+		for _, dep := range MetadataOf(t).Deps {
+			if pending[dep] > 0 && dep != t.Name() {
+				return false
+			}
+		}
+		return true
+	}
+
+	ordered := make([]Interface, 0, len(tasks))
+	done := utils.NewSet[int]()
+	for len(ordered) < len(tasks) {
+		next := -1
+		for i, t := range tasks {
+			if done.Contains(i) {
+				continue
+			}
+			if next < 0 {
+				// the first pending task is picked if no task is ready
+				next = i
+			}
+			if ready(t) {
+				next = i
+				break
+			}
+		}
+		done.Add(next)
+		pending[tasks[next].Name()]--
+		ordered = append(ordered, tasks[next])
+	}
+	return ordered
+}
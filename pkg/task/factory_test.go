@@ -0,0 +1,126 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+func TestFactorySuite(t *testing.T) {
+	suiteRun(t, new(factorySuite))
+}
+
+type factorySuite struct {
+	baseSuite
+}
+
+func newDepsTask(name string, deps ...string) *BaseTask {
+	t := new(BaseTask)
+	t.SetName(name)
+	t.SetDeps(deps...)
+	return t
+}
+
+func taskNames(tasks []Interface) []string {
+	names := make([]string, len(tasks))
+	for i, t := range tasks {
+		names[i] = t.Name()
+	}
+	return names
+}
+
+func (s *factorySuite) TestGenerateTasks() {
+	cfg := &config.Config{Services: config.Services{Storage: config.Storage{Nodes: []string{"node1", "node2"}}}}
+	manageHosts := func(cfg *config.Config) bool { return cfg.ManageHosts }
+	factories := []Factory{
+		Single(func() Interface { return newDepsTask("first") }),
+		When(manageHosts, Single(func() Interface { return newDepsTask("hosts") })),
+		PerNode(
+			func(cfg *config.Config) []string { return cfg.Services.Storage.Nodes },
+			func(node string) Interface { return newDepsTask("disk-" + node) }),
+	}
+
+	s.Equal([]string{"first", "disk-node1", "disk-node2"}, taskNames(GenerateTasks(cfg, factories...)))
+	cfg.ManageHosts = true
+	s.Equal([]string{"first", "hosts", "disk-node1", "disk-node2"}, taskNames(GenerateTasks(cfg, factories...)))
+
+	// tasks are created for each generation
+	s.NotSame(GenerateTasks(cfg, factories...)[0], GenerateTasks(cfg, factories...)[0])
+}
+
+func (s *factorySuite) TestIfEnabled() {
+	cfg := new(config.Config)
+	factory := IfEnabled(config.ServiceMonitor, Single(func() Interface { return newDepsTask("monitor") }))
+	s.Equal([]string{"monitor"}, taskNames(GenerateTasks(cfg, factory)))
+
+	disabled := false
+	cfg.Services.Monitor.Enabled = &disabled
+	s.Empty(GenerateTasks(cfg, factory))
+}
+
+func (s *factorySuite) TestOrderTasks() {
+	tasks := []Interface{
+		newDepsTask("a"),
+		newDepsTask("c", "b"),
+		newDepsTask("b", "a", "missing"),
+		newDepsTask("d"),
+	}
+	s.Equal([]string{"a", "b", "c", "d"}, taskNames(orderTasks(tasks)))
+
+	// the order is kept if dependencies are satisfied
+	tasks = []Interface{newDepsTask("a"), newDepsTask("d"), newDepsTask("b", "a")}
+	s.Equal([]string{"a", "d", "b"}, taskNames(orderTasks(tasks)))
+
+	// a cycle is broken by the given order
+	tasks = []Interface{newDepsTask("x", "y"), newDepsTask("y", "x"), newDepsTask("z")}
+	s.Equal([]string{"z", "x", "y"}, taskNames(orderTasks(tasks)))
+}
+
+func newFloatingTask(name string, phase Phase, deps ...string) *BaseTask {
+	t := newDepsTask(name, deps...)
+	t.SetPhase(phase)
+	t.SetFloating()
+	return t
+}
+
+func (s *factorySuite) TestOrderFloatingTasks() {
+	deploy := func(name string, deps ...string) *BaseTask {
+		t := newDepsTask(name, deps...)
+		t.SetService(config.ServiceStorage)
+		return t
+	}
+	verify := newDepsTask("verify")
+	verify.SetPhase(PhaseVerify)
+	after := newFloatingTask("after-a", PhaseVerify, "a")
+	tasks := []Interface{
+		newFloatingTask("end-of-prepare", PhasePrepare),
+		after,
+		newFloatingTask("after-a-2", PhaseDeploy, "a"),
+		newFloatingTask("end-of-deploy", PhaseDeploy, "missing"),
+		newDepsTask("prepare"),
+		deploy("a"),
+		deploy("b"),
+		verify,
+		newFloatingTask("end-of-verify", PhaseVerify),
+	}
+
+	s.Equal([]string{
+		"prepare", "end-of-prepare", "a", "after-a", "after-a-2", "b", "end-of-deploy", "verify", "end-of-verify",
+	}, taskNames(orderTasks(tasks)))
+	// the task joins the phase of the task it runs after
+	s.Equal(PhaseDeploy, after.Metadata().Phase)
+}
@@ -0,0 +1,282 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+const factsDirName = "facts"
+
+// factsCacheTTL is how long facts cached in the work dir are reused by later runs, facts
+// of nodes change when they're fixed by operators, e.g. after failed preflight checks.
+const factsCacheTTL = 10 * time.Minute
+
+// DiskFacts are facts of a disk of a node.
+type DiskFacts struct {
+	Name string `json:"name"`
+	Size uint64 `json:"size"`
+}
+
+// NodeFacts are facts of a node gathered once and shared by checks of the node, instead
+// of probing the node by each of them. They're cached in the runtime for the run, and in
+// the work dir for a short while, see factsCacheTTL.
+type NodeFacts struct {
+	Node        string      `json:"node"`
+	Host        string      `json:"host"`
+	OS          string      `json:"os"`
+	Kernel      string      `json:"kernel"`
+	Arch        string      `json:"arch"`
+	CPUs        uint64      `json:"cpus"`
+	MemoryBytes uint64      `json:"memoryBytes"`
+	NrOpen      uint64      `json:"nrOpen"`
+	ThreadsMax  uint64      `json:"threadsMax"`
+	Disks       []DiskFacts `json:"disks"`
+	// Tools are names of HostTools found on the node.
+	Tools            []string                `json:"tools"`
+	ContainerRuntime config.ContainerRuntime `json:"containerRuntime"`
+	GatherTime       time.Time               `json:"gatherTime"`
+
+	// cached is whether the facts are loaded from the work dir instead of gathered in the run.
+	cached bool
+}
+
+// NVMeDisks returns NVMe disks of the node in the order listed by lsblk.
+func (f *NodeFacts) NVMeDisks() []DiskFacts {
+	var disks []DiskFacts
+	for _, disk := range f.Disks {
+		if strings.HasPrefix(disk.Name, "nvme") {
+			disks = append(disks, disk)
+		}
+	}
+	return disks
+}
+
+// NodeFactsFilePath returns path of the cached facts of the node.
+func NodeFactsFilePath(workDir, clusterName, nodeName string) string {
+	return filepath.Join(ClusterStateDir(workDir, clusterName), factsDirName, nodeName+".json")
+}
+
+// SaveNodeFacts caches facts of the node in the work dir.
+func SaveNodeFacts(workDir, clusterName string, facts *NodeFacts) error {
+	factsPath := NodeFactsFilePath(workDir, clusterName, facts.Node)
+	if err := os.MkdirAll(filepath.Dir(factsPath), 0755); err != nil {
+		return errors.Annotatef(err, "create directory of %s", factsPath)
+	}
+	data, err := json.MarshalIndent(facts, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = WriteFileAtomic(factsPath, data, 0644); err != nil {
+		return errors.Annotatef(err, "write facts %s", factsPath)
+	}
+	return nil
+}
+
+// LoadNodeFacts loads cached facts of the node, it returns nil if they aren't cached.
+func LoadNodeFacts(workDir, clusterName, nodeName string) (*NodeFacts, error) {
+	factsPath := NodeFactsFilePath(workDir, clusterName, nodeName)
+	data, err := os.ReadFile(factsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Annotatef(err, "read facts %s", factsPath)
+	}
+	facts := new(NodeFacts)
+	if err = json.Unmarshal(data, facts); err != nil {
+		return nil, errors.Annotatef(err, "parse facts %s", factsPath)
+	}
+	return facts, nil
+}
+
+// NodeFacts returns facts of the node. They're gathered on the node once in the run, and
+// cached in the runtime and the work dir. Facts cached in the work dir by recent runs are
+// reused unless RefreshFacts is set.
+func (r *Runtime) NodeFacts(ctx context.Context, em *external.Manager, node config.Node) (*NodeFacts, error) {
+	facts, err := r.nodeFacts(ctx, em, node, !r.RefreshFacts)
+	return facts, errors.Trace(err)
+}
+
+// FreshNodeFacts returns facts of the node gathered in the run, facts cached in the work
+// dir are never used. It's used by checks which must see the current state of nodes.
+func (r *Runtime) FreshNodeFacts(ctx context.Context, em *external.Manager, node config.Node) (*NodeFacts, error) {
+	facts, err := r.nodeFacts(ctx, em, node, false)
+	return facts, errors.Trace(err)
+}
+
+func (r *Runtime) nodeFacts(
+	ctx context.Context, em *external.Manager, node config.Node, useCached bool) (*NodeFacts, error) {
+
+	r.factsMu.Lock()
+	facts, ok := r.facts[node.Name]
+	r.factsMu.Unlock()
+	if ok && (useCached || !facts.cached) {
+		return facts, nil
+	}
+
+	if useCached {
+		cached, err := LoadNodeFacts(r.WorkDir, r.Cfg.Name, node.Name)
+		if err != nil {
+			logrus.Warnf("Failed to load cached facts of node %s, gather them again: %v", node.Name, err)
+		} else if cached != nil && cached.Host == node.Host && time.Since(cached.GatherTime) < factsCacheTTL {
+			cached.cached = true
+			r.SetNodeFacts(cached)
+			return cached, nil
+		}
+	}
+	facts, err := GatherNodeFacts(ctx, em)
+	if err != nil {
+		return nil, errors.Annotatef(err, "gather facts of node %s", node.Name)
+	}
+	facts.Node = node.Name
+	facts.Host = node.Host
+	// the runtime may be installed later, it's checked by preflight checks
+	if facts.ContainerRuntime, err = r.ContainerRuntime(ctx, em, node); err != nil {
+		logrus.Debugf("No container runtime in facts of node %s: %v", node.Name, err)
+	}
+	if err = SaveNodeFacts(r.WorkDir, r.Cfg.Name, facts); err != nil {
+		logrus.Warnf("Failed to cache facts of node %s: %v", node.Name, err)
+	}
+	r.SetNodeFacts(facts)
+	return facts, nil
+}
+
+// SetNodeFacts caches facts of the node in the runtime.
+func (r *Runtime) SetNodeFacts(facts *NodeFacts) {
+	r.factsMu.Lock()
+	defer r.factsMu.Unlock()
+	if r.facts == nil {
+		r.facts = make(map[string]*NodeFacts)
+	}
+	r.facts[facts.Node] = facts
+}
+
+// GatherNodeFacts gathers facts of the node by running commands on it through the manager,
+// except the container runtime which is detected by Runtime.ContainerRuntime.
+func GatherNodeFacts(ctx context.Context, em *external.Manager) (*NodeFacts, error) {
+	facts := &NodeFacts{GatherTime: time.Now()}
+	out, err := em.Runner.NonSudoExec(ctx, "uname", "-r", "-m")
+	if err != nil {
+		return nil, errors.Annotate(err, "run uname")
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return nil, errors.Errorf("unexpected output of uname: %s", out)
+	}
+	facts.Kernel, facts.Arch = fields[0], fields[1]
+
+	if out, err = em.Runner.NonSudoExec(ctx, "cat", "/etc/os-release"); err != nil {
+		return nil, errors.Annotate(err, "read /etc/os-release")
+	}
+	facts.OS = parseOSRelease(out)
+	if facts.CPUs, err = readUint(ctx, em, "nproc"); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if out, err = em.Runner.NonSudoExec(ctx, "cat", "/proc/meminfo"); err != nil {
+		return nil, errors.Annotate(err, "read /proc/meminfo")
+	}
+	if facts.MemoryBytes, err = parseMemTotal(out); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if facts.NrOpen, err = readUint(ctx, em, "cat", "/proc/sys/fs/nr_open"); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if facts.ThreadsMax, err = readUint(ctx, em, "cat", "/proc/sys/kernel/threads-max"); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if out, err = em.Runner.NonSudoExec(ctx, "lsblk", "-d", "-n", "-b", "-o", "NAME,TYPE,SIZE"); err != nil {
+		return nil, errors.Annotate(err, "list disks")
+	}
+	if facts.Disks, err = parseDisks(out); err != nil {
+		return nil, errors.Trace(err)
+	}
+	facts.Tools = LookupTools(ctx, em.Runner.NonSudoExec, HostTools...)
+	return facts, nil
+}
+
+func readUint(ctx context.Context, em *external.Manager, cmd string, args ...string) (uint64, error) {
+	out, err := em.Runner.NonSudoExec(ctx, cmd, args...)
+	if err != nil {
+		return 0, errors.Annotatef(err, "run %s", strings.Join(append([]string{cmd}, args...), " "))
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return 0, errors.Annotatef(err, "parse output of %s", cmd)
+	}
+	return value, nil
+}
+
+// parseOSRelease returns the pretty name of the OS in /etc/os-release.
+func parseOSRelease(out string) string {
+	var name, version string
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "PRETTY_NAME":
+			return value
+		case "NAME":
+			name = value
+		case "VERSION_ID":
+			version = value
+		}
+	}
+	return strings.TrimSpace(name + " " + version)
+}
+
+func parseMemTotal(out string) (uint64, error) {
+	for _, line := range strings.Split(out, "\n") {
+		var kb uint64
+		if _, err := fmt.Sscanf(line, "MemTotal: %d kB", &kb); err == nil {
+			return kb << 10, nil
+		}
+	}
+	return 0, errors.New("MemTotal not found in /proc/meminfo")
+}
+
+// parseDisks parses disks listed by `lsblk -d -n -b -o NAME,TYPE,SIZE`, partitions and
+// other devices are skipped.
+func parseDisks(out string) ([]DiskFacts, error) {
+	var disks []DiskFacts
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "disk" {
+			continue
+		}
+		size, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return nil, errors.Annotatef(err, "parse size of disk %s", fields[0])
+		}
+		disks = append(disks, DiskFacts{Name: fields[0], Size: size})
+	}
+	return disks, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	texternal "github.com/open3fs/m3fs/tests/external"
+)
+
+func TestFactsSuite(t *testing.T) {
+	suiteRun(t, new(factsSuite))
+}
+
+type factsSuite struct {
+	baseSuite
+	mockRunner *texternal.MockRunner
+	em         *external.Manager
+	runtime    *Runtime
+	node       config.Node
+}
+
+func (s *factsSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.mockRunner = new(texternal.MockRunner)
+	s.em = external.NewManager(s.mockRunner, log.Logger)
+	cfg := &config.Config{Name: "test", ContainerRuntime: config.ContainerRuntimeDocker}
+	s.runtime = &Runtime{Cfg: cfg, Services: &cfg.Services, WorkDir: s.T().TempDir()}
+	s.node = config.Node{Name: "node1", Host: "192.168.1.1"}
+}
+
+func (s *factsSuite) mockGather() {
+	s.mockRunner.On("NonSudoExec", "uname", []string{"-r", "-m"}).Return("5.15.0-91-generic x86_64\n", nil).Once()
+	s.mockRunner.On("NonSudoExec", "cat", []string{"/etc/os-release"}).
+		Return("NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nPRETTY_NAME=\"Ubuntu 22.04.3 LTS\"\n", nil).Once()
+	s.mockRunner.On("NonSudoExec", "nproc", []string(nil)).Return("16\n", nil).Once()
+	s.mockRunner.On("NonSudoExec", "cat", []string{"/proc/meminfo"}).
+		Return("MemTotal:       65536000 kB\nMemFree:        1024 kB\n", nil).Once()
+	s.mockRunner.On("NonSudoExec", "cat", []string{"/proc/sys/fs/nr_open"}).Return("1048576\n", nil).Once()
+	s.mockRunner.On("NonSudoExec", "cat", []string{"/proc/sys/kernel/threads-max"}).Return("512000\n", nil).Once()
+	s.mockRunner.On("NonSudoExec", "lsblk", []string{"-d", "-n", "-b", "-o", "NAME,TYPE,SIZE"}).
+		Return("sda disk 107374182400\nsr0 rom 1073741312\nnvme0n1 disk 549755813888\n", nil).Once()
+	s.mockRunner.On("NonSudoExec", "sh", []string{"-c", "'command -v systemctl'"}).
+		Return("", errors.New("exit status 1")).Once()
+}
+
+func (s *factsSuite) TestGatherOnce() {
+	s.mockGather()
+
+	facts, err := s.runtime.NodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.mockRunner.AssertExpectations(s.T())
+	s.Equal("node1", facts.Node)
+	s.Equal("Ubuntu 22.04.3 LTS", facts.OS)
+	s.Equal("5.15.0-91-generic", facts.Kernel)
+	s.Equal("x86_64", facts.Arch)
+	s.Equal(uint64(16), facts.CPUs)
+	s.Equal(uint64(65536000<<10), facts.MemoryBytes)
+	s.Equal(uint64(1048576), facts.NrOpen)
+	s.Equal(uint64(512000), facts.ThreadsMax)
+	s.Equal([]DiskFacts{{Name: "sda", Size: 107374182400}, {Name: "nvme0n1", Size: 549755813888}}, facts.Disks)
+	s.Equal([]DiskFacts{{Name: "nvme0n1", Size: 549755813888}}, facts.NVMeDisks())
+	s.Equal(config.ContainerRuntimeDocker, facts.ContainerRuntime)
+	s.Equal([]string{}, facts.Tools)
+
+	// facts are gathered once per run
+	again, err := s.runtime.NodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.Same(facts, again)
+
+	// and cached in the work dir for later runs
+	runtime := &Runtime{Cfg: s.runtime.Cfg, Services: s.runtime.Services, WorkDir: s.runtime.WorkDir}
+	cached, err := runtime.NodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.Equal(facts.Kernel, cached.Kernel)
+	s.mockRunner.AssertNumberOfCalls(s.T(), "NonSudoExec", 8)
+}
+
+func (s *factsSuite) TestRefresh() {
+	s.mockGather()
+	_, err := s.runtime.NodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+
+	s.mockGather()
+	runtime := &Runtime{Cfg: s.runtime.Cfg, Services: s.runtime.Services, WorkDir: s.runtime.WorkDir,
+		RefreshFacts: true}
+	_, err = runtime.NodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.mockRunner.AssertNumberOfCalls(s.T(), "NonSudoExec", 16)
+}
+
+func (s *factsSuite) TestHostChanged() {
+	s.NoError(SaveNodeFacts(s.runtime.WorkDir, "test", &NodeFacts{Node: "node1", Host: "192.168.1.9"}))
+	s.mockGather()
+
+	facts, err := s.runtime.NodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.Equal("192.168.1.1", facts.Host)
+	s.mockRunner.AssertExpectations(s.T())
+}
+
+func (s *factsSuite) TestCachedFactsExpire() {
+	s.NoError(SaveNodeFacts(s.runtime.WorkDir, "test", &NodeFacts{Node: "node1", Host: "192.168.1.1",
+		Kernel: "5.4.0", GatherTime: time.Now().Add(-factsCacheTTL - time.Minute)}))
+	s.mockGather()
+
+	facts, err := s.runtime.NodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.Equal("5.15.0-91-generic", facts.Kernel)
+	s.mockRunner.AssertExpectations(s.T())
+}
+
+func (s *factsSuite) TestFreshFactsIgnoreCache() {
+	s.NoError(SaveNodeFacts(s.runtime.WorkDir, "test", &NodeFacts{Node: "node1", Host: "192.168.1.1",
+		Kernel: "5.4.0", GatherTime: time.Now()}))
+	cached, err := s.runtime.NodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.Equal("5.4.0", cached.Kernel)
+
+	s.mockGather()
+	facts, err := s.runtime.FreshNodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.Equal("5.15.0-91-generic", facts.Kernel)
+	// facts gathered in the run are shared
+	again, err := s.runtime.FreshNodeFacts(s.Ctx(), s.em, s.node)
+	s.NoError(err)
+	s.Same(facts, again)
+	s.mockRunner.AssertNumberOfCalls(s.T(), "NonSudoExec", 8)
+}
+
+func (s *factsSuite) TestParseOSReleaseWithoutPrettyName() {
+	s.Equal("openEuler 22.03", parseOSRelease("NAME=\"openEuler\"\nVERSION_ID=\"22.03\"\n"))
+}
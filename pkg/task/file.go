@@ -0,0 +1,79 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+// staleTempFileAge is the age after which a temp file of WriteFileAtomic is
+// considered left by a crashed writer, writing a file takes far less time.
+const staleTempFileAge = 10 * time.Minute
+
+// WriteFileAtomic writes data to a temp file in the directory of the path, then renames
+// it over the path, so that readers never see a partially written file. Each writer
+// uses its own temp file, so concurrent writers don't corrupt each other.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	f, err := os.CreateTemp(dir, base+".*.tmp")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tmpPath := f.Name()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return errors.Trace(err)
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return errors.Trace(err)
+	}
+	if err = f.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	if err = os.Chmod(tmpPath, perm); err != nil {
+		return errors.Trace(err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return errors.Trace(err)
+	}
+	removeStaleTempFiles(path)
+	return nil
+}
+
+// removeStaleTempFiles removes temp files of the path left by crashed writers. The
+// path itself is intact since it's only replaced by renaming, so they're garbage.
+func removeStaleTempFiles(path string) {
+	matches, _ := filepath.Glob(path + ".*.tmp")
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || time.Since(info.ModTime()) < staleTempFileAge {
+			continue
+		}
+		if err = os.Remove(match); err != nil && !os.IsNotExist(err) {
+			log.Logger.Debugf("Failed to remove stale temp file %s: %v", match, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// GraphFormat is the format of the task dependency graph.
+type GraphFormat string
+
+// defines formats of the task dependency graph
+const (
+	GraphFormatDot     GraphFormat = "dot"
+	GraphFormatMermaid GraphFormat = "mermaid"
+)
+
+// ParseGraphFormat parses the name of the graph format.
+func ParseGraphFormat(name string) (GraphFormat, error) {
+	format := GraphFormat(name)
+	if format != GraphFormatDot && format != GraphFormatMermaid {
+		return "", errors.Errorf("invalid graph format %s, must be dot or mermaid", name)
+	}
+	return format, nil
+}
+
+// graphNode is a task in the graph, or a dependency which isn't a task of the run.
+type graphNode struct {
+	id      string
+	label   string
+	scope   Scope
+	skipped bool
+	missing bool
+}
+
+// taskGraph is the dependency graph of tasks grouped by phases, edges point from the
+// dependency to the task depending on it.
+type taskGraph struct {
+	phases  []Phase
+	grouped map[Phase][]*graphNode
+	missing []*graphNode
+	edges   [][2]string
+}
+
+// newTaskGraph returns the graph of plans of tasks in the order they run.
+func newTaskGraph(plans []TaskPlan) *taskGraph {
+	g := &taskGraph{grouped: make(map[Phase][]*graphNode)}
+	ids := make(map[string][]string, len(plans))
+	for i, plan := range plans {
+		id := fmt.Sprintf("t%d", i+1)
+		ids[plan.Name] = append(ids[plan.Name], id)
+		label := fmt.Sprintf("%d. %s", i+1, plan.Name)
+		if plan.Service != "" {
+			label += fmt.Sprintf("\n(%s)", plan.Service)
+		}
+		if !plan.Run {
+			label += "\n" + plan.Reason
+		}
+		if !slices.Contains(g.phases, plan.Phase) {
+			g.phases = append(g.phases, plan.Phase)
+		}
+		g.grouped[plan.Phase] = append(g.grouped[plan.Phase],
+			&graphNode{id: id, label: label, scope: plan.Scope, skipped: !plan.Run})
+	}
+	for i, plan := range plans {
+		for _, dep := range plan.Deps {
+			if dep == plan.Name {
+				continue
+			}
+			if _, ok := ids[dep]; !ok {
+				id := fmt.Sprintf("m%d", len(g.missing)+1)
+				ids[dep] = []string{id}
+				g.missing = append(g.missing, &graphNode{id: id, label: dep + "\nnot in the run", missing: true})
+			}
+			for _, from := range ids[dep] {
+				g.edges = append(g.edges, [2]string{from, fmt.Sprintf("t%d", i+1)})
+			}
+		}
+	}
+	return g
+}
+
+// WriteGraph writes the dependency graph of plans of tasks in the format. Tasks are
+// grouped by phases and numbered in the order they run. Node scoped tasks are drawn
+// as stacked boxes, skipped tasks and dependencies which aren't tasks of the run are
+// drawn dashed.
+func WriteGraph(w io.Writer, plans []TaskPlan, format GraphFormat) error {
+	g := newTaskGraph(plans)
+	var out strings.Builder
+	switch format {
+	case GraphFormatDot:
+		g.writeDot(&out)
+	case GraphFormatMermaid:
+		g.writeMermaid(&out)
+	default:
+		return errors.Errorf("invalid graph format %s", format)
+	}
+	_, err := io.WriteString(w, out.String())
+	return errors.Trace(err)
+}
+
+func (g *taskGraph) writeDot(out *strings.Builder) {
+	out.WriteString("digraph tasks {\n")
+	out.WriteString("  rankdir=TB;\n")
+	out.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+	writeNode := func(indent string, n *graphNode) {
+		attrs := []string{fmt.Sprintf("label=%q", n.label)}
+		if n.scope == ScopeNode {
+			attrs = append(attrs, "shape=box3d")
+		}
+		switch {
+		case n.missing:
+			attrs = append(attrs, "style=dotted", "fontcolor=gray")
+		case n.skipped:
+			attrs = append(attrs, "style=dashed", "fontcolor=gray")
+		}
+		fmt.Fprintf(out, "%s%s [%s];\n", indent, n.id, strings.Join(attrs, ", "))
+	}
+	for i, phase := range g.phases {
+		fmt.Fprintf(out, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(out, "    label=%q;\n", "phase "+string(phase))
+		for _, n := range g.grouped[phase] {
+			writeNode("    ", n)
+		}
+		out.WriteString("  }\n")
+	}
+	for _, n := range g.missing {
+		writeNode("  ", n)
+	}
+	for _, edge := range g.edges {
+		fmt.Fprintf(out, "  %s -> %s;\n", edge[0], edge[1])
+	}
+	out.WriteString("}\n")
+}
+
+// mermaidLabel escapes the label for a quoted mermaid label, line breaks are <br/>.
+func mermaidLabel(label string) string {
+	label = strings.ReplaceAll(label, `"`, "#quot;")
+	return strings.ReplaceAll(label, "\n", "<br/>")
+}
+
+func (g *taskGraph) writeMermaid(out *strings.Builder) {
+	out.WriteString("flowchart TD\n")
+	writeNode := func(indent string, n *graphNode) {
+		left, right := "[", "]"
+		if n.scope == ScopeNode {
+			// subroutine shape of node scoped tasks
+			left, right = "[[", "]]"
+		}
+		fmt.Fprintf(out, "%s%s%s\"%s\"%s", indent, n.id, left, mermaidLabel(n.label), right)
+		switch {
+		case n.missing:
+			out.WriteString(":::missing")
+		case n.skipped:
+			out.WriteString(":::skipped")
+		}
+		out.WriteString("\n")
+	}
+	for _, phase := range g.phases {
+		fmt.Fprintf(out, "  subgraph phase_%s [\"phase %s\"]\n", phase, phase)
+		for _, n := range g.grouped[phase] {
+			writeNode("    ", n)
+		}
+		out.WriteString("  end\n")
+	}
+	for _, n := range g.missing {
+		writeNode("  ", n)
+	}
+	for _, edge := range g.edges {
+		fmt.Fprintf(out, "  %s --> %s\n", edge[0], edge[1])
+	}
+	out.WriteString("  classDef skipped stroke-dasharray: 5 5,color:#888\n")
+	out.WriteString("  classDef missing stroke-dasharray: 2 2,color:#888\n")
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+func TestGraphSuite(t *testing.T) {
+	suiteRun(t, new(graphSuite))
+}
+
+type graphSuite struct {
+	baseSuite
+}
+
+var testGraphPlans = []TaskPlan{
+	{
+		Metadata: Metadata{Name: "PreflightTask", Phase: PhasePrepare, Scope: ScopeNode},
+		Run:      false,
+		Reason:   "skipped: excluded by --skip-preflight",
+	},
+	{
+		Metadata: Metadata{Name: "CreateMgmtdServiceTask", Service: config.ServiceMgmtd, Phase: PhaseDeploy,
+			Scope: ScopeNode, Deps: []string{"CreateFdbClusterTask"}},
+		Run: true,
+	},
+	{
+		Metadata: Metadata{Name: "InitUserAndChainTask", Phase: PhaseDeploy, Scope: ScopeCluster,
+			Deps: []string{"CreateMgmtdServiceTask", "InitUserAndChainTask"}},
+		Run: true,
+	},
+}
+
+func (s *graphSuite) TestParseGraphFormat() {
+	format, err := ParseGraphFormat("mermaid")
+	s.NoError(err)
+	s.Equal(GraphFormatMermaid, format)
+	_, err = ParseGraphFormat("svg")
+	s.ErrorContains(err, "invalid graph format svg, must be dot or mermaid")
+}
+
+func (s *graphSuite) TestDot() {
+	buf := new(bytes.Buffer)
+	s.NoError(WriteGraph(buf, testGraphPlans, GraphFormatDot))
+
+	s.Equal(`digraph tasks {
+  rankdir=TB;
+  node [shape=box, fontname="Helvetica"];
+  subgraph cluster_0 {
+    label="phase prepare";
+    t1 [label="1. PreflightTask\nskipped: excluded by --skip-preflight", shape=box3d, style=dashed, fontcolor=gray];
+  }
+  subgraph cluster_1 {
+    label="phase deploy";
+    t2 [label="2. CreateMgmtdServiceTask\n(mgmtd)", shape=box3d];
+    t3 [label="3. InitUserAndChainTask"];
+  }
+  m1 [label="CreateFdbClusterTask\nnot in the run", style=dotted, fontcolor=gray];
+  m1 -> t2;
+  t2 -> t3;
+}
+`, buf.String())
+}
+
+func (s *graphSuite) TestMermaid() {
+	buf := new(bytes.Buffer)
+	s.NoError(WriteGraph(buf, testGraphPlans, GraphFormatMermaid))
+
+	s.Equal(`flowchart TD
+  subgraph phase_prepare ["phase prepare"]
+    t1[["1. PreflightTask<br/>skipped: excluded by --skip-preflight"]]:::skipped
+  end
+  subgraph phase_deploy ["phase deploy"]
+    t2[["2. CreateMgmtdServiceTask<br/>(mgmtd)"]]
+    t3["3. InitUserAndChainTask"]
+  end
+  m1["CreateFdbClusterTask<br/>not in the run"]:::missing
+  m1 --> t2
+  t2 --> t3
+  classDef skipped stroke-dasharray: 5 5,color:#888
+  classDef missing stroke-dasharray: 2 2,color:#888
+`, buf.String())
+}
+
+func (s *graphSuite) TestDuplicateNames() {
+	plans := []TaskPlan{
+		{Metadata: Metadata{Name: "PrepareDisksTask", Phase: PhaseDeploy}, Run: true},
+		{Metadata: Metadata{Name: "PrepareDisksTask", Phase: PhaseDeploy}, Run: true},
+		{Metadata: Metadata{Name: "CreateStorageTask", Phase: PhaseDeploy, Deps: []string{"PrepareDisksTask"}}, Run: true},
+	}
+	s.Equal([][2]string{{"t1", "t3"}, {"t2", "t3"}}, newTaskGraph(plans).edges)
+}
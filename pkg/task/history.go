@@ -0,0 +1,160 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+const historyFileName = "history.jsonl"
+
+// RunHistoryEntry is the summary of a finished run in the run history. Results of nodes
+// are reduced to failed nodes, so that entries stay small for large clusters.
+type RunHistoryEntry struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	Status    RunStatus `json:"status"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Error     string    `json:"error,omitempty"`
+	// Tasks is the number of tasks which have run.
+	Tasks int `json:"tasks"`
+	// LastPhase is the last phase which has started.
+	LastPhase Phase `json:"lastPhase,omitempty"`
+	// FailedNodes maps tasks to their failed nodes.
+	FailedNodes map[string][]string `json:"failedNodes,omitempty"`
+	// FreezeOverride is the reason of running in spite of a deployment freeze.
+	FreezeOverride string `json:"freezeOverride,omitempty"`
+}
+
+// Duration returns the duration of the run.
+func (e *RunHistoryEntry) Duration() time.Duration {
+	return e.EndTime.Sub(e.StartTime)
+}
+
+// NewRunHistoryEntry summarizes the finished run.
+func NewRunHistoryEntry(record *RunRecord) *RunHistoryEntry {
+	entry := &RunHistoryEntry{
+		ID:             record.ID,
+		Command:        record.Command,
+		Status:         record.Status,
+		StartTime:      record.StartTime,
+		Error:          record.Error,
+		Tasks:          len(record.Tasks),
+		FreezeOverride: record.FreezeOverride,
+	}
+	if record.EndTime != nil {
+		entry.EndTime = *record.EndTime
+	}
+	if len(record.Phases) > 0 {
+		entry.LastPhase = record.Phases[len(record.Phases)-1].Phase
+	}
+	for _, result := range record.Tasks {
+		if failed := result.FailedNodes(); len(failed) > 0 {
+			if entry.FailedNodes == nil {
+				entry.FailedNodes = make(map[string][]string)
+			}
+			entry.FailedNodes[result.Name] = failed
+		}
+	}
+	return entry
+}
+
+// HistoryFilePath returns the path of the run history of the cluster.
+func HistoryFilePath(workDir, clusterName string) string {
+	return filepath.Join(ClusterStateDir(workDir, clusterName), historyFileName)
+}
+
+// AppendRunHistory appends the entry to the run history of the cluster, only the latest
+// limit entries are kept.
+func AppendRunHistory(workDir, clusterName string, entry *RunHistoryEntry, limit int) error {
+	entries, err := readRunHistory(workDir, clusterName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	entries = append(entries, entry)
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	path := HistoryFilePath(workDir, clusterName)
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Trace(err)
+	}
+	if err = WriteFileAtomic(path, buf.Bytes(), 0644); err != nil {
+		return errors.Annotate(err, "write run history")
+	}
+	return nil
+}
+
+// LoadRunHistory loads the run history of the cluster, the latest run comes first.
+func LoadRunHistory(workDir, clusterName string) ([]*RunHistoryEntry, error) {
+	entries, err := readRunHistory(workDir, clusterName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// readRunHistory reads entries of the run history in the order they're appended.
+func readRunHistory(workDir, clusterName string) ([]*RunHistoryEntry, error) {
+	path := HistoryFilePath(workDir, clusterName)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Annotate(err, "open run history")
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var entries []*RunHistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		entry := new(RunHistoryEntry)
+		if err = json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return nil, errors.Annotatef(err, "parse line %d of run history %s", line, path)
+		}
+		entries = append(entries, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Annotatef(err, "read run history %s", path)
+	}
+	return entries, nil
+}
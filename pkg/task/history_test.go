@@ -0,0 +1,100 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/common"
+)
+
+func TestHistorySuite(t *testing.T) {
+	suiteRun(t, new(historySuite))
+}
+
+type historySuite struct {
+	baseSuite
+	workDir string
+}
+
+func (s *historySuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.workDir = s.T().TempDir()
+}
+
+func (s *historySuite) TestNewRunHistoryEntry() {
+	start := time.Date(2025, 4, 10, 8, 0, 0, 0, time.UTC)
+	record := &RunRecord{
+		ID:        "run1",
+		Command:   "cluster create",
+		Status:    RunStatusFailed,
+		StartTime: start,
+		EndTime:   common.Pointer(start.Add(time.Minute)),
+		Error:     "dummy error",
+		Tasks: []*TaskResult{
+			{Name: "task1", NodeResults: map[string]string{"node1": NodeStatusOK}},
+			{Name: "task2", NodeResults: map[string]string{"node1": NodeStatusFailed, "node2": NodeStatusOK}},
+		},
+		Phases: []*PhaseRecord{{Phase: PhasePrepare}, {Phase: PhaseDeploy}},
+	}
+
+	entry := NewRunHistoryEntry(record)
+
+	s.Equal(&RunHistoryEntry{
+		ID:          "run1",
+		Command:     "cluster create",
+		Status:      RunStatusFailed,
+		StartTime:   start,
+		EndTime:     start.Add(time.Minute),
+		Error:       "dummy error",
+		Tasks:       2,
+		LastPhase:   PhaseDeploy,
+		FailedNodes: map[string][]string{"task2": {"node1"}},
+	}, entry)
+	s.Equal(time.Minute, entry.Duration())
+}
+
+func (s *historySuite) TestAppendRunHistory() {
+	for i := 1; i <= 4; i++ {
+		entry := &RunHistoryEntry{ID: fmt.Sprintf("run%d", i), Status: RunStatusSucceeded}
+		s.NoError(AppendRunHistory(s.workDir, "test", entry, 3))
+	}
+
+	entries, err := LoadRunHistory(s.workDir, "test")
+	s.NoError(err)
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	s.Equal([]string{"run4", "run3", "run2"}, ids)
+}
+
+func (s *historySuite) TestLoadRunHistoryWithoutHistory() {
+	entries, err := LoadRunHistory(s.workDir, "test")
+	s.NoError(err)
+	s.Empty(entries)
+}
+
+func (s *historySuite) TestLoadRunHistoryCorrupted() {
+	path := HistoryFilePath(s.workDir, "test")
+	s.NoError(os.MkdirAll(ClusterStateDir(s.workDir, "test"), 0755))
+	s.NoError(os.WriteFile(path, []byte("{\"id\":\"run1\"}\n\n{"), 0644))
+
+	_, err := LoadRunHistory(s.workDir, "test")
+	s.ErrorContains(err, "parse line 3 of run history")
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+const lockFileName = "lock"
+
+// errCorruptLock is the error of lock files which can't be parsed, e.g. ones left by
+// older versions which wrote lock files in place and were killed while writing them.
+var errCorruptLock = errors.New("lock file is corrupt")
+
+// LockInfo records the holder of a cluster lock.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Command   string    `json:"command"`
+	StartTime time.Time `json:"startTime"`
+}
+
+// local returns whether the holder runs on this host, whose process can be checked.
+func (i *LockInfo) local() bool {
+	host, err := os.Hostname()
+	return err == nil && host == i.Host
+}
+
+// alive returns whether the holder process is still running. A holder on
+// another host is always considered alive because it can't be checked.
+func (i *LockInfo) alive() bool {
+	if !i.local() {
+		return true
+	}
+	process, err := os.FindProcess(i.PID)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// Lock is a file lock which prevents concurrent mutating runs against a cluster.
+type Lock struct {
+	path string
+}
+
+// LockFilePath returns path of the lock file of the cluster.
+func LockFilePath(workDir, clusterName string) string {
+	return filepath.Join(ClusterStateDir(workDir, clusterName), lockFileName)
+}
+
+// AcquireLock acquires the lock of the cluster for the command. A lock left by
+// a gone process is broken only if forceUnlock is set. A lock held on another host,
+// e.g. sharing the work dir on shared storage, can't be checked, so it's also broken
+// by forceUnlock, in case the host crashed or was renamed.
+func AcquireLock(workDir, clusterName, command string, forceUnlock bool) (*Lock, error) {
+	lockPath := LockFilePath(workDir, clusterName)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, errors.Annotatef(err, "create directory of %s", lockPath)
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Annotate(err, "get hostname")
+	}
+	data, err := json.Marshal(&LockInfo{
+		PID:       os.Getpid(),
+		Host:      host,
+		Command:   command,
+		StartTime: time.Now(),
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	for {
+		err := createLockFile(lockPath, data)
+		if err == nil {
+			return &Lock{path: lockPath}, nil
+		}
+		if !os.IsExist(errors.Cause(err)) {
+			return nil, errors.Trace(err)
+		}
+
+		info, err := ReadLockInfo(lockPath)
+		if err != nil {
+			if errors.Cause(err) != errCorruptLock {
+				return nil, errors.Trace(err)
+			}
+			if !forceUnlock {
+				return nil, errors.Annotate(err, "use --force-unlock to break it")
+			}
+			logrus.Warnf("Breaking corrupt lock: %v", err)
+			if err = os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+				return nil, errors.Annotatef(err, "remove corrupt lock file %s", lockPath)
+			}
+			continue
+		}
+		if info == nil {
+			// the lock is released by the holder just now
+			continue
+		}
+		if !info.local() {
+			if !forceUnlock {
+				return nil, errors.Errorf("cluster %s is locked by %q (pid %d on %s) since %s, "+
+					"if it's gone, use --force-unlock to break it or remove %s",
+					clusterName, info.Command, info.PID, info.Host, info.StartTime.Format(time.DateTime), lockPath)
+			}
+			logrus.Warnf("Breaking lock held by %q (pid %d on %s) since %s, which can't be checked "+
+				"from this host. Concurrent runs against the cluster break it if the holder is still running!",
+				info.Command, info.PID, info.Host, info.StartTime.Format(time.DateTime))
+			if err = os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+				return nil, errors.Annotatef(err, "remove lock file %s", lockPath)
+			}
+			continue
+		}
+		if info.alive() {
+			return nil, errors.Errorf("cluster %s is locked by %q (pid %d on %s) since %s",
+				clusterName, info.Command, info.PID, info.Host, info.StartTime.Format(time.DateTime))
+		}
+		if !forceUnlock {
+			return nil, errors.Errorf("cluster %s has a stale lock left by %q (pid %d on %s) since %s, "+
+				"use --force-unlock to break it",
+				clusterName, info.Command, info.PID, info.Host, info.StartTime.Format(time.DateTime))
+		}
+		logrus.Warnf("Breaking stale lock left by %q (pid %d on %s)", info.Command, info.PID, info.Host)
+		if err = os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return nil, errors.Annotatef(err, "remove stale lock file %s", lockPath)
+		}
+	}
+}
+
+// createLockFile writes the lock file to a temporary file and links it into place, so
+// that the lock file is created with its content at once and other processes never read
+// a partial lock file. Linking fails if the lock file exists.
+func createLockFile(lockPath string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(lockPath), "."+lockFileName+"-*")
+	if err != nil {
+		return errors.Annotatef(err, "create temporary lock file of %s", lockPath)
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Annotatef(err, "write lock file %s", lockPath)
+	}
+	if err = os.Link(file.Name(), lockPath); err != nil {
+		return errors.Annotatef(err, "create lock file %s", lockPath)
+	}
+	return nil
+}
+
+// ReadLockInfo reads holder of the lock file, it returns nil if the lock file doesn't exist.
+func ReadLockInfo(lockPath string) (*LockInfo, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Annotatef(err, "read lock file %s", lockPath)
+	}
+	info := new(LockInfo)
+	if err = json.Unmarshal(data, info); err != nil {
+		return nil, errors.Annotatef(errCorruptLock, "parse lock file %s: %v", lockPath, err)
+	}
+	return info, nil
+}
+
+// Release releases the lock.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return errors.Annotatef(err, "remove lock file %s", l.path)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockSuite(t *testing.T) {
+	suiteRun(t, new(lockSuite))
+}
+
+type lockSuite struct {
+	baseSuite
+	workDir string
+}
+
+func (s *lockSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.workDir = s.T().TempDir()
+}
+
+func (s *lockSuite) writeLock(info *LockInfo) {
+	lockPath := LockFilePath(s.workDir, "test")
+	s.NoError(os.MkdirAll(filepath.Dir(lockPath), 0755))
+	data, err := json.Marshal(info)
+	s.NoError(err)
+	s.NoError(os.WriteFile(lockPath, data, 0644))
+}
+
+func (s *lockSuite) TestAcquireAndRelease() {
+	lock, err := AcquireLock(s.workDir, "test", "cluster create", false)
+	s.NoError(err)
+
+	info, err := ReadLockInfo(LockFilePath(s.workDir, "test"))
+	s.NoError(err)
+	s.Equal(os.Getpid(), info.PID)
+	s.Equal("cluster create", info.Command)
+	host, _ := os.Hostname()
+	s.Equal(host, info.Host)
+
+	s.NoError(lock.Release())
+	info, err = ReadLockInfo(LockFilePath(s.workDir, "test"))
+	s.NoError(err)
+	s.Nil(info)
+}
+
+func (s *lockSuite) TestAcquireLocked() {
+	lock, err := AcquireLock(s.workDir, "test", "cluster create", false)
+	s.NoError(err)
+	defer func() { s.NoError(lock.Release()) }()
+
+	_, err = AcquireLock(s.workDir, "test", "cluster delete", true)
+	s.Error(err)
+	s.Contains(err.Error(), `cluster test is locked by "cluster create"`)
+}
+
+func (s *lockSuite) TestAcquireStaleLock() {
+	host, _ := os.Hostname()
+	// pid out of range of linux pid_max, so the process never exists
+	s.writeLock(&LockInfo{PID: 1 << 30, Host: host, Command: "cluster create", StartTime: time.Now()})
+
+	_, err := AcquireLock(s.workDir, "test", "cluster delete", false)
+	s.Error(err)
+	s.Contains(err.Error(), "use --force-unlock to break it")
+
+	lock, err := AcquireLock(s.workDir, "test", "cluster delete", true)
+	s.NoError(err)
+	info, err := ReadLockInfo(LockFilePath(s.workDir, "test"))
+	s.NoError(err)
+	s.Equal("cluster delete", info.Command)
+	s.NoError(lock.Release())
+}
+
+func (s *lockSuite) TestAcquireLockedByOtherHost() {
+	s.writeLock(&LockInfo{PID: 1 << 30, Host: "other-host", Command: "cluster create", StartTime: time.Now()})
+
+	_, err := AcquireLock(s.workDir, "test", "cluster delete", false)
+	s.Error(err)
+	s.Contains(err.Error(), "on other-host")
+	s.Contains(err.Error(), "use --force-unlock to break it or remove "+LockFilePath(s.workDir, "test"))
+
+	// the holder on another host can't be checked, it's broken by force
+	lock, err := AcquireLock(s.workDir, "test", "cluster delete", true)
+	s.NoError(err)
+	info, err := ReadLockInfo(LockFilePath(s.workDir, "test"))
+	s.NoError(err)
+	s.Equal("cluster delete", info.Command)
+	s.NoError(lock.Release())
+}
+
+func (s *lockSuite) TestAcquireCorruptLock() {
+	lockPath := LockFilePath(s.workDir, "test")
+	s.NoError(os.MkdirAll(filepath.Dir(lockPath), 0755))
+	// a lock file written partially
+	s.NoError(os.WriteFile(lockPath, []byte(`{"pid":`), 0644))
+
+	_, err := AcquireLock(s.workDir, "test", "cluster delete", false)
+	s.Error(err)
+	s.Contains(err.Error(), "lock file is corrupt")
+	s.Contains(err.Error(), "use --force-unlock to break it")
+
+	lock, err := AcquireLock(s.workDir, "test", "cluster delete", true)
+	s.NoError(err)
+	info, err := ReadLockInfo(lockPath)
+	s.NoError(err)
+	s.Equal("cluster delete", info.Command)
+	s.NoError(lock.Release())
+
+	// temporary lock files are removed
+	entries, err := os.ReadDir(filepath.Dir(lockPath))
+	s.NoError(err)
+	s.Empty(entries)
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+// Scope is the scope of a task.
+type Scope string
+
+// defines scopes of tasks
+const (
+	// ScopeNode means steps of the task run on each node of the service.
+	ScopeNode Scope = "node"
+	// ScopeCluster means steps of the task run once for the whole cluster.
+	ScopeCluster Scope = "cluster"
+)
+
+// Metadata describes a task, it's available after the task is initialized.
+type Metadata struct {
+	Name    string             `json:"name"`
+	Service config.ServiceType `json:"service,omitempty"`
+	Phase   Phase              `json:"phase"`
+	Scope   Scope              `json:"scope"`
+	Deps    []string           `json:"deps,omitempty"`
+	Steps   int                `json:"steps"`
+}
+
+// SetService sets the service the task belongs to.
+func (t *BaseTask) SetService(service config.ServiceType) {
+	t.service = service
+}
+
+// SetDeps sets names of tasks which must complete before the task runs.
+func (t *BaseTask) SetDeps(deps ...string) {
+	t.deps = deps
+}
+
+// Metadata returns metadata of the task. The task is node scoped if any step runs
+// on more than one node, otherwise it's cluster scoped.
+func (t *BaseTask) Metadata() Metadata {
+	scope := ScopeCluster
+	for _, step := range t.steps {
+		if len(step.Nodes) > 1 || step.Parallel {
+			scope = ScopeNode
+			break
+		}
+	}
+	return Metadata{
+		Name:    t.Name(),
+		Service: t.service,
+		Phase:   t.taskPhase(),
+		Scope:   scope,
+		Deps:    t.deps,
+		Steps:   len(t.steps),
+	}
+}
+
+// MetadataOf returns metadata of the initialized task, tasks not embedding BaseTask
+// only have names in metadata.
+func MetadataOf(t Interface) Metadata {
+	if describer, ok := t.(interface{ Metadata() Metadata }); ok {
+		return describer.Metadata()
+	}
+	return Metadata{Name: t.Name(), Phase: PhasePrepare, Scope: ScopeCluster}
+}
+
+// StepPlan describes a step of a task and nodes it runs on.
+type StepPlan struct {
+	Name     string   `json:"name"`
+	Nodes    []string `json:"nodes"`
+	Parallel bool     `json:"parallel"`
+	// Batches are sizes of batches of nodes if the step follows the deployment strategy.
+	Batches []int `json:"batches,omitempty"`
+}
+
+// StepPlans returns plans of steps of the initialized task, nodes not selected by the
+// node filter of the runtime are excluded. Steps are created but not initialized.
+func (t *BaseTask) StepPlans() []StepPlan {
+	plans := make([]StepPlan, 0, len(t.steps))
+	for _, step := range t.steps {
+		nodes := t.filterNodes(step.Nodes)
+		plan := StepPlan{
+			Name:     stepName(step.NewStep()),
+			Parallel: step.Parallel && len(nodes) > 1 && t.maxParallel(step) != 1,
+		}
+		if batches := t.rolloutBatches(step, len(nodes)); batches != nil {
+			plan.Parallel = slices.Max(batches) > 1
+			plan.Batches = batches
+		}
+		for _, node := range nodes {
+			plan.Nodes = append(plan.Nodes, node.Name)
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+// StepPlansOf returns plans of steps of the initialized task, they're empty for tasks
+// not embedding BaseTask.
+func StepPlansOf(t Interface) []StepPlan {
+	if describer, ok := t.(interface{ StepPlans() []StepPlan }); ok {
+		return describer.StepPlans()
+	}
+	return nil
+}
+
+// stepName returns the readable name of the step from its type name, e.g.
+// "run 3FS container" of run3FSContainerStep.
+func stepName(step Step) string {
+	typ := reflect.TypeOf(step)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	var words []string
+	for _, word := range common.Split(strings.TrimSuffix(typ.Name(), "Step")) {
+		if strings.ToUpper(word) != word {
+			word = strings.ToLower(word)
+		} else if n := len(words); n > 0 && words[n-1][0] >= '0' && words[n-1][0] <= '9' {
+			// digits are part of the acronym like 3FS
+			words[n-1] += word
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// defines statuses of nodes in a task.
+const (
+	NodeStatusOK     = "ok"
+	NodeStatusFailed = "failed"
+)
+
+// TaskResult records statuses of nodes which a task of a run ran on, so that
+// a partially failed task is reported at node granularity.
+type TaskResult struct {
+	Name        string            `json:"name"`
+	NodeResults map[string]string `json:"nodeResults,omitempty"`
+}
+
+// FailedNodes returns sorted names of failed nodes.
+func (t *TaskResult) FailedNodes() []string {
+	var nodes []string
+	for node, status := range t.NodeResults {
+		if status == NodeStatusFailed {
+			nodes = append(nodes, node)
+		}
+	}
+	slices.Sort(nodes)
+	return nodes
+}
+
+// Summary returns the summary of node statuses, e.g.
+// "deploy storage: 47 ok, 3 failed (node-12, node-33, node-41)".
+func (t *TaskResult) Summary() string {
+	failed := t.FailedNodes()
+	summary := fmt.Sprintf("%s: %d ok, %d failed", t.Name, len(t.NodeResults)-len(failed), len(failed))
+	if len(failed) > 0 {
+		summary += fmt.Sprintf(" (%s)", strings.Join(failed, ", "))
+	}
+	return summary
+}
+
+// recordNodeResult records the status of the node in the task, a failed node
+// stays failed even if it succeeds in later steps.
+func (r *Runtime) recordNodeResult(task, node string, err error) {
+	r.reportNode(task, node, r.setNodeResult(task, node, err))
+}
+
+// setNodeResult sets the status of the node in the task and returns it.
+func (r *Runtime) setNodeResult(task, node string, err error) string {
+	r.nodeResultsMu.Lock()
+	defer r.nodeResultsMu.Unlock()
+	if r.nodeResults == nil {
+		r.nodeResults = make(map[string]map[string]string)
+	}
+	results, ok := r.nodeResults[task]
+	if !ok {
+		results = make(map[string]string)
+		r.nodeResults[task] = results
+	}
+	if err != nil {
+		results[node] = NodeStatusFailed
+	} else if results[node] != NodeStatusFailed {
+		results[node] = NodeStatusOK
+	}
+	return results[node]
+}
+
+// TaskResult returns statuses of nodes which the task ran steps on.
+func (r *Runtime) TaskResult(task string) *TaskResult {
+	r.nodeResultsMu.Lock()
+	defer r.nodeResultsMu.Unlock()
+	return &TaskResult{Name: task, NodeResults: maps.Clone(r.nodeResults[task])}
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// nodesPerIdleCPU is the number of nodes running a step in parallel per idle CPU of the
+// deploy host by auto parallelism, SSH sessions mostly wait for nodes.
+const nodesPerIdleCPU = 4
+
+var (
+	numCPU = runtime.NumCPU
+	// loadAverage returns the 1 minute load average of the deploy host.
+	loadAverage = readLoadAverage
+	// parallelSampleInterval is the interval of sampling the load of the deploy host
+	// while a step backs off.
+	parallelSampleInterval = 5 * time.Second
+)
+
+func readLoadAverage() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, errors.Errorf("invalid /proc/loadavg: %q", data)
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	return load, errors.Annotatef(err, "parse /proc/loadavg")
+}
+
+// autoParallel returns the max number of nodes running a step in parallel derived from the
+// CPUs and the load average of the deploy host, within bounds of the config.
+func autoParallel(cfg config.AutoParallel, cpus int, load float64) int {
+	lower, upper := cfg.Bounds()
+	idle := max(float64(cpus)-load, 1)
+	return min(max(int(idle*nodesPerIdleCPU), lower), upper)
+}
+
+// getAutoParallel returns the max number of nodes running a step in parallel by auto
+// parallelism, it's computed once per run and 0 if auto parallelism isn't enabled.
+func (r *Runtime) getAutoParallel() int {
+	r.autoParallelOnce.Do(func() {
+		if r.Cfg == nil || !r.Cfg.Deployment.AutoParallel.Enabled {
+			return
+		}
+		cpus := numCPU()
+		load, err := loadAverage()
+		if err != nil {
+			logrus.Warnf("Failed to get load average of the deploy host, assuming it's idle: %v", err)
+		}
+		r.autoParallel = autoParallel(r.Cfg.Deployment.AutoParallel, cpus, load)
+		logrus.Infof("Auto parallelism runs steps on up to %d nodes in parallel (%d CPUs, load average %.2f)",
+			r.autoParallel, cpus, load)
+	})
+	return r.autoParallel
+}
+
+// parallelGate limits the number of nodes running a step in parallel, the limit is halved
+// while the deploy host is overloaded and raised by one node per sample once it isn't, but
+// stays within bounds. It's safe for concurrent use.
+type parallelGate struct {
+	mu       sync.Mutex
+	limit    int
+	lower    int
+	upper    int
+	running  int
+	cpus     int
+	overload float64
+	// changed is closed and replaced whenever a node may acquire the gate.
+	changed chan struct{}
+}
+
+func newParallelGate(limit int, cfg config.AutoParallel) *parallelGate {
+	lower, _ := cfg.Bounds()
+	return &parallelGate{
+		limit:    limit,
+		lower:    min(lower, limit),
+		upper:    limit,
+		cpus:     numCPU(),
+		overload: cfg.OverloadedLoad(),
+		changed:  make(chan struct{}),
+	}
+}
+
+// notify wakes up nodes waiting for the gate, the lock must be held.
+func (g *parallelGate) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// acquire waits until the node can run the step.
+func (g *parallelGate) acquire(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		if g.running < g.limit {
+			g.running++
+			g.mu.Unlock()
+			return nil
+		}
+		changed := g.changed
+		g.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+func (g *parallelGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.running--
+	g.notify()
+}
+
+// adjust updates the limit by the load average of the deploy host.
+func (g *parallelGate) adjust(load float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	perCPU := load / float64(max(g.cpus, 1))
+	switch {
+	case perCPU > g.overload && g.limit > g.lower:
+		g.limit = max(g.limit/2, g.lower)
+		logrus.Warnf("Deploy host is overloaded (load average %.2f of %d CPUs), running steps on up to %d nodes",
+			load, g.cpus, g.limit)
+	case perCPU <= g.overload && g.limit < g.upper:
+		g.limit++
+		g.notify()
+		if g.limit == g.upper {
+			logrus.Infof("Deploy host isn't overloaded any more, running steps on up to %d nodes", g.limit)
+		}
+	}
+}
+
+// watch samples the load average of the deploy host and adjusts the limit until the
+// context is done.
+func (g *parallelGate) watch(ctx context.Context) {
+	ticker := time.NewTicker(parallelSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if load, err := loadAverage(); err == nil {
+				g.adjust(load)
+			}
+		}
+	}
+}
+
+// PhaseParallel returns the max number of nodes running in parallel in the phase, which
+// is limited by the config or by auto parallelism if the phase isn't limited. It's n if
+// nothing limits the phase.
+func (r *Runtime) PhaseParallel(phase Phase, n int) int {
+	if r.Cfg == nil {
+		return n
+	}
+	limit := r.Cfg.Deployment.PhaseMaxParallel(string(phase))
+	if limit == 0 {
+		limit = r.getAutoParallel()
+	}
+	if limit > 0 && limit < n {
+		return limit
+	}
+	return n
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestParallelSuite(t *testing.T) {
+	suiteRun(t, new(parallelSuite))
+}
+
+type parallelSuite struct {
+	baseSuite
+	load float64
+}
+
+func (s *parallelSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.load = 0
+	oldNumCPU, oldLoadAverage := numCPU, loadAverage
+	numCPU = func() int { return 4 }
+	loadAverage = func() (float64, error) { return s.load, nil }
+	s.T().Cleanup(func() {
+		numCPU, loadAverage = oldNumCPU, oldLoadAverage
+	})
+}
+
+func (s *parallelSuite) TestAutoParallel() {
+	cfg := config.AutoParallel{Enabled: true}
+	s.Equal(16, autoParallel(cfg, 4, 0))
+	s.Equal(6, autoParallel(cfg, 4, 2.5))
+	// an overloaded host still runs steps on nodes of an idle CPU
+	s.Equal(4, autoParallel(cfg, 4, 10))
+	s.Equal(64, autoParallel(cfg, 64, 0))
+
+	cfg.Min, cfg.Max = 8, 12
+	s.Equal(12, autoParallel(cfg, 4, 0))
+	s.Equal(8, autoParallel(cfg, 4, 10))
+}
+
+func (s *parallelSuite) TestGetAutoParallel() {
+	runtime := &Runtime{Cfg: &config.Config{}}
+	s.Equal(0, runtime.getAutoParallel())
+
+	runtime = &Runtime{Cfg: &config.Config{}}
+	runtime.Cfg.Deployment.AutoParallel.Enabled = true
+	s.load = 1
+	s.Equal(12, runtime.getAutoParallel())
+	// it's computed once per run
+	s.load = 0
+	s.Equal(12, runtime.getAutoParallel())
+
+	runtime = &Runtime{Cfg: &config.Config{}}
+	runtime.Cfg.Deployment.AutoParallel.Enabled = true
+	loadAverage = func() (float64, error) { return 0, errors.New("no /proc") }
+	s.Equal(16, runtime.getAutoParallel())
+}
+
+func (s *parallelSuite) TestPhaseParallel() {
+	runtime := &Runtime{Cfg: &config.Config{}}
+	s.Equal(20, runtime.PhaseParallel(PhaseDeploy, 20))
+
+	runtime = &Runtime{Cfg: &config.Config{}}
+	runtime.Cfg.Deployment.AutoParallel.Enabled = true
+	s.Equal(16, runtime.PhaseParallel(PhaseDeploy, 20))
+	s.Equal(3, runtime.PhaseParallel(PhaseDeploy, 3))
+
+	limit := 2
+	runtime.Cfg.Deployment.Phases = map[string]config.PhaseDeployment{"deploy": {MaxParallel: &limit}}
+	s.Equal(2, runtime.PhaseParallel(PhaseDeploy, 20))
+	s.Equal(16, runtime.PhaseParallel(PhasePrepare, 20))
+}
+
+func (s *parallelSuite) TestGateAdjust() {
+	g := newParallelGate(8, config.AutoParallel{Min: 3})
+	g.adjust(6)
+	s.Equal(8, g.limit)
+	// load above 1.5 per CPU halves the limit down to min
+	g.adjust(6.1)
+	s.Equal(4, g.limit)
+	g.adjust(7)
+	s.Equal(3, g.limit)
+	g.adjust(7)
+	s.Equal(3, g.limit)
+	// it's raised by one node per sample up to the initial limit
+	for range 10 {
+		g.adjust(1)
+	}
+	s.Equal(8, g.limit)
+
+	// min isn't above the initial limit
+	g = newParallelGate(2, config.AutoParallel{Min: 3})
+	g.adjust(100)
+	s.Equal(2, g.limit)
+}
+
+func (s *parallelSuite) TestGateAcquire() {
+	g := newParallelGate(2, config.AutoParallel{})
+	ctx := s.Ctx()
+	s.NoError(g.acquire(ctx))
+	s.NoError(g.acquire(ctx))
+	g.adjust(100)
+	g.release()
+	g.release()
+
+	s.NoError(g.acquire(ctx))
+	acquired := make(chan error)
+	go func() {
+		acquired <- g.acquire(ctx)
+	}()
+	select {
+	case <-acquired:
+		s.Fail("acquired the gate beyond the limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+	g.adjust(0)
+	s.NoError(<-acquired)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	s.ErrorContains(g.acquire(cancelCtx), context.Canceled.Error())
+}
+
+func (s *parallelSuite) TestStepAutoParallel() {
+	nodes := []config.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}, {Name: "node4"}}
+	cfg := &config.Config{Name: "test", Nodes: nodes, ContainerRuntime: config.ContainerRuntimeDocker}
+	cfg.Deployment.AutoParallel = config.AutoParallel{Enabled: true, Max: 2, Backoff: true}
+	runtime := &Runtime{Cfg: cfg, NewNodeManager: externaltest.NewScript().NodeManager}
+	c := new(concurrency)
+	t := new(BaseTask)
+	t.SetName("testTask")
+	t.SetService(config.ServiceStorage)
+	t.Init(runtime, log.Logger)
+	t.SetSteps([]StepConfig{{
+		Nodes:    nodes,
+		Parallel: true,
+		NewStep:  func() Step { return &concurrentStep{c: c} },
+	}})
+
+	limit, auto := t.parallelLimit(t.steps[0])
+	s.Equal(2, limit)
+	s.True(auto)
+	s.NoError(t.Run(s.Ctx()))
+	s.Equal(2, c.peak)
+
+	// the limit of the phase overrides auto parallelism
+	three := 3
+	cfg.Deployment.Phases = map[string]config.PhaseDeployment{string(PhaseDeploy): {MaxParallel: &three}}
+	limit, auto = t.parallelLimit(t.steps[0])
+	s.Equal(3, limit)
+	s.False(auto)
+}
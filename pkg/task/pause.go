@@ -0,0 +1,145 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+const pauseFileName = "pause"
+
+// pauseCheckInterval is the interval of checking whether a paused run is resumed.
+var pauseCheckInterval = 2 * time.Second
+
+// PauseInfo records a pause of runs of a cluster. Runs wait before their next task
+// until the cluster is resumed, the pause survives crashes of runs as it's a file.
+type PauseInfo struct {
+	Time time.Time `json:"time"`
+	// By is what paused the cluster, e.g. a command or a signal.
+	By string `json:"by,omitempty"`
+}
+
+// PauseFilePath returns path of the pause file of the cluster.
+func PauseFilePath(workDir, clusterName string) string {
+	return filepath.Join(ClusterStateDir(workDir, clusterName), pauseFileName)
+}
+
+// PauseCluster pauses runs of the cluster, it returns false if the cluster has been paused.
+func PauseCluster(workDir, clusterName, by string) (bool, error) {
+	pausePath := PauseFilePath(workDir, clusterName)
+	if err := os.MkdirAll(filepath.Dir(pausePath), 0755); err != nil {
+		return false, errors.Annotatef(err, "create directory of %s", pausePath)
+	}
+	data, err := json.Marshal(&PauseInfo{Time: time.Now(), By: by})
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	file, err := os.OpenFile(pausePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, errors.Annotatef(err, "create pause file %s", pausePath)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(pausePath)
+		return false, errors.Annotatef(err, "write pause file %s", pausePath)
+	}
+	return true, nil
+}
+
+// ResumeCluster resumes paused runs of the cluster, it returns false if the cluster isn't paused.
+func ResumeCluster(workDir, clusterName string) (bool, error) {
+	pausePath := PauseFilePath(workDir, clusterName)
+	if err := os.Remove(pausePath); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Annotatef(err, "remove pause file %s", pausePath)
+	}
+	return true, nil
+}
+
+// ReadPauseInfo reads the pause of the cluster, it returns nil if the cluster isn't paused.
+func ReadPauseInfo(workDir, clusterName string) (*PauseInfo, error) {
+	pausePath := PauseFilePath(workDir, clusterName)
+	data, err := os.ReadFile(pausePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Annotatef(err, "read pause file %s", pausePath)
+	}
+	info := new(PauseInfo)
+	if len(data) == 0 {
+		// the pause file may be created by hand
+		return info, nil
+	}
+	if err = json.Unmarshal(data, info); err != nil {
+		return nil, errors.Annotatef(err, "parse pause file %s", pausePath)
+	}
+	return info, nil
+}
+
+// waitIfPaused waits before the task until the cluster is resumed if it's paused. The
+// record of the run is paused with the task to run next while waiting.
+func (r *Runner) waitIfPaused(ctx context.Context, task Interface) error {
+	if r.record == nil {
+		return nil
+	}
+	pausePath := PauseFilePath(r.cfg.WorkDir, r.cfg.Name)
+	paused := false
+	ticker := time.NewTicker(pauseCheckInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := os.Stat(pausePath); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return errors.Annotatef(err, "check pause file %s", pausePath)
+		}
+		if !paused {
+			paused = true
+			r.record.Status = RunStatusPaused
+			r.record.NextTask = task.Name()
+			r.saveProgress()
+			logrus.Infof("Run %s is paused before task %s, resume it by `m3fs cluster resume`",
+				r.runID, task.Name())
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Annotatef(ctx.Err(), "wait for resume before task %s", task.Name())
+		case <-ticker.C:
+		}
+	}
+	if paused {
+		r.record.Status = RunStatusRunning
+		r.record.NextTask = ""
+		r.saveProgress()
+		logrus.Infof("Run %s is resumed", r.runID)
+	}
+	return nil
+}
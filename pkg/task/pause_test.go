@@ -0,0 +1,157 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+func TestPauseSuite(t *testing.T) {
+	suiteRun(t, new(pauseSuite))
+}
+
+type pauseSuite struct {
+	baseSuite
+	workDir  string
+	interval time.Duration
+}
+
+func (s *pauseSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.workDir = s.T().TempDir()
+	s.interval = pauseCheckInterval
+	pauseCheckInterval = 10 * time.Millisecond
+}
+
+func (s *pauseSuite) TearDownTest() {
+	pauseCheckInterval = s.interval
+}
+
+func (s *pauseSuite) TestPauseAndResume() {
+	info, err := ReadPauseInfo(s.workDir, "test")
+	s.NoError(err)
+	s.Nil(info)
+
+	paused, err := PauseCluster(s.workDir, "test", "cluster pause")
+	s.NoError(err)
+	s.True(paused)
+	paused, err = PauseCluster(s.workDir, "test", "SIGUSR1")
+	s.NoError(err)
+	s.False(paused)
+	info, err = ReadPauseInfo(s.workDir, "test")
+	s.NoError(err)
+	s.Equal("cluster pause", info.By)
+	s.False(info.Time.IsZero())
+
+	resumed, err := ResumeCluster(s.workDir, "test")
+	s.NoError(err)
+	s.True(resumed)
+	resumed, err = ResumeCluster(s.workDir, "test")
+	s.NoError(err)
+	s.False(resumed)
+	info, err = ReadPauseInfo(s.workDir, "test")
+	s.NoError(err)
+	s.Nil(info)
+}
+
+func (s *pauseSuite) TestReadEmptyPauseFile() {
+	pausePath := PauseFilePath(s.workDir, "test")
+	s.NoError(os.MkdirAll(ClusterStateDir(s.workDir, "test"), 0755))
+	s.NoError(os.WriteFile(pausePath, nil, 0644))
+
+	info, err := ReadPauseInfo(s.workDir, "test")
+	s.NoError(err)
+	s.NotNil(info)
+}
+
+func (s *pauseSuite) newRunner(tasks ...Interface) *Runner {
+	runner := &Runner{tasks: tasks, cfg: &config.Config{Name: "test", WorkDir: s.workDir}, quiet: true}
+	s.NoError(runner.SetRun("create", "run1"))
+	runner.Init()
+	return runner
+}
+
+// waitPausedRecord waits until the record of the run is paused.
+func (s *pauseSuite) waitPausedRecord() *RunRecord {
+	var record *RunRecord
+	s.Eventually(func() bool {
+		records, err := LoadRunRecords(s.workDir, "test")
+		if err != nil || len(records) == 0 || records[0].Status != RunStatusPaused {
+			return false
+		}
+		record = records[0]
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	return record
+}
+
+func (s *pauseSuite) TestRunnerPausesBetweenTasks() {
+	task1, task2 := new(mockTask), new(mockTask)
+	task1.On("Init", mock.AnythingOfType("*task.Runtime"))
+	task1.On("Name").Return("task1")
+	task2.On("Init", mock.AnythingOfType("*task.Runtime"))
+	task2.On("Name").Return("task2")
+	task1.On("Run").Return(nil).Run(func(mock.Arguments) {
+		_, err := PauseCluster(s.workDir, "test", "cluster pause")
+		s.NoError(err)
+	})
+	task2.On("Run").Return(nil)
+	runner := s.newRunner(task1, task2)
+
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(s.Ctx()) }()
+
+	record := s.waitPausedRecord()
+	s.Equal("task2", record.NextTask)
+	task2.AssertNotCalled(s.T(), "Run")
+
+	_, err := ResumeCluster(s.workDir, "test")
+	s.NoError(err)
+	s.NoError(<-done)
+	task2.AssertCalled(s.T(), "Run")
+	records, err := LoadRunRecords(s.workDir, "test")
+	s.NoError(err)
+	s.Equal(RunStatusSucceeded, records[0].Status)
+	s.Empty(records[0].NextTask)
+}
+
+func (s *pauseSuite) TestRunnerCanceledWhilePaused() {
+	task1 := new(mockTask)
+	task1.On("Init", mock.AnythingOfType("*task.Runtime"))
+	task1.On("Name").Return("task1")
+	_, err := PauseCluster(s.workDir, "test", "cluster pause")
+	s.NoError(err)
+	runner := s.newRunner(task1)
+
+	ctx, cancel := context.WithCancel(s.Ctx())
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(ctx) }()
+	s.Equal("task1", s.waitPausedRecord().NextTask)
+	cancel()
+
+	err = <-done
+	s.ErrorContains(err, "wait for resume before task task1")
+	task1.AssertNotCalled(s.T(), "Run")
+	info, err := ReadPauseInfo(s.workDir, "test")
+	s.NoError(err)
+	s.NotNil(info)
+}
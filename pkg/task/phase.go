@@ -0,0 +1,81 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// Phase is a named group of consecutive tasks of a run, the run can pause at
+// boundaries of phases for approval.
+type Phase string
+
+// defines phases of tasks in order.
+const (
+	// PhasePrepare checks and prepares nodes before services are deployed.
+	PhasePrepare Phase = "prepare"
+	// PhaseDeploy deploys services.
+	PhaseDeploy Phase = "deploy"
+	// PhaseVerify verifies deployed services.
+	PhaseVerify Phase = "verify"
+)
+
+// Phases are all phases in order.
+var Phases = []Phase{PhasePrepare, PhaseDeploy, PhaseVerify}
+
+// ParsePhase parses the phase name.
+func ParsePhase(name string) (Phase, error) {
+	phase := Phase(name)
+	if !slices.Contains(Phases, phase) {
+		return "", errors.Errorf("invalid phase %s, must be one of %v", name, Phases)
+	}
+	return phase, nil
+}
+
+// PhaseRecord records the time span of a phase of a run.
+type PhaseRecord struct {
+	Phase     Phase      `json:"phase"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+}
+
+// PhaseGate is called at the boundary between the completed phase and the next phase,
+// the run stops if it returns an error.
+type PhaseGate func(ctx context.Context, completed, next Phase) error
+
+// SetPhase sets the phase of the task.
+func (t *BaseTask) SetPhase(phase Phase) {
+	t.phase = phase
+}
+
+// taskPhase returns the phase of the task. Tasks of services are in the deploy phase
+// unless they set their phases, other tasks are in the prepare phase.
+func (t *BaseTask) taskPhase() Phase {
+	if t.phase != "" {
+		return t.phase
+	}
+	if t.service != "" {
+		return PhaseDeploy
+	}
+	return PhasePrepare
+}
+
+func phaseIndex(phase Phase) int {
+	return slices.Index(Phases, phase)
+}
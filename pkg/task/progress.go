@@ -0,0 +1,52 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+// defines statuses of nodes running steps of a task, which are reported before they end
+// with NodeStatusOK or NodeStatusFailed.
+const (
+	NodeStatusPending = "pending"
+	NodeStatusRunning = "running"
+)
+
+// ProgressReporter is notified of progress of tasks of a run, e.g. to render the progress
+// in the terminal. Nodes of a task are reported from goroutines running steps on them in
+// parallel, so it must be safe for concurrent use.
+type ProgressReporter interface {
+	// TaskStarted is called before the task starts, index is 1-based in total tasks of the run.
+	TaskStarted(index, total int, task string)
+	// NodeChanged is called when the status of the node in the task changes.
+	NodeChanged(task, node, status string)
+	// TaskEnded is called after the task ends, err is the error of the task if it failed.
+	TaskEnded(task string, err error)
+}
+
+func (r *Runtime) reportTaskStarted(index, total int, task string) {
+	if r != nil && r.Progress != nil {
+		r.Progress.TaskStarted(index, total, task)
+	}
+}
+
+func (r *Runtime) reportNode(task, node, status string) {
+	if r != nil && r.Progress != nil {
+		r.Progress.NodeChanged(task, node, status)
+	}
+}
+
+func (r *Runtime) reportTaskEnded(task string, err error) {
+	if r != nil && r.Progress != nil {
+		r.Progress.TaskEnded(task, err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/common"
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+// QuarantineRecord records a node quarantined in a run for being unreachable.
+type QuarantineRecord struct {
+	Node  string    `json:"node"`
+	Task  string    `json:"task"`
+	Since time.Time `json:"since"`
+	// RecoveredAt is nil if the node didn't recover.
+	RecoveredAt *time.Time `json:"recoveredAt,omitempty"`
+	Error       string     `json:"error"`
+}
+
+// String returns the summary of the record, e.g.
+// "node1 in task RunStorageTask: recovered after 35s".
+func (q *QuarantineRecord) String() string {
+	summary := fmt.Sprintf("%s in task %s: ", q.Node, q.Task)
+	if q.RecoveredAt != nil {
+		return summary + fmt.Sprintf("recovered after %s", q.RecoveredAt.Sub(q.Since).Round(time.Second))
+	}
+	return summary + fmt.Sprintf("not recovered, %s", q.Error)
+}
+
+// quarantineNode quarantines the node which is unreachable in the task.
+func (r *Runtime) quarantineNode(task, node string, err error) *QuarantineRecord {
+	r.quarantinesMu.Lock()
+	defer r.quarantinesMu.Unlock()
+	record := &QuarantineRecord{Node: node, Task: task, Since: time.Now(), Error: err.Error()}
+	r.quarantines = append(r.quarantines, record)
+	return record
+}
+
+// recoverNode marks the quarantined node recovered.
+func (r *Runtime) recoverNode(record *QuarantineRecord) {
+	r.quarantinesMu.Lock()
+	defer r.quarantinesMu.Unlock()
+	record.RecoveredAt = common.Pointer(time.Now())
+}
+
+// updateQuarantineError updates the error of the quarantined node which is still unreachable.
+func (r *Runtime) updateQuarantineError(record *QuarantineRecord, err error) {
+	r.quarantinesMu.Lock()
+	defer r.quarantinesMu.Unlock()
+	record.Error = err.Error()
+}
+
+// Quarantines returns records of nodes quarantined in the run in order.
+func (r *Runtime) Quarantines() []*QuarantineRecord {
+	r.quarantinesMu.Lock()
+	defer r.quarantinesMu.Unlock()
+	records := make([]*QuarantineRecord, len(r.quarantines))
+	for i, record := range r.quarantines {
+		copied := *record
+		records[i] = &copied
+	}
+	return records
+}
+
+// quarantinedNode is a node quarantined in a step.
+type quarantinedNode struct {
+	node   config.Node
+	record *QuarantineRecord
+}
+
+// quarantineEnabled returns whether nodes unreachable in steps of the task are quarantined.
+func (t *BaseTask) quarantineEnabled() bool {
+	return t.Runtime.Cfg != nil && t.Runtime.Cfg.Quarantine.Enabled()
+}
+
+// quarantine quarantines the node if the step failed on it for being unreachable.
+func (t *BaseTask) quarantine(node config.Node, err error) *quarantinedNode {
+	if err == nil || !t.quarantineEnabled() || !external.IsUnreachable(err) {
+		return nil
+	}
+	t.Logger.Warnf("Node %s is unreachable and quarantined, it's retried every %s for up to %s: %v",
+		node.Name, t.Runtime.Cfg.Quarantine.RetryInterval, t.Runtime.Cfg.Quarantine.Timeout, err)
+	return &quarantinedNode{node: node, record: t.Runtime.quarantineNode(t.Name(), node.Name, err)}
+}
+
+// retryQuarantined retries the step on quarantined nodes in parallel at the retry
+// interval until all of them recover. It fails if a node is still unreachable after
+// the quarantine timeout, or the step fails on a recovered node.
+func (t *BaseTask) retryQuarantined(ctx context.Context,
+	stepExecutor func(context.Context, config.Node) error, nodes []*quarantinedNode) error {
+
+	quarantine := t.Runtime.Cfg.Quarantine
+	for len(nodes) > 0 {
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-time.After(quarantine.RetryInterval.Duration()):
+		}
+
+		var (
+			mu        sync.Mutex
+			remaining []*quarantinedNode
+		)
+		workerPool := common.NewWorkerPool(func(ctx context.Context, qn *quarantinedNode) error {
+			err := stepExecutor(ctx, qn.node)
+			if err == nil {
+				t.Runtime.recoverNode(qn.record)
+				t.Logger.Infof("Node %s is reachable again and released from quarantine", qn.node.Name)
+				t.Runtime.recordNodeResult(t.Name(), qn.node.Name, nil)
+				return nil
+			}
+			if !external.IsUnreachable(err) {
+				t.Runtime.recoverNode(qn.record)
+				t.Runtime.recordNodeResult(t.Name(), qn.node.Name, err)
+				return errors.Trace(err)
+			}
+			t.Runtime.updateQuarantineError(qn.record, err)
+			if time.Since(qn.record.Since) >= quarantine.Timeout.Duration() {
+				t.Runtime.recordNodeResult(t.Name(), qn.node.Name, err)
+				return errors.Annotatef(err, "node %s is still unreachable after quarantine of %s",
+					qn.node.Name, quarantine.Timeout)
+			}
+			mu.Lock()
+			remaining = append(remaining, qn)
+			mu.Unlock()
+			return nil
+		}, len(nodes))
+		workerPool.Start(ctx)
+		for _, qn := range nodes {
+			workerPool.Add(qn)
+		}
+		workerPool.Join()
+		if errs := workerPool.Errors(); len(errs) > 0 {
+			return errors.Trace(errs[0])
+		}
+		nodes = remaining
+	}
+	return nil
+}
+
+// quarantineReport returns lines reporting nodes quarantined in the run.
+func quarantineReport(records []*QuarantineRecord) string {
+	lines := make([]string, len(records))
+	for i, record := range records {
+		lines[i] = "  " + record.String()
+	}
+	return strings.Join(lines, "\n")
+}
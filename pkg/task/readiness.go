@@ -0,0 +1,170 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+// ReadinessProbe probes whether a service is ready, it returns output of the probe.
+type ReadinessProbe func(ctx context.Context) (ready bool, out string, err error)
+
+// ServiceListenProbe returns a probe of the service running in the container. A service
+// exits soon if it fails to start, which stops the container, so the container must be
+// running. The service must listen on the tcp port as well, because it only listens
+// after it's started. Services without a listen port, like the 3fs client whose mount
+// is verified separately, are ready once the container is running.
+func ServiceListenProbe(em *external.Manager, container, service string, port int) ReadinessProbe {
+	return func(ctx context.Context) (bool, string, error) {
+		status, err := em.Docker.InspectContainer(ctx, container, "{{.State.Status}}")
+		if err != nil {
+			return false, "", errors.Trace(err)
+		}
+		if status = strings.TrimSpace(status); status != "running" {
+			return false, fmt.Sprintf("container %s is %s", container, status), nil
+		}
+		if port == 0 {
+			return true, "", nil
+		}
+		// services run in the host network, so the port is listened on the node
+		out, err := em.Runner.Exec(ctx, "ss", "-Hltn", fmt.Sprintf("sport = :%d", port))
+		if err != nil {
+			return false, "", errors.Annotatef(err, "check listen port %d", port)
+		}
+		if strings.TrimSpace(out) == "" {
+			return false, fmt.Sprintf("%s doesn't listen on port %d", service, port), nil
+		}
+		return true, out, nil
+	}
+}
+
+// deferredReadiness is a readiness check deferred by the retry-later failure mode.
+type deferredReadiness struct {
+	service config.ServiceType
+	probe   ReadinessProbe
+}
+
+// WaitServiceReady polls the probe at readiness interval of the service until
+// the service is ready. It fails with the last probe output after readiness
+// timeout of the service. A failed probe is retried, because the service may be
+// still starting. If the service isn't ready, it returns nil when the readiness
+// failure mode of the service is warn or retry-later, the check is deferred to
+// after later tasks for retry-later.
+func (r *Runtime) WaitServiceReady(
+	ctx context.Context, service config.ServiceType, probe ReadinessProbe) error {
+
+	err := r.waitServiceReady(ctx, service, probe)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	switch r.Services.Readiness(service).FailureMode() {
+	case config.ReadinessFailureModeWarn:
+		log.Logger.Warnf("%v, continue as readinessFailureMode of %s is %s",
+			err, service, config.ReadinessFailureModeWarn)
+		return nil
+	case config.ReadinessFailureModeRetryLater:
+		log.Logger.Warnf("%v, it will be checked again after later tasks", err)
+		r.deferredReadinessMu.Lock()
+		defer r.deferredReadinessMu.Unlock()
+		r.deferredReadiness = append(r.deferredReadiness, &deferredReadiness{service: service, probe: probe})
+		return nil
+	default:
+		return err
+	}
+}
+
+func (r *Runtime) waitServiceReady(
+	ctx context.Context, service config.ServiceType, probe ReadinessProbe) error {
+
+	readiness := r.Services.Readiness(service)
+	tctx, cancel := context.WithTimeout(ctx, readiness.ReadinessTimeout.Duration())
+	defer cancel()
+
+	start := time.Now()
+	var lastOut string
+	for {
+		ready, out, err := probe(tctx)
+		if err == nil && ready {
+			return nil
+		}
+		// output of a probe interrupted by the timeout is useless
+		if tctx.Err() == nil {
+			if err != nil {
+				lastOut = err.Error()
+			} else {
+				lastOut = out
+			}
+		}
+
+		select {
+		case <-tctx.Done():
+			if ctx.Err() != nil {
+				return errors.Trace(ctx.Err())
+			}
+			return errors.Errorf("%s is not ready after waiting %s, last probe output: %s",
+				service, time.Since(start).Round(time.Millisecond), strings.TrimSpace(lastOut))
+		case <-time.After(readiness.ReadinessInterval.Duration()):
+		}
+	}
+}
+
+// RecheckDeferredReadiness probes services whose readiness checks are deferred once,
+// checks of ready services are done. It returns the number of remaining checks.
+func (r *Runtime) RecheckDeferredReadiness(ctx context.Context) int {
+	r.deferredReadinessMu.Lock()
+	defer r.deferredReadinessMu.Unlock()
+	remaining := r.deferredReadiness[:0]
+	for _, check := range r.deferredReadiness {
+		tctx, cancel := context.WithTimeout(ctx, r.Services.Readiness(check.service).ReadinessTimeout.Duration())
+		ready, _, err := check.probe(tctx)
+		cancel()
+		if err == nil && ready {
+			log.Logger.Infof("%s is ready on recheck", check.service)
+			continue
+		}
+		remaining = append(remaining, check)
+	}
+	r.deferredReadiness = remaining
+	return len(remaining)
+}
+
+// WaitDeferredReadiness waits services whose readiness checks are deferred until they're
+// ready, it fails if any of them isn't ready after its readiness timeout.
+func (r *Runtime) WaitDeferredReadiness(ctx context.Context) error {
+	r.deferredReadinessMu.Lock()
+	defer r.deferredReadinessMu.Unlock()
+	var errs []string
+	for _, check := range r.deferredReadiness {
+		if err := r.waitServiceReady(ctx, check.service, check.probe); err != nil {
+			if ctx.Err() != nil {
+				return errors.Trace(ctx.Err())
+			}
+			errs = append(errs, err.Error())
+		}
+	}
+	r.deferredReadiness = nil
+	if len(errs) > 0 {
+		return errors.Errorf("deferred readiness checks failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+// RenderedFile is a file rendered from templates for a node.
+type RenderedFile struct {
+	// Path is the destination path of the file on the node.
+	Path string
+	Data []byte
+}
+
+// ConfigRenderer renders config files of a service without touching any node.
+// It uses the same templates and template data as tasks creating the service.
+type ConfigRenderer struct {
+	Service config.ServiceType
+	// Prepare generates data shared by all nodes into the runtime, it's optional.
+	Prepare func(*Runtime) error
+	// Render renders config files of the service for the node.
+	Render func(*Runtime, config.Node) ([]*RenderedFile, error)
+}
+
+// ServiceNodes returns node names of the service.
+func (r *Runtime) ServiceNodes(service config.ServiceType) []string {
+	return r.Services.ServiceNodes(service)
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+// retryBudget limits retries of steps to a ratio of operations in a sliding window, see
+// config.RetryPolicy. It's shared by all tasks of a run and safe for concurrent use.
+type retryBudget struct {
+	mu         sync.Mutex
+	policy     config.RetryPolicy
+	now        func() time.Time
+	operations []time.Time
+	retries    []time.Time
+	// exhausted is set once the exhaustion is logged, it's reset after a retry is allowed
+	// again, so that each exhaustion is logged once.
+	exhausted bool
+	// denied is the number of retries denied in the run.
+	denied int
+}
+
+func newRetryBudget(policy config.RetryPolicy) *retryBudget {
+	return &retryBudget{policy: policy, now: time.Now}
+}
+
+// expire drops operations and retries out of the window.
+func (b *retryBudget) expire(now time.Time) {
+	since := now.Add(-b.policy.Window.Duration())
+	drop := func(times []time.Time) []time.Time {
+		i := 0
+		for i < len(times) && !times[i].After(since) {
+			i++
+		}
+		return times[i:]
+	}
+	b.operations = drop(b.operations)
+	b.retries = drop(b.retries)
+}
+
+// recordOperation records a run of a step on a node.
+func (b *retryBudget) recordOperation() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	b.expire(now)
+	b.operations = append(b.operations, now)
+}
+
+// allowRetry returns whether a failed step can be retried, the retry is spent from the
+// budget if it's allowed.
+func (b *retryBudget) allowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	b.expire(now)
+	retries := len(b.retries) + 1
+	if retries > b.policy.MinRetries && float64(retries) > b.policy.Budget*float64(len(b.operations)) {
+		b.denied++
+		if !b.exhausted {
+			b.exhausted = true
+			logrus.Errorf("RETRY BUDGET EXHAUSTED: %d retries of %d operations in the last %s reach the budget "+
+				"of %g%%, failed steps are no longer retried until retries fall below the budget",
+				len(b.retries), len(b.operations), b.policy.Window, b.policy.Budget*100)
+		}
+		return false
+	}
+	if b.exhausted {
+		b.exhausted = false
+		logrus.Infof("Retry budget is replenished, failed steps are retried again")
+	}
+	b.retries = append(b.retries, now)
+	return true
+}
+
+// getRetryBudget returns the retry budget of the run, it's nil if retries aren't limited.
+func (r *Runtime) getRetryBudget() *retryBudget {
+	r.retryBudgetOnce.Do(func() {
+		if r.Cfg != nil && r.Cfg.Deployment.RetryPolicy.Limited() {
+			r.retryBudget = newRetryBudget(r.Cfg.Deployment.RetryPolicy)
+		}
+	})
+	return r.retryBudget
+}
+
+// recordOperation records a run of a step on a node to the retry budget.
+func (r *Runtime) recordOperation() {
+	if budget := r.getRetryBudget(); budget != nil {
+		budget.recordOperation()
+	}
+}
+
+// allowRetry returns whether a failed step can be retried by the retry budget.
+func (r *Runtime) allowRetry() bool {
+	budget := r.getRetryBudget()
+	return budget == nil || budget.allowRetry()
+}
+
+// DeniedRetries returns the number of retries of failed steps denied by the retry budget.
+func (r *Runtime) DeniedRetries() int {
+	budget := r.getRetryBudget()
+	if budget == nil {
+		return 0
+	}
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	return budget.denied
+}
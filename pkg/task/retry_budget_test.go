@@ -0,0 +1,95 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+func TestRetryBudgetSuite(t *testing.T) {
+	suiteRun(t, new(retryBudgetSuite))
+}
+
+type retryBudgetSuite struct {
+	baseSuite
+}
+
+func (s *retryBudgetSuite) TestAllowRetry() {
+	now := time.Date(2025, 4, 19, 12, 0, 0, 0, time.UTC)
+	b := newRetryBudget(config.RetryPolicy{Budget: 0.1, Window: config.Duration(time.Minute), MinRetries: 2})
+	b.now = func() time.Time { return now }
+
+	for range 20 {
+		b.recordOperation()
+	}
+	// min retries are allowed regardless of the ratio
+	s.True(b.allowRetry())
+	s.True(b.allowRetry())
+	s.False(b.allowRetry())
+	s.True(b.exhausted)
+
+	for range 10 {
+		b.recordOperation()
+	}
+	s.True(b.allowRetry())
+	s.False(b.exhausted)
+	s.False(b.allowRetry())
+
+	// operations and retries out of the window are expired
+	now = now.Add(2 * time.Minute)
+	s.True(b.allowRetry())
+	s.True(b.allowRetry())
+	s.False(b.allowRetry())
+	s.Equal(3, b.denied)
+}
+
+type alwaysFailStep struct {
+	BaseStep
+	executed *int
+}
+
+func (st *alwaysFailStep) Execute(context.Context) error {
+	*st.executed++
+	return errors.New("systemic failure")
+}
+
+func (s *retryBudgetSuite) TestStepRetries() {
+	cfg := &config.Config{Name: "test", ContainerRuntime: config.ContainerRuntimeDocker}
+	cfg.Deployment.RetryPolicy = config.RetryPolicy{Budget: 0.1, Window: config.Duration(time.Hour), MinRetries: 1}
+	runtime := &Runtime{Cfg: cfg, NewNodeManager: externaltest.NewScript().NodeManager}
+	executed := 0
+	t := new(BaseTask)
+	t.SetName("testTask")
+	t.Init(runtime, log.Logger)
+	t.SetSteps([]StepConfig{
+		{
+			Nodes:     []config.Node{{Name: "node1"}},
+			RetryTime: 5,
+			NewStep:   func() Step { return &alwaysFailStep{executed: &executed} },
+		},
+	})
+
+	s.Error(t.Run(s.Ctx()), "systemic failure")
+	// the only retry of the budget is spent by the first failure
+	s.Equal(2, executed)
+	s.Equal(2, runtime.DeniedRetries())
+}
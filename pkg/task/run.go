@@ -0,0 +1,186 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// RunStatus is the status of a run.
+type RunStatus string
+
+// defines run statuses.
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+	RunStatusPaused    RunStatus = "paused"
+)
+
+const (
+	stateDirName       = ".m3fs"
+	runsDirName        = "runs"
+	runRecordFileName  = "run.json"
+	knownHostsFileName = "known_hosts"
+	journalFileName    = "journal.jsonl"
+	tempDirsFileName   = "temp-dirs.json"
+	tempDirName        = "tmp"
+
+	// run records of more tasks are saved without indentation to keep them compact
+	compactRecordTasks = 100
+)
+
+// RunRecord records the information of a run.
+type RunRecord struct {
+	ID        string     `json:"id"`
+	Cluster   string     `json:"cluster"`
+	Command   string     `json:"command"`
+	Status    RunStatus  `json:"status"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	// Tasks records statuses of nodes of tasks which have run.
+	Tasks []*TaskResult `json:"tasks,omitempty"`
+	// Phases records phases which have started.
+	Phases []*PhaseRecord `json:"phases,omitempty"`
+	// Quarantines records nodes quarantined for being unreachable.
+	Quarantines []*QuarantineRecord `json:"quarantines,omitempty"`
+	// NextTask is the task to run next while the run is paused.
+	NextTask string `json:"nextTask,omitempty"`
+	// FreezeOverride is the reason of running in spite of a deployment freeze.
+	FreezeOverride string `json:"freezeOverride,omitempty"`
+}
+
+// Duration returns the duration of the run.
+func (r *RunRecord) Duration() time.Duration {
+	if r.EndTime == nil {
+		return 0
+	}
+	return r.EndTime.Sub(r.StartTime)
+}
+
+// ClusterStateDir returns the local directory which stores state of the cluster.
+func ClusterStateDir(workDir, clusterName string) string {
+	return filepath.Join(workDir, stateDirName, clusterName)
+}
+
+// KnownHostsFilePath returns the path of the known_hosts file managed by m3fs, which
+// pins SSH host keys of nodes of the cluster.
+func KnownHostsFilePath(workDir, clusterName string) string {
+	return filepath.Join(ClusterStateDir(workDir, clusterName), knownHostsFileName)
+}
+
+// RunsDir returns the local directory which stores all runs of the cluster.
+func RunsDir(workDir, clusterName string) string {
+	return filepath.Join(ClusterStateDir(workDir, clusterName), runsDirName)
+}
+
+// RunDir returns the local directory which stores state of the run.
+func RunDir(workDir, clusterName, runID string) string {
+	return filepath.Join(RunsDir(workDir, clusterName), runID)
+}
+
+// JournalFilePath returns the path of the command journal of the run.
+func JournalFilePath(runDir string) string {
+	return filepath.Join(runDir, journalFileName)
+}
+
+// RunRecordFilePath returns the path of the record of the run.
+func RunRecordFilePath(runDir string) string {
+	return filepath.Join(runDir, runRecordFileName)
+}
+
+var runIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ValidateRunID checks the run id can name the dir of the run inside the runs dir.
+func ValidateRunID(id string) error {
+	if !runIDPattern.MatchString(id) || id == "." || id == ".." {
+		return errors.Errorf("invalid run id %q, it must consist of letters, digits, '.', '_' and '-'", id)
+	}
+	return nil
+}
+
+// NewRunID generates a run id from the current time.
+func NewRunID() string {
+	return time.Now().Format("20060102-150405.000")
+}
+
+// SaveRunRecord saves the run record into the run directory.
+func SaveRunRecord(runDir string, record *RunRecord) error {
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return errors.Annotatef(err, "create run directory %s", runDir)
+	}
+	var data []byte
+	var err error
+	if len(record.Tasks) > compactRecordTasks {
+		data, err = json.Marshal(record)
+	} else {
+		data, err = json.MarshalIndent(record, "", "  ")
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if data, err = sealState(data); err != nil {
+		return errors.Annotate(err, "encrypt run record")
+	}
+	if err = WriteFileAtomic(RunRecordFilePath(runDir), data, 0644); err != nil {
+		return errors.Annotate(err, "write run record")
+	}
+	return nil
+}
+
+// LoadRunRecords loads all run records of the cluster, the latest run comes first.
+func LoadRunRecords(workDir, clusterName string) ([]*RunRecord, error) {
+	runsDir := RunsDir(workDir, clusterName)
+	entries, err := os.ReadDir(runsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Annotatef(err, "read runs directory %s", runsDir)
+	}
+	records := make([]*RunRecord, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(runsDir, entry.Name(), runRecordFileName))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Annotatef(err, "read record of run %s", entry.Name())
+		}
+		if data, err = openState(data); err != nil {
+			return nil, errors.Annotatef(err, "read record of run %s", entry.Name())
+		}
+		record := new(RunRecord)
+		if err = json.Unmarshal(data, record); err != nil {
+			return nil, errors.Annotatef(err, "parse record of run %s", entry.Name())
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].StartTime.After(records[j].StartTime)
+	})
+	return records, nil
+}
@@ -17,12 +17,19 @@ package task
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/sirupsen/logrus"
 
+	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/external"
@@ -35,15 +42,25 @@ const (
 	RuntimeArtifactTmpDirKey    = "artifact/tmp_dir"
 	RuntimeArtifactPathKey      = "artifact/path"
 	RuntimeArtifactGzipKey      = "artifact/gzip"
-	RuntimeArtifactSha256sumKey = "artifact/sha256sum"
 	RuntimeArtifactFilePathsKey = "artifact/file_paths"
+	RuntimeArtifactManifestKey  = "artifact/manifest"
+	RuntimeArtifactImagesKey    = "artifact/images"
+
+	RuntimeArtifactProbeBandwidthKey = "artifact/probe_bandwidth"
+	RuntimeArtifactBandwidthKey      = "artifact/bandwidth"
+	RuntimeArtifactRestartExportKey  = "artifact/restart_export"
+	RuntimeArtifactRetransfersKey    = "artifact/retransfers"
+	RuntimeArtifactSourceSumKey      = "artifact/source_sum"
 
 	RuntimeClickhouseTmpDirKey      = "clickhouse/tmp_dir"
 	RuntimeMonitorTmpDirKey         = "monitor/tmp_dir"
 	RuntimeFdbClusterFileContentKey = "fdb/cluster_file_content"
+	RuntimeFdbClusterIDKey          = "fdb/cluster_id"
 	RuntimeMgmtdServerAddressesKey  = "mgmtd/server_addresses"
+	RuntimeMgmtdLeaderKey           = "mgmtd/leader"
 	RuntimeUserTokenKey             = "user_token"
 	RuntimeAdminCliTomlKey          = "admin_cli_toml"
+	RuntimeContainerRuntimeKey      = "container_runtime"
 )
 
 // Runtime contains task run info
@@ -56,6 +73,59 @@ type Runtime struct {
 	LocalEm   *external.Manager
 	LocalNode *config.Node
 
+	// RunID is the id of current run, it's empty if the run isn't recorded.
+	RunID string
+	// RunDir is the local directory which stores state of current run.
+	RunDir string
+	// Journal records commands run on nodes, it's nil if the runtime isn't created by a runner.
+	Journal *external.Journal
+	// NodeFilter selects nodes which steps run on, steps run on all their nodes if
+	// it's nil. It's used to run tasks on part of the cluster, e.g. a canary node.
+	NodeFilter func(config.Node) bool
+	// CordonedNodes are names of nodes which steps skip, they're cordoned in the
+	// cluster state.
+	CordonedNodes []string
+	// PerNodeLogs disables aggregating identical info messages of nodes running steps
+	// in parallel, so that every node logs its own messages.
+	PerNodeLogs bool
+	// NewNodeManager creates managers of nodes other than the local node instead of
+	// connecting to them by SSH, e.g. scripted managers of externaltest in tests.
+	NewNodeManager func(config.Node, log.Interface) (*external.Manager, error)
+	// RefreshFacts gathers facts of nodes again instead of using the cached ones.
+	RefreshFacts bool
+	// RequireOptionalTools fails features depending on missing optional tools instead of
+	// skipping them.
+	RequireOptionalTools bool
+	// Progress is notified of progress of tasks and their nodes if it's set.
+	Progress ProgressReporter
+
+	nodeResultsMu sync.Mutex
+	nodeResults   map[string]map[string]string
+
+	tempDirsMu sync.Mutex
+	tempDirs   []TempDir
+
+	deferredReadinessMu sync.Mutex
+	deferredReadiness   []*deferredReadiness
+
+	quarantinesMu sync.Mutex
+	quarantines   []*QuarantineRecord
+
+	stopsMu sync.Mutex
+	stops   []StopRecord
+
+	factsMu sync.Mutex
+	facts   map[string]*NodeFacts
+
+	commandStatsMu sync.Mutex
+	commandStats   map[string]*external.CommandStats
+
+	retryBudgetOnce sync.Once
+	retryBudget     *retryBudget
+
+	autoParallelOnce sync.Once
+	autoParallel     int
+
 	// MgmtdProtocol is used to set the protocol of mgmtd address.
 	// It maps RDMA types to RDMA://
 	// It maps IB types to IPoIB://
@@ -94,6 +164,101 @@ func (r *Runtime) LoadInt(key any) (int, bool) {
 	return valI.(int), true
 }
 
+// ContainerRuntime returns the container runtime of the node. It's detected on the node
+// once if not specified in config.
+func (r *Runtime) ContainerRuntime(
+	ctx context.Context, em *external.Manager, node config.Node) (config.ContainerRuntime, error) {
+
+	if r.Cfg.ContainerRuntime != "" {
+		return r.Cfg.ContainerRuntime, nil
+	}
+	key := fmt.Sprintf("%s/%s", RuntimeContainerRuntimeKey, node.Name)
+	if runtime, ok := r.LoadString(key); ok {
+		return config.ContainerRuntime(runtime), nil
+	}
+	runtime, err := external.DetectContainerRuntime(ctx, em)
+	if err != nil {
+		return "", errors.Annotatef(err, "detect container runtime of node %s", node.Name)
+	}
+	logrus.Debugf("Detected container runtime %s on node %s", runtime, node.Name)
+	r.Store(key, string(runtime))
+	return runtime, nil
+}
+
+// NodeManager returns the external manager which runs commands on the node, the
+// local manager is used if the node is the local node.
+func (r *Runtime) NodeManager(node config.Node, logger log.Interface) (*external.Manager, error) {
+	em, err := r.nodeManager(node, 0, logger)
+	return em, errors.Trace(err)
+}
+
+// nodeManager returns the manager of the node, connectTimeout overrides the connect
+// timeout of the config if it's shorter.
+func (r *Runtime) nodeManager(
+	node config.Node, connectTimeout time.Duration, logger log.Interface) (*external.Manager, error) {
+
+	if r.LocalNode != nil && node.Name == r.LocalNode.Name {
+		return r.LocalEm, nil
+	}
+	if r.NewNodeManager != nil {
+		em, err := r.NewNodeManager(node, logger)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if r.Journal != nil {
+			em.UseJournal(r.Journal, node.Name)
+		}
+		em.UseStats(r.nodeCommandStats(node.Name))
+		return em, nil
+	}
+	hostKey := &external.HostKeyCfg{
+		Policy:         r.Cfg.HostKeyPolicy,
+		KnownHostsFile: KnownHostsFilePath(r.WorkDir, r.Cfg.Name),
+	}
+	timeouts := external.Timeouts{Connect: r.Cfg.ConnectTimeout.Duration(), Command: r.Cfg.CommandTimeout.Duration()}
+	if connectTimeout > 0 && (timeouts.Connect <= 0 || connectTimeout < timeouts.Connect) {
+		timeouts.Connect = connectTimeout
+	}
+	em, err := external.NewRemoteRunnerManager(&node, hostKey, timeouts, logger)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if r.Journal != nil {
+		em.UseJournal(r.Journal, node.Name)
+	}
+	em.UseStats(r.nodeCommandStats(node.Name))
+	return em, nil
+}
+
+// LocalTempDir returns the dir in which temp files of the run are created on the deploy
+// host, e.g. generated configs and extracted artifacts. It's in the run dir if the run is
+// recorded, so runs don't clobber each other, otherwise it's the system temp dir.
+func (r *Runtime) LocalTempDir() string {
+	if r.RunDir == "" {
+		return os.TempDir()
+	}
+	dir := filepath.Join(r.RunDir, tempDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logrus.Warnf("Failed to create temp dir of run %s, use the system temp dir: %v", r.RunID, err)
+		return os.TempDir()
+	}
+	return dir
+}
+
+// setupContainerRuntime makes container operations of the manager use the container
+// runtime of the node. The runtime is detected at the first container operation if it's
+// not specified in config, so steps which don't operate containers, e.g. preparing nodes
+// before a runtime is installed, run on nodes without any runtime.
+func (r *Runtime) setupContainerRuntime(em *external.Manager, node config.Node) error {
+	if r.Cfg.ContainerRuntime != "" {
+		return errors.Trace(em.UseContainerRuntime(r.Cfg.ContainerRuntime))
+	}
+	em.UseDetectedContainerRuntime(func(ctx context.Context) (config.ContainerRuntime, error) {
+		return r.ContainerRuntime(ctx, em, node)
+	})
+	return nil
+}
+
 // Runner is a task runner.
 type Runner struct {
 	Runtime   *Runtime
@@ -101,11 +266,37 @@ type Runner struct {
 	cfg       *config.Config
 	localNode *config.Node
 	init      bool
+	command   string
+	runID     string
+	quiet     bool
+	keepTemp  bool
+	// out is where the banner is printed.
+	out io.Writer
+
+	perNodeLogs bool
+
+	// freezeOverride is the reason of overriding the deployment freeze.
+	freezeOverride string
+
+	timings    []*TaskTiming
+	timingsOut string
+	eventsOut  string
+	beforeTask func(context.Context, Interface) error
+	phaseGate  PhaseGate
+	until      Phase
+	stopped    bool
+	record     *RunRecord
+	phases     []*PhaseRecord
+	results    []*TaskResult
+	httpClient *http.Client
+
+	// logCommandStats logs latencies of commands of nodes at the end of the run.
+	logCommandStats bool
 }
 
 // Init initializes all tasks.
 func (r *Runner) Init() {
-	r.Runtime = &Runtime{Cfg: r.cfg, WorkDir: r.cfg.WorkDir, LocalNode: r.localNode}
+	r.Runtime = &Runtime{Cfg: r.cfg, WorkDir: r.cfg.WorkDir, LocalNode: r.localNode, PerNodeLogs: r.perNodeLogs}
 	r.Runtime.MgmtdProtocol = "RDMA"
 	if r.cfg.NetworkType == config.NetworkTypeIB {
 		r.Runtime.MgmtdProtocol = "IPoIB"
@@ -115,26 +306,59 @@ func (r *Runner) Init() {
 		r.Runtime.Nodes[node.Name] = node
 	}
 	r.Runtime.Services = &r.cfg.Services
+	// commands are journaled even if the run isn't recorded, the journal of such a run is
+	// the only file in its run dir, which is created on the first command.
+	journalRunID := r.runID
+	if r.runID != "" {
+		r.Runtime.RunID = r.runID
+		r.Runtime.RunDir = RunDir(r.cfg.WorkDir, r.cfg.Name, r.runID)
+	} else {
+		journalRunID = NewRunID()
+	}
+	r.Runtime.Journal = external.NewJournal(
+		JournalFilePath(RunDir(r.cfg.WorkDir, r.cfg.Name, journalRunID)))
+	external.RegisterSecrets(r.cfg.Secrets()...)
 	logger := log.Logger.Subscribe(log.FieldKeyNode, "<LOCAL>")
 	runnerCfg := &external.LocalRunnerCfg{
 		Logger:         logger,
-		MaxExitTimeout: r.cfg.CmdMaxExitTimeout,
+		MaxExitTimeout: r.cfg.CmdMaxExitTimeout.DurationPtr(),
+		CommandTimeout: r.cfg.CommandTimeout.Duration(),
 	}
 	if r.localNode != nil {
 		runnerCfg.User = r.localNode.Username
+		runnerCfg.Env = r.localNode.Env
 		if r.localNode.Password != nil {
 			runnerCfg.Password = *r.localNode.Password
 		}
 	}
 	em := external.NewManager(external.NewLocalRunner(runnerCfg), logger)
+	em.HTTPClient = r.httpClient
+	em.Node = "localhost"
+	if r.localNode != nil {
+		em.Node = r.localNode.Name
+	}
+	em.UseJournal(r.Runtime.Journal, em.Node)
+	em.UseStats(r.Runtime.nodeCommandStats(em.Node))
 	r.Runtime.LocalEm = em
+	if r.freezeOverride != "" {
+		entry := &external.JournalEntry{Time: time.Now().UTC(), Node: em.Node, FreezeOverride: r.freezeOverride}
+		if err := r.Runtime.Journal.Append(entry); err != nil {
+			logrus.Warnf("Failed to journal the override of the deployment freeze: %v", err)
+		}
+	}
 
 	for _, task := range r.tasks {
 		task.Init(r.Runtime, log.Logger.Subscribe(log.FieldKeyTask, task.Name()))
 	}
+	r.tasks = orderTasks(r.tasks)
 	r.init = true
 }
 
+// Tasks returns tasks of the runner, they're in the order to run after the runner is initialized.
+func (r *Runner) Tasks() []Interface {
+	return r.tasks
+}
+
 // Store sets the value for a key.
 func (r *Runner) Store(key, value any) error {
 	if r.Runtime == nil {
@@ -144,6 +368,80 @@ func (r *Runner) Store(key, value any) error {
 	return nil
 }
 
+// SetRun enables recording of the run with the command name and run id.
+// A run id is generated if the given one is empty, a given one must not be used
+// by an existing run.
+func (r *Runner) SetRun(command, runID string) error {
+	if r.init {
+		return errors.New("runner has been initialized")
+	}
+	if runID == "" {
+		runID = NewRunID()
+	} else {
+		if err := ValidateRunID(runID); err != nil {
+			return errors.Trace(err)
+		}
+		runDir := RunDir(r.cfg.WorkDir, r.cfg.Name, runID)
+		if _, err := os.Stat(runDir); err == nil {
+			return errors.Errorf("run %s of cluster %s already exists in %s", runID, r.cfg.Name, runDir)
+		} else if !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+	}
+	r.command = command
+	r.runID = runID
+	return nil
+}
+
+// SetFreezeOverride records the reason of running in spite of a deployment freeze in the
+// record and the command journal of the run, it must be called before Init.
+func (r *Runner) SetFreezeOverride(reason string) {
+	r.freezeOverride = reason
+}
+
+// SetQuiet disables printing the banner before running tasks.
+func (r *Runner) SetQuiet(quiet bool) {
+	r.quiet = quiet
+}
+
+// SetOutput sets where the banner is printed, it's stdout by default.
+func (r *Runner) SetOutput(out io.Writer) {
+	r.out = out
+}
+
+// SetKeepTemp keeps temp dirs of the run if it fails, which helps debugging.
+func (r *Runner) SetKeepTemp(keepTemp bool) {
+	r.keepTemp = keepTemp
+}
+
+// SetPerNodeLogs makes nodes running steps in parallel log their own info messages
+// instead of aggregated ones, it must be called before Init.
+func (r *Runner) SetPerNodeLogs(perNodeLogs bool) {
+	r.perNodeLogs = perNodeLogs
+}
+
+// SetBeforeTask sets the function called before running each task, the run stops
+// if it returns an error. It's used to gate tasks, e.g. asking for confirmation.
+func (r *Runner) SetBeforeTask(f func(context.Context, Interface) error) {
+	r.beforeTask = f
+}
+
+// SetPhaseGate sets the gate called at boundaries of phases.
+func (r *Runner) SetPhaseGate(gate PhaseGate) {
+	r.phaseGate = gate
+}
+
+// SetUntil makes the run stop cleanly after tasks of the phase complete, tasks of
+// later phases don't run.
+func (r *Runner) SetUntil(phase Phase) {
+	r.until = phase
+}
+
+// Stopped returns whether the run stopped before tasks of phases after the phase set by SetUntil.
+func (r *Runner) Stopped() bool {
+	return r.stopped
+}
+
 // Register registers tasks.
 func (r *Runner) Register(task ...Interface) error {
 	if r.init {
@@ -179,47 +477,331 @@ func getColorAttribute(colorName string) color.Attribute {
 }
 
 // Run runs all tasks.
-func (r *Runner) Run(ctx context.Context) error {
+func (r *Runner) Run(ctx context.Context) (err error) {
+	if !r.quiet && r.out != nil {
+		fmt.Fprint(r.out, r.Banner())
+	}
+	if r.logCommandStats {
+		defer r.printCommandStats()
+	}
+	if r.eventsOut != "" {
+		stopRecording, recordErr := r.recordEvents()
+		if recordErr != nil {
+			return errors.Trace(recordErr)
+		}
+		defer func() { stopRecording(err) }()
+	}
+	if r.timingsOut != "" {
+		defer func() {
+			if writeErr := r.writeTimings(); writeErr != nil {
+				logrus.Warnf("Failed to write task timings to %s: %v", r.timingsOut, writeErr)
+			}
+		}()
+	}
+	if r.runID != "" {
+		record := &RunRecord{
+			ID:             r.runID,
+			Cluster:        r.cfg.Name,
+			Command:        r.command,
+			Status:         RunStatusRunning,
+			StartTime:      time.Now(),
+			FreezeOverride: r.freezeOverride,
+		}
+		if err = SaveRunRecord(r.Runtime.RunDir, record); err != nil {
+			return errors.Trace(err)
+		}
+		r.record = record
+		logrus.Debugf("Run %s state is stored in %s", r.runID, r.Runtime.RunDir)
+		if runIDs, listErr := RunsWithTempDirs(r.cfg.WorkDir, r.cfg.Name, r.runID); listErr != nil {
+			logrus.Warnf("Failed to find temp dirs left by previous runs: %v", listErr)
+		} else if len(runIDs) > 0 {
+			logrus.Warnf("Temp dirs are left by interrupted or failed runs %s, remove them by `m3fs cluster clean`",
+				strings.Join(runIDs, ", "))
+		}
+		defer func() {
+			record.EndTime = common.Pointer(time.Now())
+			record.Tasks = r.results
+			record.Phases = r.phases
+			record.Quarantines = r.Runtime.Quarantines()
+			record.Status = RunStatusSucceeded
+			if err != nil {
+				record.Status = RunStatusFailed
+				record.Error = err.Error()
+			}
+			if saveErr := SaveRunRecord(r.Runtime.RunDir, record); saveErr != nil {
+				logrus.Warnf("Failed to save record of run %s: %v", r.runID, saveErr)
+			}
+			if r.cfg.RunHistory > 0 {
+				entry := NewRunHistoryEntry(record)
+				if saveErr := AppendRunHistory(r.cfg.WorkDir, r.cfg.Name, entry, r.cfg.RunHistory); saveErr != nil {
+					logrus.Warnf("Failed to append run %s to the run history: %v", r.runID, saveErr)
+				}
+			}
+		}()
+	}
+
+	err = r.runTasks(ctx)
+	r.reportQuarantines()
+	r.reportStops()
+	r.reportDeniedRetries()
+	r.removeTempDirs(ctx, err)
+	return err
+}
+
+// reportQuarantines reports nodes quarantined in the run and whether they recovered.
+func (r *Runner) reportQuarantines() {
+	if r.Runtime == nil {
+		return
+	}
+	records := r.Runtime.Quarantines()
+	if len(records) == 0 {
+		return
+	}
+	recovered := 0
+	for _, record := range records {
+		if record.RecoveredAt != nil {
+			recovered++
+		}
+	}
+	report := fmt.Sprintf("%d node(s) were quarantined for being unreachable, %d recovered:\n%s",
+		len(records), recovered, quarantineReport(records))
+	if recovered < len(records) {
+		logrus.Warn(report)
+	} else {
+		logrus.Info(report)
+	}
+}
+
+// reportStops reports durations of stopping services in the run.
+func (r *Runner) reportStops() {
+	if r.Runtime == nil {
+		return
+	}
+	records := r.Runtime.Stops()
+	if len(records) == 0 {
+		return
+	}
+	report := fmt.Sprintf("Stopped containers of services:\n%s", stopReport(records))
+	if slices.ContainsFunc(records, func(record StopRecord) bool { return record.Killed }) {
+		logrus.Warn(report)
+	} else {
+		logrus.Info(report)
+	}
+}
+
+// reportDeniedRetries reports failed steps which weren't retried for the exhausted retry budget.
+func (r *Runner) reportDeniedRetries() {
+	if r.Runtime == nil {
+		return
+	}
+	if denied := r.Runtime.DeniedRetries(); denied > 0 {
+		logrus.Warnf("%d retries of failed steps were denied by the retry budget of deployment.retryPolicy", denied)
+	}
+}
+
+// removeTempDirs removes temp dirs registered by the run, they're kept if the run
+// fails and temp dirs should be kept.
+func (r *Runner) removeTempDirs(ctx context.Context, runErr error) {
+	if r.Runtime == nil {
+		return
+	}
+	if runErr != nil && r.keepTemp {
+		for _, dir := range r.Runtime.TempDirs() {
+			logrus.Infof("Temp dir %s of %s is kept", dir.Path, dir.nodeName())
+		}
+		return
+	}
+	// temp dirs are removed even if the run is canceled
+	if err := r.Runtime.RemoveTempDirs(context.WithoutCancel(ctx)); err != nil {
+		logrus.Warnf("%v, remove them by `m3fs cluster clean`", err)
+	}
+}
+
+func (r *Runner) runTasks(ctx context.Context) error {
 	useColor := false
 	var highlightColor color.Attribute
 	if r.cfg != nil && r.cfg.UI.TaskInfoColor != "" {
 		highlightColor = getColorAttribute(r.cfg.UI.TaskInfoColor)
 		useColor = int(highlightColor) >= 0
 	}
-	for _, task := range r.tasks {
-		var message string
+	var ui config.UIConfig
+	if r.cfg != nil {
+		ui = r.cfg.UI
+	}
+	startMessage, endMessage, err := ui.TaskMessages()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	logTaskMessage := func(m *config.TaskMessage, fields config.TaskMessageFields) {
+		if m == nil {
+			return
+		}
+		message, err := m.Render(fields)
+		if err != nil {
+			logrus.Warnf("Failed to render message of task %s: %v", fields.Name, err)
+			return
+		}
 		if useColor {
-			taskHighlight := color.New(highlightColor, color.Bold).SprintFunc()
-			message = taskHighlight(fmt.Sprintf("Running task %s", task.Name()))
-		} else {
-			message = fmt.Sprintf("Running task %s", task.Name())
+			message = color.New(highlightColor, color.Bold).Sprint(message)
 		}
 		logrus.Info(message)
-		if err := task.Run(ctx); err != nil {
+	}
+	r.timings = make([]*TaskTiming, 0, len(r.tasks))
+	r.results = nil
+	r.phases = nil
+	r.stopped = false
+	defer r.endPhase()
+	for i, task := range r.tasks {
+		if phase := MetadataOf(task).Phase; len(r.phases) == 0 || phase != r.phases[len(r.phases)-1].Phase {
+			if stop, err := r.enterPhase(ctx, phase); err != nil {
+				return errors.Trace(err)
+			} else if stop {
+				break
+			}
+		}
+		if err := r.waitIfPaused(ctx, task); err != nil {
+			return errors.Trace(err)
+		}
+		if r.beforeTask != nil {
+			if err := r.beforeTask(ctx, task); err != nil {
+				return errors.Annotatef(err, "before task %s", task.Name())
+			}
+		}
+		timing := &TaskTiming{Index: i + 1, Name: task.Name(), StartTime: time.Now()}
+		fields := config.TaskMessageFields{Name: task.Name(), Index: i + 1, Total: len(r.tasks),
+			Time: timing.StartTime}
+		logTaskMessage(startMessage, fields)
+		r.timings = append(r.timings, timing)
+		r.Runtime.reportTaskStarted(i+1, len(r.tasks), task.Name())
+		err := r.runTask(ctx, task)
+		r.Runtime.reportTaskEnded(task.Name(), err)
+		timing.EndTime = time.Now()
+		fields.Time = timing.EndTime
+		fields.Elapsed = timing.EndTime.Sub(timing.StartTime).Round(time.Millisecond)
+		fields.Failed = err != nil
+		logTaskMessage(endMessage, fields)
+		if r.Runtime != nil {
+			if result := r.Runtime.TaskResult(task.Name()); len(result.NodeResults) > 0 {
+				r.results = append(r.results, result)
+				if len(result.FailedNodes()) > 0 {
+					logrus.Warn(result.Summary())
+				}
+			}
+		}
+		if err != nil {
 			return errors.Annotatef(err, "run task %s", task.Name())
 		}
+		timing.Completed = true
+		if r.Runtime != nil {
+			r.Runtime.RecheckDeferredReadiness(ctx)
+		}
+	}
+	if r.Runtime != nil {
+		return errors.Trace(r.Runtime.WaitDeferredReadiness(ctx))
 	}
 	return nil
 }
 
-// NewRunner creates a new task runner.
+// runTask runs the task, which is watched by the watchdog if it's enabled.
+func (r *Runner) runTask(ctx context.Context, task Interface) error {
+	ctx = external.WithTask(ctx, task.Name())
+	if r.cfg == nil || r.cfg.WatchdogInterval <= 0 {
+		return task.Run(ctx)
+	}
+	dumpDir := ""
+	if r.cfg.WatchdogDump {
+		dumpDir = r.cfg.WorkDir
+		if r.Runtime != nil && r.Runtime.RunDir != "" {
+			dumpDir = r.Runtime.RunDir
+		}
+	}
+	w := newWatchdog(task.Name(), r.cfg.WatchdogInterval.Duration(), dumpDir)
+	w.start()
+	defer w.stop()
+	return task.Run(external.WithActivity(ctx, w.activity))
+}
+
+// enterPhase ends the current phase and starts the phase, it returns true if the run
+// should stop before the phase.
+func (r *Runner) enterPhase(ctx context.Context, phase Phase) (bool, error) {
+	if r.until != "" && phaseIndex(phase) > phaseIndex(r.until) {
+		logrus.Infof("Stop after phase %s, tasks of phase %s and later phases are skipped", r.until, phase)
+		r.stopped = true
+		return true, nil
+	}
+	if len(r.phases) > 0 {
+		completed := r.phases[len(r.phases)-1].Phase
+		r.endPhase()
+		logrus.Infof("Phase %s completed", completed)
+		if r.phaseGate != nil {
+			if err := r.phaseGate(ctx, completed, phase); err != nil {
+				return false, errors.Annotatef(err, "gate of phase %s", phase)
+			}
+		}
+	}
+	logrus.Infof("Entering phase %s", phase)
+	r.phases = append(r.phases, &PhaseRecord{Phase: phase, StartTime: time.Now()})
+	r.saveProgress()
+	return false, nil
+}
+
+// endPhase ends the current phase if it's running.
+func (r *Runner) endPhase() {
+	if len(r.phases) == 0 || r.phases[len(r.phases)-1].EndTime != nil {
+		return
+	}
+	r.phases[len(r.phases)-1].EndTime = common.Pointer(time.Now())
+	r.saveProgress()
+}
+
+// saveProgress saves boundaries of phases into the record of the run.
+func (r *Runner) saveProgress() {
+	if r.record == nil {
+		return
+	}
+	r.record.Phases = r.phases
+	r.record.Tasks = r.results
+	if err := SaveRunRecord(r.Runtime.RunDir, r.record); err != nil {
+		logrus.Warnf("Failed to save record of run %s: %v", r.runID, err)
+	}
+}
+
+// NewRunner creates a new task runner. The only node of a local cluster is the local
+// node, so that all commands run without SSH.
 func NewRunner(cfg *config.Config, tasks ...Interface) (*Runner, error) {
+	localNode, err := findLocalNode(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	httpClient, err := external.NewHTTPClient(&cfg.TLS)
+	if err != nil {
+		return nil, errors.Annotate(err, "create http client")
+	}
+	return &Runner{
+		tasks:      tasks,
+		localNode:  localNode,
+		cfg:        cfg,
+		out:        os.Stdout,
+		httpClient: httpClient,
+	}, nil
+}
+
+// findLocalNode returns the node of the config which is the local host, it's nil if
+// no node is the local host.
+func findLocalNode(cfg *config.Config) (*config.Node, error) {
+	if cfg.Local && len(cfg.Nodes) > 0 {
+		return &cfg.Nodes[0], nil
+	}
 	localIPs, err := utils.GetLocalIPs()
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	var localNode *config.Node
 	for i, node := range cfg.Nodes {
 		if isLocal, err := utils.IsLocalHost(node.Host, localIPs); err != nil {
 			return nil, errors.Trace(err)
 		} else if isLocal {
-			localNode = &cfg.Nodes[i]
-			break
+			return &cfg.Nodes[i], nil
 		}
 	}
-	return &Runner{
-		tasks:     tasks,
-		localNode: localNode,
-		cfg:       cfg,
-	}, nil
+	return nil, nil
 }
@@ -15,12 +15,22 @@
 package task
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	texternal "github.com/open3fs/m3fs/tests/external"
 )
 
 func TestRunnerSuite(t *testing.T) {
@@ -38,7 +48,7 @@ func (s *runnerSuite) SetupTest() {
 	s.mockTask = new(mockTask)
 	s.runner = &Runner{
 		tasks: []Interface{s.mockTask},
-		cfg:   new(config.Config),
+		cfg:   &config.Config{WorkDir: s.T().TempDir()},
 	}
 }
 
@@ -75,10 +85,332 @@ func (s *runnerSuite) TestRegister() {
 func (s *runnerSuite) TestRun() {
 	s.mockTask.On("Name").Return("mockTask")
 	s.mockTask.On("Run").Return(nil)
+	out := new(bytes.Buffer)
+	s.runner.SetOutput(out)
 
 	s.NoError(s.runner.Run(s.Ctx()))
 
 	s.mockTask.AssertExpectations(s.T())
+	s.Equal(s.runner.Banner(), out.String())
+}
+
+func (s *runnerSuite) TestRunQuiet() {
+	s.mockTask.On("Name").Return("mockTask")
+	s.mockTask.On("Run").Return(nil)
+	out := new(bytes.Buffer)
+	s.runner.SetOutput(out)
+	s.runner.SetQuiet(true)
+
+	s.NoError(s.runner.Run(s.Ctx()))
+
+	s.Empty(out.String())
+}
+
+func (s *runnerSuite) TestRunWithBeforeTask() {
+	task2 := new(mockTask)
+	s.runner.tasks = append(s.runner.tasks, task2)
+	s.mockTask.On("Name").Return("mockTask")
+	s.mockTask.On("Run").Return(nil)
+	task2.On("Name").Return("task2")
+	s.runner.SetBeforeTask(func(_ context.Context, t Interface) error {
+		if t == task2 {
+			return errors.New("aborted")
+		}
+		return nil
+	})
+
+	err := s.runner.Run(s.Ctx())
+	s.Error(err)
+	s.Contains(err.Error(), "before task task2: aborted")
+	s.mockTask.AssertExpectations(s.T())
+	task2.AssertNotCalled(s.T(), "Run")
+}
+
+func (s *runnerSuite) TestBanner() {
+	s.runner.cfg = &config.Config{
+		Name:    "test",
+		WorkDir: "/opt/3fs",
+		Nodes:   []config.Node{{Name: "node1"}, {Name: "node2"}},
+		Services: config.Services{
+			Fdb: config.Fdb{Nodes: []string{"node1"}},
+			Storage: config.Storage{
+				Nodes:     []string{"node1", "node2"},
+				Resources: config.Resources{Memory: "64g", CPUs: 8, NoFile: "1048576"},
+			},
+		},
+		Images: config.Images{
+			FFFS: config.Image{Repo: "open3fs/3fs", Tag: "20250410"},
+			Fdb:  config.Image{Repo: "open3fs/foundationdb", Tag: "7.3.63"},
+		},
+	}
+	s.NoError(s.runner.SetRun("cluster create", "run1"))
+
+	banner := s.runner.Banner()
+
+	s.Contains(banner, "Cluster:")
+	s.Contains(banner, "cluster create")
+	s.Contains(banner, "/opt/3fs")
+	s.Regexp(`Nodes:\s+2\n`, banner)
+	s.Regexp(`foundationdb\s+1 node\(s\)\s+open3fs/foundationdb:7.3.63`, banner)
+	s.Regexp(`storage\s+2 node\(s\)\s+open3fs/3fs:20250410\s+memory=64g cpus=8 nofile=1048576\n`, banner)
+	s.NotContains(banner, "clickhouse")
+	s.NotContains(banner, "Cordoned:")
+
+	s.runner.Runtime = &Runtime{CordonedNodes: []string{"node2"}}
+	s.Regexp(`Cordoned:\s+node2 \(skipped\)\n`, s.runner.Banner())
+}
+
+func (s *runnerSuite) TestRunWithRecord() {
+	s.runner.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir()}
+	s.NoError(s.runner.SetRun("create", "run1"))
+	s.mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	s.mockTask.On("Name").Return("mockTask")
+	s.mockTask.On("Run").Return(nil)
+	s.runner.Init()
+
+	s.NoError(s.runner.Run(s.Ctx()))
+
+	s.Equal(RunDir(s.runner.cfg.WorkDir, "test", "run1"), s.runner.Runtime.RunDir)
+	records, err := LoadRunRecords(s.runner.cfg.WorkDir, "test")
+	s.NoError(err)
+	s.Len(records, 1)
+	s.Equal("run1", records[0].ID)
+	s.Equal("create", records[0].Command)
+	s.Equal(RunStatusSucceeded, records[0].Status)
+	s.NotNil(records[0].EndTime)
+}
+
+func (s *runnerSuite) TestSetRunInvalidID() {
+	s.runner.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir()}
+	for _, id := range []string{"../x", "a/b", "..", "run 1"} {
+		s.Error(s.runner.SetRun("create", id), id)
+	}
+}
+
+func (s *runnerSuite) TestSetRunExistingID() {
+	s.runner.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir()}
+	s.NoError(SaveRunRecord(RunDir(s.runner.cfg.WorkDir, "test", "run1"), &RunRecord{ID: "run1"}))
+
+	err := s.runner.SetRun("create", "run1")
+	s.Error(err)
+	s.Contains(err.Error(), "already exists")
+	s.NoError(s.runner.SetRun("create", "run-2"))
+}
+
+func (s *runnerSuite) TestJournalWithoutRun() {
+	s.runner.cfg.Name = "test"
+	s.mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	s.mockTask.On("Name").Return("mockTask")
+	s.runner.Init()
+	s.Empty(s.runner.Runtime.RunDir)
+
+	_, err := s.runner.Runtime.LocalEm.Runner.NonSudoExec(s.Ctx(), "true")
+	s.NoError(err)
+
+	runs, err := os.ReadDir(RunsDir(s.runner.cfg.WorkDir, "test"))
+	s.NoError(err)
+	s.Len(runs, 1)
+	entries, err := external.ReadJournal(JournalFilePath(RunDir(s.runner.cfg.WorkDir, "test", runs[0].Name())))
+	s.NoError(err)
+	s.Len(entries, 1)
+	s.Equal("localhost", entries[0].Node)
+	s.Equal("true", entries[0].Command)
+}
+
+func (s *runnerSuite) TestJournalFreezeOverride() {
+	s.runner.cfg.Name = "test"
+	s.NoError(s.runner.SetRun("upgrade", "run1"))
+	s.runner.SetFreezeOverride("hotfix")
+	s.mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	s.mockTask.On("Name").Return("mockTask")
+	s.runner.Init()
+
+	entries, err := external.ReadJournal(JournalFilePath(s.runner.Runtime.RunDir))
+	s.NoError(err)
+	s.Len(entries, 1)
+	s.Equal("localhost", entries[0].Node)
+	s.Equal("hotfix", entries[0].FreezeOverride)
+	s.Empty(entries[0].Command)
+}
+
+func (s *runnerSuite) TestLocalTempDirInRunDir() {
+	s.runner.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir()}
+	s.NoError(s.runner.SetRun("create", "run1"))
+	s.mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	s.mockTask.On("Name").Return("mockTask")
+	s.runner.Init()
+
+	dir := s.runner.Runtime.LocalTempDir()
+	s.Equal(filepath.Join(s.runner.Runtime.RunDir, "tmp"), dir)
+	s.DirExists(dir)
+}
+
+func (s *runnerSuite) TestRunWithHistory() {
+	s.runner.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir(), RunHistory: 5}
+	s.NoError(s.runner.SetRun("create", "run1"))
+	s.mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	s.mockTask.On("Name").Return("mockTask")
+	s.mockTask.On("Run").Return(errors.New("dummy error"))
+	s.runner.Init()
+
+	s.Error(s.runner.Run(s.Ctx()))
+
+	entries, err := LoadRunHistory(s.runner.cfg.WorkDir, "test")
+	s.NoError(err)
+	s.Len(entries, 1)
+	s.Equal("run1", entries[0].ID)
+	s.Equal("create", entries[0].Command)
+	s.Equal(RunStatusFailed, entries[0].Status)
+	s.Contains(entries[0].Error, "dummy error")
+}
+
+func (s *runnerSuite) TestRunWithoutHistory() {
+	s.TestRunWithRecord()
+
+	entries, err := LoadRunHistory(s.runner.cfg.WorkDir, "test")
+	s.NoError(err)
+	s.Empty(entries)
+}
+
+func (s *runnerSuite) TestRunWithRecordFailed() {
+	s.runner.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir()}
+	s.NoError(s.runner.SetRun("delete", "run1"))
+	s.mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	s.mockTask.On("Name").Return("mockTask")
+	s.mockTask.On("Run").Return(errors.New("dummy error"))
+	s.runner.Init()
+
+	s.Error(s.runner.Run(s.Ctx()))
+
+	records, err := LoadRunRecords(s.runner.cfg.WorkDir, "test")
+	s.NoError(err)
+	s.Len(records, 1)
+	s.Equal(RunStatusFailed, records[0].Status)
+	s.Contains(records[0].Error, "dummy error")
+}
+
+func (s *runnerSuite) TestRunWithRecordNodeResults() {
+	s.runner.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir()}
+	s.NoError(s.runner.SetRun("create", "run1"))
+	s.mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	s.mockTask.On("Name").Return("mockTask")
+	s.mockTask.On("Run").Run(func(mock.Arguments) {
+		s.runner.Runtime.recordNodeResult("mockTask", "node1", nil)
+		s.runner.Runtime.recordNodeResult("mockTask", "node2", errors.New("dummy error"))
+		s.runner.Runtime.recordNodeResult("mockTask", "node2", nil)
+	}).Return(errors.New("dummy error"))
+	s.runner.Init()
+
+	s.Error(s.runner.Run(s.Ctx()))
+
+	records, err := LoadRunRecords(s.runner.cfg.WorkDir, "test")
+	s.NoError(err)
+	s.Len(records, 1)
+	s.Len(records[0].Tasks, 1)
+	s.Equal("mockTask", records[0].Tasks[0].Name)
+	s.Equal(map[string]string{"node1": NodeStatusOK, "node2": NodeStatusFailed}, records[0].Tasks[0].NodeResults)
+}
+
+func (s *runnerSuite) TestTaskResultSummary() {
+	result := &TaskResult{
+		Name: "deploy storage",
+		NodeResults: map[string]string{
+			"node1": NodeStatusOK, "node3": NodeStatusFailed, "node2": NodeStatusFailed,
+		},
+	}
+	s.Equal([]string{"node2", "node3"}, result.FailedNodes())
+	s.Equal("deploy storage: 1 ok, 2 failed (node2, node3)", result.Summary())
+
+	result.NodeResults = map[string]string{"node1": NodeStatusOK}
+	s.Equal("deploy storage: 1 ok, 0 failed", result.Summary())
+}
+
+func (s *runnerSuite) TestRunWithTimingsCSV() {
+	timingsOut := filepath.Join(s.T().TempDir(), "timings.csv")
+	s.runner.SetTimingsOut(timingsOut)
+	task2 := new(mockTask)
+	s.runner.tasks = append(s.runner.tasks, task2)
+	s.mockTask.On("Name").Return("mockTask")
+	s.mockTask.On("Run").Return(nil)
+	task2.On("Name").Return("task2")
+	task2.On("Run").Return(errors.New("dummy error"))
+
+	s.Error(s.runner.Run(s.Ctx()))
+
+	timings := s.runner.Timings()
+	s.Len(timings, 2)
+	s.True(timings[0].Completed)
+	s.False(timings[1].Completed)
+	data, err := os.ReadFile(timingsOut)
+	s.NoError(err)
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	s.NoError(err)
+	s.Len(records, 3)
+	s.Equal([]string{"task", "name", "start", "end", "duration", "completed"}, records[0])
+	s.Equal([]string{"1", "mockTask"}, records[1][:2])
+	s.Equal("true", records[1][5])
+	s.Equal([]string{"2", "task2"}, records[2][:2])
+	s.Equal("false", records[2][5])
+}
+
+func (s *runnerSuite) TestFormatTimingsPrometheus() {
+	start := time.Unix(1700000000, 500*int64(time.Millisecond))
+	timings := []*TaskTiming{
+		{Index: 1, Name: "FdbTask", StartTime: start, EndTime: start.Add(1500 * time.Millisecond), Completed: true},
+		{Index: 2, Name: `Bad"Task`, StartTime: start, EndTime: start},
+	}
+
+	data := string(FormatTimingsPrometheus("test", "cluster create", timings))
+
+	s.Contains(data, "# TYPE m3fs_task_duration_seconds gauge\n")
+	s.Contains(data, `m3fs_task_duration_seconds{cluster="test",command="cluster create",task="FdbTask",index="1"} 1.5`+"\n")
+	s.Contains(data, `m3fs_task_start_time_seconds{cluster="test",command="cluster create",task="FdbTask",index="1"} 1700000000.5`+"\n")
+	s.Contains(data, `m3fs_task_completed{cluster="test",command="cluster create",task="Bad\"Task",index="2"} 0`+"\n")
+}
+
+func (s *runnerSuite) TestRunWithTimingsPrometheus() {
+	timingsOut := filepath.Join(s.T().TempDir(), "m3fs.prom")
+	s.runner.SetTimingsOut(timingsOut)
+	s.mockTask.On("Name").Return("mockTask")
+	s.mockTask.On("Run").Return(nil)
+
+	s.NoError(s.runner.Run(s.Ctx()))
+
+	data, err := os.ReadFile(timingsOut)
+	s.NoError(err)
+	s.Contains(string(data), `m3fs_task_completed{cluster="",command="",task="mockTask",index="1"} 1`)
+}
+
+func (s *runnerSuite) TestLoadRunRecordsWithoutRuns() {
+	records, err := LoadRunRecords(s.T().TempDir(), "test")
+	s.NoError(err)
+	s.Empty(records)
+}
+
+func (s *runnerSuite) TestContainerRuntimeFromConfig() {
+	s.runner.tasks = nil
+	s.runner.cfg.ContainerRuntime = config.ContainerRuntimePodman
+	s.runner.Init()
+
+	runtime, err := s.runner.Runtime.ContainerRuntime(s.Ctx(), nil, config.Node{Name: "node1"})
+	s.NoError(err)
+	s.Equal(config.ContainerRuntimePodman, runtime)
+}
+
+func (s *runnerSuite) TestContainerRuntimeDetected() {
+	s.runner.tasks = nil
+	s.runner.Init()
+	mockRunner := new(texternal.MockRunner)
+	em := external.NewManager(mockRunner, log.Logger)
+	mockRunner.On("Exec", "sh", []string{"-c", "'command -v docker'"}).Return("", errors.New("not found"))
+	mockRunner.On("Exec", "sh", []string{"-c", "'command -v nerdctl'"}).Return("/usr/bin/nerdctl", nil)
+
+	for i := 0; i < 2; i++ {
+		runtime, err := s.runner.Runtime.ContainerRuntime(s.Ctx(), em, config.Node{Name: "node1"})
+		s.NoError(err)
+		s.Equal(config.ContainerRuntimeContainerd, runtime)
+	}
+	mockRunner.AssertNumberOfCalls(s.T(), "Exec", 2)
 }
 
 func (s *runnerSuite) testTaskInfoHighlighting() {
@@ -131,6 +463,27 @@ func (s *runnerSuite) TestTaskInfoHighlightingWithNoUIConfig() {
 	s.testTaskInfoHighlighting()
 }
 
+func (s *runnerSuite) TestTaskMessagesQuiet() {
+	s.runner.cfg = &config.Config{
+		UI: config.UIConfig{
+			TaskStartMessage: config.TaskMessageQuiet,
+			TaskEndMessage:   "Task {{.Index}}/{{.Total}} {{.Name}} took {{.Elapsed}}",
+		},
+	}
+	s.testTaskInfoHighlighting()
+}
+
+func (s *runnerSuite) TestInvalidTaskMessage() {
+	s.runner.cfg = &config.Config{
+		UI: config.UIConfig{
+			TaskStartMessage: "Running {{.Task}}",
+		},
+	}
+
+	s.ErrorContains(s.runner.Run(s.Ctx()), "ui.taskStartMessage")
+	s.mockTask.AssertNotCalled(s.T(), "Run")
+}
+
 func (s *runnerSuite) TestGetColorAttribute() {
 	cases := []struct {
 		expected  color.Attribute
@@ -155,3 +508,204 @@ func (s *runnerSuite) TestGetColorAttribute() {
 		s.Equal(c.expected, getColorAttribute(c.colorName))
 	}
 }
+
+type phaseTask struct {
+	BaseTask
+	ran *[]string
+}
+
+func newPhaseTask(name string, phase Phase, ran *[]string) *phaseTask {
+	t := &phaseTask{ran: ran}
+	t.SetName(name)
+	t.SetPhase(phase)
+	return t
+}
+
+func (t *phaseTask) Run(context.Context) error {
+	*t.ran = append(*t.ran, t.Name())
+	return nil
+}
+
+func (s *runnerSuite) TestRunPhases() {
+	var ran []string
+	s.runner.tasks = []Interface{
+		newPhaseTask("task1", PhasePrepare, &ran),
+		newPhaseTask("task2", PhaseDeploy, &ran),
+		newPhaseTask("task3", PhaseDeploy, &ran),
+		newPhaseTask("task4", PhaseVerify, &ran),
+	}
+	var gates []string
+	s.runner.SetPhaseGate(func(_ context.Context, completed, next Phase) error {
+		gates = append(gates, string(completed)+"->"+string(next))
+		return nil
+	})
+
+	s.NoError(s.runner.Run(s.Ctx()))
+
+	s.Equal([]string{"task1", "task2", "task3", "task4"}, ran)
+	s.Equal([]string{"prepare->deploy", "deploy->verify"}, gates)
+	s.False(s.runner.Stopped())
+	s.Len(s.runner.phases, 3)
+	for _, phase := range s.runner.phases {
+		s.NotNil(phase.EndTime)
+	}
+}
+
+func (s *runnerSuite) TestRunPhaseGateRejected() {
+	var ran []string
+	s.runner.tasks = []Interface{
+		newPhaseTask("task1", PhasePrepare, &ran),
+		newPhaseTask("task2", PhaseDeploy, &ran),
+	}
+	s.runner.SetPhaseGate(func(context.Context, Phase, Phase) error {
+		return errors.New("rejected")
+	})
+
+	err := s.runner.Run(s.Ctx())
+	s.Error(err)
+	s.Contains(err.Error(), "gate of phase deploy: rejected")
+	s.Equal([]string{"task1"}, ran)
+}
+
+func (s *runnerSuite) TestRunUntil() {
+	var ran []string
+	s.runner.tasks = []Interface{
+		newPhaseTask("task1", PhasePrepare, &ran),
+		newPhaseTask("task2", PhaseDeploy, &ran),
+		newPhaseTask("task3", PhaseVerify, &ran),
+	}
+	s.runner.SetUntil(PhaseDeploy)
+	s.runner.cfg = &config.Config{Name: "test", WorkDir: s.T().TempDir()}
+	s.NoError(s.runner.SetRun("cluster create", "run1"))
+	s.runner.Init()
+
+	s.NoError(s.runner.Run(s.Ctx()))
+
+	s.Equal([]string{"task1", "task2"}, ran)
+	s.True(s.runner.Stopped())
+	records, err := LoadRunRecords(s.runner.cfg.WorkDir, "test")
+	s.NoError(err)
+	s.Len(records, 1)
+	s.Equal(RunStatusSucceeded, records[0].Status)
+	s.Len(records[0].Phases, 2)
+	s.Equal(PhaseDeploy, records[0].Phases[1].Phase)
+	s.NotNil(records[0].Phases[1].EndTime)
+}
+
+func (s *runnerSuite) TestExplain() {
+	var ran []string
+	nodes := []config.Node{{Name: "node1"}, {Name: "node2"}}
+	task2 := newPhaseTask("task2", PhaseDeploy, &ran)
+	task2.SetSteps([]StepConfig{{Nodes: nodes}})
+	task3 := newPhaseTask("task3", PhaseDeploy, &ran)
+	task3.SetSteps([]StepConfig{{Nodes: nodes[1:]}})
+	s.runner.tasks = []Interface{
+		newPhaseTask("task1", PhasePrepare, &ran),
+		task2,
+		task3,
+		newPhaseTask("task4", PhaseVerify, &ran),
+	}
+	s.runner.cfg = &config.Config{Name: "test", Nodes: nodes}
+	s.runner.Init()
+	s.runner.Runtime.NodeFilter = func(node config.Node) bool { return node.Name == "node1" }
+	s.runner.SetPhaseGate(func(context.Context, Phase, Phase) error { return nil })
+	s.runner.SetUntil(PhaseDeploy)
+
+	plans := s.runner.Explain()
+
+	s.Len(plans, 4)
+	var reasons []string
+	for _, plan := range plans {
+		reasons = append(reasons, plan.Reason)
+	}
+	s.Equal([]string{
+		"will run",
+		"will run after phase deploy is approved",
+		"skipped: node selector matches no nodes",
+		"skipped: the run stops after phase deploy",
+	}, reasons)
+	s.True(plans[1].Run)
+	s.False(plans[2].Run)
+	s.Equal("task3", plans[2].Name)
+	s.Empty(ran)
+}
+
+type readinessTask struct {
+	BaseTask
+	ready *bool
+	// waits is true if the task waits the monitor ready, otherwise it makes it ready.
+	waits bool
+}
+
+func (t *readinessTask) Run(ctx context.Context) error {
+	if !t.waits {
+		*t.ready = true
+		return nil
+	}
+	return t.Runtime.WaitServiceReady(ctx, config.ServiceMonitor,
+		func(context.Context) (bool, string, error) {
+			return *t.ready, "not ready", nil
+		})
+}
+
+func (s *runnerSuite) runReadinessTasks(mode string, makeReady bool) error {
+	ready := false
+	tasks := []Interface{&readinessTask{ready: &ready, waits: true}}
+	if makeReady {
+		tasks = append(tasks, &readinessTask{ready: &ready})
+	}
+	cfg := config.NewConfigWithDefaults()
+	cfg.Services.Monitor.ReadinessTimeout = config.Duration(30 * time.Millisecond)
+	cfg.Services.Monitor.ReadinessInterval = config.Duration(10 * time.Millisecond)
+	cfg.Services.Monitor.ReadinessFailureMode = mode
+	runner, err := NewRunner(cfg, tasks...)
+	s.NoError(err)
+	runner.SetQuiet(true)
+	runner.Init()
+	return runner.Run(s.Ctx())
+}
+
+func (s *runnerSuite) TestRunReadinessFailureModes() {
+	err := s.runReadinessTasks(config.ReadinessFailureModeFatal, true)
+	s.Error(err)
+	s.Contains(err.Error(), "monitor is not ready after waiting")
+
+	s.NoError(s.runReadinessTasks(config.ReadinessFailureModeWarn, false))
+
+	// the deferred check passes after the later task makes the monitor ready
+	s.NoError(s.runReadinessTasks(config.ReadinessFailureModeRetryLater, true))
+
+	err = s.runReadinessTasks(config.ReadinessFailureModeRetryLater, false)
+	s.Error(err)
+	s.Contains(err.Error(), "deferred readiness checks failed: monitor is not ready after waiting")
+}
+
+func (s *runnerSuite) TestNewRunnerLocal() {
+	cfg := config.NewConfigWithDefaults()
+	cfg.Local = true
+	cfg.Nodes = []config.Node{{Name: "local", Host: config.LocalNodeHost, Username: "root"}}
+	runner, err := NewRunner(cfg)
+	s.NoError(err)
+	runner.Init()
+	s.Equal(&cfg.Nodes[0], runner.Runtime.LocalNode)
+
+	em, err := runner.Runtime.NodeManager(cfg.Nodes[0], nil)
+	s.NoError(err)
+	s.Same(runner.Runtime.LocalEm, em)
+}
+
+func (s *runnerSuite) TestCommandStats() {
+	s.mockTask.On("Init", mock.AnythingOfType("*task.Runtime"))
+	s.mockTask.On("Name").Return("mockTask")
+	s.runner.Init()
+	r := s.runner.Runtime
+	s.Same(r.nodeCommandStats("node1"), r.nodeCommandStats("node1"))
+
+	_, err := r.LocalEm.Runner.NonSudoExec(s.Ctx(), "true")
+	s.NoError(err)
+	stats := r.CommandStats()
+	s.Len(stats, 1)
+	s.Equal("localhost", stats[0].Node)
+	s.Equal(1, stats[0].Count)
+	s.Equal(stats[0], r.LocalEm.Stats())
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// stopPollInterval is the interval of checking whether a stopping container exited.
+var stopPollInterval = time.Second
+
+// StopRecord records stopping the container of a service on a node in a run.
+type StopRecord struct {
+	Service  config.ServiceType `json:"service"`
+	Node     string             `json:"node"`
+	Duration time.Duration      `json:"duration"`
+	// Killed means the container was killed by SIGKILL since it didn't exit within the
+	// shutdown timeout.
+	Killed bool `json:"killed,omitempty"`
+}
+
+// recordStop records stopping the container of a service.
+func (r *Runtime) recordStop(record StopRecord) {
+	r.stopsMu.Lock()
+	defer r.stopsMu.Unlock()
+	r.stops = append(r.stops, record)
+}
+
+// Stops returns records of containers stopped in the run in order.
+func (r *Runtime) Stops() []StopRecord {
+	r.stopsMu.Lock()
+	defer r.stopsMu.Unlock()
+	return slices.Clone(r.stops)
+}
+
+// StopContainer stops the container of the service on the node gracefully. The container
+// is sent the shutdown signal of the service, then killed by SIGKILL if it doesn't exit
+// within the shutdown timeout. It does nothing if the container isn't running, and the
+// container is kept, so that it's removed by the caller.
+func (s *BaseStep) StopContainer(ctx context.Context, service config.ServiceType, containerName string) error {
+	status, err := s.Em.Docker.InspectContainer(ctx, containerName, "{{.State.Status}}")
+	if err != nil || status != "running" {
+		// the container is missing or exited already
+		return nil
+	}
+	shutdown := s.Runtime.Services.Shutdown(service)
+	start := time.Now()
+	record := StopRecord{Service: service, Node: s.Node.Name}
+	exited := false
+	if shutdown.ShutdownTimeout > 0 {
+		s.Logger.Infof("Stopping %s container %s by %s, waiting up to %s for it to exit",
+			service, containerName, shutdown.Signal(), shutdown.ShutdownTimeout)
+		if _, err = s.Em.Docker.Kill(ctx, containerName, shutdown.Signal()); err != nil {
+			return errors.Annotatef(err, "send %s to %s container %s", shutdown.Signal(), service, containerName)
+		}
+		if exited, err = s.waitContainerExit(ctx, containerName, shutdown.ShutdownTimeout.Duration()); err != nil {
+			return errors.Trace(err)
+		}
+		if !exited {
+			s.Logger.Warnf("%s container %s didn't exit within %s after %s, killing it by SIGKILL",
+				service, containerName, shutdown.ShutdownTimeout, shutdown.Signal())
+			record.Killed = true
+		}
+	}
+	if !exited {
+		if _, err = s.Em.Docker.Kill(ctx, containerName, "SIGKILL"); err != nil {
+			return errors.Annotatef(err, "kill %s container %s", service, containerName)
+		}
+	}
+	record.Duration = time.Since(start)
+	s.Runtime.recordStop(record)
+	s.Logger.Infof("Stopped %s container %s in %s", service, containerName, record.Duration.Round(time.Millisecond))
+	return nil
+}
+
+// waitContainerExit waits until the container isn't running, it returns false if the
+// container is still running after the timeout.
+func (s *BaseStep) waitContainerExit(ctx context.Context, containerName string, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := s.Em.Docker.InspectContainer(ctx, containerName, "{{.State.Status}}")
+		if err != nil || status != "running" {
+			return true, nil
+		}
+		if !time.Now().Before(deadline) {
+			return false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, errors.Trace(ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopReport returns the report of stop durations of services, e.g.
+// "storage: stopped on 3 node(s), longest 12s on node2, killed after shutdown timeout on node3".
+func stopReport(records []StopRecord) string {
+	byService := make(map[config.ServiceType][]StopRecord)
+	for _, record := range records {
+		byService[record.Service] = append(byService[record.Service], record)
+	}
+	var lines []string
+	for _, service := range config.AllServiceTypes {
+		records := byService[service]
+		if len(records) == 0 {
+			continue
+		}
+		longest := records[0]
+		var killed []string
+		for _, record := range records {
+			if record.Duration > longest.Duration {
+				longest = record
+			}
+			if record.Killed {
+				killed = append(killed, record.Node)
+			}
+		}
+		line := fmt.Sprintf("%s: stopped on %d node(s), longest %s on %s",
+			service, len(records), longest.Duration.Round(time.Millisecond), longest.Node)
+		if len(killed) > 0 {
+			slices.Sort(killed)
+			line += fmt.Sprintf(", killed by SIGKILL on %s", strings.Join(killed, ", "))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
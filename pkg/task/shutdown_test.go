@@ -0,0 +1,112 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	texternal "github.com/open3fs/m3fs/tests/external"
+)
+
+func TestShutdownSuite(t *testing.T) {
+	suiteRun(t, new(shutdownSuite))
+}
+
+type shutdownSuite struct {
+	baseSuite
+	mockDocker *texternal.MockDocker
+	runtime    *Runtime
+	step       *BaseStep
+}
+
+func (s *shutdownSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	stopPollInterval = time.Millisecond
+	s.mockDocker = new(texternal.MockDocker)
+	cfg := config.NewConfigWithDefaults()
+	s.runtime = &Runtime{Cfg: cfg, Services: &cfg.Services}
+	s.step = new(BaseStep)
+	s.step.Init(s.runtime, &external.Manager{Docker: s.mockDocker}, config.Node{Name: "node1"}, log.Logger)
+}
+
+func (s *shutdownSuite) TearDownTest() {
+	stopPollInterval = time.Second
+	s.baseSuite.TearDownTest()
+}
+
+func (s *shutdownSuite) TestStopGracefully() {
+	s.mockDocker.On("InspectContainer", "3fs-storage", "{{.State.Status}}").Return("running", nil).Twice()
+	s.mockDocker.On("Kill", "3fs-storage", "SIGTERM").Return("", nil)
+	s.mockDocker.On("InspectContainer", "3fs-storage", "{{.State.Status}}").Return("exited", nil).Once()
+
+	s.NoError(s.step.StopContainer(s.Ctx(), config.ServiceStorage, "3fs-storage"))
+
+	s.mockDocker.AssertExpectations(s.T())
+	stops := s.runtime.Stops()
+	s.Len(stops, 1)
+	s.False(stops[0].Killed)
+}
+
+func (s *shutdownSuite) TestKillAfterTimeout() {
+	s.runtime.Cfg.Services.Meta.Shutdown = config.Shutdown{
+		ShutdownTimeout: config.Duration(10 * time.Millisecond),
+		ShutdownSignal:  "quit",
+	}
+	s.mockDocker.On("InspectContainer", "3fs-meta", "{{.State.Status}}").Return("running", nil)
+	s.mockDocker.On("Kill", "3fs-meta", "SIGQUIT").Return("", nil)
+	s.mockDocker.On("Kill", "3fs-meta", "SIGKILL").Return("", nil)
+
+	s.NoError(s.step.StopContainer(s.Ctx(), config.ServiceMeta, "3fs-meta"))
+
+	s.mockDocker.AssertExpectations(s.T())
+	stops := s.runtime.Stops()
+	s.Len(stops, 1)
+	s.True(stops[0].Killed)
+	s.GreaterOrEqual(stops[0].Duration, 10*time.Millisecond)
+}
+
+func (s *shutdownSuite) TestKillWithoutTimeout() {
+	s.runtime.Cfg.Services.Mgmtd.ShutdownTimeout = 0
+	s.mockDocker.On("InspectContainer", "3fs-mgmtd", "{{.State.Status}}").Return("running", nil)
+	s.mockDocker.On("Kill", "3fs-mgmtd", "SIGKILL").Return("", nil)
+
+	s.NoError(s.step.StopContainer(s.Ctx(), config.ServiceMgmtd, "3fs-mgmtd"))
+
+	s.mockDocker.AssertExpectations(s.T())
+	s.False(s.runtime.Stops()[0].Killed)
+}
+
+func (s *shutdownSuite) TestNotRunning() {
+	s.mockDocker.On("InspectContainer", "3fs-meta", "{{.State.Status}}").Return("exited", nil)
+
+	s.NoError(s.step.StopContainer(s.Ctx(), config.ServiceMeta, "3fs-meta"))
+
+	s.mockDocker.AssertNotCalled(s.T(), "Kill")
+	s.Empty(s.runtime.Stops())
+}
+
+func (s *shutdownSuite) TestStopReport() {
+	s.Equal("storage: stopped on 2 node(s), longest 3m0s on node2, killed by SIGKILL on node2\n"+
+		"meta: stopped on 1 node(s), longest 1.5s on node1",
+		stopReport([]StopRecord{
+			{Service: config.ServiceMeta, Node: "node1", Duration: 1500 * time.Millisecond},
+			{Service: config.ServiceStorage, Node: "node1", Duration: time.Second},
+			{Service: config.ServiceStorage, Node: "node2", Duration: 3 * time.Minute, Killed: true},
+		}))
+}
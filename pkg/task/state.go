@@ -0,0 +1,381 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/utils"
+)
+
+const clusterStateFileName = "cluster-state.json"
+
+// ClusterStateSchemaVersion is the schema version of the recorded cluster state, bump it
+// and migrate older states in migrateClusterState when the format changes incompatibly.
+const ClusterStateSchemaVersion = 1
+
+// ServiceState is the recorded state of a service on a node.
+type ServiceState struct {
+	Service config.ServiceType `json:"service"`
+	Image   string             `json:"image"`
+	Ports   []int              `json:"ports,omitempty"`
+}
+
+// NodeState is the recorded state of a node.
+type NodeState struct {
+	Name     string          `json:"name"`
+	Host     string          `json:"host"`
+	Services []*ServiceState `json:"services"`
+}
+
+// ClusterState is the recorded state of a deployed cluster. Secrets are never
+// recorded, they are replaced by references to where they come from.
+// Version is the tag of the 3fs image the cluster runs, PreviousVersion is the version
+// before the last upgrade or rollback.
+type ClusterState struct {
+	SchemaVersion        int          `json:"schemaVersion"`
+	Cluster              string       `json:"cluster"`
+	RunID                string       `json:"runID,omitempty"`
+	Version              string       `json:"version,omitempty"`
+	PreviousVersion      string       `json:"previousVersion,omitempty"`
+	UpdateTime           time.Time    `json:"updateTime"`
+	Config               any          `json:"config"`
+	Nodes                []*NodeState `json:"nodes"`
+	FdbClusterFile       string       `json:"fdbClusterFile,omitempty"`
+	MgmtdServerAddresses string       `json:"mgmtdServerAddresses,omitempty"`
+	// MgmtdLeader is the mgmtd node detected as the leader by the last admin operation.
+	MgmtdLeader string `json:"mgmtdLeader,omitempty"`
+	// ClickhouseTopology is the effective topology of clickhouse, e.g. "1 shard(s) x 1 replica(s)".
+	ClickhouseTopology string `json:"clickhouseTopology,omitempty"`
+	// Canary is the outcome of the last canary upgrade, it's nil if no canary ran.
+	Canary *CanaryState `json:"canary,omitempty"`
+	// Cordons are nodes excluded from deployment and upgrade, see Runtime.CordonedNodes.
+	Cordons []*Cordon `json:"cordons,omitempty"`
+}
+
+// CanaryState is the outcome of upgrading a canary node before the rest of the cluster.
+type CanaryState struct {
+	Node    string    `json:"node"`
+	Version string    `json:"version"`
+	Status  RunStatus `json:"status"`
+	Time    time.Time `json:"time"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// SetNodeImage sets the recorded image of the service on the node.
+func (s *ClusterState) SetNodeImage(nodeName string, service config.ServiceType, image string) {
+	for _, node := range s.Nodes {
+		if node.Name != nodeName {
+			continue
+		}
+		for _, svc := range node.Services {
+			if svc.Service == service {
+				svc.Image = image
+			}
+		}
+	}
+}
+
+// NodeImage returns the recorded image of the service on the node, it's empty if the
+// service isn't recorded on the node.
+func (s *ClusterState) NodeImage(nodeName string, service config.ServiceType) string {
+	for _, node := range s.Nodes {
+		if node.Name != nodeName {
+			continue
+		}
+		for _, svc := range node.Services {
+			if svc.Service == service {
+				return svc.Image
+			}
+		}
+	}
+	return ""
+}
+
+// secretRef returns the reference of a secret in the cluster config file.
+func secretRef(field string) string {
+	return fmt.Sprintf("<secret:config:%s>", field)
+}
+
+// RedactConfig returns a copy of the config with secrets replaced by references, which
+// is safe to be recorded or shared.
+func RedactConfig(cfg *config.Config) (any, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	redacted := new(config.Config)
+	if err = yaml.Unmarshal(data, redacted); err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i := range redacted.Nodes {
+		if redacted.Nodes[i].Password != nil {
+			ref := secretRef(fmt.Sprintf("nodes[%s].password", redacted.Nodes[i].Name))
+			redacted.Nodes[i].Password = &ref
+		}
+	}
+	for i := range redacted.NodeGroups {
+		if redacted.NodeGroups[i].Password != nil {
+			ref := secretRef(fmt.Sprintf("nodeGroups[%s].password", redacted.NodeGroups[i].Name))
+			redacted.NodeGroups[i].Password = &ref
+		}
+	}
+	redacted.Services.Clickhouse.Password = secretRef("services.clickhouse.password")
+	// overrides of profiles which aren't applied may embed secrets and don't take effect
+	redacted.Profiles = nil
+	if redacted.StateEncryption.Key != "" {
+		redacted.StateEncryption.Key = secretRef("stateEncryption.key")
+	}
+	secrets := utils.NewSet(cfg.Secrets()...)
+	redactEnv("env", redacted.Env, secrets)
+	for _, node := range redacted.Nodes {
+		redactEnv(fmt.Sprintf("nodes[%s].env", node.Name), node.Env, secrets)
+	}
+	for _, nodeGroup := range redacted.NodeGroups {
+		redactEnv(fmt.Sprintf("nodeGroups[%s].env", nodeGroup.Name), nodeGroup.Env, secrets)
+	}
+
+	// the config only has yaml field names, keep them in json
+	if data, err = yaml.Marshal(redacted); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var value any
+	if err = yaml.Unmarshal(data, &value); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return value, nil
+}
+
+// redactEnv replaces secrets in the env by references, including values which are
+// any of secrets.
+func redactEnv(field string, env map[string]string, secrets *utils.Set[string]) {
+	for name, value := range env {
+		if config.RedactEnvValue(name, value) != value || secrets.Contains(value) {
+			env[name] = secretRef(fmt.Sprintf("%s.%s", field, name))
+		}
+	}
+}
+
+// ServicePorts returns ports the service listens on.
+func ServicePorts(services *config.Services, service config.ServiceType) []int {
+	switch service {
+	case config.ServiceFdb:
+		return []int{services.Fdb.Port}
+	case config.ServiceClickhouse:
+		return []int{services.Clickhouse.TCPPort}
+	case config.ServiceMonitor:
+		return []int{services.Monitor.Port}
+	case config.ServiceMgmtd:
+		return []int{services.Mgmtd.RDMAListenPort, services.Mgmtd.TCPListenPort}
+	case config.ServiceMeta:
+		return []int{services.Meta.RDMAListenPort, services.Meta.TCPListenPort}
+	case config.ServiceStorage:
+		return []int{services.Storage.RDMAListenPort, services.Storage.TCPListenPort}
+	default:
+		return nil
+	}
+}
+
+// nodeStates returns expected state of nodes of the config.
+func nodeStates(cfg *config.Config) ([]*NodeState, error) {
+	nodes := make([]*NodeState, 0, len(cfg.Nodes))
+	for _, node := range cfg.Nodes {
+		nodeState := &NodeState{Name: node.Name, Host: node.Host}
+		for _, service := range config.AllServiceTypes {
+			if !slices.Contains(cfg.Services.ServiceNodes(service), node.Name) {
+				continue
+			}
+			img, err := cfg.Images.GetImage(config.ServiceImageName(service))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			nodeState.Services = append(nodeState.Services, &ServiceState{
+				Service: service,
+				Image:   img,
+				Ports:   ServicePorts(&cfg.Services, service),
+			})
+		}
+		if len(nodeState.Services) > 0 {
+			nodes = append(nodes, nodeState)
+		}
+	}
+	return nodes, nil
+}
+
+// NewClusterState creates the state of the cluster deployed by the runtime.
+func NewClusterState(r *Runtime) (*ClusterState, error) {
+	cfg, err := RedactConfig(r.Cfg)
+	if err != nil {
+		return nil, errors.Annotate(err, "redact config")
+	}
+	nodes, err := nodeStates(r.Cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	state := &ClusterState{
+		Cluster:    r.Cfg.Name,
+		RunID:      r.RunID,
+		Version:    r.Cfg.Images.FFFS.Tag,
+		UpdateTime: time.Now(),
+		Config:     cfg,
+		Nodes:      nodes,
+	}
+	if len(r.Cfg.Services.Clickhouse.Nodes) > 0 {
+		state.ClickhouseTopology = r.Cfg.Services.Clickhouse.TopologyString()
+	}
+	state.FdbClusterFile, _ = r.LoadString(RuntimeFdbClusterFileContentKey)
+	state.MgmtdServerAddresses, _ = r.LoadString(RuntimeMgmtdServerAddressesKey)
+	state.MgmtdLeader, _ = r.LoadString(RuntimeMgmtdLeaderKey)
+	return state, nil
+}
+
+// Diverge returns differences between the recorded state and the config.
+func (s *ClusterState) Diverge(cfg *config.Config) ([]string, error) {
+	nodes, err := nodeStates(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	recorded := make(map[string]*NodeState, len(s.Nodes))
+	for _, node := range s.Nodes {
+		recorded[node.Name] = node
+	}
+
+	var diffs []string
+	for _, node := range nodes {
+		recordedNode, ok := recorded[node.Name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("node %s isn't recorded", node.Name))
+			continue
+		}
+		delete(recorded, node.Name)
+		if recordedNode.Host != node.Host {
+			diffs = append(diffs, fmt.Sprintf("host of node %s is %s but %s is recorded",
+				node.Name, node.Host, recordedNode.Host))
+		}
+		recordedServices := make(map[config.ServiceType]*ServiceState, len(recordedNode.Services))
+		for _, service := range recordedNode.Services {
+			recordedServices[service.Service] = service
+		}
+		for _, service := range node.Services {
+			recordedService, ok := recordedServices[service.Service]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("%s on node %s isn't recorded", service.Service, node.Name))
+				continue
+			}
+			delete(recordedServices, service.Service)
+			if recordedService.Image != service.Image {
+				diffs = append(diffs, fmt.Sprintf("image of %s on node %s is %s but %s is recorded",
+					service.Service, node.Name, service.Image, recordedService.Image))
+			}
+			if !slices.Equal(recordedService.Ports, service.Ports) {
+				diffs = append(diffs, fmt.Sprintf("ports of %s on node %s are %v but %v are recorded",
+					service.Service, node.Name, service.Ports, recordedService.Ports))
+			}
+		}
+		for _, service := range recordedNode.Services {
+			if _, ok := recordedServices[service.Service]; ok {
+				diffs = append(diffs, fmt.Sprintf("%s on node %s is recorded but not configured",
+					service.Service, node.Name))
+			}
+		}
+	}
+	for _, node := range s.Nodes {
+		if _, ok := recorded[node.Name]; ok {
+			diffs = append(diffs, fmt.Sprintf("node %s is recorded but not configured", node.Name))
+		}
+	}
+	return diffs, nil
+}
+
+// ClusterStateFilePath returns path of the recorded state file of the cluster.
+func ClusterStateFilePath(workDir, clusterName string) string {
+	return filepath.Join(ClusterStateDir(workDir, clusterName), clusterStateFileName)
+}
+
+// SaveClusterState saves the state of the cluster.
+func SaveClusterState(workDir string, state *ClusterState) error {
+	statePath := ClusterStateFilePath(workDir, state.Cluster)
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		return errors.Annotatef(err, "create directory of %s", statePath)
+	}
+	state.SchemaVersion = ClusterStateSchemaVersion
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if data, err = sealState(data); err != nil {
+		return errors.Annotatef(err, "encrypt cluster state %s", statePath)
+	}
+	// the state file contains the fdb cluster file, so it's only readable by the owner
+	if err = WriteFileAtomic(statePath, data, 0600); err != nil {
+		return errors.Annotatef(err, "write cluster state %s", statePath)
+	}
+	return nil
+}
+
+// LoadClusterState loads the recorded state of the cluster, it returns nil if
+// the cluster hasn't been deployed.
+func LoadClusterState(workDir, clusterName string) (*ClusterState, error) {
+	statePath := ClusterStateFilePath(workDir, clusterName)
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Annotatef(err, "read cluster state %s", statePath)
+	}
+	if data, err = openState(data); err != nil {
+		return nil, errors.Annotatef(err, "read cluster state %s", statePath)
+	}
+	state := new(ClusterState)
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, errors.Annotatef(err, "parse cluster state %s", statePath)
+	}
+	if err = migrateClusterState(state); err != nil {
+		return nil, errors.Annotatef(err, "migrate cluster state %s", statePath)
+	}
+	return state, nil
+}
+
+// migrateClusterState migrates the state recorded by an older m3fs to the current schema.
+func migrateClusterState(state *ClusterState) error {
+	if state.SchemaVersion > ClusterStateSchemaVersion {
+		return errors.Errorf("schema version %d is newer than %d supported, upgrade m3fs",
+			state.SchemaVersion, ClusterStateSchemaVersion)
+	}
+	// states recorded before the schema version was introduced are compatible with version 1
+	if state.SchemaVersion == 0 {
+		state.SchemaVersion = 1
+	}
+	return nil
+}
+
+// RemoveClusterState removes the recorded state of the cluster.
+func RemoveClusterState(workDir, clusterName string) error {
+	statePath := ClusterStateFilePath(workDir, clusterName)
+	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		return errors.Annotatef(err, "remove cluster state %s", statePath)
+	}
+	return nil
+}
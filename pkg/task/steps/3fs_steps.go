@@ -23,12 +23,13 @@ import (
 	"path"
 	"strconv"
 	"strings"
-	"text/template"
 
 	"github.com/open3fs/m3fs/pkg/common"
 	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	"github.com/open3fs/m3fs/pkg/mgmtd/leader"
 	"github.com/open3fs/m3fs/pkg/task"
 )
 
@@ -49,21 +50,22 @@ type gen3FSNodeIDStep struct {
 }
 
 func (s *gen3FSNodeIDStep) Execute(context.Context) error {
-	nodes := make([]config.Node, len(s.nodes))
-	for i, nodeName := range s.nodes {
-		nodes[i] = s.Runtime.Nodes[nodeName]
-	}
-
-	nodeIDMap := make(map[string]int, len(nodes))
-	for i, node := range nodes {
-		s.Runtime.Store(getNodeIDKey(s.service, node.Name), s.idBegin+i)
-		nodeIDMap[node.Name] = s.idBegin + i
-	}
+	nodeIDMap := Gen3FSNodeIDs(s.Runtime, s.service, s.idBegin, s.nodes)
 	s.Logger.Debugf("Node ID map: %v", nodeIDMap)
 
 	return nil
 }
 
+// Gen3FSNodeIDs generates ids of the 3fs service nodes and stores them into the runtime.
+func Gen3FSNodeIDs(r *task.Runtime, service string, idBegin int, nodes []string) map[string]int {
+	nodeIDMap := make(map[string]int, len(nodes))
+	for i, nodeName := range nodes {
+		r.Store(getNodeIDKey(service, nodeName), idBegin+i)
+		nodeIDMap[nodeName] = idBegin + i
+	}
+	return nodeIDMap
+}
+
 // NewGen3FSNodeIDStepFunc is the generate 3fs node id step factory func.
 func NewGen3FSNodeIDStepFunc(service string, idBegin int, nodes []string) func() task.Step {
 	return func() task.Step {
@@ -81,6 +83,8 @@ type Extra3FSConfigFile struct {
 	FileName string
 }
 
+// prepare3FSConfigStep renders config files of the 3fs service for its node, files of
+// all nodes are distributed by distribute3FSConfigStep.
 type prepare3FSConfigStep struct {
 	task.BaseStep
 
@@ -92,7 +96,10 @@ type prepare3FSConfigStep struct {
 	rdmaListenPort       int
 	tcpListenPort        int
 	extraMainTomlData    map[string]any
+	extraLauncherData    map[string]any
+	mgmtdServerAddresses string
 	extraConfigFilesFunc func(*task.Runtime) []*Extra3FSConfigFile
+	extraConfig          config.ExtraConfig
 }
 
 func (s *prepare3FSConfigStep) getMoniterEndpoints() string {
@@ -106,87 +113,42 @@ func (s *prepare3FSConfigStep) getMoniterEndpoints() string {
 	return strings.Join(endpoints, ",")
 }
 
-func (s *prepare3FSConfigStep) Execute(ctx context.Context) error {
-	localEm := s.Runtime.LocalEm
-	tmpDir, err := localEm.FS.MkdirTemp(ctx, os.TempDir(), "prepare-3fs-config")
-	if err != nil {
-		return errors.Trace(err)
-	}
-	defer func() {
-		if err := localEm.FS.RemoveAll(ctx, tmpDir); err != nil {
-			s.Logger.Errorf("Failed to remove temporary directory %s: %v", tmpDir, err)
-		}
-	}()
-
-	s.Logger.Infof("Create %s config dir %s", s.service, s.serviceWorkDir)
-	if err = s.Em.FS.MkdirAll(ctx, s.serviceWorkDir); err != nil {
-		return errors.Trace(err)
-	}
-
-	if err = s.genConfigs(tmpDir); err != nil {
-		return errors.Trace(err)
-	}
-
-	if err = s.genFdbClusterFile(tmpDir); err != nil {
-		return errors.Trace(err)
-	}
-
-	if err = s.copyFile(ctx, tmpDir); err != nil {
-		return errors.Trace(err)
-	}
-
-	return nil
-}
-
-func (s *prepare3FSConfigStep) copyFile(ctx context.Context, src string) error {
-	dst := getConfigDir(s.serviceWorkDir)
-	s.Logger.Infof("Copying %s configs from %s to %s %s", s.service, src, s.Node.Name, dst)
-	if err := s.Em.Runner.Scp(ctx, src, dst); err != nil {
-		return errors.Trace(err)
-	}
-
-	return nil
-}
+func (s *prepare3FSConfigStep) genConfig(fileName string, tmpl []byte, tmplData any) (
+	*task.RenderedFile, error) {
 
-func (s *prepare3FSConfigStep) genConfig(path, tmplName string, tmpl []byte, tmplData any) error {
-	s.Logger.Infof("Generating %s to %s", tmplName, path)
-	t, err := template.New(tmplName).Parse(string(tmpl))
+	filePath := path.Join(getConfigDir(s.serviceWorkDir), fileName)
+	s.Logger.Infof("Generating %s", fileName)
+	t, err := s.Runtime.NewTemplate(fileName).Parse(string(tmpl))
 	if err != nil {
-		return errors.Annotatef(err, "parse template of %s", path)
+		return nil, errors.Annotatef(err, "parse template of %s", filePath)
 	}
 	data := new(bytes.Buffer)
 
 	err = t.Execute(data, tmplData)
 	if err != nil {
-		return errors.Annotatef(err, "execute template of %s", path)
+		return nil, errors.Annotatef(err, "execute template of %s", filePath)
 	}
-	s.Logger.Debugf("Config of %s: %s", tmplName, data.String())
+	s.Logger.Debugf("Config of %s: %s", fileName, data.String())
 
-	err = s.Runtime.LocalEm.FS.WriteFile(path, data.Bytes(), 0644)
-	if err != nil {
-		return errors.Trace(err)
-	}
-
-	return nil
+	return &task.RenderedFile{Path: filePath, Data: data.Bytes()}, nil
 }
 
-func (s *prepare3FSConfigStep) genConfigs(tmpDir string) error {
+// renderConfigs renders all config files placed in the config dir of the service.
+func (s *prepare3FSConfigStep) renderConfigs() ([]*task.RenderedFile, error) {
 	nodeID, _ := s.Runtime.LoadInt(getNodeIDKey(s.service, s.Node.Name))
-	mgmtdServerAddresses, _ := s.Runtime.LoadString(task.RuntimeMgmtdServerAddressesKey)
-
-	mainAppToml := path.Join(tmpDir, fmt.Sprintf("%s_app.toml", s.service))
-	mainLauncherToml := path.Join(tmpDir, fmt.Sprintf("%s_launcher.toml", s.service))
-	mainToml := path.Join(tmpDir, fmt.Sprintf("%s.toml", s.service))
-	adminCliToml := path.Join(tmpDir, "admin_cli.toml")
+	mgmtdServerAddresses := s.mgmtdServerAddresses
+	if mgmtdServerAddresses == "" {
+		mgmtdServerAddresses, _ = s.Runtime.LoadString(task.RuntimeMgmtdServerAddressesKey)
+	}
+	configDir := getConfigDir(s.serviceWorkDir)
 
 	appTmplData := map[string]any{
 		"NodeID": nodeID,
 	}
 	s.Logger.Debugf("Template data of %s_app.toml.tmpl: %v", s.service, appTmplData)
-	if err := s.genConfig(mainAppToml, fmt.Sprintf("%s_app.toml", s.service),
-		s.mainAppTomlTmpl, appTmplData); err != nil {
-
-		return errors.Trace(err)
+	mainApp, err := s.genConfig(fmt.Sprintf("%s_app.toml", s.service), s.mainAppTomlTmpl, appTmplData)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 
 	launcherTmplData := map[string]any{
@@ -194,11 +156,14 @@ func (s *prepare3FSConfigStep) genConfigs(tmpDir string) error {
 		"HostMountpoint":       s.Runtime.Cfg.Services.Client.HostMountpoint,
 		"MgmtdServerAddresses": mgmtdServerAddresses,
 	}
+	for k, v := range s.extraLauncherData {
+		launcherTmplData[k] = v
+	}
 	s.Logger.Debugf("Template data of %s_launcher.toml.tmpl: %v", s.service, launcherTmplData)
-	if err := s.genConfig(mainLauncherToml, fmt.Sprintf("%s_launcher.toml", s.service),
-		s.mainLauncherTomlTmpl, launcherTmplData); err != nil {
-
-		return errors.Trace(err)
+	mainLauncher, err := s.genConfig(fmt.Sprintf("%s_launcher.toml", s.service),
+		s.mainLauncherTomlTmpl, launcherTmplData)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 
 	mainTmplData := map[string]any{
@@ -213,71 +178,126 @@ func (s *prepare3FSConfigStep) genConfigs(tmpDir string) error {
 		mainTmplData[k] = v
 	}
 	s.Logger.Debugf("Template data of %s.toml.tmpl: %v", s.service, mainTmplData)
-	if err := s.genConfig(mainToml, fmt.Sprintf("%s.toml", s.service),
-		s.mainTomlTmpl, mainTmplData); err != nil {
-
-		return errors.Trace(err)
+	mainToml, err := s.genConfig(fmt.Sprintf("%s.toml", s.service), s.mainTomlTmpl, mainTmplData)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
-
-	adminCliI, _ := s.Runtime.Load(task.RuntimeAdminCliTomlKey)
-	adminCliTomlData := adminCliI.([]byte)
-	s.Logger.Infof("Save admin cli config to %s", adminCliToml)
-	err := s.Runtime.LocalEm.FS.WriteFile(adminCliToml, adminCliTomlData, os.FileMode(0644))
+	var skipped []string
+	mainToml.Data, skipped, err = mergeExtraConfig(mainToml.Data, s.mainTomlTmpl, s.extraConfig)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Annotatef(err, "merge extraConfig into %s.toml", s.service)
+	}
+	for _, key := range skipped {
+		s.Logger.Warnf("%s of extraConfig is managed by m3fs and skipped, set override to override it", key)
 	}
+	files := []*task.RenderedFile{mainApp, mainLauncher, mainToml}
+
+	adminCliI, _ := s.Runtime.Load(task.RuntimeAdminCliTomlKey)
+	files = append(files, &task.RenderedFile{
+		Path: path.Join(configDir, "admin_cli.toml"),
+		Data: adminCliI.([]byte),
+	})
 
 	if s.extraConfigFilesFunc != nil {
 		for _, extraCfg := range s.extraConfigFilesFunc(s.Runtime) {
-			filePath := path.Join(tmpDir, extraCfg.FileName)
-			s.Logger.Infof("Save %s to %s", extraCfg.FileName, filePath)
-			if err = s.Runtime.LocalEm.FS.WriteFile(filePath, extraCfg.Data, os.FileMode(0644)); err != nil {
-				return errors.Trace(err)
-			}
+			files = append(files, &task.RenderedFile{
+				Path: path.Join(configDir, extraCfg.FileName),
+				Data: extraCfg.Data,
+			})
 		}
 	}
 
-	return nil
-}
-
-func (s *prepare3FSConfigStep) genFdbClusterFile(tmpDir string) error {
 	content, _ := s.Runtime.LoadString(task.RuntimeFdbClusterFileContentKey)
+	files = append(files, &task.RenderedFile{
+		Path: path.Join(configDir, "fdb.cluster"),
+		Data: []byte(content),
+	})
 
-	clusterFilePath := path.Join(tmpDir, "fdb.cluster")
-	s.Logger.Infof("Generating fdb.cluster to %s", clusterFilePath)
-	if err := s.Runtime.LocalEm.FS.WriteFile(clusterFilePath, []byte(content), 0644); err != nil {
-		return errors.Trace(err)
-	}
+	return files, nil
+}
 
-	return nil
+// Render3FSConfigs renders config files of the 3fs service for the node without
+// touching the node.
+func Render3FSConfigs(r *task.Runtime, setup *Prepare3FSConfigStepSetup, node config.Node) (
+	[]*task.RenderedFile, error) {
+
+	step := newPrepare3FSConfigStep(setup)
+	step.Init(r, nil, node, log.Logger.Subscribe(log.FieldKeyNode, node.Name))
+	files, err := step.renderConfigs()
+	return files, errors.Trace(err)
 }
 
 // Prepare3FSConfigStepSetup is a struct that holds the configuration of the prepare3FSConfigStep.
 type Prepare3FSConfigStepSetup struct {
-	Service                 string
-	ServiceWorkDir          string
-	MainAppTomlTmpl         []byte
-	MainLauncherTomlTmpl    []byte
-	MainTomlTmpl            []byte
-	RDMAListenPort          int
-	TCPListenPort           int
-	ExtraMainTomlData       map[string]any
+	Service               string
+	ServiceWorkDir        string
+	MainAppTomlTmpl       []byte
+	MainLauncherTomlTmpl  []byte
+	MainTomlTmpl          []byte
+	RDMAListenPort        int
+	TCPListenPort         int
+	ExtraMainTomlData     map[string]any
+	ExtraLauncherTomlData map[string]any
+	// MgmtdServerAddresses overrides mgmtd server addresses of the runtime if it's set.
+	MgmtdServerAddresses    string
 	Extra3FSConfigFilesFunc func(*task.Runtime) []*Extra3FSConfigFile
+	// ExtraConfig is merged into the main config file.
+	ExtraConfig config.ExtraConfig
+}
+
+func newPrepare3FSConfigStep(setup *Prepare3FSConfigStepSetup) *prepare3FSConfigStep {
+	return &prepare3FSConfigStep{
+		service:              setup.Service,
+		serviceWorkDir:       setup.ServiceWorkDir,
+		mainAppTomlTmpl:      setup.MainAppTomlTmpl,
+		mainLauncherTomlTmpl: setup.MainLauncherTomlTmpl,
+		mainTomlTmpl:         setup.MainTomlTmpl,
+		rdmaListenPort:       setup.RDMAListenPort,
+		tcpListenPort:        setup.TCPListenPort,
+		extraMainTomlData:    setup.ExtraMainTomlData,
+		extraLauncherData:    setup.ExtraLauncherTomlData,
+		mgmtdServerAddresses: setup.MgmtdServerAddresses,
+		extraConfigFilesFunc: setup.Extra3FSConfigFilesFunc,
+		extraConfig:          setup.ExtraConfig,
+	}
+}
+
+// distribute3FSConfigStep distributes config files of the 3fs service to all its nodes
+// in two phases, so that either all nodes get the new configs or none do. It runs on a
+// single node like gen3FSNodeIDStep.
+type distribute3FSConfigStep struct {
+	task.BaseStep
+
+	setup *Prepare3FSConfigStepSetup
+	nodes []config.Node
+}
+
+func (s *distribute3FSConfigStep) Execute(ctx context.Context) error {
+	nodes := s.Runtime.FilterNodes(s.nodes)
+	nodeFiles := make([]task.NodeFiles, 0, len(nodes))
+	for _, node := range nodes {
+		files, err := Render3FSConfigs(s.Runtime, s.setup, node)
+		if err != nil {
+			return errors.Annotatef(err, "render %s configs of node %s", s.setup.Service, node.Name)
+		}
+		nodeFiles = append(nodeFiles, task.NodeFiles{Node: node, Files: files})
+	}
+
+	s.Logger.Infof("Distributing %s configs to %d node(s)", s.setup.Service, len(nodeFiles))
+	parallel := s.Runtime.PhaseParallel(task.PhaseDeploy, len(nodeFiles))
+	if err := task.DistributeFiles(ctx, s.Runtime, nodeFiles, parallel); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
 }
 
-// NewPrepare3FSConfigStepFunc is prepare 3fs config step factory func.
-func NewPrepare3FSConfigStepFunc(setup *Prepare3FSConfigStepSetup) func() task.Step {
+// NewDistribute3FSConfigStepFunc is the distribute 3fs config step factory func, the
+// step renders configs of the nodes and distributes them.
+func NewDistribute3FSConfigStepFunc(setup *Prepare3FSConfigStepSetup, nodes []config.Node) func() task.Step {
 	return func() task.Step {
-		return &prepare3FSConfigStep{
-			service:              setup.Service,
-			serviceWorkDir:       setup.ServiceWorkDir,
-			mainAppTomlTmpl:      setup.MainAppTomlTmpl,
-			mainLauncherTomlTmpl: setup.MainLauncherTomlTmpl,
-			mainTomlTmpl:         setup.MainTomlTmpl,
-			rdmaListenPort:       setup.RDMAListenPort,
-			tcpListenPort:        setup.TCPListenPort,
-			extraMainTomlData:    setup.ExtraMainTomlData,
-			extraConfigFilesFunc: setup.Extra3FSConfigFilesFunc,
+		return &distribute3FSConfigStep{
+			setup: setup,
+			nodes: nodes,
 		}
 	}
 }
@@ -288,9 +308,11 @@ type run3FSContainerStep struct {
 	imgName        string
 	containerName  string
 	service        string
+	serviceType    config.ServiceType
 	serviceWorkDir string
 	extraVolumes   []*external.VolumeArgs
 	useRdmaNetwork bool
+	listenPort     int
 }
 
 func (s *run3FSContainerStep) Execute(ctx context.Context) error {
@@ -336,10 +358,22 @@ func (s *run3FSContainerStep) Execute(ctx context.Context) error {
 		}
 		args.Volumes = append(args.Volumes, s.GetRdmaVolumes()...)
 	}
+	args.SetResources(s.Runtime.Services.Resources(s.serviceType))
+	args.SetPassthrough(s.Runtime.Services.Passthrough(s.serviceType))
+	if wrapper := s.Runtime.Services.CommandWrapper(s.serviceType); len(wrapper) > 0 {
+		s.Logger.Warnf("Command of %s is wrapped by %q, clear services.%s.commandWrapper to remove it",
+			s.service, strings.Join(wrapper, " "), s.serviceType)
+		args.SetCommandWrapper(wrapper)
+	}
 	_, err = s.Em.Docker.Run(ctx, args)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	err = s.Runtime.WaitServiceReady(ctx, s.serviceType,
+		task.ServiceListenProbe(s.Em, s.containerName, s.service, s.listenPort))
+	if err != nil {
+		return errors.Annotatef(err, "wait %s ready", s.service)
+	}
 
 	s.Logger.Infof("Started %s container %s successfully", s.service, s.containerName)
 	return nil
@@ -350,9 +384,13 @@ type Run3FSContainerStepSetup struct {
 	ImgName        string
 	ContainerName  string
 	Service        string
+	ServiceType    config.ServiceType
 	WorkDir        string
 	ExtraVolumes   []*external.VolumeArgs
 	UseRdmaNetwork bool
+	// ListenPort is the tcp port the service listens on once it's ready, the readiness
+	// of the service is only judged by its container if it's 0.
+	ListenPort int
 }
 
 // NewRun3FSContainerStepFunc is run3FSContainer factory func.
@@ -362,9 +400,55 @@ func NewRun3FSContainerStepFunc(setup *Run3FSContainerStepSetup) func() task.Ste
 			imgName:        setup.ImgName,
 			containerName:  setup.ContainerName,
 			service:        setup.Service,
+			serviceType:    setup.ServiceType,
 			serviceWorkDir: setup.WorkDir,
 			extraVolumes:   setup.ExtraVolumes,
 			useRdmaNetwork: setup.UseRdmaNetwork,
+			listenPort:     setup.ListenPort,
+		}
+	}
+}
+
+type upgrade3FSContainerStep struct {
+	run3FSContainerStep
+
+	newAfterRmStep func() task.Step
+}
+
+func (s *upgrade3FSContainerStep) Execute(ctx context.Context) error {
+	s.Logger.Infof("Upgrading %s container %s", s.service, s.containerName)
+	if err := s.StopContainer(ctx, s.serviceType, s.containerName); err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := s.Em.Docker.Rm(ctx, s.containerName, true); err != nil {
+		return errors.Annotatef(err, "remove %s container %s", s.service, s.containerName)
+	}
+	if s.newAfterRmStep != nil {
+		step := s.newAfterRmStep()
+		step.Init(s.Runtime, s.Em, s.Node, s.Logger)
+		if err := step.Execute(ctx); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := s.run3FSContainerStep.Execute(ctx); err != nil {
+		return errors.Trace(err)
+	}
+	s.Logger.Infof("Upgraded %s container %s successfully", s.service, s.containerName)
+	return nil
+}
+
+// NewUpgrade3FSContainerStepFunc is upgrade3FSContainer factory func. The step replaces the
+// container of the service with a new one running the image in the config, config files and
+// data of the service are kept. The newAfterRmStep is optional, it's executed on the node
+// after the old container is removed.
+func NewUpgrade3FSContainerStepFunc(setup *Run3FSContainerStepSetup,
+	newAfterRmStep func() task.Step) func() task.Step {
+
+	newRunStep := NewRun3FSContainerStepFunc(setup)
+	return func() task.Step {
+		return &upgrade3FSContainerStep{
+			run3FSContainerStep: *newRunStep().(*run3FSContainerStep),
+			newAfterRmStep:      newAfterRmStep,
 		}
 	}
 }
@@ -374,10 +458,14 @@ type rm3FSContainerStep struct {
 
 	containerName  string
 	service        string
+	serviceType    config.ServiceType
 	serviceWorkDir string
 }
 
 func (s *rm3FSContainerStep) Execute(ctx context.Context) error {
+	if err := s.StopContainer(ctx, s.serviceType, s.containerName); err != nil {
+		return errors.Trace(err)
+	}
 	s.Logger.Infof("Removing %s container %s", s.service, s.containerName)
 	_, err := s.Em.Docker.Rm(ctx, s.containerName, true)
 	if err != nil {
@@ -402,12 +490,16 @@ func (s *rm3FSContainerStep) Execute(ctx context.Context) error {
 	return nil
 }
 
-// NewRm3FSContainerStepFunc is rm3FSContainer factory func.
-func NewRm3FSContainerStepFunc(containerName, service, serviceWorkDir string) func() task.Step {
+// NewRm3FSContainerStepFunc is rm3FSContainer factory func. The container is stopped
+// gracefully by the shutdown config of the service type before it's removed.
+func NewRm3FSContainerStepFunc(containerName, service string, serviceType config.ServiceType,
+	serviceWorkDir string) func() task.Step {
+
 	return func() task.Step {
 		return &rm3FSContainerStep{
 			containerName:  containerName,
 			service:        service,
+			serviceType:    serviceType,
 			serviceWorkDir: serviceWorkDir,
 		}
 	}
@@ -434,10 +526,33 @@ type upload3FSMainConfigStep struct {
 
 func (s *upload3FSMainConfigStep) Execute(ctx context.Context) error {
 	s.Logger.Infof("Upload %s main config", s.service)
-	img, err := s.Runtime.Cfg.Images.GetImage(s.imgName)
+	if err := s.GetErdmaSoPath(ctx); err != nil {
+		return errors.Trace(err)
+	}
+	command := fmt.Sprintf("set-config --type %s --file /opt/3fs/etc/%s.toml", s.serviceType, s.service)
+	var err error
+	if !s.Runtime.Services.Enabled(config.ServiceMgmtd) || len(s.Runtime.Services.Mgmtd.Nodes) == 0 {
+		// the client connects to mgmtd of another cluster
+		_, err = s.adminCli(ctx, GetMgmtdServerAddresses(s.Runtime), command)
+	} else {
+		// setting the same config again is idempotent, so it's retried with a new leader
+		_, err = leader.NewRouter(s.Runtime, s.adminCli, s.Logger).Run(ctx, command)
+	}
 	if err != nil {
 		return errors.Trace(err)
 	}
+
+	s.Logger.Infof("Service %s main config uploaded", s.service)
+	return nil
+}
+
+// adminCli runs the admin_cli command in a temporary container of the 3fs image with
+// config files of the service.
+func (s *upload3FSMainConfigStep) adminCli(ctx context.Context, addr, command string) (string, error) {
+	img, err := s.Runtime.Cfg.Images.GetImage(s.imgName)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
 	args := &external.RunArgs{
 		Image:       img,
 		Name:        &s.containerName,
@@ -452,9 +567,8 @@ func (s *upload3FSMainConfigStep) Execute(ctx context.Context) error {
 			"/opt/3fs/bin/admin_cli",
 			"-cfg", "/opt/3fs/etc/admin_cli.toml",
 			"--config.mgmtd_client.mgmtd_server_addresses",
-			fmt.Sprintf("'%s'", GetMgmtdServerAddresses(s.Runtime)),
-			fmt.Sprintf("'set-config --type %s --file /opt/3fs/etc/%s.toml'",
-				s.serviceType, s.service),
+			fmt.Sprintf("'%s'", addr),
+			fmt.Sprintf("'%s'", command),
 		},
 		Volumes: []*external.VolumeArgs{
 			{
@@ -467,18 +581,9 @@ func (s *upload3FSMainConfigStep) Execute(ctx context.Context) error {
 			},
 		},
 	}
-
-	if err := s.GetErdmaSoPath(ctx); err != nil {
-		return errors.Trace(err)
-	}
 	args.Volumes = append(args.Volumes, s.GetRdmaVolumes()...)
-	_, err = s.Em.Docker.Run(ctx, args)
-	if err != nil {
-		return errors.Trace(err)
-	}
-
-	s.Logger.Infof("Service %s main config uploaded", s.service)
-	return nil
+	out, err := s.Em.Docker.Run(ctx, args)
+	return out, errors.Trace(err)
 }
 
 // NewUpload3FSMainConfigStepFunc is upload3FSMainConfigStep factory func.
@@ -509,7 +614,7 @@ type remoteRunScriptStep struct {
 func (s *remoteRunScriptStep) Execute(ctx context.Context) error {
 	s.Logger.Infof("Start to run script %s on node", s.scriptName)
 	localEm := s.Runtime.LocalEm
-	tmpDir, err := localEm.FS.MkdirTemp(ctx, os.TempDir(), "remote-run-script")
+	tmpDir, err := localEm.FS.MkdirTemp(ctx, s.Runtime.LocalTempDir(), "remote-run-script")
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -519,7 +624,7 @@ func (s *remoteRunScriptStep) Execute(ctx context.Context) error {
 		}
 	}()
 
-	tmpl, err := template.New(s.scriptName).Parse(string(s.scriptTmpl))
+	tmpl, err := s.Runtime.NewTemplate(s.scriptName).Parse(string(s.scriptTmpl))
 	if err != nil {
 		return errors.Trace(err)
 	}
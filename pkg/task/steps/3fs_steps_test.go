@@ -15,8 +15,14 @@
 package steps
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
+	"regexp"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -25,6 +31,7 @@ import (
 	"github.com/open3fs/m3fs/pkg/config"
 	"github.com/open3fs/m3fs/pkg/errors"
 	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
 	"github.com/open3fs/m3fs/pkg/task"
 	ttask "github.com/open3fs/m3fs/tests/task"
 )
@@ -81,6 +88,7 @@ type prepare3FSConfigStepSuite struct {
 	ttask.StepSuite
 
 	step       *prepare3FSConfigStep
+	setup      *Prepare3FSConfigStepSetup
 	node       config.Node
 	fdbContent string
 }
@@ -102,7 +110,7 @@ func (s *prepare3FSConfigStepSuite) SetupTest() {
 	s.Cfg.Services.Mgmtd.RDMAListenPort = 8000
 	s.SetupRuntime()
 
-	s.step = NewPrepare3FSConfigStepFunc(&Prepare3FSConfigStepSetup{
+	s.setup = &Prepare3FSConfigStepSetup{
 		Service:        "mgmtd_main",
 		ServiceWorkDir: "/root/3fs/mgmtd",
 		TCPListenPort:  9000,
@@ -117,7 +125,8 @@ monitor_remote_ip = "{{ .MonitorRemoteIP }}"
 mgmtd_server_addresses = {{ .MgmtdServerAddresses }}
 listen_port = {{ .TCPListenPort }}
 listen_port_rdma = {{ .RDMAListenPort }}`),
-	})().(*prepare3FSConfigStep)
+	}
+	s.step = newPrepare3FSConfigStep(s.setup)
 	s.step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
 	s.Runtime.Store(getNodeIDKey("mgmtd_main", s.Cfg.Nodes[0].Name), 1)
 	s.fdbContent = "xxxx,xxxxx,xxxx"
@@ -125,14 +134,6 @@ listen_port_rdma = {{ .RDMAListenPort }}`),
 	s.Runtime.Store(task.RuntimeAdminCliTomlKey, []byte("admin_cli"))
 }
 
-func (s *prepare3FSConfigStepSuite) mockGenConfig(path, tmpContent string) {
-	var data any = mock.AnythingOfType("[]uint8")
-	if tmpContent != "" {
-		data = []byte(tmpContent)
-	}
-	s.MockLocalFS.On("WriteFile", path, data, os.FileMode(0644)).Return(nil)
-}
-
 func (s *prepare3FSConfigStepSuite) getGeneratedConfigContent() (string, string, string, string) {
 	mainApp := `allow_empty_node_id = true
 node_id = 1`
@@ -148,33 +149,119 @@ listen_port_rdma = 8000`
 	return mainApp, mainLauncher, mainContent, adminCli
 }
 
-func (s *prepare3FSConfigStepSuite) testPrepareConfig(removeAllErr error) {
-	tmpDir := "/root/tmp..."
-	s.MockLocalFS.On("MkdirTemp", "/tmp", "prepare-3fs-config").Return(tmpDir, nil)
-	s.MockLocalFS.On("RemoveAll", tmpDir).Return(removeAllErr)
-	mainAppConfig, mainLauncherConfig, mainConfig, adminCli := s.getGeneratedConfigContent()
-	s.mockGenConfig(tmpDir+"/mgmtd_main_app.toml", mainAppConfig)
-	s.mockGenConfig(tmpDir+"/mgmtd_main_launcher.toml", mainLauncherConfig)
-	s.mockGenConfig(tmpDir+"/mgmtd_main.toml", mainConfig)
-	s.mockGenConfig(tmpDir+"/admin_cli.toml", adminCli)
-	s.MockLocalFS.On("WriteFile", tmpDir+"/fdb.cluster", []byte(s.fdbContent), os.FileMode(0644)).
-		Return(nil)
-	s.MockFS.On("MkdirAll", "/root/3fs/mgmtd").Return(nil)
-	s.MockRunner.On("Scp", tmpDir, "/root/3fs/mgmtd/config.d").Return(nil)
+// distribute runs the distribute step on node1 and node2 after sha256 hashes of staged
+// files are scripted, except on nodes of badHashNodes.
+func (s *prepare3FSConfigStepSuite) distribute(script *externaltest.Script, badHashNodes ...string) error {
+	s.Cfg.Nodes = append(s.Cfg.Nodes, config.Node{Name: "node2", Host: "1.1.1.2"})
+	s.Cfg.Services.Mgmtd.Nodes = []string{"node1", "node2"}
+	s.Runtime.Nodes["node2"] = s.Cfg.Nodes[1]
+	s.Runtime.Store(getNodeIDKey("mgmtd_main", "node2"), 2)
+	script.OnNode("local", `^mktemp`, externaltest.Response{Output: s.T().TempDir()})
+	s.Runtime.LocalEm = script.Manager("local", s.Logger)
+	s.Runtime.NewNodeManager = script.NodeManager
+	for _, node := range s.Cfg.Nodes {
+		if slices.Contains(badHashNodes, node.Name) {
+			continue
+		}
+		files, err := Render3FSConfigs(s.Runtime, s.setup, node)
+		s.NoError(err)
+		for _, file := range files {
+			hash := sha256.Sum256(file.Data)
+			script.OnNode(node.Name, "^sha256sum "+regexp.QuoteMeta(file.Path)+`\.m3fs-staged$`,
+				externaltest.Response{Output: hex.EncodeToString(hash[:])})
+		}
+	}
 
-	s.NoError(s.step.Execute(s.Ctx()))
+	step := NewDistribute3FSConfigStepFunc(s.setup, s.Cfg.Nodes)()
+	step.Init(s.Runtime, s.MockEm, s.Cfg.Nodes[0], s.Logger)
+	return step.Execute(s.Ctx())
+}
 
-	s.MockLocalFS.AssertExpectations(s.T())
-	s.MockFS.AssertExpectations(s.T())
-	s.MockRunner.AssertExpectations(s.T())
+func (s *prepare3FSConfigStepSuite) TestDistributeConfig() {
+	script := externaltest.NewScript()
+	s.NoError(s.distribute(script))
+
+	for _, node := range []string{"node1", "node2"} {
+		s.Equal(5, script.Count(node, `^scp \S+ /root/3fs/mgmtd/config.d/\S+\.m3fs-staged$`))
+		s.Equal(1, script.Count(node, `^scp \S+ /root/3fs/mgmtd/config.d/mgmtd_main_app.toml.m3fs-staged$`))
+		s.Equal(5, script.Count(node, `^bash -c .*mv -f `))
+	}
 }
 
-func (s *prepare3FSConfigStepSuite) TestPrepareConfig() {
-	s.testPrepareConfig(nil)
+func (s *prepare3FSConfigStepSuite) TestDistributeConfigSkipCordonedNode() {
+	script := externaltest.NewScript()
+	s.Runtime.CordonedNodes = []string{"node2"}
+	s.NoError(s.distribute(script))
+
+	s.Equal(5, script.Count("node1", `^bash -c .*mv -f `))
+	s.Empty(script.Invocations("node2", ""))
+}
+
+func (s *prepare3FSConfigStepSuite) TestDistributeConfigVerifyFailed() {
+	script := externaltest.NewScript()
+	err := s.distribute(script, "node2")
+
+	distErr, ok := errors.Cause(err).(*task.DistributeError)
+	s.True(ok)
+	s.Equal(task.DistributePhaseVerify, distErr.Phase)
+	s.Equal("node2", distErr.Node)
+	for _, node := range []string{"node1", "node2"} {
+		s.Zero(script.Count(node, `^bash -c .*mv -f `))
+		s.Equal(1, script.Count(node, `^rm -f \S+\.m3fs-staged`))
+	}
+}
+
+func (s *prepare3FSConfigStepSuite) TestRender3FSConfigs() {
+	files, err := Render3FSConfigs(s.Runtime, s.setup, s.node)
+	s.NoError(err)
+
+	mainAppConfig, mainLauncherConfig, mainConfig, adminCli := s.getGeneratedConfigContent()
+	s.Equal([]*task.RenderedFile{
+		{Path: "/root/3fs/mgmtd/config.d/mgmtd_main_app.toml", Data: []byte(mainAppConfig)},
+		{Path: "/root/3fs/mgmtd/config.d/mgmtd_main_launcher.toml", Data: []byte(mainLauncherConfig)},
+		{Path: "/root/3fs/mgmtd/config.d/mgmtd_main.toml", Data: []byte(mainConfig)},
+		{Path: "/root/3fs/mgmtd/config.d/admin_cli.toml", Data: []byte(adminCli)},
+		{Path: "/root/3fs/mgmtd/config.d/fdb.cluster", Data: []byte(s.fdbContent)},
+	}, files)
+	s.MockLocalFS.AssertNotCalled(s.T(), "WriteFile")
+	s.MockRunner.AssertNotCalled(s.T(), "Scp")
+}
+
+func (s *prepare3FSConfigStepSuite) TestRender3FSConfigsWithExtraConfig() {
+	s.setup.MainTomlTmpl = []byte(`[server]
+listen_port = {{ .TCPListenPort }}
+num_threads = 8
+`)
+	s.setup.ExtraConfig = config.ExtraConfig{
+		"server.num_threads":         {Value: 16},
+		"server.listen_port":         {Value: 9100},
+		"server.io_worker.wait_time": {Value: "100ms"},
+	}
+
+	files, err := Render3FSConfigs(s.Runtime, s.setup, s.node)
+	s.NoError(err)
+	s.Equal(`[server]
+listen_port = 9000
+num_threads = 16
+
+[server.io_worker]
+wait_time = '100ms'
+`, string(files[2].Data))
+
+	s.setup.ExtraConfig["server.listen_port"] = config.ExtraConfigValue{Value: 9100, Override: true}
+	files, err = Render3FSConfigs(s.Runtime, s.setup, s.node)
+	s.NoError(err)
+	s.Contains(string(files[2].Data), "listen_port = 9100\n")
 }
 
-func (s *prepare3FSConfigStepSuite) TestPrepareConfigWithRemoveTempDirFailed() {
-	s.testPrepareConfig(errors.New("remove temp dir failed"))
+func (s *prepare3FSConfigStepSuite) TestRender3FSConfigsWithInvalidExtraConfig() {
+	s.setup.MainTomlTmpl = []byte(`[server]
+num_threads = 8
+`)
+	s.setup.ExtraConfig = config.ExtraConfig{"server": {Value: 1}}
+
+	_, err := Render3FSConfigs(s.Runtime, s.setup, s.node)
+	s.ErrorContains(err, "merge extraConfig into mgmtd_main.toml: set server: server is a table")
 }
 
 func TestRun3FSContainerStepSuite(t *testing.T) {
@@ -200,7 +287,9 @@ func (s *run3FSContainerStepSuite) SetupTest() {
 			ImgName:       config.ImageName3FS,
 			ContainerName: s.Runtime.Services.Mgmtd.ContainerName,
 			Service:       "mgmtd_main",
+			ServiceType:   config.ServiceMgmtd,
 			WorkDir:       "/root/3fs/mgmtd",
+			ListenPort:    9000,
 		})().(*run3FSContainerStep)
 	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
 	s.Runtime.Store(task.RuntimeFdbClusterFileContentKey, "xxxx")
@@ -247,6 +336,10 @@ func (s *run3FSContainerStepSuite) testRunContainer(
 		args.Volumes = append(args.Volumes, s.step.GetRdmaVolumes()...)
 	}
 	s.MockDocker.On("Run", args).Return("", nil)
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Mgmtd.ContainerName, "{{.State.Status}}").
+		Return("running\n", nil)
+	s.MockRunner.On("Exec", "ss", []string{"-Hltn", "sport = :9000"}).
+		Return("LISTEN 0 4096 0.0.0.0:9000 0.0.0.0:*", nil)
 
 	s.NoError(s.step.Execute(s.Ctx()))
 
@@ -270,6 +363,107 @@ func (s *run3FSContainerStepSuite) TestRunContainerWithoutRdmaNetwork() {
 	s.testRunContainer(false, config.NetworkTypeRDMA)
 }
 
+func (s *run3FSContainerStepSuite) TestRunContainerNotReady() {
+	s.Cfg.Services.Mgmtd.ReadinessTimeout = config.Duration(50 * time.Millisecond)
+	s.Cfg.Services.Mgmtd.ReadinessInterval = config.Duration(10 * time.Millisecond)
+	s.MockDocker.On("Run", mock.Anything).Return("", nil)
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Mgmtd.ContainerName, "{{.State.Status}}").
+		Return("running", nil)
+	s.MockRunner.On("Exec", "ss", []string{"-Hltn", "sport = :9000"}).Return("", nil)
+
+	err := s.step.Execute(s.Ctx())
+	s.Error(err)
+	s.Contains(err.Error(), "mgmtd is not ready after waiting")
+	s.Contains(err.Error(), "last probe output: mgmtd_main doesn't listen on port 9000")
+}
+
+func (s *run3FSContainerStepSuite) TestRunContainerExited() {
+	s.Cfg.Services.Mgmtd.ReadinessTimeout = config.Duration(50 * time.Millisecond)
+	s.Cfg.Services.Mgmtd.ReadinessInterval = config.Duration(10 * time.Millisecond)
+	s.MockDocker.On("Run", mock.Anything).Return("", nil)
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Mgmtd.ContainerName, "{{.State.Status}}").
+		Return("exited", nil)
+
+	err := s.step.Execute(s.Ctx())
+	s.Error(err)
+	s.Contains(err.Error(), "last probe output: container 3fs-mgmtd is exited")
+	s.MockRunner.AssertNotCalled(s.T(), "Exec", "ss", mock.Anything)
+}
+
+func TestUpgrade3FSContainerStepSuite(t *testing.T) {
+	suiteRun(t, &upgrade3FSContainerStepSuite{})
+}
+
+type upgrade3FSContainerStepSuite struct {
+	ttask.StepSuite
+
+	afterRm *afterRmStep
+}
+
+type afterRmStep struct {
+	task.BaseStep
+	executed bool
+	err      error
+}
+
+func (s *afterRmStep) Execute(context.Context) error {
+	s.executed = true
+	return s.err
+}
+
+func (s *upgrade3FSContainerStepSuite) SetupTest() {
+	s.StepSuite.SetupTest()
+
+	s.SetupRuntime()
+	s.afterRm = new(afterRmStep)
+}
+
+func (s *upgrade3FSContainerStepSuite) newStep() task.Step {
+	step := NewUpgrade3FSContainerStepFunc(
+		&Run3FSContainerStepSetup{
+			ImgName:       config.ImageName3FS,
+			ContainerName: s.Runtime.Services.Mgmtd.ContainerName,
+			Service:       "mgmtd_main",
+			ServiceType:   config.ServiceMgmtd,
+			WorkDir:       "/root/3fs/mgmtd",
+		}, func() task.Step { return s.afterRm })()
+	step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
+	return step
+}
+
+func (s *upgrade3FSContainerStepSuite) TestUpgradeContainer() {
+	s.Cfg.Images.FFFS.Tag = "20250501"
+	s.Cfg.Services.Mgmtd.ShutdownSignal = "SIGINT"
+	// the container exits gracefully by the shutdown signal
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Mgmtd.ContainerName, "{{.State.Status}}").
+		Return("running", nil).Once()
+	s.MockDocker.On("Kill", s.Cfg.Services.Mgmtd.ContainerName, "SIGINT").Return("", nil)
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Mgmtd.ContainerName, "{{.State.Status}}").
+		Return("exited", nil).Once()
+	s.MockDocker.On("Rm", s.Cfg.Services.Mgmtd.ContainerName, true).Return("", nil)
+	s.MockDocker.On("Run", mock.MatchedBy(func(args *external.RunArgs) bool {
+		return args.Image == "open3fs/3fs:20250501"
+	})).Return("", nil)
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Mgmtd.ContainerName, "{{.State.Status}}").
+		Return("running", nil).Once()
+
+	s.NoError(s.newStep().Execute(s.Ctx()))
+
+	s.True(s.afterRm.executed)
+	s.MockDocker.AssertExpectations(s.T())
+}
+
+func (s *upgrade3FSContainerStepSuite) TestAfterRmFailed() {
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Mgmtd.ContainerName, "{{.State.Status}}").
+		Return("exited", nil)
+	s.afterRm.err = errors.New("dummy error")
+	s.MockDocker.On("Rm", s.Cfg.Services.Mgmtd.ContainerName, true).Return("", nil)
+
+	s.Error(s.newStep().Execute(s.Ctx()), "dummy error")
+
+	s.MockDocker.AssertNotCalled(s.T(), "Run", mock.Anything)
+}
+
 func TestRm3FSContainerStepSuite(t *testing.T) {
 	suiteRun(t, &rm3FSContainerStepSuite{})
 }
@@ -287,8 +481,10 @@ func (s *rm3FSContainerStepSuite) SetupTest() {
 	s.configDir = "/root/3fs/mgmtd/config.d"
 	s.SetupRuntime()
 	s.step = NewRm3FSContainerStepFunc(s.Cfg.Services.Mgmtd.ContainerName,
-		"mgmtd_main", "/root/3fs/mgmtd")().(*rm3FSContainerStep)
+		"mgmtd_main", config.ServiceMgmtd, "/root/3fs/mgmtd")().(*rm3FSContainerStep)
 	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
+	s.MockDocker.On("InspectContainer", s.Cfg.Services.Mgmtd.ContainerName, "{{.State.Status}}").
+		Return("exited", nil)
 }
 
 func (s *rm3FSContainerStepSuite) TestRmContainerStep() {
@@ -344,7 +540,7 @@ func (s *upload3FSMainConfigStepSuite) SetupTest() {
 	s.Runtime.Store(task.RuntimeMgmtdServerAddressesKey, `["RDMA://1.1.1.1:8000"]`)
 }
 
-func (s *upload3FSMainConfigStepSuite) TestUploadConfig() {
+func (s *upload3FSMainConfigStepSuite) testUploadConfig(addr string) {
 	img, err := s.Runtime.Cfg.Images.GetImage(config.ImageName3FS)
 	s.NoError(err)
 	args := &external.RunArgs{
@@ -361,7 +557,7 @@ func (s *upload3FSMainConfigStepSuite) TestUploadConfig() {
 			"/opt/3fs/bin/admin_cli",
 			"-cfg", "/opt/3fs/etc/admin_cli.toml",
 			"--config.mgmtd_client.mgmtd_server_addresses",
-			`'["RDMA://1.1.1.1:8000"]'`,
+			addr,
 			"'set-config --type META --file /opt/3fs/etc/meta_main.toml'",
 		},
 		Volumes: []*external.VolumeArgs{
@@ -387,6 +583,25 @@ func (s *upload3FSMainConfigStepSuite) TestUploadConfig() {
 	s.MockDocker.AssertExpectations(s.T())
 }
 
+func (s *upload3FSMainConfigStepSuite) TestUploadConfig() {
+	s.testUploadConfig(`'["RDMA://1.1.1.1:8000"]'`)
+}
+
+func (s *upload3FSMainConfigStepSuite) TestUploadConfigToLeader() {
+	s.Cfg.Nodes = []config.Node{
+		{Name: "node1", Host: "1.1.1.1"},
+		{Name: "node2", Host: "1.1.1.2"},
+	}
+	s.Cfg.Services.Mgmtd.Nodes = []string{"node1", "node2"}
+	s.Cfg.Services.Mgmtd.RDMAListenPort = 8000
+	s.SetupRuntime()
+	s.step.Init(s.Runtime, s.MockEm, config.Node{}, s.Logger)
+	s.Runtime.MgmtdProtocol = "RDMA"
+	s.Runtime.Store(task.RuntimeMgmtdLeaderKey, "node2")
+
+	s.testUploadConfig(`'["RDMA://1.1.1.2:8000"]'`)
+}
+
 func TestRemoteRunScriptStepSuite(t *testing.T) {
 	suiteRun(t, &remoteRunScriptStepSuite{})
 }
@@ -0,0 +1,86 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steps
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/task"
+	"github.com/open3fs/m3fs/pkg/utils"
+)
+
+// mergeExtraConfig merges the extra config into the config file rendered from the
+// template, keys are set in sorted order. Keys whose values are rendered from template
+// data are managed by m3fs, they're set only if the override flag is set. It returns
+// keys of the extra config which are skipped.
+func mergeExtraConfig(rendered, tmpl []byte, extra config.ExtraConfig) ([]byte, []string, error) {
+	if len(extra) == 0 {
+		return rendered, nil, nil
+	}
+	doc, err := parseTOML(string(rendered), true)
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "parse rendered config")
+	}
+	managed, err := managedConfigKeys(tmpl)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	var skipped []string
+	for _, key := range slices.Sorted(maps.Keys(extra)) {
+		value := extra[key]
+		if managed.Contains(key) && !value.Override {
+			skipped = append(skipped, key)
+			continue
+		}
+		tomlValue, err := task.FormatTOMLValue(value.Value)
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "format value of %s", key)
+		}
+		if err = doc.set(key, tomlValue); err != nil {
+			return nil, nil, errors.Annotatef(err, "set %s", key)
+		}
+	}
+
+	merged := doc.String()
+	if _, err = parseTOML(merged, true); err != nil {
+		return nil, nil, errors.Annotate(err, "parse merged config")
+	}
+	return []byte(merged), skipped, nil
+}
+
+// managedConfigKeys returns keys of tables whose values are rendered from template data.
+func managedConfigKeys(tmpl []byte) (*utils.Set[string], error) {
+	doc, err := parseTOML(string(tmpl), false)
+	if err != nil {
+		return nil, errors.Annotate(err, "parse template")
+	}
+	managed := utils.NewSet[string]()
+	for _, section := range doc.sections {
+		if section.array {
+			continue
+		}
+		for key, span := range section.keys {
+			if strings.Contains(strings.Join(section.lines[span[0]:span[1]], "\n"), "{{") {
+				managed.Add(section.keyPath(key))
+			}
+		}
+	}
+	return managed, nil
+}
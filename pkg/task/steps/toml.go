@@ -0,0 +1,341 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steps
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tomlDoc is a line editor of TOML documents used to merge extraConfig into 3fs config
+// files, keys are set without reformatting the rest of the document. It isn't a TOML
+// parser: it only finds table headers and key/value lines, values aren't validated since
+// values set by extraConfig are encoded by task.FormatTOMLValue. It only handles the
+// syntax used by 3fs config files:
+//   - bare keys and dotted bare keys, quoted keys aren't supported.
+//   - single line values and arrays spanning lines, multi-line strings aren't supported.
+//   - tables and arrays of tables, keys in arrays of tables can't be set.
+type tomlDoc struct {
+	sections []*tomlSection
+	// tables are paths of all tables, including implicitly defined super tables,
+	// mapped to whether they're arrays of tables.
+	tables map[string]bool
+}
+
+type tomlSection struct {
+	// path is empty for the root table before any header.
+	path string
+	// array is set for arrays of tables and tables in them.
+	array bool
+	// lines include the header line.
+	lines []string
+	// keys maps keys to [begin, end) of their lines, arrays span lines.
+	keys map[string][2]int
+}
+
+func (s *tomlSection) keyPath(key string) string {
+	if s.path == "" {
+		return key
+	}
+	return s.path + "." + key
+}
+
+var tomlKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+(\s*\.\s*[A-Za-z0-9_-]+)*$`)
+
+// parseTOML splits the document into sections. If strict isn't set, lines which aren't
+// table headers or key/value pairs are kept as is, so that templates of documents can be
+// parsed.
+func parseTOML(data string, strict bool) (*tomlDoc, error) {
+	doc := &tomlDoc{tables: make(map[string]bool)}
+	cur := &tomlSection{keys: make(map[string][2]int)}
+	doc.sections = append(doc.sections, cur)
+	explicit := make(map[string]bool)
+
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		lineNo := i + 1
+		content := strings.TrimSpace(stripTOMLComment(line))
+		switch {
+		case content == "":
+			cur.lines = append(cur.lines, line)
+		case strings.HasPrefix(content, "["):
+			array := strings.HasPrefix(content, "[[")
+			opening, closing := "[", "]"
+			if array {
+				opening, closing = "[[", "]]"
+			}
+			if !strings.HasSuffix(content, closing) || len(content) < len(opening)+len(closing) {
+				return nil, fmt.Errorf("line %d: invalid table header %s", lineNo, content)
+			}
+			path, err := parseTOMLKey(content[len(opening) : len(content)-len(closing)])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			if isArray, ok := doc.tables[path]; ok && isArray != array {
+				return nil, fmt.Errorf("line %d: %s is already defined as a different kind of table", lineNo, path)
+			}
+			parts := strings.Split(path, ".")
+			inArray := array
+			for j := 1; j < len(parts); j++ {
+				super := strings.Join(parts[:j], ".")
+				if isArray, ok := doc.tables[super]; !ok {
+					doc.tables[super] = false
+				} else if isArray {
+					inArray = true
+				}
+			}
+			// tables in arrays of tables are defined once in each element
+			if !inArray {
+				if explicit[path] {
+					return nil, fmt.Errorf("line %d: table %s is defined twice", lineNo, path)
+				}
+				explicit[path] = true
+			}
+			doc.tables[path] = array
+			cur = &tomlSection{path: path, array: inArray, lines: []string{line}, keys: make(map[string][2]int)}
+			doc.sections = append(doc.sections, cur)
+		case indexTOMLOutsideQuotes(content, '=') < 0:
+			if strict {
+				return nil, fmt.Errorf("line %d: expected key = value: %s", lineNo, content)
+			}
+			cur.lines = append(cur.lines, line)
+		default:
+			eq := indexTOMLOutsideQuotes(content, '=')
+			key, err := parseTOMLKey(content[:eq])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			value := strings.TrimSpace(content[eq+1:])
+			if strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''") {
+				return nil, fmt.Errorf("line %d: multi-line string of %s is not supported", lineNo, key)
+			}
+			if strict && value == "" {
+				return nil, fmt.Errorf("line %d: value of %s is missing", lineNo, key)
+			}
+			// arrays spanning lines continue until brackets are balanced
+			end := i + 1
+			for !tomlBalanced(value) && end < len(lines) {
+				value += "\n" + strings.TrimSpace(stripTOMLComment(lines[end]))
+				end++
+			}
+			if _, ok := cur.keys[key]; ok {
+				if strict {
+					return nil, fmt.Errorf("line %d: key %s is defined twice", lineNo, cur.keyPath(key))
+				}
+				cur.lines = append(cur.lines, lines[i:end]...)
+				i = end - 1
+				continue
+			}
+			begin := len(cur.lines)
+			cur.lines = append(cur.lines, lines[i:end]...)
+			cur.keys[key] = [2]int{begin, len(cur.lines)}
+			i = end - 1
+		}
+	}
+	return doc, nil
+}
+
+// String returns the document.
+func (d *tomlDoc) String() string {
+	var lines []string
+	for _, section := range d.sections {
+		lines = append(lines, section.lines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// section returns the table section of the path.
+func (d *tomlDoc) section(path string) *tomlSection {
+	for _, section := range d.sections {
+		if section.path == path && !section.array {
+			return section
+		}
+	}
+	return nil
+}
+
+// keyPaths returns full paths of keys of tables, keys of arrays of tables are excluded.
+func (d *tomlDoc) keyPaths() []string {
+	var paths []string
+	for _, section := range d.sections {
+		if section.array {
+			continue
+		}
+		for key := range section.keys {
+			paths = append(paths, section.keyPath(key))
+		}
+	}
+	return paths
+}
+
+// set sets the key of the dotted path to the TOML value, the table of the key is
+// appended to the document if it doesn't exist.
+func (d *tomlDoc) set(path, value string) error {
+	if _, ok := d.tables[path]; ok {
+		return fmt.Errorf("%s is a table", path)
+	}
+	parts := strings.Split(path, ".")
+	tablePath := strings.Join(parts[:len(parts)-1], ".")
+	key := parts[len(parts)-1]
+	for j := 1; j < len(parts); j++ {
+		super := strings.Join(parts[:j], ".")
+		if d.tables[super] {
+			return fmt.Errorf("%s is an array of tables", super)
+		}
+		if d.hasKey(super) {
+			return fmt.Errorf("%s is not a table", super)
+		}
+	}
+
+	line := fmt.Sprintf("%s = %s", key, value)
+	section := d.section(tablePath)
+	if section == nil {
+		section = &tomlSection{path: tablePath, keys: make(map[string][2]int)}
+		// the new table is separated by a blank line and keeps the trailing newline
+		last := d.sections[len(d.sections)-1]
+		trailingNewline := len(last.lines) > 1 && last.lines[len(last.lines)-1] == ""
+		if trailingNewline {
+			last.lines = last.lines[:len(last.lines)-1]
+		}
+		if n := len(last.lines); n > 0 && strings.TrimSpace(last.lines[n-1]) != "" {
+			section.lines = append(section.lines, "")
+		}
+		section.lines = append(section.lines, fmt.Sprintf("[%s]", tablePath))
+		if trailingNewline {
+			section.lines = append(section.lines, "")
+		}
+		d.sections = append(d.sections, section)
+		for j := 1; j < len(parts); j++ {
+			super := strings.Join(parts[:j], ".")
+			if _, ok := d.tables[super]; !ok {
+				d.tables[super] = false
+			}
+		}
+	}
+	if span, ok := section.keys[key]; ok {
+		section.replaceLines(span, key, line)
+		return nil
+	}
+	// keys are appended after the last non-blank line of the section
+	pos := len(section.lines)
+	for pos > 0 && strings.TrimSpace(section.lines[pos-1]) == "" {
+		pos--
+	}
+	section.insertLine(pos, key, line)
+	return nil
+}
+
+func (d *tomlDoc) hasKey(path string) bool {
+	for _, section := range d.sections {
+		if section.array {
+			continue
+		}
+		for key := range section.keys {
+			if section.keyPath(key) == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *tomlSection) replaceLines(span [2]int, key, line string) {
+	removed := span[1] - span[0] - 1
+	s.lines = append(s.lines[:span[0]+1], s.lines[span[1]:]...)
+	s.lines[span[0]] = line
+	for key, other := range s.keys {
+		if other[0] > span[0] {
+			s.keys[key] = [2]int{other[0] - removed, other[1] - removed}
+		}
+	}
+	s.keys[key] = [2]int{span[0], span[0] + 1}
+}
+
+func (s *tomlSection) insertLine(pos int, key, line string) {
+	s.lines = append(s.lines[:pos], append([]string{line}, s.lines[pos:]...)...)
+	for other, span := range s.keys {
+		if span[0] >= pos {
+			s.keys[other] = [2]int{span[0] + 1, span[1] + 1}
+		}
+	}
+	s.keys[key] = [2]int{pos, pos + 1}
+}
+
+// stripTOMLComment removes the comment of the line.
+func stripTOMLComment(line string) string {
+	if i := indexTOMLOutsideQuotes(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// indexTOMLOutsideQuotes returns the index of the first c outside strings.
+func indexTOMLOutsideQuotes(s string, c byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == 0 && s[i] == c:
+			return i
+		case quote == 0 && (s[i] == '"' || s[i] == '\''):
+			quote = s[i]
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		}
+	}
+	return -1
+}
+
+// tomlBalanced returns whether brackets of the value are balanced outside strings.
+func tomlBalanced(value string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// parseTOMLKey parses the dotted bare key and returns it without spaces.
+func parseTOMLKey(s string) (string, error) {
+	key := strings.TrimSpace(s)
+	if strings.ContainsAny(key, `"'`) {
+		return "", fmt.Errorf("quoted key %s is not supported", key)
+	}
+	if !tomlKeyRegex.MatchString(key) {
+		return "", fmt.Errorf("invalid key %s", key)
+	}
+	parts := strings.Split(key, ".")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.Join(parts, "."), nil
+}
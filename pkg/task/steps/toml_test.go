@@ -0,0 +1,159 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestTOMLSuite(t *testing.T) {
+	suiteRun(t, new(tomlSuite))
+}
+
+type tomlSuite struct {
+	suite.Suite
+}
+
+const testTOMLDoc = `allow_dev_version = true # comment
+
+[[common.log.categories]]
+categories = [ '.' ]
+level = 'INFO'
+
+[[common.log.categories]]
+categories = [ 'eventlog' ]
+level = 'INFO'
+
+[server]
+use_memkv = false
+addresses = [
+  "RDMA://1.1.1.1:8000",
+  "RDMA://1.1.1.2:8000",
+]
+
+[server.io_worker]
+num_event_loop = 1
+timeout = '1s'
+`
+
+func (s *tomlSuite) TestParse() {
+	doc, err := parseTOML(testTOMLDoc, true)
+	s.NoError(err)
+	s.Equal(testTOMLDoc, doc.String())
+	s.ElementsMatch([]string{
+		"allow_dev_version", "server.use_memkv", "server.addresses",
+		"server.io_worker.num_event_loop", "server.io_worker.timeout",
+	}, doc.keyPaths())
+}
+
+func (s *tomlSuite) TestParseTablesInArrays() {
+	doc, err := parseTOML(`[[server.groups]]
+name = 'a'
+
+[server.groups.listener]
+port = 8000
+
+[[server.groups]]
+name = 'b'
+
+[server.groups.listener]
+port = 9000
+`, true)
+	s.NoError(err)
+	s.Empty(doc.keyPaths())
+	s.EqualError(doc.set("server.groups.listener.port", "1"), "server.groups is an array of tables")
+}
+
+func (s *tomlSuite) TestParseInvalid() {
+	for doc, msg := range map[string]string{
+		"a = ":                  "line 1: value of a is missing",
+		"a = 1\na = 2":          "line 2: key a is defined twice",
+		"[a]\n[a]":              "line 2: table a is defined twice",
+		"[[a]]\n[a]":            "line 2: a is already defined as a different kind of table",
+		"a":                     "line 1: expected key = value: a",
+		"[a b]":                 "line 1: invalid key a b",
+		"[a":                    "line 1: invalid table header [a",
+		"[[a]":                  "line 1: invalid table header [[a]",
+		`"a.b" = 1`:             `line 1: quoted key "a.b" is not supported`,
+		"a = \"\"\"\nb\n\"\"\"": "line 1: multi-line string of a is not supported",
+		"a = '''b'''":           "line 1: multi-line string of a is not supported",
+	} {
+		_, err := parseTOML(doc, true)
+		s.EqualError(err, msg, doc)
+	}
+}
+
+func (s *tomlSuite) TestParseDottedKeys() {
+	doc, err := parseTOML(`[server . io_worker]
+queue . size = 1`, true)
+	s.NoError(err)
+	s.Equal([]string{"server.io_worker.queue.size"}, doc.keyPaths())
+}
+
+func (s *tomlSuite) TestParseTemplate() {
+	doc, err := parseTOML(`{{- if .Enabled }}
+[server]
+node_id = {{ .NodeID }}
+{{- end }}`, false)
+	s.NoError(err)
+	s.Equal([]string{"server.node_id"}, doc.keyPaths())
+}
+
+func (s *tomlSuite) TestSet() {
+	doc, err := parseTOML(testTOMLDoc, true)
+	s.NoError(err)
+
+	s.NoError(doc.set("server.addresses", "[ 'RDMA://1.1.1.3:8000' ]"))
+	s.NoError(doc.set("server.io_worker.rdma_connect_timeout", "'5s'"))
+	s.NoError(doc.set("server.io_worker.num_event_loop", "2"))
+	s.NoError(doc.set("server.background_client.default_timeout", "'2s'"))
+	s.NoError(doc.set("log_level", "'DEBUG'"))
+	s.Equal(`allow_dev_version = true # comment
+log_level = 'DEBUG'
+
+[[common.log.categories]]
+categories = [ '.' ]
+level = 'INFO'
+
+[[common.log.categories]]
+categories = [ 'eventlog' ]
+level = 'INFO'
+
+[server]
+use_memkv = false
+addresses = [ 'RDMA://1.1.1.3:8000' ]
+
+[server.io_worker]
+num_event_loop = 2
+timeout = '1s'
+rdma_connect_timeout = '5s'
+
+[server.background_client]
+default_timeout = '2s'
+`, doc.String())
+	_, err = parseTOML(doc.String(), true)
+	s.NoError(err)
+}
+
+func (s *tomlSuite) TestSetInvalid() {
+	doc, err := parseTOML(testTOMLDoc, true)
+	s.NoError(err)
+
+	s.EqualError(doc.set("server.io_worker", "1"), "server.io_worker is a table")
+	s.EqualError(doc.set("common.log.categories.level", "'ERR'"), "common.log.categories is an array of tables")
+	s.EqualError(doc.set("server.use_memkv.enabled", "true"), "server.use_memkv is not a table")
+}
@@ -18,7 +18,9 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -40,10 +42,14 @@ type Interface interface {
 
 // BaseTask is a base struct that all tasks should embed.
 type BaseTask struct {
-	name    string
-	Runtime *Runtime
-	steps   []StepConfig
-	Logger  log.Interface
+	name     string
+	service  config.ServiceType
+	phase    Phase
+	deps     []string
+	floating bool
+	Runtime  *Runtime
+	steps    []StepConfig
+	Logger   log.Interface
 }
 
 // Init initializes the task with the external manager and the configuration.
@@ -72,25 +78,29 @@ func (t *BaseTask) Name() string {
 	return t.name
 }
 
-func (t *BaseTask) newStepExecuter(newStepFunc func() Step, retryTime int) func(context.Context, config.Node) error {
+func (t *BaseTask) newStepExecuter(stepCfg StepConfig,
+	newLogger func(node string) log.Interface) func(context.Context, config.Node) error {
+
 	return func(ctx context.Context, node config.Node) error {
-		step := newStepFunc()
-		logger := t.Logger.Subscribe(log.FieldKeyNode, node.Name)
+		// starts and ends of steps are activities watched by the watchdog
+		external.TouchActivity(ctx)
+		defer external.TouchActivity(ctx)
+		t.Runtime.reportNode(t.Name(), node.Name, NodeStatusRunning)
+		step := stepCfg.NewStep()
+		logger := newLogger(node.Name)
 
-		var em *external.Manager
-		var err error
-		if t.Runtime.LocalNode != nil && node.Name == t.Runtime.LocalNode.Name {
-			em = t.Runtime.LocalEm
-		} else {
-			em, err = external.NewRemoteRunnerManager(&node, logger)
-			if err != nil {
-				return errors.Trace(err)
-			}
+		em, err := t.Runtime.nodeManager(node, stepCfg.ConnectTimeout, logger)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = t.Runtime.setupContainerRuntime(em, node); err != nil {
+			return errors.Trace(err)
 		}
 		step.Init(t.Runtime, em, node, logger)
-		for i := 0; i <= retryTime; i++ {
+		for i := 0; i <= stepCfg.RetryTime; i++ {
+			t.Runtime.recordOperation()
 			err = step.Execute(ctx)
-			if err != nil && i != retryTime {
+			if err != nil && i != stepCfg.RetryTime && t.Runtime.allowRetry() {
 				logger.Warnf("Step failed, retrying: %v", err)
 				time.Sleep(time.Second)
 				continue
@@ -101,42 +111,217 @@ func (t *BaseTask) newStepExecuter(newStepFunc func() Step, retryTime int) func(
 	}
 }
 
+// SelectNodes returns nodes of the names in order, only the selected ones are returned
+// if any node is selected, e.g. nodes of a service which an upgrade changes.
+func (r *Runtime) SelectNodes(names, selected []string) []config.Node {
+	nodes := make([]config.Node, 0, len(names))
+	for _, name := range names {
+		if len(selected) > 0 && !slices.Contains(selected, name) {
+			continue
+		}
+		nodes = append(nodes, r.Nodes[name])
+	}
+	return nodes
+}
+
+// FilterNodes returns nodes selected by the node filter of the runtime, excluding cordoned nodes.
+func (r *Runtime) FilterNodes(nodes []config.Node) []config.Node {
+	if r.NodeFilter == nil && len(r.CordonedNodes) == 0 {
+		return nodes
+	}
+	return slices.DeleteFunc(slices.Clone(nodes), func(node config.Node) bool {
+		if slices.Contains(r.CordonedNodes, node.Name) {
+			return true
+		}
+		return r.NodeFilter != nil && !r.NodeFilter(node)
+	})
+}
+
+func (t *BaseTask) filterNodes(nodes []config.Node) []config.Node {
+	return t.Runtime.FilterNodes(nodes)
+}
+
+// selectsNoNode returns whether no step of the task runs on any node. Tasks without
+// steps are supposed to run on their own.
+func (t *BaseTask) selectsNoNode() bool {
+	for _, step := range t.steps {
+		if len(t.filterNodes(step.Nodes)) > 0 {
+			return false
+		}
+	}
+	return len(t.steps) > 0
+}
+
 // ExecuteSteps executes all the steps of the task.
 func (t *BaseTask) ExecuteSteps(ctx context.Context) error {
 	for _, stepCfg := range t.steps {
-		executor := t.newStepExecuter(stepCfg.NewStep, stepCfg.RetryTime)
-		if stepCfg.Parallel && len(stepCfg.Nodes) > 1 {
-			workerPool := common.NewWorkerPool(executor, len(stepCfg.Nodes))
-			workerPool.Start(ctx)
-			for _, node := range stepCfg.Nodes {
-				workerPool.Add(node)
+		nodes := t.filterNodes(stepCfg.Nodes)
+		if stepCfg.OrderNodes != nil {
+			nodes = stepCfg.OrderNodes(t.Runtime, slices.Clone(nodes))
+		}
+		var err error
+		if batches := t.rolloutBatches(stepCfg, len(nodes)); batches != nil {
+			err = t.rolloutStep(ctx, stepCfg, nodes, batches)
+		} else {
+			err = t.executeStep(ctx, stepCfg, nodes)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// rolloutBatches returns sizes of batches running the rollout step on n nodes by the
+// deployment strategy of the config, it's nil if the step doesn't follow a strategy.
+func (t *BaseTask) rolloutBatches(stepCfg StepConfig, n int) []int {
+	if !stepCfg.Rollout || t.Runtime.Cfg == nil || t.Runtime.Cfg.Deployment.Strategy == "" {
+		return nil
+	}
+	return t.Runtime.Cfg.Deployment.BatchSizes(n)
+}
+
+// rolloutStep runs the step on nodes batch by batch, nodes of a batch run in parallel.
+// The step waits the service ready on each node, so a batch which isn't healthy fails
+// the step before later batches start.
+func (t *BaseTask) rolloutStep(ctx context.Context, stepCfg StepConfig, nodes []config.Node, batches []int) error {
+	strategy := t.Runtime.Cfg.Deployment.Strategy
+	stepCfg.Parallel = true
+	stepCfg.MaxParallel = 0
+	for i, size := range batches {
+		batch := nodes[:size]
+		nodes = nodes[size:]
+		if len(batches) > 1 {
+			t.Logger.Infof("Deploying batch %d/%d on %d node(s) by %s strategy", i+1, len(batches), size, strategy)
+		}
+		if err := t.executeStep(ctx, stepCfg, batch); err != nil {
+			if len(batches) > 1 {
+				return errors.Annotatef(err, "batch %d/%d of %s strategy", i+1, len(batches), strategy)
+			}
+			return errors.Trace(err)
+		}
+		if i < len(batches)-1 && t.Runtime.Cfg.Deployment.Gated() {
+			t.Logger.Infof("Batch %d/%d is healthy, continue with the next batch", i+1, len(batches))
+		}
+	}
+	return nil
+}
+
+// maxParallel returns the max number of nodes running the step in parallel, 0 means no
+// limit. The limit of the phase of the task by the config applies unless the step limits
+// itself to fewer nodes.
+func (t *BaseTask) maxParallel(stepCfg StepConfig) int {
+	limit, _ := t.parallelLimit(stepCfg)
+	return limit
+}
+
+// parallelLimit returns the max number of nodes running the step in parallel, and whether
+// it's the limit of auto parallelism, which applies to phases without limits.
+func (t *BaseTask) parallelLimit(stepCfg StepConfig) (int, bool) {
+	limit := stepCfg.MaxParallel
+	if t.Runtime.Cfg == nil {
+		return limit, false
+	}
+	phaseLimit := t.Runtime.Cfg.Deployment.PhaseMaxParallel(string(t.taskPhase()))
+	auto := false
+	if phaseLimit == 0 {
+		phaseLimit = t.Runtime.getAutoParallel()
+		auto = phaseLimit > 0
+	}
+	if phaseLimit > 0 && (limit == 0 || phaseLimit < limit) {
+		return phaseLimit, auto
+	}
+	return limit, false
+}
+
+// executeStep runs the step on nodes, in parallel if the step is parallel. If quarantine
+// is enabled, unreachable nodes of a parallel step are quarantined while other nodes
+// continue, while a sequential step waits for its unreachable node in quarantine. The
+// step completes after quarantined nodes recover.
+func (t *BaseTask) executeStep(ctx context.Context, stepCfg StepConfig, nodes []config.Node) error {
+	newLogger := func(node string) log.Interface {
+		return t.Logger.Subscribe(log.FieldKeyNode, node)
+	}
+	if stepCfg.Parallel && len(nodes) > 1 && !t.Runtime.PerNodeLogs {
+		newLogger = log.NewAggregator(t.Logger, len(nodes)).Logger
+	}
+	stepExecutor := t.newStepExecuter(stepCfg, newLogger)
+	for _, node := range nodes {
+		t.Runtime.reportNode(t.Name(), node.Name, NodeStatusPending)
+	}
+	var (
+		quarantinedMu sync.Mutex
+		quarantined   []*quarantinedNode
+	)
+	executor := func(ctx context.Context, node config.Node) error {
+		err := stepExecutor(ctx, node)
+		if qn := t.quarantine(node, err); qn != nil {
+			quarantinedMu.Lock()
+			quarantined = append(quarantined, qn)
+			quarantinedMu.Unlock()
+			return nil
+		}
+		t.Runtime.recordNodeResult(t.Name(), node.Name, err)
+		return err
+	}
+	if stepCfg.Parallel && len(nodes) > 1 {
+		size := len(nodes)
+		limit, auto := t.parallelLimit(stepCfg)
+		if limit > 0 && limit < size {
+			size = limit
+		}
+		if autoCfg := t.Runtime.Cfg; auto && autoCfg.Deployment.AutoParallel.Backoff && size > 1 {
+			gate := newParallelGate(size, autoCfg.Deployment.AutoParallel)
+			watchCtx, stopWatch := context.WithCancel(ctx)
+			defer stopWatch()
+			go gate.watch(watchCtx)
+			gatedExecutor := executor
+			executor = func(ctx context.Context, node config.Node) error {
+				if err := gate.acquire(ctx); err != nil {
+					return err
+				}
+				defer gate.release()
+				return gatedExecutor(ctx, node)
 			}
-			workerPool.Join()
-			errs := workerPool.Errors()
-			if len(errs) > 0 {
-				if logrus.StandardLogger().Level == logrus.DebugLevel {
-					errorsTrace := make([]string, len(errs))
-					for _, err := range errs {
-						errorsTrace = append(errorsTrace, errors.StackTrace(err))
-					}
-					logrus.Debugf("Run step failed, output: %s", strings.Join(errorsTrace, "\n"))
+		}
+		workerPool := common.NewWorkerPool(executor, size)
+		workerPool.Start(ctx)
+		for _, node := range nodes {
+			workerPool.Add(node)
+		}
+		workerPool.Join()
+		errs := workerPool.Errors()
+		if len(errs) > 0 {
+			if logrus.StandardLogger().Level == logrus.DebugLevel {
+				errorsTrace := make([]string, len(errs))
+				for _, err := range errs {
+					errorsTrace = append(errorsTrace, errors.StackTrace(err))
 				}
-				return errors.Trace(errs[0])
+				logrus.Debugf("Run step failed, output: %s", strings.Join(errorsTrace, "\n"))
 			}
-		} else {
-			for _, node := range stepCfg.Nodes {
-				var err error
-				for i := 0; i <= stepCfg.RetryTime; i++ {
-					if err = executor(ctx, node); err != nil && i != stepCfg.RetryTime {
-						t.Logger.Warnf("Step failed, retrying: %v", err)
-						time.Sleep(time.Second)
-						continue
-					}
-					break
+			return errors.Trace(errs[0])
+		}
+		return errors.Trace(t.retryQuarantined(ctx, stepExecutor, quarantined))
+	} else {
+		for _, node := range nodes {
+			var err error
+			for i := 0; i <= stepCfg.RetryTime; i++ {
+				if err = stepExecutor(ctx, node); err != nil && i != stepCfg.RetryTime && t.Runtime.allowRetry() {
+					t.Logger.Warnf("Step failed, retrying: %v", err)
+					time.Sleep(time.Second)
+					continue
 				}
-				if err != nil {
+				break
+			}
+			if qn := t.quarantine(node, err); qn != nil {
+				if err = t.retryQuarantined(ctx, stepExecutor, []*quarantinedNode{qn}); err != nil {
 					return errors.Trace(err)
 				}
+				continue
+			}
+			t.Runtime.recordNodeResult(t.Name(), node.Name, err)
+			if err != nil {
+				return errors.Trace(err)
 			}
 		}
 	}
@@ -156,6 +341,17 @@ type StepConfig struct {
 	Parallel  bool
 	RetryTime int
 	NewStep   func() Step
+
+	// MaxParallel limits the number of nodes running the step in parallel, 0 means no limit.
+	MaxParallel int
+	// OrderNodes reorders nodes right before running the step, it's optional.
+	OrderNodes func(*Runtime, []config.Node) []config.Node
+	// ConnectTimeout overrides the connect timeout of the config for nodes of the step
+	// if it's shorter, e.g. preflight checks flag unreachable nodes quickly.
+	ConnectTimeout time.Duration
+	// Rollout marks the step deploying a service on nodes, it runs in batches by the
+	// deployment strategy of the config if the strategy is set, otherwise by Parallel.
+	Rollout bool
 }
 
 // BaseStep is a base struct that all steps should embed.
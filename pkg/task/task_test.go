@@ -0,0 +1,421 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/external/externaltest"
+	"github.com/open3fs/m3fs/pkg/log"
+	texternal "github.com/open3fs/m3fs/tests/external"
+)
+
+func TestTaskSuite(t *testing.T) {
+	suiteRun(t, new(taskSuite))
+}
+
+type taskSuite struct {
+	baseSuite
+	runtime *Runtime
+	nodes   []config.Node
+
+	mu       sync.Mutex
+	executed []string
+}
+
+type recordNodeStep struct {
+	BaseStep
+	s *taskSuite
+}
+
+func (st *recordNodeStep) Execute(context.Context) error {
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	st.s.executed = append(st.s.executed, st.Node.Name)
+	return nil
+}
+
+type execStep struct {
+	BaseStep
+}
+
+func (st *execStep) Execute(ctx context.Context) error {
+	_, err := st.Em.Runner.Exec(ctx, "systemctl", "restart", "3fs")
+	return err
+}
+
+func (s *taskSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.nodes = []config.Node{{Name: "node1"}, {Name: "node2"}}
+	s.executed = nil
+	s.runtime = &Runtime{
+		Cfg:       &config.Config{Name: "test", Nodes: s.nodes, ContainerRuntime: config.ContainerRuntimeDocker},
+		LocalNode: &s.nodes[0],
+		LocalEm:   external.NewManager(new(texternal.MockRunner), log.Logger),
+	}
+}
+
+func (s *taskSuite) newTask(parallel bool) *BaseTask {
+	t := new(BaseTask)
+	t.SetName("testTask")
+	t.Init(s.runtime, log.Logger)
+	t.SetSteps([]StepConfig{
+		{
+			Nodes:    s.nodes,
+			Parallel: parallel,
+			NewStep:  func() Step { return &recordNodeStep{s: s} },
+		},
+	})
+	return t
+}
+
+func (s *taskSuite) TestNodeFilter() {
+	s.runtime.NodeFilter = func(node config.Node) bool { return node.Name == "node1" }
+
+	s.NoError(s.newTask(false).Run(s.Ctx()))
+	s.NoError(s.newTask(true).Run(s.Ctx()))
+
+	s.Equal([]string{"node1", "node1"}, s.executed)
+	s.Equal([]config.Node{{Name: "node1"}, {Name: "node2"}}, s.nodes)
+}
+
+func (s *taskSuite) TestNodeFilterRejectsAll() {
+	s.runtime.NodeFilter = func(config.Node) bool { return false }
+
+	s.NoError(s.newTask(true).Run(s.Ctx()))
+
+	s.Empty(s.executed)
+}
+
+func (s *taskSuite) TestCordonedNodes() {
+	s.runtime.CordonedNodes = []string{"node2"}
+
+	s.NoError(s.newTask(false).Run(s.Ctx()))
+
+	s.Equal([]string{"node1"}, s.executed)
+
+	s.executed = nil
+	s.runtime.NodeFilter = func(node config.Node) bool { return node.Name == "node2" }
+
+	s.NoError(s.newTask(true).Run(s.Ctx()))
+
+	s.Empty(s.executed)
+}
+
+func (s *taskSuite) TestScriptedNodeManagers() {
+	script := externaltest.NewScript()
+	script.OnNode("node2", `^systemctl restart`, externaltest.Response{ExitCode: 1, Times: 1})
+	s.runtime.LocalEm = script.Manager("node1", log.Logger)
+	s.runtime.NewNodeManager = script.NodeManager
+	t := new(BaseTask)
+	t.SetName("testTask")
+	t.Init(s.runtime, log.Logger)
+	t.SetSteps([]StepConfig{
+		{
+			Nodes:     s.nodes,
+			Parallel:  true,
+			RetryTime: 1,
+			NewStep:   func() Step { return new(execStep) },
+		},
+	})
+
+	s.NoError(t.Run(s.Ctx()))
+
+	s.Equal(1, script.Count("node1", `^systemctl restart 3fs$`))
+	s.Equal(2, script.Count("node2", `^systemctl restart 3fs$`))
+}
+
+func (s *taskSuite) TestMetadata() {
+	t := s.newTask(true)
+	t.SetService(config.ServiceStorage)
+	t.SetDeps("CreateMgmtdServiceTask")
+	s.Equal(Metadata{
+		Name:    "testTask",
+		Service: config.ServiceStorage,
+		Phase:   PhaseDeploy,
+		Scope:   ScopeNode,
+		Deps:    []string{"CreateMgmtdServiceTask"},
+		Steps:   1,
+	}, MetadataOf(t))
+
+	t.SetSteps([]StepConfig{{Nodes: s.nodes[:1]}, {Nodes: s.nodes[1:]}})
+	s.Equal(ScopeCluster, MetadataOf(t).Scope)
+	s.Equal(2, MetadataOf(t).Steps)
+}
+
+type run3FSContainerStep struct {
+	BaseStep
+}
+
+func (s *taskSuite) TestStepPlans() {
+	t := s.newTask(true)
+	t.SetSteps(append(t.steps, StepConfig{
+		Nodes:   s.nodes,
+		NewStep: func() Step { return new(run3FSContainerStep) },
+	}))
+
+	s.Equal([]StepPlan{
+		{Name: "record node", Nodes: []string{"node1", "node2"}, Parallel: true},
+		{Name: "run 3FS container", Nodes: []string{"node1", "node2"}},
+	}, StepPlansOf(t))
+
+	// nodes not selected by the node filter are excluded
+	s.runtime.NodeFilter = func(node config.Node) bool { return node.Name == "node2" }
+	s.Equal([]StepPlan{
+		{Name: "record node", Nodes: []string{"node2"}},
+		{Name: "run 3FS container", Nodes: []string{"node2"}},
+	}, StepPlansOf(t))
+}
+
+// concurrency records the peak number of nodes running a step at the same time.
+type concurrency struct {
+	mu            sync.Mutex
+	running, peak int
+}
+
+type concurrentStep struct {
+	BaseStep
+	c *concurrency
+}
+
+func (st *concurrentStep) Execute(context.Context) error {
+	st.c.mu.Lock()
+	st.c.running++
+	st.c.peak = max(st.c.peak, st.c.running)
+	st.c.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	st.c.mu.Lock()
+	st.c.running--
+	st.c.mu.Unlock()
+	return nil
+}
+
+func (s *taskSuite) TestPhaseMaxParallel() {
+	s.nodes = append(s.nodes, config.Node{Name: "node3"}, config.Node{Name: "node4"})
+	s.runtime.NewNodeManager = externaltest.NewScript().NodeManager
+	one, two := 1, 2
+	s.runtime.Cfg.Deployment = config.Deployment{
+		MaxParallel: &one,
+		Phases:      map[string]config.PhaseDeployment{string(PhaseDeploy): {MaxParallel: &two}},
+	}
+	c := new(concurrency)
+	t := new(BaseTask)
+	t.SetName("testTask")
+	t.SetService(config.ServiceStorage)
+	t.Init(s.runtime, log.Logger)
+	t.SetSteps([]StepConfig{{
+		Nodes:    s.nodes,
+		Parallel: true,
+		NewStep:  func() Step { return &concurrentStep{c: c} },
+	}})
+
+	// the limit of the deploy phase overrides the default
+	s.Equal(2, t.maxParallel(t.steps[0]))
+	s.NoError(t.Run(s.Ctx()))
+	s.Equal(2, c.peak)
+
+	// the step limiting itself to fewer nodes isn't overridden
+	t.steps[0].MaxParallel = 1
+	s.Equal(1, t.maxParallel(t.steps[0]))
+	s.False(StepPlansOf(t)[0].Parallel)
+
+	// tasks of the prepare phase are limited by the default
+	t.SetService("")
+	t.steps[0].MaxParallel = 0
+	s.Equal(1, t.maxParallel(t.steps[0]))
+}
+
+type failNodeStep struct {
+	recordNodeStep
+	failNode string
+}
+
+func (st *failNodeStep) Execute(ctx context.Context) error {
+	if err := st.recordNodeStep.Execute(ctx); err != nil {
+		return err
+	}
+	if st.Node.Name == st.failNode {
+		return errors.Errorf("%s isn't ready", st.Node.Name)
+	}
+	return nil
+}
+
+func (s *taskSuite) TestRolloutByDeploymentStrategy() {
+	s.nodes = append(s.nodes, config.Node{Name: "node3"})
+	s.runtime.Cfg.Deployment = config.Deployment{Strategy: config.DeploymentStrategyRolling}
+	s.runtime.NewNodeManager = externaltest.NewScript().NodeManager
+	t := new(BaseTask)
+	t.SetName("testTask")
+	t.Init(s.runtime, log.Logger)
+	t.SetSteps([]StepConfig{
+		{
+			Nodes:    s.nodes,
+			Parallel: true,
+			Rollout:  true,
+			NewStep:  func() Step { return &failNodeStep{recordNodeStep: recordNodeStep{s: s}, failNode: "node2"} },
+		},
+	})
+
+	s.Equal([]StepPlan{
+		{Name: "fail node", Nodes: []string{"node1", "node2", "node3"}, Batches: []int{1, 1, 1}},
+	}, StepPlansOf(t))
+	err := t.Run(s.Ctx())
+	s.ErrorContains(err, "batch 2/3 of rolling strategy: node2 isn't ready")
+	// the unhealthy batch stops the rollout
+	s.Equal([]string{"node1", "node2"}, s.executed)
+
+	s.executed = nil
+	s.runtime.Cfg.Deployment = config.Deployment{Strategy: config.DeploymentStrategyAllAtOnce}
+	s.Equal([]StepPlan{
+		{Name: "fail node", Nodes: []string{"node1", "node2", "node3"}, Parallel: true, Batches: []int{3}},
+	}, StepPlansOf(t))
+	s.Error(t.Run(s.Ctx()), "node2 isn't ready")
+	s.ElementsMatch([]string{"node1", "node2", "node3"}, s.executed)
+}
+
+type unreachableNodeStep struct {
+	recordNodeStep
+	unreachableNode string
+	// unreachableTimes is the number of executions failing on the unreachable node.
+	unreachableTimes *int
+}
+
+func (st *unreachableNodeStep) Execute(ctx context.Context) error {
+	if err := st.recordNodeStep.Execute(ctx); err != nil {
+		return err
+	}
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	if st.Node.Name != st.unreachableNode || *st.unreachableTimes == 0 {
+		return nil
+	}
+	*st.unreachableTimes--
+	return errors.Trace(&external.UnreachableError{
+		Host: st.Node.Name,
+		Err:  errors.Errorf("dial tcp %s:22: connection refused", st.Node.Name),
+	})
+}
+
+func (s *taskSuite) newUnreachableTask(parallel bool, unreachableTimes int) *BaseTask {
+	s.runtime.NewNodeManager = externaltest.NewScript().NodeManager
+	t := new(BaseTask)
+	t.SetName("testTask")
+	t.Init(s.runtime, log.Logger)
+	t.SetSteps([]StepConfig{
+		{
+			Nodes:    s.nodes,
+			Parallel: parallel,
+			NewStep: func() Step {
+				return &unreachableNodeStep{
+					recordNodeStep:   recordNodeStep{s: s},
+					unreachableNode:  "node1",
+					unreachableTimes: &unreachableTimes,
+				}
+			},
+		},
+	})
+	return t
+}
+
+func (s *taskSuite) TestQuarantineRecovered() {
+	s.runtime.Cfg.Quarantine = config.Quarantine{
+		Timeout:       config.Duration(time.Second),
+		RetryInterval: config.Duration(time.Millisecond),
+	}
+
+	s.NoError(s.newUnreachableTask(true, 2).Run(s.Ctx()))
+	// node2 isn't blocked by the unreachable node1, which is retried after the step
+	s.ElementsMatch([]string{"node1", "node2"}, s.executed[:2])
+	s.Equal([]string{"node1", "node1"}, s.executed[2:])
+
+	s.executed = nil
+	s.NoError(s.newUnreachableTask(false, 1).Run(s.Ctx()))
+	s.Equal([]string{"node1", "node1", "node2"}, s.executed)
+
+	records := s.runtime.Quarantines()
+	s.Len(records, 2)
+	for _, record := range records {
+		s.Equal("node1", record.Node)
+		s.Equal("testTask", record.Task)
+		s.NotNil(record.RecoveredAt)
+		s.Contains(record.String(), "node1 in task testTask: recovered after ")
+	}
+}
+
+func (s *taskSuite) TestQuarantineTimeout() {
+	s.runtime.Cfg.Quarantine = config.Quarantine{
+		Timeout:       config.Duration(10 * time.Millisecond),
+		RetryInterval: config.Duration(time.Millisecond),
+	}
+
+	err := s.newUnreachableTask(true, 1000).Run(s.Ctx())
+	s.ErrorContains(err, "node node1 is still unreachable after quarantine of 10ms")
+	s.True(external.IsUnreachable(err))
+
+	records := s.runtime.Quarantines()
+	s.Len(records, 1)
+	s.Nil(records[0].RecoveredAt)
+	s.Equal("node1 in task testTask: not recovered, dial tcp node1:22: connection refused", records[0].String())
+}
+
+func (s *taskSuite) TestQuarantineDisabled() {
+	err := s.newUnreachableTask(false, 1).Run(s.Ctx())
+	s.ErrorContains(err, "dial tcp node1:22: connection refused")
+	s.Equal([]string{"node1"}, s.executed)
+	s.Empty(s.runtime.Quarantines())
+}
+
+type recordProgress struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (p *recordProgress) TaskStarted(index, total int, task string) {}
+
+func (p *recordProgress) NodeChanged(task, node, status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, node+" "+status)
+}
+
+func (p *recordProgress) TaskEnded(task string, err error) {}
+
+func (s *taskSuite) TestReportProgress() {
+	progress := new(recordProgress)
+	s.runtime.Progress = progress
+	s.runtime.NewNodeManager = externaltest.NewScript().NodeManager
+	t := new(BaseTask)
+	t.SetName("testTask")
+	t.Init(s.runtime, log.Logger)
+	t.SetSteps([]StepConfig{
+		{
+			Nodes:   s.nodes,
+			NewStep: func() Step { return &failNodeStep{recordNodeStep: recordNodeStep{s: s}, failNode: "node2"} },
+		},
+	})
+
+	s.Error(t.Run(s.Ctx()), "node2 isn't ready")
+	s.Equal([]string{
+		"node1 pending", "node2 pending",
+		"node1 running", "node1 ok",
+		"node2 running", "node2 failed",
+	}, progress.events)
+}
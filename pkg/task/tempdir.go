@@ -0,0 +1,189 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+// TempDir is a temp dir created by a run, Node is empty for the local node.
+type TempDir struct {
+	Node string `json:"node,omitempty"`
+	Path string `json:"path"`
+}
+
+// TempDirsFilePath returns the path of the file recording temp dirs of the run.
+func TempDirsFilePath(runDir string) string {
+	return filepath.Join(runDir, tempDirsFileName)
+}
+
+// RegisterTempDir registers the temp dir on the node, use an empty node for the local
+// node. Registered temp dirs are removed when the run completes, they're recorded in
+// the run dir if the run is recorded, so that temp dirs left by an interrupted run can
+// be removed by `cluster clean`.
+func (r *Runtime) RegisterTempDir(node, dir string) {
+	r.tempDirsMu.Lock()
+	defer r.tempDirsMu.Unlock()
+	r.tempDirs = append(r.tempDirs, TempDir{Node: node, Path: dir})
+	if r.RunDir == "" {
+		return
+	}
+	if err := saveTempDirs(r.RunDir, r.tempDirs); err != nil {
+		log.Logger.Warnf("Failed to record temp dir %s: %v", dir, err)
+	}
+}
+
+// RemoveTempDirs removes temp dirs registered by the run, temp dirs failed to be
+// removed are kept in the record of the run.
+func (r *Runtime) RemoveTempDirs(ctx context.Context) error {
+	r.tempDirsMu.Lock()
+	defer r.tempDirsMu.Unlock()
+	failed := r.removeTempDirs(ctx, r.tempDirs)
+	r.tempDirs = failed
+	if r.RunDir != "" {
+		if err := saveTempDirs(r.RunDir, failed); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to remove %d temp dirs", len(failed))
+	}
+	return nil
+}
+
+// TempDirs returns temp dirs registered by the run.
+func (r *Runtime) TempDirs() []TempDir {
+	r.tempDirsMu.Lock()
+	defer r.tempDirsMu.Unlock()
+	return slices.Clone(r.tempDirs)
+}
+
+// CleanRunTempDirs removes temp dirs recorded in the run dir, which are left by an
+// interrupted or failed run. It returns removed temp dirs.
+func (r *Runtime) CleanRunTempDirs(ctx context.Context, runDir string) ([]TempDir, error) {
+	dirs, err := LoadTempDirs(runDir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	failed := r.removeTempDirs(ctx, dirs)
+	if err = saveTempDirs(runDir, failed); err != nil {
+		return nil, errors.Trace(err)
+	}
+	removed := slices.DeleteFunc(dirs, func(dir TempDir) bool { return slices.Contains(failed, dir) })
+	if len(failed) > 0 {
+		return removed, errors.Errorf("failed to remove %d temp dirs of %s", len(failed), runDir)
+	}
+	return removed, nil
+}
+
+func (r *Runtime) removeTempDirs(ctx context.Context, dirs []TempDir) []TempDir {
+	var failed []TempDir
+	for _, dir := range dirs {
+		if err := r.removeTempDir(ctx, dir); err != nil {
+			log.Logger.Warnf("Failed to remove temp dir %s of %s: %v", dir.Path, dir.nodeName(), err)
+			failed = append(failed, dir)
+			continue
+		}
+		log.Logger.Debugf("Removed temp dir %s of %s", dir.Path, dir.nodeName())
+	}
+	return failed
+}
+
+func (r *Runtime) removeTempDir(ctx context.Context, dir TempDir) error {
+	if dir.Node == "" {
+		return errors.Trace(r.LocalEm.FS.RemoveAll(ctx, dir.Path))
+	}
+	node, ok := r.Nodes[dir.Node]
+	if !ok {
+		return errors.Errorf("node %s not exists in node list", dir.Node)
+	}
+	em, err := r.NodeManager(node, log.Logger.Subscribe(log.FieldKeyNode, node.Name))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = em.Runner.Exec(ctx, "rm", "-rf", dir.Path)
+	return errors.Trace(err)
+}
+
+func (d TempDir) nodeName() string {
+	if d.Node == "" {
+		return "the local node"
+	}
+	return d.Node
+}
+
+// LoadTempDirs loads temp dirs recorded in the run dir.
+func LoadTempDirs(runDir string) ([]TempDir, error) {
+	data, err := os.ReadFile(TempDirsFilePath(runDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Annotate(err, "read temp dirs")
+	}
+	var dirs []TempDir
+	if err = json.Unmarshal(data, &dirs); err != nil {
+		return nil, errors.Annotatef(err, "parse %s", TempDirsFilePath(runDir))
+	}
+	return dirs, nil
+}
+
+func saveTempDirs(runDir string, dirs []TempDir) error {
+	path := TempDirsFilePath(runDir)
+	if len(dirs) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return errors.Annotatef(err, "create run directory %s", runDir)
+	}
+	data, err := json.MarshalIndent(dirs, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotate(WriteFileAtomic(path, data, 0644), "write temp dirs")
+}
+
+// RunsWithTempDirs returns ids of runs of the cluster which left temp dirs, except
+// the run of the id.
+func RunsWithTempDirs(workDir, clusterName, exceptRunID string) ([]string, error) {
+	runsDir := RunsDir(workDir, clusterName)
+	entries, err := os.ReadDir(runsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Annotatef(err, "read runs directory %s", runsDir)
+	}
+	var runIDs []string
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == exceptRunID {
+			continue
+		}
+		if _, err = os.Stat(TempDirsFilePath(filepath.Join(runsDir, entry.Name()))); err == nil {
+			runIDs = append(runIDs, entry.Name())
+		}
+	}
+	return runIDs, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+	"github.com/open3fs/m3fs/pkg/log"
+	texternal "github.com/open3fs/m3fs/tests/external"
+)
+
+func TestTempDirSuite(t *testing.T) {
+	suiteRun(t, new(tempDirSuite))
+}
+
+type tempDirSuite struct {
+	baseSuite
+	workDir    string
+	mockRunner *texternal.MockRunner
+	runtime    *Runtime
+}
+
+func (s *tempDirSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.workDir = s.T().TempDir()
+	s.mockRunner = new(texternal.MockRunner)
+	s.runtime = &Runtime{
+		Cfg:     &config.Config{Name: "test"},
+		Nodes:   map[string]config.Node{},
+		LocalEm: external.NewManager(s.mockRunner, log.Logger),
+		RunDir:  RunDir(s.workDir, "test", "run1"),
+	}
+}
+
+func (s *tempDirSuite) TestRegisterTempDir() {
+	s.runtime.RegisterTempDir("", "/tmp/3fs/a")
+	s.runtime.RegisterTempDir("node1", "/tmp/3fs/b")
+
+	dirs, err := LoadTempDirs(s.runtime.RunDir)
+	s.NoError(err)
+	expected := []TempDir{{Path: "/tmp/3fs/a"}, {Node: "node1", Path: "/tmp/3fs/b"}}
+	s.Equal(expected, dirs)
+	s.Equal(expected, s.runtime.TempDirs())
+}
+
+func (s *tempDirSuite) TestRemoveTempDirs() {
+	s.runtime.RegisterTempDir("", "/tmp/3fs/a")
+	s.mockRunner.On("Exec", "rm", []string{"-fr", "/tmp/3fs/a"}).Return("", nil)
+
+	s.NoError(s.runtime.RemoveTempDirs(s.Ctx()))
+
+	s.mockRunner.AssertExpectations(s.T())
+	s.Empty(s.runtime.TempDirs())
+	_, err := os.Stat(TempDirsFilePath(s.runtime.RunDir))
+	s.True(os.IsNotExist(err))
+}
+
+func (s *tempDirSuite) TestRemoveTempDirsFailed() {
+	s.runtime.RegisterTempDir("", "/tmp/3fs/a")
+	s.runtime.RegisterTempDir("", "/tmp/3fs/b")
+	s.mockRunner.On("Exec", "rm", []string{"-fr", "/tmp/3fs/a"}).Return("", errors.New("busy"))
+	s.mockRunner.On("Exec", "rm", []string{"-fr", "/tmp/3fs/b"}).Return("", nil)
+
+	s.Error(s.runtime.RemoveTempDirs(s.Ctx()))
+
+	dirs, err := LoadTempDirs(s.runtime.RunDir)
+	s.NoError(err)
+	s.Equal([]TempDir{{Path: "/tmp/3fs/a"}}, dirs)
+}
+
+func (s *tempDirSuite) TestCleanRunTempDirs() {
+	s.runtime.RegisterTempDir("", "/tmp/3fs/a")
+	s.runtime.RegisterTempDir("node1", "/tmp/3fs/b")
+	s.mockRunner.On("Exec", "rm", []string{"-fr", "/tmp/3fs/a"}).Return("", nil)
+
+	runtime := &Runtime{Nodes: map[string]config.Node{}, LocalEm: s.runtime.LocalEm}
+	removed, err := runtime.CleanRunTempDirs(s.Ctx(), s.runtime.RunDir)
+	s.Error(err)
+	s.Contains(err.Error(), "failed to remove 1 temp dirs")
+	s.Equal([]TempDir{{Path: "/tmp/3fs/a"}}, removed)
+
+	dirs, err := LoadTempDirs(s.runtime.RunDir)
+	s.NoError(err)
+	s.Equal([]TempDir{{Node: "node1", Path: "/tmp/3fs/b"}}, dirs)
+}
+
+func (s *tempDirSuite) TestRunsWithTempDirs() {
+	s.runtime.RegisterTempDir("", "/tmp/3fs/a")
+	other := &Runtime{RunDir: RunDir(s.workDir, "test", "run2")}
+	other.RegisterTempDir("", "/tmp/3fs/b")
+	s.NoError(os.MkdirAll(RunDir(s.workDir, "test", "run3"), 0755))
+
+	runIDs, err := RunsWithTempDirs(s.workDir, "test", "run2")
+	s.NoError(err)
+	s.Equal([]string{"run1"}, runIDs)
+
+	runIDs, err = RunsWithTempDirs(filepath.Join(s.workDir, "none"), "test", "")
+	s.NoError(err)
+	s.Empty(runIDs)
+}
@@ -0,0 +1,267 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/config"
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/utils"
+)
+
+var templateRegistry = struct {
+	sync.Mutex
+	templates map[string]*[]byte
+}{templates: make(map[string]*[]byte)}
+
+// RegisterTemplate registers the bundled template, so that it can be overridden by
+// the file of the same name in the templates dir.
+func RegisterTemplate(name string, tmpl *[]byte) {
+	templateRegistry.Lock()
+	defer templateRegistry.Unlock()
+	if _, ok := templateRegistry.templates[name]; ok {
+		panic("duplicate template " + name)
+	}
+	templateRegistry.templates[name] = tmpl
+}
+
+// TemplateNames returns sorted names of all bundled templates.
+func TemplateNames() []string {
+	templateRegistry.Lock()
+	defer templateRegistry.Unlock()
+	names := make([]string, 0, len(templateRegistry.templates))
+	for name := range templateRegistry.templates {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// OverrideTemplates overrides bundled templates with files of the same names in
+// the dir, templates without files are kept. An override must parse and must only
+// reference variables which the bundled template references. It returns sorted
+// names of overridden templates.
+func OverrideTemplates(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read templates dir %s", dir)
+	}
+
+	templateRegistry.Lock()
+	defer templateRegistry.Unlock()
+	overrides := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name := entry.Name()
+		tmpl, ok := templateRegistry.templates[name]
+		if !ok {
+			return nil, errors.Errorf("unknown template %s in %s", name, dir)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = validTemplateOverride(name, *tmpl, data); err != nil {
+			return nil, errors.Trace(err)
+		}
+		overrides[name] = data
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name, data := range overrides {
+		*templateRegistry.templates[name] = data
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func validTemplateOverride(name string, bundled, override []byte) error {
+	// functions are only looked up by names when templates are parsed
+	funcs := TemplateFuncs(nil)
+	overrideTmpl, err := template.New(name).Funcs(funcs).Parse(string(override))
+	if err != nil {
+		return errors.Annotatef(err, "parse template %s", name)
+	}
+	bundledTmpl, err := template.New(name).Funcs(funcs).Parse(string(bundled))
+	if err != nil {
+		return errors.Annotatef(err, "parse bundled template %s", name)
+	}
+	known := utils.NewSet[string]()
+	templateFields(bundledTmpl.Root, known)
+	used := utils.NewSet[string]()
+	templateFields(overrideTmpl.Root, used)
+	usedFields := used.ToSlice()
+	slices.Sort(usedFields)
+	for _, field := range usedFields {
+		if !known.Contains(field) {
+			return errors.Errorf("template %s references unknown variable .%s", name, field)
+		}
+	}
+	return nil
+}
+
+// NewTemplate returns a template of the name with functions of TemplateFuncs, all
+// bundled templates and their overrides are parsed by it.
+func (r *Runtime) NewTemplate(name string) *template.Template {
+	return template.New(name).Funcs(TemplateFuncs(r))
+}
+
+// TemplateFuncs returns functions available in templates, functions looking up
+// nodes and services of the cluster use the config of the runtime:
+//
+//   - join SEP LIST joins items of the list by the separator.
+//   - indent N TEXT indents every line of the text by n spaces.
+//   - toYaml VALUE encodes the value as YAML.
+//   - toToml VALUE encodes the map or struct as a TOML document, or other values as TOML values.
+//   - default DEFAULT VALUE returns the default if the value is empty.
+//   - lookupNode NAME returns the node of the name.
+//   - servicesOnNode NAME returns sorted names of services deployed on the node.
+//   - portFor SERVICE [rdma|tcp] returns the port the service listens on, the RDMA port of
+//     3fs services by default.
+//   - env NAME returns the environment variable on the deploy host.
+func TemplateFuncs(r *Runtime) template.FuncMap {
+	return template.FuncMap{
+		"join":    templateJoin,
+		"indent":  templateIndent,
+		"toYaml":  templateToYAML,
+		"toToml":  EncodeTOML,
+		"default": templateDefault,
+		"lookupNode": func(name string) (config.Node, error) {
+			for _, node := range r.Cfg.Nodes {
+				if node.Name == name {
+					return node, nil
+				}
+			}
+			return config.Node{}, errors.Errorf("node %s not found", name)
+		},
+		"servicesOnNode": func(name string) []string {
+			var services []string
+			for _, service := range config.AllServiceTypes {
+				if slices.Contains(r.Services.ServiceNodes(service), name) {
+					services = append(services, string(service))
+				}
+			}
+			slices.Sort(services)
+			return services
+		},
+		"portFor": func(service string, kind ...string) (int, error) {
+			ports := ServicePorts(r.Services, config.ServiceType(service))
+			if len(ports) == 0 {
+				return 0, errors.Errorf("service %s has no port", service)
+			}
+			switch {
+			case len(kind) == 0 || kind[0] == "rdma" && len(ports) > 1:
+				return ports[0], nil
+			case kind[0] == "tcp":
+				return ports[len(ports)-1], nil
+			}
+			return 0, errors.Errorf("service %s has no %s port", service, kind[0])
+		},
+		"env": os.Getenv,
+	}
+}
+
+func templateJoin(sep string, list any) (string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", errors.Errorf("join of %T which isn't a list", list)
+	}
+	items := make([]string, v.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(items, sep), nil
+}
+
+func templateIndent(spaces int, text string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(text, "\n", "\n"+pad)
+}
+
+func templateToYAML(value any) (string, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+func templateDefault(def, value any) any {
+	if value == nil {
+		return def
+	}
+	if v := reflect.ValueOf(value); v.IsZero() || (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+		return def
+	}
+	return value
+}
+
+// templateFields collects fields referenced by nodes of the template.
+func templateFields(node parse.Node, fields *utils.Set[string]) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			templateFields(child, fields)
+		}
+	case *parse.ActionNode:
+		templateFields(n.Pipe, fields)
+	case *parse.IfNode:
+		templateBranchFields(&n.BranchNode, fields)
+	case *parse.RangeNode:
+		templateBranchFields(&n.BranchNode, fields)
+	case *parse.WithNode:
+		templateBranchFields(&n.BranchNode, fields)
+	case *parse.TemplateNode:
+		templateFields(n.Pipe, fields)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			templateFields(cmd, fields)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			templateFields(arg, fields)
+		}
+	case *parse.ChainNode:
+		templateFields(n.Node, fields)
+	case *parse.FieldNode:
+		fields.Add(strings.Join(n.Ident, "."))
+	}
+}
+
+func templateBranchFields(n *parse.BranchNode, fields *utils.Set[string]) {
+	templateFields(n.Pipe, fields)
+	templateFields(n.List, fields)
+	templateFields(n.ElseList, fields)
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+func TestTemplateSuite(t *testing.T) {
+	suiteRun(t, new(templateSuite))
+}
+
+type templateSuite struct {
+	baseSuite
+	dir  string
+	tmpl []byte
+}
+
+func (s *templateSuite) SetupTest() {
+	s.baseSuite.SetupTest()
+	s.dir = s.T().TempDir()
+	s.tmpl = []byte("name = {{ .Name }}\n{{ if .Debug }}debug = true{{ end }}\n")
+	RegisterTemplate("test.tmpl", &s.tmpl)
+}
+
+func (s *templateSuite) TearDownTest() {
+	templateRegistry.Lock()
+	delete(templateRegistry.templates, "test.tmpl")
+	templateRegistry.Unlock()
+}
+
+func (s *templateSuite) writeTemplate(name, content string) {
+	s.NoError(os.WriteFile(filepath.Join(s.dir, name), []byte(content), 0644))
+}
+
+func (s *templateSuite) TestOverride() {
+	s.writeTemplate("test.tmpl", "# custom\nname = {{ .Name }}\n")
+
+	names, err := OverrideTemplates(s.dir)
+	s.NoError(err)
+	s.Equal([]string{"test.tmpl"}, names)
+	s.Equal("# custom\nname = {{ .Name }}\n", string(s.tmpl))
+}
+
+func (s *templateSuite) TestOverrideKeepsTemplatesWithoutFiles() {
+	names, err := OverrideTemplates(s.dir)
+	s.NoError(err)
+	s.Empty(names)
+	s.Contains(string(s.tmpl), "debug = true")
+}
+
+func (s *templateSuite) TestOverrideUnknownTemplate() {
+	s.writeTemplate("unknown.tmpl", "foo")
+
+	_, err := OverrideTemplates(s.dir)
+	s.ErrorContains(err, "unknown template unknown.tmpl")
+}
+
+func (s *templateSuite) TestOverrideUnknownVariable() {
+	s.writeTemplate("test.tmpl", "name = {{ .Name }}\nport = {{ .Port }}\n")
+
+	_, err := OverrideTemplates(s.dir)
+	s.ErrorContains(err, "template test.tmpl references unknown variable .Port")
+	s.Contains(string(s.tmpl), "debug = true")
+}
+
+func (s *templateSuite) TestOverrideInvalidTemplate() {
+	s.writeTemplate("test.tmpl", "name = {{ .Name ")
+
+	_, err := OverrideTemplates(s.dir)
+	s.ErrorContains(err, "parse template test.tmpl")
+}
+
+func (s *templateSuite) TestTemplateNames() {
+	s.Contains(TemplateNames(), "test.tmpl")
+}
+
+func (s *templateSuite) TestOverrideWithFuncs() {
+	s.writeTemplate("test.tmpl", "name = {{ .Name | default \"open3fs\" }}\n")
+
+	names, err := OverrideTemplates(s.dir)
+	s.NoError(err)
+	s.Equal([]string{"test.tmpl"}, names)
+}
+
+func (s *templateSuite) render(text string, data any) (string, error) {
+	cfg := &config.Config{
+		Name: "open3fs",
+		Nodes: []config.Node{
+			{Name: "node1", Host: "192.168.1.1"},
+			{Name: "node2", Host: "192.168.1.2"},
+		},
+		Services: config.Services{
+			Fdb:     config.Fdb{Nodes: []string{"node1"}, Port: 4500},
+			Mgmtd:   config.Mgmtd{Nodes: []string{"node1"}, RDMAListenPort: 8000, TCPListenPort: 9000},
+			Storage: config.Storage{Nodes: []string{"node1", "node2"}, RDMAListenPort: 8002, TCPListenPort: 9002},
+		},
+	}
+	r := &Runtime{Cfg: cfg, Services: &cfg.Services}
+	tmpl, err := r.NewTemplate("test").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	out := new(bytes.Buffer)
+	err = tmpl.Execute(out, data)
+	return out.String(), err
+}
+
+func (s *templateSuite) TestFuncs() {
+	s.T().Setenv("M3FS_TEMPLATE_TEST", "value")
+	cases := map[string]string{
+		`{{ join "," .List }}`:                              "a,b",
+		`{{ join ":" .Ports }}`:                             "1:2",
+		`{{ indent 2 "a\nb" }}`:                             "  a\n  b",
+		`{{ toYaml .Map }}`:                                 "key: value",
+		`{{ toToml .Map }}`:                                 "key = 'value'",
+		`{{ default "x" .Empty }}`:                          "x",
+		`{{ default "x" .List }}`:                           "[a b]",
+		`{{ default 1 .Zero }}`:                             "1",
+		`{{ (lookupNode "node2").Host }}`:                   "192.168.1.2",
+		`{{ join "," (servicesOnNode "node1") }}`:           "fdb,mgmtd,storage",
+		`{{ join "," (servicesOnNode "node2") }}`:           "storage",
+		`{{ portFor "fdb" }}`:                               "4500",
+		`{{ portFor "mgmtd" }} {{ portFor "mgmtd" "tcp" }}`: "8000 9000",
+		`{{ env "M3FS_TEMPLATE_TEST" }}`:                    "value",
+	}
+	data := map[string]any{
+		"List":  []string{"a", "b"},
+		"Ports": []int{1, 2},
+		"Map":   map[string]string{"key": "value"},
+		"Empty": "",
+		"Zero":  0,
+	}
+	for text, expected := range cases {
+		out, err := s.render(text, data)
+		s.NoError(err, text)
+		s.Equal(expected, out, text)
+	}
+
+	for text, msg := range map[string]string{
+		`{{ lookupNode "node3" }}`:   "node node3 not found",
+		`{{ portFor "client" }}`:     "service client has no port",
+		`{{ portFor "fdb" "rdma" }}`: "service fdb has no rdma port",
+		`{{ join "," "a" }}`:         "join of string which isn't a list",
+	} {
+		_, err := s.render(text, nil)
+		s.ErrorContains(err, msg, text)
+	}
+}
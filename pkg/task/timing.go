@@ -0,0 +1,140 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// TaskTiming records the timing of a task in a run.
+type TaskTiming struct {
+	// Index is the 1-based position of the task in the run.
+	Index     int
+	Name      string
+	StartTime time.Time
+	EndTime   time.Time
+	Completed bool
+}
+
+// Duration returns the duration of the task.
+func (t *TaskTiming) Duration() time.Duration {
+	return t.EndTime.Sub(t.StartTime)
+}
+
+// Timings returns timings of tasks which have been run.
+func (r *Runner) Timings() []*TaskTiming {
+	return r.timings
+}
+
+// SetTimingsOut sets the file which task timings are written into at the end of the run.
+// Timings are written in prometheus textfile format if the file name ends with .prom,
+// otherwise in CSV format.
+func (r *Runner) SetTimingsOut(path string) {
+	r.timingsOut = path
+}
+
+// FormatTimingsCSV formats task timings in CSV format.
+func FormatTimingsCSV(timings []*TaskTiming) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	records := [][]string{{"task", "name", "start", "end", "duration", "completed"}}
+	for _, t := range timings {
+		records = append(records, []string{
+			strconv.Itoa(t.Index),
+			t.Name,
+			t.StartTime.Format(time.RFC3339Nano),
+			t.EndTime.Format(time.RFC3339Nano),
+			strconv.FormatFloat(t.Duration().Seconds(), 'f', 3, 64),
+			strconv.FormatBool(t.Completed),
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, errors.Annotate(err, "write csv")
+	}
+	return buf.Bytes(), nil
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// FormatTimingsPrometheus formats task timings in the text format which node_exporter
+// textfile collector reads.
+func FormatTimingsPrometheus(cluster, command string, timings []*TaskTiming) []byte {
+	metrics := []struct {
+		name  string
+		help  string
+		value func(*TaskTiming) float64
+	}{
+		{
+			name: "m3fs_task_duration_seconds",
+			help: "Duration of the task in the last run of m3fs.",
+			value: func(t *TaskTiming) float64 {
+				return t.Duration().Seconds()
+			},
+		},
+		{
+			name: "m3fs_task_start_time_seconds",
+			help: "Start time of the task in the last run of m3fs, in unix seconds.",
+			value: func(t *TaskTiming) float64 {
+				return float64(t.StartTime.UnixMilli()) / 1000
+			},
+		},
+		{
+			name: "m3fs_task_completed",
+			help: "Whether the task completed in the last run of m3fs.",
+			value: func(t *TaskTiming) float64 {
+				if t.Completed {
+					return 1
+				}
+				return 0
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	for _, m := range metrics {
+		fmt.Fprintf(buf, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(buf, "# TYPE %s gauge\n", m.name)
+		for _, t := range timings {
+			fmt.Fprintf(buf, "%s{cluster=\"%s\",command=\"%s\",task=\"%s\",index=\"%d\"} %s\n",
+				m.name, promLabelEscaper.Replace(cluster), promLabelEscaper.Replace(command),
+				promLabelEscaper.Replace(t.Name), t.Index,
+				strconv.FormatFloat(m.value(t), 'f', -1, 64))
+		}
+	}
+	return buf.Bytes()
+}
+
+// writeTimings writes task timings into the timings out file. The file is written
+// atomically, so that collectors never read a partial file.
+func (r *Runner) writeTimings() error {
+	var data []byte
+	if strings.HasSuffix(r.timingsOut, ".prom") {
+		data = FormatTimingsPrometheus(r.cfg.Name, r.command, r.timings)
+	} else {
+		var err error
+		if data, err = FormatTimingsCSV(r.timings); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	return errors.Annotatef(WriteFileAtomic(r.timingsOut, data, 0644), "write %s", r.timingsOut)
+}
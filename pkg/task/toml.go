@@ -0,0 +1,195 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+)
+
+// FormatTOMLValue formats the value decoded from YAML as a TOML value.
+func FormatTOMLValue(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		if !strings.ContainsAny(value, "'\n\r\t") && !strings.ContainsFunc(value, unicode.IsControl) {
+			return "'" + value + "'", nil
+		}
+		return formatTOMLBasicString(value), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case uint64:
+		return strconv.FormatUint(value, 10), nil
+	case float64:
+		switch {
+		case math.IsNaN(value):
+			return "nan", nil
+		case math.IsInf(value, 1):
+			return "inf", nil
+		case math.IsInf(value, -1):
+			return "-inf", nil
+		}
+		s := strconv.FormatFloat(value, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s, nil
+	case []any:
+		if len(value) == 0 {
+			return "[]", nil
+		}
+		items := make([]string, len(value))
+		for i, item := range value {
+			var err error
+			if items[i], err = FormatTOMLValue(item); err != nil {
+				return "", errors.Trace(err)
+			}
+		}
+		return "[ " + strings.Join(items, ", ") + " ]", nil
+	}
+	return "", errors.Errorf("unsupported value %v of type %T", value, value)
+}
+
+func formatTOMLBasicString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// EncodeTOML encodes the value as a TOML document if it's a map or a struct, values
+// of other types are encoded as TOML values. The value is converted by YAML first, so
+// that fields of structs are named by their yaml tags. Null values of tables are left
+// out, since TOML has no null.
+func EncodeTOML(value any) (string, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var decoded any
+	if err = yaml.Unmarshal(data, &decoded); err != nil {
+		return "", errors.Trace(err)
+	}
+	table, ok := decoded.(map[string]any)
+	if !ok {
+		formatted, err := FormatTOMLValue(decoded)
+		return formatted, errors.Trace(err)
+	}
+	var b strings.Builder
+	if err = writeTOMLTable(&b, nil, table, false); err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// writeTOMLTable writes keys of the table under the header of the path, followed by
+// its sub tables and arrays of tables.
+func writeTOMLTable(b *strings.Builder, path []string, table map[string]any, arrayItem bool) error {
+	keys := slices.Sorted(maps.Keys(table))
+	if len(path) > 0 {
+		header := strings.Join(quoteTOMLKeys(path), ".")
+		if arrayItem {
+			fmt.Fprintf(b, "[[%s]]\n", header)
+		} else {
+			fmt.Fprintf(b, "[%s]\n", header)
+		}
+	}
+	var tables, arrays []string
+	for _, key := range keys {
+		switch value := table[key].(type) {
+		case nil:
+			continue
+		case map[string]any:
+			tables = append(tables, key)
+			continue
+		case []any:
+			if len(value) > 0 && !slices.ContainsFunc(value, func(item any) bool {
+				_, ok := item.(map[string]any)
+				return !ok
+			}) {
+				arrays = append(arrays, key)
+				continue
+			}
+		}
+		formatted, err := FormatTOMLValue(table[key])
+		if err != nil {
+			return errors.Annotatef(err, "format value of %s", strings.Join(append(path, key), "."))
+		}
+		fmt.Fprintf(b, "%s = %s\n", quoteTOMLKeys([]string{key})[0], formatted)
+	}
+	for _, key := range tables {
+		b.WriteString("\n")
+		if err := writeTOMLTable(b, append(slices.Clone(path), key), table[key].(map[string]any), false); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, key := range arrays {
+		for _, item := range table[key].([]any) {
+			b.WriteString("\n")
+			if err := writeTOMLTable(b, append(slices.Clone(path), key), item.(map[string]any), true); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+// quoteTOMLKeys quotes keys which aren't bare keys.
+func quoteTOMLKeys(keys []string) []string {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		bare := key != "" && !strings.ContainsFunc(key, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+		})
+		if bare {
+			quoted[i] = key
+		} else {
+			quoted[i] = formatTOMLBasicString(key)
+		}
+	}
+	return quoted
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"testing"
+)
+
+func TestTOMLSuite(t *testing.T) {
+	suiteRun(t, new(tomlSuite))
+}
+
+type tomlSuite struct {
+	baseSuite
+}
+
+func (s *tomlSuite) TestFormatValue() {
+	for value, expected := range map[any]string{
+		"1s":            "'1s'",
+		"it's":          `"it's"`,
+		"a\nb\"":        `"a\nb\""`,
+		true:            "true",
+		8:               "8",
+		1.5:             "1.5",
+		float64(2):      "2.0",
+		1e21:            "1e+21",
+		uint64(1 << 63): "9223372036854775808",
+	} {
+		formatted, err := FormatTOMLValue(value)
+		s.NoError(err)
+		s.Equal(expected, formatted)
+	}
+
+	formatted, err := FormatTOMLValue([]any{"a", 1, []any{}})
+	s.NoError(err)
+	s.Equal("[ 'a', 1, [] ]", formatted)
+
+	_, err = FormatTOMLValue(map[string]any{"a": 1})
+	s.Error(err)
+}
+
+func (s *tomlSuite) TestEncode() {
+	encoded, err := EncodeTOML(map[string]any{
+		"log_level": "INFO",
+		"unset":     nil,
+		"server": map[string]any{
+			"port":   8000,
+			"tags":   []string{"a", "b"},
+			"a.b":    true,
+			"target": []map[string]any{{"id": 1}, {"id": 2}},
+		},
+	})
+	s.NoError(err)
+	s.Equal(`log_level = 'INFO'
+
+[server]
+"a.b" = true
+port = 8000
+tags = [ 'a', 'b' ]
+
+[[server.target]]
+id = 1
+
+[[server.target]]
+id = 2`, encoded)
+
+	encoded, err = EncodeTOML(struct {
+		Name  string `yaml:"name"`
+		Ports []int  `yaml:"ports"`
+	}{Name: "node1", Ports: []int{22, 8000}})
+	s.NoError(err)
+	s.Equal("name = 'node1'\nports = [ 22, 8000 ]", encoded)
+
+	encoded, err = EncodeTOML([]string{"a"})
+	s.NoError(err)
+	s.Equal("[ 'a' ]", encoded)
+}
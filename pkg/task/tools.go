@@ -0,0 +1,81 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/log"
+)
+
+// OptionalTool is a tool a feature depends on which may not be installed. The feature is
+// skipped with a warning if the tool is missing, unless optional tools are required.
+type OptionalTool struct {
+	Name string
+	// Feature is what depends on the tool.
+	Feature string
+	// Install tells how to install the tool.
+	Install string
+}
+
+var (
+	// ToolSystemctl is used to mount the 3fs client again after reboot.
+	ToolSystemctl = OptionalTool{
+		Name:    "systemctl",
+		Feature: "mounting the 3fs client again after reboot",
+		Install: "run the client on a node managed by systemd",
+	}
+	// ToolFio is used in the storage container to benchmark data disks.
+	ToolFio = OptionalTool{
+		Name:    "fio",
+		Feature: "benchmarking data disks",
+		Install: "use a storage image with fio installed, e.g. by apt-get install fio",
+	}
+)
+
+// HostTools are optional tools looked up on nodes when gathering facts of them.
+var HostTools = []OptionalTool{ToolSystemctl}
+
+// ToolExec runs a command in which tools are looked up, e.g. on a node or in a container.
+type ToolExec func(ctx context.Context, cmd string, args ...string) (string, error)
+
+// LookupTools returns names of the tools found by the shell of exec, in the order of tools.
+func LookupTools(ctx context.Context, exec ToolExec, tools ...OptionalTool) []string {
+	found := []string{}
+	for _, tool := range tools {
+		if _, err := exec(ctx, "sh", "-c", fmt.Sprintf("'command -v %s'", tool.Name)); err == nil {
+			found = append(found, tool.Name)
+		}
+	}
+	return found
+}
+
+// CheckOptionalTool returns whether the tool is found by the shell of exec. If it's missing,
+// an error telling how to install it is returned if RequireOptionalTools is set, otherwise
+// a warning is logged that the feature is skipped.
+func (r *Runtime) CheckOptionalTool(
+	ctx context.Context, exec ToolExec, tool OptionalTool, logger log.Interface) (bool, error) {
+
+	if len(LookupTools(ctx, exec, tool)) > 0 {
+		return true, nil
+	}
+	if r.RequireOptionalTools {
+		return false, errors.Errorf("%s required by %s is not found, %s", tool.Name, tool.Feature, tool.Install)
+	}
+	logger.Warnf("%s is not found, skip %s. To enable it, %s", tool.Name, tool.Feature, tool.Install)
+	return false, nil
+}
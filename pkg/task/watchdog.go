@@ -0,0 +1,129 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/open3fs/m3fs/pkg/errors"
+	"github.com/open3fs/m3fs/pkg/external"
+)
+
+// watchdog warns if the running task has no activity, i.e. no step or command starts
+// or ends, for the interval. It never stops the task, which is the job of timeouts.
+type watchdog struct {
+	task     string
+	interval time.Duration
+	// dumpDir is the directory goroutine stacks are dumped into, they're not dumped if it's empty.
+	dumpDir  string
+	activity *external.Activity
+
+	// warnedLast is the last activity the task has been warned of, and warnings is
+	// the number of warnings since it.
+	warnedLast time.Time
+	warnings   int
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newWatchdog(task string, interval time.Duration, dumpDir string) *watchdog {
+	return &watchdog{
+		task:     task,
+		interval: interval,
+		dumpDir:  dumpDir,
+		activity: external.NewActivity(),
+	}
+}
+
+// start starts watching the task in background.
+func (w *watchdog) start() {
+	w.stopCh = make(chan struct{})
+	tick := min(max(w.interval/4, 10*time.Millisecond), 30*time.Second)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stopCh:
+				return
+			case now := <-ticker.C:
+				w.check(now)
+			}
+		}
+	}()
+}
+
+// stop stops watching the task.
+func (w *watchdog) stop() {
+	close(w.stopCh)
+	w.wg.Wait()
+}
+
+// check warns if the task has no activity for the interval, it warns again after
+// each interval without activity. It returns whether it warns.
+func (w *watchdog) check(now time.Time) bool {
+	last := w.activity.Last()
+	if !last.Equal(w.warnedLast) {
+		w.warnedLast = last
+		w.warnings = 0
+	}
+	idle := now.Sub(last)
+	if idle < w.interval*time.Duration(w.warnings+1) {
+		return false
+	}
+	w.warnings++
+
+	logrus.Warnf("Task %s appears stuck (no activity for %s)", w.task, idle.Round(time.Second))
+	for _, command := range w.activity.InFlight() {
+		logrus.Warnf("Command running on %s for %s: %s",
+			command.Host, now.Sub(command.Start).Round(time.Second), command.Command)
+	}
+	if w.dumpDir != "" {
+		path, err := w.dumpGoroutines(now)
+		if err != nil {
+			logrus.Warnf("Failed to dump goroutines: %v", err)
+		} else {
+			logrus.Warnf("Goroutines are dumped into %s", path)
+		}
+	}
+	return true
+}
+
+// dumpGoroutines writes stacks of all goroutines into a file of the dump dir.
+func (w *watchdog) dumpGoroutines(now time.Time) (string, error) {
+	if err := os.MkdirAll(w.dumpDir, 0755); err != nil {
+		return "", errors.Trace(err)
+	}
+	path := filepath.Join(w.dumpDir, fmt.Sprintf("goroutines-%s-%d.txt", w.task, now.Unix()))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if err = pprof.Lookup("goroutine").WriteTo(f, 2); err != nil {
+		_ = f.Close()
+		return "", errors.Trace(err)
+	}
+	return path, errors.Trace(f.Close())
+}
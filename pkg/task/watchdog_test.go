@@ -0,0 +1,79 @@
+// Copyright 2025 Open3FS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open3fs/m3fs/pkg/config"
+)
+
+func TestWatchdogSuite(t *testing.T) {
+	suiteRun(t, new(watchdogSuite))
+}
+
+type watchdogSuite struct {
+	baseSuite
+}
+
+func (s *watchdogSuite) TestCheck() {
+	w := newWatchdog("testTask", time.Minute, "")
+	last := w.activity.Last()
+
+	s.False(w.check(last.Add(30 * time.Second)))
+	s.True(w.check(last.Add(time.Minute)))
+	// it warns again after another interval without activity
+	s.False(w.check(last.Add(90 * time.Second)))
+	s.True(w.check(last.Add(2 * time.Minute)))
+
+	w.activity.Touch()
+	last = w.activity.Last()
+	s.False(w.check(last.Add(30 * time.Second)))
+	s.True(w.check(last.Add(time.Minute)))
+}
+
+func (s *watchdogSuite) TestDumpGoroutines() {
+	dir := s.T().TempDir()
+	w := newWatchdog("testTask", time.Minute, dir)
+
+	s.True(w.check(w.activity.Last().Add(time.Minute)))
+	paths, err := filepath.Glob(filepath.Join(dir, "goroutines-testTask-*.txt"))
+	s.NoError(err)
+	s.Len(paths, 1)
+	content, err := os.ReadFile(paths[0])
+	s.NoError(err)
+	s.Contains(string(content), "goroutine")
+}
+
+type sleepTask struct {
+	BaseTask
+}
+
+func (t *sleepTask) Run(context.Context) error {
+	time.Sleep(100 * time.Millisecond)
+	return nil
+}
+
+func (s *watchdogSuite) TestTaskNotStopped() {
+	r := &Runner{cfg: &config.Config{WatchdogInterval: config.Duration(10 * time.Millisecond)}}
+	t := new(sleepTask)
+	t.SetName("sleepTask")
+
+	s.NoError(r.runTask(context.Background(), t))
+}
@@ -15,7 +15,9 @@
 package utils
 
 import (
+	"bytes"
 	"net"
+	"strings"
 
 	"github.com/open3fs/m3fs/pkg/errors"
 )
@@ -37,11 +39,47 @@ func GetLocalIPs() ([]*net.IP, error) {
 	return ips, nil
 }
 
+// TrimHostBrackets removes brackets around an IPv6 literal, such as [2001:db8::1].
+func TrimHostBrackets(host string) string {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return host[1 : len(host)-1]
+	}
+	return host
+}
+
+// NormalizeHost removes brackets around an IPv6 literal and formats IP address in
+// its canonical form, so that the same address is always written in the same way.
+func NormalizeHost(host string) string {
+	host = TrimHostBrackets(host)
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+// CompareIP compares two IP addresses, unparsable addresses are compared as strings.
+func CompareIP(a, b string) int {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return strings.Compare(a, b)
+	}
+	return bytes.Compare(ipA.To16(), ipB.To16())
+}
+
 // IsLocalHost checks if the given host is a local host.
 func IsLocalHost(host string, localIPs []*net.IP) (bool, error) {
-	hostIPs, err := net.LookupIP(host)
-	if err != nil {
-		return false, errors.Annotatef(err, "lookup IP address of %s", host)
+	host = TrimHostBrackets(host)
+	var hostIPs []net.IP
+	// the zone of IPv6 link local address is ignored, because addresses of local
+	// interfaces don't contain it.
+	if ip := net.ParseIP(strings.SplitN(host, "%", 2)[0]); ip != nil {
+		hostIPs = []net.IP{ip}
+	} else {
+		var err error
+		hostIPs, err = net.LookupIP(host)
+		if err != nil {
+			return false, errors.Annotatef(err, "lookup IP address of %s", host)
+		}
 	}
 
 	for _, ip := range hostIPs {
@@ -77,7 +115,11 @@ func GenerateIPRange(ipStart, ipEnd string) ([]string, error) {
 		startInt = ipToInt(start)
 		endInt = ipToInt(end)
 	} else {
-		// ipv6
+		// ipv6, only the interface identifier is allowed to change in the range.
+		if !start.Mask(ipv6PrefixMask).Equal(end.Mask(ipv6PrefixMask)) {
+			return nil, errors.Errorf("start IP %s and end IP %s are not in the same /64 network",
+				ipStart, ipEnd)
+		}
 		startInt = ipv6ToInt(start)
 		endInt = ipv6ToInt(end)
 	}
@@ -91,7 +133,7 @@ func GenerateIPRange(ipStart, ipEnd string) ([]string, error) {
 		if ipv4 {
 			ip = intToIP(uint32(i))
 		} else {
-			ip = intToIPv6(i)
+			ip = intToIPv6(start, i)
 		}
 		ips = append(ips, ip.String())
 	}
@@ -104,10 +146,13 @@ func ipToInt(ip net.IP) uint64 {
 	return uint64(uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3]))
 }
 
+var ipv6PrefixMask = net.CIDRMask(64, 128)
+
+// ipv6ToInt returns the interface identifier, the lower 64 bits, of the IPv6 address.
 func ipv6ToInt(ip net.IP) uint64 {
 	ip = ip.To16()
-	return uint64(ip[0])<<56 | uint64(ip[1])<<48 | uint64(ip[2])<<40 | uint64(ip[3])<<32 |
-		uint64(ip[4])<<24 | uint64(ip[5])<<16 | uint64(ip[6])<<8 | uint64(ip[7])
+	return uint64(ip[8])<<56 | uint64(ip[9])<<48 | uint64(ip[10])<<40 | uint64(ip[11])<<32 |
+		uint64(ip[12])<<24 | uint64(ip[13])<<16 | uint64(ip[14])<<8 | uint64(ip[15])
 }
 
 func intToIP(n uint32) net.IP {
@@ -119,15 +164,17 @@ func intToIP(n uint32) net.IP {
 	return ip
 }
 
-func intToIPv6(n uint64) net.IP {
+// intToIPv6 returns the IPv6 address with the /64 prefix of the given address and the interface identifier.
+func intToIPv6(prefix net.IP, n uint64) net.IP {
 	ip := make(net.IP, 16)
-	ip[0] = byte(n >> 56)
-	ip[1] = byte(n >> 48)
-	ip[2] = byte(n >> 40)
-	ip[3] = byte(n >> 32)
-	ip[4] = byte(n >> 24)
-	ip[5] = byte(n >> 16)
-	ip[6] = byte(n >> 8)
-	ip[7] = byte(n)
+	copy(ip, prefix.To16()[:8])
+	ip[8] = byte(n >> 56)
+	ip[9] = byte(n >> 48)
+	ip[10] = byte(n >> 40)
+	ip[11] = byte(n >> 32)
+	ip[12] = byte(n >> 24)
+	ip[13] = byte(n >> 16)
+	ip[14] = byte(n >> 8)
+	ip[15] = byte(n)
 	return ip
 }
@@ -16,6 +16,8 @@ package external
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 
@@ -70,3 +72,42 @@ func (m *MockDocker) Load(ctx context.Context, path string) (string, error) {
 func (m *MockDocker) Tag(ctx context.Context, src, dst string) error {
 	return m.Called(src, dst).Error(0)
 }
+
+// ImageID mock.
+func (m *MockDocker) ImageID(ctx context.Context, image string) (string, error) {
+	arg := m.Called(image)
+	return arg.String(0), arg.Error(1)
+}
+
+// Start mock.
+func (m *MockDocker) Start(ctx context.Context, name string) (string, error) {
+	arg := m.Called(name)
+	return arg.String(0), arg.Error(1)
+}
+
+// Kill mock.
+func (m *MockDocker) Kill(ctx context.Context, name, signal string) (string, error) {
+	arg := m.Called(name, signal)
+	return arg.String(0), arg.Error(1)
+}
+
+// InspectContainer mock.
+func (m *MockDocker) InspectContainer(ctx context.Context, name, format string) (string, error) {
+	arg := m.Called(name, format)
+	return arg.String(0), arg.Error(1)
+}
+
+// Logs mock.
+func (m *MockDocker) Logs(ctx context.Context, w io.Writer, name string, since time.Time, limit int64) error {
+	arg := m.Called(name, since, limit)
+	if _, err := io.WriteString(w, arg.String(0)); err != nil {
+		return err
+	}
+	return arg.Error(1)
+}
+
+// StreamLogs mock.
+func (m *MockDocker) StreamLogs(ctx context.Context, w io.Writer, name string, tail int, follow bool) error {
+	arg := m.Called(w, name, tail, follow)
+	return arg.Error(0)
+}
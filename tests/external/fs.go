@@ -79,6 +79,12 @@ func (m *MockFS) Sha256sum(ctx context.Context, path string) (string, error) {
 	return arg.String(0), arg.Error(1)
 }
 
+// FreeSpace mock.
+func (m *MockFS) FreeSpace(ctx context.Context, dir string) (uint64, error) {
+	arg := m.Called(dir)
+	return arg.Get(0).(uint64), arg.Error(1)
+}
+
 // Tar mock.
 func (m *MockFS) Tar(srcPaths []string, basePath, dstPath string, needGzip bool) error {
 	return m.Called(srcPaths, basePath, dstPath, needGzip).Error(0)
@@ -16,6 +16,7 @@ package external
 
 import (
 	"context"
+	"io"
 
 	"github.com/stretchr/testify/mock"
 
@@ -48,6 +49,15 @@ func (m *MockRunner) Exec(ctx context.Context, cmd string, args ...string) (stri
 	return arg.String(0), nil
 }
 
+// Stream mock, the returned output is written into w.
+func (m *MockRunner) Stream(ctx context.Context, w io.Writer, cmd string, args ...string) error {
+	arg := m.Called(cmd, args)
+	if _, err := io.WriteString(w, arg.String(0)); err != nil {
+		return err
+	}
+	return arg.Error(1)
+}
+
 // Scp mock.
 func (m *MockRunner) Scp(ctx context.Context, local, remote string) error {
 	arg := m.Called(local, remote)